@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// upgradeWalletKeys re-seals version-1 envelope keys as version-2 records bound
+// to their wallet address and owner. Runs once per start; keys that changed
+// concurrently or failed are retried on the next start. Keys that do not
+// match their wallet address are never bound and are logged as errors.
+func upgradeWalletKeys(ctx context.Context, walletKeys *storage.WalletKeyRepository, walletService *crypto.WalletService) {
+	for _, collection := range storage.WalletCollections {
+		upgraded, failed := 0, 0
+		err := walletKeys.ForEachWallet(ctx, collection, 100, func(w *storage.WalletKey) error {
+			bound, changed, err := walletService.BindKey(crypto.WalletKey{
+				KeyBinding:   crypto.KeyBinding{Address: w.Wallet.Address, OwnerID: w.OwnerID()},
+				EncryptedKey: w.Wallet.EncryptedPrivateKey,
+			})
+			if errors.Is(err, crypto.ErrKeyAddressMismatch) {
+				// Left unbound for an operator to investigate: binding it would
+				// tie another wallet's key to this address for good
+				failed++
+				logger.Error("Wallet key does not match its address", err, map[string]interface{}{
+					"collection": collection,
+					"owner_id":   w.OwnerID(),
+					"address":    w.Wallet.Address,
+				})
+				return nil
+			}
+			if err != nil {
+				failed++
+				logger.Warn("Failed to bind wallet key", map[string]interface{}{
+					"collection": collection,
+					"owner_id":   w.OwnerID(),
+					"error":      err.Error(),
+				})
+				return nil
+			}
+			if !changed {
+				return nil
+			}
+
+			replaced, err := walletKeys.ReplaceWalletKey(ctx, collection, w.ID, w.Wallet.EncryptedPrivateKey, bound)
+			if err != nil {
+				return err
+			}
+			if replaced {
+				upgraded++
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("Wallet key upgrade stopped", err, map[string]interface{}{
+				"collection": collection,
+			})
+			return
+		}
+		if upgraded > 0 || failed > 0 {
+			logger.Info("Wallet keys upgraded", map[string]interface{}{
+				"collection": collection,
+				"upgraded":   upgraded,
+				"failed":     failed,
+			})
+		}
+	}
+}
@@ -13,13 +13,21 @@ import (
 	"github.com/loyalcoin/backend/internal/api"
 	"github.com/loyalcoin/backend/internal/auth"
 	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/coalitions"
 	"github.com/loyalcoin/backend/internal/config"
 	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/expiry"
 	"github.com/loyalcoin/backend/internal/indexer"
 	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/notify"
+	"github.com/loyalcoin/backend/internal/referrals"
+	"github.com/loyalcoin/backend/internal/refunds"
 	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/internal/tiers"
+	"github.com/loyalcoin/backend/internal/vouchers"
 	"github.com/loyalcoin/backend/pkg/logger"
 	middleware "github.com/loyalcoin/backend/pkg/middleware"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -65,29 +73,44 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize Vault client
-	vaultClient := crypto.NewVaultClient(
-		cfg.VaultAddr,
-		cfg.VaultToken,
-		cfg.VaultTransitKey,
-	)
-
-	// Check Vault connectivity
-	if err := vaultClient.Health(); err != nil {
-		logger.Warn("Vault health check failed - wallet creation may fail", map[string]interface{}{
-			"error": err.Error(),
+	// Connect the wallet key encryption backend (the Vault token is renewed in the background)
+	vaultClient, err := crypto.ConnectEncryptionBackend(context.Background(), cfg)
+	if err != nil {
+		logger.Error("Encryption backend unavailable", err, map[string]interface{}{
+			"backend": cfg.EncryptionBackend,
 		})
-	} else {
-		logger.Info("Successfully connected to Vault", nil)
+		os.Exit(1)
 	}
+	logger.Info("Connected to encryption backend", map[string]interface{}{
+		"backend": vaultClient.Backend(),
+	})
 
 	// Initialize Wallet Service (for generating Cardano wallets)
-	walletService := crypto.NewWalletService(vaultClient)
+	walletService, err := crypto.NewWalletService(vaultClient, cfg.WalletSigner)
+	if err != nil {
+		logger.Error("Failed to initialize wallet service", err, nil)
+		os.Exit(1)
+	}
 
 	utxoRepo := storage.NewUTXORepository(db)
 	txLogRepo := storage.NewTxLogRepository(db)
 	settlementRepo := storage.NewSettlementRepository(db)
 	allocationRepo := storage.NewAllocationRepository(db)
+	governanceRepo := storage.NewGovernanceRepository(db)
+
+	// Governance wallet (single key, or m-of-n native script over admin keys)
+	governance, err := cardano.NewGovernanceWallet(cfg)
+	if err != nil {
+		logger.Error("Invalid governance wallet configuration", err, nil)
+		os.Exit(1)
+	}
+	if governance.IsMultiSig() {
+		logger.Info("Governance wallet is a multi-sig script", map[string]interface{}{
+			"address":             governance.Address,
+			"required_signatures": governance.Script.Required,
+			"signers":             len(governance.Script.KeyHashes),
+		})
+	}
 
 	cardanoService := cardano.NewCardanoService(
 		cfg,
@@ -98,21 +121,171 @@ func main() {
 
 	// Initialize repositories
 	userRepo := storage.NewUserRepository(db)
+	apiKeyRepo := storage.NewAPIKeyRepository(db)
+	apiKeyService := auth.NewAPIKeyService(apiKeyRepo, userRepo)
+	roleRepo := storage.NewRoleRepository(db)
+	staffRepo := storage.NewStaffRepository(db)
+	externalTxRepo := storage.NewExternalTxRepository(db)
+	pendingRewardRepo := storage.NewPendingRewardRepository(db)
+	paymentRequestRepo := storage.NewPaymentRequestRepository(db)
+	transferLimitRepo := storage.NewTransferLimitRepository(db)
+	earnLimitRepo := storage.NewEarnLimitRepository(db)
+	idempotencyRepo := storage.NewIdempotencyRepository(db)
+	earnRuleRepo := storage.NewEarnRuleRepository(db)
+	campaignRepo := storage.NewCampaignRepository(db)
+	lotRepo := storage.NewLotRepository(db)
+	expiryPolicyRepo := storage.NewExpiryPolicyRepository(db)
+	referralRepo := storage.NewReferralRepository(db)
+	catalogRepo := storage.NewCatalogRepository(db)
+	orderRepo := storage.NewOrderRepository(db)
+	refundRepo := storage.NewRefundRepository(db)
+	voucherRepo := storage.NewVoucherRepository(db)
+	coalitionRepo := storage.NewCoalitionRepository(db)
+
+	// Expiry sweeps return unspent LCN once its expiry window has passed
+	expiryService, err := expiry.NewService(
+		&expiry.Config{
+			SweepInterval: time.Duration(cfg.LCNExpirySweepIntervalMinutes) * time.Minute,
+			BatchSize:     50,
+			DefaultMonths: cfg.LCNExpiryMonths,
+			WarningPeriod: time.Duration(cfg.LCNExpiryWarningDays) * 24 * time.Hour,
+			ReturnTo:      cfg.LCNExpiryReturnTo,
+		},
+		cardanoService,
+		userRepo,
+		txLogRepo,
+		lotRepo,
+		expiryPolicyRepo,
+		governance.Address,
+	)
+	if err != nil {
+		logger.Error("Invalid LCN expiry configuration", err, nil)
+		os.Exit(1)
+	}
+
+	// Customer tiers are re-evaluated nightly and as transactions confirm
+	tierLadder, err := tiers.ParseLadder(cfg.CustomerTiers)
+	if err != nil {
+		logger.Error("Invalid CUSTOMER_TIERS", err, nil)
+		os.Exit(1)
+	}
+	tierService, err := tiers.NewService(
+		&tiers.Config{
+			Ladder:         tierLadder,
+			EvaluationHour: cfg.TierEvaluationHour,
+			BatchSize:      100,
+		},
+		userRepo,
+		txLogRepo,
+	)
+	if err != nil {
+		logger.Error("Invalid customer tier configuration", err, nil)
+		os.Exit(1)
+	}
+
+	// Referral rewards are paid from the governance wallet once a referee qualifies
+	referralService := referrals.NewService(
+		&referrals.Config{
+			ReferrerRewardLCN: cfg.ReferralReferrerRewardLCN,
+			RefereeRewardLCN:  cfg.ReferralRefereeRewardLCN,
+			MinQualifyingLCN:  cfg.ReferralMinQualifyingLCN,
+			MaxPerReferrer:    int64(cfg.ReferralMaxPerReferrer),
+			MaxSignupsPerIP:   int64(cfg.ReferralMaxSignupsPerIP),
+			ReviewAll:         cfg.ReferralReviewAll,
+		},
+		cardanoService,
+		userRepo,
+		txLogRepo,
+		referralRepo,
+		governanceRepo,
+		governance,
+		time.Duration(cfg.GovernanceTxTTLHours)*time.Hour,
+	)
+
+	// Refunds send LCN back along an issuance or redemption of a merchant
+	refundService := refunds.NewService(cardanoService, userRepo, txLogRepo, refundRepo)
+
+	// Vouchers are NFTs minted under each merchant's own policy
+	voucherService := vouchers.NewService(cfg.CardanoNetwork, cardanoService, userRepo, voucherRepo)
+
+	// LCN redeemed at a coalition member clears between members and is
+	// netted into clearing settlements every period
+	coalitionService := coalitions.NewService(
+		&coalitions.Config{
+			CheckInterval: time.Duration(cfg.CoalitionNettingCheckMinutes) * time.Minute,
+			BatchSize:     20,
+			ExchangeRate:  cfg.ExchangeRateLCNETB,
+		},
+		cardanoService,
+		userRepo,
+		txLogRepo,
+		lotRepo,
+		coalitionRepo,
+		settlementRepo,
+	)
+
+	// Initialize RBAC (seeds built-in roles on first start)
+	rbacService := auth.NewRBACService(roleRepo, time.Duration(cfg.RBACCacheTTLSeconds)*time.Second)
+	seedCtx, seedCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	err = rbacService.EnsureBuiltInRoles(seedCtx)
+	seedCancel()
+	if err != nil {
+		logger.Error("Failed to seed built-in roles", err, nil)
+		os.Exit(1)
+	}
 
 	// Initialize handlers
-	authHandler := api.NewAuthHandler(userRepo, jwtService, cfg)
-	walletHandler := api.NewWalletHandler(cardanoService, userRepo, txLogRepo)
+	authHandler := api.NewAuthHandler(userRepo, staffRepo, rbacService, jwtService, cardanoService, referralService, cfg)
+	walletHandler := api.NewWalletHandler(cardanoService, userRepo, txLogRepo, externalTxRepo, pendingRewardRepo, paymentRequestRepo, cfg.CardanoNetwork, cfg.RedeemToMerchantsOnly, cfg.PendingRewardTTLDays)
 	settlementHandler := api.NewSettlementHandler(settlementRepo, userRepo, cfg.ExchangeRateLCNETB)
 	allocationHandler := api.NewAllocationHandler(allocationRepo, userRepo, cfg.ExchangeRateLCNETB)
+	apiKeyHandler := api.NewAPIKeyHandler(apiKeyRepo)
+	roleHandler := api.NewRoleHandler(roleRepo, userRepo, rbacService)
+	staffHandler := api.NewStaffHandler(staffRepo, userRepo, rbacService, cfg.BcryptCost)
+	pendingRewardHandler := api.NewPendingRewardHandler(pendingRewardRepo)
+	paymentRequestHandler := api.NewPaymentRequestHandler(paymentRequestRepo, userRepo)
+	earnHandler := api.NewEarnHandler(cardanoService, userRepo, txLogRepo, earnRuleRepo, campaignRepo, earnLimitRepo, tierService, cfg.CardanoNetwork, cfg.ExchangeRateLCNETB)
+	campaignHandler := api.NewCampaignHandler(campaignRepo, userRepo, txLogRepo, cardanoService)
+	expiryHandler := api.NewExpiryHandler(expiryService, expiryPolicyRepo, userRepo)
+	emailSender, smsSender, err := newVerificationSenders(cfg)
+	if err != nil {
+		logger.Error("Failed to configure verification codes", err, nil)
+		os.Exit(1)
+	}
+	verificationHandler := api.NewVerificationHandler(userRepo, pendingRewardRepo, cardanoService, emailSender, smsSender)
+	customerHandler := api.NewCustomerHandler(userRepo, cardanoService, jwtService, cfg.CardanoNetwork, governance.Address)
+	tierHandler := api.NewTierHandler(tierService, userRepo)
+	referralHandler := api.NewReferralHandler(referralService, referralRepo, userRepo)
+	transferHandler := api.NewTransferHandler(cardanoService, userRepo, txLogRepo, transferLimitRepo, cfg.TransfersEnabled, cfg.TransferDailyLimitLCN, cfg.TransferDailyMaxTransfers)
+	catalogHandler := api.NewCatalogHandler(catalogRepo, userRepo)
+	orderHandler := api.NewOrderHandler(cardanoService, userRepo, txLogRepo, catalogRepo, orderRepo)
+	refundHandler := api.NewRefundHandler(refundService, refundRepo)
+	voucherHandler := api.NewVoucherHandler(voucherService, voucherRepo, userRepo, cfg.CardanoNetwork)
+	coalitionHandler := api.NewCoalitionHandler(coalitionService, coalitionRepo)
 	adminHandler := api.NewAdminHandler(
 		settlementRepo,
 		allocationRepo,
 		userRepo,
 		txLogRepo,
+		governanceRepo,
 		cardanoService,
-		cfg.GovernanceWalletAddress,
+		governance,
+		time.Duration(cfg.GovernanceTxTTLHours)*time.Hour,
+		api.DualControlPolicy{
+			AllocationThresholdLCN: cfg.AllocationApprovalThresholdLCN,
+			SettlementThresholdLCN: cfg.SettlementApprovalThresholdLCN,
+			RequiredApprovals:      cfg.DualControlApprovals,
+		},
+		referralService,
 	)
 
+	// Initialize rate limiter backend
+	rateLimitStore, err := newRateLimitStore(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize rate limiter", err, nil)
+		os.Exit(1)
+	}
+
 	// Set Gin mode
 	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -120,9 +293,17 @@ func main() {
 
 	// Create router
 	router := gin.New()
+	// Client IPs key rate limits and referral fraud checks, so forwarded
+	// headers are only believed from configured proxies (none by default)
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("Invalid TRUSTED_PROXIES", err, nil)
+		os.Exit(1)
+	}
+	router.TrustedPlatform = cfg.TrustedPlatform
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(middleware.RequestLoggerMiddleware())
+	router.Use(middleware.RateLimitByIP(rateLimitStore, cfg.RateLimitPerIP, time.Minute))
 
 	// Inject wallet service into context
 	router.Use(func(c *gin.Context) {
@@ -141,38 +322,176 @@ func main() {
 	// API v1 routes
 	authGroup := router.Group("/api/v1/auth")
 	authGroup.POST("/signup", authHandler.Signup)
-	authGroup.POST("/login", authHandler.Login)
-
-	authMiddleware := middleware.AuthMiddleware(jwtService)
+	authGroup.POST("/login", middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "login",
+		Limit:  cfg.RateLimitLoginPerIP,
+		Period: time.Minute,
+		Key:    middleware.IPRateLimitKey,
+	}), authHandler.Login)
+
+	// Session-only routes accept JWTs; POS routes also accept merchant API keys
+	authMiddleware := middleware.AuthMiddleware(jwtService, nil, userRepo, staffRepo)
+	posAuthMiddleware := middleware.AuthMiddleware(jwtService, apiKeyService, userRepo, staffRepo)
+	userRateLimit := middleware.RateLimitByUser(rateLimitStore, cfg.RateLimitPerUser, time.Minute)
+	// Value-moving endpoints replay the first response to a repeated Idempotency-Key
+	idempotent := middleware.IdempotencyMiddleware(idempotencyRepo, time.Duration(cfg.IdempotencyKeyTTLHours)*time.Hour)
+
+	requirePermission := func(permission models.Permission) gin.HandlerFunc {
+		return middleware.RequirePermission(rbacService, permission)
+	}
 
 	walletGroup := router.Group("/api/v1/wallet")
-	walletGroup.Use(authMiddleware)
+	walletGroup.Use(posAuthMiddleware)
+	walletGroup.Use(userRateLimit)
+	walletGroup.Use(requirePermission(models.PermWalletRead))
 	walletGroup.GET("/balance", walletHandler.GetBalance)
 	walletGroup.GET("/transactions", walletHandler.GetTransactions)
 
 	lcnGroup := router.Group("/api/v1/lcn")
-	lcnGroup.Use(authMiddleware)
-	lcnGroup.POST("/issue", walletHandler.IssueLCN)
-	lcnGroup.POST("/redeem", walletHandler.RedeemLCN)
-
-	// Merchant settlement routes (MERCHANT role required)
+	lcnGroup.Use(posAuthMiddleware)
+	lcnGroup.Use(userRateLimit)
+	lcnGroup.POST("/issue", requirePermission(models.PermLCNIssue), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "issue",
+		Limit:  cfg.RateLimitIssuePerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, walletHandler.IssueLCN)
+	lcnGroup.POST("/earn", requirePermission(models.PermLCNIssue), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "earn",
+		Limit:  cfg.RateLimitIssuePerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, earnHandler.Earn)
+	lcnGroup.POST("/redeem", requirePermission(models.PermLCNRedeem), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "redeem",
+		Limit:  cfg.RateLimitRedeemPerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, walletHandler.RedeemLCN)
+	lcnGroup.POST("/redeem/:id/submit", requirePermission(models.PermLCNRedeem), walletHandler.SubmitExternalRedemption)
+	lcnGroup.GET("/pay/:request_id", requirePermission(models.PermLCNRedeem), walletHandler.GetPayableRequest)
+	lcnGroup.POST("/pay/:request_id", requirePermission(models.PermLCNRedeem), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "pay",
+		Limit:  cfg.RateLimitRedeemPerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, walletHandler.PayRequest)
+	lcnGroup.POST("/transfer", requirePermission(models.PermLCNTransfer), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "transfer",
+		Limit:  cfg.RateLimitRedeemPerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, transferHandler.Transfer)
+	lcnGroup.GET("/transfer/limits", requirePermission(models.PermLCNTransfer), transferHandler.GetLimits)
+	lcnGroup.POST("/payment-requests", requirePermission(models.PermPaymentsRequest), paymentRequestHandler.CreatePaymentRequest)
+	lcnGroup.GET("/payment-requests", requirePermission(models.PermPaymentsRequest), paymentRequestHandler.ListPaymentRequests)
+	lcnGroup.GET("/payment-requests/:id", requirePermission(models.PermPaymentsRequest), paymentRequestHandler.GetPaymentRequest)
+	lcnGroup.DELETE("/payment-requests/:id", requirePermission(models.PermPaymentsRequest), paymentRequestHandler.CancelPaymentRequest)
+
+	// Customer routes; recovery phrase export re-checks the password, so
+	// attempts are limited per user
+	customerGroup := router.Group("/api/v1/customer")
+	customerGroup.Use(authMiddleware)
+	customerGroup.Use(userRateLimit)
+	customerGroup.POST("/wallet/recovery-phrase", requirePermission(models.PermWalletExport), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "recovery-phrase",
+		Limit:  5,
+		Period: time.Hour,
+		Key:    middleware.UserRateLimitKey,
+	}), customerHandler.ExportRecoveryPhrase)
+	customerGroup.POST("/verify/send", requirePermission(models.PermWalletRead), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "verify-send",
+		Limit:  5,
+		Period: time.Hour,
+		Key:    middleware.UserRateLimitKey,
+	}), verificationHandler.SendCode)
+	customerGroup.POST("/verify", requirePermission(models.PermWalletRead), verificationHandler.Verify)
+	customerGroup.POST("/wallet/external/challenge", requirePermission(models.PermWalletManage), customerHandler.CreateWalletChallenge)
+	customerGroup.PUT("/wallet/external", requirePermission(models.PermWalletManage), customerHandler.RegisterExternalWallet)
+	customerGroup.GET("/expiring", requirePermission(models.PermWalletRead), expiryHandler.GetExpiringLCN)
+	customerGroup.GET("/tier", requirePermission(models.PermWalletRead), tierHandler.GetTier)
+	customerGroup.GET("/referrals", requirePermission(models.PermWalletRead), referralHandler.GetCustomerReferrals)
+	customerGroup.GET("/catalog", requirePermission(models.PermWalletRead), catalogHandler.ListAvailableItems)
+	customerGroup.POST("/orders", requirePermission(models.PermLCNRedeem), middleware.RateLimitMiddleware(rateLimitStore, middleware.RateLimitRule{
+		Name:   "order",
+		Limit:  cfg.RateLimitRedeemPerUser,
+		Period: time.Minute,
+		Key:    middleware.UserRateLimitKey,
+	}), idempotent, orderHandler.PlaceOrder)
+	customerGroup.GET("/orders", requirePermission(models.PermWalletRead), orderHandler.ListCustomerOrders)
+	customerGroup.POST("/orders/:id/cancel", requirePermission(models.PermLCNRedeem), idempotent, orderHandler.CancelCustomerOrder)
+	customerGroup.GET("/refunds", requirePermission(models.PermWalletRead), refundHandler.ListCustomerRefunds)
+	customerGroup.POST("/refunds/:id/consent", requirePermission(models.PermLCNRedeem), idempotent, refundHandler.ConsentRefund)
+	customerGroup.GET("/vouchers", requirePermission(models.PermWalletRead), voucherHandler.ListCustomerVouchers)
+
+	// Merchant routes (merchant owners and staff, per permission)
 	merchantGroup := router.Group("/api/v1/merchant")
 	merchantGroup.Use(authMiddleware)
-	merchantGroup.Use(middleware.RequireRole(models.RoleMerchant))
-	merchantGroup.POST("/settlement/request", settlementHandler.RequestSettlement)
-	merchantGroup.GET("/settlement/history", settlementHandler.GetSettlementHistory)
-	merchantGroup.POST("/allocation/purchase", allocationHandler.RequestAllocation)
-	merchantGroup.GET("/allocation/history", allocationHandler.GetAllocationHistory)
-
-	// Admin routes (ADMIN role required)
+	merchantGroup.Use(userRateLimit)
+	merchantGroup.POST("/settlement/request", requirePermission(models.PermSettlementRequest), idempotent, settlementHandler.RequestSettlement)
+	merchantGroup.GET("/settlement/history", requirePermission(models.PermSettlementRequest), settlementHandler.GetSettlementHistory)
+	merchantGroup.POST("/allocation/purchase", requirePermission(models.PermAllocationRequest), allocationHandler.RequestAllocation)
+	merchantGroup.GET("/allocation/history", requirePermission(models.PermAllocationRequest), allocationHandler.GetAllocationHistory)
+	merchantGroup.POST("/api-keys", requirePermission(models.PermAPIKeysManage), apiKeyHandler.CreateAPIKey)
+	merchantGroup.GET("/api-keys", requirePermission(models.PermAPIKeysManage), apiKeyHandler.ListAPIKeys)
+	merchantGroup.DELETE("/api-keys/:id", requirePermission(models.PermAPIKeysManage), apiKeyHandler.RevokeAPIKey)
+	merchantGroup.POST("/staff", requirePermission(models.PermStaffManage), staffHandler.CreateStaff)
+	merchantGroup.GET("/staff", requirePermission(models.PermStaffManage), staffHandler.ListStaff)
+	merchantGroup.PUT("/staff/:id", requirePermission(models.PermStaffManage), staffHandler.UpdateStaff)
+	merchantGroup.GET("/pending-rewards", requirePermission(models.PermLCNIssue), pendingRewardHandler.ListPendingRewards)
+	merchantGroup.DELETE("/pending-rewards/:id", requirePermission(models.PermLCNIssue), pendingRewardHandler.CancelPendingReward)
+	merchantGroup.GET("/earn-rule", requirePermission(models.PermRewardsManage), earnHandler.GetEarnRule)
+	merchantGroup.PUT("/earn-rule", requirePermission(models.PermRewardsManage), earnHandler.SaveEarnRule)
+	merchantGroup.GET("/earn-rule/versions", requirePermission(models.PermRewardsManage), earnHandler.ListEarnRuleVersions)
+	merchantGroup.POST("/campaigns", requirePermission(models.PermRewardsManage), campaignHandler.CreateCampaign)
+	merchantGroup.GET("/campaigns", requirePermission(models.PermRewardsManage), campaignHandler.ListCampaigns)
+	merchantGroup.GET("/campaigns/:id", requirePermission(models.PermRewardsManage), campaignHandler.GetCampaign)
+	merchantGroup.PUT("/campaigns/:id/status", requirePermission(models.PermRewardsManage), campaignHandler.UpdateCampaignStatus)
+	merchantGroup.GET("/expiry-policy", requirePermission(models.PermRewardsManage), expiryHandler.GetExpiryPolicy)
+	merchantGroup.PUT("/expiry-policy", requirePermission(models.PermRewardsManage), expiryHandler.SaveExpiryPolicy)
+	merchantGroup.DELETE("/expiry-policy", requirePermission(models.PermRewardsManage), expiryHandler.ResetExpiryPolicy)
+	merchantGroup.GET("/referrals", requirePermission(models.PermRewardsManage), referralHandler.GetMerchantReferrals)
+	merchantGroup.POST("/catalog", requirePermission(models.PermRewardsManage), catalogHandler.CreateItem)
+	merchantGroup.GET("/catalog", requirePermission(models.PermRewardsManage), catalogHandler.ListItems)
+	merchantGroup.PUT("/catalog/:id", requirePermission(models.PermRewardsManage), catalogHandler.UpdateItem)
+	merchantGroup.GET("/orders", requirePermission(models.PermOrdersFulfill), orderHandler.ListMerchantOrders)
+	merchantGroup.POST("/orders/validate", requirePermission(models.PermOrdersFulfill), orderHandler.ValidateOrder)
+	merchantGroup.POST("/orders/:id/cancel", requirePermission(models.PermRewardsManage), orderHandler.CancelMerchantOrder)
+	merchantGroup.GET("/refunds/refundable", requirePermission(models.PermLCNRefund), refundHandler.GetRefundable)
+	merchantGroup.POST("/refunds", requirePermission(models.PermLCNRefund), idempotent, refundHandler.RequestRefund)
+	merchantGroup.GET("/refunds", requirePermission(models.PermLCNRefund), refundHandler.ListMerchantRefunds)
+	merchantGroup.POST("/refunds/:id/cancel", requirePermission(models.PermLCNRefund), refundHandler.CancelRefund)
+	merchantGroup.POST("/vouchers", requirePermission(models.PermVouchersIssue), idempotent, voucherHandler.IssueVoucher)
+	merchantGroup.GET("/vouchers", requirePermission(models.PermVouchersIssue), voucherHandler.ListMerchantVouchers)
+	merchantGroup.POST("/vouchers/redeem", requirePermission(models.PermVouchersRedeem), idempotent, voucherHandler.RedeemVoucher)
+	merchantGroup.GET("/coalition", requirePermission(models.PermSettlementRequest), coalitionHandler.GetMerchantCoalition)
+	merchantGroup.GET("/coalition/entries", requirePermission(models.PermSettlementRequest), coalitionHandler.ListMerchantEntries)
+	merchantGroup.GET("/coalition/nettings", requirePermission(models.PermSettlementRequest), coalitionHandler.ListMerchantNettings)
+
+	// Admin routes (platform roles, per permission)
 	adminGroup := router.Group("/api/v1/admin")
 	adminGroup.Use(authMiddleware)
-	adminGroup.Use(middleware.RequireRole(models.RoleAdmin))
-	adminGroup.POST("/allocation/approve", adminHandler.ApproveAllocation)
-	adminGroup.POST("/settlement/approve", adminHandler.ApproveSettlement)
-	adminGroup.GET("/allocation/pending", adminHandler.GetPendingAllocations)
-	adminGroup.GET("/settlement/pending", adminHandler.GetPendingSettlements)
-	adminGroup.GET("/reserve/status", adminHandler.GetReserveStatus)
+	adminGroup.Use(userRateLimit)
+	adminGroup.POST("/allocation/approve", requirePermission(models.PermAllocationApprove), idempotent, adminHandler.ApproveAllocation)
+	adminGroup.POST("/settlement/approve", requirePermission(models.PermSettlementApprove), idempotent, adminHandler.ApproveSettlement)
+	adminGroup.GET("/allocation/pending", requirePermission(models.PermAllocationApprove), adminHandler.GetPendingAllocations)
+	adminGroup.GET("/settlement/pending", requirePermission(models.PermSettlementApprove), adminHandler.GetPendingSettlements)
+	adminGroup.GET("/reserve/status", requirePermission(models.PermReserveRead), adminHandler.GetReserveStatus)
+	adminGroup.GET("/governance/transactions", requirePermission(models.PermAllocationApprove), adminHandler.ListGovernanceTransactions)
+	adminGroup.POST("/governance/transactions/:id/sign", requirePermission(models.PermAllocationApprove), adminHandler.SignGovernanceTransaction)
+	adminGroup.GET("/roles", requirePermission(models.PermUsersManage), roleHandler.ListRoles)
+	adminGroup.PUT("/roles/:name", requirePermission(models.PermUsersManage), roleHandler.SaveRole)
+	adminGroup.PUT("/users/:id/role", requirePermission(models.PermUsersManage), roleHandler.AssignRole)
+	adminGroup.GET("/referrals", requirePermission(models.PermReferralsReview), referralHandler.ListReferrals)
+	adminGroup.POST("/referrals/:id/review", requirePermission(models.PermReferralsReview), referralHandler.ReviewReferral)
+	adminGroup.GET("/refunds", requirePermission(models.PermRefundsOverride), refundHandler.ListRefunds)
+	adminGroup.POST("/refunds/:id/override", requirePermission(models.PermRefundsOverride), idempotent, refundHandler.OverrideRefund)
+	adminGroup.POST("/coalitions", requirePermission(models.PermCoalitionsManage), coalitionHandler.CreateCoalition)
+	adminGroup.GET("/coalitions", requirePermission(models.PermCoalitionsManage), coalitionHandler.ListCoalitions)
+	adminGroup.PUT("/coalitions/:id", requirePermission(models.PermCoalitionsManage), coalitionHandler.UpdateCoalition)
+	adminGroup.GET("/coalitions/:id/positions", requirePermission(models.PermCoalitionsManage), coalitionHandler.GetCoalitionPositions)
+	adminGroup.GET("/coalitions/:id/nettings", requirePermission(models.PermCoalitionsManage), coalitionHandler.ListCoalitionNettings)
+	adminGroup.POST("/coalitions/:id/net", requirePermission(models.PermCoalitionsManage), coalitionHandler.NetCoalition)
 
 	// Initialize and start indexer service
 	indexerConfig := indexer.DefaultConfig()
@@ -183,9 +502,26 @@ func main() {
 		blockfrostClient,
 		txLogRepo,
 		userRepo,
+		paymentRequestRepo,
+		transferLimitRepo,
+		earnLimitRepo,
+		expiryService,
+		tierService,
+		referralService,
+		voucherService,
+		coalitionService,
 	)
 	indexerService.Start()
 	defer indexerService.Stop()
+	expiryService.Start()
+	defer expiryService.Stop()
+	tierService.Start()
+	defer tierService.Stop()
+	coalitionService.Start()
+	defer coalitionService.Stop()
+
+	// Bind wallet keys still in the version-1 format to their wallets
+	go upgradeWalletKeys(context.Background(), storage.NewWalletKeyRepository(db), walletService)
 
 	// Setup graceful shutdown
 	srv := &http.Server{
@@ -221,3 +557,50 @@ func main() {
 
 	logger.Info("Server exited", nil)
 }
+
+// newRateLimitStore selects the rate limiter backend (in-memory for a single
+// instance, Redis when several replicas must share the buckets)
+func newRateLimitStore(cfg *config.Config) (middleware.RateLimitStore, error) {
+	switch cfg.RateLimitBackend {
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		client := redis.NewClient(opts)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Using Redis rate limiter", nil)
+		return middleware.NewRedisRateLimitStore(client), nil
+	case "memory", "":
+		logger.Info("Using in-memory rate limiter", nil)
+		return middleware.NewMemoryRateLimitStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q (expected memory or redis)", cfg.RateLimitBackend)
+	}
+}
+
+// newVerificationSenders delivers email and SMS verification codes. A channel
+// without settings logs its codes in development and is unavailable otherwise.
+func newVerificationSenders(cfg *config.Config) (email, sms notify.Sender, err error) {
+	var fallback notify.Sender = notify.Disabled{}
+	if cfg.Env != "production" {
+		fallback = notify.LogSender{}
+	}
+
+	email, sms = fallback, fallback
+	if cfg.SMTPAddr != "" {
+		if cfg.SMTPFrom == "" {
+			return nil, nil, fmt.Errorf("SMTP_FROM is required with SMTP_ADDR")
+		}
+		email = notify.NewSMTPSender(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	if cfg.SMSWebhookURL != "" {
+		sms = notify.NewWebhookSender(cfg.SMSWebhookURL, cfg.SMSWebhookToken)
+	}
+	return email, sms, nil
+}
@@ -46,11 +46,17 @@ func main() {
 	// Check if user exists
 	existing, _ := userRepo.GetMerchantByEmail(context.Background(), *email)
 
-	// Initialize Vault Client
-	vaultClient := crypto.NewVaultClient(cfg.VaultAddr, cfg.VaultToken, cfg.VaultTransitKey)
+	// Connect the encryption backend
+	vaultClient, err := crypto.ConnectEncryptionBackend(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Encryption backend unavailable: %v", err)
+	}
 
 	// Initialize Wallet Service
-	walletService := crypto.NewWalletService(vaultClient)
+	walletService, err := crypto.NewWalletService(vaultClient, cfg.WalletSigner)
+	if err != nil {
+		log.Fatalf("Failed to initialize wallet service: %v", err)
+	}
 
 	// Generate a real wallet for the admin
 	walletResult, err := walletService.CreateWallet("testnet") // Use testnet for dev
@@ -80,10 +86,24 @@ func main() {
 			Address:             walletResult.Address,
 			EncryptedPrivateKey: walletResult.EncryptedPrivKey,
 			PubKeyHex:           walletResult.PubKeyHex,
+			Signer:              walletResult.Signer,
 			CreatedAt:           time.Now().UTC(),
 		},
 	}
 
+	// Bind the wallet key to the admin account
+	ownerID := admin.ID
+	if existing != nil {
+		ownerID = existing.ID
+	}
+	admin.Wallet.EncryptedPrivateKey, _, err = walletService.BindKey(crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: admin.Wallet.Address, OwnerID: ownerID},
+		EncryptedKey: admin.Wallet.EncryptedPrivateKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to bind admin wallet key: %v", err)
+	}
+
 	collection := db.GetCollection("merchants")
 
 	// Check if user exists first to avoid duplicate key error if we re-run
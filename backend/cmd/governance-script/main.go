@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Prints the governance multi-sig configuration for a set of admin accounts
+func main() {
+	admins := flag.String("admins", "", "Comma-separated admin emails (script key order)")
+	required := flag.Int("required", 0, "Signatures required to spend (m of n)")
+	flag.Parse()
+
+	if *admins == "" || *required < 1 {
+		fmt.Println("Usage: go run cmd/governance-script/main.go -admins <email1,email2,...> -required <m>")
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	logger.Init(cfg.LogLevel, cfg.LogFormat)
+
+	db, err := storage.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := storage.NewUserRepository(db)
+
+	var keyHashes []string
+	for _, email := range strings.Split(*admins, ",") {
+		email = strings.TrimSpace(email)
+		admin, err := userRepo.GetMerchantByEmail(context.Background(), email)
+		if err != nil {
+			log.Fatalf("Admin %s not found: %v", email, err)
+		}
+		if admin.Role != models.RoleAdmin {
+			log.Fatalf("%s is not an admin account", email)
+		}
+
+		publicKey, err := hex.DecodeString(admin.Wallet.PubKeyHex)
+		if err != nil || len(publicKey) != 32 {
+			log.Fatalf("Admin %s has no valid wallet public key (re-run create-admin)", email)
+		}
+		keyHash, err := crypto.KeyHash(publicKey)
+		if err != nil {
+			log.Fatalf("Failed to hash key for %s: %v", email, err)
+		}
+		keyHashes = append(keyHashes, hex.EncodeToString(keyHash))
+		fmt.Printf("   %s  %s\n", hex.EncodeToString(keyHash), email)
+	}
+
+	script, err := crypto.NewMultiSigScript(*required, keyHashes)
+	if err != nil {
+		log.Fatalf("Invalid script: %v", err)
+	}
+
+	networkTag := byte(0x00)
+	if cfg.CardanoNetwork == "mainnet" {
+		networkTag = 0x01
+	}
+	address, err := script.Address(networkTag)
+	if err != nil {
+		log.Fatalf("Failed to derive script address: %v", err)
+	}
+
+	fmt.Printf("\n✅ %d-of-%d governance script\n", script.Required, len(keyHashes))
+	fmt.Printf("   Script hash: %s\n", hex.EncodeToString(script.Hash()))
+	fmt.Printf("   Script CBOR: %s\n\n", hex.EncodeToString(script.CBOR()))
+	fmt.Println("Add to .env and fund the address:")
+	fmt.Printf("GOVERNANCE_WALLET_ADDRESS=%s\n", address)
+	fmt.Printf("GOVERNANCE_SCRIPT_KEY_HASHES=%s\n", strings.Join(keyHashes, ","))
+	fmt.Printf("GOVERNANCE_SCRIPT_REQUIRED_SIGNATURES=%d\n", script.Required)
+}
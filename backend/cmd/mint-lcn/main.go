@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
@@ -17,13 +18,16 @@ func main() {
 	cfg := config.Load()
 	logger.Init("info", "json")
 
-	// Initialize Vault (needed for wallet creation)
-	vaultClient := crypto.NewVaultClient(cfg.VaultAddr, cfg.VaultToken, cfg.VaultTransitKey)
-	if err := vaultClient.Health(); err != nil {
-		log.Fatalf("Vault health check failed: %v", err)
+	// Connect the encryption backend (needed for wallet creation)
+	vaultClient, err := crypto.ConnectEncryptionBackend(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Encryption backend unavailable: %v", err)
 	}
 
-	walletService := crypto.NewWalletService(vaultClient)
+	walletService, err := crypto.NewWalletService(vaultClient, cfg.WalletSigner)
+	if err != nil {
+		log.Fatalf("Failed to initialize wallet service: %v", err)
+	}
 	blockfrost := cardano.NewBlockfrostClient(cfg.BlockfrostProjectID, cfg.BlockfrostAPIURL)
 
 	fmt.Println("🚀 LoyalCoin Minting Tool")
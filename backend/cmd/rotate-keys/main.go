@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Re-wraps every wallet DEK under a new master key:
+//   - fallback → fallback: rotate ENCRYPTION_KEY (-old-key is the current key)
+//   - fallback → vault:    move wallets off ENCRYPTION_KEY onto Vault transit
+//   - vault → vault:       transit/rewrap to the latest transit key version
+//     (-rotate-vault-key creates that version first)
+//
+// Wallets already under the target key are skipped, so an interrupted run is
+// resumed by running the same command again.
+func main() {
+	target := flag.String("to", crypto.WrapTargetVault, "Target wrapping key: vault or fallback")
+	oldKeyHex := flag.String("old-key", os.Getenv("OLD_ENCRYPTION_KEY"), "Current fallback key (hex), for wallets with fb: DEKs")
+	newKeyHex := flag.String("new-key", os.Getenv("ENCRYPTION_KEY"), "New fallback key (hex), when -to fallback")
+	rotateVaultKey := flag.Bool("rotate-vault-key", false, "Rotate the Vault transit key before re-wrapping")
+	dryRun := flag.Bool("dry-run", false, "Check every wallet without writing changes")
+	batchSize := flag.Int("batch", 100, "MongoDB cursor batch size")
+	progressEvery := flag.Int("progress", 500, "Print progress every N wallets")
+	flag.Parse()
+
+	cfg := config.Load()
+	logger.Init(cfg.LogLevel, cfg.LogFormat)
+
+	oldKey, err := decodeKey(*oldKeyHex)
+	if err != nil {
+		log.Fatalf("Invalid -old-key: %v", err)
+	}
+	newKey, err := decodeKey(*newKeyHex)
+	if err != nil {
+		log.Fatalf("Invalid -new-key: %v", err)
+	}
+
+	var vaultClient *crypto.VaultClient
+	if *target == crypto.WrapTargetVault {
+		// Re-wrapping onto Vault needs Vault whatever ENCRYPTION_BACKEND says
+		vaultCfg := *cfg
+		vaultCfg.EncryptionBackend = crypto.EncryptionBackendVault
+		vaultClient, err = crypto.ConnectEncryptionBackend(context.Background(), &vaultCfg)
+		if err != nil {
+			log.Fatalf("Vault unavailable: %v", err)
+		}
+		if *rotateVaultKey && !*dryRun {
+			if err := vaultClient.RotateTransitKey(); err != nil {
+				log.Fatalf("Failed to rotate Vault transit key: %v", err)
+			}
+			fmt.Printf("🔄 Rotated Vault transit key %s\n", cfg.VaultTransitKey)
+		}
+	}
+
+	rotator, err := crypto.NewDEKRotator(vaultClient, *target, oldKey, newKey)
+	if err != nil {
+		log.Fatalf("Failed to prepare rotation: %v", err)
+	}
+
+	db, err := storage.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer db.Close()
+
+	walletKeys := storage.NewWalletKeyRepository(db)
+
+	// Stop between wallets on Ctrl-C; rotated wallets are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mode := "LIVE"
+	if *dryRun {
+		mode = "DRY RUN"
+	}
+	fmt.Printf("🔐 Re-wrapping wallet DEKs → %s (%s)\n", *target, mode)
+
+	failed := 0
+	for _, collection := range storage.WalletCollections {
+		stats, err := rotateCollection(ctx, walletKeys, rotator, collection, *dryRun, int32(*batchSize), *progressEvery)
+		failed += stats.failed
+		if err != nil {
+			fmt.Printf("⚠️  Stopped in %s: %v\n", collection, err)
+			fmt.Println("Run the same command again to resume; wallets already re-wrapped are skipped.")
+			os.Exit(1)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("❌ %d wallet(s) could not be re-wrapped (see log above)\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✅ Done")
+}
+
+type rotationStats struct {
+	total, processed, rotated, current, skipped, failed int
+}
+
+func (s rotationStats) String() string {
+	return fmt.Sprintf("%d/%d processed (rotated %d, current %d, skipped %d, failed %d)",
+		s.processed, s.total, s.rotated, s.current, s.skipped, s.failed)
+}
+
+func rotateCollection(
+	ctx context.Context,
+	walletKeys *storage.WalletKeyRepository,
+	rotator *crypto.DEKRotator,
+	collection string,
+	dryRun bool,
+	batchSize int32,
+	progressEvery int,
+) (rotationStats, error) {
+	var stats rotationStats
+
+	total, err := walletKeys.CountWallets(ctx, collection)
+	if err != nil {
+		return stats, err
+	}
+	stats.total = int(total)
+	fmt.Printf("\n%s: %d wallet(s)\n", collection, total)
+
+	started := time.Now()
+	err = walletKeys.ForEachWallet(ctx, collection, batchSize, func(w *storage.WalletKey) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stats.processed++
+
+		updated, result, err := rotator.Rewrap(w.Wallet.EncryptedPrivateKey, dryRun)
+		switch {
+		case err != nil:
+			stats.failed++
+			fmt.Printf("   ✗ %v (%s): %v\n", w.ID, w.Wallet.Address, err)
+		case result == crypto.RewrapCurrent:
+			stats.current++
+		case result == crypto.RewrapSkipped:
+			stats.skipped++
+		case dryRun:
+			stats.rotated++
+		default:
+			replaced, err := walletKeys.ReplaceWalletKey(ctx, collection, w.ID, w.Wallet.EncryptedPrivateKey, updated)
+			if err != nil {
+				return err
+			}
+			if replaced {
+				stats.rotated++
+			} else {
+				stats.failed++
+				fmt.Printf("   ✗ %v (%s): wallet changed during rotation, re-run to retry\n", w.ID, w.Wallet.Address)
+			}
+		}
+
+		if progressEvery > 0 && stats.processed%progressEvery == 0 {
+			fmt.Printf("   … %s [%s]\n", stats, time.Since(started).Round(time.Second))
+		}
+		return nil
+	})
+
+	fmt.Printf("   %s\n", stats)
+	return stats, err
+}
+
+func decodeKey(keyHex string) ([]byte, error) {
+	if keyHex == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("expected 64 hex characters (32 bytes)")
+	}
+	return key, nil
+}
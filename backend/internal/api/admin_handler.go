@@ -1,25 +1,32 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/expiry"
 	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/referrals"
 	"github.com/loyalcoin/backend/internal/storage"
 	"github.com/loyalcoin/backend/pkg/logger"
 )
 
 // Admin-related requests
 type AdminHandler struct {
-	settlementRepo *storage.SettlementRepository
-	allocationRepo *storage.AllocationRepository
-	userRepo       *storage.UserRepository
-	txLogRepo      *storage.TxLogRepository
-	cardanoService *cardano.CardanoService
-	governanceAddr string
+	settlementRepo  *storage.SettlementRepository
+	allocationRepo  *storage.AllocationRepository
+	userRepo        *storage.UserRepository
+	txLogRepo       *storage.TxLogRepository
+	governanceRepo  *storage.GovernanceRepository
+	cardanoService  *cardano.CardanoService
+	governance      *cardano.GovernanceWallet
+	governanceTxTTL time.Duration
+	dualControl     DualControlPolicy
+	referralService *referrals.Service // completes referral payouts signed through the governance workflow
 }
 
 func NewAdminHandler(
@@ -27,16 +34,72 @@ func NewAdminHandler(
 	allocationRepo *storage.AllocationRepository,
 	userRepo *storage.UserRepository,
 	txLogRepo *storage.TxLogRepository,
+	governanceRepo *storage.GovernanceRepository,
 	cardanoService *cardano.CardanoService,
-	governanceAddr string,
+	governance *cardano.GovernanceWallet,
+	governanceTxTTL time.Duration,
+	dualControl DualControlPolicy,
+	referralService *referrals.Service,
 ) *AdminHandler {
 	return &AdminHandler{
-		settlementRepo: settlementRepo,
-		allocationRepo: allocationRepo,
-		userRepo:       userRepo,
-		txLogRepo:      txLogRepo,
-		cardanoService: cardanoService,
-		governanceAddr: governanceAddr,
+		settlementRepo:  settlementRepo,
+		allocationRepo:  allocationRepo,
+		userRepo:        userRepo,
+		txLogRepo:       txLogRepo,
+		governanceRepo:  governanceRepo,
+		cardanoService:  cardanoService,
+		governance:      governance,
+		governanceTxTTL: governanceTxTTL,
+		dualControl:     dualControl,
+		referralService: referralService,
+	}
+}
+
+func (h *AdminHandler) allocationApprovalTarget(allocation *models.AllocationPurchase) approvalTarget {
+	return approvalTarget{
+		event:      "ALLOCATION",
+		idField:    "purchase_id",
+		id:         allocation.ID,
+		initiation: allocation.Initiation,
+		approvals:  allocation.Approvals,
+		required:   allocation.RequiredApprovals,
+		initiate: func(ctx context.Context, adminID, notes string, required int) (bool, error) {
+			return h.allocationRepo.InitiateApproval(ctx, allocation.ID, adminID, notes, required)
+		},
+		approve: func(ctx context.Context, adminID, notes string) ([]models.Approval, error) {
+			return h.allocationRepo.AddApproval(ctx, allocation.ID, adminID, notes)
+		},
+		reload: func(ctx context.Context) (*models.Approval, []models.Approval, int, error) {
+			current, err := h.allocationRepo.GetAllocationByID(ctx, allocation.ID)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			return current.Initiation, current.Approvals, current.RequiredApprovals, nil
+		},
+	}
+}
+
+func (h *AdminHandler) settlementApprovalTarget(settlement *models.SettlementRequest) approvalTarget {
+	return approvalTarget{
+		event:      "SETTLEMENT",
+		idField:    "settlement_id",
+		id:         settlement.ID,
+		initiation: settlement.Initiation,
+		approvals:  settlement.Approvals,
+		required:   settlement.RequiredApprovals,
+		initiate: func(ctx context.Context, adminID, notes string, required int) (bool, error) {
+			return h.settlementRepo.InitiateApproval(ctx, settlement.ID, adminID, notes, required)
+		},
+		approve: func(ctx context.Context, adminID, notes string) ([]models.Approval, error) {
+			return h.settlementRepo.AddApproval(ctx, settlement.ID, adminID, notes)
+		},
+		reload: func(ctx context.Context) (*models.Approval, []models.Approval, int, error) {
+			current, err := h.settlementRepo.GetSettlementByID(ctx, settlement.ID)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			return current.Initiation, current.Approvals, current.RequiredApprovals, nil
+		},
 	}
 }
 
@@ -95,6 +158,16 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 
 	// REJECT:
 	if req.Action == "REJECT" {
+		// Only a request that is still pending can be rejected; another admin
+		// may have started approving it since it was read
+		if err := h.allocationRepo.TransitionStatus(c.Request.Context(), allocation.ID, "PENDING", "REJECTED"); err != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_INVALID_STATUS",
+				"message": "Allocation already processed",
+			})
+			return
+		}
 		allocation.Status = "REJECTED"
 		allocation.AdminID = adminID.(string)
 		allocation.AdminNotes = req.Notes
@@ -102,16 +175,12 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 		allocation.VerifiedAt = &now
 
 		if err := h.allocationRepo.UpdateAllocation(c.Request.Context(), allocation); err != nil {
-			logger.Error("Failed to update allocation", err, nil)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"code":    "500_UPDATE_FAILED",
-				"message": "Failed to reject allocation",
+			logger.Error("Failed to record allocation rejection", err, map[string]interface{}{
+				"allocation_id": allocation.ID,
 			})
-			return
 		}
 
-		logger.Audit("ALLOCATION_REJECTED", adminID.(string), map[string]interface{}{
+		auditLog(c, "ALLOCATION_REJECTED", map[string]interface{}{
 			"allocation_id": allocation.ID,
 			"merchant_id":   allocation.MerchantID,
 		})
@@ -126,6 +195,13 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 		return
 	}
 
+	// Dual control: large allocations need sign-off from admins other than the initiator
+	if allocation.Initiation != nil || h.dualControl.applies(allocation.AmountLCN, h.dualControl.AllocationThresholdLCN) {
+		if !h.collectApproval(c, h.allocationApprovalTarget(allocation), adminID.(string), req.Notes) {
+			return
+		}
+	}
+
 	// APPROVE: Transfer LCN from governance to merchant
 	merchant, err := h.userRepo.GetMerchantByID(c.Request.Context(), allocation.MerchantID)
 	if err != nil {
@@ -137,18 +213,29 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 		return
 	}
 
-	// Get governance wallet details (assuming it's stored as a special user or config)
-	// For this implementation, we'll use the governance address from config and assume
-	// we have access to its private key via the wallet service or environment
-	// In a real production system, this would be a multi-sig or hardware wallet interaction
+	// Claim the allocation so that concurrent approvals cannot transfer twice
+	if err := h.allocationRepo.TransitionStatus(c.Request.Context(), allocation.ID, "PENDING", "PROCESSING"); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_INVALID_STATUS",
+			"message": "Allocation already processed",
+		})
+		return
+	}
 
-	// Retrieve governance wallet
-	// This part depends on how the governance wallet is stored.
-	// If it's a merchant/user with role ADMIN, we can find it.
-	// Let's try to find the user associated with the governance address
-	govUser, err := h.userRepo.GetMerchantByEmail(c.Request.Context(), "admin@loyalcoin.com")
+	// Multi-sig governance wallet: the transfer waits for admin signatures
+	if h.governance.IsMultiSig() {
+		h.startGovernanceTransfer(c, allocation, merchant, adminID.(string), req.Notes)
+		return
+	}
+
+	// Single-key governance wallet: the account owning GOVERNANCE_WALLET_ADDRESS signs
+	govUser, err := h.userRepo.GetMerchantByWalletAddress(c.Request.Context(), h.governance.Address)
 	if err != nil {
 		logger.Error("Failed to retrieve governance wallet owner", err, nil)
+		if err := h.allocationRepo.TransitionStatus(c.Request.Context(), allocation.ID, "PROCESSING", "PENDING"); err != nil {
+			logger.Error("Failed to release allocation", err, nil)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"code":    "500_GOVERNANCE_WALLET_ERROR",
@@ -162,10 +249,9 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 	// TransferADA will convert to lovelace (LCN × 10,000)
 
 	txHash, err := h.cardanoService.TransferADA(
-		govUser.Wallet.Address,
+		walletKey(govUser.ID, govUser.Wallet),
 		merchant.Wallet.Address,
 		allocation.AmountLCN, // whole LCN units
-		govUser.Wallet.EncryptedPrivateKey,
 	)
 	if err != nil {
 		logger.Error("Failed to transfer ADA (LCN)", err, map[string]interface{}{
@@ -173,6 +259,9 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 			"to":     merchant.Wallet.Address,
 			"amount": allocation.AmountLCN,
 		})
+		if err := h.allocationRepo.TransitionStatus(c.Request.Context(), allocation.ID, "PROCESSING", "PENDING"); err != nil {
+			logger.Error("Failed to release allocation after transfer failure", err, nil)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"code":    "500_TRANSFER_FAILED",
@@ -213,7 +302,7 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 		return
 	}
 
-	logger.Audit("ALLOCATION_APPROVED", adminID.(string), map[string]interface{}{
+	auditLog(c, "ALLOCATION_APPROVED", map[string]interface{}{
 		"allocation_id": allocation.ID,
 		"merchant_id":   allocation.MerchantID,
 		"amount_lcn":    allocation.AmountLCN,
@@ -230,6 +319,8 @@ func (h *AdminHandler) ApproveAllocation(c *gin.Context) {
 }
 
 // POST /api/v1/admin/settlement/approve
+// Approving a payout moves the merchant's LCN to the governance wallet; a
+// clearing settlement from a coalition netting moves it to the payee member.
 func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 	adminID, exists := c.Get("user_id")
 	if !exists {
@@ -281,6 +372,16 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 	}
 
 	if req.Action == "REJECT" {
+		// Only from the status it was read in; another admin may have started
+		// paying it out since
+		if err := h.settlementRepo.TransitionStatus(c.Request.Context(), settlement.ID, []models.SettlementStatus{settlement.Status}, models.SettlementRejected); err != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_INVALID_STATUS",
+				"message": "Settlement already processed",
+			})
+			return
+		}
 		settlement.Status = models.SettlementRejected
 		settlement.AdminID = adminID.(string)
 		settlement.AdminNotes = req.Notes
@@ -288,15 +389,11 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 		settlement.ApprovedAt = &now
 
 		if err := h.settlementRepo.UpdateSettlement(c.Request.Context(), settlement); err != nil {
-			logger.Error("Failed to update settlement", err, nil)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"code":    "500_UPDATE_FAILED",
-				"message": "Failed to reject settlement",
+			logger.Error("Failed to record settlement rejection", err, map[string]interface{}{
+				"settlement_id": settlement.ID,
 			})
-			return
 		}
-		logger.Audit("SETTLEMENT_REJECTED", adminID.(string), map[string]interface{}{
+		auditLog(c, "SETTLEMENT_REJECTED", map[string]interface{}{
 			"settlement_id": settlement.ID,
 			"merchant_id":   settlement.MerchantID,
 		})
@@ -310,7 +407,14 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 		return
 	}
 
-	// APPROVE: Transfer tADA from merchant to admin (governance wallet)
+	// Dual control: large settlements need sign-off from admins other than the initiator
+	if settlement.Initiation != nil || h.dualControl.applies(settlement.AmountLCN, h.dualControl.SettlementThresholdLCN) {
+		if !h.collectApproval(c, h.settlementApprovalTarget(settlement), adminID.(string), req.Notes) {
+			return
+		}
+	}
+
+	// APPROVE: Transfer tADA from the merchant
 	merchant, err := h.userRepo.GetMerchantByID(c.Request.Context(), settlement.MerchantID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -321,31 +425,48 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 		return
 	}
 
-	// Get governance wallet (admin wallet to receive funds)
-	govUser, err := h.userRepo.GetMerchantByEmail(c.Request.Context(), "admin@loyalcoin.com")
-	if err != nil {
-		logger.Error("Failed to retrieve governance wallet", err, nil)
-		c.JSON(http.StatusInternalServerError, gin.H{
+	// Payouts go to the governance wallet, which pays out ETB; clearing
+	// settlements pay the coalition member the merchant owes
+	toAddress := h.governance.Address
+	if settlement.Kind == models.SettlementClearing {
+		payee, err := h.userRepo.GetMerchantByID(c.Request.Context(), settlement.PayeeMerchantID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"code":    "404_MERCHANT_NOT_FOUND",
+				"message": "Payee merchant not found",
+			})
+			return
+		}
+		toAddress = payee.Wallet.Address
+	}
+
+	// Claim the settlement so that concurrent approvals cannot transfer twice
+	previousStatus := settlement.Status
+	if err := h.settlementRepo.TransitionStatus(c.Request.Context(), settlement.ID, []models.SettlementStatus{previousStatus}, models.SettlementProcessing); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
 			"status":  "error",
-			"code":    "500_GOVERNANCE_WALLET_ERROR",
-			"message": "Failed to access governance wallet",
+			"code":    "409_INVALID_STATUS",
+			"message": "Settlement already processed",
 		})
 		return
 	}
 
-	// Transfer tADA from merchant to admin (governance) wallet
+	// Transfer tADA from the merchant
 	txHash, err := h.cardanoService.TransferADA(
-		merchant.Wallet.Address,
-		govUser.Wallet.Address,
+		walletKey(merchant.ID, merchant.Wallet),
+		toAddress,
 		settlement.AmountLCN,
-		merchant.Wallet.EncryptedPrivateKey,
 	)
 	if err != nil {
 		logger.Error("Failed to transfer tADA for settlement", err, map[string]interface{}{
 			"from":       merchant.Wallet.Address,
-			"to":         govUser.Wallet.Address,
+			"to":         toAddress,
 			"amount_lcn": settlement.AmountLCN,
 		})
+		if err := h.settlementRepo.TransitionStatus(c.Request.Context(), settlement.ID, []models.SettlementStatus{models.SettlementProcessing}, previousStatus); err != nil {
+			logger.Error("Failed to release settlement after transfer failure", err, nil)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"code":    "500_TRANSFER_FAILED",
@@ -354,6 +475,14 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 		return
 	}
 
+	if settlement.Kind == models.SettlementClearing {
+		if err := h.txLogRepo.SetTxType(c.Request.Context(), txHash, models.TxTypeSettlement); err != nil {
+			logger.Error("Failed to mark clearing settlement transaction", err, map[string]interface{}{
+				"tx_hash": txHash,
+			})
+		}
+	}
+
 	settlement.Status = models.SettlementCompleted
 	settlement.AdminID = adminID.(string)
 	settlement.AdminNotes = req.Notes
@@ -372,9 +501,11 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 		})
 		return
 	}
-	logger.Audit("SETTLEMENT_APPROVED", adminID.(string), map[string]interface{}{
+	auditLog(c, "SETTLEMENT_APPROVED", map[string]interface{}{
 		"settlement_id":     settlement.ID,
+		"kind":              settlement.Kind,
 		"merchant_id":       settlement.MerchantID,
+		"payee_merchant_id": settlement.PayeeMerchantID,
 		"amount_lcn":        settlement.AmountLCN,
 		"amount_etb":        settlement.AmountETB,
 		"tx_hash":           txHash,
@@ -387,34 +518,47 @@ func (h *AdminHandler) ApproveSettlement(c *gin.Context) {
 			"status":            "COMPLETED",
 			"tx_hash":           txHash,
 			"payment_reference": req.PaymentReference,
-			"message":           "Settlement completed. tADA transferred from merchant to admin wallet.",
+			"message":           settlementMessage(settlement),
 		},
 	})
 }
 
+// settlementMessage describes where a completed settlement's LCN went
+func settlementMessage(settlement *models.SettlementRequest) string {
+	if settlement.Kind == models.SettlementClearing {
+		return "Clearing settlement completed. tADA transferred from merchant to coalition member."
+	}
+	return "Settlement completed. tADA transferred from merchant to governance wallet."
+}
+
 // GET /api/v1/admin/reserve/status
 func (h *AdminHandler) GetReserveStatus(c *gin.Context) {
-	govUser, err := h.userRepo.GetMerchantByEmail(c.Request.Context(), "admin@loyalcoin.com")
+	govBalance, err := h.cardanoService.GetBalance(h.governance.Address)
 	if err != nil {
-		logger.Error("Failed to retrieve governance wallet owner", err, nil)
+		logger.Error("Failed to get governance balance", err, nil)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"code":    "500_GOVERNANCE_WALLET_ERROR",
-			"message": "Failed to access governance wallet",
+			"code":    "500_BALANCE_QUERY_FAILED",
+			"message": "Failed to query governance balance",
 		})
 		return
 	}
 
-	govBalance, err := h.cardanoService.GetBalance(govUser.Wallet.Address)
+	// Expired LCN is no longer owed to customers
+	expired, err := h.txLogRepo.SumExpired(c.Request.Context())
 	if err != nil {
-		logger.Error("Failed to get governance balance", err, nil)
+		logger.Error("Failed to sum expired LCN", err, nil)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"code":    "500_BALANCE_QUERY_FAILED",
-			"message": "Failed to query governance balance",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to query expired LCN",
 		})
 		return
 	}
+	var expiredTotal uint64
+	for _, amount := range expired {
+		expiredTotal += amount
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
@@ -422,7 +566,13 @@ func (h *AdminHandler) GetReserveStatus(c *gin.Context) {
 			"lcn_balance":               govBalance.LCN,
 			"lcn_balance_atomic":        govBalance.LCNAtomic,
 			"governance_wallet_address": govBalance.Address,
+			"multisig":                  h.governance.IsMultiSig(),
 			"health":                    "ACTIVE",
+			"expired_lcn": gin.H{
+				"total":                  expiredTotal,
+				"returned_to_merchants":  expired[expiry.ReturnToIssuer],
+				"returned_to_governance": expired[expiry.ReturnToGovernance],
+			},
 		},
 	})
 }
@@ -30,9 +30,9 @@ func NewAllocationHandler(
 
 // POST /api/v1/merchant/allocation/purchase
 func (h *AllocationHandler) RequestAllocation(c *gin.Context) {
-	// Get user ID from JWT claims
-	userID, exists := c.Get("user_id")
-	if !exists {
+	// Staff members act on behalf of their merchant
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"code":    "401_UNAUTHORIZED",
@@ -41,8 +41,6 @@ func (h *AllocationHandler) RequestAllocation(c *gin.Context) {
 		return
 	}
 
-	merchantID := userID.(string)
-
 	var req struct {
 		AmountLCN        uint64 `json:"amount_lcn" binding:"required,min=1"`
 		PaymentMethod    string `json:"payment_method" binding:"required"`
@@ -83,7 +81,7 @@ func (h *AllocationHandler) RequestAllocation(c *gin.Context) {
 		})
 		return
 	}
-	logger.Audit("ALLOCATION_REQUESTED", merchantID, map[string]interface{}{
+	auditLog(c, "ALLOCATION_REQUESTED", map[string]interface{}{
 		"allocation_id": allocation.ID,
 		"amount_lcn":    req.AmountLCN,
 		"amount_etb":    amountETB,
@@ -103,9 +101,9 @@ func (h *AllocationHandler) RequestAllocation(c *gin.Context) {
 
 // GET /api/v1/merchant/allocation/history
 func (h *AllocationHandler) GetAllocationHistory(c *gin.Context) {
-	// Get user ID from JWT claims
-	userID, exists := c.Get("user_id")
-	if !exists {
+	// Staff members act on behalf of their merchant
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"code":    "401_UNAUTHORIZED",
@@ -114,8 +112,6 @@ func (h *AllocationHandler) GetAllocationHistory(c *gin.Context) {
 		return
 	}
 
-	merchantID := userID.(string)
-
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	statusFilter := c.Query("status")
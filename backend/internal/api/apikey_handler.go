@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Permissions a merchant may grant to a point-of-sale API key
+var allowedAPIKeyScopes = map[models.Permission]bool{
+	models.PermLCNIssue:        true,
+	models.PermPaymentsRequest: true,
+	models.PermWalletRead:      true,
+}
+
+type APIKeyHandler struct {
+	apiKeyRepo *storage.APIKeyRepository
+}
+
+func NewAPIKeyHandler(apiKeyRepo *storage.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// POST /api/v1/merchant/api-keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+
+	var req struct {
+		Name   string              `json:"name" binding:"required,max=64"`
+		Scopes []models.Permission `json:"scopes" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !allowedAPIKeyScopes[scope] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_SCOPE",
+				"message": "Unsupported API key scope: " + string(scope),
+			})
+			return
+		}
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		logger.Error("Failed to generate API key", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to generate API key",
+		})
+		return
+	}
+
+	apiKey := &models.APIKey{
+		MerchantID: merchantID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Scopes:     req.Scopes,
+		CreatedBy:  c.GetString("user_id"),
+	}
+	if err := h.apiKeyRepo.CreateAPIKey(c.Request.Context(), apiKey); err != nil {
+		logger.Error("Failed to store API key", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_API_KEY_CREATION_FAILED",
+			"message": "Failed to create API key",
+		})
+		return
+	}
+
+	auditLog(c, "API_KEY_CREATED", map[string]interface{}{
+		"api_key_id": apiKey.ID,
+		"prefix":     apiKey.Prefix,
+		"scopes":     apiKey.Scopes,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"api_key": apiKey,
+			"key":     key,
+			"message": "Store this key securely. It will not be shown again.",
+		},
+	})
+}
+
+// GET /api/v1/merchant/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+
+	apiKeys, err := h.apiKeyRepo.GetAPIKeysByMerchant(c.Request.Context(), merchantID)
+	if err != nil {
+		logger.Error("Failed to list API keys", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"api_keys": apiKeys,
+			"total":    len(apiKeys),
+		},
+	})
+}
+
+// DELETE /api/v1/merchant/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	keyID := c.Param("id")
+
+	if err := h.apiKeyRepo.RevokeAPIKey(c.Request.Context(), keyID, merchantID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_API_KEY_NOT_FOUND",
+			"message": "API key not found or already revoked",
+		})
+		return
+	}
+
+	auditLog(c, "API_KEY_REVOKED", map[string]interface{}{
+		"api_key_id": keyID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"api_key_id": keyID,
+			"status":     "REVOKED",
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// auditLog writes an audit entry for the authenticated caller. Besides the
+// given fields it records the permission that authorized the request, how the
+// caller authenticated and, for merchant staff, the business they acted for.
+func auditLog(c *gin.Context, event string, fields map[string]interface{}) {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	if permission, ok := c.Get("permission"); ok {
+		fields["permission"] = permission
+	}
+	if role, ok := c.Get("role"); ok {
+		fields["user_role"] = role
+	}
+	if authMethod := c.GetString("auth_method"); authMethod != "" {
+		fields["auth_method"] = authMethod
+	}
+	if apiKeyID := c.GetString("api_key_id"); apiKeyID != "" {
+		fields["api_key_id"] = apiKeyID
+	}
+	if merchantID := c.GetString("merchant_id"); merchantID != "" {
+		if _, exists := fields["merchant_id"]; !exists {
+			fields["merchant_id"] = merchantID
+		}
+	}
+	logger.Audit(event, c.GetString("user_id"), fields)
+}
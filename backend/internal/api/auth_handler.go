@@ -2,32 +2,65 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/cardano"
 	"github.com/loyalcoin/backend/internal/config"
 	"github.com/loyalcoin/backend/internal/crypto"
 	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/referrals"
 	"github.com/loyalcoin/backend/internal/storage"
 	"github.com/loyalcoin/backend/pkg/logger"
 )
 
 type AuthHandler struct {
-	userRepo   *storage.UserRepository
-	jwtService *auth.JWTService
-	config     *config.Config
+	userRepo        *storage.UserRepository
+	staffRepo       *storage.StaffRepository
+	rbacService     *auth.RBACService
+	jwtService      *auth.JWTService
+	cardanoService  *cardano.CardanoService
+	referralService *referrals.Service
+	config          *config.Config
 }
 
-func NewAuthHandler(userRepo *storage.UserRepository, jwtService *auth.JWTService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(
+	userRepo *storage.UserRepository,
+	staffRepo *storage.StaffRepository,
+	rbacService *auth.RBACService,
+	jwtService *auth.JWTService,
+	cardanoService *cardano.CardanoService,
+	referralService *referrals.Service,
+	cfg *config.Config,
+) *AuthHandler {
 	return &AuthHandler{
-		userRepo:   userRepo,
-		jwtService: jwtService,
-		config:     cfg,
+		userRepo:        userRepo,
+		staffRepo:       staffRepo,
+		rbacService:     rbacService,
+		jwtService:      jwtService,
+		cardanoService:  cardanoService,
+		referralService: referralService,
+		config:          cfg,
 	}
 }
 
+// Returns the scope and permissions of a role for login responses
+func (h *AuthHandler) roleInfo(ctx context.Context, role models.Role) (models.RoleScope, []models.Permission) {
+	definition, err := h.rbacService.GetRole(ctx, role)
+	if err != nil {
+		logger.Warn("Failed to resolve role", map[string]interface{}{
+			"role":  role,
+			"error": err.Error(),
+		})
+		return "", []models.Permission{}
+	}
+	return definition.Scope, definition.Permissions
+}
+
 type SignupRequest struct {
 	Email        string      `json:"email" binding:"required,email"`
 	Password     string      `json:"password" binding:"required"`
@@ -35,6 +68,23 @@ type SignupRequest struct {
 	BusinessName string      `json:"business_name"` // Required for MERCHANT
 	Username     string      `json:"username"`      // Required for CUSTOMER
 	Phone        string      `json:"phone"`
+	ReferralCode string      `json:"referral_code"` // Optional, of the customer or merchant who referred the account
+}
+
+// bindWalletKey binds a new account's wallet key to the account now that its ID
+// is known. Returns false if nothing changed; unbound keys are upgraded later
+// by the background key upgrade.
+func bindWalletKey(walletService *crypto.WalletService, ownerID string, wallet *models.Wallet) bool {
+	bound, upgraded, err := walletService.BindKey(walletKey(ownerID, *wallet))
+	if err != nil {
+		logger.Warn("Failed to bind wallet key", map[string]interface{}{
+			"owner_id": ownerID,
+			"error":    err.Error(),
+		})
+		return false
+	}
+	wallet.EncryptedPrivateKey = bound
+	return upgraded
 }
 
 type LoginRequest struct {
@@ -77,9 +127,47 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Generate real Cardano wallet with envelope encryption
+	// Login tries merchants and customers before staff, so a new account with
+	// a staff member's email would lock the staff member out
+	if _, err := h.staffRepo.GetStaffByEmail(ctx, req.Email); err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "An account with this email already exists",
+		})
+		return
+	}
+
+	var referrer *models.ReferralParty
+	if strings.TrimSpace(req.ReferralCode) != "" {
+		referrer, err = h.referralService.ResolveCode(ctx, req.ReferralCode)
+		if err != nil {
+			if !errors.Is(err, storage.ErrReferralCodeNotFound) {
+				logger.Error("Failed to resolve referral code", err, nil)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_REFERRAL_CODE",
+				"message": "Referral code not found",
+			})
+			return
+		}
+	}
+	referralCode, err := referrals.NewCode()
+	if err != nil {
+		logger.Warn("Failed to generate referral code", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// Generate real Cardano wallet with envelope encryption. Customers get an
+	// HD wallet they can later restore elsewhere from its recovery phrase.
 	walletService := c.MustGet("wallet_service").(*crypto.WalletService)
-	walletResult, err := walletService.CreateWallet(h.config.CardanoNetwork)
+	createWallet := walletService.CreateWallet
+	if req.Role == models.RoleCustomer {
+		createWallet = walletService.CreateHDWallet
+	}
+	walletResult, err := createWallet(h.config.CardanoNetwork)
 	if err != nil {
 		logger.Error("Failed to create wallet", err, nil)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -94,6 +182,8 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		Address:             walletResult.Address,
 		EncryptedPrivateKey: walletResult.EncryptedPrivKey,
 		PubKeyHex:           walletResult.PubKeyHex,
+		Signer:              walletResult.Signer,
+		KeyType:             walletResult.KeyType,
 		CreatedAt:           time.Now().UTC(),
 	}
 
@@ -117,6 +207,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 			Status:        models.StatusPendingVerification,
 			AllocationLCN: 0,
 			BalanceLCN:    0,
+			ReferralCode:  referralCode,
 		}
 
 		if err := h.userRepo.CreateMerchant(ctx, merchant); err != nil {
@@ -134,9 +225,20 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 			"merchant_id": merchant.ID,
 			"email":       merchant.Email,
 		})
+		if bindWalletKey(walletService, merchant.ID, &merchant.Wallet) {
+			if err := h.userRepo.UpdateMerchant(ctx, merchant); err != nil {
+				logger.Warn("Failed to store bound wallet key", map[string]interface{}{
+					"merchant_id": merchant.ID,
+					"error":       err.Error(),
+				})
+			}
+		}
+		h.attributeReferral(ctx, c, req.ReferralCode, referrer, &models.ReferralParty{
+			ID: merchant.ID, Role: merchant.Role, Name: merchant.BusinessName, Address: merchant.Wallet.Address,
+		})
 
 		// Generate JWT token for immediate login
-		token, err := h.jwtService.GenerateToken(merchant.ID, merchant.Role, merchant.Wallet.Address)
+		token, err := h.jwtService.GenerateMerchantToken(merchant.ID, merchant.ID, merchant.Role, merchant.Wallet.Address)
 		if err != nil {
 			logger.Error("Failed to generate JWT after signup", err, nil)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -159,6 +261,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 					"role":           merchant.Role,
 					"wallet_address": merchant.Wallet.Address,
 					"status":         merchant.Status,
+					"referral_code":  merchant.ReferralCode,
 				},
 			},
 		})
@@ -172,13 +275,58 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 			})
 			return
 		}
+		// Merchants issue LCN by username or phone, so neither may be ambiguous
+		if err := auth.ValidateUsername(req.Username); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_USERNAME",
+				"message": err.Error(),
+			})
+			return
+		}
+		if req.Phone != "" {
+			phone, err := auth.NormalizePhone(req.Phone)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"status":  "error",
+					"code":    "400_INVALID_PHONE",
+					"message": err.Error(),
+				})
+				return
+			}
+			req.Phone = phone
+		}
+		for _, taken := range []struct{ field, value string }{
+			{auth.IdentifierUsername, req.Username},
+			{auth.IdentifierPhone, req.Phone},
+		} {
+			if taken.value == "" {
+				continue
+			}
+			if _, err := h.userRepo.FindCustomerByIdentifier(ctx, taken.field, taken.value); !errors.Is(err, storage.ErrCustomerNotFound) {
+				c.JSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"code":    "409_CONFLICT",
+					"message": "customer with this " + taken.field + " already exists",
+				})
+				return
+			}
+		}
 
+		handle, err := auth.NewCustomerHandle()
+		if err != nil {
+			logger.Warn("Failed to generate customer handle", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
 		customer := &models.Customer{
 			Username:     req.Username,
 			Email:        req.Email,
 			Phone:        req.Phone,
+			Handle:       handle,
 			PasswordHash: passwordHash,
 			Wallet:       wallet,
+			ReferralCode: referralCode,
 		}
 
 		if err := h.userRepo.CreateCustomer(ctx, customer); err != nil {
@@ -196,13 +344,29 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 			"customer_id": customer.ID,
 			"email":       customer.Email,
 		})
+		if bindWalletKey(walletService, customer.ID, &customer.Wallet) {
+			if err := h.userRepo.UpdateCustomer(ctx, customer); err != nil {
+				logger.Warn("Failed to store bound wallet key", map[string]interface{}{
+					"customer_id": customer.ID,
+					"error":       err.Error(),
+				})
+			}
+		}
+		h.attributeReferral(ctx, c, req.ReferralCode, referrer, &models.ReferralParty{
+			ID: customer.ID, Role: models.RoleCustomer, Name: customer.Username, Address: customer.Wallet.Address,
+		})
+
+		// LCN merchants issued to this email or phone before signup is paid
+		// out once the customer verifies it (VerificationHandler)
 
 		c.JSON(http.StatusCreated, gin.H{
 			"status": "ok",
 			"data": gin.H{
 				"user_id":        customer.ID,
 				"wallet_address": customer.Wallet.Address,
+				"handle":         customer.Handle,
 				"role":           models.RoleCustomer,
+				"referral_code":  customer.ReferralCode,
 			},
 		})
 
@@ -215,6 +379,20 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	}
 }
 
+// attributeReferral records who referred a new account. The account is
+// created either way, so failures are only logged.
+func (h *AuthHandler) attributeReferral(ctx context.Context, c *gin.Context, code string, referrer, referee *models.ReferralParty) {
+	if referrer == nil {
+		return
+	}
+	if err := h.referralService.Attribute(ctx, referrer, referee, code, c.ClientIP(), c.GetHeader("X-Device-ID")); err != nil {
+		logger.Error("Failed to attribute referral", err, map[string]interface{}{
+			"referee_id":  referee.ID,
+			"referrer_id": referrer.ID,
+		})
+	}
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -228,7 +406,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	// Merchant login
+	// Merchant (and platform admin) login
 	merchant, merchantErr := h.userRepo.GetMerchantByEmail(ctx, req.Email)
 	if merchantErr == nil {
 		if !auth.CheckPasswordHash(req.Password, merchant.PasswordHash) {
@@ -239,7 +417,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			})
 			return
 		}
-		token, err := h.jwtService.GenerateToken(merchant.ID, merchant.Role, merchant.Wallet.Address)
+		scope, permissions := h.roleInfo(ctx, merchant.Role)
+		merchantID := ""
+		if scope == models.RoleScopeMerchant {
+			merchantID = merchant.ID
+		}
+		token, err := h.jwtService.GenerateMerchantToken(merchant.ID, merchantID, merchant.Role, merchant.Wallet.Address)
 		if err != nil {
 			logger.Error("Failed to generate JWT", err, nil)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -262,6 +445,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 					"id":             merchant.ID,
 					"email":          merchant.Email,
 					"role":           merchant.Role,
+					"scope":          scope,
+					"permissions":    permissions,
 					"wallet_address": merchant.Wallet.Address,
 				},
 			},
@@ -280,6 +465,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			})
 			return
 		}
+		// Accounts created before QR handles existed get one on their next login
+		if customer.Handle == "" {
+			if handle, err := auth.NewCustomerHandle(); err == nil {
+				if assigned, err := h.userRepo.SetCustomerHandle(ctx, customer.ID, handle); err == nil && assigned {
+					customer.Handle = handle
+				}
+			}
+		}
+		scope, permissions := h.roleInfo(ctx, models.RoleCustomer)
 		token, err := h.jwtService.GenerateToken(customer.ID, models.RoleCustomer, customer.Wallet.Address)
 		if err != nil {
 			logger.Error("Failed to generate JWT", err, nil)
@@ -303,7 +497,68 @@ func (h *AuthHandler) Login(c *gin.Context) {
 					"id":             customer.ID,
 					"email":          customer.Email,
 					"role":           models.RoleCustomer,
+					"scope":          scope,
+					"permissions":    permissions,
 					"wallet_address": customer.Wallet.Address,
+					"handle":         customer.Handle,
+					"email_verified": customer.EmailVerified,
+				},
+			},
+		})
+		return
+	}
+
+	// Merchant staff login
+	staff, staffErr := h.staffRepo.GetStaffByEmail(ctx, req.Email)
+	if staffErr == nil {
+		if !auth.CheckPasswordHash(req.Password, staff.PasswordHash) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"code":    "401_INVALID_CREDENTIALS",
+				"message": "Invalid email or password",
+			})
+			return
+		}
+		employer, err := h.userRepo.GetMerchantByID(ctx, staff.MerchantID)
+		if err != nil || staff.Status == models.StatusSuspended || employer.Status == models.StatusSuspended {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"code":    "403_ACCOUNT_DISABLED",
+				"message": "This staff account is disabled",
+			})
+			return
+		}
+		scope, permissions := h.roleInfo(ctx, staff.Role)
+		token, err := h.jwtService.GenerateMerchantToken(staff.ID, staff.MerchantID, staff.Role, employer.Wallet.Address)
+		if err != nil {
+			logger.Error("Failed to generate JWT", err, nil)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to generate token",
+			})
+			return
+		}
+		logger.Audit("USER_LOGIN", staff.ID, map[string]interface{}{
+			"role":        staff.Role,
+			"email":       staff.Email,
+			"merchant_id": staff.MerchantID,
+		})
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+			"data": gin.H{
+				"token":      token,
+				"expires_at": time.Now().Add(time.Duration(h.config.JWTExpirationHours) * time.Hour).Format(time.RFC3339),
+				"user": gin.H{
+					"id":             staff.ID,
+					"email":          staff.Email,
+					"name":           staff.Name,
+					"role":           staff.Role,
+					"scope":          scope,
+					"permissions":    permissions,
+					"merchant_id":    staff.MerchantID,
+					"business_name":  employer.BusinessName,
+					"wallet_address": employer.Wallet.Address,
 				},
 			},
 		})
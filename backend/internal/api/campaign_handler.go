@@ -0,0 +1,329 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Campaigns are time-boxed promotions ("double points this weekend") that
+// POST /lcn/earn applies on top of the earn rule, within a budget the
+// merchant sets aside from their LCN.
+type CampaignHandler struct {
+	campaignRepo   *storage.CampaignRepository
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	cardanoService *cardano.CardanoService
+}
+
+func NewCampaignHandler(
+	campaignRepo *storage.CampaignRepository,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	cardanoService *cardano.CardanoService,
+) *CampaignHandler {
+	return &CampaignHandler{
+		campaignRepo:   campaignRepo,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		cardanoService: cardanoService,
+	}
+}
+
+// POST /api/v1/merchant/campaigns (requires rewards:manage)
+func (h *CampaignHandler) CreateCampaign(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	var req struct {
+		Name           string    `json:"name" binding:"required,max=80"`
+		Type           string    `json:"type" binding:"required"`
+		Multiplier     float64   `json:"multiplier"`
+		BonusLCN       uint64    `json:"bonus_lcn"`
+		StartsAt       time.Time `json:"starts_at" binding:"required"`
+		EndsAt         time.Time `json:"ends_at" binding:"required"`
+		BudgetLCN      uint64    `json:"budget_lcn" binding:"required,gt=0"`
+		FirstVisitOnly bool      `json:"first_visit_only"`
+		MinSpendETB    float64   `json:"min_spend_etb"`
+		Categories     []string  `json:"categories"`
+		Stackable      bool      `json:"stackable"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	campaign := &models.Campaign{
+		MerchantID:     merchantID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Multiplier:     req.Multiplier,
+		BonusLCN:       req.BonusLCN,
+		StartsAt:       req.StartsAt.UTC(),
+		EndsAt:         req.EndsAt.UTC(),
+		BudgetLCN:      req.BudgetLCN,
+		FirstVisitOnly: req.FirstVisitOnly,
+		MinSpendETB:    req.MinSpendETB,
+		Categories:     req.Categories,
+		Stackable:      req.Stackable,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if err := rewards.ValidateCampaign(campaign); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_CAMPAIGN",
+			"message": err.Error(),
+		})
+		return
+	}
+	now := time.Now().UTC()
+	if !campaign.EndsAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_CAMPAIGN",
+			"message": "ends_at must be in the future",
+		})
+		return
+	}
+
+	// The budget is set aside from LCN the merchant holds and has not
+	// already promised to other campaigns
+	ctx := c.Request.Context()
+	merchant, err := h.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+	balance, err := h.cardanoService.GetBalance(merchant.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to get merchant balance", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BALANCE_CHECK_FAILED",
+			"message": "Failed to verify merchant balance",
+		})
+		return
+	}
+	committed, err := h.campaignRepo.CommittedBudget(ctx, merchantID, now)
+	if err != nil {
+		logger.Error("Failed to sum campaign budgets", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to check campaign budgets",
+		})
+		return
+	}
+	available := balance.LCN - float64(committed)
+	if available < float64(campaign.BudgetLCN) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INSUFFICIENT_BALANCE",
+			"message": "The budget exceeds the LCN not already set aside for other campaigns",
+			"data": gin.H{
+				"requested": campaign.BudgetLCN,
+				"available": available,
+				"committed": committed,
+			},
+		})
+		return
+	}
+
+	if err := h.campaignRepo.CreateCampaign(ctx, campaign); err != nil {
+		logger.Error("Failed to create campaign", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create campaign",
+		})
+		return
+	}
+
+	auditLog(c, "CAMPAIGN_CREATED", map[string]interface{}{
+		"campaign_id": campaign.ID,
+		"type":        campaign.Type,
+		"budget_lcn":  campaign.BudgetLCN,
+	})
+	campaign.Status = campaignStatus(campaign, now)
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data":   campaign,
+	})
+}
+
+// GET /api/v1/merchant/campaigns (requires rewards:manage)
+func (h *CampaignHandler) ListCampaigns(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	statusFilter := c.Query("status")
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var status *models.CampaignStatus
+	if statusFilter != "" {
+		s := models.CampaignStatus(statusFilter)
+		status = &s
+	}
+
+	campaigns, total, err := h.campaignRepo.GetCampaignsByMerchant(c.Request.Context(), merchantID, limit, offset, status)
+	if err != nil {
+		logger.Error("Failed to get campaigns", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve campaigns",
+		})
+		return
+	}
+	now := time.Now().UTC()
+	for _, campaign := range campaigns {
+		campaign.Status = campaignStatus(campaign, now)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"campaigns": campaigns,
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+		},
+	})
+}
+
+// GET /api/v1/merchant/campaigns/:id (requires rewards:manage)
+// Returns the campaign with its performance, aggregated from the transaction
+// logs of the issuances it contributed to.
+func (h *CampaignHandler) GetCampaign(c *gin.Context) {
+	ctx := c.Request.Context()
+	campaign, err := h.campaignRepo.GetCampaignByID(ctx, c.Param("id"))
+	if err != nil || campaign.MerchantID != c.GetString("merchant_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CAMPAIGN_NOT_FOUND",
+			"message": "Campaign not found",
+		})
+		return
+	}
+	campaign.Status = campaignStatus(campaign, time.Now().UTC())
+
+	stats, err := h.txLogRepo.GetCampaignStats(ctx, campaign.ID)
+	if err != nil {
+		logger.Error("Failed to get campaign stats", err, map[string]interface{}{
+			"campaign_id": campaign.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve campaign stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"campaign":             campaign,
+			"stats":                stats,
+			"remaining_budget_lcn": campaign.BudgetLCN - campaign.SpentLCN,
+		},
+	})
+}
+
+// PUT /api/v1/merchant/campaigns/:id/status (requires rewards:manage)
+// Pauses, resumes or cancels a campaign. Cancelling releases its unspent budget.
+func (h *CampaignHandler) UpdateCampaignStatus(c *gin.Context) {
+	campaignID := c.Param("id")
+	var req struct {
+		Status models.CampaignStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	var from []models.CampaignStatus
+	switch req.Status {
+	case models.CampaignActive:
+		from = []models.CampaignStatus{models.CampaignPaused}
+	case models.CampaignPaused:
+		from = []models.CampaignStatus{models.CampaignActive}
+	case models.CampaignCancelled:
+		from = []models.CampaignStatus{models.CampaignActive, models.CampaignPaused, models.CampaignExhausted}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_STATUS",
+			"message": "Status must be ACTIVE, PAUSED or CANCELLED",
+		})
+		return
+	}
+
+	if err := h.campaignRepo.TransitionStatus(c.Request.Context(), campaignID, c.GetString("merchant_id"), from, req.Status); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "Campaign not found or cannot be moved to " + string(req.Status),
+		})
+		return
+	}
+
+	auditLog(c, "CAMPAIGN_STATUS_CHANGED", map[string]interface{}{
+		"campaign_id": campaignID,
+		"status":      req.Status,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"campaign_id": campaignID,
+			"status":      req.Status,
+		},
+	})
+}
+
+// campaignStatus reports active or paused campaigns outside their schedule
+// as SCHEDULED or ENDED
+func campaignStatus(campaign *models.Campaign, now time.Time) models.CampaignStatus {
+	if campaign.Status != models.CampaignActive && campaign.Status != models.CampaignPaused {
+		return campaign.Status
+	}
+	if !now.Before(campaign.EndsAt) {
+		return models.CampaignEnded
+	}
+	if campaign.Status == models.CampaignActive && now.Before(campaign.StartsAt) {
+		return models.CampaignScheduled
+	}
+	return campaign.Status
+}
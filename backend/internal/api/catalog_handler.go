@@ -0,0 +1,246 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Merchants publish the rewards customers can order with their LCN; see
+// OrderHandler for the orders
+type CatalogHandler struct {
+	catalogRepo *storage.CatalogRepository
+	userRepo    *storage.UserRepository
+}
+
+func NewCatalogHandler(catalogRepo *storage.CatalogRepository, userRepo *storage.UserRepository) *CatalogHandler {
+	return &CatalogHandler{
+		catalogRepo: catalogRepo,
+		userRepo:    userRepo,
+	}
+}
+
+type catalogItemRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description"`
+	ImageURL     string `json:"image_url" binding:"omitempty,url"`
+	PriceLCN     uint64 `json:"price_lcn" binding:"required,gt=0"`
+	Stock        int64  `json:"stock"`
+	ValidityDays int    `json:"validity_days"`
+	Active       *bool  `json:"active"` // default true
+}
+
+// POST /api/v1/merchant/catalog (requires rewards:manage)
+func (h *CatalogHandler) CreateItem(c *gin.Context) {
+	var req catalogItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	item := &models.CatalogItem{
+		MerchantID: c.GetString("merchant_id"),
+		Active:     true,
+		CreatedBy:  c.GetString("user_id"),
+	}
+	if !h.applyRequest(c, item, &req) {
+		return
+	}
+	if err := h.catalogRepo.CreateItem(c.Request.Context(), item); err != nil {
+		logger.Error("Failed to create catalog item", err, map[string]interface{}{
+			"merchant_id": item.MerchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create catalog item",
+		})
+		return
+	}
+
+	auditLog(c, "CATALOG_ITEM_CREATED", map[string]interface{}{
+		"item_id":   item.ID,
+		"name":      item.Name,
+		"price_lcn": item.PriceLCN,
+		"stock":     item.Stock,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data":   item,
+	})
+}
+
+// GET /api/v1/merchant/catalog (requires rewards:manage)
+// Lists all of the merchant's items, including inactive and sold-out ones.
+func (h *CatalogHandler) ListItems(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	items, total, err := h.catalogRepo.GetItemsByMerchant(c.Request.Context(), merchantID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get catalog items", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve catalog",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"items":  items,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// PUT /api/v1/merchant/catalog/:id (requires rewards:manage)
+// Replaces the item's details; orders already placed are not affected.
+func (h *CatalogHandler) UpdateItem(c *gin.Context) {
+	var req catalogItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	item, err := h.catalogRepo.GetItemByID(ctx, c.Param("id"))
+	if err != nil || item.MerchantID != c.GetString("merchant_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ITEM_NOT_FOUND",
+			"message": "Catalog item not found",
+		})
+		return
+	}
+	if !h.applyRequest(c, item, &req) {
+		return
+	}
+	if err := h.catalogRepo.UpdateItem(ctx, item); err != nil {
+		logger.Error("Failed to update catalog item", err, map[string]interface{}{
+			"item_id": item.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to update catalog item",
+		})
+		return
+	}
+
+	auditLog(c, "CATALOG_ITEM_UPDATED", map[string]interface{}{
+		"item_id":   item.ID,
+		"price_lcn": item.PriceLCN,
+		"stock":     item.Stock,
+		"active":    item.Active,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   item,
+	})
+}
+
+// applyRequest copies a create or update request onto the item and validates
+// it, writing the error response on failure
+func (h *CatalogHandler) applyRequest(c *gin.Context, item *models.CatalogItem, req *catalogItemRequest) bool {
+	item.Name = req.Name
+	item.Description = req.Description
+	item.ImageURL = req.ImageURL
+	item.PriceLCN = req.PriceLCN
+	item.Stock = req.Stock
+	item.ValidityDays = req.ValidityDays
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	if err := rewards.ValidateCatalogItem(item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_ITEM",
+			"message": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// A catalog item as customers browse it
+type availableItem struct {
+	*models.CatalogItem
+	BusinessName string `json:"business_name"`
+}
+
+// GET /api/v1/customer/catalog (requires wallet:read)
+// Lists the items customers can order, of all merchants or of ?merchant_id.
+func (h *CatalogHandler) ListAvailableItems(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	ctx := c.Request.Context()
+	items, total, err := h.catalogRepo.GetAvailableItems(ctx, c.Query("merchant_id"), limit, offset)
+	if err != nil {
+		logger.Error("Failed to get catalog items", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve catalog",
+		})
+		return
+	}
+
+	businessNames := map[string]string{}
+	available := make([]availableItem, 0, len(items))
+	for _, item := range items {
+		name, ok := businessNames[item.MerchantID]
+		if !ok {
+			if merchant, err := h.userRepo.GetMerchantByID(ctx, item.MerchantID); err == nil {
+				name = merchant.BusinessName
+			}
+			businessNames[item.MerchantID] = name
+		}
+		available = append(available, availableItem{CatalogItem: item, BusinessName: name})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"items":  available,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/coalitions"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Admins group merchants into coalitions whose redemptions clear between
+// members; members see their net position and the nettings that settle it
+type CoalitionHandler struct {
+	coalitionService *coalitions.Service
+	coalitionRepo    *storage.CoalitionRepository
+}
+
+func NewCoalitionHandler(coalitionService *coalitions.Service, coalitionRepo *storage.CoalitionRepository) *CoalitionHandler {
+	return &CoalitionHandler{
+		coalitionService: coalitionService,
+		coalitionRepo:    coalitionRepo,
+	}
+}
+
+// POST /api/v1/admin/coalitions (requires coalitions:manage)
+// Creates a coalition of merchants with its clearing rate and netting
+// period. A merchant belongs to one coalition at most.
+func (h *CoalitionHandler) CreateCoalition(c *gin.Context) {
+	var req coalitions.Request
+	if !bindCoalitionRequest(c, &req) {
+		return
+	}
+
+	coalition, err := h.coalitionService.Create(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+
+	auditLog(c, "COALITION_CREATED", map[string]interface{}{
+		"coalition_id": coalition.ID,
+		"merchant_ids": coalition.MerchantIDs,
+		"rate_bps":     coalition.Rules.RateBPS,
+		"period_days":  coalition.Rules.NettingPeriodDays,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data":   coalition,
+	})
+}
+
+// PUT /api/v1/admin/coalitions/:id (requires coalitions:manage)
+// Replaces a coalition's name, members and clearing rules. Entries already
+// recorded keep their rate; a new period applies from the next netting.
+func (h *CoalitionHandler) UpdateCoalition(c *gin.Context) {
+	var req coalitions.Request
+	if !bindCoalitionRequest(c, &req) {
+		return
+	}
+
+	coalition, err := h.coalitionService.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+
+	auditLog(c, "COALITION_UPDATED", map[string]interface{}{
+		"coalition_id": coalition.ID,
+		"merchant_ids": coalition.MerchantIDs,
+		"rate_bps":     coalition.Rules.RateBPS,
+		"period_days":  coalition.Rules.NettingPeriodDays,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   coalition,
+	})
+}
+
+// GET /api/v1/admin/coalitions (requires coalitions:manage)
+func (h *CoalitionHandler) ListCoalitions(c *gin.Context) {
+	limit, offset := coalitionPage(c)
+	list, total, err := h.coalitionRepo.ListCoalitions(c.Request.Context(), limit, offset)
+	if err != nil {
+		logger.Error("Failed to list coalitions", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve coalitions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"coalitions": list,
+			"total":      total,
+			"limit":      limit,
+			"offset":     offset,
+		},
+	})
+}
+
+// GET /api/v1/admin/coalitions/:id/positions (requires coalitions:manage)
+// Reports every member's net position over the entries not netted yet;
+// positive net_lcn is owed to the member.
+func (h *CoalitionHandler) GetCoalitionPositions(c *gin.Context) {
+	ctx := c.Request.Context()
+	coalition, err := h.coalitionRepo.GetCoalitionByID(ctx, c.Param("id"))
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	positions, err := h.coalitionService.OpenPositions(ctx, coalition)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"coalition": coalition,
+			"positions": positions,
+		},
+	})
+}
+
+// GET /api/v1/admin/coalitions/:id/nettings (requires coalitions:manage)
+func (h *CoalitionHandler) ListCoalitionNettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	coalition, err := h.coalitionRepo.GetCoalitionByID(ctx, c.Param("id"))
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	h.listNettings(c, coalition, "")
+}
+
+// POST /api/v1/admin/coalitions/:id/net (requires coalitions:manage)
+// Nets the coalition's open entries now instead of at the end of the period,
+// requesting a clearing settlement for each payment; the next netting is one
+// period away. The netting is null when nothing was open.
+func (h *CoalitionHandler) NetCoalition(c *gin.Context) {
+	ctx := c.Request.Context()
+	coalition, err := h.coalitionRepo.GetCoalitionByID(ctx, c.Param("id"))
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	netting, err := h.coalitionService.Net(ctx, coalition)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+
+	if netting != nil {
+		auditLog(c, "COALITION_NETTED", map[string]interface{}{
+			"coalition_id": coalition.ID,
+			"netting_id":   netting.ID,
+			"entries":      netting.Entries,
+			"gross_lcn":    netting.GrossLCN,
+			"payments":     len(netting.Payments),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"netting": netting,
+		},
+	})
+}
+
+// GET /api/v1/merchant/coalition (requires settlement:request)
+// Reports the merchant's coalition and its net position over the entries
+// not netted yet, by counterparty; positive net_lcn is owed to the merchant.
+func (h *CoalitionHandler) GetMerchantCoalition(c *gin.Context) {
+	ctx := c.Request.Context()
+	merchantID := c.GetString("merchant_id")
+	coalition, err := h.coalitionRepo.GetCoalitionByMerchant(ctx, merchantID)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	position, counterparties, err := h.coalitionService.MerchantPosition(ctx, coalition, merchantID)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"coalition": gin.H{
+				"id":              coalition.ID,
+				"name":            coalition.Name,
+				"members":         len(coalition.MerchantIDs),
+				"rules":           coalition.Rules,
+				"next_netting_at": coalition.NextNettingAt,
+				"last_netted_at":  coalition.LastNettedAt,
+			},
+			"position":       position,
+			"counterparties": counterparties,
+		},
+	})
+}
+
+// GET /api/v1/merchant/coalition/entries (requires settlement:request)
+// Lists the clearing entries the merchant owes or is owed, optionally by
+// ?status (OPEN or NETTED).
+func (h *CoalitionHandler) ListMerchantEntries(c *gin.Context) {
+	ctx := c.Request.Context()
+	merchantID := c.GetString("merchant_id")
+	coalition, err := h.coalitionRepo.GetCoalitionByMerchant(ctx, merchantID)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	var status *models.ClearingStatus
+	if statusFilter := c.Query("status"); statusFilter != "" {
+		s := models.ClearingStatus(statusFilter)
+		status = &s
+	}
+
+	limit, offset := coalitionPage(c)
+	entries, total, err := h.coalitionRepo.GetMerchantEntries(ctx, coalition.ID, merchantID, status, limit, offset)
+	if err != nil {
+		logger.Error("Failed to list clearing entries", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve clearing entries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"entries": entries,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
+
+// GET /api/v1/merchant/coalition/nettings (requires settlement:request)
+// Lists the nettings of the merchant's coalition with the merchant's own
+// position and the payments it makes or receives.
+func (h *CoalitionHandler) ListMerchantNettings(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	coalition, err := h.coalitionRepo.GetCoalitionByMerchant(c.Request.Context(), merchantID)
+	if err != nil {
+		coalitionError(c, err)
+		return
+	}
+	h.listNettings(c, coalition, merchantID)
+}
+
+// listNettings lists a coalition's nettings; for a member, only its own
+// position and payments are shown
+func (h *CoalitionHandler) listNettings(c *gin.Context, coalition *models.Coalition, merchantID string) {
+	limit, offset := coalitionPage(c)
+	nettings, total, err := h.coalitionRepo.GetNettings(c.Request.Context(), coalition.ID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to list nettings", err, map[string]interface{}{
+			"coalition_id": coalition.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve nettings",
+		})
+		return
+	}
+
+	if merchantID != "" {
+		for _, netting := range nettings {
+			positions := []models.NetPosition{}
+			for _, position := range netting.Positions {
+				if position.MerchantID == merchantID {
+					positions = append(positions, position)
+				}
+			}
+			payments := []models.ClearingPayment{}
+			for _, payment := range netting.Payments {
+				if payment.PayerID == merchantID || payment.PayeeID == merchantID {
+					payments = append(payments, payment)
+				}
+			}
+			netting.Positions, netting.Payments = positions, payments
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"nettings": nettings,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+		},
+	})
+}
+
+func bindCoalitionRequest(c *gin.Context, req *coalitions.Request) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_COALITION",
+			"message": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func coalitionPage(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	return limit, offset
+}
+
+// coalitionError maps an error of a coalition operation to its response
+func coalitionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrCoalitionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_COALITION_NOT_FOUND",
+			"message": "Coalition not found",
+		})
+	case errors.Is(err, storage.ErrMerchantInCoalition):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_MERCHANT_IN_COALITION",
+			"message": "A merchant already belongs to another coalition",
+		})
+	case errors.Is(err, coalitions.ErrUnknownMerchant):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_UNKNOWN_MERCHANT",
+			"message": err.Error(),
+		})
+	case errors.Is(err, coalitions.ErrNettingClaimed):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_NETTING_IN_PROGRESS",
+			"message": "The coalition is being netted; try again shortly",
+		})
+	default:
+		logger.Error("Coalition operation failed", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to process coalition request",
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// CIP-1852 account path of customer HD wallets, shown with the recovery phrase
+const hdAccountPath = "m/1852'/1815'/0'"
+
+// How long a customer has to sign an external wallet challenge
+const walletChallengeTTL = 10 * time.Minute
+
+type CustomerHandler struct {
+	userRepo          *storage.UserRepository
+	cardanoService    *cardano.CardanoService
+	jwtService        *auth.JWTService
+	network           string
+	governanceAddress string
+}
+
+func NewCustomerHandler(
+	userRepo *storage.UserRepository,
+	cardanoService *cardano.CardanoService,
+	jwtService *auth.JWTService,
+	network string,
+	governanceAddress string,
+) *CustomerHandler {
+	return &CustomerHandler{
+		userRepo:          userRepo,
+		cardanoService:    cardanoService,
+		jwtService:        jwtService,
+		network:           network,
+		governanceAddress: governanceAddress,
+	}
+}
+
+// POST /api/v1/customer/wallet/recovery-phrase
+// Returns the wallet's recovery phrase once, after re-authentication, so the
+// customer can restore the wallet in Eternl, Lace or another Cardano wallet.
+func (h *CustomerHandler) ExportRecoveryPhrase(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customer, err := h.userRepo.GetCustomerByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+
+	if !auth.CheckPasswordHash(req.Password, customer.PasswordHash) {
+		auditLog(c, "RECOVERY_PHRASE_EXPORT_DENIED", map[string]interface{}{
+			"reason": "invalid password",
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_INVALID_CREDENTIALS",
+			"message": "Invalid password",
+		})
+		return
+	}
+
+	if customer.Wallet.RecoveryPhraseExportedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_EXPORTED",
+			"message": "Recovery phrase was already exported",
+		})
+		return
+	}
+
+	walletService := c.MustGet("wallet_service").(*crypto.WalletService)
+	mnemonic, err := walletService.ExportMnemonic(walletKey(customer.ID, customer.Wallet))
+	if customer.Wallet.Custody == models.WalletExternal || errors.Is(err, crypto.ErrNoRecoveryPhrase) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_NO_RECOVERY_PHRASE",
+			"message": "This wallet was created without a recovery phrase",
+		})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to export recovery phrase", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to export recovery phrase",
+		})
+		return
+	}
+
+	// Claim the single export before returning the phrase
+	claimed, err := h.userRepo.MarkRecoveryPhraseExported(ctx, customer.ID)
+	if err != nil {
+		logger.Error("Failed to record recovery phrase export", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to export recovery phrase",
+		})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_EXPORTED",
+			"message": "Recovery phrase was already exported",
+		})
+		return
+	}
+
+	auditLog(c, "RECOVERY_PHRASE_EXPORTED", map[string]interface{}{
+		"wallet_address": customer.Wallet.Address,
+	})
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"recovery_phrase": mnemonic,
+			"derivation_path": hdAccountPath,
+			"wallet_address":  customer.Wallet.Address,
+			"message":         "Write the phrase down and keep it offline. It will not be shown again.",
+		},
+	})
+}
+
+// POST /api/v1/customer/wallet/external/challenge
+// Issues the message the customer's CIP-30 wallet signs (signData) to prove
+// it controls the address they are about to register.
+func (h *CustomerHandler) CreateWalletChallenge(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Address string `json:"address" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address, ok := h.parseExternalAddress(c, req.Address)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if !h.externalAddressAllowed(c, ctx, address.Bech32) {
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		logger.Error("Failed to generate wallet challenge", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create challenge",
+		})
+		return
+	}
+	expiresAt := time.Now().UTC().Add(walletChallengeTTL)
+	challenge := &models.WalletChallenge{
+		Address: address.Bech32,
+		Message: fmt.Sprintf("Link wallet %s to LoyalCoin account %s.\nNonce: %s\nExpires: %s",
+			address.Bech32, userID, hex.EncodeToString(nonce), expiresAt.Format(time.RFC3339)),
+		ExpiresAt: expiresAt,
+	}
+	if err := h.userRepo.SetWalletChallenge(ctx, userID, challenge); err != nil {
+		logger.Error("Failed to store wallet challenge", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create challenge",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"address":     challenge.Address,
+			"message":     challenge.Message,
+			"payload_hex": hex.EncodeToString([]byte(challenge.Message)),
+			"expires_at":  challenge.ExpiresAt,
+		},
+	})
+}
+
+// PUT /api/v1/customer/wallet/external
+// Switches the customer to a non-custodial wallet: rewards are received at the
+// address of their own CIP-30 wallet and redemptions are signed client-side.
+// The wallet proves it controls the address by signing the challenge from
+// POST /customer/wallet/external/challenge with the address's payment key.
+// The custodial key is deleted, so the custodial wallet must be empty unless
+// it is the same wallet (an HD wallet restored from its recovery phrase).
+func (h *CustomerHandler) RegisterExternalWallet(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Address   string `json:"address" binding:"required"`
+		Password  string `json:"password" binding:"required"`
+		Signature string `json:"signature" binding:"required"` // COSE_Sign1 from signData (hex)
+		Key       string `json:"key" binding:"required"`       // COSE_Key from signData (hex)
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address, ok := h.parseExternalAddress(c, req.Address)
+	if !ok {
+		return
+	}
+	keyHash, _ := address.PaymentKeyHash()
+	req.Address = address.Bech32
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	customer, err := h.userRepo.GetCustomerByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+
+	if !auth.CheckPasswordHash(req.Password, customer.PasswordHash) {
+		auditLog(c, "EXTERNAL_WALLET_REGISTRATION_DENIED", map[string]interface{}{
+			"reason": "invalid password",
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_INVALID_CREDENTIALS",
+			"message": "Invalid password",
+		})
+		return
+	}
+
+	if !h.externalAddressAllowed(c, ctx, req.Address) {
+		return
+	}
+	challenge := customer.WalletChallenge
+	if challenge == nil || challenge.Address != req.Address || time.Now().After(challenge.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_CHALLENGE_REQUIRED",
+			"message": "Request a wallet challenge for this address and sign it first",
+		})
+		return
+	}
+	if sigErr := verifyWalletSignature(address, challenge.Message, req.Signature, req.Key); sigErr != nil {
+		auditLog(c, "EXTERNAL_WALLET_REGISTRATION_DENIED", map[string]interface{}{
+			"reason":         "invalid wallet signature",
+			"wallet_address": req.Address,
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_SIGNATURE",
+			"message": "Wallet signature does not prove control of this address: " + sigErr.Error(),
+		})
+		return
+	}
+	claimed, err := h.userRepo.ClaimWalletChallenge(ctx, customer.ID, challenge.Message)
+	if err != nil || !claimed {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CHALLENGE_USED",
+			"message": "The wallet challenge was already used or has expired; request a new one",
+		})
+		return
+	}
+
+	if customer.Wallet.Custody == models.WalletCustodial && customer.Wallet.Address != req.Address {
+		balance, err := h.cardanoService.GetBalance(customer.Wallet.Address)
+		if err != nil {
+			logger.Error("Failed to get customer balance", err, map[string]interface{}{
+				"customer_id": customer.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_BALANCE_CHECK_FAILED",
+				"message": "Failed to verify balance",
+			})
+			return
+		}
+		if balance.Lovelace > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_CUSTODIAL_BALANCE",
+				"message": "Custodial wallet is not empty; spend its balance or restore it from the recovery phrase and register that address",
+				"data": gin.H{
+					"available": balance.LCN,
+				},
+			})
+			return
+		}
+	}
+
+	previousAddress := customer.Wallet.Address
+	customer.Wallet = models.Wallet{
+		Address:        req.Address,
+		Custody:        models.WalletExternal,
+		PaymentKeyHash: hex.EncodeToString(keyHash),
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := h.userRepo.UpdateCustomer(ctx, customer); err != nil {
+		logger.Error("Failed to register external wallet", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "Wallet address is already registered",
+		})
+		return
+	}
+
+	auditLog(c, "EXTERNAL_WALLET_REGISTERED", map[string]interface{}{
+		"wallet_address":   req.Address,
+		"previous_address": previousAddress,
+	})
+
+	// The session carries the wallet address, so issue one for the new address
+	token, err := h.jwtService.GenerateToken(customer.ID, models.RoleCustomer, customer.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to generate JWT", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TOKEN_GENERATION_FAILED",
+			"message": "Wallet registered but login failed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"token":            token,
+			"wallet_address":   customer.Wallet.Address,
+			"custody":          customer.Wallet.Custody,
+			"payment_key_hash": customer.Wallet.PaymentKeyHash,
+		},
+	})
+}
+
+// verifyWalletSignature checks a hex-encoded CIP-30 signData result over message
+func verifyWalletSignature(address *crypto.Address, message, signatureHex, keyHex string) error {
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("signature is not hex")
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return fmt.Errorf("key is not hex")
+	}
+	return crypto.VerifyDataSignature(address, []byte(message), signature, key)
+}
+
+// parseExternalAddress accepts key-controlled payment addresses on the
+// platform network
+func (h *CustomerHandler) parseExternalAddress(c *gin.Context, raw string) (*crypto.Address, bool) {
+	address, ok := parseAddress(c, "address", raw, h.network)
+	if !ok {
+		return nil, false
+	}
+	if _, err := address.PaymentKeyHash(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_ADDRESS",
+			"message": "Invalid address: " + err.Error(),
+		})
+		return nil, false
+	}
+	return address, true
+}
+
+// externalAddressAllowed refuses the governance wallet and addresses of
+// merchant accounts: services resolve a registered address to the customer
+func (h *CustomerHandler) externalAddressAllowed(c *gin.Context, ctx context.Context, address string) bool {
+	_, err := h.userRepo.GetMerchantByWalletAddress(ctx, address)
+	if err == nil || address == h.governanceAddress {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ADDRESS_NOT_ALLOWED",
+			"message": "This address belongs to a platform or merchant wallet",
+		})
+		return false
+	}
+	return true
+}
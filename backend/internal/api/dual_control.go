@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Dual-control (four-eyes) policy for admin approvals. Requests above a
+// threshold are only executed once RequiredApprovals admins other than the
+// initiating admin have approved them. A zero threshold disables dual control.
+type DualControlPolicy struct {
+	AllocationThresholdLCN uint64
+	SettlementThresholdLCN uint64
+	RequiredApprovals      int
+}
+
+func (p DualControlPolicy) applies(amountLCN, thresholdLCN uint64) bool {
+	return thresholdLCN > 0 && amountLCN > thresholdLCN && p.RequiredApprovals > 0
+}
+
+// State and storage operations of one dual-controlled request
+type approvalTarget struct {
+	event      string // audit event prefix, e.g. ALLOCATION
+	idField    string // response field holding the request ID
+	id         string
+	initiation *models.Approval
+	approvals  []models.Approval
+	required   int
+
+	initiate func(ctx context.Context, adminID, notes string, required int) (bool, error)
+	approve  func(ctx context.Context, adminID, notes string) ([]models.Approval, error)
+	reload   func(ctx context.Context) (initiation *models.Approval, approvals []models.Approval, required int, err error)
+}
+
+// collectApproval records the calling admin's step in the dual-control workflow:
+// the first admin initiates, later admins approve. Returns true once enough
+// approvals exist for the transfer to go ahead; otherwise a response has been written.
+func (h *AdminHandler) collectApproval(c *gin.Context, t approvalTarget, adminID, notes string) bool {
+	ctx := c.Request.Context()
+
+	// Fully approved already (e.g. retry after a failed transfer)
+	if t.initiation != nil && len(t.approvals) >= t.required {
+		return true
+	}
+
+	if t.initiation == nil {
+		initiated, err := t.initiate(ctx, adminID, notes, h.dualControl.RequiredApprovals)
+		if err != nil {
+			logger.Error("Failed to initiate dual-control approval", err, map[string]interface{}{
+				t.idField: t.id,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_UPDATE_FAILED",
+				"message": "Failed to record approval",
+			})
+			return false
+		}
+		if initiated {
+			auditLog(c, t.event+"_APPROVAL_INITIATED", map[string]interface{}{
+				t.idField:            t.id,
+				"required_approvals": h.dualControl.RequiredApprovals,
+				"notes":              notes,
+			})
+			respondAwaitingApprovals(c, t.idField, t.id, &models.Approval{AdminID: adminID, Notes: notes}, nil, h.dualControl.RequiredApprovals)
+			return false
+		}
+
+		// Another admin initiated it concurrently; treat this call as an approval
+		t.initiation, t.approvals, t.required, err = t.reload(ctx)
+		if err != nil || t.initiation == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_INVALID_STATUS",
+				"message": "Request is no longer pending",
+			})
+			return false
+		}
+	}
+
+	if t.initiation.AdminID == adminID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"code":    "403_INITIATOR_CANNOT_APPROVE",
+			"message": "The initiating admin cannot approve this request",
+		})
+		return false
+	}
+	for _, approval := range t.approvals {
+		if approval.AdminID == adminID {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_ALREADY_APPROVED",
+				"message": "You have already approved this request",
+			})
+			return false
+		}
+	}
+
+	approvals, err := t.approve(ctx, adminID, notes)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_APPROVAL_NOT_RECORDED",
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	auditLog(c, t.event+"_APPROVAL_RECORDED", map[string]interface{}{
+		t.idField:            t.id,
+		"approvals_received": len(approvals),
+		"required_approvals": t.required,
+		"notes":              notes,
+	})
+
+	if len(approvals) < t.required {
+		respondAwaitingApprovals(c, t.idField, t.id, t.initiation, approvals, t.required)
+		return false
+	}
+	return true
+}
+
+func respondAwaitingApprovals(c *gin.Context, idField, id string, initiation *models.Approval, approvals []models.Approval, required int) {
+	if approvals == nil {
+		approvals = []models.Approval{}
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			idField:              id,
+			"status":             "AWAITING_APPROVALS",
+			"initiated_by":       initiation.AdminID,
+			"approvals":          approvals,
+			"approvals_received": len(approvals),
+			"approvals_required": required,
+			"message":            "Approval recorded. The transfer runs once all required admins have approved.",
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// approvalRecord mirrors the conditional updates of storage.initiateApproval
+// and storage.addApproval on a single pending request
+type approvalRecord struct {
+	initiation *models.Approval
+	approvals  []models.Approval
+	required   int
+}
+
+func (r *approvalRecord) target() approvalTarget {
+	return approvalTarget{
+		event:      "ALLOCATION",
+		idField:    "purchase_id",
+		id:         "p1",
+		initiation: r.initiation,
+		approvals:  r.approvals,
+		required:   r.required,
+		initiate: func(ctx context.Context, adminID, notes string, required int) (bool, error) {
+			if r.initiation != nil {
+				return false, nil
+			}
+			r.initiation = &models.Approval{AdminID: adminID, Notes: notes}
+			r.approvals = []models.Approval{}
+			r.required = required
+			return true, nil
+		},
+		approve: func(ctx context.Context, adminID, notes string) ([]models.Approval, error) {
+			if r.initiation == nil || r.initiation.AdminID == adminID {
+				return nil, fmt.Errorf("approval not accepted")
+			}
+			for _, approval := range r.approvals {
+				if approval.AdminID == adminID {
+					return nil, fmt.Errorf("approval not accepted")
+				}
+			}
+			r.approvals = append(r.approvals, models.Approval{AdminID: adminID, Notes: notes})
+			return r.approvals, nil
+		},
+		reload: func(ctx context.Context) (*models.Approval, []models.Approval, int, error) {
+			return r.initiation, r.approvals, r.required, nil
+		},
+	}
+}
+
+func TestCollectApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h := &AdminHandler{dualControl: DualControlPolicy{AllocationThresholdLCN: 1000, RequiredApprovals: 2}}
+
+	// Each call loads the request afresh, as ApproveAllocation does
+	collect := func(record *approvalRecord, adminID string) (bool, int) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Set("user_id", adminID)
+		ok := h.collectApproval(c, record.target(), adminID, "")
+		return ok, w.Code
+	}
+
+	record := &approvalRecord{}
+
+	// The first admin initiates; nothing is executed yet
+	ok, code := collect(record, "admin-1")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "admin-1", record.initiation.AdminID)
+	assert.Equal(t, 2, record.required)
+
+	// The initiator cannot approve their own request
+	ok, code = collect(record, "admin-1")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// A first approval is recorded but is not enough
+	ok, code = collect(record, "admin-2")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Len(t, record.approvals, 1)
+
+	// The same admin cannot approve twice
+	ok, code = collect(record, "admin-2")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Len(t, record.approvals, 1)
+
+	// The last required approval lets the transfer go ahead
+	ok, _ = collect(record, "admin-3")
+	assert.True(t, ok)
+	assert.Len(t, record.approvals, 2)
+
+	// The transfer failed and the request went back to pending with its
+	// approvals: any admin may retry it, including the initiator and approvers
+	for _, adminID := range []string{"admin-1", "admin-2", "admin-3", "admin-4"} {
+		ok, _ = collect(record, adminID)
+		assert.True(t, ok, adminID)
+	}
+	assert.Len(t, record.approvals, 2)
+}
+
+func TestCollectApproval_ConcurrentInitiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h := &AdminHandler{dualControl: DualControlPolicy{AllocationThresholdLCN: 1000, RequiredApprovals: 1}}
+
+	// Another admin initiated after this request was loaded: the call counts
+	// as an approval
+	record := &approvalRecord{}
+	stale := record.target()
+	record.initiation = &models.Approval{AdminID: "admin-1"}
+	record.approvals = []models.Approval{}
+	record.required = 1
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	assert.True(t, h.collectApproval(c, stale, "admin-2", ""))
+	assert.Len(t, record.approvals, 1)
+}
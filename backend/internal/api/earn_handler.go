@@ -0,0 +1,605 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/internal/tiers"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Earn rules let a merchant reward purchases automatically: the point of sale
+// reports the purchase to POST /lcn/earn and the merchant's current rule
+// decides how much LCN the customer gets, plus any running campaign bonuses.
+type EarnHandler struct {
+	cardanoService earnWallet
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	earnRuleRepo   *storage.EarnRuleRepository
+	campaignRepo   campaignBudgets
+	earnLimitRepo  dailyEarnings
+	tierService    *tiers.Service
+	network        string
+	exchangeRate   float64 // LCN to ETB exchange rate
+}
+
+// earnWallet is the part of the Cardano service Earn pays rewards with
+type earnWallet interface {
+	GetBalance(address string) (*cardano.Balance, error)
+	TransferADAWithMeta(from crypto.WalletKey, toAddress string, amountLCN uint64, txType models.TxType, meta map[string]interface{}) (string, error)
+}
+
+// dailyEarnings is the store Earn counts rewards against the daily cap in
+type dailyEarnings interface {
+	GetDailyEarned(ctx context.Context, merchantAddress, customerAddress string, day time.Time) (uint64, error)
+	ReserveDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN, capLCN uint64) (uint64, error)
+	ReleaseDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN uint64) error
+}
+
+// campaignBudgets is the part of the campaign store Earn reserves bonuses from
+type campaignBudgets interface {
+	GetLiveCampaigns(ctx context.Context, merchantID string, now time.Time) ([]*models.Campaign, error)
+	ReserveBudget(ctx context.Context, id string, amount uint64) (uint64, error)
+	ReleaseBudget(ctx context.Context, id string, amount uint64) error
+}
+
+func NewEarnHandler(
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	earnRuleRepo *storage.EarnRuleRepository,
+	campaignRepo *storage.CampaignRepository,
+	earnLimitRepo *storage.EarnLimitRepository,
+	tierService *tiers.Service,
+	network string,
+	exchangeRate float64,
+) *EarnHandler {
+	return &EarnHandler{
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		earnRuleRepo:   earnRuleRepo,
+		campaignRepo:   campaignRepo,
+		earnLimitRepo:  earnLimitRepo,
+		tierService:    tierService,
+		network:        network,
+		exchangeRate:   exchangeRate,
+	}
+}
+
+// GET /api/v1/merchant/earn-rule (requires rewards:manage)
+func (h *EarnHandler) GetEarnRule(c *gin.Context) {
+	rule, err := h.earnRuleRepo.GetCurrentEarnRule(c.Request.Context(), c.GetString("merchant_id"))
+	if err != nil {
+		if errors.Is(err, storage.ErrEarnRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"code":    "404_EARN_RULE_NOT_FOUND",
+				"message": "No earn rule has been set up yet",
+			})
+			return
+		}
+		logger.Error("Failed to get earn rule", err, map[string]interface{}{
+			"merchant_id": c.GetString("merchant_id"),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve earn rule",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   rule,
+	})
+}
+
+// PUT /api/v1/merchant/earn-rule (requires rewards:manage)
+// Saves the rule as a new version; earlier versions stay for the record.
+func (h *EarnHandler) SaveEarnRule(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	var req struct {
+		Enabled             *bool              `json:"enabled"`
+		PercentOfSpend      float64            `json:"percent_of_spend"`
+		FixedPerVisit       uint64             `json:"fixed_per_visit"`
+		CategoryMultipliers map[string]float64 `json:"category_multipliers"`
+		MinSpendETB         float64            `json:"min_spend_etb"`
+		DailyCapLCN         uint64             `json:"daily_cap_lcn"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule := &models.EarnRule{
+		MerchantID:          merchantID,
+		Enabled:             req.Enabled == nil || *req.Enabled,
+		PercentOfSpend:      req.PercentOfSpend,
+		FixedPerVisit:       req.FixedPerVisit,
+		CategoryMultipliers: req.CategoryMultipliers,
+		MinSpendETB:         req.MinSpendETB,
+		DailyCapLCN:         req.DailyCapLCN,
+		CreatedBy:           c.GetString("user_id"),
+	}
+	if err := rewards.ValidateEarnRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_EARN_RULE",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.earnRuleRepo.CreateEarnRuleVersion(c.Request.Context(), rule); err != nil {
+		logger.Error("Failed to save earn rule", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to save earn rule",
+		})
+		return
+	}
+
+	auditLog(c, "EARN_RULE_UPDATED", map[string]interface{}{
+		"earn_rule_id": rule.ID,
+		"version":      rule.Version,
+		"enabled":      rule.Enabled,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   rule,
+	})
+}
+
+// GET /api/v1/merchant/earn-rule/versions (requires rewards:manage)
+func (h *EarnHandler) ListEarnRuleVersions(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	rules, total, err := h.earnRuleRepo.GetEarnRuleVersions(c.Request.Context(), merchantID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get earn rule versions", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve earn rule versions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"earn_rules": rules,
+			"total":      total,
+			"limit":      limit,
+			"offset":     offset,
+		},
+	})
+}
+
+// POST /api/v1/lcn/earn (requires lcn:issue)
+// Computes the reward for a purchase with the merchant's current earn rule, the
+// customer's tier multiplier and running campaigns, and issues it. The
+// issuance's transaction log records the rule version, the tier and the
+// campaigns that contributed.
+func (h *EarnHandler) Earn(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"code":    "403_MERCHANT_ONLY",
+			"message": "Only merchants can issue LCN",
+		})
+		return
+	}
+	var req struct {
+		CustomerAddress string  `json:"customer_address" binding:"required_without=Customer"`
+		Customer        string  `json:"customer" binding:"required_without=CustomerAddress"` // email, phone, username or QR handle
+		AmountETB       float64 `json:"amount_etb" binding:"required,gt=0"`
+		Items           []struct {
+			Category  string  `json:"category"`
+			AmountETB float64 `json:"amount_etb"`
+		} `json:"items"`
+		Reference string `json:"reference"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	purchase := rewards.Purchase{AmountETB: req.AmountETB}
+	for _, item := range req.Items {
+		purchase.Items = append(purchase.Items, rewards.PurchaseItem{Category: item.Category, AmountETB: item.AmountETB})
+	}
+	if err := rewards.ValidatePurchase(purchase); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_PURCHASE",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var customer *models.Customer
+	if req.CustomerAddress == "" {
+		identifier, found, ok := resolveCustomer(c, h.userRepo, req.Customer)
+		if !ok {
+			return
+		}
+		customer = found
+		if customer == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"code":    "404_CUSTOMER_NOT_FOUND",
+				"message": "No customer with this " + identifier.Kind,
+			})
+			return
+		}
+		req.CustomerAddress = customer.Wallet.Address
+	} else {
+		customerAddress, ok := parseAddress(c, "customer_address", req.CustomerAddress, h.network)
+		if !ok {
+			return
+		}
+		req.CustomerAddress = customerAddress.Bech32
+		// Addresses without an account earn at the lowest tier
+		found, err := h.userRepo.GetCustomerByWalletAddress(ctx, req.CustomerAddress)
+		if err != nil && !errors.Is(err, storage.ErrCustomerNotFound) {
+			logger.Error("Failed to get customer", err, nil)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to look up customer",
+			})
+			return
+		}
+		customer = found
+	}
+
+	merchant, err := h.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+
+	rule, err := h.earnRuleRepo.GetCurrentEarnRule(ctx, merchantID)
+	if err != nil {
+		if errors.Is(err, storage.ErrEarnRuleNotFound) {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"code":    "409_NO_EARN_RULE",
+				"message": "Set up an earn rule before rewarding purchases",
+			})
+			return
+		}
+		logger.Error("Failed to get earn rule", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to load earn rule",
+		})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var earnedToday uint64
+	if rule.DailyCapLCN > 0 {
+		earnedToday, err = h.earnLimitRepo.GetDailyEarned(ctx, merchant.Wallet.Address, req.CustomerAddress, today)
+		if err != nil {
+			logger.Error("Failed to sum earned LCN", err, map[string]interface{}{
+				"merchant_id": merchantID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to check the daily cap",
+			})
+			return
+		}
+	}
+
+	tier := h.tierService.Tier(nil)
+	if customer != nil {
+		loyalty, err := h.tierService.Loyalty(ctx, customer)
+		if err != nil {
+			logger.Error("Failed to get customer tier", err, map[string]interface{}{
+				"customer_id": customer.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to get customer tier",
+			})
+			return
+		}
+		tier = h.tierService.Tier(loyalty)
+	}
+
+	result := rewards.ComputeEarn(rule, purchase, h.exchangeRate, earnedToday, tier.EarnMultiplier)
+	if result.AmountLCN > 0 {
+		// Purchases rewarded since earnedToday was read may have used up the
+		// cap; the reservation settles how much is left
+		reserved, err := h.earnLimitRepo.ReserveDailyEarn(ctx, merchant.Wallet.Address, req.CustomerAddress, today, result.AmountLCN, rule.DailyCapLCN)
+		if err != nil {
+			logger.Error("Failed to reserve daily earnings", err, map[string]interface{}{
+				"merchant_id": merchantID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to check the daily cap",
+			})
+			return
+		}
+		if reserved < result.AmountLCN {
+			result.AmountLCN = reserved
+			result.CapReached = true
+		}
+	}
+	reward := &earnReward{customerAddress: req.CustomerAddress, day: today, baseLCN: result.AmountLCN}
+
+	awards, ok := h.applyCampaigns(c, merchant, req.CustomerAddress, purchase, result.AmountLCN)
+	if !ok {
+		h.releaseReward(merchant, reward)
+		return
+	}
+	reward.awards = awards
+	var campaignLCN uint64
+	campaignsMeta := []map[string]interface{}{}
+	for _, award := range awards {
+		campaignLCN += award.BonusLCN
+		campaignsMeta = append(campaignsMeta, map[string]interface{}{
+			"campaign_id": award.Campaign.ID,
+			"name":        award.Campaign.Name,
+			"bonus_lcn":   award.BonusLCN,
+		})
+	}
+	amountLCN := result.AmountLCN + campaignLCN
+	earned := gin.H{
+		"amount_lcn":        amountLCN,
+		"base_lcn":          result.AmountLCN,
+		"uncapped_lcn":      result.UncappedLCN,
+		"below_min_spend":   result.BelowMinSpend,
+		"cap_reached":       result.CapReached,
+		"tier":              tier.Name,
+		"tier_multiplier":   tier.EarnMultiplier,
+		"tier_lcn":          result.TierLCN,
+		"campaign_lcn":      campaignLCN,
+		"campaigns":         campaignsMeta,
+		"earn_rule_id":      rule.ID,
+		"earn_rule_version": rule.Version,
+	}
+	if amountLCN == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+			"data":   earned,
+		})
+		return
+	}
+
+	// Recorded with the issuance, so the indexer can uncount base_lcn from
+	// the daily cap if the transaction fails
+	reward.meta = map[string]interface{}{
+		"merchant_id":         merchantID,
+		"earn_rule_id":        rule.ID,
+		"earn_rule_version":   rule.Version,
+		"purchase_amount_etb": req.AmountETB,
+		"reference":           req.Reference,
+		"base_lcn":            result.AmountLCN,
+		"tier":                tier.Name,
+		"tier_lcn":            result.TierLCN,
+		"campaigns":           campaignsMeta,
+		"campaign_lcn":        campaignLCN,
+	}
+	txHash, remaining, ok := h.sendEarned(c, merchant, reward)
+	if !ok {
+		return
+	}
+
+	auditLog(c, "LCN_EARNED", map[string]interface{}{
+		"customer_address":  req.CustomerAddress,
+		"amount_lcn":        amountLCN,
+		"campaign_lcn":      campaignLCN,
+		"earn_rule_version": rule.Version,
+		"tx_hash":           txHash,
+	})
+	earned["tx_hash"] = txHash
+	earned["remaining_balance"] = remaining
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   earned,
+	})
+}
+
+// earnReward is a reward ready to be sent: its base, counted against the
+// daily cap, and the campaign bonuses reserved for it
+type earnReward struct {
+	customerAddress string
+	day             time.Time
+	baseLCN         uint64
+	awards          []rewards.CampaignAward
+	meta            map[string]interface{}
+}
+
+func (r *earnReward) amountLCN() uint64 {
+	amountLCN := r.baseLCN
+	for _, award := range r.awards {
+		amountLCN += award.BonusLCN
+	}
+	return amountLCN
+}
+
+// sendEarned transfers earned LCN from the merchant's wallet and returns the
+// transaction hash and the merchant's remaining balance. What was reserved
+// for the reward goes back if nothing is sent. ok is false once an error
+// response has been written.
+func (h *EarnHandler) sendEarned(c *gin.Context, merchant *models.Merchant, reward *earnReward) (string, float64, bool) {
+	amountLCN := reward.amountLCN()
+	balance, err := h.cardanoService.GetBalance(merchant.Wallet.Address)
+	if err != nil {
+		h.releaseReward(merchant, reward)
+		logger.Error("Failed to get merchant balance", err, map[string]interface{}{
+			"merchant_id": merchant.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BALANCE_CHECK_FAILED",
+			"message": "Failed to verify merchant balance",
+		})
+		return "", 0, false
+	}
+	if balance.LCN < float64(amountLCN) {
+		h.releaseReward(merchant, reward)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INSUFFICIENT_BALANCE",
+			"message": "Insufficient LCN balance",
+			"data": gin.H{
+				"requested": amountLCN,
+				"available": balance.LCN,
+			},
+		})
+		return "", 0, false
+	}
+
+	txHash, err := h.cardanoService.TransferADAWithMeta(walletKey(merchant.ID, merchant.Wallet), reward.customerAddress, amountLCN, models.TxTypeIssuance, reward.meta)
+	if err != nil {
+		h.releaseReward(merchant, reward)
+		logger.Error("Failed to issue earned LCN", err, map[string]interface{}{
+			"merchant_id": merchant.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_ISSUANCE_FAILED",
+			"message": "Failed to issue LCN: " + err.Error(),
+		})
+		return "", 0, false
+	}
+	return txHash, balance.LCN - float64(amountLCN), true
+}
+
+// releaseReward gives back the daily cap and campaign budget reserved for a
+// reward that was not sent
+func (h *EarnHandler) releaseReward(merchant *models.Merchant, reward *earnReward) {
+	ctx := context.Background()
+	if reward.baseLCN > 0 {
+		if err := h.earnLimitRepo.ReleaseDailyEarn(ctx, merchant.Wallet.Address, reward.customerAddress, reward.day, reward.baseLCN); err != nil {
+			logger.Error("Failed to release daily earnings", err, map[string]interface{}{
+				"merchant_id": merchant.ID,
+			})
+		}
+	}
+	for _, award := range reward.awards {
+		if err := h.campaignRepo.ReleaseBudget(ctx, award.Campaign.ID, award.BonusLCN); err != nil {
+			logger.Error("Failed to release campaign budget", err, map[string]interface{}{
+				"campaign_id": award.Campaign.ID,
+			})
+		}
+	}
+}
+
+// applyCampaigns selects the merchant's running campaigns a purchase qualifies
+// for and reserves their bonuses from the campaign budgets. A campaign whose
+// budget runs out pays what is left and stops. ok is false once an error
+// response has been written.
+func (h *EarnHandler) applyCampaigns(c *gin.Context, merchant *models.Merchant, customerAddress string, purchase rewards.Purchase, baseLCN uint64) ([]rewards.CampaignAward, bool) {
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	campaigns, err := h.campaignRepo.GetLiveCampaigns(ctx, merchant.ID, now)
+	if err != nil {
+		logger.Error("Failed to get live campaigns", err, map[string]interface{}{
+			"merchant_id": merchant.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to load campaigns",
+		})
+		return nil, false
+	}
+	if len(campaigns) == 0 {
+		return nil, true
+	}
+
+	firstVisit := false
+	for _, campaign := range campaigns {
+		if campaign.FirstVisitOnly {
+			visited, err := h.txLogRepo.HasIssuance(ctx, merchant.Wallet.Address, customerAddress)
+			if err != nil {
+				logger.Error("Failed to check earlier visits", err, map[string]interface{}{
+					"merchant_id": merchant.ID,
+				})
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status":  "error",
+					"code":    "500_INTERNAL_ERROR",
+					"message": "Failed to check campaign eligibility",
+				})
+				return nil, false
+			}
+			firstVisit = !visited
+			break
+		}
+	}
+
+	var awards []rewards.CampaignAward
+	for _, award := range rewards.SelectCampaigns(campaigns, purchase, baseLCN, firstVisit, now) {
+		granted, err := h.campaignRepo.ReserveBudget(ctx, award.Campaign.ID, award.BonusLCN)
+		if err != nil {
+			logger.Error("Failed to reserve campaign budget", err, map[string]interface{}{
+				"campaign_id": award.Campaign.ID,
+			})
+			continue
+		}
+		if granted == 0 {
+			continue
+		}
+		if granted < award.BonusLCN {
+			logger.Info("Campaign budget exhausted", map[string]interface{}{
+				"campaign_id": award.Campaign.ID,
+			})
+		}
+		award.BonusLCN = granted
+		awards = append(awards, award)
+	}
+	return awards, true
+}
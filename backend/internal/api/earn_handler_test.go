@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEarnWallet struct {
+	balance     float64
+	balanceErr  error
+	transferErr error
+	sent        uint64
+	meta        map[string]interface{}
+}
+
+func (w *fakeEarnWallet) GetBalance(address string) (*cardano.Balance, error) {
+	if w.balanceErr != nil {
+		return nil, w.balanceErr
+	}
+	return &cardano.Balance{Address: address, LCN: w.balance}, nil
+}
+
+func (w *fakeEarnWallet) TransferADAWithMeta(from crypto.WalletKey, toAddress string, amountLCN uint64, txType models.TxType, meta map[string]interface{}) (string, error) {
+	if w.transferErr != nil {
+		return "", w.transferErr
+	}
+	w.sent += amountLCN
+	w.meta = meta
+	return "tx1", nil
+}
+
+// fakeDailyEarnings mirrors the capped updates of storage.EarnLimitRepository
+type fakeDailyEarnings struct {
+	earned map[string]uint64
+}
+
+func (f *fakeDailyEarnings) GetDailyEarned(ctx context.Context, merchantAddress, customerAddress string, day time.Time) (uint64, error) {
+	return f.earned[merchantAddress+":"+customerAddress], nil
+}
+
+func (f *fakeDailyEarnings) ReserveDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN, capLCN uint64) (uint64, error) {
+	id := merchantAddress + ":" + customerAddress
+	reserved := amountLCN
+	if capLCN > 0 {
+		reserved = min(amountLCN, capLCN-min(capLCN, f.earned[id]))
+	}
+	f.earned[id] += reserved
+	return reserved, nil
+}
+
+func (f *fakeDailyEarnings) ReleaseDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN uint64) error {
+	id := merchantAddress + ":" + customerAddress
+	if f.earned[id] >= amountLCN {
+		f.earned[id] -= amountLCN
+	}
+	return nil
+}
+
+// fakeCampaignBudgets tracks what is left of each campaign's budget
+type fakeCampaignBudgets struct {
+	remaining map[string]uint64
+}
+
+func (f *fakeCampaignBudgets) GetLiveCampaigns(ctx context.Context, merchantID string, now time.Time) ([]*models.Campaign, error) {
+	return nil, nil
+}
+
+func (f *fakeCampaignBudgets) ReserveBudget(ctx context.Context, id string, amount uint64) (uint64, error) {
+	granted := min(amount, f.remaining[id])
+	f.remaining[id] -= granted
+	return granted, nil
+}
+
+func (f *fakeCampaignBudgets) ReleaseBudget(ctx context.Context, id string, amount uint64) error {
+	f.remaining[id] += amount
+	return nil
+}
+
+func TestSendEarned_ReleasesReservations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	merchant := &models.Merchant{ID: "m1", Wallet: models.Wallet{Address: "addr_merchant"}}
+
+	tests := []struct {
+		name     string
+		wallet   *fakeEarnWallet
+		code     int
+		released bool
+	}{
+		{"balance check fails", &fakeEarnWallet{balanceErr: fmt.Errorf("blockfrost unavailable")}, http.StatusInternalServerError, true},
+		{"insufficient balance", &fakeEarnWallet{balance: 10}, http.StatusBadRequest, true},
+		{"transfer fails", &fakeEarnWallet{balance: 1000, transferErr: fmt.Errorf("submit failed")}, http.StatusInternalServerError, true},
+		{"sent", &fakeEarnWallet{balance: 1000}, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		budgets := &fakeCampaignBudgets{remaining: map[string]uint64{"c1": 100}}
+		earnings := &fakeDailyEarnings{earned: map[string]uint64{}}
+		h := &EarnHandler{cardanoService: tt.wallet, campaignRepo: budgets, earnLimitRepo: earnings}
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+
+		// The base was counted against the daily cap by Earn, the bonus
+		// reserved by applyCampaigns
+		base, _ := earnings.ReserveDailyEarn(context.Background(), "addr_merchant", "addr_customer", day, 50, 200)
+		granted, _ := budgets.ReserveBudget(context.Background(), "c1", 30)
+		reward := &earnReward{
+			customerAddress: "addr_customer",
+			day:             day,
+			baseLCN:         base,
+			awards:          []rewards.CampaignAward{{Campaign: &models.Campaign{ID: "c1"}, BonusLCN: granted}},
+			meta:            map[string]interface{}{"earn_rule_version": 3, "base_lcn": base},
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		txHash, remaining, ok := h.sendEarned(c, merchant, reward)
+
+		earned, _ := earnings.GetDailyEarned(context.Background(), "addr_merchant", "addr_customer", day)
+		if tt.released {
+			assert.False(t, ok, tt.name)
+			assert.Equal(t, tt.code, w.Code, tt.name)
+			assert.Equal(t, uint64(100), budgets.remaining["c1"], tt.name)
+			assert.Zero(t, earned, tt.name)
+			assert.Zero(t, tt.wallet.sent, tt.name)
+			continue
+		}
+		assert.True(t, ok, tt.name)
+		assert.Equal(t, "tx1", txHash)
+		assert.Equal(t, float64(920), remaining)
+		assert.Equal(t, uint64(80), tt.wallet.sent)
+		assert.Equal(t, reward.meta, tt.wallet.meta, "the earn details are recorded with the issuance")
+		assert.Equal(t, uint64(70), budgets.remaining["c1"], "the bonus stays spent")
+		assert.Equal(t, uint64(50), earned, "the base stays counted")
+	}
+}
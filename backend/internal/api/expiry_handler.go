@@ -0,0 +1,203 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/expiry"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Longest expiry window a merchant can set
+const maxExpiryMonths = 120
+
+// LCN expires a number of months after it is issued: the platform sets the
+// default window and merchants may set their own. The expiry service returns
+// what customers have not spent by then.
+type ExpiryHandler struct {
+	expiryService *expiry.Service
+	policyRepo    *storage.ExpiryPolicyRepository
+	userRepo      *storage.UserRepository
+}
+
+func NewExpiryHandler(expiryService *expiry.Service, policyRepo *storage.ExpiryPolicyRepository, userRepo *storage.UserRepository) *ExpiryHandler {
+	return &ExpiryHandler{
+		expiryService: expiryService,
+		policyRepo:    policyRepo,
+		userRepo:      userRepo,
+	}
+}
+
+// GET /api/v1/merchant/expiry-policy (requires rewards:manage)
+func (h *ExpiryHandler) GetExpiryPolicy(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	months, custom, err := h.expiryService.ExpiryMonths(c.Request.Context(), merchantID)
+	if err != nil {
+		logger.Error("Failed to get expiry policy", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve expiry policy",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"expiry_months":          months,
+			"custom":                 custom,
+			"platform_expiry_months": h.expiryService.DefaultMonths(),
+		},
+	})
+}
+
+// PUT /api/v1/merchant/expiry-policy (requires rewards:manage)
+// Applies to LCN issued from now on; issued LCN keeps the expiry date it got.
+func (h *ExpiryHandler) SaveExpiryPolicy(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	var req struct {
+		ExpiryMonths *int `json:"expiry_months" binding:"required,gte=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	if *req.ExpiryMonths > maxExpiryMonths {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "expiry_months must be at most " + strconv.Itoa(maxExpiryMonths) + " (0 for no expiry)",
+		})
+		return
+	}
+
+	policy := &models.ExpiryPolicy{
+		MerchantID:   merchantID,
+		ExpiryMonths: *req.ExpiryMonths,
+		UpdatedBy:    c.GetString("user_id"),
+	}
+	if err := h.policyRepo.SaveExpiryPolicy(c.Request.Context(), policy); err != nil {
+		logger.Error("Failed to save expiry policy", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to save expiry policy",
+		})
+		return
+	}
+
+	auditLog(c, "EXPIRY_POLICY_UPDATED", map[string]interface{}{
+		"expiry_months": policy.ExpiryMonths,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   policy,
+	})
+}
+
+// DELETE /api/v1/merchant/expiry-policy (requires rewards:manage)
+// Goes back to the platform's expiry window.
+func (h *ExpiryHandler) ResetExpiryPolicy(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	if err := h.policyRepo.DeleteExpiryPolicy(c.Request.Context(), merchantID); err != nil {
+		logger.Error("Failed to delete expiry policy", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to reset expiry policy",
+		})
+		return
+	}
+
+	auditLog(c, "EXPIRY_POLICY_RESET", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"expiry_months": h.expiryService.DefaultMonths(),
+			"custom":        false,
+		},
+	})
+}
+
+// GET /api/v1/customer/expiring (requires wallet:read)
+// Lists the customer's LCN that expires within ?days (the warning period by
+// default), soonest first. Expired LCN not returned yet is included.
+func (h *ExpiryHandler) GetExpiringLCN(c *gin.Context) {
+	days := int(h.expiryService.WarningPeriod() / (24 * time.Hour))
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > 3660 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_REQUEST",
+				"message": "days must be between 0 and 3660",
+			})
+			return
+		}
+		days = parsed
+	}
+
+	ctx := c.Request.Context()
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	lots, err := h.expiryService.HeldLots(ctx, customer.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to get expiring LCN", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve expiring LCN",
+		})
+		return
+	}
+
+	until := time.Now().UTC().AddDate(0, 0, days)
+	expiring := []*models.LCNLot{}
+	var total uint64
+	for _, lot := range lots {
+		if lot.ExpiresAt == nil || lot.ExpiresAt.After(until) || lot.RemainingLCN == 0 {
+			continue
+		}
+		expiring = append(expiring, lot)
+		total += lot.RemainingLCN
+	}
+	// Lots are issued in order, but merchants' windows differ
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(*expiring[j].ExpiresAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"lots":         expiring,
+			"expiring_lcn": total,
+			"days":         days,
+		},
+	})
+}
@@ -0,0 +1,578 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Multi-sig governance workflow: when the governance wallet is a native script,
+// an approved allocation (or referral payout, built by the referrals service)
+// produces an unsigned transaction that admins holding script keys sign one by
+// one. It is submitted once the script's threshold is met.
+
+// startGovernanceTransfer builds the allocation transfer for the multi-sig
+// governance wallet and adds the approving admin's signature if they hold a script key.
+// The allocation must already be claimed (PROCESSING).
+func (h *AdminHandler) startGovernanceTransfer(c *gin.Context, allocation *models.AllocationPurchase, merchant *models.Merchant, adminID, notes string) {
+	ctx := c.Request.Context()
+	h.expireGovernanceTxs(ctx)
+
+	release := func() {
+		if err := h.allocationRepo.TransitionStatus(ctx, allocation.ID, "PROCESSING", "PENDING"); err != nil {
+			logger.Error("Failed to release allocation after governance transaction failure", err, nil)
+		}
+	}
+
+	lockedInputs, err := h.governanceRepo.GetLockedInputs(ctx)
+	if err != nil {
+		logger.Error("Failed to load locked governance inputs", err, nil)
+		release()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to prepare governance transaction",
+		})
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(h.governanceTxTTL)
+	transfer, err := h.cardanoService.BuildGovernanceTransfer(h.governance, merchant.Wallet.Address, allocation.AmountLCN, lockedInputs, expiresAt)
+	if err != nil {
+		logger.Error("Failed to build governance transaction", err, map[string]interface{}{
+			"allocation_id": allocation.ID,
+			"to":            merchant.Wallet.Address,
+			"amount":        allocation.AmountLCN,
+		})
+		release()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": "Failed to build governance transaction: " + err.Error(),
+		})
+		return
+	}
+
+	govTx := &models.GovernanceTx{
+		Purpose:            models.TxTypeAllocation,
+		ReferenceID:        allocation.ID,
+		ToAddress:          merchant.Wallet.Address,
+		AmountLCN:          allocation.AmountLCN,
+		TxHash:             transfer.TxHash,
+		TxCBOR:             transfer.TxCBOR,
+		Inputs:             transfer.Inputs,
+		RequiredSignatures: h.governance.Script.Required,
+		CreatedBy:          adminID,
+		ExpiresAt:          expiresAt,
+	}
+	if err := h.governanceRepo.CreateGovernanceTx(ctx, govTx); err != nil {
+		logger.Error("Failed to store governance transaction", err, nil)
+		release()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_UPDATE_FAILED",
+			"message": "Failed to store governance transaction",
+		})
+		return
+	}
+
+	allocation.Status = "PROCESSING"
+	allocation.AdminID = adminID
+	allocation.AdminNotes = notes
+	allocation.GovernanceTxID = govTx.ID
+	if err := h.allocationRepo.UpdateAllocation(ctx, allocation); err != nil {
+		logger.Error("Failed to link governance transaction to allocation", err, nil)
+	}
+
+	auditLog(c, "GOVERNANCE_TX_CREATED", map[string]interface{}{
+		"governance_tx_id":    govTx.ID,
+		"allocation_id":       allocation.ID,
+		"merchant_id":         allocation.MerchantID,
+		"amount_lcn":          allocation.AmountLCN,
+		"tx_hash":             govTx.TxHash,
+		"required_signatures": govTx.RequiredSignatures,
+	})
+
+	// The approving admin signs right away when they hold one of the script keys
+	if admin, keyHash, ok := h.governanceSigner(c); ok {
+		witness, err := h.cardanoService.SignGovernanceTx(govTx.TxHash, walletKey(admin.ID, admin.Wallet))
+		if err != nil {
+			logger.Warn("Failed to sign governance transaction", map[string]interface{}{
+				"governance_tx_id": govTx.ID,
+				"error":            err.Error(),
+			})
+		} else {
+			h.addGovernanceSignature(c, govTx, adminID, keyHash, witness)
+			return
+		}
+	}
+
+	respondAwaitingSignatures(c, govTx)
+}
+
+// GET /api/v1/admin/governance/transactions
+func (h *AdminHandler) ListGovernanceTransactions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	h.expireGovernanceTxs(c.Request.Context())
+
+	var status *models.GovernanceTxStatus
+	if s := c.Query("status"); s != "" {
+		st := models.GovernanceTxStatus(s)
+		status = &st
+	}
+
+	txs, total, err := h.governanceRepo.GetGovernanceTxs(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get governance transactions", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve governance transactions",
+		})
+		return
+	}
+
+	signers := []string{}
+	required := 0
+	if h.governance.IsMultiSig() {
+		signers = h.governance.Script.KeyHashesHex()
+		required = h.governance.Script.Required
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"transactions":        txs,
+			"total":               total,
+			"limit":               limit,
+			"offset":              offset,
+			"governance_address":  h.governance.Address,
+			"script_key_hashes":   signers,
+			"required_signatures": required,
+		},
+	})
+}
+
+// POST /api/v1/admin/governance/transactions/:id/sign
+// Without a body the admin's custodial key signs. Admins holding their key
+// elsewhere (hardware wallet) post {public_key, signature} over tx_hash instead.
+func (h *AdminHandler) SignGovernanceTransaction(c *gin.Context) {
+	adminID := c.GetString("user_id")
+	ctx := c.Request.Context()
+
+	if !h.governance.IsMultiSig() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_NOT_MULTISIG",
+			"message": "The governance wallet is not a multi-signature script",
+		})
+		return
+	}
+
+	var req struct {
+		PublicKey string `json:"public_key"`
+		Signature string `json:"signature"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_REQUEST",
+				"message": "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	h.expireGovernanceTxs(ctx)
+
+	govTx, err := h.governanceRepo.GetGovernanceTxByID(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_GOVERNANCE_TX_NOT_FOUND",
+			"message": "Governance transaction not found",
+		})
+		return
+	}
+	if govTx.Status != models.GovernanceTxAwaitingSignatures {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_STATUS",
+			"message": "Governance transaction is not awaiting signatures",
+			"data": gin.H{
+				"current_status": govTx.Status,
+			},
+		})
+		return
+	}
+
+	var keyHash []byte
+	var witness *crypto.VKeyWitness
+	if req.PublicKey != "" || req.Signature != "" {
+		publicKey, errPub := hex.DecodeString(req.PublicKey)
+		signature, errSig := hex.DecodeString(req.Signature)
+		txHash, _ := hex.DecodeString(govTx.TxHash)
+		if errPub != nil || errSig != nil || len(publicKey) != ed25519.PublicKeySize ||
+			!crypto.VerifySignature(publicKey, txHash, signature) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_SIGNATURE",
+				"message": "Signature does not verify against the transaction hash",
+			})
+			return
+		}
+		keyHash, err = crypto.KeyHash(publicKey)
+		if err != nil || !h.governance.Script.HasKey(keyHash) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"code":    "403_NOT_A_SIGNER",
+				"message": "Key is not part of the governance script",
+			})
+			return
+		}
+		witness = &crypto.VKeyWitness{PublicKey: publicKey, Signature: signature}
+	} else {
+		admin, adminKeyHash, ok := h.governanceSigner(c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"code":    "403_NOT_A_SIGNER",
+				"message": "Your wallet key is not part of the governance script",
+			})
+			return
+		}
+		keyHash = adminKeyHash
+		witness, err = h.cardanoService.SignGovernanceTx(govTx.TxHash, walletKey(admin.ID, admin.Wallet))
+		if err != nil {
+			logger.Error("Failed to sign governance transaction", err, map[string]interface{}{
+				"governance_tx_id": govTx.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_SIGNING_FAILED",
+				"message": "Failed to sign governance transaction",
+			})
+			return
+		}
+	}
+
+	h.addGovernanceSignature(c, govTx, adminID, keyHash, witness)
+}
+
+// governanceSigner returns the calling admin's account and key hash if their
+// custodial wallet key is one of the governance script keys
+func (h *AdminHandler) governanceSigner(c *gin.Context) (*models.Merchant, []byte, bool) {
+	if !h.governance.IsMultiSig() {
+		return nil, nil, false
+	}
+
+	admin, err := h.userRepo.GetMerchantByWalletAddress(c.Request.Context(), c.GetString("wallet_address"))
+	if err != nil || admin.Wallet.PubKeyHex == "" {
+		return nil, nil, false
+	}
+	publicKey, err := hex.DecodeString(admin.Wallet.PubKeyHex)
+	if err != nil {
+		return nil, nil, false
+	}
+	keyHash, err := crypto.KeyHash(publicKey)
+	if err != nil || !h.governance.Script.HasKey(keyHash) {
+		return nil, nil, false
+	}
+	return admin, keyHash, true
+}
+
+// addGovernanceSignature records a witness and submits the transaction once the
+// script threshold is reached
+func (h *AdminHandler) addGovernanceSignature(c *gin.Context, govTx *models.GovernanceTx, adminID string, keyHash []byte, witness *crypto.VKeyWitness) {
+	ctx := c.Request.Context()
+
+	updated, err := h.governanceRepo.AddSignature(ctx, govTx.ID, models.TxSignature{
+		AdminID:   adminID,
+		KeyHash:   hex.EncodeToString(keyHash),
+		PublicKey: hex.EncodeToString(witness.PublicKey),
+		Signature: hex.EncodeToString(witness.Signature),
+		SignedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_SIGNATURE_NOT_RECORDED",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	auditLog(c, "GOVERNANCE_TX_SIGNED", map[string]interface{}{
+		"governance_tx_id":    updated.ID,
+		"key_hash":            hex.EncodeToString(keyHash),
+		"signatures_received": len(updated.Signatures),
+		"required_signatures": updated.RequiredSignatures,
+	})
+
+	if len(updated.Signatures) < updated.RequiredSignatures {
+		respondAwaitingSignatures(c, updated)
+		return
+	}
+
+	h.submitGovernanceTx(c, updated)
+}
+
+// submitGovernanceTx submits a fully signed governance transaction and confirms its allocation
+func (h *AdminHandler) submitGovernanceTx(c *gin.Context, govTx *models.GovernanceTx) {
+	ctx := c.Request.Context()
+
+	// Claim the transaction so that concurrent final signatures submit it once
+	if err := h.governanceRepo.TransitionStatus(ctx, govTx.ID, models.GovernanceTxAwaitingSignatures, models.GovernanceTxSubmitting); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_INVALID_STATUS",
+			"message": "Governance transaction is already being submitted",
+		})
+		return
+	}
+
+	if govTx.Purpose == models.TxTypeReferral {
+		h.submitReferralPayout(c, govTx)
+		return
+	}
+
+	allocation, err := h.allocationRepo.GetAllocationByID(ctx, govTx.ReferenceID)
+	if err != nil {
+		h.failGovernanceTx(ctx, govTx, models.GovernanceTxFailed, "allocation not found")
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ALLOCATION_NOT_FOUND",
+			"message": "Allocation purchase not found",
+		})
+		return
+	}
+
+	witnesses := governanceWitnesses(govTx)
+	txHash, err := h.cardanoService.SubmitGovernanceTransfer(h.governance, govTx.ToAddress, govTx.AmountLCN, govTx.TxCBOR, witnesses, models.TxTypeAllocation)
+	if err != nil {
+		logger.Error("Failed to submit governance transaction", err, map[string]interface{}{
+			"governance_tx_id": govTx.ID,
+			"allocation_id":    allocation.ID,
+		})
+		h.failGovernanceTx(ctx, govTx, models.GovernanceTxFailed, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": "Failed to submit governance transaction: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.governanceRepo.CompleteGovernanceTx(ctx, govTx.ID, models.GovernanceTxSubmitted, txHash, ""); err != nil {
+		logger.Error("Failed to mark governance transaction submitted", err, nil)
+	}
+
+	merchant, err := h.userRepo.GetMerchantByID(ctx, allocation.MerchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+
+	merchant.AllocationLCN += allocation.AmountLCN
+	merchant.BalanceLCN += allocation.AmountLCN
+	if err := h.userRepo.UpdateMerchant(ctx, merchant); err != nil {
+		logger.Error("Failed to update merchant allocation", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_UPDATE_FAILED",
+			"message": "Failed to update merchant allocation",
+		})
+		return
+	}
+
+	allocation.Status = "CONFIRMED"
+	allocation.LCNTransferTxHash = txHash
+	now := time.Now().UTC()
+	allocation.VerifiedAt = &now
+	if err := h.allocationRepo.UpdateAllocation(ctx, allocation); err != nil {
+		logger.Error("Failed to update allocation", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_UPDATE_FAILED",
+			"message": "Failed to approve allocation",
+		})
+		return
+	}
+
+	auditLog(c, "ALLOCATION_APPROVED", map[string]interface{}{
+		"allocation_id":    allocation.ID,
+		"merchant_id":      allocation.MerchantID,
+		"amount_lcn":       allocation.AmountLCN,
+		"governance_tx_id": govTx.ID,
+		"tx_hash":          txHash,
+		"signers":          len(witnesses),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"purchase_id":      allocation.ID,
+			"governance_tx_id": govTx.ID,
+			"status":           "CONFIRMED",
+			"tx_hash":          txHash,
+			"message":          "LCN allocated to merchant wallet",
+		},
+	})
+}
+
+// submitReferralPayout submits a fully signed transaction paying one side of a
+// referral and records it on the referral
+func (h *AdminHandler) submitReferralPayout(c *gin.Context, govTx *models.GovernanceTx) {
+	ctx := c.Request.Context()
+
+	txHash, err := h.cardanoService.SubmitGovernanceTransfer(h.governance, govTx.ToAddress, govTx.AmountLCN, govTx.TxCBOR, governanceWitnesses(govTx), models.TxTypeReferral)
+	if err != nil {
+		logger.Error("Failed to submit governance transaction", err, map[string]interface{}{
+			"governance_tx_id": govTx.ID,
+			"referral_id":      govTx.ReferenceID,
+		})
+		h.failGovernanceTx(ctx, govTx, models.GovernanceTxFailed, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": "Failed to submit governance transaction: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.governanceRepo.CompleteGovernanceTx(ctx, govTx.ID, models.GovernanceTxSubmitted, txHash, ""); err != nil {
+		logger.Error("Failed to mark governance transaction submitted", err, nil)
+	}
+
+	// The LCN is on its way; the referral catches up on its next review otherwise
+	status := models.ReferralPaying
+	referral, err := h.referralService.CompleteGovernancePayout(ctx, govTx, txHash)
+	if err != nil {
+		logger.Error("Failed to record referral payout", err, map[string]interface{}{
+			"referral_id": govTx.ReferenceID,
+			"tx_hash":     txHash,
+		})
+	}
+	if referral != nil {
+		status = referral.Status
+	}
+
+	auditLog(c, "REFERRAL_PAYOUT_SUBMITTED", map[string]interface{}{
+		"referral_id":      govTx.ReferenceID,
+		"side":             govTx.Side,
+		"amount_lcn":       govTx.AmountLCN,
+		"governance_tx_id": govTx.ID,
+		"tx_hash":          txHash,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"referral_id":      govTx.ReferenceID,
+			"side":             govTx.Side,
+			"governance_tx_id": govTx.ID,
+			"referral_status":  status,
+			"tx_hash":          txHash,
+			"message":          "Referral reward sent",
+		},
+	})
+}
+
+// governanceWitnesses decodes the signatures collected on a governance transaction
+func governanceWitnesses(govTx *models.GovernanceTx) []crypto.VKeyWitness {
+	witnesses := make([]crypto.VKeyWitness, 0, len(govTx.Signatures))
+	for _, sig := range govTx.Signatures {
+		publicKey, _ := hex.DecodeString(sig.PublicKey)
+		signature, _ := hex.DecodeString(sig.Signature)
+		witnesses = append(witnesses, crypto.VKeyWitness{PublicKey: publicKey, Signature: signature})
+	}
+	return witnesses
+}
+
+// failGovernanceTx marks a governance transaction failed or expired and returns
+// its allocation to PENDING so that it can be approved again. A referral whose
+// payout it was becomes FAILED, to be approved again.
+func (h *AdminHandler) failGovernanceTx(ctx context.Context, govTx *models.GovernanceTx, status models.GovernanceTxStatus, reason string) {
+	if err := h.governanceRepo.CompleteGovernanceTx(ctx, govTx.ID, status, "", reason); err != nil {
+		logger.Error("Failed to update governance transaction", err, nil)
+	}
+	if govTx.Purpose == models.TxTypeReferral {
+		if err := h.referralService.FailGovernancePayout(ctx, govTx, reason); err != nil {
+			logger.Warn("Failed to mark referral payout failed", map[string]interface{}{
+				"referral_id": govTx.ReferenceID,
+				"error":       err.Error(),
+			})
+		}
+		return
+	}
+	if err := h.allocationRepo.TransitionStatus(ctx, govTx.ReferenceID, "PROCESSING", "PENDING"); err != nil {
+		logger.Warn("Failed to release allocation", map[string]interface{}{
+			"allocation_id": govTx.ReferenceID,
+			"error":         err.Error(),
+		})
+	}
+}
+
+// expireGovernanceTxs releases allocations whose transactions outlived their validity window
+func (h *AdminHandler) expireGovernanceTxs(ctx context.Context) {
+	expired, err := h.governanceRepo.GetExpiredGovernanceTxs(ctx)
+	if err != nil {
+		logger.Warn("Failed to query expired governance transactions", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	for _, govTx := range expired {
+		if err := h.governanceRepo.TransitionStatus(ctx, govTx.ID, models.GovernanceTxAwaitingSignatures, models.GovernanceTxExpired); err != nil {
+			continue // signed or expired concurrently
+		}
+		h.failGovernanceTx(ctx, govTx, models.GovernanceTxExpired, "signature window expired")
+		logger.Audit("GOVERNANCE_TX_EXPIRED", govTx.CreatedBy, map[string]interface{}{
+			"governance_tx_id":    govTx.ID,
+			"purpose":             govTx.Purpose,
+			"reference_id":        govTx.ReferenceID,
+			"signatures_received": len(govTx.Signatures),
+		})
+	}
+}
+
+func respondAwaitingSignatures(c *gin.Context, govTx *models.GovernanceTx) {
+	idField := "purchase_id"
+	if govTx.Purpose == models.TxTypeReferral {
+		idField = "referral_id"
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			idField:               govTx.ReferenceID,
+			"governance_tx_id":    govTx.ID,
+			"status":              govTx.Status,
+			"tx_hash":             govTx.TxHash,
+			"signatures_received": len(govTx.Signatures),
+			"signatures_required": govTx.RequiredSignatures,
+			"expires_at":          govTx.ExpiresAt,
+			"message":             "Governance transaction awaiting admin signatures. It is submitted once the script threshold is met.",
+		},
+	})
+}
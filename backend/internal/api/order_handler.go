@@ -0,0 +1,624 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// How many codes are tried before giving up on a duplicate
+const orderCodeAttempts = 3
+
+// Customers order catalog items with their LCN and pick them up at the
+// merchant by showing the order's code, which the cashier validates
+type OrderHandler struct {
+	cardanoService *cardano.CardanoService
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	catalogRepo    *storage.CatalogRepository
+	orderRepo      *storage.OrderRepository
+}
+
+func NewOrderHandler(
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	catalogRepo *storage.CatalogRepository,
+	orderRepo *storage.OrderRepository,
+) *OrderHandler {
+	return &OrderHandler{
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		catalogRepo:    catalogRepo,
+		orderRepo:      orderRepo,
+	}
+}
+
+// POST /api/v1/customer/orders (requires lcn:redeem)
+// Pays for a catalog item from the customer's custodial wallet and returns
+// the order with its pickup code.
+func (h *OrderHandler) PlaceOrder(c *gin.Context) {
+	var req struct {
+		ItemID string `json:"item_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetString("user_id")
+	item, err := h.catalogRepo.GetItemByID(ctx, req.ItemID)
+	if err != nil || !item.Active {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ITEM_NOT_FOUND",
+			"message": "Catalog item not found",
+		})
+		return
+	}
+	if item.Stock <= 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_OUT_OF_STOCK",
+			"message": "This item is out of stock",
+		})
+		return
+	}
+	merchant, err := h.userRepo.GetMerchantByID(ctx, item.MerchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+	customer, err := h.userRepo.GetCustomerByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	if customer.Wallet.Custody == models.WalletExternal {
+		// Orders are paid as soon as they are placed, which needs a key the
+		// platform holds
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_EXTERNAL_WALLET",
+			"message": "Catalog orders are paid from a LoyalCoin-held wallet",
+		})
+		return
+	}
+
+	balance, err := h.cardanoService.GetBalance(customer.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to get customer balance", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BALANCE_CHECK_FAILED",
+			"message": "Failed to verify balance",
+		})
+		return
+	}
+	if balance.LCN < float64(item.PriceLCN) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INSUFFICIENT_BALANCE",
+			"message": "Insufficient LCN balance",
+			"data": gin.H{
+				"requested": item.PriceLCN,
+				"available": balance.LCN,
+			},
+		})
+		return
+	}
+
+	reserved, err := h.catalogRepo.ReserveStock(ctx, item.ID)
+	if err != nil {
+		logger.Error("Failed to reserve catalog stock", err, map[string]interface{}{
+			"item_id": item.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to place order",
+		})
+		return
+	}
+	if !reserved {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_OUT_OF_STOCK",
+			"message": "This item is out of stock",
+		})
+		return
+	}
+
+	order := &models.RedemptionOrder{
+		ItemID:          item.ID,
+		ItemName:        item.Name,
+		MerchantID:      item.MerchantID,
+		CustomerID:      customer.ID,
+		CustomerAddress: customer.Wallet.Address,
+		PriceLCN:        item.PriceLCN,
+	}
+	for attempt := 0; attempt < orderCodeAttempts; attempt++ {
+		if order.Code, err = rewards.NewOrderCode(); err != nil {
+			break
+		}
+		if err = h.orderRepo.CreateOrder(ctx, order); !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to create redemption order", err, map[string]interface{}{
+			"item_id":     item.ID,
+			"customer_id": customer.ID,
+		})
+		h.releaseStock(c, item.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to place order",
+		})
+		return
+	}
+	auditLog(c, "CATALOG_ORDER_PLACED", map[string]interface{}{
+		"order_id":    order.ID,
+		"item_id":     item.ID,
+		"merchant_id": item.MerchantID,
+		"price_lcn":   item.PriceLCN,
+	})
+
+	txHash, err := h.cardanoService.TransferADAAs(
+		walletKey(customer.ID, customer.Wallet),
+		merchant.Wallet.Address,
+		item.PriceLCN,
+		models.TxTypeRedemption,
+	)
+	if err != nil {
+		logger.Error("Failed to pay redemption order", err, map[string]interface{}{
+			"order_id": order.ID,
+		})
+		if markErr := h.orderRepo.MarkFailed(ctx, order.ID, err.Error()); markErr != nil {
+			logger.Error("Failed to mark redemption order failed", markErr, map[string]interface{}{
+				"order_id": order.ID,
+			})
+		}
+		h.releaseStock(c, item.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_REDEMPTION_FAILED",
+			"message": "Failed to redeem LCN: " + err.Error(),
+		})
+		return
+	}
+	if err := h.txLogRepo.SetTxMeta(ctx, txHash, map[string]interface{}{
+		"order_id":        order.ID,
+		"catalog_item_id": item.ID,
+		"item_name":       item.Name,
+	}); err != nil {
+		logger.Error("Failed to record order details", err, map[string]interface{}{
+			"tx_hash": txHash,
+		})
+	}
+
+	now := time.Now().UTC()
+	order.TxHash = txHash
+	order.ExpiresAt = rewards.OrderExpiry(item, now)
+	order.Status = models.OrderReady
+	if err := h.orderRepo.MarkPaid(ctx, order.ID, txHash, order.ExpiresAt); err != nil {
+		// Paid all the same; the order stays PLACED until fixed by hand
+		logger.Error("Failed to mark redemption order paid", err, map[string]interface{}{
+			"order_id": order.ID,
+			"tx_hash":  txHash,
+		})
+		order.Status = models.OrderPlaced
+	}
+
+	auditLog(c, "CATALOG_ORDER_PAID", map[string]interface{}{
+		"order_id": order.ID,
+		"tx_hash":  txHash,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data":   order,
+	})
+}
+
+// releaseStock returns an order's unit of stock, logging failures
+func (h *OrderHandler) releaseStock(c *gin.Context, itemID string) {
+	if err := h.catalogRepo.ReleaseStock(c.Request.Context(), itemID); err != nil {
+		logger.Error("Failed to release catalog stock", err, map[string]interface{}{
+			"item_id": itemID,
+		})
+	}
+}
+
+// GET /api/v1/customer/orders (requires wallet:read)
+func (h *OrderHandler) ListCustomerOrders(c *gin.Context) {
+	customerID := c.GetString("user_id")
+	limit, offset := orderPage(c)
+
+	orders, total, err := h.orderRepo.GetOrdersByCustomer(c.Request.Context(), customerID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get redemption orders", err, map[string]interface{}{
+			"customer_id": customerID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve orders",
+		})
+		return
+	}
+	respondOrders(c, orders, total, limit, offset)
+}
+
+// GET /api/v1/merchant/orders (requires orders:fulfill)
+// Lists the merchant's orders, optionally by ?status.
+func (h *OrderHandler) ListMerchantOrders(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, offset := orderPage(c)
+
+	var status *models.OrderStatus
+	if statusFilter := c.Query("status"); statusFilter != "" {
+		s := models.OrderStatus(statusFilter)
+		status = &s
+	}
+
+	orders, total, err := h.orderRepo.GetOrdersByMerchant(c.Request.Context(), merchantID, status, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get redemption orders", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve orders",
+		})
+		return
+	}
+	respondOrders(c, orders, total, limit, offset)
+}
+
+func orderPage(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	return limit, offset
+}
+
+func respondOrders(c *gin.Context, orders []*models.RedemptionOrder, total int64, limit, offset int) {
+	now := time.Now().UTC()
+	for _, order := range orders {
+		order.Status = rewards.OrderStatus(order, now)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"orders": orders,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// POST /api/v1/merchant/orders/validate (requires orders:fulfill)
+// Validates the code a customer shows at pickup and marks the order
+// fulfilled. A code is accepted once.
+func (h *OrderHandler) ValidateOrder(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+	code, ok := rewards.NormalizeOrderCode(req.Code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_CODE",
+			"message": "Not a valid order code",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	merchantID := c.GetString("merchant_id")
+	order, err := h.orderRepo.GetOrderByCode(ctx, merchantID, code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ORDER_NOT_FOUND",
+			"message": "No order with this code",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	fulfilled := false
+	if rewards.OrderStatus(order, now) == models.OrderReady {
+		fulfilled, err = h.orderRepo.FulfillOrder(ctx, order.ID, c.GetString("user_id"), now)
+		if err != nil {
+			logger.Error("Failed to fulfill redemption order", err, map[string]interface{}{
+				"order_id": order.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to validate order",
+			})
+			return
+		}
+	}
+	if !fulfilled {
+		if order, err = h.orderRepo.GetOrderByID(ctx, order.ID); err == nil {
+			order.Status = rewards.OrderStatus(order, now)
+			orderNotFulfillable(c, order)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to validate order",
+		})
+		return
+	}
+
+	order.Status = models.OrderFulfilled
+	order.FulfilledBy = c.GetString("user_id")
+	order.FulfilledAt = &now
+	auditLog(c, "CATALOG_ORDER_FULFILLED", map[string]interface{}{
+		"order_id":    order.ID,
+		"item_id":     order.ItemID,
+		"customer_id": order.CustomerID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   order,
+	})
+}
+
+// orderNotFulfillable explains why a code cannot be accepted
+func orderNotFulfillable(c *gin.Context, order *models.RedemptionOrder) {
+	switch order.Status {
+	case models.OrderFulfilled:
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_FULFILLED",
+			"message": "This order was already picked up",
+			"data":    order,
+		})
+	case models.OrderExpired:
+		c.JSON(http.StatusGone, gin.H{
+			"status":  "error",
+			"code":    "410_ORDER_EXPIRED",
+			"message": "This order's code has expired",
+			"data":    order,
+		})
+	case models.OrderCancelling, models.OrderCancelled:
+		c.JSON(http.StatusGone, gin.H{
+			"status":  "error",
+			"code":    "410_ORDER_CANCELLED",
+			"message": "This order was cancelled",
+			"data":    order,
+		})
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_INVALID_STATUS",
+			"message": "This order is not ready for pickup",
+			"data":    order,
+		})
+	}
+}
+
+// POST /api/v1/customer/orders/:id/cancel (requires lcn:redeem)
+// Cancels one of the customer's orders not picked up yet, refunding its LCN.
+func (h *OrderHandler) CancelCustomerOrder(c *gin.Context) {
+	order, err := h.orderRepo.GetOrderByID(c.Request.Context(), c.Param("id"))
+	if err != nil || order.CustomerID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ORDER_NOT_FOUND",
+			"message": "Order not found",
+		})
+		return
+	}
+	h.cancelOrder(c, order)
+}
+
+// POST /api/v1/merchant/orders/:id/cancel (requires rewards:manage)
+// Cancels an order not picked up yet, refunding its LCN from the merchant's
+// wallet.
+func (h *OrderHandler) CancelMerchantOrder(c *gin.Context) {
+	order, err := h.orderRepo.GetOrderByID(c.Request.Context(), c.Param("id"))
+	if err != nil || order.MerchantID != c.GetString("merchant_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_ORDER_NOT_FOUND",
+			"message": "Order not found",
+		})
+		return
+	}
+	h.cancelOrder(c, order)
+}
+
+// cancelOrder refunds a ready or expired order from the merchant's wallet
+// and returns its unit of stock. The order is claimed first so that it can
+// neither be picked up nor refunded twice meanwhile.
+func (h *OrderHandler) cancelOrder(c *gin.Context, order *models.RedemptionOrder) {
+	ctx := c.Request.Context()
+	userID := c.GetString("user_id")
+	merchant, err := h.userRepo.GetMerchantByID(ctx, order.MerchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+	// Refund to the customer's current wallet, which may have been rotated
+	// or replaced by an external one since
+	refundAddress := order.CustomerAddress
+	if customer, err := h.userRepo.GetCustomerByID(ctx, order.CustomerID); err == nil {
+		refundAddress = customer.Wallet.Address
+	}
+
+	claimed, err := h.orderRepo.ClaimCancellation(ctx, order.ID, userID)
+	if err != nil {
+		logger.Error("Failed to claim redemption order", err, map[string]interface{}{
+			"order_id": order.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to cancel order",
+		})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_INVALID_STATUS",
+			"message": "Only orders not picked up yet can be cancelled",
+			"data": gin.H{
+				"status": order.Status,
+			},
+		})
+		return
+	}
+
+	// Reserved on the order's payment like any other refund of it, so the
+	// payment shows what was refunded
+	reserved, err := h.txLogRepo.ReserveRefund(ctx, order.TxHash, order.PriceLCN)
+	if err != nil || !reserved {
+		reason := "payment already refunded"
+		if err != nil {
+			reason = err.Error()
+		}
+		if abortErr := h.orderRepo.AbortCancellation(ctx, order.ID, reason); abortErr != nil {
+			logger.Error("Failed to restore redemption order", abortErr, map[string]interface{}{
+				"order_id": order.ID,
+			})
+		}
+		if err != nil {
+			logger.Error("Failed to reserve order refund", err, map[string]interface{}{
+				"order_id": order.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to cancel order",
+			})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_REFUNDED",
+			"message": "The order's payment has already been refunded",
+		})
+		return
+	}
+
+	// Refunds are not earnings: they create no expiry lot and count towards
+	// no tier or referral
+	txHash, err := h.cardanoService.TransferADAAs(
+		walletKey(merchant.ID, merchant.Wallet),
+		refundAddress,
+		order.PriceLCN,
+		models.TxTypeRefund,
+	)
+	if err != nil {
+		logger.Error("Failed to refund redemption order", err, map[string]interface{}{
+			"order_id": order.ID,
+		})
+		if releaseErr := h.txLogRepo.ReleaseRefund(ctx, order.TxHash, order.PriceLCN); releaseErr != nil {
+			logger.Error("Failed to release refund reservation", releaseErr, map[string]interface{}{
+				"tx_hash": order.TxHash,
+			})
+		}
+		if abortErr := h.orderRepo.AbortCancellation(ctx, order.ID, err.Error()); abortErr != nil {
+			logger.Error("Failed to restore redemption order", abortErr, map[string]interface{}{
+				"order_id": order.ID,
+			})
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": "Failed to refund order: " + err.Error(),
+		})
+		return
+	}
+	if err := h.txLogRepo.SetTxMeta(ctx, txHash, map[string]interface{}{
+		"order_id":     order.ID,
+		"refund_of":    order.TxHash,
+		"item_name":    order.ItemName,
+		"cancelled_by": userID,
+	}); err != nil {
+		logger.Error("Failed to record refund details", err, map[string]interface{}{
+			"tx_hash": txHash,
+		})
+	}
+	if err := h.txLogRepo.RecordRefund(ctx, order.TxHash, txHash, order.PriceLCN); err != nil {
+		logger.Error("Failed to link refund to its transaction", err, map[string]interface{}{
+			"tx_hash":   txHash,
+			"refund_of": order.TxHash,
+		})
+	}
+	if err := h.orderRepo.CompleteCancellation(ctx, order.ID, txHash); err != nil {
+		logger.Error("Failed to mark redemption order cancelled", err, map[string]interface{}{
+			"order_id": order.ID,
+			"tx_hash":  txHash,
+		})
+	}
+	h.releaseStock(c, order.ItemID)
+
+	now := time.Now().UTC()
+	order.Status = models.OrderCancelled
+	order.RefundTxHash = txHash
+	order.CancelledBy = userID
+	order.CancelledAt = &now
+	order.Error = ""
+	auditLog(c, "CATALOG_ORDER_CANCELLED", map[string]interface{}{
+		"order_id":       order.ID,
+		"refund_tx_hash": txHash,
+		"amount_lcn":     order.PriceLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   order,
+	})
+}
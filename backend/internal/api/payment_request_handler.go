@@ -0,0 +1,212 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+const (
+	// QR payload prefix of payment requests; the customer app pays the ID after it
+	paymentRequestQRPrefix = "loyalcoin:pay:"
+
+	defaultPaymentRequestTTL = 15 * time.Minute
+	maxPaymentRequestTTL     = 24 * time.Hour
+)
+
+// Payment requests let a merchant ask for an exact amount: the cashier creates
+// one, the customer scans its QR code and pays it once via POST /lcn/pay/:id.
+type PaymentRequestHandler struct {
+	paymentRequestRepo *storage.PaymentRequestRepository
+	userRepo           *storage.UserRepository
+}
+
+func NewPaymentRequestHandler(paymentRequestRepo *storage.PaymentRequestRepository, userRepo *storage.UserRepository) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		paymentRequestRepo: paymentRequestRepo,
+		userRepo:           userRepo,
+	}
+}
+
+// POST /api/v1/lcn/payment-requests (requires payments:request)
+func (h *PaymentRequestHandler) CreatePaymentRequest(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	var req struct {
+		AmountLCN        uint64 `json:"amount_lcn" binding:"required,gt=0"`
+		Description      string `json:"description" binding:"max=140"`
+		ExpiresInMinutes int    `json:"expires_in_minutes" binding:"gte=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	ttl := defaultPaymentRequestTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	if ttl > maxPaymentRequestTTL {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Payment requests expire within 24 hours",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	merchant, err := h.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+
+	request := &models.PaymentRequest{
+		MerchantID:      merchant.ID,
+		MerchantAddress: merchant.Wallet.Address,
+		BusinessName:    merchant.BusinessName,
+		CreatedBy:       c.GetString("user_id"),
+		AmountLCN:       req.AmountLCN,
+		Description:     req.Description,
+		ExpiresAt:       time.Now().UTC().Add(ttl),
+	}
+	if err := h.paymentRequestRepo.CreatePaymentRequest(ctx, request); err != nil {
+		logger.Error("Failed to create payment request", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create payment request",
+		})
+		return
+	}
+
+	auditLog(c, "PAYMENT_REQUEST_CREATED", map[string]interface{}{
+		"payment_request_id": request.ID,
+		"amount_lcn":         request.AmountLCN,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"payment_request": request,
+			"qr_payload":      paymentRequestQRPrefix + request.ID,
+		},
+	})
+}
+
+// GET /api/v1/lcn/payment-requests (requires payments:request)
+func (h *PaymentRequestHandler) ListPaymentRequests(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	statusFilter := c.Query("status")
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var status *models.PaymentRequestStatus
+	if statusFilter != "" {
+		s := models.PaymentRequestStatus(statusFilter)
+		status = &s
+	}
+
+	requests, total, err := h.paymentRequestRepo.GetPaymentRequestsByMerchant(c.Request.Context(), merchantID, limit, offset, status)
+	if err != nil {
+		logger.Error("Failed to get payment requests", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve payment requests",
+		})
+		return
+	}
+	for _, request := range requests {
+		request.Status = paymentRequestStatus(request)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"payment_requests": requests,
+			"total":            total,
+			"limit":            limit,
+			"offset":           offset,
+		},
+	})
+}
+
+// GET /api/v1/lcn/payment-requests/:id (requires payments:request)
+// Polled by the point of sale until the request is PAID.
+func (h *PaymentRequestHandler) GetPaymentRequest(c *gin.Context) {
+	request, err := h.paymentRequestRepo.GetPaymentRequestByID(c.Request.Context(), c.Param("id"))
+	if err != nil || request.MerchantID != c.GetString("merchant_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_PAYMENT_REQUEST_NOT_FOUND",
+			"message": "Payment request not found",
+		})
+		return
+	}
+	request.Status = paymentRequestStatus(request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   request,
+	})
+}
+
+// DELETE /api/v1/lcn/payment-requests/:id (requires payments:request)
+func (h *PaymentRequestHandler) CancelPaymentRequest(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	requestID := c.Param("id")
+
+	if err := h.paymentRequestRepo.CancelPaymentRequest(c.Request.Context(), requestID, merchantID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "Payment request not found or already being paid",
+		})
+		return
+	}
+
+	auditLog(c, "PAYMENT_REQUEST_CANCELLED", map[string]interface{}{
+		"payment_request_id": requestID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"payment_request_id": requestID,
+			"status":             models.PaymentRequestCancelled,
+		},
+	})
+}
+
+// paymentRequestStatus reports unpaid requests past their expiry as EXPIRED
+func paymentRequestStatus(request *models.PaymentRequest) models.PaymentRequestStatus {
+	now := time.Now()
+	lapsed := request.Status == models.PaymentRequestPaying && request.ReservedUntil != nil && now.After(*request.ReservedUntil)
+	if (request.Status == models.PaymentRequestOpen || lapsed) && now.After(request.ExpiresAt) {
+		return models.PaymentRequestExpired
+	}
+	return request.Status
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Rewards issued by email or phone number to people without an account yet.
+// IssueLCN creates them; they are paid out from the merchant's wallet when a
+// customer signs up with that email or phone and verifies it.
+type PendingRewardHandler struct {
+	pendingRewardRepo *storage.PendingRewardRepository
+}
+
+func NewPendingRewardHandler(pendingRewardRepo *storage.PendingRewardRepository) *PendingRewardHandler {
+	return &PendingRewardHandler{pendingRewardRepo: pendingRewardRepo}
+}
+
+// GET /api/v1/merchant/pending-rewards (requires lcn:issue)
+func (h *PendingRewardHandler) ListPendingRewards(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	statusFilter := c.Query("status")
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var status *models.PendingRewardStatus
+	if statusFilter != "" {
+		s := models.PendingRewardStatus(statusFilter)
+		status = &s
+	}
+
+	rewards, total, err := h.pendingRewardRepo.GetPendingRewardsByMerchant(c.Request.Context(), merchantID, limit, offset, status)
+	if err != nil {
+		logger.Error("Failed to get pending rewards", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve pending rewards",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"pending_rewards": rewards,
+			"total":           total,
+			"limit":           limit,
+			"offset":          offset,
+		},
+	})
+}
+
+// DELETE /api/v1/merchant/pending-rewards/:id (requires lcn:issue)
+func (h *PendingRewardHandler) CancelPendingReward(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+	rewardID := c.Param("id")
+
+	if err := h.pendingRewardRepo.CancelPendingReward(c.Request.Context(), rewardID, merchantID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_PENDING_REWARD_NOT_FOUND",
+			"message": "Pending reward not found or already claimed",
+		})
+		return
+	}
+
+	auditLog(c, "PENDING_REWARD_CANCELLED", map[string]interface{}{
+		"pending_reward_id": rewardID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"pending_reward_id": rewardID,
+			"status":            models.PendingRewardCancelled,
+		},
+	})
+}
+
+// pendingRewardClaims is the part of the pending reward store claims use
+type pendingRewardClaims interface {
+	ClaimNextPendingReward(ctx context.Context, identifiers []string, customerID string) (*models.PendingReward, error)
+	CompletePendingReward(ctx context.Context, id string, status models.PendingRewardStatus, txHash, errMsg string) error
+}
+
+// pendingRewardPayer transfers a claimed reward to the customer and returns
+// the transaction hash
+type pendingRewardPayer func(ctx context.Context, reward *models.PendingReward) (string, error)
+
+// claimPendingRewards pays out the rewards held for an email or phone number
+// the customer has just verified. Each reward is claimed atomically before its
+// transfer, so it is paid at most once; failed transfers are left FAILED for
+// the merchant to see.
+func claimPendingRewards(claims pendingRewardClaims, pay pendingRewardPayer, customerID string, identifiers []string) {
+	ctx := context.Background()
+	for {
+		reward, err := claims.ClaimNextPendingReward(ctx, identifiers, customerID)
+		if err != nil {
+			logger.Error("Failed to claim pending reward", err, map[string]interface{}{
+				"customer_id": customerID,
+			})
+			return
+		}
+		if reward == nil {
+			return
+		}
+
+		status, errMsg := models.PendingRewardClaimed, ""
+		txHash, err := pay(ctx, reward)
+		if err != nil {
+			status, errMsg = models.PendingRewardFailed, err.Error()
+			logger.Error("Failed to pay pending reward", err, map[string]interface{}{
+				"pending_reward_id": reward.ID,
+				"customer_id":       customerID,
+			})
+		}
+		if err := claims.CompletePendingReward(ctx, reward.ID, status, txHash, errMsg); err != nil {
+			logger.Warn("Failed to record pending reward claim", map[string]interface{}{
+				"pending_reward_id": reward.ID,
+				"error":             err.Error(),
+			})
+		}
+		logger.Audit("PENDING_REWARD_CLAIMED", customerID, map[string]interface{}{
+			"pending_reward_id": reward.ID,
+			"merchant_id":       reward.MerchantID,
+			"amount_lcn":        reward.AmountLCN,
+			"status":            status,
+			"tx_hash":           txHash,
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePendingRewards mirrors the conditional updates of
+// storage.PendingRewardRepository
+type fakePendingRewards struct {
+	mu      sync.Mutex
+	rewards []*models.PendingReward
+}
+
+func (f *fakePendingRewards) CreatePendingReward(ctx context.Context, reward *models.PendingReward) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reward.ID = fmt.Sprintf("r%d", len(f.rewards)+1)
+	reward.Status = models.PendingRewardPending
+	f.rewards = append(f.rewards, reward)
+	return nil
+}
+
+func (f *fakePendingRewards) SumOutstandingByMerchant(ctx context.Context, merchantID string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var total uint64
+	for _, reward := range f.rewards {
+		live := reward.Status == models.PendingRewardPending && reward.ExpiresAt.After(time.Now())
+		if reward.MerchantID == merchantID && (live || reward.Status == models.PendingRewardClaiming) {
+			total += reward.AmountLCN
+		}
+	}
+	return total, nil
+}
+
+func (f *fakePendingRewards) CancelPendingReward(ctx context.Context, id, merchantID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, reward := range f.rewards {
+		if reward.ID == id && reward.MerchantID == merchantID && reward.Status == models.PendingRewardPending {
+			reward.Status = models.PendingRewardCancelled
+			return nil
+		}
+	}
+	return fmt.Errorf("pending reward not found or already claimed")
+}
+
+func (f *fakePendingRewards) ClaimNextPendingReward(ctx context.Context, identifiers []string, customerID string) (*models.PendingReward, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, reward := range f.rewards {
+		if reward.Status != models.PendingRewardPending {
+			continue
+		}
+		for _, identifier := range identifiers {
+			if reward.Identifier == identifier {
+				reward.Status = models.PendingRewardClaiming
+				reward.CustomerID = customerID
+				return reward, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakePendingRewards) CompletePendingReward(ctx context.Context, id string, status models.PendingRewardStatus, txHash, errMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, reward := range f.rewards {
+		if reward.ID == id {
+			reward.Status, reward.TxHash, reward.Error = status, txHash, errMsg
+			return nil
+		}
+	}
+	return fmt.Errorf("pending reward %s not found", id)
+}
+
+func TestClaimPendingRewards(t *testing.T) {
+	logger.Init("error", "text")
+	store := &fakePendingRewards{rewards: []*models.PendingReward{
+		{ID: "r1", MerchantID: "m1", Identifier: "amy@example.com", AmountLCN: 50, Status: models.PendingRewardPending},
+		{ID: "r2", MerchantID: "m2", Identifier: "amy@example.com", AmountLCN: 20, Status: models.PendingRewardPending},
+		{ID: "r3", MerchantID: "m1", Identifier: "+251911000000", AmountLCN: 30, Status: models.PendingRewardPending},
+		{ID: "r4", MerchantID: "m1", Identifier: "amy@example.com", AmountLCN: 10, Status: models.PendingRewardCancelled},
+	}}
+
+	var paid []string
+	pay := func(ctx context.Context, reward *models.PendingReward) (string, error) {
+		if reward.MerchantID == "m2" {
+			return "", fmt.Errorf("insufficient LCN")
+		}
+		paid = append(paid, reward.ID)
+		return "tx-" + reward.ID, nil
+	}
+
+	// Only the verified email is claimed; the unverified phone stays pending
+	claimPendingRewards(store, pay, "c1", []string{"amy@example.com"})
+
+	assert.Equal(t, []string{"r1"}, paid)
+	assert.Equal(t, models.PendingRewardClaimed, store.rewards[0].Status)
+	assert.Equal(t, "tx-r1", store.rewards[0].TxHash)
+	assert.Equal(t, "c1", store.rewards[0].CustomerID)
+	assert.Equal(t, models.PendingRewardFailed, store.rewards[1].Status)
+	assert.Equal(t, "insufficient LCN", store.rewards[1].Error)
+	assert.Equal(t, models.PendingRewardPending, store.rewards[2].Status)
+	assert.Empty(t, store.rewards[2].CustomerID)
+	assert.Equal(t, models.PendingRewardCancelled, store.rewards[3].Status)
+
+	// Claiming again pays nothing twice
+	claimPendingRewards(store, pay, "c1", []string{"amy@example.com"})
+	assert.Equal(t, []string{"r1"}, paid)
+
+	// Verifying the phone later pays its reward
+	claimPendingRewards(store, pay, "c1", []string{"+251911000000"})
+	assert.Equal(t, []string{"r1", "r3"}, paid)
+	assert.Equal(t, models.PendingRewardClaimed, store.rewards[2].Status)
+}
+
+func TestContactOf(t *testing.T) {
+	customer := &models.Customer{Email: "amy@example.com", Phone: "+251911000000", PhoneVerified: true}
+
+	contact, verified := contactOf(customer, "email")
+	assert.Equal(t, "amy@example.com", contact)
+	assert.False(t, verified)
+
+	contact, verified = contactOf(customer, "phone")
+	assert.Equal(t, "+251911000000", contact)
+	assert.True(t, verified)
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/referrals"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Customers and merchants share their referral code; accounts signing up
+// with it are attributed to them and both sides are rewarded once the new
+// account makes its first qualifying transaction
+type ReferralHandler struct {
+	referralService *referrals.Service
+	referralRepo    *storage.ReferralRepository
+	userRepo        *storage.UserRepository
+}
+
+func NewReferralHandler(referralService *referrals.Service, referralRepo *storage.ReferralRepository, userRepo *storage.UserRepository) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+		referralRepo:    referralRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// What a referrer sees of the accounts they brought in; signup IPs, devices
+// and fraud flags stay with the admins
+type referralSummary struct {
+	ID                string                `json:"id"`
+	RefereeName       string                `json:"referee_name"`
+	RefereeRole       models.Role           `json:"referee_role"`
+	Status            models.ReferralStatus `json:"status"`
+	ReferrerRewardLCN uint64                `json:"referrer_reward_lcn"`
+	CreatedAt         time.Time             `json:"created_at"`
+	QualifiedAt       *time.Time            `json:"qualified_at,omitempty"`
+	PaidAt            *time.Time            `json:"paid_at,omitempty"`
+}
+
+// GET /api/v1/customer/referrals (requires wallet:read)
+// Returns the customer's referral code and the signups it brought in.
+func (h *ReferralHandler) GetCustomerReferrals(c *gin.Context) {
+	ctx := c.Request.Context()
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	code := customer.ReferralCode
+	if code == "" {
+		code, err = h.assignCode(c, models.RoleCustomer, customer.ID)
+		if err != nil {
+			return
+		}
+	}
+	h.respondReferrals(c, customer.ID, code)
+}
+
+// GET /api/v1/merchant/referrals (requires rewards:manage)
+// Returns the merchant's referral code and the signups it brought in.
+func (h *ReferralHandler) GetMerchantReferrals(c *gin.Context) {
+	ctx := c.Request.Context()
+	merchant, err := h.userRepo.GetMerchantByID(ctx, c.GetString("merchant_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_MERCHANT_NOT_FOUND",
+			"message": "Merchant not found",
+		})
+		return
+	}
+	code := merchant.ReferralCode
+	if code == "" {
+		code, err = h.assignCode(c, models.RoleMerchant, merchant.ID)
+		if err != nil {
+			return
+		}
+	}
+	h.respondReferrals(c, merchant.ID, code)
+}
+
+// assignCode gives an account that signed up before referral codes existed
+// its code, writing the error response on failure
+func (h *ReferralHandler) assignCode(c *gin.Context, role models.Role, accountID string) (string, error) {
+	ctx := c.Request.Context()
+	code, err := h.referralService.AssignCode(ctx, role, accountID)
+	if err == nil && code == "" {
+		// Assigned by a concurrent request
+		if role == models.RoleCustomer {
+			var customer *models.Customer
+			if customer, err = h.userRepo.GetCustomerByID(ctx, accountID); err == nil {
+				code = customer.ReferralCode
+			}
+		} else {
+			var merchant *models.Merchant
+			if merchant, err = h.userRepo.GetMerchantByID(ctx, accountID); err == nil {
+				code = merchant.ReferralCode
+			}
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to assign referral code", err, map[string]interface{}{
+			"account_id": accountID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to assign referral code",
+		})
+		return "", err
+	}
+	return code, nil
+}
+
+func (h *ReferralHandler) respondReferrals(c *gin.Context, referrerID, code string) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	list, total, err := h.referralRepo.GetReferralsByReferrer(c.Request.Context(), referrerID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get referrals", err, map[string]interface{}{
+			"referrer_id": referrerID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve referrals",
+		})
+		return
+	}
+	summaries := make([]referralSummary, 0, len(list))
+	for _, referral := range list {
+		summaries = append(summaries, referralSummary{
+			ID:                referral.ID,
+			RefereeName:       referral.Referee.Name,
+			RefereeRole:       referral.Referee.Role,
+			Status:            referral.Status,
+			ReferrerRewardLCN: referral.ReferrerRewardLCN,
+			CreatedAt:         referral.CreatedAt,
+			QualifiedAt:       referral.QualifiedAt,
+			PaidAt:            referral.PaidAt,
+		})
+	}
+
+	referrerReward, refereeReward := h.referralService.Rewards()
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"referral_code":       code,
+			"referrer_reward_lcn": referrerReward,
+			"referee_reward_lcn":  refereeReward,
+			"referrals":           summaries,
+			"total":               total,
+			"limit":               limit,
+			"offset":              offset,
+		},
+	})
+}
+
+// GET /api/v1/admin/referrals (requires referrals:review)
+// Lists referrals with their fraud flags, optionally by ?status (REVIEW for
+// the review queue).
+func (h *ReferralHandler) ListReferrals(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	statusFilter := c.Query("status")
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var status *models.ReferralStatus
+	if statusFilter != "" {
+		s := models.ReferralStatus(statusFilter)
+		status = &s
+	}
+
+	list, total, err := h.referralRepo.GetReferrals(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get referrals", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve referrals",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"referrals": list,
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+		},
+	})
+}
+
+// POST /api/v1/admin/referrals/:id/review (requires referrals:review)
+// Approves (pays) or rejects a referral held for review. A referral whose
+// payout failed can be approved again to retry it.
+func (h *ReferralHandler) ReviewReferral(c *gin.Context) {
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=APPROVE REJECT"`
+		Notes  string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	if _, err := h.referralRepo.GetReferralByID(ctx, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_REFERRAL_NOT_FOUND",
+			"message": "Referral not found",
+		})
+		return
+	}
+
+	review := h.referralService.Approve
+	event := "REFERRAL_APPROVED"
+	if req.Action == "REJECT" {
+		review = h.referralService.Reject
+		event = "REFERRAL_REJECTED"
+	}
+	referral, err := review(ctx, id, c.GetString("user_id"), req.Notes)
+	if errors.Is(err, referrals.ErrNotReviewable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_NOT_REVIEWABLE",
+			"message": "Referral is not awaiting review",
+		})
+		return
+	}
+	if referral == nil {
+		logger.Error("Failed to review referral", err, map[string]interface{}{
+			"referral_id": id,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to review referral",
+		})
+		return
+	}
+
+	auditLog(c, event, map[string]interface{}{
+		"referral_id": referral.ID,
+		"referrer_id": referral.Referrer.ID,
+		"referee_id":  referral.Referee.ID,
+		"notes":       req.Notes,
+	})
+	if err != nil {
+		// Approved, but the payout failed; the referral is FAILED and can be retried
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": err.Error(),
+			"data":    referral,
+		})
+		return
+	}
+	if referral.Status == models.ReferralPaying {
+		// Multi-sig governance: the payouts wait for admin signatures
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "ok",
+			"data":    referral,
+			"message": "Payout transactions await governance signatures (GET /admin/governance/transactions)",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   referral,
+	})
+}
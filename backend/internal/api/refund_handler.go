@@ -0,0 +1,333 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/refunds"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Merchants refund a redemption to the customer, or reverse LCN issued in
+// error back to themselves once the customer consents or an admin overrides
+type RefundHandler struct {
+	refundService *refunds.Service
+	refundRepo    *storage.RefundRepository
+}
+
+func NewRefundHandler(refundService *refunds.Service, refundRepo *storage.RefundRepository) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+		refundRepo:    refundRepo,
+	}
+}
+
+// GET /api/v1/merchant/refunds/refundable?tx_hash= (requires lcn:refund)
+// Returns which way a transaction of the merchant would be refunded and how
+// much of it is left to refund.
+func (h *RefundHandler) GetRefundable(c *gin.Context) {
+	txHash := c.Query("tx_hash")
+	if txHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "tx_hash is required",
+		})
+		return
+	}
+
+	quote, _, err := h.refundService.Quote(c.Request.Context(), c.GetString("merchant_id"), txHash)
+	if err != nil {
+		refundError(c, err, "Failed to look up transaction")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   quote,
+	})
+}
+
+// POST /api/v1/merchant/refunds (requires lcn:refund)
+// Refunds amount_lcn (all that is left if omitted) of a transaction of the
+// merchant. A redemption is refunded to the customer at once; an issuance
+// waits for the customer's consent.
+func (h *RefundHandler) RequestRefund(c *gin.Context) {
+	var req struct {
+		TxHash    string `json:"tx_hash" binding:"required"`
+		AmountLCN uint64 `json:"amount_lcn"`
+		Reason    string `json:"reason" binding:"required,max=200"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	merchantID := c.GetString("merchant_id")
+	refund, err := h.refundService.Request(ctx, merchantID, req.TxHash, req.AmountLCN, req.Reason, c.GetString("user_id"))
+	if errors.Is(err, refunds.ErrExceedsRefundable) {
+		data := gin.H{}
+		if quote, _, quoteErr := h.refundService.Quote(ctx, merchantID, req.TxHash); quoteErr == nil {
+			data["refundable_lcn"] = quote.RefundableLCN
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_EXCEEDS_REFUNDABLE",
+			"message": "Amount exceeds what can still be refunded of this transaction",
+			"data":    data,
+		})
+		return
+	}
+	if refund == nil {
+		refundError(c, err, "Failed to request refund")
+		return
+	}
+
+	auditLog(c, "REFUND_REQUESTED", map[string]interface{}{
+		"refund_id":        refund.ID,
+		"original_tx_hash": refund.OriginalTxHash,
+		"customer_id":      refund.CustomerID,
+		"direction":        refund.Direction,
+		"amount_lcn":       refund.AmountLCN,
+		"reason":           refund.Reason,
+	})
+	h.respondRefund(c, refund, err)
+}
+
+// GET /api/v1/merchant/refunds (requires lcn:refund)
+// Lists the merchant's refunds, optionally by ?status.
+func (h *RefundHandler) ListMerchantRefunds(c *gin.Context) {
+	h.listRefunds(c, c.GetString("merchant_id"), "")
+}
+
+// POST /api/v1/merchant/refunds/:id/cancel (requires lcn:refund)
+// Withdraws a reversal the customer has not consented to yet.
+func (h *RefundHandler) CancelRefund(c *gin.Context) {
+	refund, err := h.refundService.Cancel(c.Request.Context(), c.Param("id"), c.GetString("merchant_id"), c.GetString("user_id"))
+	if err != nil {
+		refundError(c, err, "Failed to cancel refund")
+		return
+	}
+	auditLog(c, "REFUND_CANCELLED", map[string]interface{}{
+		"refund_id":  refund.ID,
+		"amount_lcn": refund.AmountLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   refund,
+	})
+}
+
+// GET /api/v1/customer/refunds (requires wallet:read)
+// Lists refunds of the customer's transactions, optionally by ?status
+// (PENDING_CONSENT for those awaiting their answer).
+func (h *RefundHandler) ListCustomerRefunds(c *gin.Context) {
+	h.listRefunds(c, "", c.GetString("user_id"))
+}
+
+// POST /api/v1/customer/refunds/:id/consent (requires lcn:redeem)
+// Approves or declines a merchant's reversal of LCN issued to the customer.
+// Approving sends the LCN back to the merchant.
+func (h *RefundHandler) ConsentRefund(c *gin.Context) {
+	var req struct {
+		Approve *bool `json:"approve" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.refundService.Consent(c.Request.Context(), c.Param("id"), c.GetString("user_id"), *req.Approve)
+	if refund == nil {
+		refundError(c, err, "Failed to answer refund")
+		return
+	}
+	event := "REFUND_CONSENTED"
+	if !*req.Approve {
+		event = "REFUND_DECLINED"
+	}
+	auditLog(c, event, map[string]interface{}{
+		"refund_id":   refund.ID,
+		"merchant_id": refund.MerchantID,
+		"amount_lcn":  refund.AmountLCN,
+	})
+	h.respondRefund(c, refund, err)
+}
+
+// GET /api/v1/admin/refunds (requires refunds:override)
+// Lists all refunds, optionally by ?status, ?merchant_id and ?customer_id.
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	h.listRefunds(c, c.Query("merchant_id"), c.Query("customer_id"))
+}
+
+// POST /api/v1/admin/refunds/:id/override (requires refunds:override)
+// Sends a reversal awaiting consent back to the merchant without the
+// customer's consent, e.g. when they cannot be reached.
+func (h *RefundHandler) OverrideRefund(c *gin.Context) {
+	var req struct {
+		Notes string `json:"notes" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.refundService.Override(c.Request.Context(), c.Param("id"), c.GetString("user_id"), req.Notes)
+	if refund == nil {
+		refundError(c, err, "Failed to override refund")
+		return
+	}
+	auditLog(c, "REFUND_OVERRIDDEN", map[string]interface{}{
+		"refund_id":   refund.ID,
+		"merchant_id": refund.MerchantID,
+		"customer_id": refund.CustomerID,
+		"amount_lcn":  refund.AmountLCN,
+		"notes":       req.Notes,
+	})
+	h.respondRefund(c, refund, err)
+}
+
+func (h *RefundHandler) listRefunds(c *gin.Context, merchantID, customerID string) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	statusFilter := c.Query("status")
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var status *models.RefundStatus
+	if statusFilter != "" {
+		s := models.RefundStatus(statusFilter)
+		status = &s
+	}
+
+	list, total, err := h.refundRepo.GetRefunds(c.Request.Context(), merchantID, customerID, status, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get refunds", err, map[string]interface{}{
+			"merchant_id": merchantID,
+			"customer_id": customerID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve refunds",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"refunds": list,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
+
+// respondRefund answers with a refund, which is FAILED if sending it failed
+func (h *RefundHandler) respondRefund(c *gin.Context, refund *models.Refund, err error) {
+	if err != nil {
+		if errors.Is(err, refunds.ErrInsufficientBalance) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INSUFFICIENT_BALANCE",
+				"message": "The sending wallet does not hold enough LCN for this refund",
+				"data":    refund,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": err.Error(),
+			"data":    refund,
+		})
+		return
+	}
+	if refund.Status == models.RefundCompleted {
+		auditLog(c, "REFUND_COMPLETED", map[string]interface{}{
+			"refund_id":        refund.ID,
+			"original_tx_hash": refund.OriginalTxHash,
+			"tx_hash":          refund.TxHash,
+			"amount_lcn":       refund.AmountLCN,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   refund,
+	})
+}
+
+// refundError maps an error of the refund service to its response
+func refundError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, refunds.ErrTxNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_TX_NOT_FOUND",
+			"message": "Transaction not found",
+		})
+	case errors.Is(err, storage.ErrRefundNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_REFUND_NOT_FOUND",
+			"message": "Refund not found",
+		})
+	case errors.Is(err, refunds.ErrNotRefundable):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_NOT_REFUNDABLE",
+			"message": err.Error(),
+		})
+	case errors.Is(err, refunds.ErrExceedsRefundable):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_EXCEEDS_REFUNDABLE",
+			"message": "Nothing is left to refund of this transaction",
+		})
+	case errors.Is(err, refunds.ErrExternalWallet):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_EXTERNAL_WALLET",
+			"message": "The customer holds their own wallet; ask them to send the LCN back",
+		})
+	case errors.Is(err, refunds.ErrNotPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_NOT_PENDING",
+			"message": "Refund is not awaiting consent",
+		})
+	default:
+		logger.Error(message, err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": message,
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+type RoleHandler struct {
+	roleRepo    *storage.RoleRepository
+	userRepo    *storage.UserRepository
+	rbacService *auth.RBACService
+}
+
+func NewRoleHandler(roleRepo *storage.RoleRepository, userRepo *storage.UserRepository, rbacService *auth.RBACService) *RoleHandler {
+	return &RoleHandler{
+		roleRepo:    roleRepo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+	}
+}
+
+// GET /api/v1/admin/roles
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleRepo.GetAllRoles(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to list roles", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"roles": roles,
+			"total": len(roles),
+		},
+	})
+}
+
+// PUT /api/v1/admin/roles/:name
+func (h *RoleHandler) SaveRole(c *gin.Context) {
+	var req struct {
+		Description string              `json:"description"`
+		Scope       models.RoleScope    `json:"scope" binding:"required,oneof=PLATFORM MERCHANT CUSTOMER"`
+		Permissions []models.Permission `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	name := models.Role(c.Param("name"))
+	if err := auth.ValidatePermissions(req.Scope, req.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_PERMISSION",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Never allow the platform to lock itself out of role management
+	if name == models.RoleAdmin && !containsPermission(req.Permissions, models.PermUsersManage) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_PERMISSION",
+			"message": "The ADMIN role must keep the users:manage permission",
+		})
+		return
+	}
+
+	role := &models.RoleDefinition{
+		Name:        name,
+		Description: req.Description,
+		Scope:       req.Scope,
+		Permissions: req.Permissions,
+	}
+	if err := h.roleRepo.UpsertRole(c.Request.Context(), role); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": err.Error(),
+		})
+		return
+	}
+	h.rbacService.Invalidate()
+
+	auditLog(c, "ROLE_SAVED", map[string]interface{}{
+		"role":             name,
+		"role_scope":       req.Scope,
+		"role_permissions": req.Permissions,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   role,
+	})
+}
+
+// PUT /api/v1/admin/users/:id/role
+// Assigns a role to a platform or merchant owner account. The new role must
+// have the same scope as the current one, so merchants cannot be promoted to admins.
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	var req struct {
+		Role models.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := c.Param("id")
+	if userID == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_SELF_ASSIGNMENT",
+			"message": "You cannot change your own role",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	account, err := h.userRepo.GetMerchantByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_USER_NOT_FOUND",
+			"message": "User not found",
+		})
+		return
+	}
+
+	newRole, err := h.rbacService.GetRole(ctx, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_UNKNOWN_ROLE",
+			"message": "Role does not exist",
+		})
+		return
+	}
+	currentRole, err := h.rbacService.GetRole(ctx, account.Role)
+	if err != nil || currentRole.Scope != newRole.Scope {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_SCOPE_MISMATCH",
+			"message": "Role scope does not match the account type",
+		})
+		return
+	}
+
+	previousRole := account.Role
+	account.Role = newRole.Name
+	if err := h.userRepo.UpdateMerchant(ctx, account); err != nil {
+		logger.Error("Failed to assign role", err, map[string]interface{}{
+			"target_user_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_UPDATE_FAILED",
+			"message": "Failed to assign role",
+		})
+		return
+	}
+
+	auditLog(c, "ROLE_ASSIGNED", map[string]interface{}{
+		"target_user_id": userID,
+		"previous_role":  previousRole,
+		"new_role":       newRole.Name,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"user_id": userID,
+			"role":    newRole.Name,
+			"message": "Role takes effect on the user's next request",
+		},
+	})
+}
+
+func containsPermission(permissions []models.Permission, permission models.Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
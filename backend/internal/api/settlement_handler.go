@@ -30,9 +30,9 @@ func NewSettlementHandler(
 
 // POST /api/v1/merchant/settlement/request
 func (h *SettlementHandler) RequestSettlement(c *gin.Context) {
-	// Get user ID from JWT claims
-	userID, exists := c.Get("user_id")
-	if !exists {
+	// Staff members act on behalf of their merchant
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"code":    "401_UNAUTHORIZED",
@@ -41,7 +41,6 @@ func (h *SettlementHandler) RequestSettlement(c *gin.Context) {
 		return
 	}
 	// Verify user is a merchant
-	merchantID := userID.(string)
 	merchant, err := h.userRepo.GetMerchantByID(c.Request.Context(), merchantID)
 	if err != nil {
 		logger.Error("Failed to get merchant", err, map[string]interface{}{
@@ -106,7 +105,7 @@ func (h *SettlementHandler) RequestSettlement(c *gin.Context) {
 		})
 		return
 	}
-	logger.Audit("SETTLEMENT_REQUESTED", merchantID, map[string]interface{}{
+	auditLog(c, "SETTLEMENT_REQUESTED", map[string]interface{}{
 		"settlement_id": settlement.ID,
 		"amount_lcn":    req.AmountLCN,
 		"amount_etb":    amountETB,
@@ -127,9 +126,9 @@ func (h *SettlementHandler) RequestSettlement(c *gin.Context) {
 
 // GET /api/v1/merchant/settlement/history
 func (h *SettlementHandler) GetSettlementHistory(c *gin.Context) {
-	// Get user ID from JWT claims
-	userID, exists := c.Get("user_id")
-	if !exists {
+	// Staff members act on behalf of their merchant
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
 			"code":    "401_UNAUTHORIZED",
@@ -137,8 +136,6 @@ func (h *SettlementHandler) GetSettlementHistory(c *gin.Context) {
 		})
 		return
 	}
-
-	merchantID := userID.(string)
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	statusFilter := c.Query("status")
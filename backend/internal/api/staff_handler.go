@@ -0,0 +1,222 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+type StaffHandler struct {
+	staffRepo   *storage.StaffRepository
+	userRepo    *storage.UserRepository
+	rbacService *auth.RBACService
+	bcryptCost  int
+}
+
+func NewStaffHandler(
+	staffRepo *storage.StaffRepository,
+	userRepo *storage.UserRepository,
+	rbacService *auth.RBACService,
+	bcryptCost int,
+) *StaffHandler {
+	return &StaffHandler{
+		staffRepo:   staffRepo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		bcryptCost:  bcryptCost,
+	}
+}
+
+// validateStaffRole checks that a role can be given to a merchant sub-user.
+// The MERCHANT role is reserved for the business owner.
+func (h *StaffHandler) validateStaffRole(c *gin.Context, role models.Role) bool {
+	definition, err := h.rbacService.GetRole(c.Request.Context(), role)
+	if err != nil || definition.Scope != models.RoleScopeMerchant || role == models.RoleMerchant {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_ROLE",
+			"message": "Role cannot be assigned to merchant staff: " + string(role),
+		})
+		return false
+	}
+	return true
+}
+
+// POST /api/v1/merchant/staff
+func (h *StaffHandler) CreateStaff(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+
+	var req struct {
+		Name     string      `json:"name" binding:"required,max=100"`
+		Email    string      `json:"email" binding:"required,email"`
+		Password string      `json:"password" binding:"required"`
+		Role     models.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !h.validateStaffRole(c, req.Role) {
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_WEAK_PASSWORD",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Login looks up merchants and customers first, so the email must be unused there too
+	ctx := c.Request.Context()
+	_, merchantErr := h.userRepo.GetMerchantByEmail(ctx, req.Email)
+	_, customerErr := h.userRepo.GetCustomerByEmail(ctx, req.Email)
+	if merchantErr == nil || customerErr == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "An account with this email already exists",
+		})
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password, h.bcryptCost)
+	if err != nil {
+		logger.Error("Failed to hash password", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to process request",
+		})
+		return
+	}
+
+	staff := &models.MerchantStaff{
+		MerchantID:   merchantID,
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: passwordHash,
+		Role:         req.Role,
+		Status:       models.StatusActive,
+		CreatedBy:    c.GetString("user_id"),
+	}
+	if err := h.staffRepo.CreateStaff(ctx, staff); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	auditLog(c, "STAFF_CREATED", map[string]interface{}{
+		"staff_id":   staff.ID,
+		"staff_role": staff.Role,
+		"email":      staff.Email,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "ok",
+		"data":   staff,
+	})
+}
+
+// GET /api/v1/merchant/staff
+func (h *StaffHandler) ListStaff(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+
+	staff, err := h.staffRepo.GetStaffByMerchant(c.Request.Context(), merchantID)
+	if err != nil {
+		logger.Error("Failed to list staff", err, map[string]interface{}{
+			"merchant_id": merchantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve staff",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"staff": staff,
+			"total": len(staff),
+		},
+	})
+}
+
+// PUT /api/v1/merchant/staff/:id
+func (h *StaffHandler) UpdateStaff(c *gin.Context) {
+	merchantID := c.GetString("merchant_id")
+
+	var req struct {
+		Role   models.Role       `json:"role"`
+		Status models.UserStatus `json:"status" binding:"omitempty,oneof=ACTIVE SUSPENDED"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	staff, err := h.staffRepo.GetStaffByID(ctx, c.Param("id"), merchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_STAFF_NOT_FOUND",
+			"message": "Staff member not found",
+		})
+		return
+	}
+
+	if req.Role != "" {
+		if !h.validateStaffRole(c, req.Role) {
+			return
+		}
+		staff.Role = req.Role
+	}
+	if req.Status != "" {
+		staff.Status = req.Status
+	}
+
+	if err := h.staffRepo.UpdateStaff(ctx, staff); err != nil {
+		logger.Error("Failed to update staff member", err, map[string]interface{}{
+			"staff_id": staff.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_UPDATE_FAILED",
+			"message": "Failed to update staff member",
+		})
+		return
+	}
+
+	auditLog(c, "STAFF_UPDATED", map[string]interface{}{
+		"staff_id":     staff.ID,
+		"staff_role":   staff.Role,
+		"staff_status": staff.Status,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   staff,
+	})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/internal/tiers"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Customers climb tiers by earning and redeeming LCN; higher tiers earn more
+// per purchase
+type TierHandler struct {
+	tierService *tiers.Service
+	userRepo    *storage.UserRepository
+}
+
+func NewTierHandler(tierService *tiers.Service, userRepo *storage.UserRepository) *TierHandler {
+	return &TierHandler{
+		tierService: tierService,
+		userRepo:    userRepo,
+	}
+}
+
+// GET /api/v1/customer/tier (requires wallet:read)
+// Shows the customer's tier as last evaluated and their progress to the next.
+func (h *TierHandler) GetTier(c *gin.Context) {
+	ctx := c.Request.Context()
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	loyalty, err := h.tierService.Loyalty(ctx, customer)
+	if err != nil {
+		logger.Error("Failed to get customer tier", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve tier",
+		})
+		return
+	}
+
+	progress := h.tierService.Ladder().ProgressFor(loyalty.QualifyingLCN())
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"tier":             progress.Tier,
+			"next_tier":        progress.Next,
+			"lcn_to_next_tier": progress.LCNToNext,
+			"progress_percent": progress.PercentToNext,
+			"qualifying_lcn":   loyalty.QualifyingLCN(),
+			"earned_lcn":       loyalty.EarnedLCN,
+			"redeemed_lcn":     loyalty.RedeemedLCN,
+			"window_months":    tiers.WindowMonths,
+			"tier_since":       loyalty.TierSince,
+			"evaluated_at":     loyalty.EvaluatedAt,
+			"tiers":            h.tierService.Ladder(),
+		},
+	})
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Longest gift message a transfer can carry
+const maxGiftMessageLength = 140
+
+// Customers send LCN to each other, found by username, phone number or QR
+// handle, within daily limits
+type TransferHandler struct {
+	cardanoService    transferWallet
+	userRepo          transferUsers
+	txLogRepo         transferLogs
+	transferLimitRepo dailyTransferLimits
+	enabled           bool   // TRANSFERS_ENABLED; off where merchants want closed-loop points
+	dailyLimitLCN     uint64 // 0: no limit
+	dailyMaxTransfers int    // 0: no limit
+}
+
+// transferWallet is the part of the Cardano service transfers are sent with
+type transferWallet interface {
+	GetBalance(address string) (*cardano.Balance, error)
+	TransferADAAs(from crypto.WalletKey, toAddress string, amountLCN uint64, txType models.TxType) (string, error)
+}
+
+// transferUsers is the part of the user store transfers find customers in
+type transferUsers interface {
+	customerFinder
+	GetCustomerByID(ctx context.Context, id string) (*models.Customer, error)
+}
+
+// transferLogs is the part of the transaction log transfers annotate
+type transferLogs interface {
+	SetTxMeta(ctx context.Context, txHash string, meta map[string]interface{}) error
+}
+
+// dailyTransferLimits counts each wallet's transfers per UTC day
+type dailyTransferLimits interface {
+	GetDailyTransfers(ctx context.Context, address string, day time.Time) (*models.DailyTransfers, error)
+	ReserveDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64, maxTransfers int, limitLCN uint64) (*models.DailyTransfers, error)
+	ReleaseDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64) error
+}
+
+func NewTransferHandler(
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	transferLimitRepo *storage.TransferLimitRepository,
+	enabled bool,
+	dailyLimitLCN uint64,
+	dailyMaxTransfers int,
+) *TransferHandler {
+	return &TransferHandler{
+		cardanoService:    cardanoService,
+		userRepo:          userRepo,
+		txLogRepo:         txLogRepo,
+		transferLimitRepo: transferLimitRepo,
+		enabled:           enabled,
+		dailyLimitLCN:     dailyLimitLCN,
+		dailyMaxTransfers: dailyMaxTransfers,
+	}
+}
+
+// POST /api/v1/lcn/transfer (requires lcn:transfer)
+// Sends LCN from the customer's custodial wallet to another customer, with
+// an optional gift message kept in the transaction's meta.
+func (h *TransferHandler) Transfer(c *gin.Context) {
+	if !h.enabled {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"code":    "403_TRANSFERS_DISABLED",
+			"message": "Transfers between customers are disabled",
+		})
+		return
+	}
+	var req struct {
+		Recipient string `json:"recipient" binding:"required"` // username, phone or QR handle
+		AmountLCN uint64 `json:"amount_lcn" binding:"required,gt=0"`
+		Message   string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if utf8.RuneCountInString(req.Message) > maxGiftMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "message must be at most " + strconv.Itoa(maxGiftMessageLength) + " characters",
+		})
+		return
+	}
+
+	identifier, recipient, ok := resolveCustomer(c, h.userRepo, req.Recipient)
+	if !ok {
+		return
+	}
+	// Emails are not accepted so that transfers cannot be used to find out
+	// who has an account
+	if identifier.Kind == auth.IdentifierEmail {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_IDENTIFIER",
+			"message": "Send to a username, phone number or QR handle",
+		})
+		return
+	}
+	if recipient == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "No customer with this " + identifier.Kind,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetString("user_id")
+	sender, err := h.userRepo.GetCustomerByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	if recipient.ID == sender.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_SELF_TRANSFER",
+			"message": "You cannot send LCN to yourself",
+		})
+		return
+	}
+	if sender.Wallet.Custody == models.WalletExternal {
+		// The platform holds no key for it; the wallet can send LCN itself
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_EXTERNAL_WALLET",
+			"message": "Send LCN from your own wallet",
+		})
+		return
+	}
+	auditLog(c, "LCN_TRANSFER_INITIATED", map[string]interface{}{
+		"recipient_id": recipient.ID,
+		"amount_lcn":   req.AmountLCN,
+	})
+
+	balance, err := h.cardanoService.GetBalance(sender.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to get customer balance", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BALANCE_CHECK_FAILED",
+			"message": "Failed to verify balance",
+		})
+		return
+	}
+	if balance.LCN < float64(req.AmountLCN) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INSUFFICIENT_BALANCE",
+			"message": "Insufficient LCN balance",
+			"data": gin.H{
+				"requested": req.AmountLCN,
+				"available": balance.LCN,
+			},
+		})
+		return
+	}
+
+	// Count the transfer against today's limits before sending it, so
+	// concurrent transfers cannot exceed them together
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	daily, err := h.transferLimitRepo.ReserveDailyTransfer(ctx, sender.Wallet.Address, today, req.AmountLCN, h.dailyMaxTransfers, h.dailyLimitLCN)
+	if err != nil {
+		logger.Error("Failed to reserve daily transfer", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to check transfer limits",
+		})
+		return
+	}
+	if daily == nil {
+		sent, err := h.transferLimitRepo.GetDailyTransfers(ctx, sender.Wallet.Address, today)
+		if err != nil {
+			sent = &models.DailyTransfers{}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_DAILY_LIMIT_EXCEEDED",
+			"message": "Daily transfer limit exceeded",
+			"data":    h.limits(sent.Transfers, sent.AmountLCN),
+		})
+		return
+	}
+
+	// Transfers are neither earned nor redeemed: they count towards no tier
+	// or referral, and are recorded as such from the start
+	txHash, err := h.cardanoService.TransferADAAs(
+		walletKey(sender.ID, sender.Wallet),
+		recipient.Wallet.Address,
+		req.AmountLCN,
+		models.TxTypeTransfer,
+	)
+	if err != nil {
+		if err := h.transferLimitRepo.ReleaseDailyTransfer(context.Background(), sender.Wallet.Address, today, req.AmountLCN); err != nil {
+			logger.Warn("Failed to release daily transfer", map[string]interface{}{
+				"customer_id": userID,
+				"error":       err.Error(),
+			})
+		}
+		logger.Error("Failed to transfer LCN", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_TRANSFER_FAILED",
+			"message": "Failed to transfer LCN: " + err.Error(),
+		})
+		return
+	}
+	meta := map[string]interface{}{
+		"sender_username":    sender.Username,
+		"recipient_username": recipient.Username,
+	}
+	if req.Message != "" {
+		meta["message"] = req.Message
+	}
+	if err := h.txLogRepo.SetTxMeta(ctx, txHash, meta); err != nil {
+		logger.Error("Failed to record transfer details", err, map[string]interface{}{
+			"tx_hash": txHash,
+		})
+	}
+
+	auditLog(c, "LCN_TRANSFER_COMPLETED", map[string]interface{}{
+		"recipient_id": recipient.ID,
+		"tx_hash":      txHash,
+		"amount_lcn":   req.AmountLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"tx_hash":    txHash,
+			"amount_lcn": req.AmountLCN,
+			"recipient":  recipient.Username,
+			"message":    req.Message,
+			"limits":     h.limits(daily.Transfers, daily.AmountLCN),
+		},
+	})
+}
+
+// GET /api/v1/lcn/transfer/limits (requires lcn:transfer)
+// Shows what the customer can still send today.
+func (h *TransferHandler) GetLimits(c *gin.Context) {
+	ctx := c.Request.Context()
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	daily, err := h.transferLimitRepo.GetDailyTransfers(ctx, customer.Wallet.Address, time.Now().UTC().Truncate(24*time.Hour))
+	if err != nil {
+		logger.Error("Failed to get today's transfers", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve transfer limits",
+		})
+		return
+	}
+
+	limits := h.limits(daily.Transfers, daily.AmountLCN)
+	limits["enabled"] = h.enabled
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   limits,
+	})
+}
+
+// limits describes the daily limits after sentToday transfers of sentTodayLCN
+func (h *TransferHandler) limits(sentToday int, sentTodayLCN uint64) gin.H {
+	limits := gin.H{
+		"daily_limit_lcn":     h.dailyLimitLCN,
+		"daily_max_transfers": h.dailyMaxTransfers,
+		"sent_today_lcn":      sentTodayLCN,
+		"transfers_today":     sentToday,
+	}
+	if h.dailyLimitLCN > 0 {
+		remaining := uint64(0)
+		if sentTodayLCN < h.dailyLimitLCN {
+			remaining = h.dailyLimitLCN - sentTodayLCN
+		}
+		limits["remaining_lcn"] = remaining
+	}
+	return limits
+}
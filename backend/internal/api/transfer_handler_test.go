@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDailyTransfers mirrors the conditional updates of
+// storage.TransferLimitRepository
+type fakeDailyTransfers struct {
+	mu    sync.Mutex
+	daily map[string]*models.DailyTransfers
+}
+
+func (f *fakeDailyTransfers) GetDailyTransfers(ctx context.Context, address string, day time.Time) (*models.DailyTransfers, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.get(address, day), nil
+}
+
+func (f *fakeDailyTransfers) ReserveDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64, maxTransfers int, limitLCN uint64) (*models.DailyTransfers, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	daily := f.get(address, day)
+	if (maxTransfers > 0 && daily.Transfers >= maxTransfers) || (limitLCN > 0 && daily.AmountLCN+amountLCN > limitLCN) {
+		return nil, nil
+	}
+	daily.Transfers++
+	daily.AmountLCN += amountLCN
+	copied := *daily
+	return &copied, nil
+}
+
+func (f *fakeDailyTransfers) ReleaseDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if daily := f.get(address, day); daily.Transfers > 0 && daily.AmountLCN >= amountLCN {
+		daily.Transfers--
+		daily.AmountLCN -= amountLCN
+	}
+	return nil
+}
+
+func (f *fakeDailyTransfers) get(address string, day time.Time) *models.DailyTransfers {
+	id := address + ":" + day.Format("2006-01-02")
+	if _, ok := f.daily[id]; !ok {
+		f.daily[id] = &models.DailyTransfers{ID: id, Address: address, Day: day}
+	}
+	return f.daily[id]
+}
+
+// fakeTransferWallet records the transfers it is asked to send
+type fakeTransferWallet struct {
+	mu          sync.Mutex
+	transferErr error
+	sent        []uint64
+	txTypes     []models.TxType
+}
+
+func (w *fakeTransferWallet) GetBalance(address string) (*cardano.Balance, error) {
+	return &cardano.Balance{Address: address, LCN: 1000}, nil
+}
+
+func (w *fakeTransferWallet) TransferADAAs(from crypto.WalletKey, toAddress string, amountLCN uint64, txType models.TxType) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.transferErr != nil {
+		return "", w.transferErr
+	}
+	w.sent = append(w.sent, amountLCN)
+	w.txTypes = append(w.txTypes, txType)
+	return fmt.Sprintf("tx%d", len(w.sent)), nil
+}
+
+type fakeTransferLogs struct{}
+
+func (fakeTransferLogs) SetTxMeta(ctx context.Context, txHash string, meta map[string]interface{}) error {
+	return nil
+}
+
+func newTransferHandler(dailyLimitLCN uint64, dailyMaxTransfers int) (*TransferHandler, *fakeTransferWallet, *fakeDailyTransfers) {
+	wallet := &fakeTransferWallet{}
+	limits := &fakeDailyTransfers{daily: map[string]*models.DailyTransfers{}}
+	h := &TransferHandler{
+		cardanoService: wallet,
+		userRepo: &fakeWalletUsers{customers: map[string]*models.Customer{
+			"c1": {ID: "c1", Username: "amy", Wallet: models.Wallet{Address: "addr_c1"}},
+			"c2": {ID: "c2", Username: "ben", Wallet: models.Wallet{Address: "addr_c2"}},
+		}},
+		txLogRepo:         fakeTransferLogs{},
+		transferLimitRepo: limits,
+		enabled:           true,
+		dailyLimitLCN:     dailyLimitLCN,
+		dailyMaxTransfers: dailyMaxTransfers,
+	}
+	return h, wallet, limits
+}
+
+type transferResponse struct {
+	Code string `json:"code"`
+	Data struct {
+		TxHash string `json:"tx_hash"`
+		Limits struct {
+			SentTodayLCN   uint64 `json:"sent_today_lcn"`
+			TransfersToday int    `json:"transfers_today"`
+		} `json:"limits"`
+		SentTodayLCN   uint64 `json:"sent_today_lcn"`
+		TransfersToday int    `json:"transfers_today"`
+	} `json:"data"`
+}
+
+func transfer(t *testing.T, h *TransferHandler, amountLCN uint64) (int, transferResponse) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/lcn/transfer", strings.NewReader(fmt.Sprintf(`{"recipient":"ben","amount_lcn":%d}`, amountLCN)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", "c1")
+
+	h.Transfer(c)
+
+	var resp transferResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestTransfer_DailyLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+
+	tests := []struct {
+		name              string
+		dailyLimitLCN     uint64
+		dailyMaxTransfers int
+		amounts           []uint64
+		allowed           []bool
+	}{
+		{"max transfers", 0, 2, []uint64{10, 10, 10}, []bool{true, true, false}},
+		{"LCN limit", 100, 0, []uint64{60, 50, 40, 1}, []bool{true, false, true, false}},
+		{"more than the limit at once", 100, 0, []uint64{101, 100}, []bool{false, true}},
+		{"both limits", 100, 2, []uint64{30, 30, 30}, []bool{true, true, false}},
+		{"no limits", 0, 0, []uint64{500, 500}, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		h, wallet, _ := newTransferHandler(tt.dailyLimitLCN, tt.dailyMaxTransfers)
+		var sent []uint64
+		var sentLCN uint64
+		for i, amount := range tt.amounts {
+			code, resp := transfer(t, h, amount)
+			if !tt.allowed[i] {
+				assert.Equal(t, http.StatusBadRequest, code, "%s: transfer %d", tt.name, i)
+				assert.Equal(t, "400_DAILY_LIMIT_EXCEEDED", resp.Code, "%s: transfer %d", tt.name, i)
+				assert.Equal(t, len(sent), resp.Data.TransfersToday, "%s: transfer %d", tt.name, i)
+				assert.Equal(t, sentLCN, resp.Data.SentTodayLCN, "%s: transfer %d", tt.name, i)
+				continue
+			}
+			sent = append(sent, amount)
+			sentLCN += amount
+			assert.Equal(t, http.StatusOK, code, "%s: transfer %d", tt.name, i)
+			assert.Equal(t, len(sent), resp.Data.Limits.TransfersToday, "%s: transfer %d", tt.name, i)
+			assert.Equal(t, sentLCN, resp.Data.Limits.SentTodayLCN, "%s: transfer %d", tt.name, i)
+		}
+		assert.Equal(t, sent, wallet.sent, tt.name)
+	}
+}
+
+func TestTransfer_ConcurrentTransfersStayWithinLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, wallet, limits := newTransferHandler(100, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transfer(t, h, 30)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, wallet.sent, 3)
+	daily, _ := limits.GetDailyTransfers(context.Background(), "addr_c1", time.Now().UTC().Truncate(24*time.Hour))
+	assert.Equal(t, 3, daily.Transfers)
+	assert.Equal(t, uint64(90), daily.AmountLCN)
+}
+
+func TestTransfer_ReleasesLimitsOnFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, wallet, limits := newTransferHandler(100, 1)
+
+	wallet.transferErr = fmt.Errorf("submit failed")
+	code, resp := transfer(t, h, 80)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "500_TRANSFER_FAILED", resp.Code)
+	daily, _ := limits.GetDailyTransfers(context.Background(), "addr_c1", time.Now().UTC().Truncate(24*time.Hour))
+	assert.Zero(t, daily.Transfers)
+	assert.Zero(t, daily.AmountLCN)
+
+	// The failed transfer used up nothing
+	wallet.transferErr = nil
+	code, _ = transfer(t, h, 80)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []models.TxType{models.TxTypeTransfer}, wallet.txTypes, "recorded as a transfer when sent")
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/notify"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// How long a verification code sent to an email or phone stays valid
+const verificationCodeTTL = 15 * time.Minute
+
+// VerificationHandler lets customers prove they own their email and phone.
+// LCN merchants issued to an email or phone before signup is only paid out
+// once it is verified.
+type VerificationHandler struct {
+	userRepo          *storage.UserRepository
+	pendingRewardRepo *storage.PendingRewardRepository
+	cardanoService    *cardano.CardanoService
+	senders           map[string]notify.Sender // by channel
+}
+
+func NewVerificationHandler(
+	userRepo *storage.UserRepository,
+	pendingRewardRepo *storage.PendingRewardRepository,
+	cardanoService *cardano.CardanoService,
+	emailSender notify.Sender,
+	smsSender notify.Sender,
+) *VerificationHandler {
+	return &VerificationHandler{
+		userRepo:          userRepo,
+		pendingRewardRepo: pendingRewardRepo,
+		cardanoService:    cardanoService,
+		senders: map[string]notify.Sender{
+			auth.IdentifierEmail: emailSender,
+			auth.IdentifierPhone: smsSender,
+		},
+	}
+}
+
+// contactOf returns the customer's email or phone and whether it is verified
+func contactOf(customer *models.Customer, channel string) (string, bool) {
+	if channel == auth.IdentifierPhone {
+		return customer.Phone, customer.PhoneVerified
+	}
+	return customer.Email, customer.EmailVerified
+}
+
+// POST /api/v1/customer/verify/send
+// Sends a one-time code to the customer's email or phone
+func (h *VerificationHandler) SendCode(c *gin.Context) {
+	var req struct {
+		Channel string `json:"channel" binding:"required,oneof=email phone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "channel must be email or phone",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	contact, verified := contactOf(customer, req.Channel)
+	if contact == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_NO_CONTACT",
+			"message": "No " + req.Channel + " on this account",
+		})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_VERIFIED",
+			"message": "This " + req.Channel + " is already verified",
+		})
+		return
+	}
+
+	code, codeHash, err := auth.NewVerificationCode()
+	if err != nil {
+		logger.Error("Failed to generate verification code", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to send verification code",
+		})
+		return
+	}
+	expiresAt := time.Now().UTC().Add(verificationCodeTTL)
+	if err := h.userRepo.SetContactVerification(ctx, customer.ID, &models.ContactVerification{
+		Channel:   req.Channel,
+		Contact:   contact,
+		CodeHash:  codeHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		logger.Error("Failed to store verification code", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to send verification code",
+		})
+		return
+	}
+
+	message := fmt.Sprintf("Your LoyalCoin verification code is %s. It expires in %d minutes.", code, int(verificationCodeTTL.Minutes()))
+	if err := h.senders[req.Channel].Send(ctx, contact, "Your LoyalCoin verification code", message); err != nil {
+		if errors.Is(err, notify.ErrNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"code":    "503_CHANNEL_UNAVAILABLE",
+				"message": "Verification by " + req.Channel + " is not available",
+			})
+			return
+		}
+		logger.Error("Failed to send verification code", err, map[string]interface{}{
+			"customer_id": customer.ID,
+			"channel":     req.Channel,
+		})
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status":  "error",
+			"code":    "502_DELIVERY_FAILED",
+			"message": "Failed to send verification code",
+		})
+		return
+	}
+	auditLog(c, "CONTACT_VERIFICATION_SENT", map[string]interface{}{
+		"channel": req.Channel,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"channel":    req.Channel,
+			"expires_at": expiresAt,
+		},
+	})
+}
+
+// POST /api/v1/customer/verify
+// Checks a code sent by SendCode. Once the email or phone is verified, LCN
+// merchants issued to it before signup is paid out.
+func (h *VerificationHandler) Verify(c *gin.Context) {
+	var req struct {
+		Channel string `json:"channel" binding:"required,oneof=email phone"`
+		Code    string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "channel (email or phone) and code are required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customer, err := h.userRepo.GetCustomerByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	contact, verified := contactOf(customer, req.Channel)
+	if verified {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_VERIFIED",
+			"message": "This " + req.Channel + " is already verified",
+		})
+		return
+	}
+
+	// Count the attempt first, so codes cannot be guessed
+	counted, err := h.userRepo.CountVerificationAttempt(ctx, customer.ID, auth.VerificationMaxAttempts)
+	if err != nil {
+		logger.Error("Failed to record verification attempt", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to verify code",
+		})
+		return
+	}
+	confirmed := false
+	if counted {
+		confirmed, err = h.userRepo.ConfirmContact(ctx, customer.ID, req.Channel, contact, auth.HashVerificationCode(strings.TrimSpace(req.Code)))
+		if err != nil {
+			logger.Error("Failed to confirm contact", err, map[string]interface{}{
+				"customer_id": customer.ID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to verify code",
+			})
+			return
+		}
+	}
+	if !confirmed {
+		auditLog(c, "CONTACT_VERIFICATION_FAILED", map[string]interface{}{
+			"channel": req.Channel,
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_CODE",
+			"message": "Invalid or expired code; request a new one",
+		})
+		return
+	}
+	auditLog(c, "CONTACT_VERIFIED", map[string]interface{}{
+		"channel": req.Channel,
+	})
+
+	// Pay out LCN merchants issued to this email or phone before signup
+	identifier := contact
+	if req.Channel == auth.IdentifierEmail {
+		identifier = strings.ToLower(contact)
+	}
+	go claimPendingRewards(h.pendingRewardRepo, h.payPendingReward(customer), customer.ID, []string{identifier})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"channel":  req.Channel,
+			"verified": true,
+		},
+	})
+}
+
+// payPendingReward transfers a claimed reward from the issuing merchant's
+// wallet to the customer
+func (h *VerificationHandler) payPendingReward(customer *models.Customer) pendingRewardPayer {
+	return func(ctx context.Context, reward *models.PendingReward) (string, error) {
+		merchant, err := h.userRepo.GetMerchantByID(ctx, reward.MerchantID)
+		if err != nil {
+			return "", err
+		}
+		return h.cardanoService.TransferADA(
+			walletKey(merchant.ID, merchant.Wallet),
+			customer.Wallet.Address,
+			reward.AmountLCN,
+		)
+	}
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/internal/vouchers"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Merchants issue single-use vouchers minted as CIP-68 NFTs to customers'
+// wallets and redeem them at the counter by the code the holder shows
+type VoucherHandler struct {
+	voucherService *vouchers.Service
+	voucherRepo    *storage.VoucherRepository
+	userRepo       *storage.UserRepository
+	network        string
+}
+
+func NewVoucherHandler(voucherService *vouchers.Service, voucherRepo *storage.VoucherRepository, userRepo *storage.UserRepository, network string) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+		voucherRepo:    voucherRepo,
+		userRepo:       userRepo,
+		network:        network,
+	}
+}
+
+// POST /api/v1/merchant/vouchers (requires vouchers:issue)
+// Mints a voucher to a customer (by identifier) or to any wallet address. The
+// voucher is MINTING until the transaction confirms, then ACTIVE.
+func (h *VoucherHandler) IssueVoucher(c *gin.Context) {
+	var req struct {
+		vouchers.Request
+		CustomerAddress string `json:"customer_address" binding:"required_without=Customer"`
+		Customer        string `json:"customer" binding:"required_without=CustomerAddress"` // email, phone, username or QR handle
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := req.Request.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_VOUCHER",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	toAddress, customerID := "", ""
+	if req.CustomerAddress == "" {
+		identifier, customer, ok := resolveCustomer(c, h.userRepo, req.Customer)
+		if !ok {
+			return
+		}
+		if customer == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"code":    "404_CUSTOMER_NOT_FOUND",
+				"message": "No customer with this " + identifier.Kind,
+			})
+			return
+		}
+		toAddress, customerID = customer.Wallet.Address, customer.ID
+	} else {
+		address, ok := parseAddress(c, "customer_address", req.CustomerAddress, h.network)
+		if !ok {
+			return
+		}
+		toAddress = address.Bech32
+		// Vouchers can go to wallets without an account; they show up in the
+		// customer's list if one holds the address
+		customer, err := h.userRepo.GetCustomerByWalletAddress(ctx, toAddress)
+		if err != nil && !errors.Is(err, storage.ErrCustomerNotFound) {
+			logger.Error("Failed to get customer", err, nil)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to look up customer",
+			})
+			return
+		}
+		if customer != nil {
+			customerID = customer.ID
+		}
+	}
+
+	voucher, err := h.voucherService.Issue(ctx, c.GetString("merchant_id"), c.GetString("user_id"), req.Request, toAddress, customerID)
+	if voucher == nil {
+		logger.Error("Failed to issue voucher", err, map[string]interface{}{
+			"merchant_id": c.GetString("merchant_id"),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to issue voucher",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_MINT_FAILED",
+			"message": err.Error(),
+			"data":    voucher,
+		})
+		return
+	}
+
+	auditLog(c, "VOUCHER_ISSUED", map[string]interface{}{
+		"voucher_id":     voucher.ID,
+		"policy_id":      voucher.PolicyID,
+		"asset_name":     voucher.AssetName,
+		"holder_address": voucher.HolderAddress,
+		"customer_id":    voucher.HolderCustomerID,
+		"value":          voucher.Value,
+		"tx_hash":        voucher.MintTxHash,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   voucher,
+	})
+}
+
+// GET /api/v1/merchant/vouchers (requires vouchers:issue)
+// Lists the merchant's vouchers, optionally by ?status.
+func (h *VoucherHandler) ListMerchantVouchers(c *gin.Context) {
+	var status *models.VoucherStatus
+	if statusFilter := c.Query("status"); statusFilter != "" {
+		s := models.VoucherStatus(statusFilter)
+		status = &s
+	}
+	merchantID := c.GetString("merchant_id")
+	h.listVouchers(c, func(limit, offset int) ([]*models.Voucher, int64, error) {
+		return h.voucherRepo.GetVouchersByMerchant(c.Request.Context(), merchantID, status, limit, offset)
+	})
+}
+
+// POST /api/v1/merchant/vouchers/redeem (requires vouchers:redeem)
+// Redeems the voucher with the code the holder shows by burning it. The
+// voucher is REDEEMING until the burn confirms, then REDEEMED.
+func (h *VoucherHandler) RedeemVoucher(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	voucher, err := h.voucherService.Redeem(c.Request.Context(), c.GetString("merchant_id"), req.Code, c.GetString("user_id"))
+	if err != nil {
+		voucherError(c, err)
+		return
+	}
+
+	auditLog(c, "VOUCHER_REDEEMED", map[string]interface{}{
+		"voucher_id":  voucher.ID,
+		"customer_id": voucher.HolderCustomerID,
+		"value":       voucher.Value,
+		"tx_hash":     voucher.BurnTxHash,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data":   voucher,
+	})
+}
+
+// GET /api/v1/customer/vouchers (requires wallet:read)
+// Lists the vouchers the customer holds or redeemed, with the codes to show
+// at the counter.
+func (h *VoucherHandler) ListCustomerVouchers(c *gin.Context) {
+	customerID := c.GetString("user_id")
+	h.listVouchers(c, func(limit, offset int) ([]*models.Voucher, int64, error) {
+		return h.voucherRepo.GetVouchersByHolder(c.Request.Context(), customerID, limit, offset)
+	})
+}
+
+func (h *VoucherHandler) listVouchers(c *gin.Context, query func(limit, offset int) ([]*models.Voucher, int64, error)) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	list, total, err := query(limit, offset)
+	if err != nil {
+		logger.Error("Failed to get vouchers", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_QUERY_FAILED",
+			"message": "Failed to retrieve vouchers",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, voucher := range list {
+		voucher.Status = vouchers.Status(voucher, now)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"vouchers": list,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+		},
+	})
+}
+
+// voucherError maps an error of a voucher redemption to its response
+func voucherError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrVoucherNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_VOUCHER_NOT_FOUND",
+			"message": "No voucher with this code",
+		})
+	case errors.Is(err, vouchers.ErrExpired):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_VOUCHER_EXPIRED",
+			"message": "Voucher has expired",
+		})
+	case errors.Is(err, vouchers.ErrNotActive):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_VOUCHER_NOT_ACTIVE",
+			"message": "Voucher is not active (not yet confirmed, already redeemed or being redeemed)",
+		})
+	case errors.Is(err, vouchers.ErrExternalWallet):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_EXTERNAL_WALLET",
+			"message": "The voucher is held in a wallet the platform cannot sign for; it cannot be redeemed here",
+		})
+	case errors.Is(err, vouchers.ErrPolicyMismatch):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_POLICY_MISMATCH",
+			"message": "The voucher was minted by a previous merchant wallet and cannot be burned with the current one",
+		})
+	default:
+		logger.Error("Failed to redeem voucher", err, nil)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BURN_FAILED",
+			"message": "Failed to redeem voucher",
+		})
+	}
+}
@@ -1,31 +1,100 @@
 package api
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
 	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
 	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/refunds"
 	"github.com/loyalcoin/backend/internal/storage"
 	"github.com/loyalcoin/backend/pkg/logger"
 )
 
+// How long a customer has to sign a redemption built for their external wallet
+const externalTxTTL = 15 * time.Minute
+
 type WalletHandler struct {
-	cardanoService *cardano.CardanoService
-	userRepo       *storage.UserRepository
-	txLogRepo      *storage.TxLogRepository
+	cardanoService        walletChain
+	userRepo              walletUsers
+	txLogRepo             *storage.TxLogRepository
+	externalTxRepo        externalTxs
+	pendingRewardRepo     pendingRewards
+	paymentRequestRepo    paymentRequests
+	network               string // CARDANO_NETWORK; user-supplied addresses must match it
+	redeemToMerchantsOnly bool   // redemptions may only pay registered merchant wallets
+	pendingRewardTTLDays  int
+}
+
+// walletChain is the part of the Cardano service wallets move LCN with
+type walletChain interface {
+	GetBalance(address string) (*cardano.Balance, error)
+	TransferADA(from crypto.WalletKey, toAddress string, amountLCN uint64) (string, error)
+	BuildExternalTransfer(fromAddress, toAddress string, amountLCN uint64, validUntil time.Time) (*cardano.ExternalTransfer, error)
+	SubmitExternalTransfer(tx *models.ExternalTx, witnesses []crypto.VKeyWitness) (string, error)
+}
+
+// walletUsers is the part of the user store wallets look accounts up in
+type walletUsers interface {
+	customerFinder
+	GetCustomerByID(ctx context.Context, id string) (*models.Customer, error)
+	GetMerchantByID(ctx context.Context, id string) (*models.Merchant, error)
+	GetMerchantByWalletAddress(ctx context.Context, address string) (*models.Merchant, error)
+}
+
+// externalTxs tracks transactions waiting for a customer's external wallet
+type externalTxs interface {
+	CreateExternalTx(ctx context.Context, tx *models.ExternalTx) error
+	GetExternalTxByID(ctx context.Context, id string) (*models.ExternalTx, error)
+	TransitionStatus(ctx context.Context, id string, from, to models.ExternalTxStatus) error
+	CompleteExternalTx(ctx context.Context, id string, status models.ExternalTxStatus, submittedTxHash, errMsg string) error
+}
+
+// pendingRewards is the part of the pending reward store issuance holds LCN
+// for customers who have not signed up in
+type pendingRewards interface {
+	CreatePendingReward(ctx context.Context, reward *models.PendingReward) error
+	SumOutstandingByMerchant(ctx context.Context, merchantID string) (uint64, error)
+	CancelPendingReward(ctx context.Context, id, merchantID string) error
+}
+
+// paymentRequests is the part of the payment request store customers pay through
+type paymentRequests interface {
+	GetPaymentRequestByID(ctx context.Context, id string) (*models.PaymentRequest, error)
+	ReservePaymentRequest(ctx context.Context, id, customerID string, until time.Time) (*models.PaymentRequest, error)
+	ReleasePaymentRequest(ctx context.Context, id, customerID string) error
+	SetExternalTx(ctx context.Context, id, customerID, externalTxID string) error
+	MarkSubmitted(ctx context.Context, id, customerID, txHash string) error
 }
 
 func NewWalletHandler(
 	cardanoService *cardano.CardanoService,
 	userRepo *storage.UserRepository,
 	txLogRepo *storage.TxLogRepository,
+	externalTxRepo *storage.ExternalTxRepository,
+	pendingRewardRepo *storage.PendingRewardRepository,
+	paymentRequestRepo *storage.PaymentRequestRepository,
+	network string,
+	redeemToMerchantsOnly bool,
+	pendingRewardTTLDays int,
 ) *WalletHandler {
 	return &WalletHandler{
-		cardanoService: cardanoService,
-		userRepo:       userRepo,
-		txLogRepo:      txLogRepo,
+		cardanoService:        cardanoService,
+		userRepo:              userRepo,
+		txLogRepo:             txLogRepo,
+		externalTxRepo:        externalTxRepo,
+		pendingRewardRepo:     pendingRewardRepo,
+		paymentRequestRepo:    paymentRequestRepo,
+		network:               network,
+		redeemToMerchantsOnly: redeemToMerchantsOnly,
+		pendingRewardTTLDays:  pendingRewardTTLDays,
 	}
 }
 
@@ -56,12 +125,13 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
 		"data": gin.H{
-			"address":      balance.Address,
-			"ada":          balance.ADA,
-			"lovelace":     balance.Lovelace,
-			"lcn":          balance.LCN,
-			"lcn_atomic":   balance.LCNAtomic,
-			"other_assets": balance.OtherAssets,
+			"address":         balance.Address,
+			"ada":             balance.ADA,
+			"lovelace":        balance.Lovelace,
+			"lcn":             balance.LCN,
+			"lcn_atomic":      balance.LCNAtomic,
+			"other_assets":    balance.OtherAssets,
+			"locked_lovelace": balance.LockedLovelace,
 		},
 	})
 }
@@ -123,6 +193,31 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 		if tx.BlockHeight > 0 {
 			txData["block_height"] = tx.BlockHeight
 		}
+		// Gifts between customers show who sent them and their message
+		if tx.Type == models.TxTypeTransfer {
+			for _, key := range []string{"sender_username", "recipient_username", "message"} {
+				if value, ok := tx.Meta[key]; ok {
+					txData[key] = value
+				}
+			}
+		}
+		// Catalog orders and their refunds show what was ordered
+		if tx.Type == models.TxTypeRedemption || tx.Type == models.TxTypeRefund {
+			for _, key := range []string{"order_id", "item_name"} {
+				if value, ok := tx.Meta[key]; ok {
+					txData[key] = value
+				}
+			}
+		}
+		// Refunds link to the transaction they refund, and it links back
+		for _, key := range []string{"refund_of", "refund_id", "reason", "refund_tx_hashes"} {
+			if value, ok := tx.Meta[key]; ok {
+				txData[key] = value
+			}
+		}
+		if refunded := refunds.Refunded(tx); refunded > 0 {
+			txData["refunded_lcn"] = float64(refunded) / 1000
+		}
 		transactions = append(transactions, txData)
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -136,13 +231,11 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 	})
 }
 
-// POST /api/v1/lcn/issue (Merchant only)
+// POST /api/v1/lcn/issue (requires lcn:issue)
 func (h *WalletHandler) IssueLCN(c *gin.Context) {
-	userID := c.GetString("user_id")
-	roleValue, _ := c.Get("role")
-	role := roleValue.(models.Role)
-
-	if role != models.RoleMerchant {
+	// Cashiers and managers issue from their merchant's wallet
+	merchantID := c.GetString("merchant_id")
+	if merchantID == "" {
 		c.JSON(http.StatusForbidden, gin.H{
 			"status":  "error",
 			"code":    "403_MERCHANT_ONLY",
@@ -151,7 +244,9 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 		return
 	}
 	var req struct {
-		CustomerAddress string  `json:"customer_address" binding:"required"`
+		CustomerAddress string  `json:"customer_address" binding:"required_without=Customer"`
+		Customer        string  `json:"customer" binding:"required_without=CustomerAddress"` // email, phone, username or QR handle
+		CreatePending   bool    `json:"create_pending"`                                      // hold the LCN for an unknown email/phone until signup
 		AmountLCN       float64 `json:"amount_lcn" binding:"required,gt=0"`
 		Reference       string  `json:"reference"`
 	}
@@ -163,16 +258,45 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 		})
 		return
 	}
-	logger.Audit("LCN_ISSUANCE_INITIATED", userID, map[string]interface{}{
+
+	ctx := c.Request.Context()
+	var pending *auth.CustomerIdentifier
+	if req.CustomerAddress == "" {
+		identifier, customer, ok := resolveCustomer(c, h.userRepo, req.Customer)
+		if !ok {
+			return
+		}
+		switch {
+		case customer != nil:
+			req.CustomerAddress = customer.Wallet.Address
+		// Usernames and handles only exist once the customer has signed up
+		case req.CreatePending && (identifier.Kind == auth.IdentifierEmail || identifier.Kind == auth.IdentifierPhone):
+			pending = &identifier
+		default:
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"code":    "404_CUSTOMER_NOT_FOUND",
+				"message": "No customer with this " + identifier.Kind + "; set create_pending to reward an email or phone number that has not signed up yet",
+			})
+			return
+		}
+	} else {
+		customerAddress, ok := parseAddress(c, "customer_address", req.CustomerAddress, h.network)
+		if !ok {
+			return
+		}
+		req.CustomerAddress = customerAddress.Bech32
+	}
+	auditLog(c, "LCN_ISSUANCE_INITIATED", map[string]interface{}{
 		"customer_address": req.CustomerAddress,
+		"customer":         req.Customer,
 		"amount_lcn":       req.AmountLCN,
 	})
 
-	ctx := c.Request.Context()
-	merchant, err := h.userRepo.GetMerchantByID(ctx, userID)
+	merchant, err := h.userRepo.GetMerchantByID(ctx, merchantID)
 	if err != nil {
 		logger.Error("Merchant not found", err, map[string]interface{}{
-			"user_id": userID,
+			"merchant_id": merchantID,
 		})
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "error",
@@ -186,7 +310,7 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 	balance, err := h.cardanoService.GetBalance(merchant.Wallet.Address)
 	if err != nil {
 		logger.Error("Failed to get merchant balance", err, map[string]interface{}{
-			"user_id": userID,
+			"merchant_id": merchantID,
 		})
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -210,16 +334,89 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 		return
 	}
 
+	if pending != nil {
+		// The balance stays in the merchant's wallet until the customer signs
+		// up, so rewards still pending already hold part of it
+		outstanding, err := h.pendingRewardRepo.SumOutstandingByMerchant(ctx, merchantID)
+		if err != nil {
+			logger.Error("Failed to sum pending rewards", err, map[string]interface{}{
+				"merchant_id": merchantID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_BALANCE_CHECK_FAILED",
+				"message": "Failed to verify merchant balance",
+			})
+			return
+		}
+		if balance.LCN < float64(outstanding)+req.AmountLCN {
+			pendingRewardsExceedBalance(c, req.AmountLCN, balance.LCN, outstanding)
+			return
+		}
+
+		reward := &models.PendingReward{
+			MerchantID:     merchantID,
+			IssuedBy:       c.GetString("user_id"),
+			IdentifierType: pending.Kind,
+			Identifier:     pending.Value,
+			AmountLCN:      uint64(req.AmountLCN),
+			Reference:      req.Reference,
+			ExpiresAt:      time.Now().UTC().AddDate(0, 0, h.pendingRewardTTLDays),
+		}
+		if err := h.pendingRewardRepo.CreatePendingReward(ctx, reward); err != nil {
+			logger.Error("Failed to create pending reward", err, map[string]interface{}{
+				"merchant_id": merchantID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"code":    "500_INTERNAL_ERROR",
+				"message": "Failed to create pending reward",
+			})
+			return
+		}
+		// Rewards created at the same time all passed the check above; recount
+		// with them and back out rather than promise more than the balance
+		outstanding, err = h.pendingRewardRepo.SumOutstandingByMerchant(ctx, merchantID)
+		if err == nil && balance.LCN < float64(outstanding) {
+			err = h.pendingRewardRepo.CancelPendingReward(ctx, reward.ID, merchantID)
+			if err == nil {
+				pendingRewardsExceedBalance(c, req.AmountLCN, balance.LCN, outstanding-reward.AmountLCN)
+				return
+			}
+		}
+		if err != nil {
+			logger.Error("Failed to recheck pending rewards", err, map[string]interface{}{
+				"merchant_id":       merchantID,
+				"pending_reward_id": reward.ID,
+			})
+		}
+		auditLog(c, "PENDING_REWARD_CREATED", map[string]interface{}{
+			"pending_reward_id": reward.ID,
+			"identifier_type":   reward.IdentifierType,
+			"amount_lcn":        reward.AmountLCN,
+		})
+		c.JSON(http.StatusAccepted, gin.H{
+			"status": "ok",
+			"data": gin.H{
+				"pending_reward_id": reward.ID,
+				"status":            reward.Status,
+				"amount_lcn":        reward.AmountLCN,
+				"expires_at":        reward.ExpiresAt,
+				"message":           "The customer receives the LCN when they sign up with this " + reward.IdentifierType,
+			},
+		})
+		return
+	}
+
 	// Transfer LCN (TransferADA expects whole LCN and converts to lovelace internally)
 	txHash, err := h.cardanoService.TransferADA(
-		merchant.Wallet.Address,
+		walletKey(merchant.ID, merchant.Wallet),
 		req.CustomerAddress,
 		uint64(req.AmountLCN),
-		merchant.Wallet.EncryptedPrivateKey,
 	)
 	if err != nil {
 		logger.Error("Failed to issue LCN", err, map[string]interface{}{
-			"merchant_id": userID,
+			"merchant_id": merchantID,
 		})
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -229,7 +426,7 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 		return
 	}
 	logger.Info("LCN issued successfully", map[string]interface{}{
-		"merchant_id": userID,
+		"merchant_id": merchantID,
 		"tx_hash":     txHash,
 		"amount_lcn":  req.AmountLCN,
 	})
@@ -243,21 +440,26 @@ func (h *WalletHandler) IssueLCN(c *gin.Context) {
 	})
 }
 
-// POST /api/v1/lcn/redeem (Customer only)
+// pendingRewardsExceedBalance rejects a pending reward the merchant's balance
+// cannot cover next to the rewards already pending
+func pendingRewardsExceedBalance(c *gin.Context, requested, balance float64, outstanding uint64) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":  "error",
+		"code":    "400_INSUFFICIENT_BALANCE",
+		"message": "Insufficient LCN balance: pending rewards already hold part of it",
+		"data": gin.H{
+			"requested":           requested,
+			"available":           max(balance-float64(outstanding), 0),
+			"pending_rewards_lcn": outstanding,
+		},
+	})
+}
+
+// POST /api/v1/lcn/redeem (requires lcn:redeem)
+// Customers with an external wallet get an unsigned transaction to sign and
+// pass to POST /api/v1/lcn/redeem/:id/submit.
 func (h *WalletHandler) RedeemLCN(c *gin.Context) {
 	userID := c.GetString("user_id")
-	roleValue, _ := c.Get("role")
-	role := roleValue.(models.Role)
-
-	// Verify customer role
-	if role != models.RoleCustomer {
-		c.JSON(http.StatusForbidden, gin.H{
-			"status":  "error",
-			"code":    "403_CUSTOMER_ONLY",
-			"message": "Only customers can redeem LCN",
-		})
-		return
-	}
 	var req struct {
 		MerchantAddress string  `json:"merchant_address" binding:"required"`
 		AmountLCN       float64 `json:"amount_lcn" binding:"required,gt=0"`
@@ -270,13 +472,30 @@ func (h *WalletHandler) RedeemLCN(c *gin.Context) {
 		})
 		return
 	}
-	logger.Audit("LCN_REDEMPTION_INITIATED", userID, map[string]interface{}{
+	merchantAddress, ok := parseAddress(c, "merchant_address", req.MerchantAddress, h.network)
+	if !ok {
+		return
+	}
+	req.MerchantAddress = merchantAddress.Bech32
+	auditLog(c, "LCN_REDEMPTION_INITIATED", map[string]interface{}{
 		"merchant_address": req.MerchantAddress,
 		"amount_lcn":       req.AmountLCN,
 	})
 
-	// Get customer
 	ctx := c.Request.Context()
+	if h.redeemToMerchantsOnly {
+		merchant, err := h.userRepo.GetMerchantByWalletAddress(ctx, req.MerchantAddress)
+		if err != nil || merchant.Role != models.RoleMerchant {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_UNKNOWN_MERCHANT",
+				"message": "merchant_address is not a registered merchant wallet",
+			})
+			return
+		}
+	}
+
+	// Get customer
 	customer, err := h.userRepo.GetCustomerByID(ctx, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -312,12 +531,16 @@ func (h *WalletHandler) RedeemLCN(c *gin.Context) {
 		})
 		return
 	}
+	if customer.Wallet.Custody == models.WalletExternal {
+		h.buildExternalRedemption(c, customer, req.MerchantAddress, uint64(req.AmountLCN), "")
+		return
+	}
+
 	// Transfer LCN (TransferADA expects whole LCN and converts to lovelace internally)
 	txHash, err := h.cardanoService.TransferADA(
-		customer.Wallet.Address,
+		walletKey(customer.ID, customer.Wallet),
 		req.MerchantAddress,
 		uint64(req.AmountLCN), // Pass whole LCN, not atomic units
-		customer.Wallet.EncryptedPrivateKey,
 	)
 	if err != nil {
 		logger.Error("Failed to redeem LCN", err, map[string]interface{}{
@@ -343,3 +566,520 @@ func (h *WalletHandler) RedeemLCN(c *gin.Context) {
 		},
 	})
 }
+
+// buildExternalRedemption builds an unsigned redemption for the customer's
+// external wallet to sign, optionally paying a payment request. Returns false
+// if it wrote an error response.
+func (h *WalletHandler) buildExternalRedemption(c *gin.Context, customer *models.Customer, merchantAddress string, amountLCN uint64, paymentRequestID string) bool {
+	expiresAt := time.Now().UTC().Add(externalTxTTL)
+	transfer, err := h.cardanoService.BuildExternalTransfer(customer.Wallet.Address, merchantAddress, amountLCN, expiresAt)
+	if err != nil {
+		logger.Error("Failed to build external wallet redemption", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_REDEMPTION_FAILED",
+			"message": "Failed to build redemption transaction: " + err.Error(),
+		})
+		return false
+	}
+
+	tx := &models.ExternalTx{
+		Purpose:          models.TxTypeRedemption,
+		CustomerID:       customer.ID,
+		FromAddress:      customer.Wallet.Address,
+		ToAddress:        merchantAddress,
+		AmountLCN:        amountLCN,
+		KeyHash:          customer.Wallet.PaymentKeyHash,
+		TxHash:           transfer.TxHash,
+		TxCBOR:           transfer.TxCBOR,
+		PaymentRequestID: paymentRequestID,
+		ExpiresAt:        expiresAt,
+	}
+	if err := h.externalTxRepo.CreateExternalTx(c.Request.Context(), tx); err != nil {
+		logger.Error("Failed to store external wallet redemption", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to create redemption",
+		})
+		return false
+	}
+
+	if paymentRequestID != "" {
+		if err := h.paymentRequestRepo.SetExternalTx(c.Request.Context(), paymentRequestID, customer.ID, tx.ID); err != nil {
+			logger.Warn("Failed to link payment request to external transaction", map[string]interface{}{
+				"payment_request_id": paymentRequestID,
+				"error":              err.Error(),
+			})
+		}
+	}
+
+	auditLog(c, "LCN_REDEMPTION_AWAITING_SIGNATURE", map[string]interface{}{
+		"external_tx_id": tx.ID,
+		"tx_hash":        tx.TxHash,
+		"amount_lcn":     amountLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"id":         tx.ID,
+			"status":     tx.Status,
+			"tx_hash":    tx.TxHash,
+			"tx_cbor":    tx.TxCBOR,
+			"amount_lcn": amountLCN,
+			"expires_at": tx.ExpiresAt,
+		},
+	})
+	return true
+}
+
+// POST /api/v1/lcn/redeem/:id/submit (requires lcn:redeem)
+// Accepts the witness set from the customer's wallet (CIP-30 signTx, hex),
+// checks it signs the transaction with the registered key and submits it.
+func (h *WalletHandler) SubmitExternalRedemption(c *gin.Context) {
+	userID := c.GetString("user_id")
+	id := c.Param("id")
+	var req struct {
+		WitnessSet string `json:"witness_set" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.externalTxRepo.GetExternalTxByID(ctx, id)
+	if err != nil || tx.CustomerID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_NOT_FOUND",
+			"message": "Redemption not found",
+		})
+		return
+	}
+	if tx.Status != models.ExternalTxAwaitingSignature {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "Redemption is not awaiting a signature (status: " + string(tx.Status) + ")",
+		})
+		return
+	}
+	if time.Now().After(tx.ExpiresAt) {
+		if err := h.externalTxRepo.TransitionStatus(ctx, tx.ID, models.ExternalTxAwaitingSignature, models.ExternalTxExpired); err != nil {
+			logger.Warn("Failed to expire external transaction", map[string]interface{}{
+				"external_tx_id": tx.ID,
+				"error":          err.Error(),
+			})
+		}
+		c.JSON(http.StatusGone, gin.H{
+			"status":  "error",
+			"code":    "410_EXPIRED",
+			"message": "Redemption expired; request a new one",
+		})
+		return
+	}
+
+	// Keep only witnesses that sign this transaction with the registered key
+	witnessSet, err := hex.DecodeString(req.WitnessSet)
+	var witnesses []crypto.VKeyWitness
+	if err == nil {
+		witnesses, err = crypto.DecodeVKeyWitnessSet(witnessSet)
+	}
+	keyHash, _ := hex.DecodeString(tx.KeyHash)
+	txHash, _ := hex.DecodeString(tx.TxHash)
+	var valid []crypto.VKeyWitness
+	for _, w := range witnesses {
+		if w.Verify(keyHash, txHash) {
+			valid = append(valid, w)
+		}
+	}
+	if len(valid) == 0 {
+		auditLog(c, "LCN_REDEMPTION_WITNESS_REJECTED", map[string]interface{}{
+			"external_tx_id": tx.ID,
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_WITNESS",
+			"message": "Witness set does not sign this transaction with the registered wallet key",
+		})
+		return
+	}
+
+	if err := h.externalTxRepo.TransitionStatus(ctx, tx.ID, models.ExternalTxAwaitingSignature, models.ExternalTxSubmitting); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_CONFLICT",
+			"message": "Redemption is already being submitted",
+		})
+		return
+	}
+
+	submittedHash, err := h.cardanoService.SubmitExternalTransfer(tx, valid[:1])
+	if err != nil {
+		if completeErr := h.externalTxRepo.CompleteExternalTx(ctx, tx.ID, models.ExternalTxFailed, "", err.Error()); completeErr != nil {
+			logger.Warn("Failed to record external transaction failure", map[string]interface{}{
+				"external_tx_id": tx.ID,
+				"error":          completeErr.Error(),
+			})
+		}
+		logger.Error("Failed to submit external wallet redemption", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_REDEMPTION_FAILED",
+			"message": "Failed to submit redemption: " + err.Error(),
+		})
+		return
+	}
+	if err := h.externalTxRepo.CompleteExternalTx(ctx, tx.ID, models.ExternalTxSubmitted, submittedHash, ""); err != nil {
+		logger.Warn("Failed to record external transaction submission", map[string]interface{}{
+			"external_tx_id": tx.ID,
+			"error":          err.Error(),
+		})
+	}
+	if tx.PaymentRequestID != "" {
+		if err := h.paymentRequestRepo.MarkSubmitted(ctx, tx.PaymentRequestID, userID, submittedHash); err != nil {
+			logger.Warn("Failed to record payment request submission", map[string]interface{}{
+				"payment_request_id": tx.PaymentRequestID,
+				"error":              err.Error(),
+			})
+		}
+	}
+
+	auditLog(c, "LCN_REDEMPTION_COMPLETED", map[string]interface{}{
+		"external_tx_id":   tx.ID,
+		"merchant_address": tx.ToAddress,
+		"tx_hash":          submittedHash,
+		"amount_lcn":       tx.AmountLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"tx_hash":    submittedHash,
+			"amount_lcn": tx.AmountLCN,
+		},
+	})
+}
+
+// GET /api/v1/lcn/pay/:request_id (requires lcn:redeem)
+// Shows the customer what a scanned payment request asks for before paying it.
+func (h *WalletHandler) GetPayableRequest(c *gin.Context) {
+	request, err := h.paymentRequestRepo.GetPaymentRequestByID(c.Request.Context(), c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_PAYMENT_REQUEST_NOT_FOUND",
+			"message": "Payment request not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"id":               request.ID,
+			"business_name":    request.BusinessName,
+			"merchant_address": request.MerchantAddress,
+			"amount_lcn":       request.AmountLCN,
+			"description":      request.Description,
+			"status":           paymentRequestStatus(request),
+			"paid_by_you":      request.CustomerID == c.GetString("user_id"),
+			"expires_at":       request.ExpiresAt,
+		},
+	})
+}
+
+// POST /api/v1/lcn/pay/:request_id (requires lcn:redeem)
+// Pays a merchant's payment request. The request is reserved for the customer
+// before any transfer, so scanning or submitting twice never pays twice:
+// repeats return the payment already made.
+func (h *WalletHandler) PayRequest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req struct {
+		AmountLCN uint64 `json:"amount_lcn" binding:"required,gt=0"` // the amount the customer confirmed
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_REQUEST",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	request, err := h.paymentRequestRepo.GetPaymentRequestByID(ctx, c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_PAYMENT_REQUEST_NOT_FOUND",
+			"message": "Payment request not found",
+		})
+		return
+	}
+
+	// Repeated payments by the same customer return the existing payment
+	if request.CustomerID == userID {
+		switch request.Status {
+		case models.PaymentRequestSubmitted, models.PaymentRequestPaid:
+			c.JSON(http.StatusOK, gin.H{
+				"status": "ok",
+				"data": gin.H{
+					"payment_request_id": request.ID,
+					"status":             request.Status,
+					"tx_hash":            request.TxHash,
+					"amount_lcn":         request.AmountLCN,
+				},
+			})
+			return
+		case models.PaymentRequestPaying:
+			if request.ReservedUntil != nil && time.Now().Before(*request.ReservedUntil) {
+				// Hand the external wallet the transaction it still has to sign
+				if tx, err := h.externalTxRepo.GetExternalTxByID(ctx, request.ExternalTxID); err == nil && tx.Status == models.ExternalTxAwaitingSignature {
+					c.JSON(http.StatusOK, gin.H{
+						"status": "ok",
+						"data": gin.H{
+							"id":         tx.ID,
+							"status":     tx.Status,
+							"tx_hash":    tx.TxHash,
+							"tx_cbor":    tx.TxCBOR,
+							"amount_lcn": tx.AmountLCN,
+							"expires_at": tx.ExpiresAt,
+						},
+					})
+					return
+				}
+				c.JSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"code":    "409_PAYMENT_IN_PROGRESS",
+					"message": "This payment is already in progress",
+				})
+				return
+			}
+		}
+	}
+	switch paymentRequestStatus(request) {
+	case models.PaymentRequestCancelled:
+		c.JSON(http.StatusGone, gin.H{
+			"status":  "error",
+			"code":    "410_CANCELLED",
+			"message": "The merchant cancelled this payment request",
+		})
+		return
+	case models.PaymentRequestExpired:
+		c.JSON(http.StatusGone, gin.H{
+			"status":  "error",
+			"code":    "410_EXPIRED",
+			"message": "Payment request expired; ask the merchant for a new one",
+		})
+		return
+	}
+	if req.AmountLCN != request.AmountLCN {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_AMOUNT_MISMATCH",
+			"message": "Amount does not match the payment request",
+			"data": gin.H{
+				"requested": request.AmountLCN,
+			},
+		})
+		return
+	}
+
+	customer, err := h.userRepo.GetCustomerByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"code":    "404_CUSTOMER_NOT_FOUND",
+			"message": "Customer not found",
+		})
+		return
+	}
+	balance, err := h.cardanoService.GetBalance(customer.Wallet.Address)
+	if err != nil {
+		logger.Error("Failed to get customer balance", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_BALANCE_CHECK_FAILED",
+			"message": "Failed to verify balance",
+		})
+		return
+	}
+	if balance.LCN < float64(request.AmountLCN) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INSUFFICIENT_BALANCE",
+			"message": "Insufficient LCN balance",
+			"data": gin.H{
+				"requested": request.AmountLCN,
+				"available": balance.LCN,
+			},
+		})
+		return
+	}
+
+	// Reserve for as long as an external wallet has to sign
+	request, err = h.paymentRequestRepo.ReservePaymentRequest(ctx, request.ID, userID, time.Now().UTC().Add(externalTxTTL))
+	if err != nil {
+		logger.Error("Failed to reserve payment request", err, map[string]interface{}{
+			"customer_id": userID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to pay payment request",
+		})
+		return
+	}
+	if request == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_ALREADY_PAID",
+			"message": "Payment request is already paid or being paid",
+		})
+		return
+	}
+	auditLog(c, "PAYMENT_REQUEST_PAYMENT_INITIATED", map[string]interface{}{
+		"payment_request_id": request.ID,
+		"merchant_address":   request.MerchantAddress,
+		"amount_lcn":         request.AmountLCN,
+	})
+
+	if customer.Wallet.Custody == models.WalletExternal {
+		if !h.buildExternalRedemption(c, customer, request.MerchantAddress, request.AmountLCN, request.ID) {
+			h.releasePaymentRequest(request.ID, userID)
+		}
+		return
+	}
+
+	txHash, err := h.cardanoService.TransferADA(
+		walletKey(customer.ID, customer.Wallet),
+		request.MerchantAddress,
+		request.AmountLCN,
+	)
+	if err != nil {
+		h.releasePaymentRequest(request.ID, userID)
+		logger.Error("Failed to pay payment request", err, map[string]interface{}{
+			"customer_id":        userID,
+			"payment_request_id": request.ID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_REDEMPTION_FAILED",
+			"message": "Failed to pay: " + err.Error(),
+		})
+		return
+	}
+	if err := h.paymentRequestRepo.MarkSubmitted(ctx, request.ID, userID, txHash); err != nil {
+		logger.Warn("Failed to record payment request submission", map[string]interface{}{
+			"payment_request_id": request.ID,
+			"tx_hash":            txHash,
+			"error":              err.Error(),
+		})
+	}
+
+	auditLog(c, "PAYMENT_REQUEST_PAID", map[string]interface{}{
+		"payment_request_id": request.ID,
+		"tx_hash":            txHash,
+		"amount_lcn":         request.AmountLCN,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"payment_request_id": request.ID,
+			"status":             models.PaymentRequestSubmitted,
+			"tx_hash":            txHash,
+			"amount_lcn":         request.AmountLCN,
+		},
+	})
+}
+
+// releasePaymentRequest reopens a reserved payment request after a failed payment
+func (h *WalletHandler) releasePaymentRequest(requestID, customerID string) {
+	if err := h.paymentRequestRepo.ReleasePaymentRequest(context.Background(), requestID, customerID); err != nil {
+		logger.Warn("Failed to release payment request", map[string]interface{}{
+			"payment_request_id": requestID,
+			"error":              err.Error(),
+		})
+	}
+}
+
+// parseAddress validates a user-supplied payment address for the platform's
+// network. On failure it writes a 400 response and returns false.
+func parseAddress(c *gin.Context, field, address, network string) (*crypto.Address, bool) {
+	parsed, err := crypto.ParseAddress(address, network)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_ADDRESS",
+			"message": "Invalid " + field + ": " + err.Error(),
+		})
+		return nil, false
+	}
+	return parsed, true
+}
+
+// customerFinder looks customers up by email or phone
+type customerFinder interface {
+	FindCustomerByIdentifier(ctx context.Context, field, value string) (*models.Customer, error)
+}
+
+// resolveCustomer looks up the customer an issuance identifier refers to.
+// Returns a nil customer if nobody has signed up with it; ok is false once an
+// error response has been written.
+func resolveCustomer(c *gin.Context, userRepo customerFinder, raw string) (auth.CustomerIdentifier, *models.Customer, bool) {
+	identifier, err := auth.ParseCustomerIdentifier(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"code":    "400_INVALID_IDENTIFIER",
+			"message": "Invalid customer identifier: " + err.Error(),
+		})
+		return identifier, nil, false
+	}
+
+	customer, err := userRepo.FindCustomerByIdentifier(c.Request.Context(), identifier.Kind, identifier.Value)
+	switch {
+	case err == nil:
+		return identifier, customer, true
+	case errors.Is(err, storage.ErrCustomerNotFound):
+		return identifier, nil, true
+	case errors.Is(err, storage.ErrAmbiguousCustomer):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_AMBIGUOUS_CUSTOMER",
+			"message": "Several customers share this " + identifier.Kind + "; use their QR handle or wallet address",
+		})
+		return identifier, nil, false
+	default:
+		logger.Error("Failed to look up customer", err, map[string]interface{}{
+			"identifier_type": identifier.Kind,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"code":    "500_INTERNAL_ERROR",
+			"message": "Failed to look up customer",
+		})
+		return identifier, nil, false
+	}
+}
+
+// walletKey pairs an account's wallet key record with the wallet and owner it is bound to
+func walletKey(ownerID string, wallet models.Wallet) crypto.WalletKey {
+	return crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: wallet.Address, OwnerID: ownerID},
+		EncryptedKey: wallet.EncryptedPrivateKey,
+	}
+}
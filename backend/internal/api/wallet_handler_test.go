@@ -0,0 +1,438 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePaymentRequests mirrors the conditional updates of
+// storage.PaymentRequestRepository
+type fakePaymentRequests struct {
+	requests map[string]*models.PaymentRequest
+}
+
+func (f *fakePaymentRequests) GetPaymentRequestByID(ctx context.Context, id string) (*models.PaymentRequest, error) {
+	request, ok := f.requests[id]
+	if !ok {
+		return nil, fmt.Errorf("payment request not found")
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (f *fakePaymentRequests) ReservePaymentRequest(ctx context.Context, id, customerID string, until time.Time) (*models.PaymentRequest, error) {
+	request, ok := f.requests[id]
+	now := time.Now().UTC()
+	if !ok || !request.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	lapsed := request.Status == models.PaymentRequestPaying && request.ReservedUntil != nil && request.ReservedUntil.Before(now)
+	if request.Status != models.PaymentRequestOpen && !lapsed {
+		return nil, nil
+	}
+	request.Status, request.CustomerID, request.ReservedUntil, request.ExternalTxID = models.PaymentRequestPaying, customerID, &until, ""
+	copied := *request
+	return &copied, nil
+}
+
+func (f *fakePaymentRequests) ReleasePaymentRequest(ctx context.Context, id, customerID string) error {
+	if request := f.reservedBy(id, customerID); request != nil {
+		request.Status, request.CustomerID, request.ReservedUntil, request.ExternalTxID = models.PaymentRequestOpen, "", nil, ""
+	}
+	return nil
+}
+
+func (f *fakePaymentRequests) SetExternalTx(ctx context.Context, id, customerID, externalTxID string) error {
+	if request := f.reservedBy(id, customerID); request != nil {
+		request.ExternalTxID = externalTxID
+	}
+	return nil
+}
+
+func (f *fakePaymentRequests) MarkSubmitted(ctx context.Context, id, customerID, txHash string) error {
+	request := f.reservedBy(id, customerID)
+	if request == nil {
+		return fmt.Errorf("payment request is no longer reserved by this customer")
+	}
+	request.Status, request.TxHash, request.ReservedUntil = models.PaymentRequestSubmitted, txHash, nil
+	return nil
+}
+
+func (f *fakePaymentRequests) reservedBy(id, customerID string) *models.PaymentRequest {
+	request, ok := f.requests[id]
+	if !ok || request.Status != models.PaymentRequestPaying || request.CustomerID != customerID {
+		return nil
+	}
+	return request
+}
+
+type fakeExternalTxs struct {
+	txs map[string]*models.ExternalTx
+}
+
+func (f *fakeExternalTxs) CreateExternalTx(ctx context.Context, tx *models.ExternalTx) error {
+	tx.ID = fmt.Sprintf("ext%d", len(f.txs)+1)
+	tx.Status = models.ExternalTxAwaitingSignature
+	f.txs[tx.ID] = tx
+	return nil
+}
+
+func (f *fakeExternalTxs) GetExternalTxByID(ctx context.Context, id string) (*models.ExternalTx, error) {
+	tx, ok := f.txs[id]
+	if !ok {
+		return nil, fmt.Errorf("external transaction not found")
+	}
+	return tx, nil
+}
+
+func (f *fakeExternalTxs) TransitionStatus(ctx context.Context, id string, from, to models.ExternalTxStatus) error {
+	if tx, ok := f.txs[id]; ok && tx.Status == from {
+		tx.Status = to
+		return nil
+	}
+	return fmt.Errorf("external transaction is not %s", from)
+}
+
+func (f *fakeExternalTxs) CompleteExternalTx(ctx context.Context, id string, status models.ExternalTxStatus, submittedTxHash, errMsg string) error {
+	f.txs[id].Status = status
+	return nil
+}
+
+type fakeWalletUsers struct {
+	customers map[string]*models.Customer
+	merchants map[string]*models.Merchant
+}
+
+func (f *fakeWalletUsers) FindCustomerByIdentifier(ctx context.Context, field, value string) (*models.Customer, error) {
+	for _, customer := range f.customers {
+		if field == auth.IdentifierUsername && customer.Username == value {
+			return customer, nil
+		}
+	}
+	return nil, storage.ErrCustomerNotFound
+}
+
+func (f *fakeWalletUsers) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
+	customer, ok := f.customers[id]
+	if !ok {
+		return nil, fmt.Errorf("customer not found")
+	}
+	return customer, nil
+}
+
+func (f *fakeWalletUsers) GetMerchantByID(ctx context.Context, id string) (*models.Merchant, error) {
+	merchant, ok := f.merchants[id]
+	if !ok {
+		return nil, fmt.Errorf("merchant not found")
+	}
+	return merchant, nil
+}
+
+func (f *fakeWalletUsers) GetMerchantByWalletAddress(ctx context.Context, address string) (*models.Merchant, error) {
+	return nil, fmt.Errorf("merchant not found")
+}
+
+// fakeWalletChain counts the transfers it is asked to make
+type fakeWalletChain struct {
+	transferErr error
+	transfers   int
+	builds      int
+}
+
+func (w *fakeWalletChain) GetBalance(address string) (*cardano.Balance, error) {
+	return &cardano.Balance{Address: address, LCN: 1000}, nil
+}
+
+func (w *fakeWalletChain) TransferADA(from crypto.WalletKey, toAddress string, amountLCN uint64) (string, error) {
+	if w.transferErr != nil {
+		return "", w.transferErr
+	}
+	w.transfers++
+	return fmt.Sprintf("tx%d", w.transfers), nil
+}
+
+func (w *fakeWalletChain) BuildExternalTransfer(fromAddress, toAddress string, amountLCN uint64, validUntil time.Time) (*cardano.ExternalTransfer, error) {
+	w.builds++
+	return &cardano.ExternalTransfer{TxHash: fmt.Sprintf("body%d", w.builds), TxCBOR: "84a4"}, nil
+}
+
+func (w *fakeWalletChain) SubmitExternalTransfer(tx *models.ExternalTx, witnesses []crypto.VKeyWitness) (string, error) {
+	return tx.TxHash, nil
+}
+
+func newPayRequestHandler(request *models.PaymentRequest) (*WalletHandler, *fakePaymentRequests, *fakeWalletChain, *fakeExternalTxs) {
+	requests := &fakePaymentRequests{requests: map[string]*models.PaymentRequest{request.ID: request}}
+	chain := &fakeWalletChain{}
+	externalTxs := &fakeExternalTxs{txs: map[string]*models.ExternalTx{}}
+	h := &WalletHandler{
+		cardanoService: chain,
+		userRepo: &fakeWalletUsers{customers: map[string]*models.Customer{
+			"c1":  {ID: "c1", Wallet: models.Wallet{Address: "addr_c1"}},
+			"c2":  {ID: "c2", Wallet: models.Wallet{Address: "addr_c2"}},
+			"ext": {ID: "ext", Wallet: models.Wallet{Address: "addr_ext", Custody: models.WalletExternal}},
+		}},
+		externalTxRepo:     externalTxs,
+		paymentRequestRepo: requests,
+	}
+	return h, requests, chain, externalTxs
+}
+
+func openPaymentRequest() *models.PaymentRequest {
+	return &models.PaymentRequest{
+		ID:              "pr1",
+		MerchantID:      "m1",
+		MerchantAddress: "addr_merchant",
+		AmountLCN:       50,
+		Status:          models.PaymentRequestOpen,
+		ExpiresAt:       time.Now().UTC().Add(time.Hour),
+	}
+}
+
+type payResponse struct {
+	Code string `json:"code"`
+	Data struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		TxHash string `json:"tx_hash"`
+	} `json:"data"`
+}
+
+func payRequest(t *testing.T, h *WalletHandler, customerID string, amountLCN uint64) (int, payResponse) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/lcn/pay/pr1", strings.NewReader(fmt.Sprintf(`{"amount_lcn":%d}`, amountLCN)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "request_id", Value: "pr1"}}
+	c.Set("user_id", customerID)
+
+	h.PayRequest(c)
+
+	var resp payResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestPayRequest_DoubleScanPaysOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, requests, chain, _ := newPayRequestHandler(openPaymentRequest())
+
+	code, resp := payRequest(t, h, "c1", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tx1", resp.Data.TxHash)
+	assert.Equal(t, string(models.PaymentRequestSubmitted), resp.Data.Status)
+
+	// Scanning again returns the payment already made
+	code, resp = payRequest(t, h, "c1", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tx1", resp.Data.TxHash)
+	assert.Equal(t, 1, chain.transfers)
+
+	// Still the same payment once the indexer confirmed it
+	requests.requests["pr1"].Status = models.PaymentRequestPaid
+	code, resp = payRequest(t, h, "c1", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, string(models.PaymentRequestPaid), resp.Data.Status)
+	assert.Equal(t, 1, chain.transfers)
+
+	// Nobody else can pay it
+	code, resp = payRequest(t, h, "c2", 50)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "409_ALREADY_PAID", resp.Code)
+	assert.Equal(t, 1, chain.transfers)
+}
+
+func TestPayRequest_ExternalWalletReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, requests, chain, externalTxs := newPayRequestHandler(openPaymentRequest())
+
+	code, resp := payRequest(t, h, "ext", 50)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ext1", resp.Data.ID)
+	assert.Equal(t, "ext1", requests.requests["pr1"].ExternalTxID)
+
+	// Scanning again hands back the transaction still waiting for a signature
+	code, resp = payRequest(t, h, "ext", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ext1", resp.Data.ID)
+	assert.Equal(t, "body1", resp.Data.TxHash)
+	assert.Equal(t, 1, chain.builds)
+
+	// Another customer cannot take over the reservation
+	code, resp = payRequest(t, h, "c2", 50)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "409_ALREADY_PAID", resp.Code)
+
+	// Once the signed transaction is being submitted, a repeat waits for it
+	externalTxs.txs["ext1"].Status = models.ExternalTxSubmitting
+	code, resp = payRequest(t, h, "ext", 50)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "409_PAYMENT_IN_PROGRESS", resp.Code)
+
+	// A reservation that lapsed unsigned can be paid again
+	externalTxs.txs["ext1"].Status = models.ExternalTxAwaitingSignature
+	lapsed := time.Now().UTC().Add(-time.Minute)
+	requests.requests["pr1"].ReservedUntil = &lapsed
+	code, resp = payRequest(t, h, "ext", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ext2", resp.Data.ID)
+	assert.Equal(t, 2, chain.builds)
+	assert.Equal(t, "ext2", requests.requests["pr1"].ExternalTxID)
+	assert.Zero(t, chain.transfers)
+}
+
+func TestPayRequest_ReleasesOnTransferFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, requests, chain, _ := newPayRequestHandler(openPaymentRequest())
+
+	chain.transferErr = fmt.Errorf("submit failed")
+	code, resp := payRequest(t, h, "c1", 50)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "500_REDEMPTION_FAILED", resp.Code)
+	assert.Equal(t, models.PaymentRequestOpen, requests.requests["pr1"].Status)
+	assert.Empty(t, requests.requests["pr1"].CustomerID)
+
+	// Reopened, so anyone can pay it
+	chain.transferErr = nil
+	code, resp = payRequest(t, h, "c2", 50)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tx1", resp.Data.TxHash)
+	assert.Equal(t, "c2", requests.requests["pr1"].CustomerID)
+}
+
+func TestPayRequest_Rejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+
+	expired := openPaymentRequest()
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	cancelled := openPaymentRequest()
+	cancelled.Status = models.PaymentRequestCancelled
+
+	tests := []struct {
+		name    string
+		request *models.PaymentRequest
+		amount  uint64
+		code    int
+		errCode string
+	}{
+		{"expired", expired, 50, http.StatusGone, "410_EXPIRED"},
+		{"cancelled", cancelled, 50, http.StatusGone, "410_CANCELLED"},
+		{"amount changed", openPaymentRequest(), 40, http.StatusBadRequest, "400_AMOUNT_MISMATCH"},
+	}
+	for _, tt := range tests {
+		h, requests, chain, _ := newPayRequestHandler(tt.request)
+		code, resp := payRequest(t, h, "c1", tt.amount)
+		assert.Equal(t, tt.code, code, tt.name)
+		assert.Equal(t, tt.errCode, resp.Code, tt.name)
+		assert.Empty(t, requests.requests["pr1"].CustomerID, tt.name)
+		assert.Zero(t, chain.transfers, tt.name)
+	}
+}
+
+func newIssueHandler(pending ...*models.PendingReward) (*WalletHandler, *fakePendingRewards, *fakeWalletChain) {
+	rewards := &fakePendingRewards{rewards: pending}
+	chain := &fakeWalletChain{}
+	h := &WalletHandler{
+		cardanoService: chain,
+		userRepo: &fakeWalletUsers{merchants: map[string]*models.Merchant{
+			"m1": {ID: "m1", Wallet: models.Wallet{Address: "addr_merchant"}},
+		}},
+		pendingRewardRepo:    rewards,
+		pendingRewardTTLDays: 30,
+	}
+	return h, rewards, chain
+}
+
+type issueResponse struct {
+	Code string `json:"code"`
+	Data struct {
+		PendingRewardID   string  `json:"pending_reward_id"`
+		Available         float64 `json:"available"`
+		PendingRewardsLCN uint64  `json:"pending_rewards_lcn"`
+	} `json:"data"`
+}
+
+func issueLCN(t *testing.T, h *WalletHandler, customer string, amountLCN uint64) (int, issueResponse) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/lcn/issue", strings.NewReader(fmt.Sprintf(`{"customer":%q,"create_pending":true,"amount_lcn":%d}`, customer, amountLCN)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("merchant_id", "m1")
+	c.Set("user_id", "m1")
+
+	h.IssueLCN(c)
+
+	var resp issueResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestIssueLCN_PendingRewardsHoldBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, rewards, chain := newIssueHandler(
+		// Neither holds any of the balance any more
+		&models.PendingReward{ID: "old1", MerchantID: "m1", AmountLCN: 900, Status: models.PendingRewardPending, ExpiresAt: time.Now().Add(-time.Hour)},
+		&models.PendingReward{ID: "old2", MerchantID: "m1", AmountLCN: 900, Status: models.PendingRewardCancelled, ExpiresAt: time.Now().Add(time.Hour)},
+	)
+
+	// The wallet holds 1000 LCN
+	code, _ := issueLCN(t, h, "amy@example.com", 600)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	code, resp := issueLCN(t, h, "ben@example.com", 500)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "400_INSUFFICIENT_BALANCE", resp.Code)
+	assert.Equal(t, float64(400), resp.Data.Available)
+	assert.Equal(t, uint64(600), resp.Data.PendingRewardsLCN)
+
+	code, _ = issueLCN(t, h, "ben@example.com", 400)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	// Cancelling one frees its share
+	require.NoError(t, rewards.CancelPendingReward(context.Background(), "r3", "m1"))
+	code, _ = issueLCN(t, h, "cat@example.com", 600)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Zero(t, chain.transfers)
+}
+
+func TestIssueLCN_ConcurrentPendingRewardsStayWithinBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	h, rewards, _ := newIssueHandler()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			issueLCN(t, h, fmt.Sprintf("customer%d@example.com", i), 300)
+		}(i)
+	}
+	wg.Wait()
+
+	// Rewards that raced past the first check back out again
+	outstanding, err := rewards.SumOutstandingByMerchant(context.Background(), "m1")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, outstanding, uint64(1000))
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+const apiKeyPrefix = "lcn_"
+
+// Identity behind a validated API key
+type APIKeyPrincipal struct {
+	KeyID         string
+	MerchantID    string
+	WalletAddress string
+	Scopes        []models.Permission
+}
+
+// Generates a new API key of the form lcn_<prefix>_<secret>.
+// Returns the full key (shown once), its visible prefix and the hash to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key prefix: %w", err)
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	prefix = apiKeyPrefix + hex.EncodeToString(prefixBytes)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// Hashes an API key for storage and lookup.
+// Keys carry 256 bits of entropy, so a fast unsalted hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a credential looks like a LoyalCoin API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// API key storage used for authentication (implemented by storage.APIKeyRepository)
+type APIKeyStore interface {
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id string) error
+}
+
+// Merchant lookup used for authentication (implemented by storage.UserRepository)
+type MerchantStore interface {
+	GetMerchantByID(ctx context.Context, id string) (*models.Merchant, error)
+}
+
+type APIKeyService struct {
+	apiKeyRepo APIKeyStore
+	userRepo   MerchantStore
+}
+
+func NewAPIKeyService(apiKeyRepo APIKeyStore, userRepo MerchantStore) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Authenticate validates a raw API key and resolves the merchant it belongs to
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*APIKeyPrincipal, error) {
+	if !IsAPIKey(rawKey) {
+		return nil, fmt.Errorf("malformed API key")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetActiveAPIKeyByHash(ctx, HashAPIKey(rawKey))
+	if err != nil {
+		return nil, err
+	}
+
+	merchant, err := s.userRepo.GetMerchantByID(ctx, apiKey.MerchantID)
+	if err != nil {
+		return nil, fmt.Errorf("API key owner not found: %w", err)
+	}
+	if merchant.Status == models.StatusSuspended {
+		return nil, fmt.Errorf("merchant account is suspended")
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		logger.Warn("Failed to record API key usage", map[string]interface{}{
+			"api_key_id": apiKey.ID,
+			"error":      err.Error(),
+		})
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:         apiKey.ID,
+		MerchantID:    merchant.ID,
+		WalletAddress: merchant.Wallet.Address,
+		Scopes:        apiKey.Scopes,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Failed to generate API key: %v", err)
+	}
+
+	if !IsAPIKey(key) {
+		t.Errorf("Key should start with %q, got: %s", apiKeyPrefix, key)
+	}
+
+	if !strings.HasPrefix(key, prefix+"_") {
+		t.Errorf("Key %s should start with its visible prefix %s", key, prefix)
+	}
+
+	if hash != HashAPIKey(key) {
+		t.Error("Returned hash should match HashAPIKey(key)")
+	}
+
+	if strings.Contains(hash, key) || len(hash) != 64 {
+		t.Errorf("Hash should be a 64-char SHA-256 hex digest, got: %s", hash)
+	}
+
+	key2, prefix2, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Failed to generate second API key: %v", err)
+	}
+	if key == key2 || prefix == prefix2 {
+		t.Error("Two generated API keys should not be identical")
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	if IsAPIKey("eyJhbGciOiJSUzI1NiIs") {
+		t.Error("JWT should not be detected as an API key")
+	}
+}
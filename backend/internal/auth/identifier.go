@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// Customer identifier kinds; each is also the customers field it matches
+const (
+	IdentifierEmail    = "email"
+	IdentifierPhone    = "phone"
+	IdentifierHandle   = "handle"
+	IdentifierUsername = "username"
+)
+
+const (
+	handlePrefix   = "lc-"
+	handleLength   = 8
+	handleAlphabet = "0123456789abcdefghjkmnpqrstvwxyz" // Crockford base32, lower case
+)
+
+// CustomerIdentifier is a normalized email, phone number, QR handle or username
+type CustomerIdentifier struct {
+	Kind  string
+	Value string
+}
+
+// ParseCustomerIdentifier classifies what a merchant typed or scanned:
+// anything with an @ is an email, lc-xxxxxxxx is a QR handle, +/digits is a
+// phone number and the rest is a username. Signup rejects usernames that would
+// be classified as anything else, so the kinds never overlap.
+func ParseCustomerIdentifier(identifier string) (CustomerIdentifier, error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return CustomerIdentifier{}, fmt.Errorf("identifier is empty")
+	}
+	lower := strings.ToLower(identifier)
+	switch {
+	case strings.Contains(identifier, "@"):
+		return CustomerIdentifier{Kind: IdentifierEmail, Value: lower}, nil
+	case strings.HasPrefix(lower, handlePrefix):
+		return CustomerIdentifier{Kind: IdentifierHandle, Value: lower}, nil
+	case looksLikePhone(identifier):
+		phone, err := NormalizePhone(identifier)
+		if err != nil {
+			return CustomerIdentifier{}, err
+		}
+		return CustomerIdentifier{Kind: IdentifierPhone, Value: phone}, nil
+	default:
+		return CustomerIdentifier{Kind: IdentifierUsername, Value: identifier}, nil
+	}
+}
+
+// NormalizePhone strips separators, keeping an optional leading + and 7-15 digits (E.164)
+func NormalizePhone(phone string) (string, error) {
+	var normalized strings.Builder
+	digits := 0
+	for i, char := range strings.TrimSpace(phone) {
+		switch {
+		case char >= '0' && char <= '9':
+			normalized.WriteRune(char)
+			digits++
+		case char == '+' && i == 0:
+			normalized.WriteRune(char)
+		case char == ' ' || char == '-' || char == '.' || char == '(' || char == ')':
+		default:
+			return "", fmt.Errorf("phone number contains invalid character %q", char)
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return "", fmt.Errorf("phone number must have 7 to 15 digits")
+	}
+	return normalized.String(), nil
+}
+
+// ValidateUsername rejects usernames that would be read as another identifier kind
+func ValidateUsername(username string) error {
+	if strings.TrimSpace(username) == "" {
+		return fmt.Errorf("username is required")
+	}
+	identifier, err := ParseCustomerIdentifier(username)
+	if err != nil || identifier.Kind != IdentifierUsername {
+		return fmt.Errorf("username must not look like an email, phone number or %s handle", handlePrefix)
+	}
+	return nil
+}
+
+// NewCustomerHandle generates a random QR handle of the form lc-xxxxxxxx (40 bits)
+func NewCustomerHandle() (string, error) {
+	random := make([]byte, handleLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate handle: %w", err)
+	}
+	handle := []byte(handlePrefix)
+	for _, b := range random {
+		handle = append(handle, handleAlphabet[b%byte(len(handleAlphabet))])
+	}
+	return string(handle), nil
+}
+
+func looksLikePhone(identifier string) bool {
+	for _, char := range identifier {
+		if !(char >= '0' && char <= '9') && !strings.ContainsRune("+ -.()", char) {
+			return false
+		}
+	}
+	return true
+}
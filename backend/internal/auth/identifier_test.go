@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCustomerIdentifier(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  string
+		value string
+	}{
+		{"Alice@Example.com", IdentifierEmail, "alice@example.com"},
+		{"LC-7K3M9X2A", IdentifierHandle, "lc-7k3m9x2a"},
+		{"+251 (911) 23-45-67", IdentifierPhone, "+251911234567"},
+		{"0911234567", IdentifierPhone, "0911234567"},
+		{" alice ", IdentifierUsername, "alice"},
+	}
+	for _, tt := range tests {
+		identifier, err := ParseCustomerIdentifier(tt.input)
+		if err != nil {
+			t.Fatalf("ParseCustomerIdentifier(%q) failed: %v", tt.input, err)
+		}
+		if identifier.Kind != tt.kind || identifier.Value != tt.value {
+			t.Errorf("ParseCustomerIdentifier(%q) = %+v, want %s %q", tt.input, identifier, tt.kind, tt.value)
+		}
+	}
+
+	for _, invalid := range []string{"", "   ", "12345", "+2519112345678901"} {
+		if _, err := ParseCustomerIdentifier(invalid); err == nil {
+			t.Errorf("ParseCustomerIdentifier(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	if err := ValidateUsername("alice_99"); err != nil {
+		t.Errorf("valid username rejected: %v", err)
+	}
+	for _, username := range []string{"alice@example.com", "lc-abc", "0911234567", ""} {
+		if err := ValidateUsername(username); err == nil {
+			t.Errorf("username %q should be rejected", username)
+		}
+	}
+}
+
+func TestNewCustomerHandle(t *testing.T) {
+	handle, err := NewCustomerHandle()
+	if err != nil {
+		t.Fatalf("Failed to generate handle: %v", err)
+	}
+	if !strings.HasPrefix(handle, "lc-") || len(handle) != len("lc-")+8 {
+		t.Errorf("unexpected handle format %q", handle)
+	}
+	identifier, err := ParseCustomerIdentifier(handle)
+	if err != nil || identifier.Kind != IdentifierHandle || identifier.Value != handle {
+		t.Errorf("handle %q does not parse back as a handle: %+v, %v", handle, identifier, err)
+	}
+}
@@ -17,6 +17,8 @@ type JWTClaims struct {
 	UserID        string      `json:"user_id"`
 	Role          models.Role `json:"role"`
 	WalletAddress string      `json:"wallet_address"`
+	// Business the user acts for (merchant owners and staff only)
+	MerchantID string `json:"merchant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -72,11 +74,17 @@ func NewJWTService(privateKeyPath, publicKeyPath string, expirationHours int) (*
 }
 
 func (s *JWTService) GenerateToken(userID string, role models.Role, walletAddress string) (string, error) {
+	return s.GenerateMerchantToken(userID, "", role, walletAddress)
+}
+
+// Generates a token for a merchant owner or staff member acting for merchantID
+func (s *JWTService) GenerateMerchantToken(userID, merchantID string, role models.Role, walletAddress string) (string, error) {
 	now := time.Now()
 	claims := JWTClaims{
 		UserID:        userID,
 		Role:          role,
 		WalletAddress: walletAddress,
+		MerchantID:    merchantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.expiration)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+)
+
+// Account scope each permission may be granted to. Permissions missing
+// from this map are unknown and rejected when roles are edited.
+var permissionScopes = map[models.Permission][]models.RoleScope{
+	models.PermAllocationApprove: {models.RoleScopePlatform},
+	models.PermSettlementApprove: {models.RoleScopePlatform},
+	models.PermReserveRead:       {models.RoleScopePlatform},
+	models.PermUsersManage:       {models.RoleScopePlatform},
+	models.PermReferralsReview:   {models.RoleScopePlatform},
+	models.PermRefundsOverride:   {models.RoleScopePlatform},
+	models.PermCoalitionsManage:  {models.RoleScopePlatform},
+	models.PermLCNIssue:          {models.RoleScopeMerchant},
+	models.PermAllocationRequest: {models.RoleScopeMerchant},
+	models.PermSettlementRequest: {models.RoleScopeMerchant},
+	models.PermAPIKeysManage:     {models.RoleScopeMerchant},
+	models.PermStaffManage:       {models.RoleScopeMerchant},
+	models.PermPaymentsRequest:   {models.RoleScopeMerchant},
+	models.PermRewardsManage:     {models.RoleScopeMerchant},
+	models.PermOrdersFulfill:     {models.RoleScopeMerchant},
+	models.PermLCNRefund:         {models.RoleScopeMerchant},
+	models.PermVouchersIssue:     {models.RoleScopeMerchant},
+	models.PermVouchersRedeem:    {models.RoleScopeMerchant},
+	models.PermLCNRedeem:         {models.RoleScopeCustomer},
+	models.PermLCNTransfer:       {models.RoleScopeCustomer},
+	models.PermWalletExport:      {models.RoleScopeCustomer},
+	models.PermWalletManage:      {models.RoleScopeCustomer},
+	models.PermWalletRead:        {models.RoleScopePlatform, models.RoleScopeMerchant, models.RoleScopeCustomer},
+}
+
+// Roles seeded on startup. Admins may later edit their permissions.
+func BuiltInRoles() []*models.RoleDefinition {
+	return []*models.RoleDefinition{
+		{
+			Name:        models.RoleAdmin,
+			Description: "Platform administrator",
+			Scope:       models.RoleScopePlatform,
+			Permissions: []models.Permission{
+				models.PermAllocationApprove,
+				models.PermSettlementApprove,
+				models.PermReserveRead,
+				models.PermUsersManage,
+				models.PermReferralsReview,
+				models.PermRefundsOverride,
+				models.PermCoalitionsManage,
+				models.PermWalletRead,
+			},
+		},
+		{
+			Name:        models.RoleFinanceOfficer,
+			Description: "Reviews allocation purchases and settlements",
+			Scope:       models.RoleScopePlatform,
+			Permissions: []models.Permission{
+				models.PermAllocationApprove,
+				models.PermSettlementApprove,
+				models.PermReserveRead,
+				models.PermReferralsReview,
+				models.PermRefundsOverride,
+				models.PermCoalitionsManage,
+			},
+		},
+		{
+			Name:        models.RoleAuditor,
+			Description: "Read-only access to the governance reserve",
+			Scope:       models.RoleScopePlatform,
+			Permissions: []models.Permission{
+				models.PermReserveRead,
+			},
+		},
+		{
+			Name:        models.RoleMerchant,
+			Description: "Merchant business owner",
+			Scope:       models.RoleScopeMerchant,
+			Permissions: []models.Permission{
+				models.PermLCNIssue,
+				models.PermAllocationRequest,
+				models.PermSettlementRequest,
+				models.PermAPIKeysManage,
+				models.PermStaffManage,
+				models.PermPaymentsRequest,
+				models.PermRewardsManage,
+				models.PermOrdersFulfill,
+				models.PermLCNRefund,
+				models.PermVouchersIssue,
+				models.PermVouchersRedeem,
+				models.PermWalletRead,
+			},
+		},
+		{
+			Name:        models.RoleMerchantManager,
+			Description: "Store manager acting for a merchant",
+			Scope:       models.RoleScopeMerchant,
+			Permissions: []models.Permission{
+				models.PermLCNIssue,
+				models.PermAllocationRequest,
+				models.PermSettlementRequest,
+				models.PermAPIKeysManage,
+				models.PermPaymentsRequest,
+				models.PermRewardsManage,
+				models.PermOrdersFulfill,
+				models.PermLCNRefund,
+				models.PermVouchersIssue,
+				models.PermVouchersRedeem,
+				models.PermWalletRead,
+			},
+		},
+		{
+			Name:        models.RoleMerchantCashier,
+			Description: "Cashier issuing LCN at the point of sale",
+			Scope:       models.RoleScopeMerchant,
+			Permissions: []models.Permission{
+				models.PermLCNIssue,
+				models.PermPaymentsRequest,
+				models.PermOrdersFulfill,
+				models.PermVouchersRedeem,
+				models.PermWalletRead,
+			},
+		},
+		{
+			Name:        models.RoleCustomer,
+			Description: "Loyalty program customer",
+			Scope:       models.RoleScopeCustomer,
+			Permissions: []models.Permission{
+				models.PermLCNRedeem,
+				models.PermLCNTransfer,
+				models.PermWalletRead,
+				models.PermWalletExport,
+				models.PermWalletManage,
+			},
+		},
+	}
+}
+
+// ValidatePermissions checks that every permission is known and may be held by roles of the given scope
+func ValidatePermissions(scope models.RoleScope, permissions []models.Permission) error {
+	for _, permission := range permissions {
+		scopes, ok := permissionScopes[permission]
+		if !ok {
+			return fmt.Errorf("unknown permission: %s", permission)
+		}
+		allowed := false
+		for _, s := range scopes {
+			if s == scope {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("permission %s cannot be granted to %s roles", permission, scope)
+		}
+	}
+	return nil
+}
+
+// RBACService resolves role permissions from the roles collection.
+// Roles are cached in memory and reloaded every cacheTTL.
+type RBACService struct {
+	roleRepo *storage.RoleRepository
+	cacheTTL time.Duration
+
+	mu       sync.RWMutex
+	roles    map[models.Role]*models.RoleDefinition
+	loadedAt time.Time
+}
+
+func NewRBACService(roleRepo *storage.RoleRepository, cacheTTL time.Duration) *RBACService {
+	return &RBACService{
+		roleRepo: roleRepo,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Seeds built-in roles that are missing from the database and grants
+// existing ones the built-in permissions added since they were seeded
+func (s *RBACService) EnsureBuiltInRoles(ctx context.Context) error {
+	for _, role := range BuiltInRoles() {
+		role.BuiltIn = true
+		if err := s.roleRepo.EnsureRole(ctx, role); err != nil {
+			return err
+		}
+	}
+	s.Invalidate()
+	return nil
+}
+
+// Retrieves a role definition (cached)
+func (s *RBACService) GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	roles, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	role, ok := roles[name]
+	if !ok {
+		return nil, fmt.Errorf("role not found: %s", name)
+	}
+	return role, nil
+}
+
+// HasPermission reports whether the role grants the permission
+func (s *RBACService) HasPermission(ctx context.Context, name models.Role, permission models.Permission) (bool, error) {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range role.Permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Invalidate drops the cache so the next lookup reloads roles from the database
+func (s *RBACService) Invalidate() {
+	s.mu.Lock()
+	s.roles = nil
+	s.mu.Unlock()
+}
+
+func (s *RBACService) load(ctx context.Context) (map[models.Role]*models.RoleDefinition, error) {
+	s.mu.RLock()
+	if s.roles != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		roles := s.roles
+		s.mu.RUnlock()
+		return roles, nil
+	}
+	s.mu.RUnlock()
+
+	list, err := s.roleRepo.GetAllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[models.Role]*models.RoleDefinition, len(list))
+	for _, role := range list {
+		roles[role.Name] = role
+	}
+
+	s.mu.Lock()
+	s.roles = roles
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return roles, nil
+}
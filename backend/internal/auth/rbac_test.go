@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestBuiltInRoles_Valid(t *testing.T) {
+	seen := map[models.Role]bool{}
+	for _, role := range BuiltInRoles() {
+		if seen[role.Name] {
+			t.Errorf("Duplicate built-in role: %s", role.Name)
+		}
+		seen[role.Name] = true
+
+		if err := ValidatePermissions(role.Scope, role.Permissions); err != nil {
+			t.Errorf("Built-in role %s is invalid: %v", role.Name, err)
+		}
+	}
+
+	// The roles carried by existing JWTs must keep working
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleMerchant, models.RoleCustomer} {
+		if !seen[role] {
+			t.Errorf("Missing built-in role: %s", role)
+		}
+	}
+}
+
+func TestValidatePermissions(t *testing.T) {
+	err := ValidatePermissions(models.RoleScopeMerchant, []models.Permission{
+		models.PermLCNIssue,
+		models.PermWalletRead,
+	})
+	if err != nil {
+		t.Errorf("Merchant permissions should be valid for merchant roles: %v", err)
+	}
+
+	// Platform permissions cannot be granted to merchant staff
+	err = ValidatePermissions(models.RoleScopeMerchant, []models.Permission{models.PermSettlementApprove})
+	if err == nil {
+		t.Error("settlement:approve should be rejected for merchant roles")
+	}
+
+	err = ValidatePermissions(models.RoleScopePlatform, []models.Permission{"wallet:drain"})
+	if err == nil {
+		t.Error("Unknown permissions should be rejected")
+	}
+}
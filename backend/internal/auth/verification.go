@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+const (
+	VerificationCodeDigits     = 6
+	VerificationMaxAttempts    = 5
+	verificationCodeUpperBound = 1000000 // 10^VerificationCodeDigits
+)
+
+// NewVerificationCode returns a random numeric one-time code and the hash to
+// store for it
+func NewVerificationCode() (code, hash string, err error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(verificationCodeUpperBound))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	code = fmt.Sprintf("%0*d", VerificationCodeDigits, n.Int64())
+	return code, HashVerificationCode(code), nil
+}
+
+// HashVerificationCode is how one-time codes are stored
+func HashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckVerificationCode compares a submitted code with a stored hash in
+// constant time
+func CheckVerificationCode(code, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashVerificationCode(code)), []byte(hash)) == 1
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestVerificationCode(t *testing.T) {
+	code, hash, err := NewVerificationCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if len(code) != VerificationCodeDigits {
+		t.Errorf("Code %q should have %d digits", code, VerificationCodeDigits)
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			t.Fatalf("Code %q should be numeric", code)
+		}
+	}
+	if hash == code {
+		t.Error("Stored hash should not be the code")
+	}
+
+	if !CheckVerificationCode(code, hash) {
+		t.Error("Code should match its hash")
+	}
+	if CheckVerificationCode("", hash) {
+		t.Error("Empty code should not match")
+	}
+	other := "000000"
+	if other == code {
+		other = "111111"
+	}
+	if CheckVerificationCode(other, hash) {
+		t.Error("Another code should not match")
+	}
+}
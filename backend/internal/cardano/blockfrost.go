@@ -271,3 +271,39 @@ func (c *BlockfrostClient) GetLatestBlock() (*BlockInfo, error) {
 	}
 	return &block, nil
 }
+
+// Address holding a native asset
+type AssetAddress struct {
+	Address  string `json:"address"`
+	Quantity string `json:"quantity"`
+}
+
+// Retrieves the addresses currently holding an asset (policy ID + hex asset
+// name); none once it is burned
+func (c *BlockfrostClient) GetAssetAddresses(unit string) ([]AssetAddress, error) {
+	url := fmt.Sprintf("%s/assets/%s/addresses", c.baseURL, unit)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("project_id", c.projectID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Blockfrost: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("blockfrost returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var addresses []AssetAddress
+	if err := json.NewDecoder(resp.Body).Decode(&addresses); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return addresses, nil
+}
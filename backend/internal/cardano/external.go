@@ -0,0 +1,96 @@
+package cardano
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Unsigned transaction for a customer's external (CIP-30) wallet to sign
+type ExternalTransfer struct {
+	TxHash string // body hash the wallet signs
+	TxCBOR string // unsigned transaction (hex), passed to the wallet's signTx
+}
+
+// Builds an unsigned transfer from an external wallet address. The backend
+// holds no key for it; the customer's wallet signs the returned transaction.
+func (s *CardanoService) BuildExternalTransfer(
+	fromAddress string,
+	toAddress string,
+	amountLCN uint64, // in whole LCN units
+	validUntil time.Time,
+) (*ExternalTransfer, error) {
+	var result struct {
+		Status string `json:"status"`
+		TxHash string `json:"txHash"`
+		TxCBOR string `json:"txCbor"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":      "build",
+		"fromAddress": fromAddress,
+		"toAddress":   toAddress,
+		"lovelace":    amountLCN * 10000, // LCN to Lovelace
+		"validToMs":   validUntil.UnixMilli(),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalTransfer{
+		TxHash: result.TxHash,
+		TxCBOR: result.TxCBOR,
+	}, nil
+}
+
+// Attaches the customer's witnesses to an external wallet transaction, submits
+// it and records it in the transaction log
+func (s *CardanoService) SubmitExternalTransfer(tx *models.ExternalTx, witnesses []crypto.VKeyWitness) (string, error) {
+	var result struct {
+		Status string `json:"status"`
+		TxHash string `json:"txHash"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":     "submit",
+		"txCbor":     tx.TxCBOR,
+		"witnessSet": hex.EncodeToString(crypto.EncodeVKeyWitnessSet(witnesses)),
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	txLog := &models.TxLog{
+		TxHash:        result.TxHash,
+		FromAddress:   tx.FromAddress,
+		ToAddress:     tx.ToAddress,
+		AmountLCN:     tx.AmountLCN,
+		AssetPolicyID: "ADA", // Mark as ADA-backed
+		AssetName:     "LCN",
+		Type:          tx.Purpose,
+		Status:        models.TxStatusPending,
+		SubmittedAt:   time.Now().UTC(),
+		Meta: map[string]interface{}{
+			"external_wallet": true,
+		},
+	}
+	if err := s.txLogRepo.CreateTxLog(ctx, txLog); err != nil {
+		logger.Warn("Failed to record transaction", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if err := s.utxoRepo.ClearCache(ctx, address); err != nil {
+			logger.Warn("Failed to clear UTXO cache", map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return result.TxHash, nil
+}
@@ -0,0 +1,203 @@
+package cardano
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// GovernanceWallet is the platform wallet that funds merchant allocations.
+// With a Script configured it is an m-of-n native script address controlled
+// by admin keys; otherwise it is an ordinary single-key wallet.
+type GovernanceWallet struct {
+	Address string
+	Script  *crypto.MultiSigScript
+}
+
+// NewGovernanceWallet validates the governance configuration. In multi-sig mode
+// GOVERNANCE_WALLET_ADDRESS must be the address derived from the configured script.
+func NewGovernanceWallet(cfg *config.Config) (*GovernanceWallet, error) {
+	if cfg.GovernanceWalletAddress == "" {
+		return nil, fmt.Errorf("GOVERNANCE_WALLET_ADDRESS is required")
+	}
+
+	gov := &GovernanceWallet{Address: cfg.GovernanceWalletAddress}
+	if len(cfg.GovernanceScriptKeyHashes) == 0 {
+		return gov, nil
+	}
+
+	script, err := crypto.NewMultiSigScript(cfg.GovernanceScriptRequiredSignatures, cfg.GovernanceScriptKeyHashes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid governance script: %w", err)
+	}
+
+	networkTag := byte(0x00)
+	if cfg.CardanoNetwork == "mainnet" {
+		networkTag = 0x01
+	}
+	scriptAddress, err := script.Address(networkTag)
+	if err != nil {
+		return nil, err
+	}
+	if scriptAddress != cfg.GovernanceWalletAddress {
+		return nil, fmt.Errorf("GOVERNANCE_WALLET_ADDRESS %s does not match governance script address %s", cfg.GovernanceWalletAddress, scriptAddress)
+	}
+
+	gov.Script = script
+	return gov, nil
+}
+
+func (g *GovernanceWallet) IsMultiSig() bool {
+	return g.Script != nil
+}
+
+// Unsigned governance transaction awaiting admin signatures
+type GovernanceTransfer struct {
+	TxHash string   // body hash that every signer signs
+	TxCBOR string   // unsigned transaction (hex)
+	Inputs []string // "txhash#index" of the spent governance UTXOs
+}
+
+// Builds an unsigned transfer from the governance script address. Inputs already
+// reserved by other unsigned transactions are excluded so that both can be submitted.
+func (s *CardanoService) BuildGovernanceTransfer(
+	gov *GovernanceWallet,
+	toAddress string,
+	amountLCN uint64, // in whole LCN units
+	excludeInputs []string,
+	validUntil time.Time,
+) (*GovernanceTransfer, error) {
+	if !gov.IsMultiSig() {
+		return nil, fmt.Errorf("governance wallet is not a multi-sig script")
+	}
+
+	var result struct {
+		Status string   `json:"status"`
+		TxHash string   `json:"txHash"`
+		TxCBOR string   `json:"txCbor"`
+		Inputs []string `json:"inputs"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":        "build",
+		"keyHashes":     gov.Script.KeyHashesHex(),
+		"required":      gov.Script.Required,
+		"toAddress":     toAddress,
+		"lovelace":      amountLCN * 10000, // LCN to Lovelace
+		"excludeInputs": excludeInputs,
+		"validToMs":     validUntil.UnixMilli(),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GovernanceTransfer{
+		TxHash: result.TxHash,
+		TxCBOR: result.TxCBOR,
+		Inputs: result.Inputs,
+	}, nil
+}
+
+// Signs a governance transaction body hash with a custodial admin key
+func (s *CardanoService) SignGovernanceTx(txHash string, adminKey crypto.WalletKey) (*crypto.VKeyWitness, error) {
+	return s.signTxHash(txHash, adminKey)
+}
+
+// Attaches the collected witnesses to a governance transaction, submits it and
+// records it in the transaction log as the given type
+func (s *CardanoService) SubmitGovernanceTransfer(
+	gov *GovernanceWallet,
+	toAddress string,
+	amountLCN uint64,
+	txCBOR string,
+	witnesses []crypto.VKeyWitness,
+	txType models.TxType,
+) (string, error) {
+	var result struct {
+		Status string `json:"status"`
+		TxHash string `json:"txHash"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":     "submit",
+		"txCbor":     txCBOR,
+		"witnessSet": hex.EncodeToString(crypto.EncodeVKeyWitnessSet(witnesses)),
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	txLog := &models.TxLog{
+		TxHash:        result.TxHash,
+		FromAddress:   gov.Address,
+		ToAddress:     toAddress,
+		AmountLCN:     amountLCN,
+		AssetPolicyID: "ADA", // Mark as ADA-backed
+		AssetName:     "LCN",
+		Type:          txType,
+		Status:        models.TxStatusPending,
+		SubmittedAt:   time.Now().UTC(),
+		Meta: map[string]interface{}{
+			"signatures": len(witnesses),
+		},
+	}
+	if err := s.txLogRepo.CreateTxLog(ctx, txLog); err != nil {
+		logger.Warn("Failed to record transaction", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	for _, address := range []string{gov.Address, toAddress} {
+		if err := s.utxoRepo.ClearCache(ctx, address); err != nil {
+			logger.Warn("Failed to clear UTXO cache", map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return result.TxHash, nil
+}
+
+// runTransferScript runs a Node.js transfer script with JSON on stdin and decodes its JSON output
+func runTransferScript(script string, input interface{}, result interface{}) error {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal input data: %w", err)
+	}
+
+	cmd := exec.Command("node", script)
+	cmd.Env = append(os.Environ(), "NODE_OPTIONS=--dns-result-order=ipv4first")
+	cmd.Stdin = bytes.NewReader(inputJSON)
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("transfer script failed: %s (stderr: %s)", err, stderr.String())
+	}
+
+	var status struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &status); err != nil {
+		return fmt.Errorf("failed to parse script output: %w (output: %s)", err, stdout.String())
+	}
+	if status.Status != "ok" {
+		return fmt.Errorf("transfer failed: %s", status.Message)
+	}
+
+	return json.Unmarshal(stdout.Bytes(), result)
+}
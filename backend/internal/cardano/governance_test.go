@@ -0,0 +1,78 @@
+package cardano
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGovernanceWallet(t *testing.T) {
+	keyHashes := []string{
+		strings.Repeat("00", 28),
+		strings.Repeat("11", 28),
+		strings.Repeat("22", 28),
+	}
+	scriptAddress := "addr_test1wz7xdek7xwaf6ek0nz4n0pr6a9lw9e7csr0m3zelpcyptlczpaxgv"
+
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		wantErr      bool
+		wantMultiSig bool
+	}{
+		{
+			name:    "Missing address",
+			cfg:     config.Config{CardanoNetwork: "testnet"},
+			wantErr: true,
+		},
+		{
+			name: "Single key",
+			cfg:  config.Config{CardanoNetwork: "testnet", GovernanceWalletAddress: "addr_test1vqexample"},
+		},
+		{
+			name: "Multi-sig matching address",
+			cfg: config.Config{
+				CardanoNetwork:                     "testnet",
+				GovernanceWalletAddress:            scriptAddress,
+				GovernanceScriptKeyHashes:          keyHashes,
+				GovernanceScriptRequiredSignatures: 2,
+			},
+			wantMultiSig: true,
+		},
+		{
+			name: "Multi-sig address mismatch",
+			cfg: config.Config{
+				CardanoNetwork:                     "testnet",
+				GovernanceWalletAddress:            scriptAddress,
+				GovernanceScriptKeyHashes:          keyHashes,
+				GovernanceScriptRequiredSignatures: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Multi-sig invalid threshold",
+			cfg: config.Config{
+				CardanoNetwork:                     "testnet",
+				GovernanceWalletAddress:            scriptAddress,
+				GovernanceScriptKeyHashes:          keyHashes,
+				GovernanceScriptRequiredSignatures: 0,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gov, err := NewGovernanceWallet(&tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMultiSig, gov.IsMultiSig())
+			assert.Equal(t, tt.cfg.GovernanceWalletAddress, gov.Address)
+		})
+	}
+}
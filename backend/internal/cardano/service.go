@@ -3,6 +3,7 @@ package cardano
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -59,6 +60,9 @@ type Balance struct {
 	LCNAtomic   uint64
 	LCN         float64
 	OtherAssets map[string]uint64
+	// Lovelace that has to stay with the tokens held (such as voucher NFTs)
+	// and is not counted as LCN
+	LockedLovelace uint64
 }
 
 // Retrieves wallet balance (ADA-backed LCN)
@@ -112,92 +116,84 @@ func (s *CardanoService) GetBalance(address string) (*Balance, error) {
 
 	for _, utxo := range utxos {
 		balance.Lovelace += utxo.Value.Lovelace
+		for _, asset := range utxo.Value.Assets {
+			balance.OtherAssets[asset.PolicyID+asset.AssetName] += asset.Quantity
+		}
+		if len(utxo.Value.Assets) > 0 {
+			balance.LockedLovelace += min(utxo.Value.Lovelace, s.txBuilder.CalculateMinADA(len(utxo.Value.Assets)))
+		}
 	}
 
 	balance.ADA = float64(balance.Lovelace) / 1_000_000
 
 	// LCN is backed by ADA at 1 ADA = 100 LCN ratio
-	balance.LCNAtomic = balance.Lovelace / 10000 // Lovelace / 10,000
-	balance.LCN = balance.ADA * 100              // ADA × 100
+	spendable := balance.Lovelace - balance.LockedLovelace
+	balance.LCNAtomic = spendable / 10000              // Lovelace / 10,000
+	balance.LCN = float64(spendable) / 1_000_000 * 100 // ADA × 100
 	return balance, nil
 }
 
+// GetChainBalance retrieves a wallet's balance from Blockfrost, bypassing the
+// UTXO cache, for callers that must see the transactions confirmed so far
+func (s *CardanoService) GetChainBalance(address string) (*Balance, error) {
+	if err := s.utxoRepo.ClearCache(context.Background(), address); err != nil {
+		return nil, fmt.Errorf("failed to clear UTXO cache: %w", err)
+	}
+	return s.GetBalance(address)
+}
+
 // Transfers ADA (representing LCN at 1 ADA = 100 LCN ratio)
 func (s *CardanoService) TransferADA(
-	fromAddress string,
+	from crypto.WalletKey,
+	toAddress string,
+	amountLCN uint64, // in whole LCN units
+) (string, error) {
+	return s.TransferADAAs(from, toAddress, amountLCN, models.TxTypeIssuance)
+}
+
+// TransferADAAs transfers like TransferADA and records the transaction in the
+// transaction log as the given type, so the indexer never sees it as anything else
+func (s *CardanoService) TransferADAAs(
+	from crypto.WalletKey,
 	toAddress string,
 	amountLCN uint64, // in whole LCN units
-	encryptedPrivateKey string,
+	txType models.TxType,
 ) (string, error) {
+	return s.TransferADAWithMeta(from, toAddress, amountLCN, txType, nil)
+}
+
+// TransferADAWithMeta transfers like TransferADAAs and records meta with the
+// transaction log from the start, for details the indexer acts on
+func (s *CardanoService) TransferADAWithMeta(
+	from crypto.WalletKey,
+	toAddress string,
+	amountLCN uint64, // in whole LCN units
+	txType models.TxType,
+	meta map[string]interface{},
+) (string, error) {
+	fromAddress := from.Address
+
 	// Convert LCN to Lovelace
 	// 100 LCN = 1 ADA = 1,000,000 Lovelace
 	// So: 1 LCN = 0.01 ADA = 10,000 Lovelace
 	lovelaceAmount := amountLCN * 10000 // LCN to Lovelace
 
-	// 1. Decrypt private key
-	privateKey, err := s.walletService.DecryptPrivateKey(encryptedPrivateKey)
-	if err != nil {
-		return "", fmt.Errorf("failed to decrypt private key: %w", err)
-	}
-	privateKeyHex := crypto.PrivateKeyToHex(privateKey)
-
-	// 2. Prepare input for Node.js simple transfer script
-	inputData := map[string]interface{}{
-		"privateKey": privateKeyHex,
-		"toAddress":  toAddress,
-		"lovelace":   lovelaceAmount,
-	}
-
-	inputJSON, err := json.Marshal(inputData)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal input data: %w", err)
-	}
-
-	// 3. Execute simple ADA transfer script
-	cmd := exec.Command("node", "scripts/transfer/transfer-ada.mjs")
-	cmd.Env = append(os.Environ(), "NODE_OPTIONS=--dns-result-order=ipv4first")
-
-	stdin, err := cmd.StdinPipe()
+	// 1-4. Build, sign and submit with the signer the wallet was created with
+	hasRawKey, err := s.walletService.HasRawKey(from.EncryptedKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create stdin pipe: %w", err)
+		return "", err
 	}
 
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start transfer script: %w", err)
+	var txHash string
+	if hasRawKey {
+		txHash, err = s.transferWithPrivateKey(from, toAddress, lovelaceAmount)
+	} else {
+		txHash, err = s.transferWithWitness(from, toAddress, lovelaceAmount)
 	}
-
-	_, err = stdin.Write(inputJSON)
 	if err != nil {
-		return "", fmt.Errorf("failed to write to stdin: %w", err)
-	}
-	stdin.Close()
-
-	if err := cmd.Wait(); err != nil {
-		return "", fmt.Errorf("transfer script failed: %s (stderr: %s)", err, stderr.String())
-	}
-
-	// 4. Parse output
-	var result struct {
-		Status  string `json:"status"`
-		TxHash  string `json:"txHash"`
-		Message string `json:"message"`
-	}
-
-	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
-		return "", fmt.Errorf("failed to parse script output: %w (output: %s)", err, stdout.String())
-	}
-
-	if result.Status != "ok" {
-		return "", fmt.Errorf("transfer failed: %s", result.Message)
+		return "", err
 	}
 
-	txHash := result.TxHash
-
 	// 5. Record transaction
 	ctx := context.Background()
 	txLog := &models.TxLog{
@@ -207,9 +203,10 @@ func (s *CardanoService) TransferADA(
 		AmountLCN:     amountLCN,
 		AssetPolicyID: "ADA", // Mark as ADA-backed
 		AssetName:     "LCN",
-		Type:          models.TxTypeIssuance,
+		Type:          txType,
 		Status:        models.TxStatusPending,
 		SubmittedAt:   time.Now().UTC(),
+		Meta:          meta,
 	}
 
 	if err := s.txLogRepo.CreateTxLog(ctx, txLog); err != nil {
@@ -236,15 +233,95 @@ func (s *CardanoService) TransferADA(
 	return txHash, nil
 }
 
+// transferWithPrivateKey decrypts the wallet key and lets the Node.js script sign and submit
+func (s *CardanoService) transferWithPrivateKey(from crypto.WalletKey, toAddress string, lovelaceAmount uint64) (string, error) {
+	privateKey, err := s.walletService.DecryptPrivateKey(from)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	privateKeyHex := crypto.PrivateKeyToHex(privateKey)
+	crypto.ZeroBytes(privateKey)
+
+	var result struct {
+		TxHash string `json:"txHash"`
+	}
+	err = runTransferScript("scripts/transfer/transfer-ada.mjs", map[string]interface{}{
+		"privateKey": privateKeyHex,
+		"toAddress":  toAddress,
+		"lovelace":   lovelaceAmount,
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.TxHash, nil
+}
+
+// transferWithWitness builds the transaction unsigned, has the wallet's signer
+// sign its body hash and submits it with that witness. Used for Vault transit
+// keys, which never leave Vault, and HD keys, which the script cannot derive.
+func (s *CardanoService) transferWithWitness(from crypto.WalletKey, toAddress string, lovelaceAmount uint64) (string, error) {
+	var built struct {
+		TxHash string `json:"txHash"`
+		TxCBOR string `json:"txCbor"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":      "build",
+		"fromAddress": from.Address,
+		"toAddress":   toAddress,
+		"lovelace":    lovelaceAmount,
+	}, &built)
+	if err != nil {
+		return "", err
+	}
+
+	witness, err := s.signTxHash(built.TxHash, from)
+	if err != nil {
+		return "", err
+	}
+
+	var submitted struct {
+		TxHash string `json:"txHash"`
+	}
+	err = runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":     "submit",
+		"txCbor":     built.TxCBOR,
+		"witnessSet": hex.EncodeToString(crypto.EncodeVKeyWitnessSet([]crypto.VKeyWitness{*witness})),
+	}, &submitted)
+	if err != nil {
+		return "", err
+	}
+	return submitted.TxHash, nil
+}
+
+// signTxHash produces a vkey witness over a transaction body hash with the wallet's signer
+func (s *CardanoService) signTxHash(txHash string, key crypto.WalletKey) (*crypto.VKeyWitness, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hash: %w", err)
+	}
+
+	publicKey, err := s.walletService.PublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key: %w", err)
+	}
+	signature, err := s.walletService.SignTransaction(key, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &crypto.VKeyWitness{PublicKey: publicKey, Signature: signature}, nil
+}
+
 // Transfers LCN tokens from one address to another
 func (s *CardanoService) TransferLCN(
-	fromAddress string,
+	from crypto.WalletKey,
 	toAddress string,
 	amountLCN uint64, // in atomic units
-	encryptedPrivateKey string,
 ) (string, error) {
+	fromAddress := from.Address
+
 	// 1. Decrypt private key
-	privateKey, err := s.walletService.DecryptPrivateKey(encryptedPrivateKey)
+	privateKey, err := s.walletService.DecryptPrivateKey(from)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt private key: %w", err)
 	}
@@ -0,0 +1,191 @@
+package cardano
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Voucher NFT to mint under a merchant's policy
+type VoucherMint struct {
+	VoucherID string
+	Policy    *crypto.MultiSigScript
+	AssetName string // hex, without CIP-67 label
+	ToAddress string
+	Datum     map[string]interface{} // CIP-68 reference datum metadata
+	Metadata  map[string]interface{} // CIP-25 metadata of the user token
+	Deposit   uint64                 // lovelace sent along with the user token
+}
+
+// VoucherDeposit is the lovelace sent along with a voucher's user token: the
+// minimum an output holding one token needs, which balances do not count as LCN
+func (s *CardanoService) VoucherDeposit() uint64 {
+	return s.txBuilder.CalculateMinADA(1)
+}
+
+// MintVoucher mints a voucher's CIP-68 token pair, signed by the merchant's
+// wallet key (the policy's only signer), and records it in the transaction log
+func (s *CardanoService) MintVoucher(merchantKey crypto.WalletKey, mint *VoucherMint) (string, error) {
+	keyHashes := mint.Policy.KeyHashesHex()
+	if len(keyHashes) != 1 {
+		return "", fmt.Errorf("voucher policy must have a single signer")
+	}
+
+	var built struct {
+		TxHash   string `json:"txHash"`
+		TxCBOR   string `json:"txCbor"`
+		PolicyID string `json:"policyId"`
+	}
+	err := runTransferScript("scripts/transfer/voucher.mjs", map[string]interface{}{
+		"action":      "mint",
+		"fromAddress": merchantKey.Address,
+		"keyHash":     keyHashes[0],
+		"toAddress":   mint.ToAddress,
+		"assetName":   mint.AssetName,
+		"datum":       mint.Datum,
+		"metadata":    mint.Metadata,
+		"lovelace":    mint.Deposit,
+	}, &built)
+	if err != nil {
+		return "", err
+	}
+
+	policyID := hex.EncodeToString(mint.Policy.Hash())
+	txHash, err := s.submitVoucherTx(built.TxHash, built.TxCBOR, built.PolicyID, policyID, merchantKey)
+	if err != nil {
+		return "", err
+	}
+
+	s.recordVoucherTx(&models.TxLog{
+		TxHash:        txHash,
+		FromAddress:   merchantKey.Address,
+		ToAddress:     mint.ToAddress,
+		AssetPolicyID: policyID,
+		AssetName:     mint.AssetName,
+		Type:          models.TxTypeVoucherMint,
+		Meta: map[string]interface{}{
+			"voucher_id":       mint.VoucherID,
+			"deposit_lovelace": mint.Deposit,
+		},
+	})
+	return txHash, nil
+}
+
+// BurnVoucher burns a voucher's token pair. The holder's key signs for the
+// user token's output, the merchant's for the reference token, the burn and
+// the fee; the deposit goes back to the merchant.
+func (s *CardanoService) BurnVoucher(merchantKey, holderKey crypto.WalletKey, voucher *models.Voucher, policy *crypto.MultiSigScript) (string, error) {
+	keyHashes := policy.KeyHashesHex()
+	if len(keyHashes) != 1 {
+		return "", fmt.Errorf("voucher policy must have a single signer")
+	}
+
+	var built struct {
+		TxHash   string `json:"txHash"`
+		TxCBOR   string `json:"txCbor"`
+		PolicyID string `json:"policyId"`
+	}
+	err := runTransferScript("scripts/transfer/voucher.mjs", map[string]interface{}{
+		"action":          "burn",
+		"merchantAddress": merchantKey.Address,
+		"holderAddress":   holderKey.Address,
+		"keyHash":         keyHashes[0],
+		"assetName":       voucher.AssetName,
+		"lovelace":        voucher.DepositLovelace,
+	}, &built)
+	if err != nil {
+		return "", err
+	}
+
+	txHash, err := s.submitVoucherTx(built.TxHash, built.TxCBOR, built.PolicyID, voucher.PolicyID, holderKey, merchantKey)
+	if err != nil {
+		return "", err
+	}
+
+	s.recordVoucherTx(&models.TxLog{
+		TxHash:        txHash,
+		FromAddress:   holderKey.Address,
+		ToAddress:     merchantKey.Address,
+		AssetPolicyID: voucher.PolicyID,
+		AssetName:     voucher.AssetName,
+		Type:          models.TxTypeVoucherBurn,
+		Meta: map[string]interface{}{
+			"voucher_id":       voucher.ID,
+			"deposit_lovelace": voucher.DepositLovelace,
+		},
+	})
+	return txHash, nil
+}
+
+// submitVoucherTx checks that the script built the transaction under the
+// expected policy, witnesses it with the given keys and submits it
+func (s *CardanoService) submitVoucherTx(txHash, txCBOR, builtPolicyID, policyID string, signers ...crypto.WalletKey) (string, error) {
+	if builtPolicyID != policyID {
+		return "", fmt.Errorf("voucher policy mismatch: script built %s, expected %s", builtPolicyID, policyID)
+	}
+
+	witnesses := make([]crypto.VKeyWitness, 0, len(signers))
+	for _, signer := range signers {
+		witness, err := s.signTxHash(txHash, signer)
+		if err != nil {
+			return "", err
+		}
+		witnesses = append(witnesses, *witness)
+	}
+
+	var submitted struct {
+		TxHash string `json:"txHash"`
+	}
+	err := runTransferScript("scripts/transfer/unsigned-tx.mjs", map[string]interface{}{
+		"action":     "submit",
+		"txCbor":     txCBOR,
+		"witnessSet": hex.EncodeToString(crypto.EncodeVKeyWitnessSet(witnesses)),
+	}, &submitted)
+	if err != nil {
+		return "", err
+	}
+	return submitted.TxHash, nil
+}
+
+// recordVoucherTx logs a submitted voucher transaction and clears the UTXO
+// caches of both wallets
+func (s *CardanoService) recordVoucherTx(txLog *models.TxLog) {
+	ctx := context.Background()
+	txLog.Status = models.TxStatusPending
+	txLog.SubmittedAt = time.Now().UTC()
+	if err := s.txLogRepo.CreateTxLog(ctx, txLog); err != nil {
+		logger.Warn("Failed to record transaction", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	for _, address := range []string{txLog.FromAddress, txLog.ToAddress} {
+		if err := s.utxoRepo.ClearCache(ctx, address); err != nil {
+			logger.Warn("Failed to clear UTXO cache", map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			})
+		}
+	}
+}
+
+// VoucherHolders returns the addresses holding a voucher's user token; none
+// once it is burned
+func (s *CardanoService) VoucherHolders(unit string) ([]string, error) {
+	holders, err := s.blockfrost.GetAssetAddresses(unit)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(holders))
+	for _, holder := range holders {
+		if holder.Quantity != "0" {
+			addresses = append(addresses, holder.Address)
+		}
+	}
+	return addresses, nil
+}
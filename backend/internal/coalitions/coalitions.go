@@ -0,0 +1,228 @@
+// Package coalitions clears LCN between the merchants of a coalition. When a
+// customer redeems LCN at a member, the LCN is attributed to the merchants
+// that issued it (oldest lots first, as customers spend them); each other
+// member whose LCN was redeemed owes the redeeming member under the
+// coalition's clearing rate. Open balances are netted every period into as
+// few clearing settlements as settle every member's net position.
+package coalitions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/expiry"
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+const (
+	MinMembers           = 2
+	MaxMembers           = 50
+	MaxNettingPeriodDays = 90
+
+	// Clearing rates are in basis points of the LCN redeemed
+	ParRateBPS = 10000
+
+	maxNameLength = 64
+)
+
+var (
+	ErrUnknownMerchant = errors.New("no merchant with this ID")
+	ErrNettingClaimed  = errors.New("the coalition is being netted by another run")
+)
+
+// Coalition fields an admin sets when creating or updating one
+type Request struct {
+	Name              string   `json:"name"`
+	MerchantIDs       []string `json:"merchant_ids"`
+	RateBPS           int      `json:"rate_bps"`
+	NettingPeriodDays int      `json:"netting_period_days"`
+}
+
+// Validate checks and trims a coalition request, dropping repeated members
+func (r *Request) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d bytes", maxNameLength)
+	}
+
+	seen := make(map[string]bool, len(r.MerchantIDs))
+	members := make([]string, 0, len(r.MerchantIDs))
+	for _, id := range r.MerchantIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		members = append(members, id)
+	}
+	r.MerchantIDs = members
+	if len(members) < MinMembers || len(members) > MaxMembers {
+		return fmt.Errorf("a coalition needs between %d and %d merchants", MinMembers, MaxMembers)
+	}
+
+	if r.RateBPS < 1 || r.RateBPS > ParRateBPS {
+		return fmt.Errorf("rate_bps must be between 1 and %d", ParRateBPS)
+	}
+	if r.NettingPeriodDays < 1 || r.NettingPeriodDays > MaxNettingPeriodDays {
+		return fmt.Errorf("netting_period_days must be between 1 and %d", MaxNettingPeriodDays)
+	}
+	return nil
+}
+
+// Rules are the clearing rules of a validated request
+func (r *Request) Rules() models.ClearingRules {
+	return models.ClearingRules{
+		RateBPS:           r.RateBPS,
+		NettingPeriodDays: r.NettingPeriodDays,
+	}
+}
+
+// NextNetting is when balances netted at from are next netted
+func NextNetting(from time.Time, rules models.ClearingRules) time.Time {
+	return from.AddDate(0, 0, rules.NettingPeriodDays)
+}
+
+// Attribute splits the LCN a customer redeemed among the merchants that
+// issued it, given the customer's lots oldest first and their balance after
+// the redemption. Customers spend their oldest LCN first, so the redemption
+// took what the balance no longer covers. LCN held before lots were tracked
+// counts as older than every lot; neither it nor the lots of LCN received
+// other than from a merchant is attributed to anyone.
+func Attribute(lots []*models.LCNLot, balanceAfter, redeemed uint64) map[string]uint64 {
+	before := make([]uint64, len(lots))
+	expiry.AllocateBalance(lots, balanceAfter+redeemed)
+	for i, lot := range lots {
+		before[i] = lot.RemainingLCN
+	}
+	expiry.AllocateBalance(lots, balanceAfter)
+
+	issued := make(map[string]uint64)
+	for i, lot := range lots {
+		if spent := before[i] - lot.RemainingLCN; spent > 0 && lot.MerchantID != "" {
+			issued[lot.MerchantID] += spent
+		}
+	}
+	return issued
+}
+
+// ClearingAmount is what an issuer owes for LCN of theirs redeemed at
+// another member, at a clearing rate in basis points (rounded down)
+func ClearingAmount(redeemedLCN uint64, rateBPS int) uint64 {
+	return redeemedLCN * uint64(rateBPS) / ParRateBPS
+}
+
+// Positions sums what each member is owed and owes over bilateral balances,
+// by merchant ID
+func Positions(balances []models.ClearingBalance) []models.NetPosition {
+	byMerchant := make(map[string]*models.NetPosition)
+	position := func(merchantID string) *models.NetPosition {
+		p, ok := byMerchant[merchantID]
+		if !ok {
+			p = &models.NetPosition{MerchantID: merchantID}
+			byMerchant[merchantID] = p
+		}
+		return p
+	}
+	for _, balance := range balances {
+		if balance.PayerID == balance.PayeeID {
+			continue
+		}
+		position(balance.PayerID).PayableLCN += balance.AmountLCN
+		position(balance.PayeeID).ReceivableLCN += balance.AmountLCN
+	}
+
+	return sortedPositions(byMerchant)
+}
+
+// Counterparties breaks a member's position down by the members it is owed
+// by or owes, from its own side: ReceivableLCN is what the counterparty owes
+// the member
+func Counterparties(balances []models.ClearingBalance, merchantID string) []models.NetPosition {
+	byMerchant := make(map[string]*models.NetPosition)
+	counterparty := func(id string) *models.NetPosition {
+		p, ok := byMerchant[id]
+		if !ok {
+			p = &models.NetPosition{MerchantID: id}
+			byMerchant[id] = p
+		}
+		return p
+	}
+	for _, balance := range balances {
+		switch {
+		case balance.PayerID == balance.PayeeID:
+		case balance.PayeeID == merchantID:
+			counterparty(balance.PayerID).ReceivableLCN += balance.AmountLCN
+		case balance.PayerID == merchantID:
+			counterparty(balance.PayeeID).PayableLCN += balance.AmountLCN
+		}
+	}
+	return sortedPositions(byMerchant)
+}
+
+// sortedPositions nets positions and lists them by merchant ID
+func sortedPositions(byMerchant map[string]*models.NetPosition) []models.NetPosition {
+	positions := make([]models.NetPosition, 0, len(byMerchant))
+	for _, p := range byMerchant {
+		p.NetLCN = int64(p.ReceivableLCN) - int64(p.PayableLCN)
+		positions = append(positions, *p)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].MerchantID < positions[j].MerchantID
+	})
+	return positions
+}
+
+// Payments settles net positions with at most one payment fewer than there
+// are members with a non-zero position: the largest debtor pays the largest
+// creditor until one of them is square, and so on
+func Payments(positions []models.NetPosition) []models.ClearingPayment {
+	type party struct {
+		merchantID string
+		amount     uint64
+	}
+	var debtors, creditors []*party
+	for _, p := range positions {
+		switch {
+		case p.NetLCN < 0:
+			debtors = append(debtors, &party{p.MerchantID, uint64(-p.NetLCN)})
+		case p.NetLCN > 0:
+			creditors = append(creditors, &party{p.MerchantID, uint64(p.NetLCN)})
+		}
+	}
+	byAmount := func(parties []*party) {
+		sort.Slice(parties, func(i, j int) bool {
+			if parties[i].amount != parties[j].amount {
+				return parties[i].amount > parties[j].amount
+			}
+			return parties[i].merchantID < parties[j].merchantID
+		})
+	}
+	byAmount(debtors)
+	byAmount(creditors)
+
+	var payments []models.ClearingPayment
+	for d, c := 0, 0; d < len(debtors) && c < len(creditors); {
+		debtor, creditor := debtors[d], creditors[c]
+		amount := min(debtor.amount, creditor.amount)
+		payments = append(payments, models.ClearingPayment{
+			PayerID:   debtor.merchantID,
+			PayeeID:   creditor.merchantID,
+			AmountLCN: amount,
+		})
+		debtor.amount -= amount
+		creditor.amount -= amount
+		if debtor.amount == 0 {
+			d++
+		}
+		if creditor.amount == 0 {
+			c++
+		}
+	}
+	return payments
+}
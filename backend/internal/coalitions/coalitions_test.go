@@ -0,0 +1,203 @@
+package coalitions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   Request
+		valid bool
+	}{
+		{"valid", Request{Name: " Mall ", MerchantIDs: []string{"a", "b"}, RateBPS: 9500, NettingPeriodDays: 7}, true},
+		{"at par", Request{Name: "Mall", MerchantIDs: []string{"a", "b"}, RateBPS: ParRateBPS, NettingPeriodDays: 1}, true},
+		{"no name", Request{MerchantIDs: []string{"a", "b"}, RateBPS: 9500, NettingPeriodDays: 7}, false},
+		{"one member", Request{Name: "Mall", MerchantIDs: []string{"a"}, RateBPS: 9500, NettingPeriodDays: 7}, false},
+		{"repeated member", Request{Name: "Mall", MerchantIDs: []string{"a", " a", ""}, RateBPS: 9500, NettingPeriodDays: 7}, false},
+		{"no rate", Request{Name: "Mall", MerchantIDs: []string{"a", "b"}, NettingPeriodDays: 7}, false},
+		{"above par", Request{Name: "Mall", MerchantIDs: []string{"a", "b"}, RateBPS: ParRateBPS + 1, NettingPeriodDays: 7}, false},
+		{"no period", Request{Name: "Mall", MerchantIDs: []string{"a", "b"}, RateBPS: 9500}, false},
+		{"long period", Request{Name: "Mall", MerchantIDs: []string{"a", "b"}, RateBPS: 9500, NettingPeriodDays: MaxNettingPeriodDays + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+
+	req := Request{Name: " Mall ", MerchantIDs: []string{"a", "b ", "a"}, RateBPS: 9500, NettingPeriodDays: 7}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if req.Name != "Mall" || !reflect.DeepEqual(req.MerchantIDs, []string{"a", "b"}) {
+		t.Errorf("expected a trimmed name and distinct members, got %q %v", req.Name, req.MerchantIDs)
+	}
+}
+
+func TestNextNetting(t *testing.T) {
+	from := time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)
+	if got := NextNetting(from, models.ClearingRules{NettingPeriodDays: 7}); !got.Equal(from.AddDate(0, 0, 7)) {
+		t.Errorf("NextNetting = %v", got)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	lots := func() []*models.LCNLot {
+		return []*models.LCNLot{
+			{MerchantID: "a", AmountLCN: 100},
+			{MerchantID: "b", AmountLCN: 50},
+			{MerchantID: "a", AmountLCN: 30},
+		}
+	}
+	tests := []struct {
+		name         string
+		balanceAfter uint64
+		redeemed     uint64
+		want         map[string]uint64
+	}{
+		{"oldest lot first", 150, 30, map[string]uint64{"a": 30}},
+		{"across lots", 60, 70, map[string]uint64{"a": 50, "b": 20}},
+		{"whole wallet", 0, 180, map[string]uint64{"a": 130, "b": 50}},
+		{"untracked LCN first", 180, 20, map[string]uint64{}},
+		{"partly untracked", 170, 20, map[string]uint64{"a": 10}},
+		{"nothing redeemed", 100, 0, map[string]uint64{}},
+	}
+	for _, tt := range tests {
+		if got := Attribute(lots(), tt.balanceAfter, tt.redeemed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Attribute = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := Attribute(nil, 10, 10); len(got) != 0 {
+		t.Errorf("expected nothing attributed without lots, got %v", got)
+	}
+}
+
+func TestAttribute_ReceivedLCN(t *testing.T) {
+	lots := func() []*models.LCNLot {
+		return []*models.LCNLot{
+			{MerchantID: "a", AmountLCN: 100},
+			{Source: models.TxTypeTransfer, AmountLCN: 50},
+			{MerchantID: "b", AmountLCN: 30},
+		}
+	}
+	tests := []struct {
+		name         string
+		balanceAfter uint64
+		redeemed     uint64
+		want         map[string]uint64
+	}{
+		{"older issued lot first", 80, 100, map[string]uint64{"a": 100}},
+		{"gift is billed to nobody", 30, 150, map[string]uint64{"a": 100}},
+		{"only the gift", 30, 50, map[string]uint64{}},
+		{"past the gift", 10, 170, map[string]uint64{"a": 100, "b": 20}},
+	}
+	for _, tt := range tests {
+		if got := Attribute(lots(), tt.balanceAfter, tt.redeemed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Attribute = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClearingAmount(t *testing.T) {
+	tests := []struct {
+		redeemed uint64
+		rateBPS  int
+		want     uint64
+	}{
+		{100, ParRateBPS, 100},
+		{100, 9500, 95},
+		{7, 9500, 6},
+		{0, 9500, 0},
+	}
+	for _, tt := range tests {
+		if got := ClearingAmount(tt.redeemed, tt.rateBPS); got != tt.want {
+			t.Errorf("ClearingAmount(%d, %d) = %d, want %d", tt.redeemed, tt.rateBPS, got, tt.want)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	positions := Positions([]models.ClearingBalance{
+		{PayerID: "a", PayeeID: "b", AmountLCN: 100},
+		{PayerID: "b", PayeeID: "a", AmountLCN: 30},
+		{PayerID: "c", PayeeID: "a", AmountLCN: 50},
+		{PayerID: "c", PayeeID: "c", AmountLCN: 10},
+	})
+	want := []models.NetPosition{
+		{MerchantID: "a", ReceivableLCN: 80, PayableLCN: 100, NetLCN: -20},
+		{MerchantID: "b", ReceivableLCN: 100, PayableLCN: 30, NetLCN: 70},
+		{MerchantID: "c", ReceivableLCN: 0, PayableLCN: 50, NetLCN: -50},
+	}
+	if !reflect.DeepEqual(positions, want) {
+		t.Errorf("Positions = %+v, want %+v", positions, want)
+	}
+}
+
+func TestCounterparties(t *testing.T) {
+	counterparties := Counterparties([]models.ClearingBalance{
+		{PayerID: "a", PayeeID: "b", AmountLCN: 100},
+		{PayerID: "b", PayeeID: "a", AmountLCN: 30},
+		{PayerID: "c", PayeeID: "a", AmountLCN: 50},
+		{PayerID: "c", PayeeID: "b", AmountLCN: 40},
+	}, "a")
+	want := []models.NetPosition{
+		{MerchantID: "b", ReceivableLCN: 30, PayableLCN: 100, NetLCN: -70},
+		{MerchantID: "c", ReceivableLCN: 50, PayableLCN: 0, NetLCN: 50},
+	}
+	if !reflect.DeepEqual(counterparties, want) {
+		t.Errorf("Counterparties = %+v, want %+v", counterparties, want)
+	}
+}
+
+func TestPayments(t *testing.T) {
+	positions := []models.NetPosition{
+		{MerchantID: "a", NetLCN: -20},
+		{MerchantID: "b", NetLCN: 70},
+		{MerchantID: "c", NetLCN: -50},
+		{MerchantID: "d", NetLCN: 0},
+	}
+	payments := Payments(positions)
+	want := []models.ClearingPayment{
+		{PayerID: "c", PayeeID: "b", AmountLCN: 50},
+		{PayerID: "a", PayeeID: "b", AmountLCN: 20},
+	}
+	if !reflect.DeepEqual(payments, want) {
+		t.Errorf("Payments = %+v, want %+v", payments, want)
+	}
+
+	// Paying out every payment squares every position
+	positions = []models.NetPosition{
+		{MerchantID: "a", NetLCN: -45},
+		{MerchantID: "b", NetLCN: 30},
+		{MerchantID: "c", NetLCN: -15},
+		{MerchantID: "d", NetLCN: 20},
+		{MerchantID: "e", NetLCN: 10},
+	}
+	net := make(map[string]int64)
+	for _, p := range positions {
+		net[p.MerchantID] = p.NetLCN
+	}
+	payments = Payments(positions)
+	if len(payments) > 4 {
+		t.Errorf("expected at most 4 payments, got %d", len(payments))
+	}
+	for _, payment := range payments {
+		net[payment.PayerID] += int64(payment.AmountLCN)
+		net[payment.PayeeID] -= int64(payment.AmountLCN)
+	}
+	for merchantID, amount := range net {
+		if amount != 0 {
+			t.Errorf("%s left at %d", merchantID, amount)
+		}
+	}
+
+	if got := Payments(nil); len(got) != 0 {
+		t.Errorf("expected no payments, got %v", got)
+	}
+}
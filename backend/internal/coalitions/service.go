@@ -0,0 +1,399 @@
+package coalitions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Config struct {
+	CheckInterval time.Duration // how often coalitions due for netting are looked for
+	BatchSize     int
+	ExchangeRate  float64 // LCN to ETB, for the value of clearing settlements
+}
+
+type Service struct {
+	config         *Config
+	cardanoService *cardano.CardanoService
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	lotRepo        *storage.LotRepository
+	coalitionRepo  *storage.CoalitionRepository
+	settlementRepo *storage.SettlementRepository
+	stopCh         chan struct{}
+	stoppedCh      chan struct{}
+}
+
+func NewService(
+	config *Config,
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	lotRepo *storage.LotRepository,
+	coalitionRepo *storage.CoalitionRepository,
+	settlementRepo *storage.SettlementRepository,
+) *Service {
+	return &Service{
+		config:         config,
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		lotRepo:        lotRepo,
+		coalitionRepo:  coalitionRepo,
+		settlementRepo: settlementRepo,
+		stopCh:         make(chan struct{}),
+		stoppedCh:      make(chan struct{}),
+	}
+}
+
+// Begins netting coalitions as they fall due in the background
+func (s *Service) Start() {
+	logger.Info("Starting coalition clearing service", map[string]interface{}{
+		"check_interval": s.config.CheckInterval,
+	})
+	go s.run()
+}
+
+// Gracefully stops the clearing service
+func (s *Service) Stop() {
+	close(s.stopCh)
+	<-s.stoppedCh
+	logger.Info("Coalition clearing service stopped", nil)
+}
+
+func (s *Service) run() {
+	defer close(s.stoppedCh)
+
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	s.netDue()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.netDue()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Create records a coalition of existing merchants; its first netting is one
+// period away
+func (s *Service) Create(ctx context.Context, req Request, createdBy string) (*models.Coalition, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkMerchants(ctx, req.MerchantIDs); err != nil {
+		return nil, err
+	}
+	coalition := &models.Coalition{
+		Name:          req.Name,
+		MerchantIDs:   req.MerchantIDs,
+		Rules:         req.Rules(),
+		CreatedBy:     createdBy,
+		NextNettingAt: NextNetting(time.Now().UTC(), req.Rules()),
+	}
+	if err := s.coalitionRepo.CreateCoalition(ctx, coalition); err != nil {
+		return nil, err
+	}
+	return coalition, nil
+}
+
+// Update replaces a coalition's name, members and rules. Open entries of
+// members who leave are still netted with the coalition.
+func (s *Service) Update(ctx context.Context, id string, req Request) (*models.Coalition, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkMerchants(ctx, req.MerchantIDs); err != nil {
+		return nil, err
+	}
+	return s.coalitionRepo.UpdateCoalition(ctx, id, req.Name, req.MerchantIDs, req.Rules())
+}
+
+func (s *Service) checkMerchants(ctx context.Context, merchantIDs []string) error {
+	for _, id := range merchantIDs {
+		if _, err := s.userRepo.GetMerchantByID(ctx, id); err != nil {
+			return fmt.Errorf("%w: %s", ErrUnknownMerchant, id)
+		}
+	}
+	return nil
+}
+
+// RecordTransaction records what the issuers of LCN redeemed at a coalition
+// member owe it once the redemption confirms, and reverses it when the
+// redemption is refunded
+func (s *Service) RecordTransaction(ctx context.Context, tx *models.TxLog) {
+	var err error
+	switch tx.Type {
+	case models.TxTypeIssuance, models.TxTypeRedemption:
+		// Custodial redemptions were logged as issuances
+		err = s.recordRedemption(ctx, tx)
+	case models.TxTypeRefund:
+		err = s.recordRefund(ctx, tx)
+	default:
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to record coalition clearing", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+			"type":    tx.Type,
+		})
+	}
+}
+
+func (s *Service) recordRedemption(ctx context.Context, tx *models.TxLog) error {
+	merchant, err := s.userRepo.GetMerchantByWalletAddress(ctx, tx.ToAddress)
+	if err != nil {
+		return nil
+	}
+	// Lots, and so issuers, are only tracked for custodial wallets
+	customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, tx.FromAddress)
+	if err != nil || customer.Wallet.Custody != models.WalletCustodial {
+		return nil
+	}
+	coalition, err := s.coalitionRepo.GetCoalitionByMerchant(ctx, merchant.ID)
+	if errors.Is(err, storage.ErrCoalitionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lots, err := s.lotRepo.GetHeldLots(ctx, customer.Wallet.Address)
+	if err != nil || len(lots) == 0 {
+		return err
+	}
+	// The chain balance includes the redemption now that it confirmed
+	balance, err := s.cardanoService.GetChainBalance(customer.Wallet.Address)
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	members := make(map[string]bool, len(coalition.MerchantIDs))
+	for _, id := range coalition.MerchantIDs {
+		members[id] = true
+	}
+	for issuerID, redeemed := range Attribute(lots, balance.LCNAtomic, tx.AmountLCN) {
+		if issuerID == merchant.ID || !members[issuerID] {
+			continue
+		}
+		entry := &models.ClearingEntry{
+			CoalitionID: coalition.ID,
+			TxHash:      tx.TxHash,
+			PayerID:     issuerID,
+			PayeeID:     merchant.ID,
+			CustomerID:  customer.ID,
+			RedeemedLCN: redeemed,
+			AmountLCN:   ClearingAmount(redeemed, coalition.Rules.RateBPS),
+			RateBPS:     coalition.Rules.RateBPS,
+		}
+		if entry.AmountLCN == 0 {
+			continue
+		}
+		if err := s.coalitionRepo.CreateClearingEntry(ctx, entry); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordRefund reverses the refunded share of a redemption's entries: the
+// redeeming member gave the value back, so it owes the issuer in return
+func (s *Service) recordRefund(ctx context.Context, tx *models.TxLog) error {
+	refundOf, _ := tx.Meta["refund_of"].(string)
+	if refundOf == "" {
+		return nil
+	}
+	entries, err := s.coalitionRepo.GetRedemptionEntries(ctx, refundOf)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	original, err := s.txLogRepo.GetTxLogByHash(ctx, refundOf)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded transaction: %w", err)
+	}
+	if original.AmountLCN == 0 {
+		return nil
+	}
+	refunded := min(tx.AmountLCN, original.AmountLCN)
+
+	for _, entry := range entries {
+		reversal := &models.ClearingEntry{
+			CoalitionID: entry.CoalitionID,
+			TxHash:      tx.TxHash,
+			RefundOf:    refundOf,
+			PayerID:     entry.PayeeID,
+			PayeeID:     entry.PayerID,
+			CustomerID:  entry.CustomerID,
+			RedeemedLCN: entry.RedeemedLCN * refunded / original.AmountLCN,
+			AmountLCN:   entry.AmountLCN * refunded / original.AmountLCN,
+			RateBPS:     entry.RateBPS,
+		}
+		if reversal.AmountLCN == 0 {
+			continue
+		}
+		if err := s.coalitionRepo.CreateClearingEntry(ctx, reversal); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenPositions returns every member's position over the coalition's entries
+// not netted yet, by merchant ID
+func (s *Service) OpenPositions(ctx context.Context, coalition *models.Coalition) ([]models.NetPosition, error) {
+	balances, err := s.coalitionRepo.OpenBalances(ctx, coalition.ID)
+	if err != nil {
+		return nil, err
+	}
+	positions := Positions(balances)
+
+	// Members with nothing open are square
+	listed := make(map[string]bool, len(positions))
+	for _, p := range positions {
+		listed[p.MerchantID] = true
+	}
+	for _, id := range coalition.MerchantIDs {
+		if !listed[id] {
+			positions = append(positions, models.NetPosition{MerchantID: id})
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].MerchantID < positions[j].MerchantID
+	})
+	s.nameMerchants(ctx, positions)
+	return positions, nil
+}
+
+// MerchantPosition returns a member's position over its coalition's entries
+// not netted yet, and its breakdown by counterparty
+func (s *Service) MerchantPosition(ctx context.Context, coalition *models.Coalition, merchantID string) (*models.NetPosition, []models.NetPosition, error) {
+	balances, err := s.coalitionRepo.OpenBalances(ctx, coalition.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	counterparties := Counterparties(balances, merchantID)
+	position := &models.NetPosition{MerchantID: merchantID}
+	for _, counterparty := range counterparties {
+		position.ReceivableLCN += counterparty.ReceivableLCN
+		position.PayableLCN += counterparty.PayableLCN
+	}
+	position.NetLCN = int64(position.ReceivableLCN) - int64(position.PayableLCN)
+	s.nameMerchants(ctx, counterparties)
+	return position, counterparties, nil
+}
+
+// Net closes a coalition's open entries into a netting and requests a
+// clearing settlement for each payment that squares the members' positions.
+// Returns nil when there was nothing to net. The next netting is one period
+// from now.
+func (s *Service) Net(ctx context.Context, coalition *models.Coalition) (*models.Netting, error) {
+	now := time.Now().UTC()
+	claimed, err := s.coalitionRepo.ClaimNetting(ctx, coalition.ID, coalition.NextNettingAt, now, NextNetting(now, coalition.Rules))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNettingClaimed
+	}
+
+	open, err := s.coalitionRepo.OpenBalances(ctx, coalition.ID)
+	if err != nil || len(open) == 0 {
+		return nil, err
+	}
+	netting := &models.Netting{
+		CoalitionID: coalition.ID,
+		Cutoff:      now,
+	}
+	if err := s.coalitionRepo.CreateNetting(ctx, netting); err != nil {
+		return nil, err
+	}
+	count, err := s.coalitionRepo.NetEntries(ctx, coalition.ID, netting.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.coalitionRepo.NettedBalances(ctx, netting.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	netting.Entries = int(count)
+	for _, balance := range balances {
+		netting.GrossLCN += balance.AmountLCN
+	}
+	netting.Positions = Positions(balances)
+	s.nameMerchants(ctx, netting.Positions)
+	netting.Payments = Payments(netting.Positions)
+	for i := range netting.Payments {
+		payment := &netting.Payments[i]
+		settlement := &models.SettlementRequest{
+			MerchantID:      payment.PayerID,
+			AmountLCN:       payment.AmountLCN,
+			AmountETB:       float64(payment.AmountLCN) / 1000.0 * s.config.ExchangeRate,
+			ExchangeRate:    s.config.ExchangeRate,
+			Kind:            models.SettlementClearing,
+			PayeeMerchantID: payment.PayeeID,
+			NettingID:       netting.ID,
+		}
+		if err := s.settlementRepo.CreateSettlement(ctx, settlement); err != nil {
+			// The netting still records the payment; an admin can settle it by hand
+			logger.Error("Failed to request clearing settlement", err, map[string]interface{}{
+				"netting_id": netting.ID,
+				"payer_id":   payment.PayerID,
+				"payee_id":   payment.PayeeID,
+				"amount_lcn": payment.AmountLCN,
+			})
+			continue
+		}
+		payment.SettlementID = settlement.ID
+	}
+	if err := s.coalitionRepo.CompleteNetting(ctx, netting); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Coalition netted", map[string]interface{}{
+		"coalition_id": coalition.ID,
+		"netting_id":   netting.ID,
+		"entries":      netting.Entries,
+		"gross_lcn":    netting.GrossLCN,
+		"payments":     len(netting.Payments),
+	})
+	return netting, nil
+}
+
+// Nets the coalitions whose period has ended
+func (s *Service) netDue() {
+	ctx := context.Background()
+	coalitions, err := s.coalitionRepo.GetDueCoalitions(ctx, time.Now().UTC(), s.config.BatchSize)
+	if err != nil {
+		logger.Error("Failed to find coalitions due for netting", err, nil)
+		return
+	}
+	for _, coalition := range coalitions {
+		if _, err := s.Net(ctx, coalition); err != nil && !errors.Is(err, ErrNettingClaimed) {
+			logger.Error("Failed to net coalition", err, map[string]interface{}{
+				"coalition_id": coalition.ID,
+			})
+		}
+	}
+}
+
+// nameMerchants fills in the business names of positions' merchants
+func (s *Service) nameMerchants(ctx context.Context, positions []models.NetPosition) {
+	for i := range positions {
+		if merchant, err := s.userRepo.GetMerchantByID(ctx, positions[i].MerchantID); err == nil {
+			positions[i].BusinessName = merchant.BusinessName
+		}
+	}
+}
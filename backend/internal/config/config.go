@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -29,10 +30,24 @@ type Config struct {
 	LCNAssetName            string
 	GovernanceWalletAddress string
 
-	// Vault
-	VaultAddr       string
-	VaultToken      string
-	VaultTransitKey string
+	// Governance multi-sig (empty key hashes = single-key governance wallet)
+	GovernanceScriptKeyHashes          []string
+	GovernanceScriptRequiredSignatures int
+	GovernanceTxTTLHours               int
+
+	// Key management
+	EncryptionBackend        string // vault or local
+	EncryptionKey            string // hex key for the local backend (or legacy fb: DEKs)
+	VaultAddr                string
+	VaultTransitKey          string
+	VaultAuthMethod          string // token, approle or kubernetes
+	VaultAuthMount           string // defaults to the method name
+	VaultToken               string
+	VaultRoleID              string
+	VaultSecretID            string
+	VaultKubernetesRole      string
+	VaultKubernetesTokenPath string
+	WalletSigner             string // envelope or vault-transit (for new wallets)
 
 	// JWT
 	JWTPrivateKeyPath  string
@@ -40,12 +55,24 @@ type Config struct {
 	JWTExpirationHours int
 
 	// Security
-	BcryptCost int
-	AESKeySize int
+	BcryptCost          int
+	AESKeySize          int
+	RBACCacheTTLSeconds int
 
 	// Rate Limiting
-	RateLimitPerIP   int
-	RateLimitPerUser int
+	RateLimitBackend       string // memory or redis
+	RateLimitPerIP         int
+	RateLimitPerUser       int
+	RateLimitLoginPerIP    int
+	RateLimitIssuePerUser  int
+	RateLimitRedeemPerUser int
+
+	// Client IP resolution (rate limits, audit logs, referral fraud checks)
+	TrustedProxies  []string // proxy IPs/CIDRs whose X-Forwarded-For is honoured; empty trusts none
+	TrustedPlatform string   // header set by the hosting platform's edge, e.g. CF-Connecting-IP
+
+	// Idempotency
+	IdempotencyKeyTTLHours int // how long a response is replayed for a repeated Idempotency-Key
 
 	// Transaction Settings
 	MinADAOutput          uint64
@@ -54,11 +81,51 @@ type Config struct {
 	FeeBufferMultiplier   float64
 	ConfirmationsRequired int
 	WalletSeedADA         uint64
+	RedeemToMerchantsOnly bool // reject redemptions to addresses that are not merchant wallets
+	PendingRewardTTLDays  int  // how long LCN issued to a customer who has not signed up stays claimable
+
+	// Email and phone verification codes (unset: logged in development, unavailable in production)
+	SMTPAddr        string // host:port
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	SMSWebhookURL   string // gateway receiving {"to", "message"} as JSON
+	SMSWebhookToken string // sent as a bearer token
+
+	// Customer-to-customer transfers (off for closed-loop deployments)
+	TransfersEnabled          bool
+	TransferDailyLimitLCN     uint64 // LCN a customer can send per day (UTC); 0: no limit
+	TransferDailyMaxTransfers int    // transfers a customer can send per day (UTC); 0: no limit
+
+	// Points expiry
+	LCNExpiryMonths               int    // platform expiry window from issuance; 0: LCN never expires
+	LCNExpiryWarningDays          int    // how long before expiry customers are warned
+	LCNExpiryReturnTo             string // issuer or governance
+	LCNExpirySweepIntervalMinutes int
+
+	// Customer tiers
+	CustomerTiers      string // name:min_lcn:earn_multiplier, comma separated, lowest first
+	TierEvaluationHour int    // hour of the day (UTC) tiers are re-evaluated
+
+	// Referrals (rewards paid from the governance wallet; both 0 disables)
+	ReferralReferrerRewardLCN uint64
+	ReferralRefereeRewardLCN  uint64
+	ReferralMinQualifyingLCN  uint64 // smallest transaction that qualifies a referee
+	ReferralMaxPerReferrer    int    // referrals a referrer can be rewarded for; 0: no cap
+	ReferralMaxSignupsPerIP   int    // referred signups from one IP within a day before review; 0: no limit
+	ReferralReviewAll         bool   // send every qualified referral to admin review
+	// Coalition clearing
+	CoalitionNettingCheckMinutes int // how often coalitions due for netting are looked for
 
 	// Settlement
 	ExchangeRateLCNETB            float64
 	SettlementProcessingTimeHours int
 
+	// Dual control (thresholds in LCN; 0 disables)
+	AllocationApprovalThresholdLCN uint64
+	SettlementApprovalThresholdLCN uint64
+	DualControlApprovals           int
+
 	// Monitoring
 	PrometheusPort string
 	SentryDSN      string
@@ -96,10 +163,24 @@ func Load() *Config {
 		LCNAssetName:            getEnv("LCN_ASSET_NAME", "4c434e"),
 		GovernanceWalletAddress: getEnv("GOVERNANCE_WALLET_ADDRESS", ""),
 
-		// Vault
-		VaultAddr:       getEnv("VAULT_ADDR", "http://localhost:8200"),
-		VaultToken:      getEnv("VAULT_TOKEN", ""),
-		VaultTransitKey: getEnv("VAULT_TRANSIT_KEY", "lcn-keys"),
+		// Governance multi-sig
+		GovernanceScriptKeyHashes:          getEnvAsSlice("GOVERNANCE_SCRIPT_KEY_HASHES"),
+		GovernanceScriptRequiredSignatures: getEnvAsInt("GOVERNANCE_SCRIPT_REQUIRED_SIGNATURES", 0),
+		GovernanceTxTTLHours:               getEnvAsInt("GOVERNANCE_TX_TTL_HOURS", 24),
+
+		// Key management
+		EncryptionBackend:        getEnv("ENCRYPTION_BACKEND", "vault"),
+		EncryptionKey:            getEnv("ENCRYPTION_KEY", ""),
+		VaultAddr:                getEnv("VAULT_ADDR", "http://localhost:8200"),
+		VaultTransitKey:          getEnv("VAULT_TRANSIT_KEY", "lcn-keys"),
+		VaultAuthMethod:          getEnv("VAULT_AUTH_METHOD", "token"),
+		VaultAuthMount:           getEnv("VAULT_AUTH_MOUNT", ""),
+		VaultToken:               getEnv("VAULT_TOKEN", ""),
+		VaultRoleID:              getEnv("VAULT_ROLE_ID", ""),
+		VaultSecretID:            getEnv("VAULT_SECRET_ID", ""),
+		VaultKubernetesRole:      getEnv("VAULT_KUBERNETES_ROLE", ""),
+		VaultKubernetesTokenPath: getEnv("VAULT_KUBERNETES_TOKEN_PATH", "/var/run/secrets/kubernetes.io/serviceaccount/token"),
+		WalletSigner:             getEnv("WALLET_SIGNER", "envelope"),
 
 		// JWT
 		JWTPrivateKeyPath:  getEnv("JWT_PRIVATE_KEY_PATH", "./keys/jwt_private.pem"),
@@ -107,12 +188,24 @@ func Load() *Config {
 		JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 
 		// Security
-		BcryptCost: getEnvAsInt("BCRYPT_COST", 12),
-		AESKeySize: getEnvAsInt("AES_KEY_SIZE", 32),
+		BcryptCost:          getEnvAsInt("BCRYPT_COST", 12),
+		AESKeySize:          getEnvAsInt("AES_KEY_SIZE", 32),
+		RBACCacheTTLSeconds: getEnvAsInt("RBAC_CACHE_TTL_SECONDS", 60),
+
+		// Rate Limiting (requests per minute)
+		RateLimitBackend:       getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitPerIP:         getEnvAsInt("RATE_LIMIT_PER_IP", 100),
+		RateLimitPerUser:       getEnvAsInt("RATE_LIMIT_PER_USER", 30),
+		RateLimitLoginPerIP:    getEnvAsInt("RATE_LIMIT_LOGIN_PER_IP", 5),
+		RateLimitIssuePerUser:  getEnvAsInt("RATE_LIMIT_ISSUE_PER_USER", 10),
+		RateLimitRedeemPerUser: getEnvAsInt("RATE_LIMIT_REDEEM_PER_USER", 10),
 
-		// Rate Limiting
-		RateLimitPerIP:   getEnvAsInt("RATE_LIMIT_PER_IP", 100),
-		RateLimitPerUser: getEnvAsInt("RATE_LIMIT_PER_USER", 30),
+		// Client IP resolution
+		TrustedProxies:  getEnvAsSlice("TRUSTED_PROXIES"),
+		TrustedPlatform: getEnv("TRUSTED_PLATFORM", ""),
+
+		// Idempotency
+		IdempotencyKeyTTLHours: getEnvAsInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
 
 		// Transaction Settings
 		MinADAOutput:          getEnvAsUint64("MIN_ADA_OUTPUT", 1200000),
@@ -121,11 +214,52 @@ func Load() *Config {
 		FeeBufferMultiplier:   getEnvAsFloat64("FEE_BUFFER_MULTIPLIER", 1.2),
 		ConfirmationsRequired: getEnvAsInt("CONFIRMATIONS_REQUIRED", 3),
 		WalletSeedADA:         getEnvAsUint64("WALLET_SEED_ADA", 5000000),
+		RedeemToMerchantsOnly: getEnvAsBool("REDEEM_TO_MERCHANTS_ONLY", false),
+		PendingRewardTTLDays:  getEnvAsInt("PENDING_REWARD_TTL_DAYS", 90),
+
+		// Email and phone verification
+		SMTPAddr:        getEnv("SMTP_ADDR", ""),
+		SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:        getEnv("SMTP_FROM", ""),
+		SMSWebhookURL:   getEnv("SMS_WEBHOOK_URL", ""),
+		SMSWebhookToken: getEnv("SMS_WEBHOOK_TOKEN", ""),
+
+		// Customer-to-customer transfers
+		TransfersEnabled:          getEnvAsBool("TRANSFERS_ENABLED", true),
+		TransferDailyLimitLCN:     getEnvAsUint64("TRANSFER_DAILY_LIMIT_LCN", 500),
+		TransferDailyMaxTransfers: getEnvAsInt("TRANSFER_DAILY_MAX_TRANSFERS", 10),
+
+		// Points expiry
+		LCNExpiryMonths:               getEnvAsInt("LCN_EXPIRY_MONTHS", 0),
+		LCNExpiryWarningDays:          getEnvAsInt("LCN_EXPIRY_WARNING_DAYS", 30),
+		LCNExpiryReturnTo:             getEnv("LCN_EXPIRY_RETURN_TO", "issuer"),
+		LCNExpirySweepIntervalMinutes: getEnvAsInt("LCN_EXPIRY_SWEEP_INTERVAL_MINUTES", 60),
+
+		// Customer tiers
+		CustomerTiers:      getEnv("CUSTOMER_TIERS", "Bronze:0:1,Silver:1000:1.25,Gold:5000:1.5"),
+		TierEvaluationHour: getEnvAsInt("TIER_EVALUATION_HOUR", 2),
+
+		// Referrals
+		ReferralReferrerRewardLCN: getEnvAsUint64("REFERRAL_REFERRER_REWARD_LCN", 50),
+		ReferralRefereeRewardLCN:  getEnvAsUint64("REFERRAL_REFEREE_REWARD_LCN", 25),
+		ReferralMinQualifyingLCN:  getEnvAsUint64("REFERRAL_MIN_QUALIFYING_LCN", 10),
+		ReferralMaxPerReferrer:    getEnvAsInt("REFERRAL_MAX_PER_REFERRER", 100),
+		ReferralMaxSignupsPerIP:   getEnvAsInt("REFERRAL_MAX_SIGNUPS_PER_IP", 3),
+		ReferralReviewAll:         getEnvAsBool("REFERRAL_REVIEW_ALL", false),
+
+		// Coalition clearing
+		CoalitionNettingCheckMinutes: getEnvAsInt("COALITION_NETTING_CHECK_MINUTES", 60),
 
 		// Settlement
 		ExchangeRateLCNETB:            getEnvAsFloat64("EXCHANGE_RATE_LCN_ETB", 1.0),
 		SettlementProcessingTimeHours: getEnvAsInt("SETTLEMENT_PROCESSING_TIME_HOURS", 48),
 
+		// Dual control
+		AllocationApprovalThresholdLCN: getEnvAsUint64("ALLOCATION_APPROVAL_THRESHOLD_LCN", 0),
+		SettlementApprovalThresholdLCN: getEnvAsUint64("SETTLEMENT_APPROVAL_THRESHOLD_LCN", 0),
+		DualControlApprovals:           getEnvAsInt("DUAL_CONTROL_APPROVALS", 1),
+
 		// Monitoring
 		PrometheusPort: getEnv("PROMETHEUS_PORT", "9090"),
 		SentryDSN:      getEnv("SENTRY_DSN", ""),
@@ -180,3 +314,23 @@ func getEnvAsFloat64(key string, fallback float64) float64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return fallback
+}
+
+// getEnvAsSlice reads a comma-separated list, dropping empty entries
+func getEnvAsSlice(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
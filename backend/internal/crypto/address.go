@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// Shelley payment address types (CIP-19)
+type AddressType string
+
+const (
+	AddressBase       AddressType = "base"       // payment + stake credential
+	AddressPointer    AddressType = "pointer"    // payment credential + stake registration pointer
+	AddressEnterprise AddressType = "enterprise" // payment credential only
+)
+
+// Address is a decoded Shelley payment address
+type Address struct {
+	Bech32            string
+	Bytes             []byte // header byte and credentials, as signed in CIP-30 signData headers
+	Type              AddressType
+	NetworkTag        byte   // 0x00 testnet, 0x01 mainnet
+	PaymentCredential []byte // key or script hash (28 bytes)
+	PaymentIsScript   bool
+	StakeCredential   []byte // base addresses only
+	StakeIsScript     bool
+}
+
+// NetworkTag returns the address network tag for a CARDANO_NETWORK value
+func NetworkTag(network string) byte {
+	switch network {
+	case "mainnet":
+		return 0x01
+	default: // testnet, preprod, etc
+		return 0x00
+	}
+}
+
+// ParseAddress decodes a bech32 payment address and checks that it belongs
+// to the given network (CARDANO_NETWORK). Byron and reward (stake) addresses
+// are rejected.
+func ParseAddress(address string, network string) (*Address, error) {
+	parsed, hrp, err := decodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	expectedTag := NetworkTag(network)
+	if parsed.NetworkTag != expectedTag {
+		return nil, fmt.Errorf("address is for %s, this platform runs on %s", networkName(parsed.NetworkTag), networkName(expectedTag))
+	}
+	expectedPrefix := "addr_test"
+	if expectedTag == 0x01 {
+		expectedPrefix = "addr"
+	}
+	if hrp != expectedPrefix {
+		return nil, fmt.Errorf("address prefix %q does not match network (expected %q)", hrp, expectedPrefix)
+	}
+	return parsed, nil
+}
+
+// decodeAddress decodes a bech32 payment address of any network, returning
+// it with its human-readable prefix
+func decodeAddress(address string) (*Address, string, error) {
+	// Base addresses are longer than the 90 characters bech32.Decode allows
+	hrp, data, err := bech32.DecodeNoLimit(address)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid bech32 address: %w", err)
+	}
+	payload, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid address payload: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, "", fmt.Errorf("empty address")
+	}
+
+	header := payload[0]
+	addressType := header >> 4
+	if addressType > 7 {
+		return nil, "", fmt.Errorf("not a payment address (header type %d)", addressType)
+	}
+
+	parsed := &Address{
+		Bech32:          strings.ToLower(address),
+		Bytes:           payload,
+		NetworkTag:      header & 0x0f,
+		PaymentIsScript: addressType%2 == 1,
+	}
+	switch {
+	case addressType <= 3:
+		if len(payload) != 57 {
+			return nil, "", fmt.Errorf("invalid base address length %d", len(payload))
+		}
+		parsed.Type = AddressBase
+		parsed.StakeCredential = payload[29:57]
+		parsed.StakeIsScript = addressType >= 2
+	case addressType <= 5:
+		if len(payload) < 32 {
+			return nil, "", fmt.Errorf("invalid pointer address length %d", len(payload))
+		}
+		parsed.Type = AddressPointer
+	default:
+		if len(payload) != 29 {
+			return nil, "", fmt.Errorf("invalid enterprise address length %d", len(payload))
+		}
+		parsed.Type = AddressEnterprise
+	}
+	parsed.PaymentCredential = payload[1:29]
+	return parsed, hrp, nil
+}
+
+// PaymentKeyHash returns the payment key hash, failing for script-controlled addresses
+func (a *Address) PaymentKeyHash() ([]byte, error) {
+	if a.PaymentIsScript {
+		return nil, fmt.Errorf("address is controlled by a script, not a key")
+	}
+	return a.PaymentCredential, nil
+}
+
+func networkName(networkTag byte) string {
+	if networkTag == 0x01 {
+		return "mainnet"
+	}
+	return "testnet"
+}
@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress_Enterprise(t *testing.T) {
+	wallet, _ := GenerateCardanoWallet(0x00)
+	keyHash, _ := KeyHash(wallet.PublicKey)
+
+	address, err := ParseAddress(wallet.Address, "testnet")
+	require.NoError(t, err)
+	assert.Equal(t, AddressEnterprise, address.Type)
+	assert.Equal(t, byte(0x00), address.NetworkTag)
+	paymentKeyHash, err := address.PaymentKeyHash()
+	require.NoError(t, err)
+	assert.Equal(t, keyHash, paymentKeyHash)
+	assert.Nil(t, address.StakeCredential)
+}
+
+func TestParseAddress_Base(t *testing.T) {
+	// Base addresses exceed the 90-character bech32 limit
+	address, err := ParseAddress(hdTestAddressTestnet, "preprod")
+	require.NoError(t, err)
+	assert.Equal(t, AddressBase, address.Type)
+
+	paymentPublic, _ := hex.DecodeString(hdTestPaymentPublic)
+	stakePublic, _ := hex.DecodeString(hdTestStakePublic)
+	paymentHash, _ := KeyHash(paymentPublic)
+	stakeHash, _ := KeyHash(stakePublic)
+	paymentKeyHash, err := address.PaymentKeyHash()
+	require.NoError(t, err)
+	assert.Equal(t, paymentHash, paymentKeyHash)
+	assert.Equal(t, stakeHash, address.StakeCredential)
+
+	mainnet, err := ParseAddress(hdTestAddressMainnet, "mainnet")
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x01), mainnet.NetworkTag)
+
+	// Case-insensitive; the canonical form is lower case
+	upper, err := ParseAddress(strings.ToUpper(hdTestAddressTestnet), "testnet")
+	require.NoError(t, err)
+	assert.Equal(t, hdTestAddressTestnet, upper.Bech32)
+}
+
+func TestParseAddress_Script(t *testing.T) {
+	wallet, _ := GenerateCardanoWallet(0x00)
+	keyHash, _ := KeyHash(wallet.PublicKey)
+	script, _ := NewMultiSigScript(1, []string{hex.EncodeToString(keyHash)})
+	scriptAddress, _ := script.Address(0x00)
+
+	address, err := ParseAddress(scriptAddress, "testnet")
+	require.NoError(t, err)
+	assert.True(t, address.PaymentIsScript)
+	assert.Equal(t, script.Hash(), address.PaymentCredential)
+	_, err = address.PaymentKeyHash()
+	assert.Error(t, err)
+}
+
+func TestParseAddress_Invalid(t *testing.T) {
+	wallet, _ := GenerateCardanoWallet(0x00)
+	mainnetWallet, _ := GenerateCardanoWallet(0x01)
+
+	tests := []struct {
+		name    string
+		address string
+		network string
+	}{
+		{"typo", wallet.Address[:len(wallet.Address)-1] + "x", "testnet"},
+		{"mainnet address on testnet", mainnetWallet.Address, "testnet"},
+		{"testnet address on mainnet", wallet.Address, "mainnet"},
+		{"not bech32", "DdzFFzCqrhsrandom", "testnet"},
+		{"reward address", "stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn", "testnet"},
+		{"empty", "", "testnet"},
+	}
+	for _, tt := range tests {
+		_, err := ParseAddress(tt.address, tt.network)
+		assert.Error(t, err, tt.name)
+	}
+}
@@ -42,31 +42,66 @@ func GenerateCardanoWallet(networkTag byte) (*CardanoWallet, error) {
 func deriveCardanoAddress(publicKey ed25519.PublicKey, networkTag byte) (string, error) {
 	// 1. Hash the public key with Blake2b-224
 	// Cardano uses Blake2b-224 (28 bytes) for key hashing
-	hash, err := blake2b.New(28, nil)
+	pkHash, err := KeyHash(publicKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create blake2b-224 hasher: %w", err)
+		return "", err
 	}
-	hash.Write(publicKey)
-	pkHash := hash.Sum(nil)
 
 	// 2. Construct address header
 	// Header byte: 0b0110_0000 (0x60) for testnet payment address (enterprise)
 	// Header byte: 0b0110_0001 (0x61) for mainnet payment address (enterprise)
 	// The networkTag passed in is 0x00 (testnet) or 0x01 (mainnet)
+	return encodeEnterpriseAddress(0x60, pkHash, networkTag)
+}
+
+// KeyHash returns the Blake2b-224 hash of a public key (a Cardano key hash)
+func KeyHash(publicKey ed25519.PublicKey) ([]byte, error) {
+	hash, err := blake2b.New(28, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blake2b-224 hasher: %w", err)
+	}
+	hash.Write(publicKey)
+	return hash.Sum(nil), nil
+}
 
+// encodeEnterpriseAddress encodes a 28-byte payment credential as an enterprise
+// address (no stake credential). baseHeader is 0x60 for key credentials and
+// 0x70 for script credentials; the network tag goes in the low nibble.
+func encodeEnterpriseAddress(baseHeader byte, credential []byte, networkTag byte) (string, error) {
 	var header byte
 	if networkTag == 0x01 { // Mainnet
-		header = 0x61 // Payment key, no stake key
+		header = baseHeader | 0x01
 	} else { // Testnet
-		header = 0x60 // Payment key, no stake key
+		header = baseHeader
 	}
 
-	// 3. Construct address payload (Header + KeyHash)
-	// Enterprise address is 29 bytes: 1 byte header + 28 bytes key hash
+	// 3. Construct address payload (Header + credential hash)
+	// Enterprise address is 29 bytes: 1 byte header + 28 bytes hash
 	payload := make([]byte, 1+28)
 	payload[0] = header
-	copy(payload[1:], pkHash)
+	copy(payload[1:], credential)
+
+	return encodeAddress(payload, networkTag)
+}
+
+// encodeBaseAddress encodes a base address: payment and stake key hashes
+// (header 0b0000, both credentials keys)
+func encodeBaseAddress(paymentKeyHash, stakeKeyHash []byte, networkTag byte) (string, error) {
+	if len(paymentKeyHash) != 28 || len(stakeKeyHash) != 28 {
+		return "", fmt.Errorf("key hashes must be 28 bytes")
+	}
+
+	// Base address is 57 bytes: 1 byte header + payment hash + stake hash
+	payload := make([]byte, 0, 1+28+28)
+	payload = append(payload, 0x00|networkTag&0x0f)
+	payload = append(payload, paymentKeyHash...)
+	payload = append(payload, stakeKeyHash...)
+
+	return encodeAddress(payload, networkTag)
+}
 
+// encodeAddress bech32-encodes raw address bytes with the network's prefix
+func encodeAddress(payload []byte, networkTag byte) (string, error) {
 	// 4. Encode as Bech32
 	prefix := "addr_test"
 	if networkTag == 0x01 {
@@ -0,0 +1,197 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+)
+
+// COSE labels used by CIP-8 message signing (RFC 8152)
+const (
+	coseAlgLabel   = 1  // protected header: algorithm
+	coseAlgEdDSA   = -8 // EdDSA
+	coseKeyXLabel  = -2 // COSE_Key: public key of an OKP key
+	coseSign1Tag   = 18
+	coseAddressHdr = "address" // CIP-8 protected header: the signing address
+)
+
+// VerifyDataSignature checks a CIP-30 signData result: coseSign1 must be a
+// COSE_Sign1 over payload, made for address by its payment key, which coseKey
+// holds. Hashed payloads are not accepted.
+func VerifyDataSignature(address *Address, payload, coseSign1, coseKey []byte) error {
+	keyHash, err := address.PaymentKeyHash()
+	if err != nil {
+		return err
+	}
+	publicKey, err := decodeCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+	if hash, err := KeyHash(publicKey); err != nil || !bytes.Equal(hash, keyHash) {
+		return fmt.Errorf("signing key is not the address's payment key")
+	}
+
+	r := &cborReader{data: coseSign1}
+	major, n, err := r.head()
+	if err != nil {
+		return err
+	}
+	if major == 6 && n == coseSign1Tag {
+		if major, n, err = r.head(); err != nil {
+			return err
+		}
+	}
+	if major != 4 || n != 4 {
+		return fmt.Errorf("signature is not a COSE_Sign1 structure")
+	}
+	protected, err := r.bytes()
+	if err != nil {
+		return fmt.Errorf("invalid protected headers: %w", err)
+	}
+	if err := r.skip(); err != nil { // unprotected headers
+		return err
+	}
+	signedPayload, err := r.bytes()
+	if err != nil {
+		return fmt.Errorf("signature carries no payload: %w", err)
+	}
+	signature, err := r.bytes()
+	if err != nil {
+		return err
+	}
+	if r.pos != len(coseSign1) {
+		return fmt.Errorf("trailing data after COSE_Sign1")
+	}
+
+	if !bytes.Equal(signedPayload, payload) {
+		return fmt.Errorf("signed payload does not match")
+	}
+	if err := checkProtectedHeaders(protected, address); err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, sigStructure(protected, payload), signature) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// checkProtectedHeaders requires the EdDSA algorithm and the signing address
+func checkProtectedHeaders(protected []byte, address *Address) error {
+	r := &cborReader{data: protected}
+	major, entries, err := r.head()
+	if err != nil {
+		return err
+	}
+	if major != 5 {
+		return fmt.Errorf("protected headers are not a CBOR map")
+	}
+
+	var algOK, addressOK bool
+	for i := uint64(0); i < entries; i++ {
+		major, key, err := r.head()
+		if err != nil {
+			return err
+		}
+		switch {
+		case major == 0 && key == coseAlgLabel:
+			valueMajor, value, err := r.head()
+			if err != nil {
+				return err
+			}
+			if err := r.skipValue(valueMajor, value); err != nil {
+				return err
+			}
+			algOK = valueMajor == 1 && -1-int64(value) == coseAlgEdDSA
+		case major == 3:
+			label, err := r.text(key)
+			if err != nil {
+				return err
+			}
+			if label != coseAddressHdr {
+				if err := r.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			signer, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			addressOK = bytes.Equal(signer, address.Bytes)
+		default:
+			if err := r.skipValue(major, key); err != nil {
+				return err
+			}
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	if !algOK {
+		return fmt.Errorf("signature algorithm is not EdDSA")
+	}
+	if !addressOK {
+		return fmt.Errorf("signature was not made for this address")
+	}
+	return nil
+}
+
+// decodeCOSEKey returns the Ed25519 public key of a COSE_Key
+func decodeCOSEKey(data []byte) (ed25519.PublicKey, error) {
+	r := &cborReader{data: data}
+	major, entries, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, fmt.Errorf("key is not a COSE_Key map")
+	}
+
+	var publicKey []byte
+	for i := uint64(0); i < entries; i++ {
+		major, key, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		if major == 1 && -1-int64(key) == coseKeyXLabel {
+			if publicKey, err = r.bytes(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := r.skipValue(major, key); err != nil {
+			return nil, err
+		}
+		if err := r.skip(); err != nil {
+			return nil, err
+		}
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("COSE_Key has no Ed25519 public key")
+	}
+	return publicKey, nil
+}
+
+// sigStructure is what a COSE_Sign1 signs: ["Signature1", protected, external data (empty), payload]
+func sigStructure(protected, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(cborHead(4, 4))
+	buf.Write(cborHead(3, uint64(len("Signature1"))))
+	buf.WriteString("Signature1")
+	buf.Write(cborHead(2, uint64(len(protected))))
+	buf.Write(protected)
+	buf.Write(cborHead(2, 0)) // no external data
+	buf.Write(cborHead(2, uint64(len(payload))))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// text reads the content of a text string whose head was already read
+func (r *cborReader) text(n uint64) (string, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return "", fmt.Errorf("unexpected end of CBOR data")
+	}
+	s := string(r.data[r.pos : r.pos+int(n)])
+	r.pos += int(n)
+	return s, nil
+}
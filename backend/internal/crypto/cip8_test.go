@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signData builds a CIP-30 signData result the way wallets do: a tagged
+// COSE_Sign1 with the algorithm and address in the protected headers, and a
+// COSE_Key holding the public key
+func signData(privateKey ed25519.PrivateKey, addressBytes, payload []byte) (coseSign1, coseKey []byte) {
+	var protected bytes.Buffer
+	protected.Write(cborHead(5, 2))
+	protected.Write(cborHead(0, 1)) // alg
+	protected.Write(cborHead(1, 7)) // -8 (EdDSA)
+	protected.Write(cborHead(3, 7))
+	protected.WriteString("address")
+	protected.Write(cborHead(2, uint64(len(addressBytes))))
+	protected.Write(addressBytes)
+
+	signature := ed25519.Sign(privateKey, sigStructure(protected.Bytes(), payload))
+
+	var sign1 bytes.Buffer
+	sign1.Write(cborHead(6, 18))
+	sign1.Write(cborHead(4, 4))
+	sign1.Write(cborHead(2, uint64(protected.Len())))
+	sign1.Write(protected.Bytes())
+	sign1.Write(cborHead(5, 1)) // unprotected: {"hashed": false}
+	sign1.Write(cborHead(3, 6))
+	sign1.WriteString("hashed")
+	sign1.WriteByte(0xf4)
+	sign1.Write(cborHead(2, uint64(len(payload))))
+	sign1.Write(payload)
+	sign1.Write(cborHead(2, uint64(len(signature))))
+	sign1.Write(signature)
+
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	var key bytes.Buffer
+	key.Write(cborHead(5, 4))
+	key.Write(cborHead(0, 1)) // kty: OKP
+	key.Write(cborHead(0, 1))
+	key.Write(cborHead(0, 3)) // alg: EdDSA
+	key.Write(cborHead(1, 7))
+	key.Write(cborHead(1, 0)) // crv: Ed25519
+	key.Write(cborHead(0, 6))
+	key.Write(cborHead(1, 1)) // x
+	key.Write(cborHead(2, uint64(len(publicKey))))
+	key.Write(publicKey)
+
+	return sign1.Bytes(), key.Bytes()
+}
+
+func TestVerifyDataSignature(t *testing.T) {
+	wallet, err := GenerateCardanoWallet(0x00)
+	require.NoError(t, err)
+	address, err := ParseAddress(wallet.Address, "preprod")
+	require.NoError(t, err)
+	payload := []byte("Link this wallet to LoyalCoin. Nonce: 1234")
+
+	coseSign1, coseKey := signData(wallet.PrivateKey, address.Bytes, payload)
+	assert.NoError(t, VerifyDataSignature(address, payload, coseSign1, coseKey))
+
+	// Another payload (e.g. an older challenge) is refused
+	assert.Error(t, VerifyDataSignature(address, []byte("other nonce"), coseSign1, coseKey))
+
+	// Signed for another address
+	other, _ := GenerateCardanoWallet(0x00)
+	otherAddress, err := ParseAddress(other.Address, "preprod")
+	require.NoError(t, err)
+	coseSign1, coseKey = signData(wallet.PrivateKey, otherAddress.Bytes, payload)
+	assert.Error(t, VerifyDataSignature(address, payload, coseSign1, coseKey))
+
+	// Signed by a key that does not control the address
+	coseSign1, coseKey = signData(other.PrivateKey, address.Bytes, payload)
+	assert.Error(t, VerifyDataSignature(address, payload, coseSign1, coseKey))
+
+	// Tampered signature
+	coseSign1, coseKey = signData(wallet.PrivateKey, address.Bytes, payload)
+	coseSign1[len(coseSign1)-1] ^= 0xff
+	assert.Error(t, VerifyDataSignature(address, payload, coseSign1, coseKey))
+
+	// Malformed input
+	assert.Error(t, VerifyDataSignature(address, payload, []byte{0x80}, coseKey))
+	assert.Error(t, VerifyDataSignature(address, payload, coseSign1, []byte{0xa0}))
+}
@@ -10,13 +10,25 @@ import (
 	"io"
 )
 
+// Key record formats (EncryptedBlob.Version)
+const (
+	BlobVersionUnbound = 1 // no associated data
+	BlobVersionBound   = 2 // GCM associated data binds the wallet address and owner ID
+)
+
+// Stored key record of a wallet. Envelope keys carry the ciphertext and wrapped
+// DEK; Vault transit keys only the key name and public key.
 type EncryptedBlob struct {
 	Version    int    `json:"version"`
 	Algorithm  string `json:"algorithm"`
-	Nonce      string `json:"nonce"`
-	Ciphertext string `json:"ciphertext"`
-	Tag        string `json:"tag"`
-	DEKWrapped string `json:"dek_wrapped"`
+	Nonce      string `json:"nonce,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	DEKWrapped string `json:"dek_wrapped,omitempty"`
+	Signer     string `json:"signer,omitempty"`   // empty for envelope keys created before signers existed
+	KeyType    string `json:"key_type,omitempty"` // empty: ed25519 key; KeyTypeHD: BIP-39 entropy
+	KeyName    string `json:"key_name,omitempty"`
+	PublicKey  string `json:"public_key,omitempty"`
 }
 
 func GenerateDEK() ([]byte, error) {
@@ -29,6 +41,19 @@ func GenerateDEK() ([]byte, error) {
 
 // EncryptWithDEK encrypts data using AES-256-GCM with the provided DEK
 func EncryptWithDEK(plaintext []byte, dek []byte) (*EncryptedBlob, error) {
+	return sealBlob(plaintext, dek, nil, BlobVersionUnbound)
+}
+
+// EncryptWithDEKBound encrypts data with the DEK and authenticates aad with it
+// (a version-2 blob). Decryption fails unless the same aad is supplied.
+func EncryptWithDEKBound(plaintext, dek, aad []byte) (*EncryptedBlob, error) {
+	if len(aad) == 0 {
+		return nil, fmt.Errorf("associated data is required")
+	}
+	return sealBlob(plaintext, dek, aad, BlobVersionBound)
+}
+
+func sealBlob(plaintext, dek, aad []byte, version int) (*EncryptedBlob, error) {
 	// Create AES cipher
 	block, err := aes.NewCipher(dek)
 	if err != nil {
@@ -48,7 +73,7 @@ func EncryptWithDEK(plaintext []byte, dek []byte) (*EncryptedBlob, error) {
 	}
 
 	// Encrypt
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, aad)
 
 	// GCM appends the tag to the ciphertext, split them
 	tagSize := 16 // GCM tag is always 16 bytes
@@ -60,7 +85,7 @@ func EncryptWithDEK(plaintext []byte, dek []byte) (*EncryptedBlob, error) {
 	tag := ciphertext[len(ciphertext)-tagSize:]
 
 	blob := &EncryptedBlob{
-		Version:    1,
+		Version:    version,
 		Algorithm:  "AES-256-GCM",
 		Nonce:      hex.EncodeToString(nonce),
 		Ciphertext: hex.EncodeToString(actualCiphertext),
@@ -72,6 +97,25 @@ func EncryptWithDEK(plaintext []byte, dek []byte) (*EncryptedBlob, error) {
 
 // DecryptWithDEK decrypts data using AES-256-GCM with the provided DEK
 func DecryptWithDEK(blob *EncryptedBlob, dek []byte) ([]byte, error) {
+	if blob.Version >= BlobVersionBound {
+		return nil, fmt.Errorf("blob version %d requires associated data", blob.Version)
+	}
+	return openBlob(blob, dek, nil)
+}
+
+// DecryptWithDEKBound decrypts a blob of either version: aad is checked for
+// version-2 blobs and ignored for version-1 blobs, which have none
+func DecryptWithDEKBound(blob *EncryptedBlob, dek, aad []byte) ([]byte, error) {
+	if blob.Version < BlobVersionBound {
+		return openBlob(blob, dek, nil)
+	}
+	if len(aad) == 0 {
+		return nil, fmt.Errorf("blob version %d requires associated data", blob.Version)
+	}
+	return openBlob(blob, dek, aad)
+}
+
+func openBlob(blob *EncryptedBlob, dek, aad []byte) ([]byte, error) {
 	// Decode hex strings
 	nonce, err := hex.DecodeString(blob.Nonce)
 	if err != nil {
@@ -104,7 +148,7 @@ func DecryptWithDEK(blob *EncryptedBlob, dek []byte) ([]byte, error) {
 	}
 
 	// Decrypt
-	plaintext, err := gcm.Open(nil, nonce, combined, nil)
+	plaintext, err := gcm.Open(nil, nonce, combined, aad)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt: %w", err)
 	}
@@ -124,3 +124,48 @@ func TestDecryptWrongDEK(t *testing.T) {
 		t.Error("Decrypt should fail with wrong DEK")
 	}
 }
+
+func TestEncryptDecryptBound(t *testing.T) {
+	plaintext := []byte("my-secret-private-key-12345")
+	aad := []byte("wallet-a")
+	dek, err := GenerateDEK()
+	if err != nil {
+		t.Fatalf("Failed to generate DEK: %v", err)
+	}
+
+	blob, err := EncryptWithDEKBound(plaintext, dek, aad)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if blob.Version != BlobVersionBound {
+		t.Errorf("Expected version %d, got %d", BlobVersionBound, blob.Version)
+	}
+
+	decrypted, err := DecryptWithDEKBound(blob, dek, aad)
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Errorf("Decrypted text doesn't match original")
+	}
+
+	if _, err := DecryptWithDEKBound(blob, dek, []byte("wallet-b")); err == nil {
+		t.Error("Decryption with different associated data should fail")
+	}
+	if _, err := DecryptWithDEK(blob, dek); err == nil {
+		t.Error("Version 2 blob should not decrypt without associated data")
+	}
+
+	// Version 1 blobs decrypt whatever associated data is supplied
+	v1, err := EncryptWithDEK(plaintext, dek)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	decrypted, err = DecryptWithDEKBound(v1, dek, aad)
+	if err != nil {
+		t.Fatalf("Failed to decrypt version 1 blob: %v", err)
+	}
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Errorf("Decrypted text doesn't match original")
+	}
+}
@@ -0,0 +1,217 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// CIP-1852 derivation path constants (m/1852'/1815'/account'/role/index)
+const (
+	hardenedOffset      uint32 = 0x80000000
+	cip1852Purpose             = 1852 | hardenedOffset
+	cardanoCoinType            = 1815 | hardenedOffset
+	RoleExternal        uint32 = 0   // payment keys
+	RoleStaking         uint32 = 2   // stake keys
+	mnemonicEntropyBits        = 256 // 24 words
+)
+
+// ExtendedKey is a BIP32-Ed25519 extended private key: the 64-byte expanded
+// scalar (kL || kR) and its chain code
+type ExtendedKey struct {
+	key       [64]byte
+	chainCode [32]byte
+}
+
+// NewMnemonic generates a 24-word BIP-39 recovery phrase and its entropy
+func NewMnemonic() (string, []byte, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode mnemonic: %w", err)
+	}
+	return mnemonic, entropy, nil
+}
+
+// MnemonicFromEntropy returns the BIP-39 recovery phrase for stored entropy
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
+
+// RootKeyFromEntropy derives the Icarus master key from BIP-39 entropy (no
+// passphrase), as used by Daedalus, Yoroi, Eternl and Lace
+func RootKeyFromEntropy(entropy []byte) *ExtendedKey {
+	xprv := pbkdf2.Key(nil, entropy, 4096, 96, sha512.New)
+	defer ZeroBytes(xprv)
+
+	var root ExtendedKey
+	copy(root.key[:], xprv[:64])
+	copy(root.chainCode[:], xprv[64:])
+	root.key[0] &= 0xf8
+	root.key[31] &= 0x1f
+	root.key[31] |= 0x40
+	return &root
+}
+
+// Derive returns the child key at index (hardened when index >= 2^31)
+func (k *ExtendedKey) Derive(index uint32) *ExtendedKey {
+	var indexBytes [4]byte
+	binary.LittleEndian.PutUint32(indexBytes[:], index)
+
+	zMac := hmac.New(sha512.New, k.chainCode[:])
+	ccMac := hmac.New(sha512.New, k.chainCode[:])
+	if index >= hardenedOffset {
+		zMac.Write([]byte{0x00})
+		zMac.Write(k.key[:])
+		ccMac.Write([]byte{0x01})
+		ccMac.Write(k.key[:])
+	} else {
+		publicKey := k.PublicKey()
+		zMac.Write([]byte{0x02})
+		zMac.Write(publicKey)
+		ccMac.Write([]byte{0x03})
+		ccMac.Write(publicKey)
+	}
+	zMac.Write(indexBytes[:])
+	ccMac.Write(indexBytes[:])
+	z := zMac.Sum(nil)
+	defer ZeroBytes(z)
+
+	var child ExtendedKey
+	// kL = 8 * ZL[0:28] + kL (little-endian)
+	var carry uint16
+	for i := 0; i < 32; i++ {
+		var zl uint16
+		if i < 28 {
+			zl = uint16(z[i] << 3)
+		}
+		if i > 0 && i <= 28 {
+			zl |= uint16(z[i-1]) >> 5
+		}
+		sum := uint16(k.key[i]) + zl + carry
+		child.key[i] = byte(sum)
+		carry = sum >> 8
+	}
+	// kR = ZR + kR (mod 2^256)
+	carry = 0
+	for i := 0; i < 32; i++ {
+		sum := uint16(k.key[32+i]) + uint16(z[32+i]) + carry
+		child.key[32+i] = byte(sum)
+		carry = sum >> 8
+	}
+	copy(child.chainCode[:], ccMac.Sum(nil)[32:])
+	return &child
+}
+
+// DerivePath derives a chain of child indexes
+func (k *ExtendedKey) DerivePath(path ...uint32) *ExtendedKey {
+	key := k
+	for _, index := range path {
+		key = key.Derive(index)
+	}
+	return key
+}
+
+// AccountKey returns the CIP-1852 account key m/1852'/1815'/account'
+func (k *ExtendedKey) AccountKey(account uint32) *ExtendedKey {
+	return k.DerivePath(cip1852Purpose, cardanoCoinType, account|hardenedOffset)
+}
+
+// PublicKey returns the ed25519 public key kL·B
+func (k *ExtendedKey) PublicKey() ed25519.PublicKey {
+	scalar := k.scalar()
+	return ed25519.PublicKey(new(edwards25519.Point).ScalarBaseMult(scalar).Bytes())
+}
+
+// Sign produces a standard ed25519 signature with the extended key (verifiable
+// with ed25519.Verify against PublicKey)
+func (k *ExtendedKey) Sign(message []byte) []byte {
+	publicKey := k.PublicKey()
+
+	h := sha512.New()
+	h.Write(k.key[32:])
+	h.Write(message)
+	r, _ := new(edwards25519.Scalar).SetUniformBytes(h.Sum(nil))
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+
+	h.Reset()
+	h.Write(R)
+	h.Write(publicKey)
+	h.Write(message)
+	challenge, _ := new(edwards25519.Scalar).SetUniformBytes(h.Sum(nil))
+
+	s := new(edwards25519.Scalar).MultiplyAdd(challenge, k.scalar(), r)
+	return append(R, s.Bytes()...)
+}
+
+// Bytes returns the 64-byte extended private key (kL || kR), as used by
+// Cardano tooling for ed25519e_sk keys
+func (k *ExtendedKey) Bytes() []byte {
+	return append([]byte(nil), k.key[:]...)
+}
+
+// Zero clears the key material
+func (k *ExtendedKey) Zero() {
+	ZeroBytes(k.key[:])
+	ZeroBytes(k.chainCode[:])
+}
+
+// scalar reduces kL modulo the group order (kL·B is unchanged by the reduction)
+func (k *ExtendedKey) scalar() *edwards25519.Scalar {
+	var wide [64]byte
+	copy(wide[:], k.key[:32])
+	scalar, _ := new(edwards25519.Scalar).SetUniformBytes(wide[:])
+	ZeroBytes(wide[:])
+	return scalar
+}
+
+// HDWallet holds the CIP-1852 payment and stake keys of account 0, address 0
+type HDWallet struct {
+	PaymentKey *ExtendedKey
+	StakeKey   *ExtendedKey
+}
+
+// HDWalletFromEntropy derives the first payment and stake keys of account 0
+func HDWalletFromEntropy(entropy []byte) *HDWallet {
+	root := RootKeyFromEntropy(entropy)
+	defer root.Zero()
+	account := root.AccountKey(0)
+	defer account.Zero()
+
+	return &HDWallet{
+		PaymentKey: account.DerivePath(RoleExternal, 0),
+		StakeKey:   account.DerivePath(RoleStaking, 0),
+	}
+}
+
+// BaseAddress returns the wallet's base address (payment + stake key credentials)
+func (w *HDWallet) BaseAddress(networkTag byte) (string, error) {
+	paymentHash, err := KeyHash(w.PaymentKey.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	stakeHash, err := KeyHash(w.StakeKey.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	return encodeBaseAddress(paymentHash, stakeHash, networkTag)
+}
+
+// Zero clears both keys
+func (w *HDWallet) Zero() {
+	w.PaymentKey.Zero()
+	w.StakeKey.Zero()
+}
@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Vector produced with Lucid (CML Bip32PrivateKey.from_bip39_entropy, CIP-1852 account 0)
+const (
+	hdTestMnemonic       = "test walk nut penalty hip pave soap entry language right filter choice"
+	hdTestEntropy        = "df9ed25ed146bf43336a5d7cf7395994"
+	hdTestPaymentKey     = "b813a62becba674d8e29ce907ee3533f622d41e155768d58793cbad373e1a45e47f9d20ab7f78b023a2cf363c2217400a8c658dfd1c8057c4f62b6f6746d1c41"
+	hdTestPaymentPublic  = "73fea80d424276ad0978d4fe5310e8bc2d485f5f6bb3bf87612989f112ad5a7d"
+	hdTestStakePublic    = "2c041c9c6a676ac54d25e2fdce44c56581e316ae43adc4c7bf17f23214d8d892"
+	hdTestZeroSignature  = "2809076e4399c59129ceaabf1b110a0009a9b8753d3c72259d52479ca611ed151f7510ba4ac4b6e4d82f4c6c031e1e3c18afb92abe09b51752106f3551ef9003"
+	hdTestAddressTestnet = "addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwq2ytjqp"
+	hdTestAddressMainnet = "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwqfjkjv7"
+)
+
+func TestHDWalletFromEntropy(t *testing.T) {
+	entropy, _ := hex.DecodeString(hdTestEntropy)
+
+	mnemonic, err := MnemonicFromEntropy(entropy)
+	require.NoError(t, err)
+	assert.Equal(t, hdTestMnemonic, mnemonic)
+
+	wallet := HDWalletFromEntropy(entropy)
+	assert.Equal(t, hdTestPaymentKey, hex.EncodeToString(wallet.PaymentKey.Bytes()))
+	assert.Equal(t, hdTestPaymentPublic, hex.EncodeToString(wallet.PaymentKey.PublicKey()))
+	assert.Equal(t, hdTestStakePublic, hex.EncodeToString(wallet.StakeKey.PublicKey()))
+
+	testnet, err := wallet.BaseAddress(0x00)
+	require.NoError(t, err)
+	assert.Equal(t, hdTestAddressTestnet, testnet)
+	mainnet, err := wallet.BaseAddress(0x01)
+	require.NoError(t, err)
+	assert.Equal(t, hdTestAddressMainnet, mainnet)
+}
+
+func TestExtendedKey_Sign(t *testing.T) {
+	entropy, _ := hex.DecodeString(hdTestEntropy)
+	paymentKey := HDWalletFromEntropy(entropy).PaymentKey
+
+	message := make([]byte, 32)
+	signature := paymentKey.Sign(message)
+	assert.Equal(t, hdTestZeroSignature, hex.EncodeToString(signature))
+	assert.True(t, ed25519.Verify(paymentKey.PublicKey(), message, signature))
+	assert.False(t, ed25519.Verify(paymentKey.PublicKey(), []byte("other"), signature))
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic, entropy, err := NewMnemonic()
+	require.NoError(t, err)
+	assert.Len(t, entropy, 32)
+
+	restored, err := MnemonicFromEntropy(entropy)
+	require.NoError(t, err)
+	assert.Equal(t, mnemonic, restored)
+}
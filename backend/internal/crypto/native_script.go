@@ -0,0 +1,312 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// MultiSigScript is an m-of-n native script (ScriptNOfK over ScriptPubkey
+// leaves). Funds at its address can only be spent by a transaction carrying
+// vkey witnesses from at least Required of the listed keys.
+type MultiSigScript struct {
+	Required  int
+	KeyHashes [][]byte
+}
+
+// NewMultiSigScript builds an m-of-n script from hex-encoded key hashes.
+// Key order is part of the script, so it changes the hash and address.
+func NewMultiSigScript(required int, keyHashesHex []string) (*MultiSigScript, error) {
+	if len(keyHashesHex) == 0 {
+		return nil, fmt.Errorf("multi-sig script needs at least one key")
+	}
+	if required < 1 || required > len(keyHashesHex) {
+		return nil, fmt.Errorf("required signatures must be between 1 and %d, got %d", len(keyHashesHex), required)
+	}
+
+	seen := make(map[string]bool)
+	keyHashes := make([][]byte, 0, len(keyHashesHex))
+	for _, h := range keyHashesHex {
+		keyHash, err := hex.DecodeString(h)
+		if err != nil || len(keyHash) != 28 {
+			return nil, fmt.Errorf("invalid key hash %q: expected 28 bytes of hex", h)
+		}
+		if seen[h] {
+			return nil, fmt.Errorf("duplicate key hash %s", h)
+		}
+		seen[h] = true
+		keyHashes = append(keyHashes, keyHash)
+	}
+
+	return &MultiSigScript{Required: required, KeyHashes: keyHashes}, nil
+}
+
+// CBOR encodes the script as [3, required, [[0, keyhash], ...]]
+func (s *MultiSigScript) CBOR() []byte {
+	var buf bytes.Buffer
+	buf.Write(cborHead(4, 3)) // array(3)
+	buf.Write(cborHead(0, 3)) // ScriptNOfK tag
+	buf.Write(cborHead(0, uint64(s.Required)))
+	buf.Write(cborHead(4, uint64(len(s.KeyHashes))))
+	for _, keyHash := range s.KeyHashes {
+		buf.Write(cborHead(4, 2)) // [0, keyhash]
+		buf.Write(cborHead(0, 0)) // ScriptPubkey tag
+		buf.Write(cborHead(2, uint64(len(keyHash))))
+		buf.Write(keyHash)
+	}
+	return buf.Bytes()
+}
+
+// Hash returns the script hash: Blake2b-224 over the native script tag (0x00) and its CBOR
+func (s *MultiSigScript) Hash() []byte {
+	hash, _ := blake2b.New(28, nil)
+	hash.Write([]byte{0x00})
+	hash.Write(s.CBOR())
+	return hash.Sum(nil)
+}
+
+// Address returns the script's enterprise address for the network (0x00 testnet, 0x01 mainnet)
+func (s *MultiSigScript) Address(networkTag byte) (string, error) {
+	return encodeEnterpriseAddress(0x70, s.Hash(), networkTag)
+}
+
+// HasKey reports whether the key hash is one of the script's signers
+func (s *MultiSigScript) HasKey(keyHash []byte) bool {
+	for _, h := range s.KeyHashes {
+		if bytes.Equal(h, keyHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyHashesHex returns the signer key hashes in script order
+func (s *MultiSigScript) KeyHashesHex() []string {
+	hashes := make([]string, len(s.KeyHashes))
+	for i, h := range s.KeyHashes {
+		hashes[i] = hex.EncodeToString(h)
+	}
+	return hashes
+}
+
+// VKeyWitness is one key's signature over a transaction body hash
+type VKeyWitness struct {
+	PublicKey ed25519.PublicKey
+	Signature []byte
+}
+
+// EncodeVKeyWitnessSet encodes vkey witnesses as a transaction witness set: {0: [[vkey, sig], ...]}
+func EncodeVKeyWitnessSet(witnesses []VKeyWitness) []byte {
+	var buf bytes.Buffer
+	buf.Write(cborHead(5, 1)) // map(1)
+	buf.Write(cborHead(0, 0)) // key 0: vkey witnesses
+	buf.Write(cborHead(4, uint64(len(witnesses))))
+	for _, w := range witnesses {
+		buf.Write(cborHead(4, 2))
+		buf.Write(cborHead(2, uint64(len(w.PublicKey))))
+		buf.Write(w.PublicKey)
+		buf.Write(cborHead(2, uint64(len(w.Signature))))
+		buf.Write(w.Signature)
+	}
+	return buf.Bytes()
+}
+
+// DecodeVKeyWitnessSet reads the vkey witnesses (key 0) of a transaction
+// witness set, as returned by a CIP-30 wallet's signTx. Other witness kinds
+// are skipped.
+func DecodeVKeyWitnessSet(data []byte) ([]VKeyWitness, error) {
+	r := &cborReader{data: data}
+	major, entries, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, fmt.Errorf("witness set is not a CBOR map")
+	}
+
+	var witnesses []VKeyWitness
+	for i := uint64(0); i < entries; i++ {
+		major, key, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != 0 || key != 0 {
+			if err := r.skipValue(major, key); err != nil {
+				return nil, err
+			}
+			if err := r.skip(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		count, err := r.array()
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < count; j++ {
+			if n, err := r.array(); err != nil || n != 2 {
+				return nil, fmt.Errorf("invalid vkey witness")
+			}
+			publicKey, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			signature, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			if len(publicKey) != ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+				return nil, fmt.Errorf("invalid vkey witness length")
+			}
+			witnesses = append(witnesses, VKeyWitness{PublicKey: publicKey, Signature: signature})
+		}
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("trailing data after witness set")
+	}
+	return witnesses, nil
+}
+
+// Verify reports whether the witness is a valid signature of txHash by the key with keyHash
+func (w VKeyWitness) Verify(keyHash, txHash []byte) bool {
+	hash, err := KeyHash(w.PublicKey)
+	if err != nil || !bytes.Equal(hash, keyHash) {
+		return false
+	}
+	return ed25519.Verify(w.PublicKey, txHash, w.Signature)
+}
+
+// cborReader decodes the subset of CBOR found in witness sets (definite lengths only)
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) head() (byte, uint64, error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	major, info := b>>5, b&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR encoding (indefinite length)")
+	}
+	if r.pos+size > len(r.data) {
+		return 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	var n uint64
+	for _, c := range r.data[r.pos : r.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	r.pos += size
+	return major, n, nil
+}
+
+// array reads an array header; a set (tag 258) around the array is accepted
+func (r *cborReader) array() (uint64, error) {
+	major, n, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if major == 6 && n == 258 {
+		major, n, err = r.head()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if major != 4 {
+		return 0, fmt.Errorf("expected CBOR array")
+	}
+	return n, nil
+}
+
+func (r *cborReader) bytes() ([]byte, error) {
+	major, n, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != 2 || n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("expected CBOR byte string")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// skip skips one complete data item
+func (r *cborReader) skip() error {
+	major, n, err := r.head()
+	if err != nil {
+		return err
+	}
+	return r.skipValue(major, n)
+}
+
+// skipValue skips the content of an item whose head was already read
+func (r *cborReader) skipValue(major byte, n uint64) error {
+	switch major {
+	case 2, 3: // byte and text strings
+		if n > uint64(len(r.data)-r.pos) {
+			return fmt.Errorf("unexpected end of CBOR data")
+		}
+		r.pos += int(n)
+	case 4: // array
+		for i := uint64(0); i < n; i++ {
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+	case 5: // map
+		for i := uint64(0); i < 2*n; i++ {
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+	case 6: // tag
+		return r.skip()
+	}
+	return nil
+}
+
+// cborHead encodes a CBOR major type and argument (RFC 8949 §3)
+func cborHead(major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		b := []byte{major | 25, 0, 0}
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		return b
+	case n <= 0xffffffff:
+		b := []byte{major | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	default:
+		b := make([]byte, 9)
+		b[0] = major | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		return b
+	}
+}
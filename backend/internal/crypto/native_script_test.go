@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Vector generated with lucid-cardano 0.10 (nativeScriptFromJson / validatorToAddress)
+func TestMultiSigScript_KnownVector(t *testing.T) {
+	script, err := NewMultiSigScript(2, []string{
+		strings.Repeat("00", 28),
+		strings.Repeat("11", 28),
+		strings.Repeat("22", 28),
+	})
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	expectedCBOR := "830302838200581c" + strings.Repeat("00", 28) +
+		"8200581c" + strings.Repeat("11", 28) +
+		"8200581c" + strings.Repeat("22", 28)
+	if got := hex.EncodeToString(script.CBOR()); got != expectedCBOR {
+		t.Errorf("Unexpected script CBOR: %s", got)
+	}
+
+	if got := hex.EncodeToString(script.Hash()); got != "bc66e6de33ba9d66cf98ab37847ae97ee2e7d880dfb88b3f0e0815ff" {
+		t.Errorf("Unexpected script hash: %s", got)
+	}
+
+	address, err := script.Address(0x00)
+	if err != nil {
+		t.Fatalf("Failed to derive address: %v", err)
+	}
+	if address != "addr_test1wz7xdek7xwaf6ek0nz4n0pr6a9lw9e7csr0m3zelpcyptlczpaxgv" {
+		t.Errorf("Unexpected script address: %s", address)
+	}
+}
+
+func TestNewMultiSigScript_Validation(t *testing.T) {
+	keyHash := strings.Repeat("ab", 28)
+
+	tests := []struct {
+		name      string
+		required  int
+		keyHashes []string
+	}{
+		{"no keys", 1, nil},
+		{"zero required", 0, []string{keyHash}},
+		{"required exceeds keys", 2, []string{keyHash}},
+		{"short key hash", 1, []string{"abcd"}},
+		{"duplicate key", 1, []string{keyHash, keyHash}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewMultiSigScript(tt.required, tt.keyHashes); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestMultiSigScript_HasKey(t *testing.T) {
+	wallet, _ := GenerateCardanoWallet(0x00)
+	keyHash, err := KeyHash(wallet.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to hash key: %v", err)
+	}
+
+	script, err := NewMultiSigScript(1, []string{hex.EncodeToString(keyHash)})
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	if !script.HasKey(keyHash) {
+		t.Error("Script should contain the signer key hash")
+	}
+
+	other, _ := GenerateCardanoWallet(0x00)
+	otherHash, _ := KeyHash(other.PublicKey)
+	if script.HasKey(otherHash) {
+		t.Error("Script should not contain an unrelated key hash")
+	}
+}
+
+func TestEncodeVKeyWitnessSet(t *testing.T) {
+	witness := VKeyWitness{
+		PublicKey: make(ed25519.PublicKey, 32),
+		Signature: make([]byte, 64),
+	}
+
+	encoded := hex.EncodeToString(EncodeVKeyWitnessSet([]VKeyWitness{witness}))
+	expected := "a10081825820" + strings.Repeat("00", 32) + "5840" + strings.Repeat("00", 64)
+	if encoded != expected {
+		t.Errorf("Unexpected witness set encoding: %s", encoded)
+	}
+}
+
+func TestDecodeVKeyWitnessSet(t *testing.T) {
+	wallet, _ := GenerateCardanoWallet(0x00)
+	txHash := make([]byte, 32)
+	witness := VKeyWitness{PublicKey: wallet.PublicKey, Signature: SignMessage(wallet.PrivateKey, txHash)}
+	encoded := EncodeVKeyWitnessSet([]VKeyWitness{witness})
+
+	witnesses, err := DecodeVKeyWitnessSet(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode witness set: %v", err)
+	}
+	if len(witnesses) != 1 || !bytes.Equal(witnesses[0].PublicKey, wallet.PublicKey) {
+		t.Fatalf("Unexpected witnesses: %+v", witnesses)
+	}
+
+	keyHash, _ := KeyHash(wallet.PublicKey)
+	if !witnesses[0].Verify(keyHash, txHash) {
+		t.Error("Witness should verify against the signer's key hash")
+	}
+	other, _ := GenerateCardanoWallet(0x00)
+	otherHash, _ := KeyHash(other.PublicKey)
+	if witnesses[0].Verify(otherHash, txHash) {
+		t.Error("Witness should not verify against another key hash")
+	}
+
+	// Conway wallets wrap the witnesses in a set (tag 258) and may add other
+	// witness kinds, e.g. bootstrap witnesses under key 2
+	tagged := append([]byte{0xa2, 0x00, 0xd9, 0x01, 0x02}, encoded[2:]...)
+	tagged = append(tagged, 0x02, 0x80)
+	witnesses, err = DecodeVKeyWitnessSet(tagged)
+	if err != nil {
+		t.Fatalf("Failed to decode tagged witness set: %v", err)
+	}
+	if len(witnesses) != 1 {
+		t.Errorf("Expected 1 witness, got %d", len(witnesses))
+	}
+
+	if _, err := DecodeVKeyWitnessSet(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Truncated witness set should fail to decode")
+	}
+}
@@ -0,0 +1,131 @@
+package crypto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where wallet DEKs are wrapped after a rotation
+const (
+	WrapTargetFallback = "fallback" // local ENCRYPTION_KEY ("fb:" prefix)
+	WrapTargetVault    = "vault"    // Vault transit key ("vault:vN:" prefix)
+)
+
+// Outcome of re-wrapping one wallet key record
+type RewrapResult int
+
+const (
+	RewrapRotated RewrapResult = iota // record re-wrapped under the target key
+	RewrapCurrent                     // already wrapped by the target key
+	RewrapSkipped                     // nothing to wrap (key held in Vault transit)
+)
+
+// DEKRotator re-wraps wallet DEKs under a new master key. Re-wrapping is
+// idempotent: records already under the target key are reported as current,
+// so an interrupted rotation can simply be run again.
+type DEKRotator struct {
+	vaultClient    *VaultClient
+	target         string
+	oldFallbackKey []byte
+	newFallbackKey []byte
+	latestVersion  int // target transit key version (vault target)
+}
+
+// NewDEKRotator prepares a rotation to the target. oldFallbackKey unwraps "fb:"
+// records; newFallbackKey wraps them when the target is the fallback key.
+func NewDEKRotator(vaultClient *VaultClient, target string, oldFallbackKey, newFallbackKey []byte) (*DEKRotator, error) {
+	r := &DEKRotator{
+		vaultClient:    vaultClient,
+		target:         target,
+		oldFallbackKey: oldFallbackKey,
+		newFallbackKey: newFallbackKey,
+	}
+
+	switch target {
+	case WrapTargetFallback:
+		if len(newFallbackKey) != 32 {
+			return nil, fmt.Errorf("new fallback key must be 32 bytes")
+		}
+	case WrapTargetVault:
+		version, err := vaultClient.LatestKeyVersion()
+		if err != nil {
+			return nil, err
+		}
+		r.latestVersion = version
+	default:
+		return nil, fmt.Errorf("unknown rotation target %q (expected %s or %s)", target, WrapTargetFallback, WrapTargetVault)
+	}
+	return r, nil
+}
+
+// Rewrap re-wraps the DEK of a serialized key record and returns the updated
+// record. With dryRun the old key is still checked but nothing is re-wrapped.
+func (r *DEKRotator) Rewrap(encryptedKeyJSON string, dryRun bool) (string, RewrapResult, error) {
+	record, err := JSONToEncryptedBlob(encryptedKeyJSON)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse encrypted key: %w", err)
+	}
+	if record.Signer == SignerVaultTransit || record.DEKWrapped == "" {
+		return encryptedKeyJSON, RewrapSkipped, nil
+	}
+
+	wrapped, result, err := r.rewrapDEK(record.DEKWrapped, dryRun)
+	if err != nil || result != RewrapRotated || dryRun {
+		return encryptedKeyJSON, result, err
+	}
+
+	record.DEKWrapped = wrapped
+	updated, err := EncryptedBlobToJSON(record)
+	if err != nil {
+		return "", 0, err
+	}
+	return updated, RewrapRotated, nil
+}
+
+func (r *DEKRotator) rewrapDEK(wrapped string, dryRun bool) (string, RewrapResult, error) {
+	if strings.HasPrefix(wrapped, "fb:") {
+		if r.target == WrapTargetFallback {
+			if dek, err := unwrapDEKWithKey(r.newFallbackKey, wrapped); err == nil {
+				ZeroBytes(dek)
+				return wrapped, RewrapCurrent, nil
+			}
+		}
+
+		dek, err := unwrapDEKWithKey(r.oldFallbackKey, wrapped)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to unwrap DEK with old key: %w", err)
+		}
+		defer ZeroBytes(dek)
+		if dryRun {
+			return wrapped, RewrapRotated, nil
+		}
+
+		var rewrapped string
+		if r.target == WrapTargetVault {
+			rewrapped, err = r.vaultClient.transitEncrypt(dek)
+		} else {
+			rewrapped, err = wrapDEKWithKey(r.newFallbackKey, dek)
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to wrap DEK with new key: %w", err)
+		}
+		return rewrapped, RewrapRotated, nil
+	}
+
+	// Vault transit ciphertext
+	if r.target != WrapTargetVault {
+		return "", 0, fmt.Errorf("moving DEKs from Vault to the fallback key is not supported")
+	}
+	if strings.HasPrefix(wrapped, fmt.Sprintf("vault:v%d:", r.latestVersion)) {
+		return wrapped, RewrapCurrent, nil
+	}
+	if dryRun {
+		return wrapped, RewrapRotated, nil
+	}
+
+	rewrapped, err := r.vaultClient.RewrapDEK(wrapped)
+	if err != nil {
+		return "", 0, err
+	}
+	return rewrapped, RewrapRotated, nil
+}
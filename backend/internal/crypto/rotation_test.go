@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fallbackRecord(t *testing.T, key []byte) (string, []byte) {
+	dek, err := GenerateDEK()
+	require.NoError(t, err)
+	blob, err := EncryptWithDEK([]byte("secret"), dek)
+	require.NoError(t, err)
+	blob.DEKWrapped, err = wrapDEKWithKey(key, dek)
+	require.NoError(t, err)
+	record, err := EncryptedBlobToJSON(blob)
+	require.NoError(t, err)
+	return record, dek
+}
+
+func TestDEKRotator_FallbackToFallback(t *testing.T) {
+	oldKey, _ := GenerateDEK()
+	newKey, _ := GenerateDEK()
+	record, dek := fallbackRecord(t, oldKey)
+
+	rotator, err := NewDEKRotator(nil, WrapTargetFallback, oldKey, newKey)
+	require.NoError(t, err)
+
+	// Dry run validates the old key without changing the record
+	unchanged, result, err := rotator.Rewrap(record, true)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapRotated, result)
+	assert.Equal(t, record, unchanged)
+
+	rotated, result, err := rotator.Rewrap(record, false)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapRotated, result)
+
+	blob, err := JSONToEncryptedBlob(rotated)
+	require.NoError(t, err)
+	unwrapped, err := unwrapDEKWithKey(newKey, blob.DEKWrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dek, unwrapped)
+
+	plaintext, err := DecryptWithDEK(blob, unwrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	// Re-running is a no-op
+	_, result, err = rotator.Rewrap(rotated, false)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapCurrent, result)
+}
+
+func TestDEKRotator_WrongOldKey(t *testing.T) {
+	key, _ := GenerateDEK()
+	otherKey, _ := GenerateDEK()
+	newKey, _ := GenerateDEK()
+	record, _ := fallbackRecord(t, key)
+
+	rotator, err := NewDEKRotator(nil, WrapTargetFallback, otherKey, newKey)
+	require.NoError(t, err)
+
+	_, _, err = rotator.Rewrap(record, false)
+	assert.Error(t, err)
+}
+
+func TestDEKRotator_SkipsTransitKeys(t *testing.T) {
+	newKey, _ := GenerateDEK()
+	rotator, err := NewDEKRotator(nil, WrapTargetFallback, nil, newKey)
+	require.NoError(t, err)
+
+	_, result, err := rotator.Rewrap(`{"version":1,"algorithm":"ED25519","signer":"vault-transit","key_name":"wallet-1"}`, false)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapSkipped, result)
+}
+
+func TestDEKRotator_VaultRewrap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/transit/keys/lcn-keys":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"latest_version": 2},
+			})
+		case "/v1/transit/rewrap/lcn-keys":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"ciphertext": "vault:v2:rewrapped"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	rotator, err := NewDEKRotator(NewVaultClient(server.URL, "test-token", "lcn-keys"), WrapTargetVault, nil, nil)
+	require.NoError(t, err)
+
+	rotated, result, err := rotator.Rewrap(`{"version":1,"algorithm":"AES-256-GCM","dek_wrapped":"vault:v1:old"}`, false)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapRotated, result)
+	assert.Contains(t, rotated, "vault:v2:rewrapped")
+
+	_, result, err = rotator.Rewrap(rotated, false)
+	require.NoError(t, err)
+	assert.Equal(t, RewrapCurrent, result)
+}
@@ -0,0 +1,286 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// How a wallet's private key is held. Recorded in the wallet's key record so
+// that signing is routed to the implementation the wallet was created with.
+const (
+	SignerEnvelope     = "envelope"      // AES-256-GCM encrypted key, DEK wrapped by Vault transit
+	SignerVaultTransit = "vault-transit" // non-exportable ed25519 key inside Vault transit
+)
+
+// What an envelope record encrypts
+const (
+	KeyTypeEd25519 = ""   // standalone ed25519 private key (hex)
+	KeyTypeHD      = "hd" // BIP-39 entropy (hex); keys are derived per CIP-1852
+)
+
+// ErrKeyNotExportable is returned when a raw ed25519 private key is requested
+// for a wallet whose key lives inside Vault or is derived from a recovery phrase
+var ErrKeyNotExportable = errors.New("wallet key cannot be exported as a raw ed25519 key")
+
+// ErrNoRecoveryPhrase is returned when exporting the recovery phrase of a
+// wallet that was not created from one
+var ErrNoRecoveryPhrase = errors.New("wallet has no recovery phrase")
+
+// Signer creates wallet keys and signs with them
+type Signer interface {
+	// CreateKey generates a new key and returns its storable record and public key
+	CreateKey() (*EncryptedBlob, ed25519.PublicKey, error)
+	// PublicKey returns the public key of a stored key
+	PublicKey(record *EncryptedBlob, binding KeyBinding) (ed25519.PublicKey, error)
+	// Sign signs a message (for transactions, the 32-byte body hash)
+	Sign(record *EncryptedBlob, binding KeyBinding, message []byte) ([]byte, error)
+}
+
+// envelopeSigner keeps the private key encrypted with a per-wallet DEK that is
+// wrapped by Vault. Signing decrypts the key in process memory.
+type envelopeSigner struct {
+	vaultClient *VaultClient
+}
+
+func (s *envelopeSigner) CreateKey() (*EncryptedBlob, ed25519.PublicKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	defer ZeroBytes(privateKey)
+
+	blob, err := s.seal([]byte(PrivateKeyToHex(privateKey)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return blob, publicKey, nil
+}
+
+// createHDKey generates a BIP-39 recovery phrase and stores its entropy
+func (s *envelopeSigner) createHDKey() (*EncryptedBlob, *HDWallet, error) {
+	_, entropy, err := NewMnemonic()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ZeroBytes(entropy)
+
+	blob, err := s.seal([]byte(hex.EncodeToString(entropy)))
+	if err != nil {
+		return nil, nil, err
+	}
+	blob.KeyType = KeyTypeHD
+	return blob, HDWalletFromEntropy(entropy), nil
+}
+
+// seal encrypts key material with a fresh DEK and wraps the DEK
+func (s *envelopeSigner) seal(plaintext []byte) (*EncryptedBlob, error) {
+	// Generate DEK (Data Encryption Key)
+	dek, err := GenerateDEK()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate DEK: %w", err)
+	}
+	defer ZeroBytes(dek)
+
+	// Encrypt private key with DEK
+	blob, err := EncryptWithDEK(plaintext, dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	// Wrap DEK with Vault
+	wrappedDEK, err := s.vaultClient.WrapDEK(dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap DEK: %w", err)
+	}
+	blob.DEKWrapped = wrappedDEK
+
+	return blob, nil
+}
+
+func (s *envelopeSigner) PublicKey(record *EncryptedBlob, binding KeyBinding) (ed25519.PublicKey, error) {
+	if record.KeyType == KeyTypeHD {
+		wallet, err := s.hdWallet(record, binding)
+		if err != nil {
+			return nil, err
+		}
+		defer wallet.Zero()
+		return wallet.PaymentKey.PublicKey(), nil
+	}
+
+	privateKey, err := s.decrypt(record, binding)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(privateKey)
+	return DerivePublicKey(privateKey), nil
+}
+
+func (s *envelopeSigner) Sign(record *EncryptedBlob, binding KeyBinding, message []byte) ([]byte, error) {
+	if record.KeyType == KeyTypeHD {
+		wallet, err := s.hdWallet(record, binding)
+		if err != nil {
+			return nil, err
+		}
+		defer wallet.Zero()
+		return wallet.PaymentKey.Sign(message), nil
+	}
+
+	privateKey, err := s.decrypt(record, binding)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(privateKey) // Clear from memory
+	return SignMessage(privateKey, message), nil
+}
+
+// entropy decrypts the BIP-39 entropy of an HD record
+func (s *envelopeSigner) entropy(record *EncryptedBlob, binding KeyBinding) ([]byte, error) {
+	if record.KeyType != KeyTypeHD {
+		return nil, ErrNoRecoveryPhrase
+	}
+	plaintext, err := s.open(record, binding)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(plaintext)
+
+	entropy, err := hex.DecodeString(string(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wallet entropy: %w", err)
+	}
+	return entropy, nil
+}
+
+// hdWallet derives the CIP-1852 keys of an HD record
+func (s *envelopeSigner) hdWallet(record *EncryptedBlob, binding KeyBinding) (*HDWallet, error) {
+	entropy, err := s.entropy(record, binding)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(entropy)
+	return HDWalletFromEntropy(entropy), nil
+}
+
+// decrypt decrypts a standalone ed25519 private key
+func (s *envelopeSigner) decrypt(record *EncryptedBlob, binding KeyBinding) (ed25519.PrivateKey, error) {
+	if record.KeyType != KeyTypeEd25519 {
+		return nil, ErrKeyNotExportable
+	}
+
+	privateKeyHexBytes, err := s.open(record, binding)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(privateKeyHexBytes) // Clear plaintext from memory
+
+	privateKey, err := HexToPrivateKey(string(privateKeyHexBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return privateKey, nil
+}
+
+// open decrypts the record's key material; version-2 records must match the binding
+func (s *envelopeSigner) open(record *EncryptedBlob, binding KeyBinding) ([]byte, error) {
+	var aad []byte
+	if record.Version >= BlobVersionBound {
+		var err error
+		if aad, err = binding.associatedData(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Unwrap DEK from Vault
+	dek, err := s.vaultClient.UnwrapDEK(record.DEKWrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap DEK: %w", err)
+	}
+	defer ZeroBytes(dek) // Clear DEK from memory
+
+	// Decrypt private key with DEK
+	plaintext, err := DecryptWithDEKBound(record, dek, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return plaintext, nil
+}
+
+// bind re-seals a version-1 record as a version-2 record bound to the wallet.
+// The DEK is reused, so the wrapped DEK does not change.
+func (s *envelopeSigner) bind(record *EncryptedBlob, binding KeyBinding) (*EncryptedBlob, error) {
+	aad, err := binding.associatedData()
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := s.vaultClient.UnwrapDEK(record.DEKWrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap DEK: %w", err)
+	}
+	defer ZeroBytes(dek)
+
+	plaintext, err := DecryptWithDEK(record, dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	defer ZeroBytes(plaintext)
+
+	bound, err := EncryptWithDEKBound(plaintext, dek, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+	bound.DEKWrapped = record.DEKWrapped
+	bound.Signer = record.Signer
+	bound.KeyType = record.KeyType
+	return bound, nil
+}
+
+// transitSigner keeps one non-exportable ed25519 key per wallet in Vault and
+// signs through transit/sign; the key record only names the Vault key.
+type transitSigner struct {
+	vaultClient *VaultClient
+}
+
+func (s *transitSigner) CreateKey() (*EncryptedBlob, ed25519.PublicKey, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key name: %w", err)
+	}
+	name := "wallet-" + hex.EncodeToString(suffix)
+
+	if err := s.vaultClient.CreateSigningKey(name); err != nil {
+		return nil, nil, err
+	}
+	publicKey, err := s.vaultClient.GetPublicKey(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &EncryptedBlob{
+		Version:   1,
+		Algorithm: "ED25519",
+		Signer:    SignerVaultTransit,
+		KeyName:   name,
+		PublicKey: hex.EncodeToString(publicKey),
+	}, publicKey, nil
+}
+
+func (s *transitSigner) PublicKey(record *EncryptedBlob, _ KeyBinding) (ed25519.PublicKey, error) {
+	if record.PublicKey != "" {
+		publicKey, err := hex.DecodeString(record.PublicKey)
+		if err == nil && len(publicKey) == ed25519.PublicKeySize {
+			return publicKey, nil
+		}
+	}
+	return s.vaultClient.GetPublicKey(record.KeyName)
+}
+
+func (s *transitSigner) Sign(record *EncryptedBlob, _ KeyBinding, message []byte) ([]byte, error) {
+	if record.KeyName == "" {
+		return nil, fmt.Errorf("key record has no Vault key name")
+	}
+	return s.vaultClient.Sign(record.KeyName, message)
+}
@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransit implements the Vault transit key/sign endpoints used by transitSigner
+func fakeTransit(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	keys := map[string]ed25519.PrivateKey{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/v1/transit/keys/"):
+			var req struct {
+				Type       string `json:"type"`
+				Exportable bool   `json:"exportable"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			assert.Equal(t, "ed25519", req.Type)
+			assert.False(t, req.Exportable)
+			_, priv, _ := ed25519.GenerateKey(rand.Reader)
+			keys[strings.TrimPrefix(r.URL.Path, "/v1/transit/keys/")] = priv
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/transit/keys/"):
+			priv := keys[strings.TrimPrefix(r.URL.Path, "/v1/transit/keys/")]
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"latest_version": 1,
+					"keys": map[string]interface{}{
+						"1": map[string]string{"public_key": base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))},
+					},
+				},
+			})
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/v1/transit/sign/"):
+			priv, ok := keys[strings.TrimPrefix(r.URL.Path, "/v1/transit/sign/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var req struct {
+				Input string `json:"input"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			input, _ := base64.StdEncoding.DecodeString(req.Input)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{
+					"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(ed25519.Sign(priv, input)),
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestWalletService_TransitSigner(t *testing.T) {
+	server := fakeTransit(t)
+	defer server.Close()
+
+	walletService, err := NewWalletService(NewVaultClient(server.URL, "test-token", "lcn-keys"), SignerVaultTransit)
+	require.NoError(t, err)
+
+	result, err := walletService.CreateWallet("testnet")
+	require.NoError(t, err)
+	assert.Contains(t, result.Address, "addr_test")
+	assert.Equal(t, SignerVaultTransit, result.Signer)
+	assert.NotContains(t, result.EncryptedPrivKey, "ciphertext")
+
+	signer, err := walletService.SignerOf(result.EncryptedPrivKey)
+	require.NoError(t, err)
+	assert.Equal(t, SignerVaultTransit, signer)
+
+	key := WalletKey{KeyBinding: KeyBinding{Address: result.Address, OwnerID: "owner"}, EncryptedKey: result.EncryptedPrivKey}
+	txHash := make([]byte, 32)
+	_, _ = rand.Read(txHash)
+	signature, err := walletService.SignTransaction(key, txHash)
+	require.NoError(t, err)
+
+	publicKey, err := walletService.PublicKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, result.PubKeyHex, PublicKeyToHex(publicKey))
+	assert.True(t, VerifySignature(publicKey, txHash, signature))
+
+	_, err = walletService.DecryptPrivateKey(key)
+	assert.ErrorIs(t, err, ErrKeyNotExportable)
+}
+
+func TestWalletService_LegacyRecordsUseEnvelopeSigner(t *testing.T) {
+	walletService, err := NewWalletService(NewVaultClient("disabled", "", ""), SignerVaultTransit)
+	require.NoError(t, err)
+
+	// Key records written before signers existed carry no signer field
+	signer, err := walletService.SignerOf(`{"version":1,"algorithm":"AES-256-GCM","nonce":"00","ciphertext":"00","tag":"00","dek_wrapped":"fb:AA=="}`)
+	require.NoError(t, err)
+	assert.Equal(t, SignerEnvelope, signer)
+}
+
+func TestNewWalletService_UnknownSigner(t *testing.T) {
+	_, err := NewWalletService(NewVaultClient("disabled", "", ""), "hsm")
+	assert.Error(t, err)
+}
@@ -4,91 +4,105 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Backends that wrap wallet DEKs (ENCRYPTION_BACKEND)
+const (
+	EncryptionBackendVault = "vault" // Vault transit key
+	EncryptionBackendLocal = "local" // AES-256-GCM under ENCRYPTION_KEY (development only)
+)
+
 type VaultClient struct {
-	addr        string
-	token       string
-	transitKey  string
-	httpClient  *http.Client
-	fallbackKey []byte // For non-Vault encryption
-	useVault    bool
+	addr       string
+	transitKey string
+	httpClient *http.Client
+	backend    string
+	localKey   []byte // local backend key; under Vault only reads legacy "fb:" DEKs
+
+	auth *vaultAuth // nil for the local backend
+
+	mu        sync.RWMutex
+	token     string
+	tokenTTL  time.Duration // 0 = does not expire
+	renewable bool
 }
 
+// NewVaultClient returns a Vault-backed client authenticated with a static token
 func NewVaultClient(addr, token, transitKey string) *VaultClient {
-	// Check if Vault is disabled
-	useVault := addr != "" && addr != "disabled" && !strings.HasPrefix(addr, "http://localhost")
-
-	// Get fallback encryption key from environment
-	var fallbackKey []byte
-	if envKey := os.Getenv("ENCRYPTION_KEY"); envKey != "" {
-		decodedKey, err := hex.DecodeString(envKey)
-		if err == nil && len(decodedKey) == 32 {
-			fallbackKey = decodedKey
-		}
-	}
-
 	return &VaultClient{
-		addr:        addr,
-		token:       token,
-		transitKey:  transitKey,
-		fallbackKey: fallbackKey,
-		useVault:    useVault,
+		addr:       addr,
+		token:      token,
+		transitKey: transitKey,
+		backend:    EncryptionBackendVault,
+		auth:       &vaultAuth{method: VaultAuthToken},
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
 }
 
-// WrapDEK wraps a DEK using Vault's transit encryption or fallback encryption
-func (v *VaultClient) WrapDEK(dek []byte) (string, error) {
-	// Use fallback encryption if Vault is disabled
-	if !v.useVault || v.fallbackKey != nil {
-		return v.wrapDEKFallback(dek)
+// NewLocalKeyClient returns a client that wraps DEKs with a local 32-byte key
+// instead of Vault. Transit signing is not available.
+func NewLocalKeyClient(key []byte) (*VaultClient, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("local encryption key must be 32 bytes")
 	}
+	return &VaultClient{backend: EncryptionBackendLocal, localKey: key}, nil
+}
 
-	// Base64 encode the DEK for Vault
-	dekBase64 := base64.StdEncoding.EncodeToString(dek)
+// Backend reports which backend wraps new DEKs
+func (v *VaultClient) Backend() string {
+	return v.backend
+}
 
-	payload := map[string]interface{}{
-		"plaintext": dekBase64,
+// WrapDEK wraps a DEK with the configured backend
+func (v *VaultClient) WrapDEK(dek []byte) (string, error) {
+	if v.backend == EncryptionBackendLocal {
+		return wrapDEKWithKey(v.localKey, dek)
 	}
+	return v.transitEncrypt(dek)
+}
 
-	payloadBytes, err := json.Marshal(payload)
+// transitEncrypt wraps a DEK with the Vault transit key (no fallback)
+func (v *VaultClient) transitEncrypt(dek []byte) (string, error) {
+	body, err := v.transitRequest("POST", "encrypt/"+v.transitKey, map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(dek),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
+		return "", err
 	}
 
-	// Vault API path needs /v1/ prefix
-	url := fmt.Sprintf("%s/v1/transit/encrypt/%s", v.addr, v.transitKey)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	var result struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
 	}
 
-	req.Header.Set("X-Vault-Token", v.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := v.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call Vault: %w", err)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
+	return result.Data.Ciphertext, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("vault returned status %d: %s", resp.StatusCode, string(body))
+// RewrapDEK re-encrypts a Vault-wrapped DEK with the latest version of the
+// transit key without exposing the DEK (transit/rewrap)
+func (v *VaultClient) RewrapDEK(ciphertext string) (string, error) {
+	body, err := v.transitRequest("POST", "rewrap/"+v.transitKey, map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrap DEK: %w", err)
 	}
 
 	var result struct {
@@ -96,48 +110,60 @@ func (v *VaultClient) WrapDEK(dek []byte) (string, error) {
 			Ciphertext string `json:"ciphertext"`
 		} `json:"data"`
 	}
-
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 	return result.Data.Ciphertext, nil
 }
 
-// UnwrapDEK unwraps a DEK using Vault's transit decryption or fallback decryption
-func (v *VaultClient) UnwrapDEK(ciphertext string) ([]byte, error) {
-	// Use fallback decryption if Vault is disabled or if ciphertext is fallback format
-	if !v.useVault || v.fallbackKey != nil || strings.HasPrefix(ciphertext, "fb:") {
-		return v.unwrapDEKFallback(ciphertext)
+// LatestKeyVersion returns the latest version of the DEK-wrapping transit key
+func (v *VaultClient) LatestKeyVersion() (int, error) {
+	body, err := v.transitRequest("GET", "keys/"+v.transitKey, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read transit key: %w", err)
 	}
 
-	payload := map[string]interface{}{
-		"ciphertext": ciphertext,
+	var result struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+		} `json:"data"`
 	}
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
 	}
+	return result.Data.LatestVersion, nil
+}
 
-	// Vault API path needs /v1/ prefix
-	url := fmt.Sprintf("%s/v1/transit/decrypt/%s", v.addr, v.transitKey)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// RotateTransitKey creates a new version of the DEK-wrapping transit key
+func (v *VaultClient) RotateTransitKey() error {
+	if _, err := v.transitRequest("POST", "keys/"+v.transitKey+"/rotate", nil); err != nil {
+		return fmt.Errorf("failed to rotate transit key: %w", err)
 	}
+	return nil
+}
 
-	req.Header.Set("X-Vault-Token", v.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := v.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Vault: %w", err)
+// UnwrapDEK unwraps a DEK with whichever key wrapped it: "fb:" DEKs need the
+// local key (ENCRYPTION_KEY), everything else Vault
+func (v *VaultClient) UnwrapDEK(ciphertext string) ([]byte, error) {
+	if strings.HasPrefix(ciphertext, "fb:") {
+		if v.localKey == nil {
+			return nil, fmt.Errorf("DEK is wrapped by a local key: set ENCRYPTION_KEY or move it to Vault with cmd/rotate-keys")
+		}
+		return unwrapDEKWithKey(v.localKey, ciphertext)
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
+	if v.backend == EncryptionBackendLocal {
+		return nil, fmt.Errorf("DEK is wrapped by Vault but ENCRYPTION_BACKEND is %s", v.backend)
+	}
+	return v.transitDecrypt(ciphertext)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, string(body))
+// transitDecrypt unwraps a Vault-wrapped DEK (no fallback)
+func (v *VaultClient) transitDecrypt(ciphertext string) ([]byte, error) {
+	body, err := v.transitRequest("POST", "decrypt/"+v.transitKey, map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var result struct {
@@ -158,16 +184,16 @@ func (v *VaultClient) UnwrapDEK(ciphertext string) ([]byte, error) {
 	return dek, nil
 }
 
-// --- Fallback encryption methods (no Vault required) ---
+// --- Local key encryption (no Vault required) ---
 
-// wrapDEKFallback encrypts DEK using AES-256-GCM with environment key
-func (v *VaultClient) wrapDEKFallback(dek []byte) (string, error) {
-	if len(v.fallbackKey) != 32 {
+// wrapDEKWithKey encrypts a DEK with AES-256-GCM under a local master key ("fb:" format)
+func wrapDEKWithKey(key, dek []byte) (string, error) {
+	if len(key) != 32 {
 		return "", fmt.Errorf("fallback encryption key not configured (set ENCRYPTION_KEY environment variable)")
 	}
 
 	// Create AES cipher
-	block, err := aes.NewCipher(v.fallbackKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -191,9 +217,9 @@ func (v *VaultClient) wrapDEKFallback(dek []byte) (string, error) {
 	return "fb:" + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// unwrapDEKFallback decrypts DEK using AES-256-GCM with environment key
-func (v *VaultClient) unwrapDEKFallback(ciphertext string) ([]byte, error) {
-	if len(v.fallbackKey) != 32 {
+// unwrapDEKWithKey decrypts an "fb:" wrapped DEK with a local master key
+func unwrapDEKWithKey(key []byte, ciphertext string) ([]byte, error) {
+	if len(key) != 32 {
 		return nil, fmt.Errorf("fallback encryption key not configured (set ENCRYPTION_KEY environment variable)")
 	}
 
@@ -207,7 +233,7 @@ func (v *VaultClient) unwrapDEKFallback(ciphertext string) ([]byte, error) {
 	}
 
 	// Create AES cipher
-	block, err := aes.NewCipher(v.fallbackKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -235,7 +261,133 @@ func (v *VaultClient) unwrapDEKFallback(ciphertext string) ([]byte, error) {
 	return dek, nil
 }
 
+// --- Transit signing keys (private key never leaves Vault) ---
+
+// CreateSigningKey creates a non-exportable ed25519 transit key
+func (v *VaultClient) CreateSigningKey(name string) error {
+	payload := map[string]interface{}{
+		"type":                   "ed25519",
+		"exportable":             false,
+		"allow_plaintext_backup": false,
+	}
+	_, err := v.transitRequest("POST", "keys/"+name, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+	return nil
+}
+
+// GetPublicKey returns the public key of the latest version of a transit key
+func (v *VaultClient) GetPublicKey(name string) (ed25519.PublicKey, error) {
+	body, err := v.transitRequest("GET", "keys/"+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	key, ok := result.Data.Keys[strconv.Itoa(result.Data.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s has no public key", name)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key for signing key %s", name)
+	}
+	return ed25519.PublicKey(publicKey), nil
+}
+
+// Sign signs a message with a transit key (ed25519 signs the raw input, no prehash)
+func (v *VaultClient) Sign(name string, message []byte) ([]byte, error) {
+	payload := map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(message),
+	}
+	body, err := v.transitRequest("POST", "sign/"+name, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Format: vault:v<version>:<base64 signature>
+	parts := strings.SplitN(result.Data.Signature, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected signature format")
+	}
+	signature, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	return signature, nil
+}
+
+// transitRequest calls a Vault transit endpoint and returns the response body
+func (v *VaultClient) transitRequest(method, path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		reqBody = bytes.NewBuffer(payloadBytes)
+	}
+
+	return v.request(method, "transit/"+path, reqBody, true)
+}
+
+// request calls a Vault API path (relative to /v1/) and returns the response body
+func (v *VaultClient) request(method, path string, reqBody io.Reader, authenticated bool) ([]byte, error) {
+	if v.backend != EncryptionBackendVault {
+		return nil, fmt.Errorf("vault is not the configured encryption backend")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s", v.addr, path)
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if authenticated {
+		req.Header.Set("X-Vault-Token", v.currentToken())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
 func (v *VaultClient) Health() error {
+	if v.backend != EncryptionBackendVault {
+		return nil
+	}
+
 	url := fmt.Sprintf("%s/v1/sys/health", v.addr)
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -0,0 +1,260 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Vault login methods (VAULT_AUTH_METHOD)
+const (
+	VaultAuthToken      = "token"      // static VAULT_TOKEN
+	VaultAuthAppRole    = "approle"    // VAULT_ROLE_ID + VAULT_SECRET_ID
+	VaultAuthKubernetes = "kubernetes" // service account JWT + VAULT_KUBERNETES_ROLE
+)
+
+// How long to wait before retrying a failed token renewal or login
+const vaultRetryInterval = 30 * time.Second
+
+// Credentials for logging in to Vault
+type vaultAuth struct {
+	method         string
+	mount          string // auth mount path, defaults to the method name
+	roleID         string
+	secretID       string
+	kubernetesRole string
+	jwtPath        string // service account token file
+}
+
+// Token fields of a Vault login or renew-self response
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// ConnectEncryptionBackend builds the DEK-wrapping client selected by
+// ENCRYPTION_BACKEND and fails if it is unusable. For Vault it logs in,
+// checks access to the transit key and keeps the token renewed until ctx is done.
+func ConnectEncryptionBackend(ctx context.Context, cfg *config.Config) (*VaultClient, error) {
+	var localKey []byte
+	if cfg.EncryptionKey != "" {
+		key, err := hex.DecodeString(cfg.EncryptionKey)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("ENCRYPTION_KEY must be 64 hex characters (32 bytes)")
+		}
+		localKey = key
+	}
+
+	switch cfg.EncryptionBackend {
+	case EncryptionBackendLocal:
+		if localKey == nil {
+			return nil, fmt.Errorf("ENCRYPTION_BACKEND=%s requires ENCRYPTION_KEY", EncryptionBackendLocal)
+		}
+		return NewLocalKeyClient(localKey)
+	case EncryptionBackendVault:
+	default:
+		return nil, fmt.Errorf("unknown ENCRYPTION_BACKEND %q (expected %s or %s)",
+			cfg.EncryptionBackend, EncryptionBackendVault, EncryptionBackendLocal)
+	}
+
+	auth, err := newVaultAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	v := NewVaultClient(cfg.VaultAddr, cfg.VaultToken, cfg.VaultTransitKey)
+	v.auth = auth
+	v.localKey = localKey // legacy "fb:" DEKs stay readable until rotated to Vault
+
+	if err := v.Health(); err != nil {
+		return nil, err
+	}
+	if err := v.login(); err != nil {
+		return nil, fmt.Errorf("vault login (%s) failed: %w", auth.method, err)
+	}
+	if _, err := v.LatestKeyVersion(); err != nil {
+		return nil, fmt.Errorf("transit key %s is not usable: %w", cfg.VaultTransitKey, err)
+	}
+
+	go v.renewToken(ctx)
+	return v, nil
+}
+
+func newVaultAuth(cfg *config.Config) (*vaultAuth, error) {
+	auth := &vaultAuth{
+		method:         cfg.VaultAuthMethod,
+		mount:          cfg.VaultAuthMount,
+		roleID:         cfg.VaultRoleID,
+		secretID:       cfg.VaultSecretID,
+		kubernetesRole: cfg.VaultKubernetesRole,
+		jwtPath:        cfg.VaultKubernetesTokenPath,
+	}
+	if auth.mount == "" {
+		auth.mount = auth.method
+	}
+
+	switch auth.method {
+	case VaultAuthToken:
+		if cfg.VaultToken == "" {
+			return nil, fmt.Errorf("VAULT_AUTH_METHOD=%s requires VAULT_TOKEN", VaultAuthToken)
+		}
+	case VaultAuthAppRole:
+		if auth.roleID == "" || auth.secretID == "" {
+			return nil, fmt.Errorf("VAULT_AUTH_METHOD=%s requires VAULT_ROLE_ID and VAULT_SECRET_ID", VaultAuthAppRole)
+		}
+	case VaultAuthKubernetes:
+		if auth.kubernetesRole == "" || auth.jwtPath == "" {
+			return nil, fmt.Errorf("VAULT_AUTH_METHOD=%s requires VAULT_KUBERNETES_ROLE", VaultAuthKubernetes)
+		}
+	default:
+		return nil, fmt.Errorf("unknown VAULT_AUTH_METHOD %q (expected %s, %s or %s)",
+			auth.method, VaultAuthToken, VaultAuthAppRole, VaultAuthKubernetes)
+	}
+	return auth, nil
+}
+
+// login obtains a token with the configured method. A static token is only
+// looked up to learn its TTL.
+func (v *VaultClient) login() error {
+	if v.auth.method == VaultAuthToken {
+		body, err := v.request("GET", "auth/token/lookup-self", nil, true)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		v.setToken("", result.Data.TTL, result.Data.Renewable)
+		return nil
+	}
+
+	var payload map[string]string
+	if v.auth.method == VaultAuthAppRole {
+		payload = map[string]string{"role_id": v.auth.roleID, "secret_id": v.auth.secretID}
+	} else {
+		jwt, err := os.ReadFile(v.auth.jwtPath)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token: %w", err)
+		}
+		payload = map[string]string{"role": v.auth.kubernetesRole, "jwt": strings.TrimSpace(string(jwt))}
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	body, err := v.request("POST", "auth/"+v.auth.mount+"/login", bytes.NewReader(payloadBytes), false)
+	if err != nil {
+		return err
+	}
+	var result vaultAuthResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if result.Auth.ClientToken == "" {
+		return fmt.Errorf("vault login returned no token")
+	}
+	v.setToken(result.Auth.ClientToken, result.Auth.LeaseDuration, result.Auth.Renewable)
+	return nil
+}
+
+// renewToken keeps the token alive, renewing it at two thirds of its TTL.
+// When renewal fails or the token reaches its max TTL, AppRole and Kubernetes
+// clients log in again.
+func (v *VaultClient) renewToken(ctx context.Context) {
+	retrying := false
+	for {
+		v.mu.RLock()
+		ttl, renewable := v.tokenTTL, v.renewable
+		v.mu.RUnlock()
+
+		if ttl == 0 {
+			return // token does not expire
+		}
+		if v.auth.method == VaultAuthToken && !renewable {
+			logger.Warn("Vault token is not renewable and will expire", map[string]interface{}{
+				"ttl": ttl.String(),
+			})
+			return
+		}
+
+		wait := ttl * 2 / 3
+		if retrying && wait > vaultRetryInterval {
+			wait = vaultRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		err := v.refreshToken(ttl, renewable)
+		retrying = err != nil
+		if err != nil {
+			logger.Error("Failed to renew Vault token", err, map[string]interface{}{
+				"auth_method": v.auth.method,
+			})
+		}
+	}
+}
+
+func (v *VaultClient) refreshToken(ttl time.Duration, renewable bool) error {
+	if renewable {
+		renewedTTL, err := v.renewSelf()
+		// A much shorter lease means the token is close to its max TTL
+		if err == nil && (v.auth.method == VaultAuthToken || renewedTTL >= ttl/2) {
+			return nil
+		}
+		if v.auth.method == VaultAuthToken {
+			return err
+		}
+	}
+	return v.login()
+}
+
+func (v *VaultClient) renewSelf() (time.Duration, error) {
+	body, err := v.request("POST", "auth/token/renew-self", nil, true)
+	if err != nil {
+		return 0, err
+	}
+	var result vaultAuthResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	v.setToken("", result.Auth.LeaseDuration, result.Auth.Renewable)
+	return time.Duration(result.Auth.LeaseDuration) * time.Second, nil
+}
+
+// setToken stores a new token (empty keeps the current one) and its lease
+func (v *VaultClient) setToken(token string, ttlSeconds int, renewable bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if token != "" {
+		v.token = token
+	}
+	v.tokenTTL = time.Duration(ttlSeconds) * time.Second
+	v.renewable = renewable
+}
+
+func (v *VaultClient) currentToken() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.token
+}
@@ -0,0 +1,125 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVaultAuth serves AppRole login, renew-self and a transit key lookup.
+// Login tokens expire after one second; renewal fails when failRenew is set.
+func fakeVaultAuth(t *testing.T, logins, renewals *int32, failRenew bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/auth/approle/login":
+			atomic.AddInt32(logins, 1)
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"approle-token","lease_duration":1,"renewable":true}}`))
+		case "/v1/auth/token/renew-self":
+			atomic.AddInt32(renewals, 1)
+			if failRenew {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"approle-token","lease_duration":1,"renewable":true}}`))
+		case "/v1/transit/keys/lcn-keys":
+			assert.Equal(t, "approle-token", r.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"data":{"latest_version":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func appRoleConfig(addr string) *config.Config {
+	return &config.Config{
+		EncryptionBackend: EncryptionBackendVault,
+		VaultAddr:         addr,
+		VaultTransitKey:   "lcn-keys",
+		VaultAuthMethod:   VaultAuthAppRole,
+		VaultRoleID:       "role",
+		VaultSecretID:     "secret",
+	}
+}
+
+func TestConnectEncryptionBackend_AppRoleRenewsToken(t *testing.T) {
+	var logins, renewals int32
+	server := fakeVaultAuth(t, &logins, &renewals, false)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vaultClient, err := ConnectEncryptionBackend(ctx, appRoleConfig(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionBackendVault, vaultClient.Backend())
+	assert.Equal(t, "approle-token", vaultClient.currentToken())
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&renewals) >= 1 }, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&logins))
+}
+
+func TestConnectEncryptionBackend_LogsInAgainWhenRenewalFails(t *testing.T) {
+	var logins, renewals int32
+	server := fakeVaultAuth(t, &logins, &renewals, true)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := ConnectEncryptionBackend(ctx, appRoleConfig(server.URL))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&logins) >= 2 }, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestConnectEncryptionBackend_FailsWhenVaultUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	_, err := ConnectEncryptionBackend(context.Background(), appRoleConfig(server.URL))
+	assert.Error(t, err)
+}
+
+func TestConnectEncryptionBackend_Local(t *testing.T) {
+	key, _ := GenerateDEK()
+	cfg := &config.Config{EncryptionBackend: EncryptionBackendLocal, EncryptionKey: hex.EncodeToString(key)}
+
+	vaultClient, err := ConnectEncryptionBackend(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionBackendLocal, vaultClient.Backend())
+
+	wrapped, err := vaultClient.WrapDEK([]byte("dek"))
+	require.NoError(t, err)
+	assert.Contains(t, wrapped, "fb:")
+
+	// Vault-wrapped DEKs cannot be read without Vault
+	_, err = vaultClient.UnwrapDEK("vault:v1:abc")
+	assert.Error(t, err)
+
+	// The local backend needs a key; the backend must be named explicitly
+	_, err = ConnectEncryptionBackend(context.Background(), &config.Config{EncryptionBackend: EncryptionBackendLocal})
+	assert.Error(t, err)
+	_, err = ConnectEncryptionBackend(context.Background(), &config.Config{EncryptionKey: hex.EncodeToString(key)})
+	assert.Error(t, err)
+}
+
+func TestConnectEncryptionBackend_RequiresAuthCredentials(t *testing.T) {
+	cfg := appRoleConfig("http://vault.invalid")
+	cfg.VaultSecretID = ""
+	_, err := ConnectEncryptionBackend(context.Background(), cfg)
+	assert.ErrorContains(t, err, "VAULT_SECRET_ID")
+
+	cfg.VaultAuthMethod = "userpass"
+	_, err = ConnectEncryptionBackend(context.Background(), cfg)
+	assert.ErrorContains(t, err, "VAULT_AUTH_METHOD")
+}
@@ -2,113 +2,265 @@ package crypto
 
 import (
 	"crypto/ed25519"
+	"errors"
 	"fmt"
 )
 
 type WalletService struct {
-	vaultClient *VaultClient
+	vaultClient   *VaultClient
+	signers       map[string]Signer
+	defaultSigner string
 }
 
-func NewWalletService(vaultClient *VaultClient) *WalletService {
-	return &WalletService{
-		vaultClient: vaultClient,
+// NewWalletService creates a wallet service that creates new wallets with the
+// given signer (SignerEnvelope or SignerVaultTransit). Existing wallets keep
+// signing with the signer they were created with.
+func NewWalletService(vaultClient *VaultClient, defaultSigner string) (*WalletService, error) {
+	signers := map[string]Signer{
+		SignerEnvelope:     &envelopeSigner{vaultClient: vaultClient},
+		SignerVaultTransit: &transitSigner{vaultClient: vaultClient},
+	}
+	if defaultSigner == "" {
+		defaultSigner = SignerEnvelope
+	}
+	if _, ok := signers[defaultSigner]; !ok {
+		return nil, fmt.Errorf("unknown wallet signer %q (expected %s or %s)", defaultSigner, SignerEnvelope, SignerVaultTransit)
+	}
+	if defaultSigner == SignerVaultTransit && vaultClient.Backend() != EncryptionBackendVault {
+		return nil, fmt.Errorf("wallet signer %s requires ENCRYPTION_BACKEND=%s", SignerVaultTransit, EncryptionBackendVault)
 	}
+
+	return &WalletService{
+		vaultClient:   vaultClient,
+		signers:       signers,
+		defaultSigner: defaultSigner,
+	}, nil
 }
 
-// CreateWallet generates a new Cardano wallet with encrypted private key
+// CreateWallet generates a new Cardano wallet whose key is held by the default signer
 func (s *WalletService) CreateWallet(network string) (*WalletResult, error) {
-	// Determine network tag
-	var networkTag byte
-	switch network {
-	case "mainnet":
-		networkTag = 0x01
-	default: // testnet, preprod, etc
-		networkTag = 0x00
+	networkTag := NetworkTag(network)
+
+	// 1. Generate the key with the configured signer
+	record, publicKey, err := s.signers[s.defaultSigner].CreateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet key: %w", err)
+	}
+	if s.defaultSigner != SignerEnvelope {
+		record.Signer = s.defaultSigner
 	}
 
-	// 1. Generate Cardano wallet
-	wallet, err := GenerateCardanoWallet(networkTag)
+	// 2. Derive the payment address
+	address, err := deriveCardanoAddress(publicKey, networkTag)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate wallet: %w", err)
+		return nil, fmt.Errorf("failed to derive address: %w", err)
 	}
 
-	// 2. Generate DEK (Data Encryption Key)
-	dek, err := GenerateDEK()
+	// 3. Convert to JSON
+	encryptedKeyJSON, err := EncryptedBlobToJSON(record)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate DEK: %w", err)
+		return nil, fmt.Errorf("failed to serialize encrypted key: %w", err)
 	}
-	defer ZeroBytes(dek)
 
-	// 3. Encrypt private key with DEK
-	privKeyHex := PrivateKeyToHex(wallet.PrivateKey)
-	encryptedBlob, err := EncryptWithDEK([]byte(privKeyHex), dek)
+	return &WalletResult{
+		Address:          address,
+		EncryptedPrivKey: encryptedKeyJSON,
+		WrappedDEK:       record.DEKWrapped,
+		PubKeyHex:        PublicKeyToHex(publicKey),
+		Signer:           s.defaultSigner,
+	}, nil
+}
+
+// CreateHDWallet generates a wallet from a new BIP-39 recovery phrase. Keys
+// follow CIP-1852 (account 0, address 0) and the address is a base address
+// with a staking credential, so the phrase restores the same wallet in
+// Eternl, Lace or any other Icarus-compatible wallet. The entropy is stored
+// envelope-encrypted regardless of the default signer.
+func (s *WalletService) CreateHDWallet(network string) (*WalletResult, error) {
+	envelope := s.signers[SignerEnvelope].(*envelopeSigner)
+	record, wallet, err := envelope.createHDKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+		return nil, fmt.Errorf("failed to create wallet key: %w", err)
 	}
+	defer wallet.Zero()
 
-	// 4. Wrap DEK with Vault
-	wrappedDEK, err := s.vaultClient.WrapDEK(dek)
+	address, err := wallet.BaseAddress(NetworkTag(network))
 	if err != nil {
-		return nil, fmt.Errorf("failed to wrap DEK: %w", err)
+		return nil, fmt.Errorf("failed to derive address: %w", err)
 	}
-	encryptedBlob.DEKWrapped = wrappedDEK
 
-	// 5. Convert to JSON
-	encryptedKeyJSON, err := EncryptedBlobToJSON(encryptedBlob)
+	encryptedKeyJSON, err := EncryptedBlobToJSON(record)
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize encrypted key: %w", err)
 	}
 
 	return &WalletResult{
-		Address:          wallet.Address,
+		Address:          address,
 		EncryptedPrivKey: encryptedKeyJSON,
-		WrappedDEK:       wrappedDEK,
-		PubKeyHex:        PublicKeyToHex(wallet.PublicKey),
+		WrappedDEK:       record.DEKWrapped,
+		PubKeyHex:        PublicKeyToHex(wallet.PaymentKey.PublicKey()),
+		Signer:           SignerEnvelope,
+		KeyType:          KeyTypeHD,
 	}, nil
 }
 
-// DecryptPrivateKey decrypts an encrypted private key
-func (s *WalletService) DecryptPrivateKey(encryptedKeyJSON string) (ed25519.PrivateKey, error) {
-	// 1. Parse encrypted blob
-	blob, err := JSONToEncryptedBlob(encryptedKeyJSON)
+// ExportMnemonic returns the recovery phrase of an HD wallet. Fails with
+// ErrNoRecoveryPhrase for wallets with a standalone key.
+func (s *WalletService) ExportMnemonic(key WalletKey) (string, error) {
+	record, signer, err := s.resolve(key.EncryptedKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse encrypted key: %w", err)
+		return "", err
 	}
 
-	// 2. Unwrap DEK from Vault
-	dek, err := s.vaultClient.UnwrapDEK(blob.DEKWrapped)
+	envelope, ok := signer.(*envelopeSigner)
+	if !ok {
+		return "", ErrNoRecoveryPhrase
+	}
+	entropy, err := envelope.entropy(record, key.KeyBinding)
+	if err != nil {
+		return "", err
+	}
+	defer ZeroBytes(entropy)
+	return MnemonicFromEntropy(entropy)
+}
+
+// HasRawKey reports whether DecryptPrivateKey can return the wallet's key.
+// Other wallets (Vault transit, HD) sign through SignTransaction only.
+func (s *WalletService) HasRawKey(encryptedKeyJSON string) (bool, error) {
+	record, signer, err := s.resolve(encryptedKeyJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unwrap DEK: %w", err)
+		return false, err
 	}
-	defer ZeroBytes(dek) // Clear DEK from memory
+	_, ok := signer.(*envelopeSigner)
+	return ok && record.KeyType == KeyTypeEd25519, nil
+}
 
-	// 3. Decrypt private key with DEK
-	privateKeyHexBytes, err := DecryptWithDEK(blob, dek)
+// SignerOf returns the signer a wallet's key record was created with
+func (s *WalletService) SignerOf(encryptedKeyJSON string) (string, error) {
+	record, _, err := s.resolve(encryptedKeyJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+		return "", err
 	}
-	defer ZeroBytes(privateKeyHexBytes) // Clear plaintext from memory
+	if record.Signer == "" {
+		return SignerEnvelope, nil
+	}
+	return record.Signer, nil
+}
 
-	// 4. Convert hex to private key
-	privateKey, err := HexToPrivateKey(string(privateKeyHexBytes))
+// DecryptPrivateKey decrypts a wallet's private key. Fails with
+// ErrKeyNotExportable for wallets whose key lives in Vault or is derived
+// from a recovery phrase.
+func (s *WalletService) DecryptPrivateKey(key WalletKey) (ed25519.PrivateKey, error) {
+	record, signer, err := s.resolve(key.EncryptedKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
+		return nil, err
 	}
 
-	return privateKey, nil
+	envelope, ok := signer.(*envelopeSigner)
+	if !ok {
+		return nil, ErrKeyNotExportable
+	}
+	return envelope.decrypt(record, key.KeyBinding)
 }
 
-// SignTransaction signs a transaction with the decrypted private key
-func (s *WalletService) SignTransaction(encryptedKeyJSON string, txBody []byte) ([]byte, error) {
-	// 1. Decrypt private key
-	privateKey, err := s.DecryptPrivateKey(encryptedKeyJSON)
+// PublicKey returns a wallet's public key
+func (s *WalletService) PublicKey(key WalletKey) (ed25519.PublicKey, error) {
+	record, signer, err := s.resolve(key.EncryptedKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt key: %w", err)
+		return nil, err
 	}
-	defer ZeroBytes(privateKey) // Clear from memory
+	return signer.PublicKey(record, key.KeyBinding)
+}
 
-	// 2. Sign transaction
-	signature := SignMessage(privateKey, txBody)
+// SignTransaction signs a transaction body hash with the wallet's signer
+func (s *WalletService) SignTransaction(key WalletKey, txBody []byte) ([]byte, error) {
+	record, signer, err := s.resolve(key.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
 
+	signature, err := signer.Sign(record, key.KeyBinding, txBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
 	return signature, nil
 }
+
+// ErrKeyAddressMismatch is returned by BindKey for a key record that does not
+// control the wallet address it is stored with
+var ErrKeyAddressMismatch = errors.New("wallet key does not match the wallet address")
+
+// BindKey upgrades a version-1 envelope key record to version 2, bound to the
+// wallet's address and owner. Returns the record unchanged (and false) when
+// there is nothing to upgrade: already bound, or held in Vault transit.
+// A record whose key does not hash to the address's payment credential is
+// refused with ErrKeyAddressMismatch rather than bound to the wrong wallet.
+func (s *WalletService) BindKey(key WalletKey) (string, bool, error) {
+	record, signer, err := s.resolve(key.EncryptedKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	envelope, ok := signer.(*envelopeSigner)
+	if !ok || record.Version >= BlobVersionBound {
+		return key.EncryptedKey, false, nil
+	}
+
+	if err := checkKeyAddress(envelope, record, key); err != nil {
+		return "", false, err
+	}
+
+	bound, err := envelope.bind(record, key.KeyBinding)
+	if err != nil {
+		return "", false, err
+	}
+	boundJSON, err := EncryptedBlobToJSON(bound)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to serialize encrypted key: %w", err)
+	}
+	return boundJSON, true, nil
+}
+
+// checkKeyAddress checks that a key record's payment key is the one behind
+// the wallet address
+func checkKeyAddress(envelope *envelopeSigner, record *EncryptedBlob, key WalletKey) error {
+	address, _, err := decodeAddress(key.Address)
+	if err != nil {
+		return fmt.Errorf("invalid wallet address: %w", err)
+	}
+	addressKeyHash, err := address.PaymentKeyHash()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKeyAddressMismatch, err)
+	}
+
+	publicKey, err := envelope.PublicKey(record, key.KeyBinding)
+	if err != nil {
+		return err
+	}
+	keyHash, err := KeyHash(publicKey)
+	if err != nil {
+		return err
+	}
+	if !SecureCompare(keyHash, addressKeyHash) {
+		return ErrKeyAddressMismatch
+	}
+	return nil
+}
+
+// resolve parses a key record and picks the signer it was created with
+func (s *WalletService) resolve(encryptedKeyJSON string) (*EncryptedBlob, Signer, error) {
+	record, err := JSONToEncryptedBlob(encryptedKeyJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse encrypted key: %w", err)
+	}
+
+	name := record.Signer
+	if name == "" {
+		name = SignerEnvelope
+	}
+	signer, ok := s.signers[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown wallet signer %q", name)
+	}
+	return record, signer, nil
+}
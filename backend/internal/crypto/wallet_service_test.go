@@ -1,22 +1,29 @@
 package crypto
 
 import (
+	"crypto/ed25519"
+	"strings"
 	"testing"
 
+	"github.com/tyler-smith/go-bip39"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestWalletService_CreateWallet(t *testing.T) {
-	// This test requires a running Vault instance
-	// Skip if Vault is not available
-	vaultAddr := "http://localhost:8200"
-	vaultToken := "dev-only-token"
-	transitKey := "lcn-transit-key"
+func newLocalWalletService(t *testing.T) *WalletService {
+	key, err := GenerateDEK()
+	require.NoError(t, err)
+	vaultClient, err := NewLocalKeyClient(key)
+	require.NoError(t, err)
 
-	vaultClient := NewVaultClient(vaultAddr, vaultToken, transitKey)
+	walletService, err := NewWalletService(vaultClient, SignerEnvelope)
+	require.NoError(t, err)
+	return walletService
+}
 
-	walletService := NewWalletService(vaultClient)
+func TestWalletService_CreateWallet(t *testing.T) {
+	walletService := newLocalWalletService(t)
 
 	result, err := walletService.CreateWallet("testnet")
 	require.NoError(t, err)
@@ -29,23 +36,148 @@ func TestWalletService_CreateWallet(t *testing.T) {
 }
 
 func TestWalletService_DecryptPrivateKey(t *testing.T) {
-	vaultAddr := "http://localhost:8200"
-	vaultToken := "dev-only-token"
-	transitKey := "lcn-transit-key"
-
-	vaultClient := NewVaultClient(vaultAddr, vaultToken, transitKey)
-
-	walletService := NewWalletService(vaultClient)
+	walletService := newLocalWalletService(t)
 
 	// Create a wallet first
 	result, err := walletService.CreateWallet("testnet")
 	require.NoError(t, err)
 
 	// Decrypt the private key
-	privKey, err := walletService.DecryptPrivateKey(result.EncryptedPrivKey)
+	privKey, err := walletService.DecryptPrivateKey(WalletKey{EncryptedKey: result.EncryptedPrivKey})
+	require.NoError(t, err)
+
+	// Ed25519 private key (seed + public key) matching the wallet
+	assert.Len(t, privKey, 64)
+	assert.Equal(t, result.PubKeyHex, PublicKeyToHex(DerivePublicKey(privKey)))
+}
+
+func TestNewWalletService_TransitSignerRequiresVault(t *testing.T) {
+	key, _ := GenerateDEK()
+	vaultClient, err := NewLocalKeyClient(key)
+	require.NoError(t, err)
+
+	_, err = NewWalletService(vaultClient, SignerVaultTransit)
+	assert.Error(t, err)
+}
+
+func TestWalletService_BindKey(t *testing.T) {
+	walletService := newLocalWalletService(t)
+
+	result, err := walletService.CreateWallet("testnet")
+	require.NoError(t, err)
+	binding := KeyBinding{Address: result.Address, OwnerID: "65f0c0ffee0000000000a001"}
+
+	bound, upgraded, err := walletService.BindKey(WalletKey{KeyBinding: binding, EncryptedKey: result.EncryptedPrivKey})
+	require.NoError(t, err)
+	assert.True(t, upgraded)
+	record, err := JSONToEncryptedBlob(bound)
+	require.NoError(t, err)
+	assert.Equal(t, BlobVersionBound, record.Version)
+
+	// Binding again is a no-op
+	again, upgraded, err := walletService.BindKey(WalletKey{KeyBinding: binding, EncryptedKey: bound})
+	require.NoError(t, err)
+	assert.False(t, upgraded)
+	assert.Equal(t, bound, again)
+
+	// The bound key decrypts for its own wallet only
+	privKey, err := walletService.DecryptPrivateKey(WalletKey{KeyBinding: binding, EncryptedKey: bound})
+	require.NoError(t, err)
+	assert.Equal(t, result.PubKeyHex, PublicKeyToHex(DerivePublicKey(privKey)))
+
+	_, err = walletService.DecryptPrivateKey(WalletKey{
+		KeyBinding:   KeyBinding{Address: result.Address, OwnerID: "65f0c0ffee0000000000a002"},
+		EncryptedKey: bound,
+	})
+	assert.Error(t, err)
+	_, err = walletService.DecryptPrivateKey(WalletKey{
+		KeyBinding:   KeyBinding{Address: "addr_test1other", OwnerID: binding.OwnerID},
+		EncryptedKey: bound,
+	})
+	assert.Error(t, err)
+	_, err = walletService.DecryptPrivateKey(WalletKey{EncryptedKey: bound})
+	assert.Error(t, err)
+}
+
+func TestWalletService_BindKeyRefusesMismatchedAddress(t *testing.T) {
+	walletService := newLocalWalletService(t)
+
+	result, err := walletService.CreateWallet("testnet")
+	require.NoError(t, err)
+	other, err := walletService.CreateHDWallet("testnet")
+	require.NoError(t, err)
+	script, err := NewMultiSigScript(1, []string{strings.Repeat("ab", 28)})
+	require.NoError(t, err)
+	scriptAddress, err := script.Address(0x00)
+	require.NoError(t, err)
+
+	for _, address := range []string{other.Address, scriptAddress} {
+		_, upgraded, err := walletService.BindKey(WalletKey{
+			KeyBinding:   KeyBinding{Address: address, OwnerID: "65f0c0ffee0000000000a001"},
+			EncryptedKey: result.EncryptedPrivKey,
+		})
+		assert.ErrorIs(t, err, ErrKeyAddressMismatch, address)
+		assert.False(t, upgraded)
+	}
+
+	// Each key still binds to its own wallet
+	for _, wallet := range []*WalletResult{result, other} {
+		_, upgraded, err := walletService.BindKey(WalletKey{
+			KeyBinding:   KeyBinding{Address: wallet.Address, OwnerID: "65f0c0ffee0000000000a001"},
+			EncryptedKey: wallet.EncryptedPrivKey,
+		})
+		require.NoError(t, err)
+		assert.True(t, upgraded)
+	}
+}
+
+func TestWalletService_CreateHDWallet(t *testing.T) {
+	walletService := newLocalWalletService(t)
+
+	result, err := walletService.CreateHDWallet("testnet")
+	require.NoError(t, err)
+	assert.Equal(t, KeyTypeHD, result.KeyType)
+	assert.True(t, strings.HasPrefix(result.Address, "addr_test1q"), "expected a base address")
+
+	key := WalletKey{KeyBinding: KeyBinding{Address: result.Address, OwnerID: "customer-1"}, EncryptedKey: result.EncryptedPrivKey}
+
+	// Signs with the derived payment key; no raw key is exposed
+	hasRawKey, err := walletService.HasRawKey(key.EncryptedKey)
+	require.NoError(t, err)
+	assert.False(t, hasRawKey)
+	_, err = walletService.DecryptPrivateKey(key)
+	assert.ErrorIs(t, err, ErrKeyNotExportable)
+
+	publicKey, err := walletService.PublicKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, result.PubKeyHex, PublicKeyToHex(publicKey))
+	message := make([]byte, 32)
+	signature, err := walletService.SignTransaction(key, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(publicKey, message, signature))
+
+	// The recovery phrase restores the same address, also after binding
+	bound, upgraded, err := walletService.BindKey(key)
+	require.NoError(t, err)
+	assert.True(t, upgraded)
+	key.EncryptedKey = bound
+
+	mnemonic, err := walletService.ExportMnemonic(key)
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	require.NoError(t, err)
+	address, err := HDWalletFromEntropy(entropy).BaseAddress(0x00)
+	require.NoError(t, err)
+	assert.Equal(t, result.Address, address)
+}
+
+func TestWalletService_ExportMnemonicStandaloneKey(t *testing.T) {
+	walletService := newLocalWalletService(t)
+
+	result, err := walletService.CreateWallet("testnet")
 	require.NoError(t, err)
-	assert.NotEmpty(t, privKey)
 
-	// Verify it's a valid hex string (128 chars for Ed25519)
-	assert.Equal(t, 128, len(privKey))
+	_, err = walletService.ExportMnemonic(WalletKey{EncryptedKey: result.EncryptedPrivKey})
+	assert.ErrorIs(t, err, ErrNoRecoveryPhrase)
 }
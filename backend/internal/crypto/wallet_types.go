@@ -1,8 +1,33 @@
 package crypto
 
+import "fmt"
+
 type WalletResult struct {
 	Address          string
 	EncryptedPrivKey string
 	WrappedDEK       string
 	PubKeyHex        string
+	Signer           string
+	KeyType          string // KeyTypeHD for wallets with a recovery phrase
+}
+
+// KeyBinding names the wallet record a key belongs to. Version-2 envelope
+// keys authenticate it as GCM associated data, so a key record copied onto
+// another wallet or account no longer decrypts.
+type KeyBinding struct {
+	Address string
+	OwnerID string // ID of the merchant or customer account
+}
+
+func (b KeyBinding) associatedData() ([]byte, error) {
+	if b.Address == "" || b.OwnerID == "" {
+		return nil, fmt.Errorf("wallet address and owner ID are required")
+	}
+	return []byte("loyalcoin/wallet-key/v2\x00" + b.Address + "\x00" + b.OwnerID), nil
+}
+
+// WalletKey is a wallet's stored key record with the wallet it is bound to
+type WalletKey struct {
+	KeyBinding
+	EncryptedKey string
 }
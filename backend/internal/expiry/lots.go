@@ -0,0 +1,39 @@
+package expiry
+
+import (
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+// AllocateBalance sets RemainingLCN on a customer's lots, given oldest first,
+// from the LCN their wallet holds. Customers spend their oldest LCN first, so
+// the balance is what is left of the newest lots. Lots that never expire,
+// such as LCN received from other customers, take their place in the order
+// like any other. Balance beyond the lots (LCN received before lots were
+// tracked) counts as older than all of them.
+func AllocateBalance(lots []*models.LCNLot, balance uint64) {
+	for i := len(lots) - 1; i >= 0; i-- {
+		remaining := lots[i].AmountLCN
+		if remaining > balance {
+			remaining = balance
+		}
+		lots[i].RemainingLCN = remaining
+		balance -= remaining
+	}
+}
+
+// ExpiryDate returns when LCN issued at issuedAt expires under an expiry
+// window of months, or nil when it never expires
+func ExpiryDate(issuedAt time.Time, months int) *time.Time {
+	if months <= 0 {
+		return nil
+	}
+	expiresAt := issuedAt.AddDate(0, months, 0)
+	return &expiresAt
+}
+
+// expired reports whether a lot had expired by now
+func expired(lot *models.LCNLot, now time.Time) bool {
+	return lot.ExpiresAt != nil && !lot.ExpiresAt.After(now)
+}
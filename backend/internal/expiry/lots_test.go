@@ -0,0 +1,100 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func lots(amounts ...uint64) []*models.LCNLot {
+	result := make([]*models.LCNLot, len(amounts))
+	for i, amount := range amounts {
+		result[i] = &models.LCNLot{AmountLCN: amount}
+	}
+	return result
+}
+
+func remaining(lots []*models.LCNLot) []uint64 {
+	result := make([]uint64, len(lots))
+	for i, lot := range lots {
+		result[i] = lot.RemainingLCN
+	}
+	return result
+}
+
+func TestAllocateBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []uint64
+		balance uint64
+		want    []uint64
+	}{
+		{"nothing spent", []uint64{100, 50, 30}, 180, []uint64{100, 50, 30}},
+		// 120 LCN spent: all of the oldest lot and 20 of the next
+		{"oldest spent first", []uint64{100, 50, 30}, 60, []uint64{0, 30, 30}},
+		{"all spent", []uint64{100, 50}, 0, []uint64{0, 0}},
+		// LCN held before lots were tracked is spent before any lot
+		{"untracked balance", []uint64{100, 50}, 400, []uint64{100, 50}},
+		{"no lots", nil, 50, []uint64{}},
+	}
+	for _, tt := range tests {
+		l := lots(tt.amounts...)
+		AllocateBalance(l, tt.balance)
+		got := remaining(l)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAllocateBalance_ReceivedLCN(t *testing.T) {
+	expiresAt := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	issued := &models.LCNLot{MerchantID: "m1", AmountLCN: 100, ExpiresAt: &expiresAt}
+	gift := &models.LCNLot{Source: models.TxTypeTransfer, AmountLCN: 100}
+
+	// The customer spent 100 LCN after receiving the gift: it came out of the
+	// older issued lot, so nothing is left to expire
+	AllocateBalance([]*models.LCNLot{issued, gift}, 100)
+	if issued.RemainingLCN != 0 || gift.RemainingLCN != 100 {
+		t.Errorf("got issued %d, gift %d; want 0, 100", issued.RemainingLCN, gift.RemainingLCN)
+	}
+	if expired(gift, expiresAt.AddDate(10, 0, 0)) {
+		t.Error("received LCN expired")
+	}
+
+	// A gift received before the issuance is spent first
+	AllocateBalance([]*models.LCNLot{gift, issued}, 150)
+	if gift.RemainingLCN != 50 || issued.RemainingLCN != 100 {
+		t.Errorf("got gift %d, issued %d; want 50, 100", gift.RemainingLCN, issued.RemainingLCN)
+	}
+}
+
+func TestExpiryDate(t *testing.T) {
+	issuedAt := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	if got := ExpiryDate(issuedAt, 0); got != nil {
+		t.Errorf("no expiry window: got %v, want nil", got)
+	}
+	got := ExpiryDate(issuedAt, 12)
+	if want := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC); got == nil || !got.Equal(want) {
+		t.Errorf("12 months: got %v, want %v", got, want)
+	}
+
+	lot := &models.LCNLot{ExpiresAt: got}
+	if expired(lot, got.Add(-time.Second)) {
+		t.Error("lot expired before its expiry date")
+	}
+	if !expired(lot, *got) {
+		t.Error("lot not expired on its expiry date")
+	}
+	if expired(&models.LCNLot{}, *got) {
+		t.Error("lot without expiry date expired")
+	}
+}
@@ -0,0 +1,452 @@
+// Package expiry tracks the LCN lots merchants issue to customers and
+// returns what is left of them once they expire.
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Where expired LCN goes
+const (
+	ReturnToIssuer     = "issuer"
+	ReturnToGovernance = "governance"
+)
+
+// How long a sweep holds the lots it is expiring
+const claimTTL = time.Hour
+
+type Config struct {
+	SweepInterval time.Duration
+	BatchSize     int
+	DefaultMonths int           // platform expiry window; 0: LCN never expires
+	WarningPeriod time.Duration // how long before expiry customers are warned
+	ReturnTo      string        // issuer or governance
+}
+
+type Service struct {
+	config            *Config
+	cardanoService    *cardano.CardanoService
+	userRepo          *storage.UserRepository
+	txLogRepo         *storage.TxLogRepository
+	lotRepo           *storage.LotRepository
+	policyRepo        *storage.ExpiryPolicyRepository
+	governanceAddress string
+	stopCh            chan struct{}
+	stoppedCh         chan struct{}
+}
+
+func NewService(
+	config *Config,
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	lotRepo *storage.LotRepository,
+	policyRepo *storage.ExpiryPolicyRepository,
+	governanceAddress string,
+) (*Service, error) {
+	if config.ReturnTo != ReturnToIssuer && config.ReturnTo != ReturnToGovernance {
+		return nil, fmt.Errorf("unknown expiry return target %q (expected issuer or governance)", config.ReturnTo)
+	}
+	return &Service{
+		config:            config,
+		cardanoService:    cardanoService,
+		userRepo:          userRepo,
+		txLogRepo:         txLogRepo,
+		lotRepo:           lotRepo,
+		policyRepo:        policyRepo,
+		governanceAddress: governanceAddress,
+		stopCh:            make(chan struct{}),
+		stoppedCh:         make(chan struct{}),
+	}, nil
+}
+
+// Begins the expiry sweeps in the background
+func (s *Service) Start() {
+	logger.Info("Starting LCN expiry service", map[string]interface{}{
+		"sweep_interval": s.config.SweepInterval,
+		"default_months": s.config.DefaultMonths,
+		"return_to":      s.config.ReturnTo,
+	})
+
+	go s.run()
+}
+
+// Gracefully stops the expiry service
+func (s *Service) Stop() {
+	close(s.stopCh)
+	<-s.stoppedCh
+	logger.Info("LCN expiry service stopped", nil)
+}
+
+func (s *Service) run() {
+	defer close(s.stoppedCh)
+
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+
+	s.sweep()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// ExpiryMonths returns the expiry window of LCN a merchant issues, and
+// whether the merchant set it rather than the platform
+func (s *Service) ExpiryMonths(ctx context.Context, merchantID string) (int, bool, error) {
+	policy, err := s.policyRepo.GetExpiryPolicy(ctx, merchantID)
+	if errors.Is(err, storage.ErrExpiryPolicyNotFound) {
+		return s.config.DefaultMonths, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return policy.ExpiryMonths, true, nil
+}
+
+// DefaultMonths returns the platform expiry window
+func (s *Service) DefaultMonths() int {
+	return s.config.DefaultMonths
+}
+
+// WarningPeriod returns how long before expiry customers are warned
+func (s *Service) WarningPeriod() time.Duration {
+	return s.config.WarningPeriod
+}
+
+// RecordTransaction records the lot of a confirmed transaction into a
+// customer's custodial wallet: an issuance from a merchant expires under the
+// merchant's policy, a transfer from another customer or a referral reward
+// never expires. Other wallets are ignored: the platform cannot return LCN
+// from wallets it holds no key for.
+func (s *Service) RecordTransaction(ctx context.Context, tx *models.TxLog) {
+	if tx.Type != models.TxTypeIssuance && tx.Type != models.TxTypeTransfer && tx.Type != models.TxTypeReferral {
+		return
+	}
+	customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, tx.ToAddress)
+	if err != nil || customer.Wallet.Custody != models.WalletCustodial {
+		return
+	}
+	if tx.Type != models.TxTypeIssuance {
+		s.recordInflow(ctx, customer, tx)
+		return
+	}
+	merchant, err := s.userRepo.GetMerchantByWalletAddress(ctx, tx.FromAddress)
+	if err != nil {
+		return
+	}
+
+	months, _, err := s.ExpiryMonths(ctx, merchant.ID)
+	if err != nil {
+		logger.Error("Failed to get expiry policy", err, map[string]interface{}{
+			"merchant_id": merchant.ID,
+			"tx_hash":     tx.TxHash,
+		})
+		return
+	}
+	lot := &models.LCNLot{
+		CustomerID:      customer.ID,
+		CustomerAddress: customer.Wallet.Address,
+		MerchantID:      merchant.ID,
+		MerchantAddress: merchant.Wallet.Address,
+		TxHash:          tx.TxHash,
+		AmountLCN:       tx.AmountLCN,
+		IssuedAt:        tx.SubmittedAt,
+		ExpiresAt:       ExpiryDate(tx.SubmittedAt, months),
+	}
+	if err := s.lotRepo.CreateLot(ctx, lot); err != nil {
+		logger.Error("Failed to record issued lot", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+		})
+	}
+}
+
+// recordInflow records LCN a customer received other than from a merchant
+// as a lot that never expires. Without it the LCN would count as older than
+// every lot, keeping older lots from being spent and expiring it with them.
+func (s *Service) recordInflow(ctx context.Context, customer *models.Customer, tx *models.TxLog) {
+	lot := &models.LCNLot{
+		CustomerID:      customer.ID,
+		CustomerAddress: customer.Wallet.Address,
+		Source:          tx.Type,
+		TxHash:          tx.TxHash,
+		AmountLCN:       tx.AmountLCN,
+		IssuedAt:        tx.SubmittedAt,
+	}
+	if err := s.lotRepo.CreateLot(ctx, lot); err != nil {
+		logger.Error("Failed to record received lot", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+		})
+	}
+}
+
+// HeldLots returns the lots in a customer's wallet, oldest first, with what
+// is left of each
+func (s *Service) HeldLots(ctx context.Context, customerAddress string) ([]*models.LCNLot, error) {
+	lots, err := s.lotRepo.GetHeldLots(ctx, customerAddress)
+	if err != nil || len(lots) == 0 {
+		return lots, err
+	}
+	balance, err := s.cardanoService.GetBalance(customerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	AllocateBalance(lots, balance.LCNAtomic)
+	return lots, nil
+}
+
+// Warns customers about upcoming expiries, then expires overdue lots
+func (s *Service) sweep() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if s.config.WarningPeriod > 0 {
+		addresses, err := s.lotRepo.CustomersToWarn(ctx, now, now.Add(s.config.WarningPeriod), s.config.BatchSize)
+		if err != nil {
+			logger.Error("Failed to find lots to warn about", err, nil)
+		}
+		for _, address := range addresses {
+			s.warnCustomer(ctx, address, now)
+		}
+	}
+
+	addresses, err := s.lotRepo.CustomersWithExpiredLots(ctx, now, s.config.BatchSize)
+	if err != nil {
+		logger.Error("Failed to find expired lots", err, nil)
+		return
+	}
+	for _, address := range addresses {
+		s.expireCustomer(ctx, address, now)
+	}
+}
+
+// settledLots returns a customer's held lots once nothing moves in or out of
+// their wallet, with what is left of each. Returns nil when the lots cannot
+// be settled now; lots of customers who took custody of their wallet are
+// released.
+func (s *Service) settledLots(ctx context.Context, address string) (*models.Customer, []*models.LCNLot) {
+	customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, address)
+	if err != nil && !errors.Is(err, storage.ErrCustomerNotFound) {
+		logger.Error("Failed to get lot holder", err, map[string]interface{}{
+			"address": address,
+		})
+		return nil, nil
+	}
+	lots, err := s.lotRepo.GetHeldLots(ctx, address)
+	if err != nil {
+		logger.Error("Failed to get held lots", err, map[string]interface{}{
+			"address": address,
+		})
+		return nil, nil
+	}
+	if customer == nil || customer.Wallet.Custody != models.WalletCustodial {
+		ids := make([]string, len(lots))
+		for i, lot := range lots {
+			ids[i] = lot.ID
+		}
+		if err := s.lotRepo.CloseLots(ctx, ids, models.LotReleased); err != nil {
+			logger.Error("Failed to release lots", err, map[string]interface{}{
+				"address": address,
+			})
+		}
+		return nil, nil
+	}
+
+	// The balance only matches the lots once every transaction is confirmed
+	pending, err := s.txLogRepo.HasPendingTransactions(ctx, address)
+	if err != nil {
+		logger.Error("Failed to check pending transactions", err, map[string]interface{}{
+			"address": address,
+		})
+		return nil, nil
+	}
+	if pending {
+		return nil, nil
+	}
+	balance, err := s.cardanoService.GetBalance(address)
+	if err != nil {
+		logger.Error("Failed to get customer balance", err, map[string]interface{}{
+			"address": address,
+		})
+		return nil, nil
+	}
+	AllocateBalance(lots, balance.LCNAtomic)
+	return customer, lots
+}
+
+// Warns a customer about the LCN in lots expiring within the warning period
+func (s *Service) warnCustomer(ctx context.Context, address string, now time.Time) {
+	customer, lots := s.settledLots(ctx, address)
+	if customer == nil {
+		return
+	}
+	until := now.Add(s.config.WarningPeriod)
+
+	var ids []string
+	var expiringLCN uint64
+	var firstExpiry time.Time
+	for _, lot := range lots {
+		if lot.Status != models.LotOpen || lot.WarnedAt != nil || lot.ExpiresAt == nil ||
+			!lot.ExpiresAt.After(now) || lot.ExpiresAt.After(until) {
+			continue
+		}
+		ids = append(ids, lot.ID)
+		if lot.RemainingLCN > 0 {
+			if expiringLCN == 0 {
+				firstExpiry = *lot.ExpiresAt
+			}
+			expiringLCN += lot.RemainingLCN
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.lotRepo.MarkLotsWarned(ctx, ids, now); err != nil {
+		logger.Error("Failed to mark lots warned", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		return
+	}
+	if expiringLCN > 0 {
+		s.notifyExpiryWarning(customer, expiringLCN, firstExpiry)
+	}
+}
+
+// Customers also see upcoming expiries through GET /customer/expiring
+func (s *Service) notifyExpiryWarning(customer *models.Customer, expiringLCN uint64, firstExpiry time.Time) {
+	logger.Info("LCN expiry warning", map[string]interface{}{
+		"customer_id":  customer.ID,
+		"expiring_lcn": expiringLCN,
+		"expires_at":   firstExpiry,
+	})
+}
+
+// Returns what is left of a customer's expired lots. Lots are returned to
+// one destination per sweep: the customer's next transaction waits for the
+// previous one to confirm.
+func (s *Service) expireCustomer(ctx context.Context, address string, now time.Time) {
+	customer, lots := s.settledLots(ctx, address)
+	if customer == nil {
+		return
+	}
+
+	var spent []string
+	var expiring []*models.LCNLot
+	returnAddress := ""
+	for _, lot := range lots {
+		if !expired(lot, now) || (lot.Status == models.LotExpiring && lot.ClaimedUntil != nil && lot.ClaimedUntil.After(now)) {
+			continue
+		}
+		if lot.RemainingLCN == 0 {
+			spent = append(spent, lot.ID)
+			continue
+		}
+		to := s.returnAddress(lot)
+		if returnAddress == "" {
+			returnAddress = to
+		}
+		if to == returnAddress {
+			expiring = append(expiring, lot)
+		}
+	}
+	if err := s.lotRepo.CloseLots(ctx, spent, models.LotConsumed); err != nil {
+		logger.Error("Failed to close spent lots", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+	}
+	if len(expiring) == 0 {
+		return
+	}
+
+	ids := make([]string, len(expiring))
+	var merchantIDs []string
+	var amountLCN uint64
+	for i, lot := range expiring {
+		ids[i] = lot.ID
+		if !slices.Contains(merchantIDs, lot.MerchantID) {
+			merchantIDs = append(merchantIDs, lot.MerchantID)
+		}
+		amountLCN += lot.RemainingLCN
+	}
+	claimedUntil := now.Add(claimTTL)
+	claimed, err := s.lotRepo.ClaimLots(ctx, ids, claimedUntil)
+	if err != nil {
+		logger.Error("Failed to claim expired lots", err, map[string]interface{}{
+			"customer_id": customer.ID,
+		})
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	txHash, err := s.cardanoService.TransferADAAs(
+		crypto.WalletKey{
+			KeyBinding:   crypto.KeyBinding{Address: customer.Wallet.Address, OwnerID: customer.ID},
+			EncryptedKey: customer.Wallet.EncryptedPrivateKey,
+		},
+		returnAddress,
+		amountLCN,
+		models.TxTypeExpiry,
+	)
+	if err != nil {
+		logger.Error("Failed to return expired LCN", err, map[string]interface{}{
+			"customer_id": customer.ID,
+			"amount_lcn":  amountLCN,
+		})
+		if err := s.lotRepo.UnclaimLots(ctx, ids, claimedUntil); err != nil {
+			logger.Error("Failed to release expired lots", err, map[string]interface{}{
+				"customer_id": customer.ID,
+			})
+		}
+		return
+	}
+
+	if err := s.txLogRepo.SetTxMeta(ctx, txHash, map[string]interface{}{
+		"returned_to":  s.config.ReturnTo,
+		"merchant_ids": merchantIDs,
+		"lot_ids":      ids,
+	}); err != nil {
+		logger.Warn("Failed to record expiry details", map[string]interface{}{
+			"tx_hash": txHash,
+			"error":   err.Error(),
+		})
+	}
+	for _, lot := range expiring {
+		if err := s.lotRepo.MarkLotExpired(ctx, lot.ID, lot.RemainingLCN, txHash); err != nil {
+			logger.Error("Failed to mark lot expired", err, map[string]interface{}{
+				"lot_id":  lot.ID,
+				"tx_hash": txHash,
+			})
+		}
+	}
+	logger.Audit("LCN_EXPIRED", customer.ID, map[string]interface{}{
+		"amount_lcn":  amountLCN,
+		"returned_to": s.config.ReturnTo,
+		"to_address":  returnAddress,
+		"lots":        len(expiring),
+		"tx_hash":     txHash,
+	})
+}
+
+func (s *Service) returnAddress(lot *models.LCNLot) string {
+	if s.config.ReturnTo == ReturnToGovernance {
+		return s.governanceAddress
+	}
+	return lot.MerchantAddress
+}
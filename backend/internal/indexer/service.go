@@ -6,8 +6,13 @@ import (
 	"time"
 
 	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/coalitions"
+	"github.com/loyalcoin/backend/internal/expiry"
 	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/referrals"
 	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/internal/tiers"
+	"github.com/loyalcoin/backend/internal/vouchers"
 	"github.com/loyalcoin/backend/pkg/logger"
 )
 
@@ -32,12 +37,20 @@ func DefaultConfig() *Config {
 }
 
 type Service struct {
-	config     *Config
-	blockfrost *cardano.BlockfrostClient
-	txLogRepo  *storage.TxLogRepository
-	userRepo   *storage.UserRepository
-	stopCh     chan struct{}
-	stoppedCh  chan struct{}
+	config             *Config
+	blockfrost         *cardano.BlockfrostClient
+	txLogRepo          *storage.TxLogRepository
+	userRepo           *storage.UserRepository
+	paymentRequestRepo *storage.PaymentRequestRepository
+	transferLimitRepo  *storage.TransferLimitRepository
+	earnLimitRepo      *storage.EarnLimitRepository
+	expiryService      *expiry.Service
+	tierService        *tiers.Service
+	referralService    *referrals.Service
+	voucherService     *vouchers.Service
+	coalitionService   *coalitions.Service
+	stopCh             chan struct{}
+	stoppedCh          chan struct{}
 }
 
 func NewService(
@@ -45,18 +58,34 @@ func NewService(
 	blockfrost *cardano.BlockfrostClient,
 	txLogRepo *storage.TxLogRepository,
 	userRepo *storage.UserRepository,
+	paymentRequestRepo *storage.PaymentRequestRepository,
+	transferLimitRepo *storage.TransferLimitRepository,
+	earnLimitRepo *storage.EarnLimitRepository,
+	expiryService *expiry.Service,
+	tierService *tiers.Service,
+	referralService *referrals.Service,
+	voucherService *vouchers.Service,
+	coalitionService *coalitions.Service,
 ) *Service {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
 	return &Service{
-		config:     config,
-		blockfrost: blockfrost,
-		txLogRepo:  txLogRepo,
-		userRepo:   userRepo,
-		stopCh:     make(chan struct{}),
-		stoppedCh:  make(chan struct{}),
+		config:             config,
+		blockfrost:         blockfrost,
+		txLogRepo:          txLogRepo,
+		userRepo:           userRepo,
+		paymentRequestRepo: paymentRequestRepo,
+		transferLimitRepo:  transferLimitRepo,
+		earnLimitRepo:      earnLimitRepo,
+		expiryService:      expiryService,
+		tierService:        tierService,
+		referralService:    referralService,
+		voucherService:     voucherService,
+		coalitionService:   coalitionService,
+		stopCh:             make(chan struct{}),
+		stoppedCh:          make(chan struct{}),
 	}
 }
 
@@ -86,11 +115,13 @@ func (s *Service) run() {
 	defer ticker.Stop()
 
 	s.processPendingTransactions()
+	s.syncVouchers()
 
 	for {
 		select {
 		case <-ticker.C:
 			s.processPendingTransactions()
+			s.syncVouchers()
 		case <-s.stopCh:
 			return
 		}
@@ -119,6 +150,11 @@ func (s *Service) processPendingTransactions() {
 	}
 }
 
+// Tracks who holds active vouchers on-chain and which were burned
+func (s *Service) syncVouchers() {
+	s.voucherService.SyncOwnership(context.Background(), s.config.BatchSize)
+}
+
 // Checks and updates a single transaction
 func (s *Service) processTransaction(ctx context.Context, tx *models.TxLog) {
 	// Query Blockfrost for transaction details
@@ -180,6 +216,26 @@ func (s *Service) markTransactionConfirmed(ctx context.Context, tx *models.TxLog
 		"block_height": details.BlockHeight,
 		"type":         tx.Type,
 	})
+	if paid, err := s.paymentRequestRepo.MarkPaidByTxHash(ctx, tx.TxHash); err != nil {
+		logger.Error("Failed to mark payment request paid", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+		})
+	} else if paid {
+		logger.Info("Payment request paid", map[string]interface{}{
+			"tx_hash": tx.TxHash,
+		})
+	}
+	// Confirmed issuances become lots that expire under the merchant's policy;
+	// transfers and referral rewards become lots that never expire
+	s.expiryService.RecordTransaction(ctx, tx)
+	// Earning and redeeming move customers up (or down) the tiers
+	s.tierService.RecordTransaction(ctx, tx)
+	// A referee's first qualifying transaction pays out their referral
+	s.referralService.RecordTransaction(ctx, tx)
+	// Confirmed mints activate vouchers, confirmed burns redeem them
+	s.voucherService.RecordTransaction(ctx, tx)
+	// LCN redeemed at a coalition member is owed to it by the issuing members
+	s.coalitionService.RecordTransaction(ctx, tx)
 	if s.config.EnableNotifications {
 		s.notifyTransactionConfirmed(ctx, tx)
 	}
@@ -204,6 +260,32 @@ func (s *Service) markTransactionFailed(ctx context.Context, tx *models.TxLog, r
 		"tx_hash": tx.TxHash,
 		"reason":  reason,
 	})
+	// A payment request paid by a failed transaction can be paid again
+	if _, err := s.paymentRequestRepo.ReopenByTxHash(ctx, tx.TxHash); err != nil {
+		logger.Error("Failed to reopen payment request", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+		})
+	}
+	// A failed transfer no longer counts towards the sender's daily limits
+	if tx.Type == models.TxTypeTransfer {
+		day := tx.SubmittedAt.UTC().Truncate(24 * time.Hour)
+		if err := s.transferLimitRepo.ReleaseDailyTransfer(ctx, tx.FromAddress, day, tx.AmountLCN); err != nil {
+			logger.Error("Failed to release daily transfer", err, map[string]interface{}{
+				"tx_hash": tx.TxHash,
+			})
+		}
+	}
+	// A failed reward no longer counts towards the customer's daily earn cap
+	if baseLCN := tx.MetaLCN("base_lcn"); baseLCN > 0 && tx.Meta["earn_rule_version"] != nil {
+		day := tx.SubmittedAt.UTC().Truncate(24 * time.Hour)
+		if err := s.earnLimitRepo.ReleaseDailyEarn(ctx, tx.FromAddress, tx.ToAddress, day, baseLCN); err != nil {
+			logger.Error("Failed to release daily earnings", err, map[string]interface{}{
+				"tx_hash": tx.TxHash,
+			})
+		}
+	}
+	// A voucher whose burn failed can be redeemed again
+	s.voucherService.RecordFailure(ctx, tx, reason)
 	if s.config.EnableNotifications {
 		s.notifyTransactionFailed(ctx, tx, reason)
 	}
@@ -5,9 +5,58 @@ import "time"
 type Role string
 
 const (
-	RoleAdmin    Role = "ADMIN"
-	RoleMerchant Role = "MERCHANT"
-	RoleCustomer Role = "CUSTOMER"
+	RoleAdmin           Role = "ADMIN"
+	RoleMerchant        Role = "MERCHANT"
+	RoleCustomer        Role = "CUSTOMER"
+	RoleMerchantManager Role = "MERCHANT_MANAGER"
+	RoleMerchantCashier Role = "MERCHANT_CASHIER"
+	RoleFinanceOfficer  Role = "FINANCE_OFFICER"
+	RoleAuditor         Role = "AUDITOR"
+)
+
+// Named permission checked by RequirePermission
+type Permission string
+
+const (
+	// Platform (admin) permissions
+	PermAllocationApprove Permission = "allocation:approve"
+	PermSettlementApprove Permission = "settlement:approve"
+	PermReserveRead       Permission = "reserve:read"
+	PermUsersManage       Permission = "users:manage"
+	PermReferralsReview   Permission = "referrals:review"
+	PermRefundsOverride   Permission = "refunds:override"
+	PermCoalitionsManage  Permission = "coalitions:manage"
+
+	// Merchant permissions
+	PermLCNIssue          Permission = "lcn:issue"
+	PermAllocationRequest Permission = "allocation:request"
+	PermSettlementRequest Permission = "settlement:request"
+	PermAPIKeysManage     Permission = "apikeys:manage"
+	PermStaffManage       Permission = "staff:manage"
+	PermPaymentsRequest   Permission = "payments:request"
+	PermRewardsManage     Permission = "rewards:manage"
+	PermOrdersFulfill     Permission = "orders:fulfill"
+	PermLCNRefund         Permission = "lcn:refund"
+	PermVouchersIssue     Permission = "vouchers:issue"
+	PermVouchersRedeem    Permission = "vouchers:redeem"
+
+	// Customer permissions
+	PermLCNRedeem    Permission = "lcn:redeem"
+	PermLCNTransfer  Permission = "lcn:transfer"
+	PermWalletExport Permission = "wallet:export"
+	PermWalletManage Permission = "wallet:manage"
+
+	// Shared permissions
+	PermWalletRead Permission = "wallet:read"
+)
+
+// Kind of account a role can be assigned to
+type RoleScope string
+
+const (
+	RoleScopePlatform RoleScope = "PLATFORM"
+	RoleScopeMerchant RoleScope = "MERCHANT"
+	RoleScopeCustomer RoleScope = "CUSTOMER"
 )
 
 type UserStatus string
@@ -21,11 +70,17 @@ const (
 type TxType string
 
 const (
-	TxTypeIssuance   TxType = "ISSUANCE"
-	TxTypeRedemption TxType = "REDEMPTION"
-	TxTypeAllocation TxType = "ALLOCATION"
-	TxTypeMint       TxType = "MINT"
-	TxTypeSettlement TxType = "SETTLEMENT"
+	TxTypeIssuance    TxType = "ISSUANCE"
+	TxTypeRedemption  TxType = "REDEMPTION"
+	TxTypeAllocation  TxType = "ALLOCATION"
+	TxTypeMint        TxType = "MINT"
+	TxTypeSettlement  TxType = "SETTLEMENT"
+	TxTypeExpiry      TxType = "EXPIRY"
+	TxTypeReferral    TxType = "REFERRAL"
+	TxTypeTransfer    TxType = "TRANSFER"     // customer to customer
+	TxTypeRefund      TxType = "REFUND"       // meta.refund_of links the refunded transaction
+	TxTypeVoucherMint TxType = "VOUCHER_MINT" // meta.voucher_id; the amount is the NFT's ADA deposit
+	TxTypeVoucherBurn TxType = "VOUCHER_BURN"
 )
 
 type TxStatus string
@@ -46,12 +101,37 @@ const (
 	SettlementRejected   SettlementStatus = "REJECTED"
 )
 
+// What a settlement pays out
+type SettlementKind string
+
+const (
+	SettlementPayout   SettlementKind = ""         // merchant's LCN → ETB cash-out
+	SettlementClearing SettlementKind = "CLEARING" // coalition netting: LCN from one member to another
+)
+
+// Who holds a wallet's key
+type WalletCustody string
+
+const (
+	WalletCustodial WalletCustody = ""         // key held (encrypted) by the backend
+	WalletExternal  WalletCustody = "EXTERNAL" // customer's own CIP-30 wallet; signs client-side
+)
+
 // Cardano wallet
 type Wallet struct {
 	Address             string    `bson:"address" json:"address"`
 	EncryptedPrivateKey string    `bson:"encrypted_private_key" json:"-"`
 	PubKeyHex           string    `bson:"pub_key_hex,omitempty" json:"pub_key_hex,omitempty"`
+	Signer              string    `bson:"signer,omitempty" json:"signer,omitempty"`     // envelope (default) or vault-transit
+	KeyType             string    `bson:"key_type,omitempty" json:"key_type,omitempty"` // "hd" for CIP-1852 wallets with a recovery phrase
 	CreatedAt           time.Time `bson:"created_at" json:"created_at"`
+
+	// Set when the customer exported the recovery phrase (allowed once)
+	RecoveryPhraseExportedAt *time.Time `bson:"recovery_phrase_exported_at,omitempty" json:"recovery_phrase_exported_at,omitempty"`
+
+	// External wallets store no key, only the payment key hash witnesses must match
+	Custody        WalletCustody `bson:"custody,omitempty" json:"custody,omitempty"`
+	PaymentKeyHash string        `bson:"payment_key_hash,omitempty" json:"payment_key_hash,omitempty"`
 }
 
 // Merchant Bank Account Details
@@ -74,20 +154,496 @@ type Merchant struct {
 	BalanceLCN    uint64      `bson:"balance_lcn" json:"balance_lcn"`
 	BankAccount   BankAccount `bson:"bank_account" json:"bank_account"`
 	Status        UserStatus  `bson:"status" json:"status"`
+	ReferralCode  string      `bson:"referral_code,omitempty" json:"referral_code,omitempty"`
 	CreatedAt     time.Time   `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time   `bson:"updated_at" json:"updated_at"`
 }
 
+// Merchant sub-user (cashier, manager) acting on behalf of one business
+type MerchantStaff struct {
+	ID           string     `bson:"_id,omitempty" json:"id"`
+	MerchantID   string     `bson:"merchant_id" json:"merchant_id"`
+	Name         string     `bson:"name" json:"name"`
+	Email        string     `bson:"email" json:"email"`
+	PasswordHash string     `bson:"password_hash" json:"-"`
+	Role         Role       `bson:"role" json:"role"`
+	Status       UserStatus `bson:"status" json:"status"`
+	CreatedBy    string     `bson:"created_by" json:"created_by"`
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
 // Customer Details
 type Customer struct {
 	ID           string    `bson:"_id,omitempty" json:"id"`
 	Username     string    `bson:"username" json:"username"`
 	Email        string    `bson:"email" json:"email"`
 	Phone        string    `bson:"phone,omitempty" json:"phone,omitempty"`
+	Handle       string    `bson:"handle,omitempty" json:"handle,omitempty"` // short ID shown as a QR code for merchants to scan
+	ReferralCode string    `bson:"referral_code,omitempty" json:"referral_code,omitempty"`
 	PasswordHash string    `bson:"password_hash" json:"-"`
 	Wallet       Wallet    `bson:"wallet" json:"wallet"`
+	Loyalty      *Loyalty  `bson:"loyalty,omitempty" json:"loyalty,omitempty"` // nil until the tier is first evaluated
 	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+
+	// Set once the customer entered a code sent to the email or phone
+	EmailVerified bool `bson:"email_verified,omitempty" json:"email_verified"`
+	PhoneVerified bool `bson:"phone_verified,omitempty" json:"phone_verified"`
+
+	// Pending proof of control for an external wallet registration
+	WalletChallenge *WalletChallenge `bson:"wallet_challenge,omitempty" json:"-"`
+	// One-time code sent to verify the email or phone
+	ContactVerification *ContactVerification `bson:"contact_verification,omitempty" json:"-"`
+}
+
+// One-time code sent to a customer's email or phone. Single use; the code
+// is stored hashed.
+type ContactVerification struct {
+	Channel   string    `bson:"channel"` // email or phone
+	Contact   string    `bson:"contact"` // the email or phone the code was sent to
+	CodeHash  string    `bson:"code_hash"`
+	Attempts  int       `bson:"attempts"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Message a customer's CIP-30 wallet must sign (signData) to register its
+// address as their external wallet. Single use.
+type WalletChallenge struct {
+	Address   string    `bson:"address" json:"address"`
+	Message   string    `bson:"message" json:"message"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// Customer's loyalty tier, from the LCN they earned and redeemed over the
+// rolling tier window
+type Loyalty struct {
+	Tier        string    `bson:"tier" json:"tier"`
+	EarnedLCN   uint64    `bson:"earned_lcn" json:"earned_lcn"`
+	RedeemedLCN uint64    `bson:"redeemed_lcn" json:"redeemed_lcn"`
+	TierSince   time.Time `bson:"tier_since" json:"tier_since"`
+	EvaluatedAt time.Time `bson:"evaluated_at" json:"evaluated_at"`
+}
+
+// QualifyingLCN is what counts towards a tier
+func (l *Loyalty) QualifyingLCN() uint64 {
+	return l.EarnedLCN + l.RedeemedLCN
+}
+
+type ReferralStatus string
+
+const (
+	ReferralPending  ReferralStatus = "PENDING" // waiting for the referee's first qualifying transaction
+	ReferralReview   ReferralStatus = "REVIEW"  // qualified, waiting for an admin to approve the payout
+	ReferralPaying   ReferralStatus = "PAYING"
+	ReferralPaid     ReferralStatus = "PAID"
+	ReferralRejected ReferralStatus = "REJECTED"
+	ReferralFailed   ReferralStatus = "FAILED" // payout failed; an admin may retry it
+)
+
+// Account on either side of a referral
+type ReferralParty struct {
+	ID      string `bson:"id" json:"id"`
+	Role    Role   `bson:"role" json:"role"`
+	Name    string `bson:"name" json:"name"` // username or business name
+	Address string `bson:"address" json:"address"`
+}
+
+// Signup attributed to a referral code. Both sides are rewarded from the
+// governance wallet once the referee completes a qualifying transaction.
+type Referral struct {
+	ID                string         `bson:"_id,omitempty" json:"id"`
+	Code              string         `bson:"code" json:"code"`
+	Referrer          ReferralParty  `bson:"referrer" json:"referrer"`
+	Referee           ReferralParty  `bson:"referee" json:"referee"`
+	SignupIP          string         `bson:"signup_ip,omitempty" json:"signup_ip,omitempty"`
+	DeviceID          string         `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	Flags             []string       `bson:"flags,omitempty" json:"flags,omitempty"` // fraud heuristics that sent it to review
+	Status            ReferralStatus `bson:"status" json:"status"`
+	ReferrerRewardLCN uint64         `bson:"referrer_reward_lcn" json:"referrer_reward_lcn"`
+	RefereeRewardLCN  uint64         `bson:"referee_reward_lcn" json:"referee_reward_lcn"`
+	QualifyingTxHash  string         `bson:"qualifying_tx_hash,omitempty" json:"qualifying_tx_hash,omitempty"`
+	QualifiedAt       *time.Time     `bson:"qualified_at,omitempty" json:"qualified_at,omitempty"`
+	ReferrerTxHash    string         `bson:"referrer_tx_hash,omitempty" json:"referrer_tx_hash,omitempty"`
+	RefereeTxHash     string         `bson:"referee_tx_hash,omitempty" json:"referee_tx_hash,omitempty"`
+	PaidAt            *time.Time     `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	Error             string         `bson:"error,omitempty" json:"error,omitempty"`
+	ReviewedBy        string         `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewNotes       string         `bson:"review_notes,omitempty" json:"review_notes,omitempty"`
+	ReviewedAt        *time.Time     `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	CreatedAt         time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+type PendingRewardStatus string
+
+const (
+	PendingRewardPending   PendingRewardStatus = "PENDING"
+	PendingRewardClaiming  PendingRewardStatus = "CLAIMING"
+	PendingRewardClaimed   PendingRewardStatus = "CLAIMED"
+	PendingRewardFailed    PendingRewardStatus = "FAILED"
+	PendingRewardCancelled PendingRewardStatus = "CANCELLED"
+)
+
+// LCN a merchant issued to an email or phone number with no account yet. It
+// is transferred from the merchant's wallet when the customer signs up.
+type PendingReward struct {
+	ID             string              `bson:"_id,omitempty" json:"id"`
+	MerchantID     string              `bson:"merchant_id" json:"merchant_id"`
+	IssuedBy       string              `bson:"issued_by" json:"issued_by"`
+	IdentifierType string              `bson:"identifier_type" json:"identifier_type"` // email or phone
+	Identifier     string              `bson:"identifier" json:"identifier"`           // normalized
+	AmountLCN      uint64              `bson:"amount_lcn" json:"amount_lcn"`
+	Reference      string              `bson:"reference,omitempty" json:"reference,omitempty"`
+	Status         PendingRewardStatus `bson:"status" json:"status"`
+	CustomerID     string              `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	TxHash         string              `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
+	Error          string              `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	ExpiresAt      time.Time           `bson:"expires_at" json:"expires_at"`
+	ClaimedAt      *time.Time          `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+}
+
+// Merchant earn rule: how purchases convert to LCN rewards. Rules are never
+// edited in place; every change is saved as the next version, and the latest
+// version is the one in effect. Issuances record the version they used.
+type EarnRule struct {
+	ID                  string             `bson:"_id,omitempty" json:"id"`
+	MerchantID          string             `bson:"merchant_id" json:"merchant_id"`
+	Version             int                `bson:"version" json:"version"`
+	Enabled             bool               `bson:"enabled" json:"enabled"`
+	PercentOfSpend      float64            `bson:"percent_of_spend" json:"percent_of_spend"`                             // share of the ETB purchase value paid back in LCN
+	FixedPerVisit       uint64             `bson:"fixed_per_visit" json:"fixed_per_visit"`                               // LCN added to every qualifying purchase
+	CategoryMultipliers map[string]float64 `bson:"category_multipliers,omitempty" json:"category_multipliers,omitempty"` // applied to the percent reward of matching items
+	MinSpendETB         float64            `bson:"min_spend_etb" json:"min_spend_etb"`
+	DailyCapLCN         uint64             `bson:"daily_cap_lcn" json:"daily_cap_lcn"` // per customer per UTC day; 0 means no cap
+	CreatedBy           string             `bson:"created_by" json:"created_by"`
+	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Campaign status. SCHEDULED and ENDED are reported for active campaigns
+// outside their schedule and never stored.
+type CampaignStatus string
+
+const (
+	CampaignActive    CampaignStatus = "ACTIVE"
+	CampaignPaused    CampaignStatus = "PAUSED"
+	CampaignExhausted CampaignStatus = "EXHAUSTED" // budget spent; stopped automatically
+	CampaignCancelled CampaignStatus = "CANCELLED"
+	CampaignScheduled CampaignStatus = "SCHEDULED"
+	CampaignEnded     CampaignStatus = "ENDED"
+)
+
+// Campaign reward types
+const (
+	CampaignTypeMultiplier = "MULTIPLIER" // multiplies the earn rule reward ("double points")
+	CampaignTypeBonus      = "BONUS"      // fixed LCN on top of it ("50 LCN for a first visit")
+)
+
+// Time-boxed promotion applied by POST /lcn/earn. Bonuses are paid from the
+// merchant's wallet and counted against the campaign's budget.
+type Campaign struct {
+	ID         string         `bson:"_id,omitempty" json:"id"`
+	MerchantID string         `bson:"merchant_id" json:"merchant_id"`
+	Name       string         `bson:"name" json:"name"`
+	Type       string         `bson:"type" json:"type"`
+	Multiplier float64        `bson:"multiplier,omitempty" json:"multiplier,omitempty"`
+	BonusLCN   uint64         `bson:"bonus_lcn,omitempty" json:"bonus_lcn,omitempty"`
+	StartsAt   time.Time      `bson:"starts_at" json:"starts_at"`
+	EndsAt     time.Time      `bson:"ends_at" json:"ends_at"`
+	BudgetLCN  uint64         `bson:"budget_lcn" json:"budget_lcn"`
+	SpentLCN   uint64         `bson:"spent_lcn" json:"spent_lcn"`
+	Status     CampaignStatus `bson:"status" json:"status"`
+	// Eligibility
+	FirstVisitOnly bool     `bson:"first_visit_only" json:"first_visit_only"`
+	MinSpendETB    float64  `bson:"min_spend_etb" json:"min_spend_etb"`
+	Categories     []string `bson:"categories,omitempty" json:"categories,omitempty"` // purchase must include one of them
+	// Stackable campaigns combine with each other; an exclusive one only
+	// applies alone, when it beats the stackable campaigns together
+	Stackable bool      `bson:"stackable" json:"stackable"`
+	CreatedBy string    `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Campaign performance, aggregated from the transaction logs it tagged
+type CampaignStats struct {
+	Issuances   int64   `bson:"issuances" json:"issuances"`
+	Confirmed   int64   `bson:"confirmed" json:"confirmed"`
+	Customers   int64   `bson:"customers" json:"customers"`
+	BonusLCN    uint64  `bson:"bonus_lcn" json:"bonus_lcn"`
+	TotalLCN    uint64  `bson:"total_lcn" json:"total_lcn"` // whole rewards the campaign was part of
+	PurchaseETB float64 `bson:"purchase_etb" json:"purchase_etb"`
+}
+
+type LotStatus string
+
+const (
+	LotOpen     LotStatus = "OPEN"
+	LotExpiring LotStatus = "EXPIRING" // claimed by an expiry sweep while its transfer is submitted
+	LotExpired  LotStatus = "EXPIRED"
+	LotConsumed LotStatus = "CONSUMED" // spent before it expired
+	LotReleased LotStatus = "RELEASED" // the customer took custody of their wallet
+)
+
+// LCN a merchant issued to a customer, recorded when the issuance confirms.
+// A customer spends their oldest lots first; what is left of a lot when it
+// expires is returned by the expiry sweep. LCN received from another customer
+// or as a referral reward is recorded as a lot too, with no merchant and no
+// expiry, so that it takes its place in the order without being returned or
+// attributed to an issuer.
+type LCNLot struct {
+	ID              string     `bson:"_id,omitempty" json:"id"`
+	CustomerID      string     `bson:"customer_id" json:"customer_id"`
+	CustomerAddress string     `bson:"customer_address" json:"customer_address"`
+	MerchantID      string     `bson:"merchant_id,omitempty" json:"merchant_id,omitempty"` // empty: not issued by a merchant
+	MerchantAddress string     `bson:"merchant_address,omitempty" json:"merchant_address,omitempty"`
+	Source          TxType     `bson:"source,omitempty" json:"source,omitempty"` // TRANSFER or REFERRAL when not issued
+	TxHash          string     `bson:"tx_hash" json:"tx_hash"`
+	AmountLCN       uint64     `bson:"amount_lcn" json:"amount_lcn"`
+	IssuedAt        time.Time  `bson:"issued_at" json:"issued_at"`
+	ExpiresAt       *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // nil: never expires
+	Status          LotStatus  `bson:"status" json:"status"`
+	ClaimedUntil    *time.Time `bson:"claimed_until,omitempty" json:"-"`
+	WarnedAt        *time.Time `bson:"warned_at,omitempty" json:"warned_at,omitempty"`
+	ExpiredLCN      uint64     `bson:"expired_lcn,omitempty" json:"expired_lcn,omitempty"`
+	ExpiryTxHash    string     `bson:"expiry_tx_hash,omitempty" json:"expiry_tx_hash,omitempty"`
+	ClosedAt        *time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+
+	RemainingLCN uint64 `bson:"-" json:"remaining_lcn"` // derived from the wallet balance
+}
+
+// Merchant's own expiry window, overriding the platform default
+type ExpiryPolicy struct {
+	MerchantID   string    `bson:"_id" json:"merchant_id"`
+	ExpiryMonths int       `bson:"expiry_months" json:"expiry_months"` // 0: never expires
+	UpdatedBy    string    `bson:"updated_by" json:"updated_by"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Reward a merchant offers in its catalog, bought with LCN
+type CatalogItem struct {
+	ID           string    `bson:"_id,omitempty" json:"id"`
+	MerchantID   string    `bson:"merchant_id" json:"merchant_id"`
+	Name         string    `bson:"name" json:"name"`
+	Description  string    `bson:"description,omitempty" json:"description,omitempty"`
+	ImageURL     string    `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	PriceLCN     uint64    `bson:"price_lcn" json:"price_lcn"`
+	Stock        int64     `bson:"stock" json:"stock"`                 // units left to order
+	ValidityDays int       `bson:"validity_days" json:"validity_days"` // how long a redemption code can be picked up; 0: no limit
+	Active       bool      `bson:"active" json:"active"`
+	CreatedBy    string    `bson:"created_by" json:"created_by"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Redemption order status. EXPIRED is reported for ready orders past their
+// expiry and never stored.
+type OrderStatus string
+
+const (
+	OrderPlaced     OrderStatus = "PLACED" // stock reserved while the LCN is sent
+	OrderReady      OrderStatus = "READY"  // paid; the code can be picked up
+	OrderFulfilled  OrderStatus = "FULFILLED"
+	OrderCancelling OrderStatus = "CANCELLING" // claimed for cancellation while the refund is sent
+	OrderCancelled  OrderStatus = "CANCELLED"
+	OrderFailed     OrderStatus = "FAILED" // the LCN could not be sent
+	OrderExpired    OrderStatus = "EXPIRED"
+)
+
+// Customer's order of a catalog item. The customer shows the code at the
+// merchant, whose cashier validates it to hand the item over.
+type RedemptionOrder struct {
+	ID              string      `bson:"_id,omitempty" json:"id"`
+	ItemID          string      `bson:"item_id" json:"item_id"`
+	ItemName        string      `bson:"item_name" json:"item_name"`
+	MerchantID      string      `bson:"merchant_id" json:"merchant_id"`
+	CustomerID      string      `bson:"customer_id" json:"customer_id"`
+	CustomerAddress string      `bson:"customer_address" json:"customer_address"`
+	PriceLCN        uint64      `bson:"price_lcn" json:"price_lcn"`
+	Code            string      `bson:"code" json:"code"`
+	Status          OrderStatus `bson:"status" json:"status"`
+	TxHash          string      `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
+	RefundTxHash    string      `bson:"refund_tx_hash,omitempty" json:"refund_tx_hash,omitempty"`
+	Error           string      `bson:"error,omitempty" json:"error,omitempty"`
+	ExpiresAt       *time.Time  `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // nil: no limit
+	FulfilledBy     string      `bson:"fulfilled_by,omitempty" json:"fulfilled_by,omitempty"`
+	FulfilledAt     *time.Time  `bson:"fulfilled_at,omitempty" json:"fulfilled_at,omitempty"`
+	CancelledBy     string      `bson:"cancelled_by,omitempty" json:"cancelled_by,omitempty"`
+	CancelledAt     *time.Time  `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time   `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time   `bson:"updated_at" json:"updated_at"`
+}
+
+// Which way a refund sends LCN back
+type RefundDirection string
+
+const (
+	RefundToMerchant RefundDirection = "TO_MERCHANT" // reverses an issuance; the customer consents
+	RefundToCustomer RefundDirection = "TO_CUSTOMER" // refunds a redemption from the merchant's wallet
+)
+
+type RefundStatus string
+
+const (
+	RefundPendingConsent RefundStatus = "PENDING_CONSENT"
+	RefundProcessing     RefundStatus = "PROCESSING" // the LCN is being sent back
+	RefundCompleted      RefundStatus = "COMPLETED"
+	RefundDeclined       RefundStatus = "DECLINED"  // by the customer
+	RefundCancelled      RefundStatus = "CANCELLED" // by the merchant before consent
+	RefundFailed         RefundStatus = "FAILED"
+)
+
+// Merchant's refund or reversal of (part of) a transaction with a customer.
+// Its amount is reserved on the original transaction until it completes or
+// is given up.
+type Refund struct {
+	ID             string          `bson:"_id,omitempty" json:"id"`
+	MerchantID     string          `bson:"merchant_id" json:"merchant_id"`
+	CustomerID     string          `bson:"customer_id" json:"customer_id"`
+	OriginalTxHash string          `bson:"original_tx_hash" json:"original_tx_hash"`
+	OriginalType   TxType          `bson:"original_type" json:"original_type"`
+	Direction      RefundDirection `bson:"direction" json:"direction"`
+	AmountLCN      uint64          `bson:"amount_lcn" json:"amount_lcn"`
+	Reason         string          `bson:"reason" json:"reason"`
+	Status         RefundStatus    `bson:"status" json:"status"`
+	RequestedBy    string          `bson:"requested_by" json:"requested_by"`
+	ConsentedAt    *time.Time      `bson:"consented_at,omitempty" json:"consented_at,omitempty"`
+	OverriddenBy   string          `bson:"overridden_by,omitempty" json:"overridden_by,omitempty"` // admin who sent it back without consent
+	OverrideNotes  string          `bson:"override_notes,omitempty" json:"override_notes,omitempty"`
+	ClosedBy       string          `bson:"closed_by,omitempty" json:"closed_by,omitempty"` // who declined or cancelled it
+	TxHash         string          `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
+	Error          string          `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt      time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `bson:"updated_at" json:"updated_at"`
+	CompletedAt    *time.Time      `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+type VoucherStatus string
+
+const (
+	VoucherMinting   VoucherStatus = "MINTING" // mint transaction submitted, not yet confirmed
+	VoucherActive    VoucherStatus = "ACTIVE"
+	VoucherRedeeming VoucherStatus = "REDEEMING" // burn transaction submitted, not yet confirmed
+	VoucherRedeemed  VoucherStatus = "REDEEMED"  // burned
+	VoucherFailed    VoucherStatus = "FAILED"    // never minted
+	VoucherExpired   VoucherStatus = "EXPIRED"   // derived: active past expires_at
+)
+
+// Single-use voucher minted as a CIP-68 NFT pair under the merchant's policy:
+// the user token (label 222) goes to the holder's wallet and the reference
+// token (label 100), whose inline datum carries the metadata, stays at the
+// policy script's address. Redeeming burns both.
+type Voucher struct {
+	ID               string        `bson:"_id,omitempty" json:"id"`
+	MerchantID       string        `bson:"merchant_id" json:"merchant_id"`
+	BusinessName     string        `bson:"business_name" json:"business_name"`
+	PolicyID         string        `bson:"policy_id" json:"policy_id"`
+	AssetName        string        `bson:"asset_name" json:"asset_name"` // hex, without the CIP-68 label
+	Code             string        `bson:"code" json:"code"`             // shown at the counter; unique per merchant
+	Name             string        `bson:"name" json:"name"`
+	Description      string        `bson:"description,omitempty" json:"description,omitempty"`
+	ImageURL         string        `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	Value            string        `bson:"value" json:"value"` // what it is good for, e.g. "1 coffee"
+	ExpiresAt        *time.Time    `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	DepositLovelace  uint64        `bson:"deposit_lovelace" json:"deposit_lovelace"` // ADA sent along with the user token
+	HolderAddress    string        `bson:"holder_address" json:"holder_address"`
+	HolderCustomerID string        `bson:"holder_customer_id,omitempty" json:"holder_customer_id,omitempty"` // empty if held outside the platform
+	Status           VoucherStatus `bson:"status" json:"status"`
+	MintTxHash       string        `bson:"mint_tx_hash,omitempty" json:"mint_tx_hash,omitempty"`
+	BurnTxHash       string        `bson:"burn_tx_hash,omitempty" json:"burn_tx_hash,omitempty"`
+	IssuedBy         string        `bson:"issued_by" json:"issued_by"`
+	RedeemedBy       string        `bson:"redeemed_by,omitempty" json:"redeemed_by,omitempty"`
+	Error            string        `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt        time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time     `bson:"updated_at" json:"updated_at"`
+	RedeemedAt       *time.Time    `bson:"redeemed_at,omitempty" json:"redeemed_at,omitempty"`
+	SyncedAt         *time.Time    `bson:"synced_at,omitempty" json:"synced_at,omitempty"` // holder last checked on-chain
+}
+
+// Coalition clearing rules
+type ClearingRules struct {
+	RateBPS           int `bson:"rate_bps" json:"rate_bps"`                       // share of the LCN redeemed at a member the issuing member owes it, in basis points (10000: at par)
+	NettingPeriodDays int `bson:"netting_period_days" json:"netting_period_days"` // how often open balances are netted and settled
+}
+
+// Group of merchants whose customers' LCN clears between them: LCN issued
+// by one member and redeemed at another leaves the issuer owing the
+// redeeming member, and balances are netted every period
+type Coalition struct {
+	ID            string        `bson:"_id,omitempty" json:"id"`
+	Name          string        `bson:"name" json:"name"`
+	MerchantIDs   []string      `bson:"merchant_ids" json:"merchant_ids"` // a merchant belongs to one coalition at most
+	Rules         ClearingRules `bson:"rules" json:"rules"`
+	CreatedBy     string        `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+	NextNettingAt time.Time     `bson:"next_netting_at" json:"next_netting_at"`
+	LastNettedAt  *time.Time    `bson:"last_netted_at,omitempty" json:"last_netted_at,omitempty"`
+}
+
+type ClearingStatus string
+
+const (
+	ClearingOpen   ClearingStatus = "OPEN"
+	ClearingNetted ClearingStatus = "NETTED"
+)
+
+// What a coalition member owes another for LCN it issued that a customer
+// redeemed at the other. A refund of the redemption records a reversal the
+// other way round.
+type ClearingEntry struct {
+	ID          string         `bson:"_id,omitempty" json:"id"`
+	CoalitionID string         `bson:"coalition_id" json:"coalition_id"`
+	TxHash      string         `bson:"tx_hash" json:"tx_hash"`                         // redemption, or refund for reversals
+	RefundOf    string         `bson:"refund_of,omitempty" json:"refund_of,omitempty"` // reversals: the redemption refunded
+	PayerID     string         `bson:"payer_id" json:"payer_id"`                       // owes (the issuer)
+	PayeeID     string         `bson:"payee_id" json:"payee_id"`                       // is owed (the redeeming merchant)
+	CustomerID  string         `bson:"customer_id" json:"customer_id"`
+	RedeemedLCN uint64         `bson:"redeemed_lcn" json:"redeemed_lcn"` // of the payer's LCN
+	AmountLCN   uint64         `bson:"amount_lcn" json:"amount_lcn"`     // owed under the clearing rate
+	RateBPS     int            `bson:"rate_bps" json:"rate_bps"`
+	Status      ClearingStatus `bson:"status" json:"status"`
+	NettingID   string         `bson:"netting_id,omitempty" json:"netting_id,omitempty"`
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// Total a coalition member owes another over a set of clearing entries
+type ClearingBalance struct {
+	PayerID   string `bson:"payer_id" json:"payer_id"`
+	PayeeID   string `bson:"payee_id" json:"payee_id"`
+	AmountLCN uint64 `bson:"amount_lcn" json:"amount_lcn"`
+	Entries   int    `bson:"entries" json:"entries"`
+}
+
+// Member's position over a set of clearing entries; positive NetLCN is owed
+// to the member
+type NetPosition struct {
+	MerchantID    string `bson:"merchant_id" json:"merchant_id"`
+	BusinessName  string `bson:"business_name,omitempty" json:"business_name,omitempty"`
+	ReceivableLCN uint64 `bson:"receivable_lcn" json:"receivable_lcn"`
+	PayableLCN    uint64 `bson:"payable_lcn" json:"payable_lcn"`
+	NetLCN        int64  `bson:"net_lcn" json:"net_lcn"`
+}
+
+// Payment a netting run settles between two members
+type ClearingPayment struct {
+	PayerID      string `bson:"payer_id" json:"payer_id"`
+	PayeeID      string `bson:"payee_id" json:"payee_id"`
+	AmountLCN    uint64 `bson:"amount_lcn" json:"amount_lcn"`
+	SettlementID string `bson:"settlement_id,omitempty" json:"settlement_id,omitempty"`
+}
+
+// Periodic netting of a coalition's open clearing entries into as few
+// payments as settle every member's net position
+type Netting struct {
+	ID          string            `bson:"_id,omitempty" json:"id"`
+	CoalitionID string            `bson:"coalition_id" json:"coalition_id"`
+	Cutoff      time.Time         `bson:"cutoff" json:"cutoff"` // entries recorded up to then
+	Entries     int               `bson:"entries" json:"entries"`
+	GrossLCN    uint64            `bson:"gross_lcn" json:"gross_lcn"`
+	Positions   []NetPosition     `bson:"positions" json:"positions"`
+	Payments    []ClearingPayment `bson:"payments" json:"payments"`
+	CreatedAt   time.Time         `bson:"created_at" json:"created_at"`
 }
 
 // Blockchain transaction log
@@ -107,22 +663,94 @@ type TxLog struct {
 	Meta          map[string]interface{} `bson:"meta,omitempty" json:"meta,omitempty"`
 }
 
+// MetaLCN reads an LCN amount stored in the transaction's meta
+func (t *TxLog) MetaLCN(key string) uint64 {
+	switch value := t.Meta[key].(type) {
+	case int32:
+		if value > 0 {
+			return uint64(value)
+		}
+	case int64:
+		if value > 0 {
+			return uint64(value)
+		}
+	case float64:
+		if value > 0 {
+			return uint64(value)
+		}
+	}
+	return 0
+}
+
+// Transfers a wallet sent on one UTC day, counted before each transfer is
+// sent so that concurrent transfers cannot exceed the daily limits
+type DailyTransfers struct {
+	ID        string    `bson:"_id" json:"-"` // address:YYYY-MM-DD
+	Address   string    `bson:"address" json:"address"`
+	Day       time.Time `bson:"day" json:"day"`
+	Transfers int       `bson:"transfers" json:"transfers"`
+	AmountLCN uint64    `bson:"amount_lcn" json:"amount_lcn"`
+	ExpiresAt time.Time `bson:"expires_at" json:"-"` // removed by MongoDB after the day
+}
+
+// Rule-based rewards one merchant wallet paid one customer address on one UTC
+// day, counted before each reward is sent so that concurrent purchases cannot
+// exceed the earn rule's daily cap
+type DailyEarnings struct {
+	ID              string    `bson:"_id" json:"-"` // merchant address:customer address:YYYY-MM-DD
+	MerchantAddress string    `bson:"merchant_address" json:"merchant_address"`
+	CustomerAddress string    `bson:"customer_address" json:"customer_address"`
+	Day             time.Time `bson:"day" json:"day"`
+	AmountLCN       uint64    `bson:"amount_lcn" json:"amount_lcn"`
+	ExpiresAt       time.Time `bson:"expires_at" json:"-"` // removed by MongoDB after the day
+}
+
+// Merchant API key for server-to-server (point-of-sale) integrations.
+// Only the SHA-256 hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         string       `bson:"_id,omitempty" json:"id"`
+	MerchantID string       `bson:"merchant_id" json:"merchant_id"`
+	Name       string       `bson:"name" json:"name"`
+	Prefix     string       `bson:"prefix" json:"prefix"`
+	KeyHash    string       `bson:"key_hash" json:"-"`
+	Scopes     []Permission `bson:"scopes" json:"scopes"`
+	CreatedBy  string       `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time    `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time   `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time   `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
 // Merchant's LCN → ETB settlement request
 type SettlementRequest struct {
-	ID               string           `bson:"_id,omitempty" json:"id"`
-	MerchantID       string           `bson:"merchant_id" json:"merchant_id"`
-	AmountLCN        uint64           `bson:"amount_lcn" json:"amount_lcn"`
-	AmountETB        float64          `bson:"amount_etb" json:"amount_etb"`
-	ExchangeRate     float64          `bson:"exchange_rate" json:"exchange_rate"`
-	Status           SettlementStatus `bson:"status" json:"status"`
-	BankAccount      BankAccount      `bson:"bank_account" json:"bank_account"`
-	RequestedAt      time.Time        `bson:"requested_at" json:"requested_at"`
-	ApprovedAt       *time.Time       `bson:"approved_at,omitempty" json:"approved_at,omitempty"`
-	ProcessedAt      *time.Time       `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
-	TxHash           string           `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
-	PaymentReference string           `bson:"payment_reference,omitempty" json:"payment_reference,omitempty"`
-	AdminID          string           `bson:"admin_id,omitempty" json:"admin_id,omitempty"`
-	AdminNotes       string           `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
+	ID           string           `bson:"_id,omitempty" json:"id"`
+	MerchantID   string           `bson:"merchant_id" json:"merchant_id"`
+	AmountLCN    uint64           `bson:"amount_lcn" json:"amount_lcn"`
+	AmountETB    float64          `bson:"amount_etb" json:"amount_etb"`
+	ExchangeRate float64          `bson:"exchange_rate" json:"exchange_rate"`
+	Status       SettlementStatus `bson:"status" json:"status"`
+	BankAccount  BankAccount      `bson:"bank_account" json:"bank_account"`
+	// Clearing settlements pay a coalition member rather than a bank account
+	Kind             SettlementKind `bson:"kind,omitempty" json:"kind,omitempty"`
+	PayeeMerchantID  string         `bson:"payee_merchant_id,omitempty" json:"payee_merchant_id,omitempty"`
+	NettingID        string         `bson:"netting_id,omitempty" json:"netting_id,omitempty"`
+	RequestedAt      time.Time      `bson:"requested_at" json:"requested_at"`
+	ApprovedAt       *time.Time     `bson:"approved_at,omitempty" json:"approved_at,omitempty"`
+	ProcessedAt      *time.Time     `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
+	TxHash           string         `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
+	PaymentReference string         `bson:"payment_reference,omitempty" json:"payment_reference,omitempty"`
+	AdminID          string         `bson:"admin_id,omitempty" json:"admin_id,omitempty"`
+	AdminNotes       string         `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
+	// Dual control (only set for amounts above the approval threshold)
+	Initiation        *Approval  `bson:"initiation,omitempty" json:"initiation,omitempty"`
+	RequiredApprovals int        `bson:"required_approvals,omitempty" json:"required_approvals,omitempty"`
+	Approvals         []Approval `bson:"approvals,omitempty" json:"approvals,omitempty"`
+}
+
+// An admin's sign-off on a dual-controlled allocation or settlement
+type Approval struct {
+	AdminID    string    `bson:"admin_id" json:"admin_id"`
+	Notes      string    `bson:"notes,omitempty" json:"notes,omitempty"`
+	ApprovedAt time.Time `bson:"approved_at" json:"approved_at"`
 }
 
 // Merchant's request to buy more LCN
@@ -134,12 +762,122 @@ type AllocationPurchase struct {
 	PaymentMethod     string     `bson:"payment_method" json:"payment_method"`
 	PaymentReference  string     `bson:"payment_reference" json:"payment_reference"`
 	PaymentProofURL   string     `bson:"payment_proof_url,omitempty" json:"payment_proof_url,omitempty"`
-	Status            string     `bson:"status" json:"status"` // PENDING, VERIFIED, CONFIRMED, REJECTED
+	Status            string     `bson:"status" json:"status"` // PENDING, VERIFIED, PROCESSING, CONFIRMED, REJECTED
 	PurchasedAt       time.Time  `bson:"purchased_at" json:"purchased_at"`
 	VerifiedAt        *time.Time `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
 	LCNTransferTxHash string     `bson:"lcn_transfer_tx_hash,omitempty" json:"lcn_transfer_tx_hash,omitempty"`
 	AdminID           string     `bson:"admin_id,omitempty" json:"admin_id,omitempty"`
 	AdminNotes        string     `bson:"admin_notes,omitempty" json:"admin_notes,omitempty"`
+	// Dual control (only set for amounts above the approval threshold)
+	Initiation        *Approval  `bson:"initiation,omitempty" json:"initiation,omitempty"`
+	RequiredApprovals int        `bson:"required_approvals,omitempty" json:"required_approvals,omitempty"`
+	Approvals         []Approval `bson:"approvals,omitempty" json:"approvals,omitempty"`
+	// Multi-sig governance transaction funding this allocation
+	GovernanceTxID string `bson:"governance_tx_id,omitempty" json:"governance_tx_id,omitempty"`
+}
+
+type GovernanceTxStatus string
+
+const (
+	GovernanceTxAwaitingSignatures GovernanceTxStatus = "AWAITING_SIGNATURES"
+	GovernanceTxSubmitting         GovernanceTxStatus = "SUBMITTING"
+	GovernanceTxSubmitted          GovernanceTxStatus = "SUBMITTED"
+	GovernanceTxFailed             GovernanceTxStatus = "FAILED"
+	GovernanceTxExpired            GovernanceTxStatus = "EXPIRED"
+)
+
+// Unsigned transaction spending from the multi-sig governance wallet.
+// Admins sign TxHash (the body hash) until RequiredSignatures is reached.
+type GovernanceTx struct {
+	ID                 string             `bson:"_id,omitempty" json:"id"`
+	Purpose            TxType             `bson:"purpose" json:"purpose"`
+	ReferenceID        string             `bson:"reference_id" json:"reference_id"`     // allocation purchase or referral ID
+	Side               string             `bson:"side,omitempty" json:"side,omitempty"` // referral payouts: referrer or referee
+	ToAddress          string             `bson:"to_address" json:"to_address"`
+	AmountLCN          uint64             `bson:"amount_lcn" json:"amount_lcn"`
+	TxHash             string             `bson:"tx_hash" json:"tx_hash"`
+	TxCBOR             string             `bson:"tx_cbor" json:"tx_cbor"`
+	Inputs             []string           `bson:"inputs" json:"inputs"`
+	RequiredSignatures int                `bson:"required_signatures" json:"required_signatures"`
+	Signatures         []TxSignature      `bson:"signatures" json:"signatures"`
+	Status             GovernanceTxStatus `bson:"status" json:"status"`
+	SubmittedTxHash    string             `bson:"submitted_tx_hash,omitempty" json:"submitted_tx_hash,omitempty"`
+	Error              string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedBy          string             `bson:"created_by" json:"created_by"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt          time.Time          `bson:"expires_at" json:"expires_at"`
+	SubmittedAt        *time.Time         `bson:"submitted_at,omitempty" json:"submitted_at,omitempty"`
+}
+
+type ExternalTxStatus string
+
+const (
+	ExternalTxAwaitingSignature ExternalTxStatus = "AWAITING_SIGNATURE"
+	ExternalTxSubmitting        ExternalTxStatus = "SUBMITTING"
+	ExternalTxSubmitted         ExternalTxStatus = "SUBMITTED"
+	ExternalTxFailed            ExternalTxStatus = "FAILED"
+	ExternalTxExpired           ExternalTxStatus = "EXPIRED"
+)
+
+// Unsigned transaction spending from a customer's external wallet. The
+// customer's wallet signs TxCBOR client-side and returns a witness set that
+// must carry a signature of TxHash by KeyHash.
+type ExternalTx struct {
+	ID               string           `bson:"_id,omitempty" json:"id"`
+	Purpose          TxType           `bson:"purpose" json:"purpose"`
+	CustomerID       string           `bson:"customer_id" json:"customer_id"`
+	FromAddress      string           `bson:"from_address" json:"from_address"`
+	ToAddress        string           `bson:"to_address" json:"to_address"`
+	AmountLCN        uint64           `bson:"amount_lcn" json:"amount_lcn"`
+	KeyHash          string           `bson:"key_hash" json:"key_hash"`
+	TxHash           string           `bson:"tx_hash" json:"tx_hash"`
+	TxCBOR           string           `bson:"tx_cbor" json:"tx_cbor"`
+	Status           ExternalTxStatus `bson:"status" json:"status"`
+	PaymentRequestID string           `bson:"payment_request_id,omitempty" json:"payment_request_id,omitempty"`
+	SubmittedTxHash  string           `bson:"submitted_tx_hash,omitempty" json:"submitted_tx_hash,omitempty"`
+	Error            string           `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt        time.Time        `bson:"created_at" json:"created_at"`
+	ExpiresAt        time.Time        `bson:"expires_at" json:"expires_at"`
+	SubmittedAt      *time.Time       `bson:"submitted_at,omitempty" json:"submitted_at,omitempty"`
+}
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestOpen      PaymentRequestStatus = "OPEN"
+	PaymentRequestPaying    PaymentRequestStatus = "PAYING"    // reserved by a customer while their transfer is built or signed
+	PaymentRequestSubmitted PaymentRequestStatus = "SUBMITTED" // transfer submitted, waiting for the indexer
+	PaymentRequestPaid      PaymentRequestStatus = "PAID"
+	PaymentRequestCancelled PaymentRequestStatus = "CANCELLED"
+	PaymentRequestExpired   PaymentRequestStatus = "EXPIRED" // reported for unpaid requests past expires_at; never stored
+)
+
+// Invoice a merchant shows as a QR code; a customer pays it once by ID
+type PaymentRequest struct {
+	ID              string               `bson:"_id,omitempty" json:"id"`
+	MerchantID      string               `bson:"merchant_id" json:"merchant_id"`
+	MerchantAddress string               `bson:"merchant_address" json:"merchant_address"`
+	BusinessName    string               `bson:"business_name" json:"business_name"`
+	CreatedBy       string               `bson:"created_by" json:"created_by"`
+	AmountLCN       uint64               `bson:"amount_lcn" json:"amount_lcn"`
+	Description     string               `bson:"description,omitempty" json:"description,omitempty"`
+	Status          PaymentRequestStatus `bson:"status" json:"status"`
+	CustomerID      string               `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	ReservedUntil   *time.Time           `bson:"reserved_until,omitempty" json:"-"`
+	ExternalTxID    string               `bson:"external_tx_id,omitempty" json:"external_tx_id,omitempty"`
+	TxHash          string               `bson:"tx_hash,omitempty" json:"tx_hash,omitempty"`
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
+	ExpiresAt       time.Time            `bson:"expires_at" json:"expires_at"`
+	PaidAt          *time.Time           `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+}
+
+// One admin's vkey witness on a governance transaction
+type TxSignature struct {
+	AdminID   string    `bson:"admin_id" json:"admin_id"`
+	KeyHash   string    `bson:"key_hash" json:"key_hash"`
+	PublicKey string    `bson:"public_key" json:"public_key"`
+	Signature string    `bson:"signature" json:"signature"`
+	SignedAt  time.Time `bson:"signed_at" json:"signed_at"`
 }
 
 // UTXOAsset
@@ -185,3 +923,30 @@ type GovernanceReserve struct {
 	TotalSettlementsPending float64   `bson:"total_settlements_pending" json:"total_settlements_pending"`
 	CreatedAt               time.Time `bson:"created_at" json:"created_at"`
 }
+
+// Role: a named group of permissions, stored in the roles collection
+type RoleDefinition struct {
+	Name        Role         `bson:"_id" json:"name"`
+	Description string       `bson:"description" json:"description"`
+	Scope       RoleScope    `bson:"scope" json:"scope"`
+	Permissions []Permission `bson:"permissions" json:"permissions"`
+	BuiltIn     bool         `bson:"built_in" json:"built_in"`
+	// Built-in permissions already granted by a release, so that ones added
+	// later are granted once without undoing admins' removals
+	SeededPermissions []Permission `bson:"seeded_permissions,omitempty" json:"-"`
+	CreatedAt         time.Time    `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time    `bson:"updated_at" json:"updated_at"`
+}
+
+// Idempotency record: the first response to a request sent with an
+// Idempotency-Key, replayed when the client retries with the same key
+type IdempotencyRecord struct {
+	Key         string    `bson:"_id" json:"key"`
+	Fingerprint string    `bson:"fingerprint" json:"fingerprint"`
+	Completed   bool      `bson:"completed" json:"completed"`
+	StatusCode  int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty" json:"-"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,116 @@
+// Package notify delivers short messages to customers: one-time codes by
+// email over SMTP and by SMS through an HTTP gateway.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+var ErrNotConfigured = errors.New("no delivery channel is configured")
+
+// Sender delivers a message to an email address or phone number
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// SMTPSender sends plain text email through an SMTP server
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPSender(addr, username, password, from string) *SMTPSender {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return &SMTPSender{addr: addr, host: host, username: username, password: password, from: from}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	message := "From: " + s.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body + "\r\n"
+	if err := smtp.SendMail(s.addr, auth, s.from, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// WebhookSender posts SMS messages as JSON ({"to", "message"}) to a gateway
+type WebhookSender struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewWebhookSender(url, token string) *WebhookSender {
+	return &WebhookSender{url: url, token: token, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, to, subject, body string) error {
+	payload, err := json.Marshal(map[string]string{"to": to, "message": body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogSender writes messages to the log instead of delivering them, for
+// development without a mail server or SMS gateway
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, subject, body string) error {
+	logger.Info("Message not delivered (development)", map[string]interface{}{
+		"to":      to,
+		"subject": subject,
+		"body":    body,
+	})
+	return nil
+}
+
+// Disabled refuses every message; used when a channel is not configured
+type Disabled struct{}
+
+func (Disabled) Send(ctx context.Context, to, subject, body string) error {
+	return ErrNotConfigured
+}
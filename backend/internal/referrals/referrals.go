@@ -0,0 +1,106 @@
+package referrals
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+const (
+	codeLength   = 8
+	codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // Crockford base32
+)
+
+// Fraud heuristics that send a qualified referral to admin review, or reject it
+const (
+	FlagSharedIP       = "SHARED_IP"            // another signup the referrer brought in came from the same IP
+	FlagSharedDevice   = "SHARED_DEVICE"        // another signup the referrer brought in came from the same device
+	FlagIPBurst        = "IP_BURST"             // too many referred signups from the IP within a day
+	FlagTxWithReferrer = "TX_WITH_REFERRER"     // the qualifying transaction was with the referrer
+	FlagReferrerCap    = "REFERRER_CAP_REACHED" // the referrer was already rewarded for the maximum number of referrals
+)
+
+// Sides of a referral that are rewarded
+const (
+	SideReferrer = "referrer"
+	SideReferee  = "referee"
+)
+
+// Payout is one side's reward of a referral
+type Payout struct {
+	Side      string
+	Party     models.ReferralParty
+	AmountLCN uint64
+}
+
+// Unpaid returns the rewards of a referral that have not been sent yet
+func Unpaid(referral *models.Referral) []Payout {
+	payouts := []Payout{}
+	if referral.ReferrerRewardLCN > 0 && referral.ReferrerTxHash == "" {
+		payouts = append(payouts, Payout{SideReferrer, referral.Referrer, referral.ReferrerRewardLCN})
+	}
+	if referral.RefereeRewardLCN > 0 && referral.RefereeTxHash == "" {
+		payouts = append(payouts, Payout{SideReferee, referral.Referee, referral.RefereeRewardLCN})
+	}
+	return payouts
+}
+
+// Signals are what is known about a referral when it qualifies
+type Signals struct {
+	SharedIP       bool
+	SharedDevice   bool
+	IPSignups      int64 // referred signups from the signup IP within a day of this one, this one included
+	TxWithReferrer bool
+}
+
+// NewCode generates a random referral code of 8 characters (40 bits)
+func NewCode() (string, error) {
+	random := make([]byte, codeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate referral code: %w", err)
+	}
+	code := make([]byte, codeLength)
+	for i, b := range random {
+		code[i] = codeAlphabet[b%byte(len(codeAlphabet))]
+	}
+	return string(code), nil
+}
+
+// NormalizeCode upper-cases a code as typed by a user, reading the letters
+// Crockford base32 leaves out as the digits they resemble. Returns false if
+// it cannot be a referral code.
+func NormalizeCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "", "I", "1", "L", "1", "O", "0").Replace(code)
+	if len(code) != codeLength {
+		return "", false
+	}
+	for _, char := range code {
+		if !strings.ContainsRune(codeAlphabet, char) {
+			return "", false
+		}
+	}
+	return code, true
+}
+
+// Assess returns the fraud flags raised by a qualified referral.
+// maxSignupsPerIP is how many referred signups one IP may make within a day
+// before they are flagged (0: no limit).
+func Assess(signals Signals, maxSignupsPerIP int64) []string {
+	flags := []string{}
+	if signals.SharedIP {
+		flags = append(flags, FlagSharedIP)
+	}
+	if signals.SharedDevice {
+		flags = append(flags, FlagSharedDevice)
+	}
+	if maxSignupsPerIP > 0 && signals.IPSignups > maxSignupsPerIP {
+		flags = append(flags, FlagIPBurst)
+	}
+	if signals.TxWithReferrer {
+		flags = append(flags, FlagTxWithReferrer)
+	}
+	return flags
+}
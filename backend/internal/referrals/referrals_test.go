@@ -0,0 +1,86 @@
+package referrals
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestNewCode(t *testing.T) {
+	code, err := NewCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized, ok := NormalizeCode(code); !ok || normalized != code {
+		t.Errorf("generated code %q does not normalize to itself (got %q)", code, normalized)
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"K7M3X9QA", "K7M3X9QA", true},
+		{" k7m3-x9qa ", "K7M3X9QA", true},
+		// Letters that look like digits are read as digits
+		{"IL0O2345", "11002345", true},
+		{"K7M3X9Q", "", false},
+		{"K7M3X9QAB", "", false},
+		{"K7M3X9QU", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeCode(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeCode(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAssess(t *testing.T) {
+	if flags := Assess(Signals{IPSignups: 1}, 3); len(flags) != 0 {
+		t.Errorf("clean referral flagged: %v", flags)
+	}
+
+	flags := Assess(Signals{SharedIP: true, SharedDevice: true, IPSignups: 4, TxWithReferrer: true}, 3)
+	for _, want := range []string{FlagSharedIP, FlagSharedDevice, FlagIPBurst, FlagTxWithReferrer} {
+		if !slices.Contains(flags, want) {
+			t.Errorf("missing %s in %v", want, flags)
+		}
+	}
+
+	if flags := Assess(Signals{IPSignups: 3}, 3); slices.Contains(flags, FlagIPBurst) {
+		t.Error("signups up to the limit should not be flagged")
+	}
+	if flags := Assess(Signals{IPSignups: 50}, 0); slices.Contains(flags, FlagIPBurst) {
+		t.Error("no limit configured, yet signups were flagged")
+	}
+}
+
+func TestUnpaid(t *testing.T) {
+	sides := func(referral models.Referral) []string {
+		got := []string{}
+		for _, payout := range Unpaid(&referral) {
+			got = append(got, payout.Side)
+		}
+		return got
+	}
+	tests := []struct {
+		name     string
+		referral models.Referral
+		want     []string
+	}{
+		{"nothing paid", models.Referral{ReferrerRewardLCN: 50, RefereeRewardLCN: 25}, []string{SideReferrer, SideReferee}},
+		{"referrer paid", models.Referral{ReferrerRewardLCN: 50, RefereeRewardLCN: 25, ReferrerTxHash: "tx1"}, []string{SideReferee}},
+		{"all paid", models.Referral{ReferrerRewardLCN: 50, RefereeRewardLCN: 25, ReferrerTxHash: "tx1", RefereeTxHash: "tx2"}, []string{}},
+		{"no referee reward", models.Referral{ReferrerRewardLCN: 50}, []string{SideReferrer}},
+	}
+	for _, tt := range tests {
+		if got := sides(tt.referral); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
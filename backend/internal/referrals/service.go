@@ -0,0 +1,469 @@
+// Package referrals attributes signups to referral codes and rewards both
+// sides from the governance wallet once the referee starts using LoyalCoin.
+package referrals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotReviewable is returned when a referral is not waiting for a review decision
+var ErrNotReviewable = errors.New("referral is not awaiting review")
+
+type Config struct {
+	ReferrerRewardLCN uint64
+	RefereeRewardLCN  uint64
+	MinQualifyingLCN  uint64 // smallest transaction that qualifies a referee
+	MaxPerReferrer    int64  // referrals a referrer can be rewarded for; 0: no cap
+	MaxSignupsPerIP   int64  // referred signups from one IP within a day before review; 0: no limit
+	ReviewAll         bool   // send every qualified referral to admin review
+}
+
+type Service struct {
+	config         *Config
+	cardanoService *cardano.CardanoService
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	referralRepo   *storage.ReferralRepository
+	governanceRepo *storage.GovernanceRepository
+	governance     *cardano.GovernanceWallet
+	governanceTTL  time.Duration // signature window of multi-sig payouts
+}
+
+func NewService(
+	config *Config,
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	referralRepo *storage.ReferralRepository,
+	governanceRepo *storage.GovernanceRepository,
+	governance *cardano.GovernanceWallet,
+	governanceTTL time.Duration,
+) *Service {
+	return &Service{
+		config:         config,
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		referralRepo:   referralRepo,
+		governanceRepo: governanceRepo,
+		governance:     governance,
+		governanceTTL:  governanceTTL,
+	}
+}
+
+// Rewards returns what the referrer and the referee are paid
+func (s *Service) Rewards() (uint64, uint64) {
+	return s.config.ReferrerRewardLCN, s.config.RefereeRewardLCN
+}
+
+// ResolveCode returns the account owning a referral code as typed at signup
+func (s *Service) ResolveCode(ctx context.Context, code string) (*models.ReferralParty, error) {
+	code, ok := NormalizeCode(code)
+	if !ok {
+		return nil, storage.ErrReferralCodeNotFound
+	}
+	return s.userRepo.FindReferrerByCode(ctx, code)
+}
+
+// AssignCode gives an account created before referral codes existed its
+// code. Returns an empty code if the account got one meanwhile.
+func (s *Service) AssignCode(ctx context.Context, role models.Role, accountID string) (string, error) {
+	set := s.userRepo.SetMerchantReferralCode
+	if role == models.RoleCustomer {
+		set = s.userRepo.SetCustomerReferralCode
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		code, err := NewCode()
+		if err != nil {
+			return "", err
+		}
+		assigned, err := set(ctx, accountID, code)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if !assigned {
+			return "", nil
+		}
+		return code, nil
+	}
+	return "", fmt.Errorf("failed to generate a unique referral code")
+}
+
+// Attribute records that the referrer brought the referee in with the code
+// resolved by ResolveCode
+func (s *Service) Attribute(ctx context.Context, referrer, referee *models.ReferralParty, code, signupIP, deviceID string) error {
+	code, _ = NormalizeCode(code)
+	referral := &models.Referral{
+		Code:     code,
+		Referrer: *referrer,
+		Referee:  *referee,
+		SignupIP: signupIP,
+		DeviceID: deviceID,
+	}
+	if err := s.referralRepo.CreateReferral(ctx, referral); err != nil {
+		return err
+	}
+	logger.Audit("REFERRAL_ATTRIBUTED", referee.ID, map[string]interface{}{
+		"referral_id":   referral.ID,
+		"referrer_id":   referrer.ID,
+		"referrer_role": referrer.Role,
+		"referee_role":  referee.Role,
+	})
+	return nil
+}
+
+// RecordTransaction qualifies the pending referral of a customer or merchant
+// completing their first qualifying transaction: customers qualify by
+// earning from or redeeming at a merchant, merchants by issuing to or
+// being paid by a customer
+func (s *Service) RecordTransaction(ctx context.Context, tx *models.TxLog) {
+	if tx.Type != models.TxTypeIssuance && tx.Type != models.TxTypeRedemption {
+		return
+	}
+	if tx.AmountLCN < s.config.MinQualifyingLCN || (s.config.ReferrerRewardLCN == 0 && s.config.RefereeRewardLCN == 0) {
+		return
+	}
+
+	sender := s.accountByAddress(ctx, tx.FromAddress)
+	recipient := s.accountByAddress(ctx, tx.ToAddress)
+	if sender == nil || recipient == nil {
+		return
+	}
+	for _, side := range []struct{ referee, counterparty *models.ReferralParty }{
+		{sender, recipient},
+		{recipient, sender},
+	} {
+		customerAndMerchant := (side.referee.Role == models.RoleCustomer && side.counterparty.Role == models.RoleMerchant) ||
+			(side.referee.Role == models.RoleMerchant && side.counterparty.Role == models.RoleCustomer)
+		if !customerAndMerchant {
+			continue
+		}
+		referral, err := s.referralRepo.GetReferralByReferee(ctx, side.referee.ID)
+		if err != nil {
+			if !errors.Is(err, storage.ErrReferralNotFound) {
+				logger.Error("Failed to get referral", err, map[string]interface{}{
+					"referee_id": side.referee.ID,
+				})
+			}
+			continue
+		}
+		if referral.Status == models.ReferralPending {
+			s.qualify(ctx, referral, tx, side.counterparty)
+		}
+	}
+}
+
+// accountByAddress returns the customer or merchant owning an address, or nil
+func (s *Service) accountByAddress(ctx context.Context, address string) *models.ReferralParty {
+	if customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, address); err == nil {
+		return &models.ReferralParty{ID: customer.ID, Role: models.RoleCustomer, Name: customer.Username, Address: address}
+	}
+	if merchant, err := s.userRepo.GetMerchantByWalletAddress(ctx, address); err == nil {
+		return &models.ReferralParty{ID: merchant.ID, Role: merchant.Role, Name: merchant.BusinessName, Address: address}
+	}
+	return nil
+}
+
+func (s *Service) qualify(ctx context.Context, referral *models.Referral, tx *models.TxLog, counterparty *models.ReferralParty) {
+	fields := map[string]interface{}{
+		"referral_id": referral.ID,
+		"tx_hash":     tx.TxHash,
+	}
+	signals, err := s.signals(ctx, referral)
+	if err != nil {
+		// The referral stays pending; the referee's next transaction retries
+		logger.Error("Failed to assess referral", err, fields)
+		return
+	}
+	signals.TxWithReferrer = counterparty.ID == referral.Referrer.ID
+	rewarded, err := s.referralRepo.CountRewardedReferrals(ctx, referral.Referrer.ID)
+	if err != nil {
+		logger.Error("Failed to count rewarded referrals", err, fields)
+		return
+	}
+
+	referral.Flags = Assess(signals, s.config.MaxSignupsPerIP)
+	referral.ReferrerRewardLCN = s.config.ReferrerRewardLCN
+	referral.RefereeRewardLCN = s.config.RefereeRewardLCN
+	referral.QualifyingTxHash = tx.TxHash
+	switch {
+	case s.config.MaxPerReferrer > 0 && rewarded >= s.config.MaxPerReferrer:
+		referral.Flags = append(referral.Flags, FlagReferrerCap)
+		referral.Status = models.ReferralRejected
+	case len(referral.Flags) > 0 || s.config.ReviewAll:
+		referral.Status = models.ReferralReview
+	default:
+		referral.Status = models.ReferralPaying
+	}
+
+	qualified, err := s.referralRepo.QualifyReferral(ctx, referral)
+	if err != nil {
+		logger.Error("Failed to qualify referral", err, fields)
+		return
+	}
+	if !qualified {
+		return
+	}
+	logger.Audit("REFERRAL_QUALIFIED", referral.Referee.ID, map[string]interface{}{
+		"referral_id": referral.ID,
+		"referrer_id": referral.Referrer.ID,
+		"tx_hash":     tx.TxHash,
+		"status":      referral.Status,
+		"flags":       referral.Flags,
+	})
+	if referral.Status == models.ReferralPaying {
+		if err := s.pay(ctx, referral); err != nil {
+			logger.Error("Failed to pay referral rewards", err, fields)
+		}
+	}
+}
+
+// signals gathers the device and IP heuristics of a referral
+func (s *Service) signals(ctx context.Context, referral *models.Referral) (Signals, error) {
+	var signals Signals
+	// Both counts include the referral itself
+	sameIP, sameDevice, err := s.referralRepo.CountReferrerSignups(ctx, referral.Referrer.ID, referral.SignupIP, referral.DeviceID)
+	if err != nil {
+		return signals, err
+	}
+	signals.SharedIP = sameIP > 1
+	signals.SharedDevice = sameDevice > 1
+	if referral.SignupIP != "" {
+		signals.IPSignups, err = s.referralRepo.CountSignupsFromIP(ctx, referral.SignupIP,
+			referral.CreatedAt.Add(-24*time.Hour), referral.CreatedAt.Add(24*time.Hour))
+		if err != nil {
+			return signals, err
+		}
+	}
+	return signals, nil
+}
+
+// Approve pays a referral waiting for review, or retries a failed payout
+func (s *Service) Approve(ctx context.Context, id, adminID, notes string) (*models.Referral, error) {
+	claimed, err := s.referralRepo.ReviewReferral(ctx, id,
+		[]models.ReferralStatus{models.ReferralReview, models.ReferralFailed}, models.ReferralPaying, adminID, notes)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNotReviewable
+	}
+	referral, err := s.referralRepo.GetReferralByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pay(ctx, referral); err != nil {
+		return referral, err
+	}
+	return referral, nil
+}
+
+// Reject closes a referral waiting for review (or whose payout failed) unpaid
+func (s *Service) Reject(ctx context.Context, id, adminID, notes string) (*models.Referral, error) {
+	rejected, err := s.referralRepo.ReviewReferral(ctx, id,
+		[]models.ReferralStatus{models.ReferralReview, models.ReferralFailed}, models.ReferralRejected, adminID, notes)
+	if err != nil {
+		return nil, err
+	}
+	if !rejected {
+		return nil, ErrNotReviewable
+	}
+	return s.referralRepo.GetReferralByID(ctx, id)
+}
+
+// pay sends the rewards of a referral claimed for payout (PAYING) from the
+// governance wallet. A side paid by an earlier attempt is not paid again.
+func (s *Service) pay(ctx context.Context, referral *models.Referral) error {
+	fail := func(err error) error {
+		referral.Status = models.ReferralFailed
+		referral.Error = err.Error()
+		if err := s.referralRepo.CompletePayout(ctx, referral.ID, models.ReferralFailed, referral.Error); err != nil {
+			logger.Error("Failed to record referral payout failure", err, map[string]interface{}{
+				"referral_id": referral.ID,
+			})
+		}
+		return err
+	}
+
+	if s.governance.IsMultiSig() {
+		return s.startGovernancePayouts(ctx, referral, fail)
+	}
+	govUser, err := s.userRepo.GetMerchantByWalletAddress(ctx, s.governance.Address)
+	if err != nil {
+		return fail(fmt.Errorf("governance wallet owner not found: %w", err))
+	}
+	governanceKey := crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: govUser.Wallet.Address, OwnerID: govUser.ID},
+		EncryptedKey: govUser.Wallet.EncryptedPrivateKey,
+	}
+
+	for _, payout := range Unpaid(referral) {
+		address, err := s.currentAddress(ctx, payout.Party)
+		if err != nil {
+			return fail(fmt.Errorf("%s not found: %w", payout.Side, err))
+		}
+		// Logged as a referral from the start, so the indexer records the
+		// reward as a lot that never expires
+		txHash, err := s.cardanoService.TransferADAAs(governanceKey, address, payout.AmountLCN, models.TxTypeReferral)
+		if err != nil {
+			return fail(fmt.Errorf("failed to pay %s: %w", payout.Side, err))
+		}
+		s.recordPayout(ctx, referral, payout.Side, txHash)
+	}
+	return s.markPaid(ctx, referral)
+}
+
+// startGovernancePayouts builds a transaction from the multi-sig governance
+// wallet for every unpaid side of a referral. Admins sign them like
+// allocation transfers; the referral stays PAYING until CompleteGovernancePayout
+// has recorded every side.
+func (s *Service) startGovernancePayouts(ctx context.Context, referral *models.Referral, fail func(error) error) error {
+	unpaid := Unpaid(referral)
+	if len(unpaid) == 0 {
+		return s.markPaid(ctx, referral)
+	}
+	for _, payout := range unpaid {
+		// A transaction from an earlier attempt may still be signed
+		open, err := s.governanceRepo.HasOpenGovernanceTx(ctx, models.TxTypeReferral, referral.ID, payout.Side)
+		if err != nil {
+			return fail(err)
+		}
+		if open {
+			continue
+		}
+		address, err := s.currentAddress(ctx, payout.Party)
+		if err != nil {
+			return fail(fmt.Errorf("%s not found: %w", payout.Side, err))
+		}
+		lockedInputs, err := s.governanceRepo.GetLockedInputs(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		expiresAt := time.Now().UTC().Add(s.governanceTTL)
+		transfer, err := s.cardanoService.BuildGovernanceTransfer(s.governance, address, payout.AmountLCN, lockedInputs, expiresAt)
+		if err != nil {
+			return fail(fmt.Errorf("failed to build %s payout: %w", payout.Side, err))
+		}
+		govTx := &models.GovernanceTx{
+			Purpose:            models.TxTypeReferral,
+			ReferenceID:        referral.ID,
+			Side:               payout.Side,
+			ToAddress:          address,
+			AmountLCN:          payout.AmountLCN,
+			TxHash:             transfer.TxHash,
+			TxCBOR:             transfer.TxCBOR,
+			Inputs:             transfer.Inputs,
+			RequiredSignatures: s.governance.Script.Required,
+			CreatedBy:          referral.ReviewedBy,
+			ExpiresAt:          expiresAt,
+		}
+		if err := s.governanceRepo.CreateGovernanceTx(ctx, govTx); err != nil {
+			return fail(err)
+		}
+		logger.Audit("GOVERNANCE_TX_CREATED", referral.ReviewedBy, map[string]interface{}{
+			"governance_tx_id":    govTx.ID,
+			"referral_id":         referral.ID,
+			"side":                payout.Side,
+			"amount_lcn":          payout.AmountLCN,
+			"tx_hash":             govTx.TxHash,
+			"required_signatures": govTx.RequiredSignatures,
+		})
+	}
+	return nil
+}
+
+// CompleteGovernancePayout records a submitted multi-sig transaction paying
+// one side of a referral and marks the referral PAID once every side is paid.
+// A referral that failed meanwhile keeps the payment; approving it again
+// only pays the other side.
+func (s *Service) CompleteGovernancePayout(ctx context.Context, govTx *models.GovernanceTx, txHash string) (*models.Referral, error) {
+	referral, err := s.referralRepo.GetReferralByID(ctx, govTx.ReferenceID)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPayout(ctx, referral, govTx.Side, txHash)
+	if referral.Status != models.ReferralPaying || len(Unpaid(referral)) > 0 {
+		return referral, nil
+	}
+	return referral, s.markPaid(ctx, referral)
+}
+
+// FailGovernancePayout marks a referral FAILED when a transaction paying it
+// failed or expired, so an admin can approve it again
+func (s *Service) FailGovernancePayout(ctx context.Context, govTx *models.GovernanceTx, reason string) error {
+	return s.referralRepo.CompletePayout(ctx, govTx.ReferenceID, models.ReferralFailed,
+		fmt.Sprintf("%s payout not submitted: %s", govTx.Side, reason))
+}
+
+// recordPayout stores the transaction paying one side of a referral
+func (s *Service) recordPayout(ctx context.Context, referral *models.Referral, side, txHash string) {
+	field := "referee_tx_hash"
+	if side == SideReferrer {
+		field = "referrer_tx_hash"
+		referral.ReferrerTxHash = txHash
+	} else {
+		referral.RefereeTxHash = txHash
+	}
+	if err := s.referralRepo.SetPayoutTx(ctx, referral.ID, field, txHash); err != nil {
+		logger.Error("Failed to record referral payout", err, map[string]interface{}{
+			"referral_id": referral.ID,
+			"tx_hash":     txHash,
+		})
+	}
+	if err := s.txLogRepo.SetTxMeta(ctx, txHash, map[string]interface{}{
+		"referral_id": referral.ID,
+		"side":        side,
+	}); err != nil {
+		logger.Error("Failed to record referral on transaction", err, map[string]interface{}{
+			"tx_hash": txHash,
+		})
+	}
+}
+
+// markPaid completes a referral whose rewards have all been sent
+func (s *Service) markPaid(ctx context.Context, referral *models.Referral) error {
+	if err := s.referralRepo.CompletePayout(ctx, referral.ID, models.ReferralPaid, ""); err != nil {
+		return err
+	}
+	referral.Status = models.ReferralPaid
+	referral.Error = ""
+	logger.Audit("REFERRAL_PAID", referral.Referee.ID, map[string]interface{}{
+		"referral_id":         referral.ID,
+		"referrer_id":         referral.Referrer.ID,
+		"referrer_reward_lcn": referral.ReferrerRewardLCN,
+		"referee_reward_lcn":  referral.RefereeRewardLCN,
+		"referrer_tx_hash":    referral.ReferrerTxHash,
+		"referee_tx_hash":     referral.RefereeTxHash,
+	})
+	return nil
+}
+
+// currentAddress returns the wallet address of a referral party now: a
+// customer may have moved to an external wallet since signing up
+func (s *Service) currentAddress(ctx context.Context, party models.ReferralParty) (string, error) {
+	if party.Role == models.RoleCustomer {
+		customer, err := s.userRepo.GetCustomerByID(ctx, party.ID)
+		if err != nil {
+			return "", err
+		}
+		return customer.Wallet.Address, nil
+	}
+	merchant, err := s.userRepo.GetMerchantByID(ctx, party.ID)
+	if err != nil {
+		return "", err
+	}
+	return merchant.Wallet.Address, nil
+}
@@ -0,0 +1,56 @@
+// Package refunds sends LCN back along a transaction between a merchant and
+// a customer: an issuance made in error is reversed to the merchant once the
+// customer consents (or an admin overrides), and a redemption is refunded to
+// the customer from the merchant's wallet.
+package refunds
+
+import (
+	"errors"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+var (
+	ErrTxNotFound          = errors.New("transaction not found")
+	ErrNotRefundable       = errors.New("transaction cannot be refunded")
+	ErrExceedsRefundable   = errors.New("amount exceeds what can still be refunded")
+	ErrExternalWallet      = errors.New("the customer holds their own wallet")
+	ErrInsufficientBalance = errors.New("insufficient LCN balance")
+	ErrNotPending          = errors.New("refund is not awaiting consent")
+)
+
+// Direction tells which way LCN goes back along a transaction of the
+// merchant's wallet, and the customer's address in it. Returns false if the
+// transaction is not one the merchant can refund.
+func Direction(tx *models.TxLog, merchantAddress string) (models.RefundDirection, string, bool) {
+	// Custodial redemptions were logged as issuances, so the addresses
+	// rather than the type tell which way the LCN went
+	if tx.Type != models.TxTypeIssuance && tx.Type != models.TxTypeRedemption {
+		return "", "", false
+	}
+	if tx.Status == models.TxStatusFailed {
+		return "", "", false
+	}
+	switch merchantAddress {
+	case tx.FromAddress:
+		return models.RefundToMerchant, tx.ToAddress, true
+	case tx.ToAddress:
+		return models.RefundToCustomer, tx.FromAddress, true
+	}
+	return "", "", false
+}
+
+// Refundable is what is left of a transaction once completed refunds and
+// refunds in progress are taken off
+func Refundable(tx *models.TxLog) uint64 {
+	taken := tx.MetaLCN("refunded_lcn") + tx.MetaLCN("refund_pending_lcn")
+	if taken >= tx.AmountLCN {
+		return 0
+	}
+	return tx.AmountLCN - taken
+}
+
+// Refunded is how much of a transaction has been refunded so far
+func Refunded(tx *models.TxLog) uint64 {
+	return tx.MetaLCN("refunded_lcn")
+}
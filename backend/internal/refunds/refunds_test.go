@@ -0,0 +1,57 @@
+package refunds
+
+import (
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestDirection(t *testing.T) {
+	const merchant, customer, other = "addr_merchant", "addr_customer", "addr_other"
+	tests := []struct {
+		name      string
+		tx        models.TxLog
+		direction models.RefundDirection
+		address   string
+		ok        bool
+	}{
+		{"issuance", models.TxLog{Type: models.TxTypeIssuance, FromAddress: merchant, ToAddress: customer}, models.RefundToMerchant, customer, true},
+		{"redemption", models.TxLog{Type: models.TxTypeRedemption, FromAddress: customer, ToAddress: merchant}, models.RefundToCustomer, customer, true},
+		// Custodial redemptions are logged as issuances
+		{"redemption logged as issuance", models.TxLog{Type: models.TxTypeIssuance, FromAddress: customer, ToAddress: merchant}, models.RefundToCustomer, customer, true},
+		{"another merchant", models.TxLog{Type: models.TxTypeIssuance, FromAddress: other, ToAddress: customer}, "", "", false},
+		{"failed", models.TxLog{Type: models.TxTypeIssuance, Status: models.TxStatusFailed, FromAddress: merchant, ToAddress: customer}, "", "", false},
+		{"refund", models.TxLog{Type: models.TxTypeRefund, FromAddress: merchant, ToAddress: customer}, "", "", false},
+		{"transfer", models.TxLog{Type: models.TxTypeTransfer, FromAddress: merchant, ToAddress: customer}, "", "", false},
+	}
+	for _, tt := range tests {
+		direction, address, ok := Direction(&tt.tx, merchant)
+		if direction != tt.direction || address != tt.address || ok != tt.ok {
+			t.Errorf("%s: got (%q, %q, %v), want (%q, %q, %v)", tt.name, direction, address, ok, tt.direction, tt.address, tt.ok)
+		}
+	}
+}
+
+func TestRefundable(t *testing.T) {
+	tests := []struct {
+		meta map[string]interface{}
+		want uint64
+	}{
+		{nil, 100},
+		{map[string]interface{}{"refunded_lcn": int32(30)}, 70},
+		{map[string]interface{}{"refunded_lcn": int64(30), "refund_pending_lcn": int64(20)}, 50},
+		{map[string]interface{}{"refund_pending_lcn": float64(100)}, 0},
+		{map[string]interface{}{"refunded_lcn": int64(150)}, 0},
+		{map[string]interface{}{"refunded_lcn": "30"}, 100},
+	}
+	for _, tt := range tests {
+		tx := &models.TxLog{AmountLCN: 100, Meta: tt.meta}
+		if got := Refundable(tx); got != tt.want {
+			t.Errorf("Refundable(%v) = %d, want %d", tt.meta, got, tt.want)
+		}
+	}
+
+	if got := Refunded(&models.TxLog{AmountLCN: 100, Meta: map[string]interface{}{"refunded_lcn": int64(30), "refund_pending_lcn": int64(20)}}); got != 30 {
+		t.Errorf("Refunded = %d, want 30", got)
+	}
+}
@@ -0,0 +1,316 @@
+package refunds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type Service struct {
+	cardanoService *cardano.CardanoService
+	userRepo       *storage.UserRepository
+	txLogRepo      *storage.TxLogRepository
+	refundRepo     *storage.RefundRepository
+}
+
+func NewService(
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	txLogRepo *storage.TxLogRepository,
+	refundRepo *storage.RefundRepository,
+) *Service {
+	return &Service{
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		txLogRepo:      txLogRepo,
+		refundRepo:     refundRepo,
+	}
+}
+
+// Quote is what a merchant can refund of one of their transactions
+type Quote struct {
+	Tx              *models.TxLog          `json:"transaction"`
+	Direction       models.RefundDirection `json:"direction"`
+	CustomerID      string                 `json:"customer_id"`
+	RefundableLCN   uint64                 `json:"refundable_lcn"`
+	RequiresConsent bool                   `json:"requires_consent"`
+}
+
+// Quote works out what the merchant can refund of a transaction
+func (s *Service) Quote(ctx context.Context, merchantID, txHash string) (*Quote, *models.Customer, error) {
+	merchant, err := s.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("merchant not found: %w", err)
+	}
+	tx, err := s.txLogRepo.GetTxLogByHash(ctx, txHash)
+	if err != nil {
+		return nil, nil, ErrTxNotFound
+	}
+	direction, customerAddress, ok := Direction(tx, merchant.Wallet.Address)
+	if !ok {
+		return nil, nil, ErrNotRefundable
+	}
+	// Catalog orders are refunded by cancelling them, which also returns
+	// the stock and voids the pickup code
+	if _, ok := tx.Meta["order_id"]; ok {
+		return nil, nil, fmt.Errorf("%w: cancel the catalog order instead", ErrNotRefundable)
+	}
+	customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, customerAddress)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: no customer holds %s", ErrNotRefundable, customerAddress)
+	}
+
+	return &Quote{
+		Tx:              tx,
+		Direction:       direction,
+		CustomerID:      customer.ID,
+		RefundableLCN:   Refundable(tx),
+		RequiresConsent: direction == models.RefundToMerchant,
+	}, customer, nil
+}
+
+// Request starts a refund of amountLCN (0: all that is left) of a
+// transaction of the merchant. Refunds to the customer are sent at once;
+// reversals of an issuance wait for the customer's consent. When sending
+// fails the refund is returned FAILED along with the error.
+func (s *Service) Request(ctx context.Context, merchantID, txHash string, amountLCN uint64, reason, requestedBy string) (*models.Refund, error) {
+	quote, customer, err := s.Quote(ctx, merchantID, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if amountLCN == 0 {
+		amountLCN = quote.RefundableLCN
+	}
+	if amountLCN == 0 || amountLCN > quote.RefundableLCN {
+		return nil, ErrExceedsRefundable
+	}
+	// The platform holds no key to send LCN back from the customer's own wallet
+	if quote.Direction == models.RefundToMerchant && customer.Wallet.Custody == models.WalletExternal {
+		return nil, ErrExternalWallet
+	}
+
+	reserved, err := s.txLogRepo.ReserveRefund(ctx, txHash, amountLCN)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		// Refunded concurrently
+		return nil, ErrExceedsRefundable
+	}
+
+	refund := &models.Refund{
+		MerchantID:     merchantID,
+		CustomerID:     customer.ID,
+		OriginalTxHash: txHash,
+		OriginalType:   quote.Tx.Type,
+		Direction:      quote.Direction,
+		AmountLCN:      amountLCN,
+		Reason:         reason,
+		Status:         models.RefundPendingConsent,
+		RequestedBy:    requestedBy,
+	}
+	if quote.Direction == models.RefundToCustomer {
+		refund.Status = models.RefundProcessing
+	}
+	if err := s.refundRepo.CreateRefund(ctx, refund); err != nil {
+		s.release(ctx, refund)
+		return nil, err
+	}
+
+	if refund.Status == models.RefundProcessing {
+		return s.execute(ctx, refund)
+	}
+	logger.Info("Refund awaiting customer consent", map[string]interface{}{
+		"refund_id":   refund.ID,
+		"customer_id": refund.CustomerID,
+		"amount_lcn":  refund.AmountLCN,
+	})
+	return refund, nil
+}
+
+// Consent records the customer's answer to a reversal awaiting consent,
+// sending the LCN back if they approve
+func (s *Service) Consent(ctx context.Context, id, customerID string, approve bool) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if refund.CustomerID != customerID {
+		return nil, storage.ErrRefundNotFound
+	}
+
+	now := time.Now().UTC()
+	if !approve {
+		return s.close(ctx, refund, models.RefundDeclined, customerID)
+	}
+	claimed, err := s.refundRepo.TransitionRefund(ctx, refund.ID, models.RefundPendingConsent, models.RefundProcessing, bson.M{
+		"consented_at": now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNotPending
+	}
+	refund.Status = models.RefundProcessing
+	refund.ConsentedAt = &now
+	return s.execute(ctx, refund)
+}
+
+// Override sends a reversal back without the customer's consent
+func (s *Service) Override(ctx context.Context, id, adminID, notes string) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.refundRepo.TransitionRefund(ctx, refund.ID, models.RefundPendingConsent, models.RefundProcessing, bson.M{
+		"overridden_by":  adminID,
+		"override_notes": notes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNotPending
+	}
+	refund.Status = models.RefundProcessing
+	refund.OverriddenBy = adminID
+	refund.OverrideNotes = notes
+	return s.execute(ctx, refund)
+}
+
+// Cancel withdraws a merchant's reversal still awaiting consent
+func (s *Service) Cancel(ctx context.Context, id, merchantID, cancelledBy string) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if refund.MerchantID != merchantID {
+		return nil, storage.ErrRefundNotFound
+	}
+	return s.close(ctx, refund, models.RefundCancelled, cancelledBy)
+}
+
+// close declines or cancels a refund awaiting consent and releases its amount
+func (s *Service) close(ctx context.Context, refund *models.Refund, status models.RefundStatus, closedBy string) (*models.Refund, error) {
+	closed, err := s.refundRepo.TransitionRefund(ctx, refund.ID, models.RefundPendingConsent, status, bson.M{
+		"closed_by": closedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, ErrNotPending
+	}
+	s.release(ctx, refund)
+	refund.Status = status
+	refund.ClosedBy = closedBy
+	return refund, nil
+}
+
+// execute sends a refund claimed for processing back along its transaction
+// and links the two
+func (s *Service) execute(ctx context.Context, refund *models.Refund) (*models.Refund, error) {
+	fail := func(err error) (*models.Refund, error) {
+		refund.Status = models.RefundFailed
+		refund.Error = err.Error()
+		if _, updateErr := s.refundRepo.TransitionRefund(ctx, refund.ID, models.RefundProcessing, models.RefundFailed, bson.M{
+			"error": refund.Error,
+		}); updateErr != nil {
+			logger.Error("Failed to record refund failure", updateErr, map[string]interface{}{
+				"refund_id": refund.ID,
+			})
+		}
+		s.release(ctx, refund)
+		return refund, err
+	}
+
+	merchant, err := s.userRepo.GetMerchantByID(ctx, refund.MerchantID)
+	if err != nil {
+		return fail(fmt.Errorf("merchant not found: %w", err))
+	}
+	customer, err := s.userRepo.GetCustomerByID(ctx, refund.CustomerID)
+	if err != nil {
+		return fail(fmt.Errorf("customer not found: %w", err))
+	}
+
+	// LCN goes back to the current wallet of either side, which may have
+	// been rotated since the original transaction
+	senderKey := crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: merchant.Wallet.Address, OwnerID: merchant.ID},
+		EncryptedKey: merchant.Wallet.EncryptedPrivateKey,
+	}
+	recipient := customer.Wallet.Address
+	if refund.Direction == models.RefundToMerchant {
+		if customer.Wallet.Custody == models.WalletExternal {
+			return fail(ErrExternalWallet)
+		}
+		senderKey = crypto.WalletKey{
+			KeyBinding:   crypto.KeyBinding{Address: customer.Wallet.Address, OwnerID: customer.ID},
+			EncryptedKey: customer.Wallet.EncryptedPrivateKey,
+		}
+		recipient = merchant.Wallet.Address
+	}
+
+	balance, err := s.cardanoService.GetBalance(senderKey.Address)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get balance: %w", err))
+	}
+	if balance.LCN < float64(refund.AmountLCN) {
+		return fail(ErrInsufficientBalance)
+	}
+
+	// Refunds are not earnings: they create no expiry lot and count towards
+	// no tier or referral
+	txHash, err := s.cardanoService.TransferADAAs(senderKey, recipient, refund.AmountLCN, models.TxTypeRefund)
+	if err != nil {
+		return fail(fmt.Errorf("failed to send refund: %w", err))
+	}
+	if err := s.txLogRepo.SetTxMeta(ctx, txHash, map[string]interface{}{
+		"refund_of": refund.OriginalTxHash,
+		"refund_id": refund.ID,
+		"reason":    refund.Reason,
+	}); err != nil {
+		logger.Error("Failed to record refund details", err, map[string]interface{}{
+			"tx_hash": txHash,
+		})
+	}
+	if err := s.txLogRepo.RecordRefund(ctx, refund.OriginalTxHash, txHash, refund.AmountLCN); err != nil {
+		logger.Error("Failed to link refund to its transaction", err, map[string]interface{}{
+			"tx_hash":   txHash,
+			"refund_of": refund.OriginalTxHash,
+		})
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.refundRepo.TransitionRefund(ctx, refund.ID, models.RefundProcessing, models.RefundCompleted, bson.M{
+		"tx_hash":      txHash,
+		"completed_at": now,
+	}); err != nil {
+		logger.Error("Failed to mark refund completed", err, map[string]interface{}{
+			"refund_id": refund.ID,
+			"tx_hash":   txHash,
+		})
+	}
+	refund.Status = models.RefundCompleted
+	refund.TxHash = txHash
+	refund.CompletedAt = &now
+	return refund, nil
+}
+
+// release gives back the amount a refund reserved on its transaction
+func (s *Service) release(ctx context.Context, refund *models.Refund) {
+	if err := s.txLogRepo.ReleaseRefund(ctx, refund.OriginalTxHash, refund.AmountLCN); err != nil {
+		logger.Error("Failed to release refund reservation", err, map[string]interface{}{
+			"refund_id": refund.ID,
+			"tx_hash":   refund.OriginalTxHash,
+		})
+	}
+}
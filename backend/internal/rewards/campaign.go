@@ -0,0 +1,126 @@
+package rewards
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+const maxCampaignMultiplier = 10
+
+// CampaignAward is the bonus one campaign adds to a reward
+type CampaignAward struct {
+	Campaign *models.Campaign
+	BonusLCN uint64
+}
+
+// ValidateCampaign checks a new campaign and normalizes its categories
+func ValidateCampaign(campaign *models.Campaign) error {
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if campaign.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch campaign.Type {
+	case models.CampaignTypeMultiplier:
+		if campaign.Multiplier <= 1 || campaign.Multiplier > maxCampaignMultiplier {
+			return fmt.Errorf("multiplier must be above 1 and at most %d", maxCampaignMultiplier)
+		}
+		campaign.BonusLCN = 0
+	case models.CampaignTypeBonus:
+		if campaign.BonusLCN == 0 {
+			return fmt.Errorf("bonus_lcn must be positive")
+		}
+		campaign.Multiplier = 0
+	default:
+		return fmt.Errorf("type must be %s or %s", models.CampaignTypeMultiplier, models.CampaignTypeBonus)
+	}
+	if !campaign.EndsAt.After(campaign.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if campaign.BudgetLCN == 0 {
+		return fmt.Errorf("budget_lcn must be positive")
+	}
+	if campaign.MinSpendETB < 0 {
+		return fmt.Errorf("min_spend_etb cannot be negative")
+	}
+
+	categories := make([]string, 0, len(campaign.Categories))
+	for _, category := range campaign.Categories {
+		if category = normalizeCategory(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	campaign.Categories = categories
+	return nil
+}
+
+// CampaignEligible reports whether a purchase qualifies for a campaign at now
+func CampaignEligible(campaign *models.Campaign, purchase Purchase, firstVisit bool, now time.Time) bool {
+	if campaign.Status != models.CampaignActive || now.Before(campaign.StartsAt) || !now.Before(campaign.EndsAt) {
+		return false
+	}
+	if campaign.SpentLCN >= campaign.BudgetLCN {
+		return false
+	}
+	if campaign.FirstVisitOnly && !firstVisit {
+		return false
+	}
+	if purchase.AmountETB < campaign.MinSpendETB {
+		return false
+	}
+	if len(campaign.Categories) == 0 {
+		return true
+	}
+	for _, item := range purchase.Items {
+		for _, category := range campaign.Categories {
+			if normalizeCategory(item.Category) == category {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CampaignBonus is the LCN a campaign adds to a base reward, before its budget
+func CampaignBonus(campaign *models.Campaign, baseLCN uint64) uint64 {
+	switch campaign.Type {
+	case models.CampaignTypeMultiplier:
+		return uint64(math.Floor(float64(baseLCN)*(campaign.Multiplier-1) + 1e-9))
+	case models.CampaignTypeBonus:
+		return campaign.BonusLCN
+	}
+	return 0
+}
+
+// SelectCampaigns picks the campaigns a purchase gets: every eligible
+// stackable campaign together, or the single best exclusive campaign if it
+// pays more. Multipliers apply to the base reward, never to other bonuses.
+func SelectCampaigns(campaigns []*models.Campaign, purchase Purchase, baseLCN uint64, firstVisit bool, now time.Time) []CampaignAward {
+	var stacked []CampaignAward
+	var stackedTotal uint64
+	var best *CampaignAward
+
+	for _, campaign := range campaigns {
+		if !CampaignEligible(campaign, purchase, firstVisit, now) {
+			continue
+		}
+		award := CampaignAward{Campaign: campaign, BonusLCN: CampaignBonus(campaign, baseLCN)}
+		if award.BonusLCN == 0 {
+			continue
+		}
+		if campaign.Stackable {
+			stacked = append(stacked, award)
+			stackedTotal += award.BonusLCN
+		} else if best == nil || award.BonusLCN > best.BonusLCN {
+			best = &award
+		}
+	}
+
+	if best != nil && best.BonusLCN > stackedTotal {
+		return []CampaignAward{*best}
+	}
+	return stacked
+}
@@ -0,0 +1,105 @@
+package rewards
+
+import (
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestSelectCampaigns(t *testing.T) {
+	now := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+	campaign := func(id, kind string, stackable bool) *models.Campaign {
+		return &models.Campaign{
+			ID:        id,
+			Type:      kind,
+			Status:    models.CampaignActive,
+			StartsAt:  now.Add(-time.Hour),
+			EndsAt:    now.Add(time.Hour),
+			BudgetLCN: 1000,
+			Stackable: stackable,
+		}
+	}
+
+	double := campaign("double", models.CampaignTypeMultiplier, true)
+	double.Multiplier = 2
+	bonus := campaign("bonus", models.CampaignTypeBonus, true)
+	bonus.BonusLCN = 20
+	firstVisit := campaign("first-visit", models.CampaignTypeBonus, false)
+	firstVisit.BonusLCN = 50
+	firstVisit.FirstVisitOnly = true
+	coffee := campaign("coffee", models.CampaignTypeBonus, true)
+	coffee.BonusLCN = 10
+	coffee.Categories = []string{"coffee"}
+
+	campaigns := []*models.Campaign{double, bonus, firstVisit, coffee}
+	purchase := Purchase{AmountETB: 100}
+
+	// Stackable campaigns combine: 100 extra from doubling plus 20
+	awards := SelectCampaigns(campaigns, purchase, 100, false, now)
+	if len(awards) != 2 || awards[0].BonusLCN != 100 || awards[1].BonusLCN != 20 {
+		t.Errorf("stacked awards = %+v", awards)
+	}
+
+	// The exclusive first-visit bonus wins only when it pays more
+	awards = SelectCampaigns(campaigns, purchase, 10, true, now)
+	if len(awards) != 1 || awards[0].Campaign.ID != "first-visit" {
+		t.Errorf("exclusive award = %+v", awards)
+	}
+	awards = SelectCampaigns(campaigns, purchase, 100, true, now)
+	if len(awards) != 2 {
+		t.Errorf("stacked awards should beat the exclusive one: %+v", awards)
+	}
+
+	// Category filter
+	awards = SelectCampaigns([]*models.Campaign{coffee}, Purchase{AmountETB: 100, Items: []PurchaseItem{{Category: "Coffee", AmountETB: 30}}}, 0, false, now)
+	if len(awards) != 1 || awards[0].BonusLCN != 10 {
+		t.Errorf("category award = %+v", awards)
+	}
+
+	// Outside the schedule, paused or out of budget
+	if awards := SelectCampaigns([]*models.Campaign{bonus}, purchase, 100, false, now.Add(2*time.Hour)); len(awards) != 0 {
+		t.Errorf("ended campaign applied: %+v", awards)
+	}
+	bonus.SpentLCN = bonus.BudgetLCN
+	if awards := SelectCampaigns([]*models.Campaign{bonus}, purchase, 100, false, now); len(awards) != 0 {
+		t.Errorf("exhausted campaign applied: %+v", awards)
+	}
+	double.Status = models.CampaignPaused
+	if awards := SelectCampaigns([]*models.Campaign{double}, purchase, 100, false, now); len(awards) != 0 {
+		t.Errorf("paused campaign applied: %+v", awards)
+	}
+}
+
+func TestValidateCampaign(t *testing.T) {
+	start := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	valid := &models.Campaign{
+		Name:       "Double points weekend",
+		Type:       models.CampaignTypeMultiplier,
+		Multiplier: 2,
+		StartsAt:   start,
+		EndsAt:     start.Add(48 * time.Hour),
+		BudgetLCN:  5000,
+		Categories: []string{" Coffee ", ""},
+	}
+	if err := ValidateCampaign(valid); err != nil {
+		t.Fatalf("valid campaign rejected: %v", err)
+	}
+	if len(valid.Categories) != 1 || valid.Categories[0] != "coffee" {
+		t.Errorf("categories should be normalized: %v", valid.Categories)
+	}
+
+	invalid := []*models.Campaign{
+		{Name: "", Type: models.CampaignTypeBonus, BonusLCN: 5, StartsAt: start, EndsAt: start.Add(time.Hour), BudgetLCN: 10},
+		{Name: "x", Type: "CASHBACK", StartsAt: start, EndsAt: start.Add(time.Hour), BudgetLCN: 10},
+		{Name: "x", Type: models.CampaignTypeMultiplier, Multiplier: 1, StartsAt: start, EndsAt: start.Add(time.Hour), BudgetLCN: 10},
+		{Name: "x", Type: models.CampaignTypeBonus, StartsAt: start, EndsAt: start.Add(time.Hour), BudgetLCN: 10},
+		{Name: "x", Type: models.CampaignTypeBonus, BonusLCN: 5, StartsAt: start, EndsAt: start, BudgetLCN: 10},
+		{Name: "x", Type: models.CampaignTypeBonus, BonusLCN: 5, StartsAt: start, EndsAt: start.Add(time.Hour)},
+	}
+	for i, campaign := range invalid {
+		if err := ValidateCampaign(campaign); err == nil {
+			t.Errorf("invalid campaign %d accepted", i)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rewards
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+const (
+	maxItemNameLength        = 80
+	maxItemDescriptionLength = 500
+	MaxItemValidityDays      = 365
+
+	orderCodeLength   = 8
+	orderCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // Crockford base32
+)
+
+// ValidateCatalogItem checks a catalog item a merchant creates or edits
+func ValidateCatalogItem(item *models.CatalogItem) error {
+	item.Name = strings.TrimSpace(item.Name)
+	item.Description = strings.TrimSpace(item.Description)
+	if item.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(item.Name) > maxItemNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxItemNameLength)
+	}
+	if utf8.RuneCountInString(item.Description) > maxItemDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxItemDescriptionLength)
+	}
+	if item.PriceLCN == 0 {
+		return fmt.Errorf("price_lcn must be positive")
+	}
+	if item.Stock < 0 {
+		return fmt.Errorf("stock cannot be negative")
+	}
+	if item.ValidityDays < 0 || item.ValidityDays > MaxItemValidityDays {
+		return fmt.Errorf("validity_days must be between 0 and %d", MaxItemValidityDays)
+	}
+	return nil
+}
+
+// OrderExpiry is when the code of an order of the item paid at now stops
+// being accepted; nil if the item has no validity limit
+func OrderExpiry(item *models.CatalogItem, now time.Time) *time.Time {
+	if item.ValidityDays == 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, item.ValidityDays)
+	return &expiresAt
+}
+
+// OrderStatus is the status to report for an order at now: ready orders past
+// their expiry are EXPIRED
+func OrderStatus(order *models.RedemptionOrder, now time.Time) models.OrderStatus {
+	if order.Status == models.OrderReady && order.ExpiresAt != nil && !now.Before(*order.ExpiresAt) {
+		return models.OrderExpired
+	}
+	return order.Status
+}
+
+// NewOrderCode generates the random 8 character code a customer shows to pick
+// up an order
+func NewOrderCode() (string, error) {
+	random := make([]byte, orderCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate order code: %w", err)
+	}
+	code := make([]byte, orderCodeLength)
+	for i, b := range random {
+		code[i] = orderCodeAlphabet[b%byte(len(orderCodeAlphabet))]
+	}
+	return string(code), nil
+}
+
+// NormalizeOrderCode upper-cases a code as typed by a cashier, reading the
+// letters Crockford base32 leaves out as the digits they resemble. Returns
+// false if it cannot be an order code.
+func NormalizeOrderCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "", "I", "1", "L", "1", "O", "0").Replace(code)
+	if len(code) != orderCodeLength {
+		return "", false
+	}
+	for _, char := range code {
+		if !strings.ContainsRune(orderCodeAlphabet, char) {
+			return "", false
+		}
+	}
+	return code, true
+}
@@ -0,0 +1,93 @@
+package rewards
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestValidateCatalogItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    models.CatalogItem
+		wantErr bool
+	}{
+		{"valid", models.CatalogItem{Name: " Free coffee ", PriceLCN: 50, Stock: 10, ValidityDays: 30}, false},
+		{"no validity limit", models.CatalogItem{Name: "Mug", PriceLCN: 200}, false},
+		{"blank name", models.CatalogItem{Name: "  ", PriceLCN: 50}, true},
+		{"long name", models.CatalogItem{Name: strings.Repeat("x", 81), PriceLCN: 50}, true},
+		{"free", models.CatalogItem{Name: "Mug"}, true},
+		{"negative stock", models.CatalogItem{Name: "Mug", PriceLCN: 50, Stock: -1}, true},
+		{"validity too long", models.CatalogItem{Name: "Mug", PriceLCN: 50, ValidityDays: 366}, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCatalogItem(&tt.item)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateCatalogItem() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	item := models.CatalogItem{Name: " Free coffee ", PriceLCN: 50}
+	if err := ValidateCatalogItem(&item); err != nil || item.Name != "Free coffee" {
+		t.Errorf("ValidateCatalogItem() name = %q, %v; want trimmed", item.Name, err)
+	}
+}
+
+func TestOrderStatus(t *testing.T) {
+	now := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+
+	if expiry := OrderExpiry(&models.CatalogItem{}, now); expiry != nil {
+		t.Errorf("OrderExpiry() = %v; want nil without a validity limit", expiry)
+	}
+	expiry := OrderExpiry(&models.CatalogItem{ValidityDays: 7}, now)
+	if expiry == nil || !expiry.Equal(now.AddDate(0, 0, 7)) {
+		t.Fatalf("OrderExpiry() = %v; want a week later", expiry)
+	}
+
+	order := &models.RedemptionOrder{Status: models.OrderReady, ExpiresAt: expiry}
+	if got := OrderStatus(order, now); got != models.OrderReady {
+		t.Errorf("OrderStatus() = %s; want READY before expiry", got)
+	}
+	if got := OrderStatus(order, *expiry); got != models.OrderExpired {
+		t.Errorf("OrderStatus() = %s; want EXPIRED at expiry", got)
+	}
+	order.Status = models.OrderFulfilled
+	if got := OrderStatus(order, expiry.Add(time.Hour)); got != models.OrderFulfilled {
+		t.Errorf("OrderStatus() = %s; want FULFILLED orders to stay so", got)
+	}
+	order = &models.RedemptionOrder{Status: models.OrderReady}
+	if got := OrderStatus(order, now.AddDate(10, 0, 0)); got != models.OrderReady {
+		t.Errorf("OrderStatus() = %s; want orders without expiry to stay READY", got)
+	}
+}
+
+func TestOrderCode(t *testing.T) {
+	code, err := NewOrderCode()
+	if err != nil {
+		t.Fatalf("NewOrderCode() error = %v", err)
+	}
+	if normalized, ok := NormalizeOrderCode(code); !ok || normalized != code {
+		t.Errorf("NormalizeOrderCode(%q) = %q, %v; want the code unchanged", code, normalized, ok)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"7K3M-9QXA", "7K3M9QXA", true},
+		{" 7k3m 9qxa ", "7K3M9QXA", true},
+		{"OIL23456", "01123456", true},
+		{"7K3M9QX", "", false},
+		{"7K3M9QXU", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeOrderCode(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeOrderCode(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package rewards
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+const (
+	maxPercentOfSpend     = 100
+	maxCategoryMultiplier = 10
+)
+
+// Purchase is what a point of sale reports to POST /lcn/earn
+type Purchase struct {
+	AmountETB float64
+	Items     []PurchaseItem
+}
+
+// PurchaseItem is a line of a purchase. Items only matter for category
+// multipliers; the part of the purchase not covered by items earns the base rate.
+type PurchaseItem struct {
+	Category  string
+	AmountETB float64
+}
+
+// EarnResult explains how a reward was computed
+type EarnResult struct {
+	AmountLCN     uint64 // reward to issue, after the daily cap
+	UncappedLCN   uint64 // reward before the daily cap
+	TierLCN       uint64 // part of the uncapped reward added by the customer's tier
+	BelowMinSpend bool
+	CapReached    bool
+}
+
+// ValidateEarnRule checks a rule before it is saved as a new version and
+// normalizes its category names
+func ValidateEarnRule(rule *models.EarnRule) error {
+	if rule.PercentOfSpend < 0 || rule.PercentOfSpend > maxPercentOfSpend {
+		return fmt.Errorf("percent_of_spend must be between 0 and %d", maxPercentOfSpend)
+	}
+	if rule.MinSpendETB < 0 {
+		return fmt.Errorf("min_spend_etb cannot be negative")
+	}
+	if rule.Enabled && rule.PercentOfSpend == 0 && rule.FixedPerVisit == 0 {
+		return fmt.Errorf("an enabled rule needs percent_of_spend or fixed_per_visit")
+	}
+
+	multipliers := make(map[string]float64, len(rule.CategoryMultipliers))
+	for category, multiplier := range rule.CategoryMultipliers {
+		category = normalizeCategory(category)
+		if category == "" {
+			return fmt.Errorf("category names cannot be empty")
+		}
+		if multiplier < 0 || multiplier > maxCategoryMultiplier {
+			return fmt.Errorf("multiplier for %q must be between 0 and %d", category, maxCategoryMultiplier)
+		}
+		if _, ok := multipliers[category]; ok {
+			return fmt.Errorf("category %q is listed twice", category)
+		}
+		multipliers[category] = multiplier
+	}
+	rule.CategoryMultipliers = multipliers
+	return nil
+}
+
+// ValidatePurchase rejects purchases whose items do not add up
+func ValidatePurchase(purchase Purchase) error {
+	if purchase.AmountETB <= 0 {
+		return fmt.Errorf("amount_etb must be positive")
+	}
+	var itemsETB float64
+	for _, item := range purchase.Items {
+		if item.AmountETB < 0 {
+			return fmt.Errorf("item amounts cannot be negative")
+		}
+		itemsETB += item.AmountETB
+	}
+	// Allow for rounding in the point of sale's line totals
+	if itemsETB > purchase.AmountETB+0.01 {
+		return fmt.Errorf("items add up to %.2f ETB, more than the purchase amount", itemsETB)
+	}
+	return nil
+}
+
+// ComputeEarn applies a rule to a purchase. etbPerLCN converts the percent
+// reward from ETB to LCN; earnedToday is what the customer has already earned
+// under this merchant's rules today, counted against the daily cap.
+// tierMultiplier is the customer's tier earn multiplier; the tier bonus counts
+// against the daily cap like the rest of the reward.
+func ComputeEarn(rule *models.EarnRule, purchase Purchase, etbPerLCN float64, earnedToday uint64, tierMultiplier float64) EarnResult {
+	var result EarnResult
+	if !rule.Enabled {
+		return result
+	}
+	if purchase.AmountETB < rule.MinSpendETB {
+		result.BelowMinSpend = true
+		return result
+	}
+
+	// Percent reward in ETB: items in a listed category are weighted by its
+	// multiplier, everything else counts once
+	weightedETB := purchase.AmountETB
+	for _, item := range purchase.Items {
+		if multiplier, ok := rule.CategoryMultipliers[normalizeCategory(item.Category)]; ok {
+			weightedETB += item.AmountETB * (multiplier - 1)
+		}
+	}
+	var percentLCN uint64
+	if etbPerLCN > 0 && weightedETB > 0 {
+		// The epsilon keeps e.g. 199.99999999 from rounding down a whole LCN
+		percentLCN = uint64(math.Floor(weightedETB*rule.PercentOfSpend/100/etbPerLCN + 1e-9))
+	}
+	result.UncappedLCN = percentLCN + rule.FixedPerVisit
+	if tierMultiplier > 1 {
+		tiered := uint64(math.Floor(float64(result.UncappedLCN)*tierMultiplier + 1e-9))
+		result.TierLCN = tiered - result.UncappedLCN
+		result.UncappedLCN = tiered
+	}
+	result.AmountLCN = result.UncappedLCN
+
+	if rule.DailyCapLCN > 0 {
+		remaining := uint64(0)
+		if earnedToday < rule.DailyCapLCN {
+			remaining = rule.DailyCapLCN - earnedToday
+		}
+		if result.AmountLCN >= remaining {
+			result.AmountLCN = remaining
+			result.CapReached = true
+		}
+	}
+	return result
+}
+
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
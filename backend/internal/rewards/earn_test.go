@@ -0,0 +1,110 @@
+package rewards
+
+import (
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+func TestComputeEarn(t *testing.T) {
+	rule := &models.EarnRule{
+		Enabled:             true,
+		PercentOfSpend:      10,
+		FixedPerVisit:       5,
+		CategoryMultipliers: map[string]float64{"coffee": 2},
+		MinSpendETB:         50,
+	}
+	tests := []struct {
+		name     string
+		purchase Purchase
+		want     uint64
+	}{
+		// 10% of 200 ETB is 20 ETB = 200 LCN at 0.1 ETB per LCN, plus 5 per visit
+		{"percent and fixed", Purchase{AmountETB: 200}, 205},
+		// The 100 ETB of coffee counts twice
+		{"category multiplier", Purchase{AmountETB: 200, Items: []PurchaseItem{{Category: " Coffee ", AmountETB: 100}}}, 305},
+		{"other categories", Purchase{AmountETB: 200, Items: []PurchaseItem{{Category: "tea", AmountETB: 100}}}, 205},
+		{"below minimum spend", Purchase{AmountETB: 49.99}, 0},
+	}
+	for _, tt := range tests {
+		if got := ComputeEarn(rule, tt.purchase, 0.1, 0, 1); got.AmountLCN != tt.want {
+			t.Errorf("%s: got %d LCN, want %d", tt.name, got.AmountLCN, tt.want)
+		}
+	}
+
+	rule.Enabled = false
+	if got := ComputeEarn(rule, Purchase{AmountETB: 200}, 0.1, 0, 1); got.AmountLCN != 0 {
+		t.Errorf("disabled rule earned %d LCN", got.AmountLCN)
+	}
+}
+
+func TestComputeEarn_DailyCap(t *testing.T) {
+	rule := &models.EarnRule{Enabled: true, FixedPerVisit: 40, DailyCapLCN: 100}
+
+	result := ComputeEarn(rule, Purchase{AmountETB: 10}, 1, 30, 1)
+	if result.AmountLCN != 40 || result.CapReached {
+		t.Errorf("under the cap: got %+v", result)
+	}
+	result = ComputeEarn(rule, Purchase{AmountETB: 10}, 1, 70, 1)
+	if result.AmountLCN != 30 || result.UncappedLCN != 40 || !result.CapReached {
+		t.Errorf("partly capped: got %+v", result)
+	}
+	result = ComputeEarn(rule, Purchase{AmountETB: 10}, 1, 120, 1)
+	if result.AmountLCN != 0 || !result.CapReached {
+		t.Errorf("over the cap: got %+v", result)
+	}
+}
+
+func TestComputeEarn_TierMultiplier(t *testing.T) {
+	rule := &models.EarnRule{Enabled: true, PercentOfSpend: 10, FixedPerVisit: 5, DailyCapLCN: 100}
+
+	// 10% of 150 ETB is 15 LCN, plus 5 per visit, times 1.5
+	result := ComputeEarn(rule, Purchase{AmountETB: 150}, 1, 0, 1.5)
+	if result.AmountLCN != 30 || result.TierLCN != 10 {
+		t.Errorf("tier bonus: got %+v", result)
+	}
+	// The tier bonus counts against the daily cap
+	result = ComputeEarn(rule, Purchase{AmountETB: 150}, 1, 80, 1.5)
+	if result.AmountLCN != 20 || result.UncappedLCN != 30 || !result.CapReached {
+		t.Errorf("capped tier bonus: got %+v", result)
+	}
+	if result := ComputeEarn(rule, Purchase{AmountETB: 150}, 1, 0, 0); result.AmountLCN != 20 || result.TierLCN != 0 {
+		t.Errorf("no tier: got %+v", result)
+	}
+}
+
+func TestValidateEarnRule(t *testing.T) {
+	rule := &models.EarnRule{Enabled: true, PercentOfSpend: 5, CategoryMultipliers: map[string]float64{" Bakery ": 1.5}}
+	if err := ValidateEarnRule(rule); err != nil {
+		t.Fatalf("valid rule rejected: %v", err)
+	}
+	if _, ok := rule.CategoryMultipliers["bakery"]; !ok {
+		t.Errorf("category names should be normalized: %v", rule.CategoryMultipliers)
+	}
+
+	invalid := []*models.EarnRule{
+		{Enabled: true},
+		{Enabled: true, PercentOfSpend: 101},
+		{Enabled: true, FixedPerVisit: 1, MinSpendETB: -1},
+		{Enabled: true, FixedPerVisit: 1, CategoryMultipliers: map[string]float64{"": 2}},
+		{Enabled: true, FixedPerVisit: 1, CategoryMultipliers: map[string]float64{"coffee": 11}},
+		{Enabled: true, FixedPerVisit: 1, CategoryMultipliers: map[string]float64{"Coffee": 2, "coffee": 3}},
+	}
+	for i, rule := range invalid {
+		if err := ValidateEarnRule(rule); err == nil {
+			t.Errorf("invalid rule %d accepted: %+v", i, rule)
+		}
+	}
+}
+
+func TestValidatePurchase(t *testing.T) {
+	if err := ValidatePurchase(Purchase{AmountETB: 100, Items: []PurchaseItem{{AmountETB: 60}, {AmountETB: 40}}}); err != nil {
+		t.Errorf("valid purchase rejected: %v", err)
+	}
+	if err := ValidatePurchase(Purchase{AmountETB: 100, Items: []PurchaseItem{{AmountETB: 101}}}); err == nil {
+		t.Error("items exceeding the purchase should be rejected")
+	}
+	if err := ValidatePurchase(Purchase{AmountETB: 0}); err == nil {
+		t.Error("empty purchase should be rejected")
+	}
+}
@@ -106,6 +106,7 @@ func (r *AllocationRepository) UpdateAllocation(ctx context.Context, allocation
 			"lcn_transfer_tx_hash": allocation.LCNTransferTxHash,
 			"admin_id":             allocation.AdminID,
 			"admin_notes":          allocation.AdminNotes,
+			"governance_tx_id":     allocation.GovernanceTxID,
 		},
 	}
 
@@ -146,3 +147,21 @@ func (r *AllocationRepository) GetAllPendingAllocations(ctx context.Context, lim
 
 	return allocations, total, nil
 }
+
+// Starts dual control on a pending allocation. Returns false if another admin initiated it first.
+func (r *AllocationRepository) InitiateApproval(ctx context.Context, id, adminID, notes string, required int) (bool, error) {
+	collection := r.db.GetCollection("allocation_purchases")
+	return initiateApproval(ctx, collection, id, []string{"PENDING"}, newApproval(adminID, notes), required)
+}
+
+// Records an admin's approval of a dual-controlled allocation
+func (r *AllocationRepository) AddApproval(ctx context.Context, id, adminID, notes string) ([]models.Approval, error) {
+	collection := r.db.GetCollection("allocation_purchases")
+	return addApproval(ctx, collection, id, []string{"PENDING"}, newApproval(adminID, notes))
+}
+
+// Atomically moves an allocation between statuses (fails if it is no longer in `from`)
+func (r *AllocationRepository) TransitionStatus(ctx context.Context, id, from, to string) error {
+	collection := r.db.GetCollection("allocation_purchases")
+	return transitionStatus(ctx, collection, id, []string{from}, to)
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Stores a new (already hashed) API key
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	apiKey.CreatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("api_keys")
+	result, err := collection.InsertOne(ctx, apiKey)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	apiKey.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Retrieves an active (non-revoked) API key by its hash
+func (r *APIKeyRepository) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	collection := r.db.GetCollection("api_keys")
+
+	var apiKey models.APIKey
+	err := collection.FindOne(ctx, bson.M{
+		"key_hash":   keyHash,
+		"revoked_at": bson.M{"$exists": false},
+	}).Decode(&apiKey)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("API key not found")
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &apiKey, nil
+}
+
+// Lists a merchant's API keys, newest first
+func (r *APIKeyRepository) GetAPIKeysByMerchant(ctx context.Context, merchantID string) ([]*models.APIKey, error) {
+	collection := r.db.GetCollection("api_keys")
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := collection.Find(ctx, bson.M{"merchant_id": merchantID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	apiKeys := []*models.APIKey{}
+	if err := cursor.All(ctx, &apiKeys); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+	return apiKeys, nil
+}
+
+// Revokes a merchant's API key
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id, merchantID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid API key ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("api_keys")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"merchant_id": merchantID,
+		"revoked_at":  bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"revoked_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("API key not found")
+	}
+	return nil
+}
+
+// Records the last use of an API key. Writes are throttled to one per minute per key.
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid API key ID: %w", err)
+	}
+
+	now := time.Now().UTC()
+	collection := r.db.GetCollection("api_keys")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id": objID,
+		"$or": []bson.M{
+			{"last_used_at": bson.M{"$exists": false}},
+			{"last_used_at": bson.M{"$lt": now.Add(-time.Minute)}},
+		},
+	}, bson.M{
+		"$set": bson.M{"last_used_at": now},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update API key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Dual-control helpers shared by allocation purchases and settlement requests.
+// Every step is a single conditional update so that concurrent admins cannot
+// initiate twice, approve twice or approve their own request.
+
+// initiateApproval starts dual control on a pending request.
+// Returns false if the request was already initiated or is no longer pending.
+func initiateApproval(ctx context.Context, collection *mongo.Collection, id string, pendingStatuses interface{}, initiation models.Approval, required int) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid request ID: %w", err)
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":        objID,
+		"status":     bson.M{"$in": pendingStatuses},
+		"initiation": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{
+			"initiation":         initiation,
+			"required_approvals": required,
+			"approvals":          []models.Approval{},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to initiate approval: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// addApproval records an approval from an admin who neither initiated nor
+// already approved the request. Returns all approvals after the update.
+func addApproval(ctx context.Context, collection *mongo.Collection, id string, pendingStatuses interface{}, approval models.Approval) ([]models.Approval, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request ID: %w", err)
+	}
+
+	var updated struct {
+		Approvals []models.Approval `bson:"approvals"`
+	}
+	err = collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":                 objID,
+		"status":              bson.M{"$in": pendingStatuses},
+		"initiation":          bson.M{"$exists": true},
+		"initiation.admin_id": bson.M{"$ne": approval.AdminID},
+		"approvals.admin_id":  bson.M{"$ne": approval.AdminID},
+	}, bson.M{
+		"$push": bson.M{"approvals": approval},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("approval not accepted: request is not awaiting approval from this admin")
+		}
+		return nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+	return updated.Approvals, nil
+}
+
+// transitionStatus atomically moves a request from one of the given statuses to a new one
+func transitionStatus(ctx context.Context, collection *mongo.Collection, id string, fromStatuses interface{}, to interface{}) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %w", err)
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": bson.M{"$in": fromStatuses},
+	}, bson.M{
+		"$set": bson.M{"status": to},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("request status changed concurrently")
+	}
+	return nil
+}
+
+func newApproval(adminID, notes string) models.Approval {
+	return models.Approval{
+		AdminID:    adminID,
+		Notes:      notes,
+		ApprovedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CampaignRepository struct {
+	db *DB
+}
+
+func NewCampaignRepository(db *DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+// Creates a new active campaign
+func (r *CampaignRepository) CreateCampaign(ctx context.Context, campaign *models.Campaign) error {
+	campaign.CreatedAt = time.Now().UTC()
+	campaign.Status = models.CampaignActive
+	campaign.SpentLCN = 0
+
+	collection := r.db.GetCollection("campaigns")
+	result, err := collection.InsertOne(ctx, campaign)
+	if err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	campaign.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Get campaign by ID
+func (r *CampaignRepository) GetCampaignByID(ctx context.Context, id string) (*models.Campaign, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("campaigns")
+	var campaign models.Campaign
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&campaign); err != nil {
+		return nil, fmt.Errorf("campaign not found: %w", err)
+	}
+	return &campaign, nil
+}
+
+// Retrieves a merchant's campaigns, newest first
+func (r *CampaignRepository) GetCampaignsByMerchant(ctx context.Context, merchantID string, limit, offset int, status *models.CampaignStatus) ([]*models.Campaign, int64, error) {
+	collection := r.db.GetCollection("campaigns")
+
+	filter := bson.M{"merchant_id": merchantID}
+	if status != nil {
+		filter["status"] = *status
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count campaigns: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query campaigns: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	campaigns := []*models.Campaign{}
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode campaigns: %w", err)
+	}
+
+	return campaigns, total, nil
+}
+
+// Retrieves a merchant's active campaigns running at now
+func (r *CampaignRepository) GetLiveCampaigns(ctx context.Context, merchantID string, now time.Time) ([]*models.Campaign, error) {
+	collection := r.db.GetCollection("campaigns")
+	cursor, err := collection.Find(ctx, bson.M{
+		"merchant_id": merchantID,
+		"status":      models.CampaignActive,
+		"starts_at":   bson.M{"$lte": now},
+		"ends_at":     bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query live campaigns: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	campaigns := []*models.Campaign{}
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, fmt.Errorf("failed to decode campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+// CommittedBudget is the unspent budget of a merchant's campaigns that have
+// not ended or been stopped; new campaigns cannot draw on it
+func (r *CampaignRepository) CommittedBudget(ctx context.Context, merchantID string, now time.Time) (uint64, error) {
+	collection := r.db.GetCollection("campaigns")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"merchant_id": merchantID,
+			"status":      bson.M{"$in": []models.CampaignStatus{models.CampaignActive, models.CampaignPaused}},
+			"ends_at":     bson.M{"$gt": now},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": bson.M{"$subtract": bson.A{"$budget_lcn", "$spent_lcn"}}},
+		}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum campaign budgets: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode campaign budgets: %w", err)
+	}
+	if len(results) == 0 || results[0].Total < 0 {
+		return 0, nil
+	}
+	return uint64(results[0].Total), nil
+}
+
+// Moves a merchant's campaign to a new status if it is in one of the given ones
+func (r *CampaignRepository) TransitionStatus(ctx context.Context, id, merchantID string, from []models.CampaignStatus, to models.CampaignStatus) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("campaigns")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"merchant_id": merchantID,
+		"status":      bson.M{"$in": from},
+	}, bson.M{
+		"$set": bson.M{"status": to},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update campaign: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("campaign not found or cannot move to %s", to)
+	}
+	return nil
+}
+
+// ReserveBudget takes up to amount LCN from an active campaign's remaining
+// budget and returns how much it got (0 once the budget is spent). The
+// campaign stops automatically when its budget runs out.
+func (r *CampaignRepository) ReserveBudget(ctx context.Context, id string, amount uint64) (uint64, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("campaigns")
+	// A single conditional update, capped at the remaining budget, so that
+	// concurrent issuances never overspend it nor miss what is left
+	var before models.Campaign
+	err = collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":    objID,
+		"status": models.CampaignActive,
+		"$expr":  bson.M{"$lt": bson.A{"$spent_lcn", "$budget_lcn"}},
+	}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"spent_lcn": bson.M{"$min": bson.A{bson.M{"$add": bson.A{"$spent_lcn", amount}}, "$budget_lcn"}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$spent_lcn", "$budget_lcn"}}, models.CampaignExhausted, "$status",
+			}},
+		}}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve campaign budget: %w", err)
+	}
+	return min(amount, before.BudgetLCN-before.SpentLCN), nil
+}
+
+// ReleaseBudget gives back budget reserved for an issuance that failed,
+// resuming the campaign if that reservation had exhausted it
+func (r *CampaignRepository) ReleaseBudget(ctx context.Context, id string, amount uint64) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("campaigns")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID, "spent_lcn": bson.M{"$gte": amount}}, bson.M{
+		"$inc": bson.M{"spent_lcn": -int64(amount)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release campaign budget: %w", err)
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": models.CampaignExhausted,
+		"$expr":  bson.M{"$lt": bson.A{"$spent_lcn", "$budget_lcn"}},
+	}, bson.M{
+		"$set": bson.M{"status": models.CampaignActive},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resume campaign: %w", err)
+	}
+	return nil
+}
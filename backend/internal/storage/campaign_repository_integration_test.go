@@ -0,0 +1,71 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignRepository_ReserveAndReleaseBudget(t *testing.T) {
+	repo := NewCampaignRepository(testDB(t))
+	ctx := context.Background()
+	campaign := &models.Campaign{MerchantID: "m1", Name: "Launch", BudgetLCN: 100}
+	require.NoError(t, repo.CreateCampaign(ctx, campaign))
+
+	granted, err := repo.ReserveBudget(ctx, campaign.ID, 60)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(60), granted)
+
+	// Only what is left of the budget is granted, and that exhausts it
+	granted, err = repo.ReserveBudget(ctx, campaign.ID, 60)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(40), granted)
+	stored, err := repo.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), stored.SpentLCN)
+	assert.Equal(t, models.CampaignExhausted, stored.Status)
+
+	granted, err = repo.ReserveBudget(ctx, campaign.ID, 10)
+	require.NoError(t, err)
+	assert.Zero(t, granted)
+
+	// Releasing budget resumes the campaign
+	require.NoError(t, repo.ReleaseBudget(ctx, campaign.ID, 40))
+	stored, err = repo.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(60), stored.SpentLCN)
+	assert.Equal(t, models.CampaignActive, stored.Status)
+}
+
+func TestCampaignRepository_ConcurrentReservationsSpendTheBudget(t *testing.T) {
+	repo := NewCampaignRepository(testDB(t))
+	campaign := &models.Campaign{MerchantID: "m1", Name: "Launch", BudgetLCN: 100}
+	require.NoError(t, repo.CreateCampaign(context.Background(), campaign))
+
+	var granted atomic.Uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			amount, err := repo.ReserveBudget(context.Background(), campaign.ID, 30)
+			assert.NoError(t, err)
+			granted.Add(amount)
+		}()
+	}
+	wg.Wait()
+
+	// However the reservations race, the whole budget is handed out and no more
+	assert.Equal(t, uint64(100), granted.Load())
+	stored, err := repo.GetCampaignByID(context.Background(), campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), stored.SpentLCN)
+	assert.Equal(t, models.CampaignExhausted, stored.Status)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrCatalogItemNotFound = errors.New("catalog item not found")
+
+type CatalogRepository struct {
+	db *DB
+}
+
+func NewCatalogRepository(db *DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+func (r *CatalogRepository) CreateItem(ctx context.Context, item *models.CatalogItem) error {
+	item.CreatedAt = time.Now().UTC()
+	item.UpdatedAt = item.CreatedAt
+
+	collection := r.db.GetCollection("catalog_items")
+	result, err := collection.InsertOne(ctx, item)
+	if err != nil {
+		return fmt.Errorf("failed to create catalog item: %w", err)
+	}
+	item.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *CatalogRepository) GetItemByID(ctx context.Context, id string) (*models.CatalogItem, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrCatalogItemNotFound
+	}
+
+	collection := r.db.GetCollection("catalog_items")
+	var item models.CatalogItem
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&item); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCatalogItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get catalog item: %w", err)
+	}
+	return &item, nil
+}
+
+// GetItemsByMerchant lists a merchant's catalog, active or not, newest first
+func (r *CatalogRepository) GetItemsByMerchant(ctx context.Context, merchantID string, limit, offset int) ([]*models.CatalogItem, int64, error) {
+	return r.list(ctx, bson.M{"merchant_id": merchantID}, limit, offset)
+}
+
+// GetAvailableItems lists the active items in stock, of one merchant or all
+// when merchantID is empty, newest first
+func (r *CatalogRepository) GetAvailableItems(ctx context.Context, merchantID string, limit, offset int) ([]*models.CatalogItem, int64, error) {
+	filter := bson.M{"active": true, "stock": bson.M{"$gt": 0}}
+	if merchantID != "" {
+		filter["merchant_id"] = merchantID
+	}
+	return r.list(ctx, filter, limit, offset)
+}
+
+func (r *CatalogRepository) list(ctx context.Context, filter bson.M, limit, offset int) ([]*models.CatalogItem, int64, error) {
+	collection := r.db.GetCollection("catalog_items")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count catalog items: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query catalog items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := []*models.CatalogItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode catalog items: %w", err)
+	}
+	return items, total, nil
+}
+
+// UpdateItem saves the fields a merchant can edit. Orders placed before keep
+// the price and validity they were placed with.
+func (r *CatalogRepository) UpdateItem(ctx context.Context, item *models.CatalogItem) error {
+	objID, err := primitive.ObjectIDFromHex(item.ID)
+	if err != nil {
+		return fmt.Errorf("invalid catalog item ID: %w", err)
+	}
+	item.UpdatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("catalog_items")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{
+			"name":          item.Name,
+			"description":   item.Description,
+			"image_url":     item.ImageURL,
+			"price_lcn":     item.PriceLCN,
+			"stock":         item.Stock,
+			"validity_days": item.ValidityDays,
+			"active":        item.Active,
+			"updated_at":    item.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update catalog item: %w", err)
+	}
+	return nil
+}
+
+// ReserveStock takes one unit of an active item for an order. Returns false
+// if it is out of stock or no longer active.
+func (r *CatalogRepository) ReserveStock(ctx context.Context, id string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid catalog item ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("catalog_items")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"active": true,
+		"stock":  bson.M{"$gt": 0},
+	}, bson.M{
+		"$inc": bson.M{"stock": -1},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ReleaseStock returns the unit of a failed or cancelled order
+func (r *CatalogRepository) ReleaseStock(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid catalog item ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("catalog_items")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$inc": bson.M{"stock": 1},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release stock: %w", err)
+	}
+	return nil
+}
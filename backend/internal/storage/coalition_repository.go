@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCoalitionNotFound   = errors.New("coalition not found")
+	ErrMerchantInCoalition = errors.New("merchant already belongs to another coalition")
+)
+
+// Coalitions, the clearing entries between their members and the nettings
+// that settle them
+type CoalitionRepository struct {
+	db *DB
+}
+
+func NewCoalitionRepository(db *DB) *CoalitionRepository {
+	return &CoalitionRepository{db: db}
+}
+
+// CreateCoalition records a coalition. A merchant belongs to one coalition at
+// most; ErrMerchantInCoalition is returned otherwise.
+func (r *CoalitionRepository) CreateCoalition(ctx context.Context, coalition *models.Coalition) error {
+	coalition.CreatedAt = time.Now().UTC()
+	coalition.UpdatedAt = coalition.CreatedAt
+
+	collection := r.db.GetCollection("coalitions")
+	result, err := collection.InsertOne(ctx, coalition)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMerchantInCoalition
+		}
+		return fmt.Errorf("failed to create coalition: %w", err)
+	}
+	coalition.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// UpdateCoalition replaces a coalition's name, members and rules. Entries
+// already recorded keep the rate they were recorded at.
+func (r *CoalitionRepository) UpdateCoalition(ctx context.Context, id, name string, merchantIDs []string, rules models.ClearingRules) (*models.Coalition, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrCoalitionNotFound
+	}
+	collection := r.db.GetCollection("coalitions")
+	var coalition models.Coalition
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"name":         name,
+		"merchant_ids": merchantIDs,
+		"rules":        rules,
+		"updated_at":   time.Now().UTC(),
+	}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&coalition)
+	if err != nil {
+		switch {
+		case err == mongo.ErrNoDocuments:
+			return nil, ErrCoalitionNotFound
+		case mongo.IsDuplicateKeyError(err):
+			return nil, ErrMerchantInCoalition
+		}
+		return nil, fmt.Errorf("failed to update coalition: %w", err)
+	}
+	return &coalition, nil
+}
+
+func (r *CoalitionRepository) GetCoalitionByID(ctx context.Context, id string) (*models.Coalition, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrCoalitionNotFound
+	}
+	return r.findCoalition(ctx, bson.M{"_id": objID})
+}
+
+// GetCoalitionByMerchant returns the coalition a merchant belongs to
+func (r *CoalitionRepository) GetCoalitionByMerchant(ctx context.Context, merchantID string) (*models.Coalition, error) {
+	return r.findCoalition(ctx, bson.M{"merchant_ids": merchantID})
+}
+
+func (r *CoalitionRepository) findCoalition(ctx context.Context, filter bson.M) (*models.Coalition, error) {
+	collection := r.db.GetCollection("coalitions")
+
+	var coalition models.Coalition
+	if err := collection.FindOne(ctx, filter).Decode(&coalition); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCoalitionNotFound
+		}
+		return nil, fmt.Errorf("failed to get coalition: %w", err)
+	}
+	return &coalition, nil
+}
+
+// ListCoalitions lists coalitions by name
+func (r *CoalitionRepository) ListCoalitions(ctx context.Context, limit, offset int) ([]*models.Coalition, int64, error) {
+	collection := r.db.GetCollection("coalitions")
+	total, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count coalitions: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "name", Value: 1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query coalitions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	coalitions := []*models.Coalition{}
+	if err := cursor.All(ctx, &coalitions); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode coalitions: %w", err)
+	}
+	return coalitions, total, nil
+}
+
+// GetDueCoalitions returns coalitions whose netting is due, most overdue first
+func (r *CoalitionRepository) GetDueCoalitions(ctx context.Context, now time.Time, limit int) ([]*models.Coalition, error) {
+	collection := r.db.GetCollection("coalitions")
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "next_netting_at", Value: 1}})
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, bson.M{"next_netting_at": bson.M{"$lte": now}}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due coalitions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var coalitions []*models.Coalition
+	if err := cursor.All(ctx, &coalitions); err != nil {
+		return nil, fmt.Errorf("failed to decode coalitions: %w", err)
+	}
+	return coalitions, nil
+}
+
+// ClaimNetting schedules a coalition's next netting for one run to net it.
+// Returns false if another run moved the schedule since it was read.
+func (r *CoalitionRepository) ClaimNetting(ctx context.Context, id string, scheduled, now, next time.Time) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, ErrCoalitionNotFound
+	}
+	collection := r.db.GetCollection("coalitions")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID, "next_netting_at": scheduled}, bson.M{"$set": bson.M{
+		"next_netting_at": next,
+		"last_netted_at":  now,
+	}})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim coalition netting: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// CreateClearingEntry records an open clearing entry. There is one entry per
+// transaction and pair of members; a repeat returns a duplicate key error.
+func (r *CoalitionRepository) CreateClearingEntry(ctx context.Context, entry *models.ClearingEntry) error {
+	entry.Status = models.ClearingOpen
+	entry.CreatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("clearing_entries")
+	result, err := collection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to create clearing entry: %w", err)
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// GetRedemptionEntries returns the clearing entries a redemption created,
+// leaving out reversals
+func (r *CoalitionRepository) GetRedemptionEntries(ctx context.Context, txHash string) ([]*models.ClearingEntry, error) {
+	collection := r.db.GetCollection("clearing_entries")
+	cursor, err := collection.Find(ctx, bson.M{"tx_hash": txHash, "refund_of": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clearing entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.ClearingEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode clearing entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetMerchantEntries lists the clearing entries a merchant owes or is owed
+// in a coalition, optionally by status, newest first
+func (r *CoalitionRepository) GetMerchantEntries(ctx context.Context, coalitionID, merchantID string, status *models.ClearingStatus, limit, offset int) ([]*models.ClearingEntry, int64, error) {
+	filter := bson.M{
+		"coalition_id": coalitionID,
+		"$or":          []bson.M{{"payer_id": merchantID}, {"payee_id": merchantID}},
+	}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	collection := r.db.GetCollection("clearing_entries")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count clearing entries: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query clearing entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*models.ClearingEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode clearing entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// OpenBalances sums a coalition's open entries by pair of members
+func (r *CoalitionRepository) OpenBalances(ctx context.Context, coalitionID string) ([]models.ClearingBalance, error) {
+	return r.balances(ctx, bson.M{"coalition_id": coalitionID, "status": models.ClearingOpen})
+}
+
+// NettedBalances sums the entries of a netting by pair of members
+func (r *CoalitionRepository) NettedBalances(ctx context.Context, nettingID string) ([]models.ClearingBalance, error) {
+	return r.balances(ctx, bson.M{"netting_id": nettingID})
+}
+
+func (r *CoalitionRepository) balances(ctx context.Context, filter bson.M) ([]models.ClearingBalance, error) {
+	collection := r.db.GetCollection("clearing_entries")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        bson.M{"payer_id": "$payer_id", "payee_id": "$payee_id"},
+			"amount_lcn": bson.M{"$sum": "$amount_lcn"},
+			"entries":    bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.payer_id", Value: 1}, {Key: "_id.payee_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum clearing entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Pair struct {
+			PayerID string `bson:"payer_id"`
+			PayeeID string `bson:"payee_id"`
+		} `bson:"_id"`
+		AmountLCN int64 `bson:"amount_lcn"`
+		Entries   int   `bson:"entries"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode clearing balances: %w", err)
+	}
+	balances := make([]models.ClearingBalance, len(results))
+	for i, result := range results {
+		balances[i] = models.ClearingBalance{
+			PayerID:   result.Pair.PayerID,
+			PayeeID:   result.Pair.PayeeID,
+			AmountLCN: uint64(result.AmountLCN),
+			Entries:   result.Entries,
+		}
+	}
+	return balances, nil
+}
+
+// NetEntries closes a coalition's open entries recorded up to cutoff into a
+// netting and returns how many it closed
+func (r *CoalitionRepository) NetEntries(ctx context.Context, coalitionID, nettingID string, cutoff time.Time) (int64, error) {
+	collection := r.db.GetCollection("clearing_entries")
+	result, err := collection.UpdateMany(ctx, bson.M{
+		"coalition_id": coalitionID,
+		"status":       models.ClearingOpen,
+		"created_at":   bson.M{"$lte": cutoff},
+	}, bson.M{"$set": bson.M{
+		"status":     models.ClearingNetted,
+		"netting_id": nettingID,
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to net clearing entries: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// CreateNetting records the start of a netting run
+func (r *CoalitionRepository) CreateNetting(ctx context.Context, netting *models.Netting) error {
+	netting.CreatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("nettings")
+	result, err := collection.InsertOne(ctx, netting)
+	if err != nil {
+		return fmt.Errorf("failed to create netting: %w", err)
+	}
+	netting.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// CompleteNetting records the outcome of a netting run
+func (r *CoalitionRepository) CompleteNetting(ctx context.Context, netting *models.Netting) error {
+	objID, err := primitive.ObjectIDFromHex(netting.ID)
+	if err != nil {
+		return fmt.Errorf("invalid netting ID: %w", err)
+	}
+	collection := r.db.GetCollection("nettings")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"entries":   netting.Entries,
+		"gross_lcn": netting.GrossLCN,
+		"positions": netting.Positions,
+		"payments":  netting.Payments,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to complete netting: %w", err)
+	}
+	return nil
+}
+
+// GetNettings lists a coalition's nettings, newest first
+func (r *CoalitionRepository) GetNettings(ctx context.Context, coalitionID string, limit, offset int) ([]*models.Netting, int64, error) {
+	filter := bson.M{"coalition_id": coalitionID}
+	collection := r.db.GetCollection("nettings")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count nettings: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query nettings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	nettings := []*models.Netting{}
+	if err := cursor.All(ctx, &nettings); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode nettings: %w", err)
+	}
+	return nettings, total, nil
+}
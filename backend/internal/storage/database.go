@@ -7,6 +7,7 @@ import (
 
 	"github.com/loyalcoin/backend/internal/config"
 	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -70,6 +71,12 @@ func (db *DB) createIndexes(ctx context.Context) error {
 		{
 			Keys: map[string]interface{}{"status": 1},
 		},
+		{
+			Keys: map[string]interface{}{"referral_code": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(map[string]interface{}{
+				"referral_code": map[string]interface{}{"$gt": ""},
+			}),
+		},
 	}
 	if _, err := merchantCollection.Indexes().CreateMany(ctx, merchantIndexes); err != nil {
 		return fmt.Errorf("failed to create merchant indexes: %w", err)
@@ -86,6 +93,30 @@ func (db *DB) createIndexes(ctx context.Context) error {
 			Keys:    map[string]interface{}{"wallet.address": 1},
 			Options: options.Index().SetUnique(true),
 		},
+		// Identifier lookups when merchants issue LCN; not unique, as existing
+		// accounts may share them (ambiguous lookups are rejected instead)
+		{
+			Keys: map[string]interface{}{"phone": 1},
+		},
+		{
+			Keys: map[string]interface{}{"username": 1},
+		},
+		{
+			Keys: map[string]interface{}{"handle": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(map[string]interface{}{
+				"handle": map[string]interface{}{"$gt": ""},
+			}),
+		},
+		// Nightly tier re-evaluation
+		{
+			Keys: bson.D{{Key: "loyalty.evaluated_at", Value: 1}, {Key: "_id", Value: 1}},
+		},
+		{
+			Keys: map[string]interface{}{"referral_code": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(map[string]interface{}{
+				"referral_code": map[string]interface{}{"$gt": ""},
+			}),
+		},
 	}
 	if _, err := customerCollection.Indexes().CreateMany(ctx, customerIndexes); err != nil {
 		return fmt.Errorf("failed to create customer indexes: %w", err)
@@ -113,6 +144,11 @@ func (db *DB) createIndexes(ctx context.Context) error {
 		{
 			Keys: map[string]interface{}{"submitted_at": -1},
 		},
+		{
+			// Issuances tagged by campaigns, for campaign stats
+			Keys:    map[string]interface{}{"meta.campaigns.campaign_id": 1},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 	if _, err := txLogCollection.Indexes().CreateMany(ctx, txLogIndexes); err != nil {
 		return fmt.Errorf("failed to create transaction log indexes: %w", err)
@@ -147,6 +183,323 @@ func (db *DB) createIndexes(ctx context.Context) error {
 		return fmt.Errorf("failed to create UTXO cache indexes: %w", err)
 	}
 
+	// API key indexes
+	apiKeyCollection := db.Database.Collection("api_keys")
+	apiKeyIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"key_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"merchant_id": 1},
+		},
+	}
+	if _, err := apiKeyCollection.Indexes().CreateMany(ctx, apiKeyIndexes); err != nil {
+		return fmt.Errorf("failed to create API key indexes: %w", err)
+	}
+
+	// Merchant staff indexes
+	staffCollection := db.Database.Collection("merchant_staff")
+	staffIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"email": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"merchant_id": 1},
+		},
+	}
+	if _, err := staffCollection.Indexes().CreateMany(ctx, staffIndexes); err != nil {
+		return fmt.Errorf("failed to create merchant staff indexes: %w", err)
+	}
+
+	// Governance multi-sig transaction indexes
+	governanceCollection := db.Database.Collection("governance_transactions")
+	governanceIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"status": 1},
+		},
+		{
+			Keys: map[string]interface{}{"reference_id": 1},
+		},
+	}
+	if _, err := governanceCollection.Indexes().CreateMany(ctx, governanceIndexes); err != nil {
+		return fmt.Errorf("failed to create governance transaction indexes: %w", err)
+	}
+
+	// Unsigned transactions from customers' external wallets
+	externalTxCollection := db.Database.Collection("external_transactions")
+	externalTxIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{"customer_id": 1},
+		},
+		{
+			Keys: map[string]interface{}{"status": 1},
+		},
+	}
+	if _, err := externalTxCollection.Indexes().CreateMany(ctx, externalTxIndexes); err != nil {
+		return fmt.Errorf("failed to create external transaction indexes: %w", err)
+	}
+
+	// Rewards issued to customers who have not signed up yet
+	pendingRewardCollection := db.Database.Collection("pending_rewards")
+	pendingRewardIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "identifier", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			Keys: map[string]interface{}{"merchant_id": 1},
+		},
+	}
+	if _, err := pendingRewardCollection.Indexes().CreateMany(ctx, pendingRewardIndexes); err != nil {
+		return fmt.Errorf("failed to create pending reward indexes: %w", err)
+	}
+
+	// Merchant payment requests (invoices paid by customers)
+	paymentRequestCollection := db.Database.Collection("payment_requests")
+	paymentRequestIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    map[string]interface{}{"tx_hash": 1},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+	if _, err := paymentRequestCollection.Indexes().CreateMany(ctx, paymentRequestIndexes); err != nil {
+		return fmt.Errorf("failed to create payment request indexes: %w", err)
+	}
+
+	// Earn rule versions
+	earnRuleCollection := db.Database.Collection("earn_rules")
+	earnRuleIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "merchant_id", Value: 1}, {Key: "version", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := earnRuleCollection.Indexes().CreateMany(ctx, earnRuleIndexes); err != nil {
+		return fmt.Errorf("failed to create earn rule indexes: %w", err)
+	}
+
+	// Campaigns
+	campaignCollection := db.Database.Collection("campaigns")
+	campaignIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "ends_at", Value: 1}},
+		},
+	}
+	if _, err := campaignCollection.Indexes().CreateMany(ctx, campaignIndexes); err != nil {
+		return fmt.Errorf("failed to create campaign indexes: %w", err)
+	}
+
+	// Issued LCN lots, spent oldest first and expired by the expiry sweep
+	lotCollection := db.Database.Collection("lcn_lots")
+	lotIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"tx_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "customer_address", Value: 1}, {Key: "issued_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+	}
+	if _, err := lotCollection.Indexes().CreateMany(ctx, lotIndexes); err != nil {
+		return fmt.Errorf("failed to create lot indexes: %w", err)
+	}
+
+	// Referred signups
+	referralCollection := db.Database.Collection("referrals")
+	referralIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"referee.id": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "referrer.id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		// Fraud heuristics
+		{
+			Keys: bson.D{{Key: "signup_ip", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		{
+			Keys: map[string]interface{}{"device_id": 1},
+		},
+	}
+	if _, err := referralCollection.Indexes().CreateMany(ctx, referralIndexes); err != nil {
+		return fmt.Errorf("failed to create referral indexes: %w", err)
+	}
+
+	// Merchant reward catalogs and the orders placed against them
+	catalogCollection := db.Database.Collection("catalog_items")
+	catalogIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "active", Value: 1}, {Key: "stock", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	if _, err := catalogCollection.Indexes().CreateMany(ctx, catalogIndexes); err != nil {
+		return fmt.Errorf("failed to create catalog indexes: %w", err)
+	}
+
+	orderCollection := db.Database.Collection("redemption_orders")
+	orderIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "merchant_id", Value: 1}, {Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	if _, err := orderCollection.Indexes().CreateMany(ctx, orderIndexes); err != nil {
+		return fmt.Errorf("failed to create redemption order indexes: %w", err)
+	}
+
+	refundCollection := db.Database.Collection("refunds")
+	refundIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: map[string]interface{}{"original_tx_hash": 1},
+		},
+	}
+	if _, err := refundCollection.Indexes().CreateMany(ctx, refundIndexes); err != nil {
+		return fmt.Errorf("failed to create refund indexes: %w", err)
+	}
+
+	voucherCollection := db.Database.Collection("vouchers")
+	voucherIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "merchant_id", Value: 1}, {Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "policy_id", Value: 1}, {Key: "asset_name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "holder_customer_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "synced_at", Value: 1}},
+		},
+		{
+			Keys: map[string]interface{}{"mint_tx_hash": 1},
+		},
+		{
+			Keys: map[string]interface{}{"burn_tx_hash": 1},
+		},
+	}
+	if _, err := voucherCollection.Indexes().CreateMany(ctx, voucherIndexes); err != nil {
+		return fmt.Errorf("failed to create voucher indexes: %w", err)
+	}
+
+	// A merchant belongs to one coalition at most
+	coalitionCollection := db.Database.Collection("coalitions")
+	coalitionIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"merchant_ids": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: map[string]interface{}{"next_netting_at": 1},
+		},
+	}
+	if _, err := coalitionCollection.Indexes().CreateMany(ctx, coalitionIndexes); err != nil {
+		return fmt.Errorf("failed to create coalition indexes: %w", err)
+	}
+
+	clearingCollection := db.Database.Collection("clearing_entries")
+	clearingIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tx_hash", Value: 1}, {Key: "payer_id", Value: 1}, {Key: "payee_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "coalition_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "coalition_id", Value: 1}, {Key: "payer_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "coalition_id", Value: 1}, {Key: "payee_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: map[string]interface{}{"netting_id": 1},
+		},
+	}
+	if _, err := clearingCollection.Indexes().CreateMany(ctx, clearingIndexes); err != nil {
+		return fmt.Errorf("failed to create clearing entry indexes: %w", err)
+	}
+
+	nettingCollection := db.Database.Collection("nettings")
+	nettingIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "coalition_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+	if _, err := nettingCollection.Indexes().CreateMany(ctx, nettingIndexes); err != nil {
+		return fmt.Errorf("failed to create netting indexes: %w", err)
+	}
+
+	// Idempotency keys are removed by MongoDB once they expire
+	idempotencyCollection := db.Database.Collection("idempotency_keys")
+	idempotencyIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := idempotencyCollection.Indexes().CreateMany(ctx, idempotencyIndexes); err != nil {
+		return fmt.Errorf("failed to create idempotency key indexes: %w", err)
+	}
+
+	// Daily transfer counts are removed by MongoDB once the day has passed
+	dailyTransfersCollection := db.Database.Collection("daily_transfers")
+	dailyTransfersIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := dailyTransfersCollection.Indexes().CreateMany(ctx, dailyTransfersIndexes); err != nil {
+		return fmt.Errorf("failed to create daily transfer indexes: %w", err)
+	}
+
+	// Daily earn counts likewise
+	dailyEarningsCollection := db.Database.Collection("daily_earnings")
+	dailyEarningsIndexes := []mongo.IndexModel{
+		{
+			Keys:    map[string]interface{}{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := dailyEarningsCollection.Indexes().CreateMany(ctx, dailyEarningsIndexes); err != nil {
+		return fmt.Errorf("failed to create daily earnings indexes: %w", err)
+	}
+
 	logger.Info("Successfully created database indexes", nil)
 	return nil
 }
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a day's earn counts are kept
+const dailyEarningsRetention = 48 * time.Hour
+
+type EarnLimitRepository struct {
+	db *DB
+}
+
+func NewEarnLimitRepository(db *DB) *EarnLimitRepository {
+	return &EarnLimitRepository{db: db}
+}
+
+func dailyEarningsID(merchantAddress, customerAddress string, day time.Time) string {
+	return merchantAddress + ":" + customerAddress + ":" + day.UTC().Format("2006-01-02")
+}
+
+// GetDailyEarned returns the rule-based rewards a merchant wallet sent a
+// customer address on the given UTC day
+func (r *EarnLimitRepository) GetDailyEarned(ctx context.Context, merchantAddress, customerAddress string, day time.Time) (uint64, error) {
+	collection := r.db.GetCollection("daily_earnings")
+	var daily models.DailyEarnings
+	err := collection.FindOne(ctx, bson.M{"_id": dailyEarningsID(merchantAddress, customerAddress, day)}).Decode(&daily)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily earnings: %w", err)
+	}
+	return daily.AmountLCN, nil
+}
+
+// ReserveDailyEarn counts a reward of up to amountLCN against the day's cap
+// (0: no cap) before it is sent. Returns how much was counted, which is less
+// than amountLCN, down to 0, once the cap is reached.
+func (r *EarnLimitRepository) ReserveDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN, capLCN uint64) (uint64, error) {
+	id := dailyEarningsID(merchantAddress, customerAddress, day)
+	collection := r.db.GetCollection("daily_earnings")
+
+	// Create the day's counter first, so that the conditional update below
+	// never races another request's insert
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$setOnInsert": bson.M{
+			"merchant_address": merchantAddress,
+			"customer_address": customerAddress,
+			"day":              day,
+			"amount_lcn":       uint64(0),
+			"expires_at":       day.Add(dailyEarningsRetention),
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return 0, fmt.Errorf("failed to create daily earnings: %w", err)
+	}
+
+	if capLCN == 0 {
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+			"$inc": bson.M{"amount_lcn": amountLCN},
+		}); err != nil {
+			return 0, fmt.Errorf("failed to reserve daily earnings: %w", err)
+		}
+		return amountLCN, nil
+	}
+
+	// One update adds what fits under the cap; the document before it tells
+	// how much that was
+	var before models.DailyEarnings
+	err = collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":        id,
+		"amount_lcn": bson.M{"$lt": capLCN},
+	}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"amount_lcn": bson.M{"$min": bson.A{bson.M{"$add": bson.A{"$amount_lcn", amountLCN}}, capLCN}},
+		}}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve daily earnings: %w", err)
+	}
+	return min(amountLCN, capLCN-before.AmountLCN), nil
+}
+
+// ReleaseDailyEarn uncounts a reserved reward that was not sent, or that
+// failed on-chain
+func (r *EarnLimitRepository) ReleaseDailyEarn(ctx context.Context, merchantAddress, customerAddress string, day time.Time, amountLCN uint64) error {
+	collection := r.db.GetCollection("daily_earnings")
+	_, err := collection.UpdateOne(ctx, bson.M{
+		"_id":        dailyEarningsID(merchantAddress, customerAddress, day),
+		"amount_lcn": bson.M{"$gte": amountLCN},
+	}, bson.M{
+		"$inc": bson.M{"amount_lcn": -int64(amountLCN)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release daily earnings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEarnLimitRepository_ReserveAndRelease(t *testing.T) {
+	repo := NewEarnLimitRepository(testDB(t))
+	ctx := context.Background()
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	earned, err := repo.GetDailyEarned(ctx, "addr_m1", "addr_c1", day)
+	require.NoError(t, err)
+	assert.Zero(t, earned)
+
+	reserved, err := repo.ReserveDailyEarn(ctx, "addr_m1", "addr_c1", day, 60, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(60), reserved)
+
+	// Only what is left under the cap is counted
+	reserved, err = repo.ReserveDailyEarn(ctx, "addr_m1", "addr_c1", day, 50, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(40), reserved)
+	reserved, err = repo.ReserveDailyEarn(ctx, "addr_m1", "addr_c1", day, 10, 100)
+	require.NoError(t, err)
+	assert.Zero(t, reserved)
+
+	// Releasing a reward frees its share; other merchants and days are separate
+	require.NoError(t, repo.ReleaseDailyEarn(ctx, "addr_m1", "addr_c1", day, 40))
+	earned, err = repo.GetDailyEarned(ctx, "addr_m1", "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(60), earned)
+
+	reserved, err = repo.ReserveDailyEarn(ctx, "addr_m2", "addr_c1", day, 100, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), reserved)
+	reserved, err = repo.ReserveDailyEarn(ctx, "addr_m1", "addr_c1", day.AddDate(0, 0, 1), 100, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), reserved)
+
+	// Without a cap everything is counted
+	reserved, err = repo.ReserveDailyEarn(ctx, "addr_m1", "addr_c1", day, 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), reserved)
+	earned, err = repo.GetDailyEarned(ctx, "addr_m1", "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(560), earned)
+}
+
+func TestEarnLimitRepository_ConcurrentReservations(t *testing.T) {
+	repo := NewEarnLimitRepository(testDB(t))
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var reserved atomic.Uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			amount, err := repo.ReserveDailyEarn(context.Background(), "addr_m1", "addr_c1", day, 30, 100)
+			assert.NoError(t, err)
+			reserved.Add(amount)
+		}()
+	}
+	wg.Wait()
+
+	// Racing purchases never earn more than the cap between them
+	assert.Equal(t, uint64(100), reserved.Load())
+	earned, err := repo.GetDailyEarned(context.Background(), "addr_m1", "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), earned)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrEarnRuleNotFound = errors.New("merchant has no earn rule")
+
+type EarnRuleRepository struct {
+	db *DB
+}
+
+func NewEarnRuleRepository(db *DB) *EarnRuleRepository {
+	return &EarnRuleRepository{db: db}
+}
+
+// CreateEarnRuleVersion saves rule as the merchant's next version. Two
+// concurrent saves cannot get the same version (unique index); the loser fails.
+func (r *EarnRuleRepository) CreateEarnRuleVersion(ctx context.Context, rule *models.EarnRule) error {
+	rule.Version = 1
+	current, err := r.GetCurrentEarnRule(ctx, rule.MerchantID)
+	switch {
+	case err == nil:
+		rule.Version = current.Version + 1
+	case !errors.Is(err, ErrEarnRuleNotFound):
+		return err
+	}
+	rule.ID = ""
+	rule.CreatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("earn_rules")
+	result, err := collection.InsertOne(ctx, rule)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("earn rule was changed concurrently, please retry")
+		}
+		return fmt.Errorf("failed to create earn rule: %w", err)
+	}
+
+	rule.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Returns the merchant's latest earn rule version
+func (r *EarnRuleRepository) GetCurrentEarnRule(ctx context.Context, merchantID string) (*models.EarnRule, error) {
+	collection := r.db.GetCollection("earn_rules")
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	var rule models.EarnRule
+	if err := collection.FindOne(ctx, bson.M{"merchant_id": merchantID}, findOptions).Decode(&rule); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrEarnRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get earn rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// Retrieves a merchant's earn rule versions, newest first
+func (r *EarnRuleRepository) GetEarnRuleVersions(ctx context.Context, merchantID string, limit, offset int) ([]*models.EarnRule, int64, error) {
+	collection := r.db.GetCollection("earn_rules")
+
+	filter := bson.M{"merchant_id": merchantID}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count earn rules: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "version", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query earn rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rules := []*models.EarnRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode earn rules: %w", err)
+	}
+
+	return rules, total, nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrExpiryPolicyNotFound = errors.New("merchant has no expiry policy")
+
+type ExpiryPolicyRepository struct {
+	db *DB
+}
+
+func NewExpiryPolicyRepository(db *DB) *ExpiryPolicyRepository {
+	return &ExpiryPolicyRepository{db: db}
+}
+
+// Returns the merchant's own expiry policy
+func (r *ExpiryPolicyRepository) GetExpiryPolicy(ctx context.Context, merchantID string) (*models.ExpiryPolicy, error) {
+	collection := r.db.GetCollection("expiry_policies")
+
+	var policy models.ExpiryPolicy
+	if err := collection.FindOne(ctx, bson.M{"_id": merchantID}).Decode(&policy); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrExpiryPolicyNotFound
+		}
+		return nil, fmt.Errorf("failed to get expiry policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// Creates or replaces the merchant's expiry policy
+func (r *ExpiryPolicyRepository) SaveExpiryPolicy(ctx context.Context, policy *models.ExpiryPolicy) error {
+	policy.UpdatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("expiry_policies")
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": policy.MerchantID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save expiry policy: %w", err)
+	}
+	return nil
+}
+
+// Removes the merchant's expiry policy; the platform default applies again
+func (r *ExpiryPolicyRepository) DeleteExpiryPolicy(ctx context.Context, merchantID string) error {
+	collection := r.db.GetCollection("expiry_policies")
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": merchantID}); err != nil {
+		return fmt.Errorf("failed to delete expiry policy: %w", err)
+	}
+	return nil
+}
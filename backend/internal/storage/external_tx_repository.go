@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExternalTxRepository struct {
+	db *DB
+}
+
+func NewExternalTxRepository(db *DB) *ExternalTxRepository {
+	return &ExternalTxRepository{db: db}
+}
+
+// Stores a new unsigned transaction awaiting the customer's signature
+func (r *ExternalTxRepository) CreateExternalTx(ctx context.Context, tx *models.ExternalTx) error {
+	tx.CreatedAt = time.Now().UTC()
+	tx.Status = models.ExternalTxAwaitingSignature
+
+	collection := r.db.GetCollection("external_transactions")
+	result, err := collection.InsertOne(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to create external transaction: %w", err)
+	}
+
+	tx.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Get external transaction by ID
+func (r *ExternalTxRepository) GetExternalTxByID(ctx context.Context, id string) (*models.ExternalTx, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid external transaction ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("external_transactions")
+	var tx models.ExternalTx
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&tx); err != nil {
+		return nil, fmt.Errorf("external transaction not found: %w", err)
+	}
+	return &tx, nil
+}
+
+// Atomically moves an external transaction between statuses (fails if it is no longer in `from`)
+func (r *ExternalTxRepository) TransitionStatus(ctx context.Context, id string, from, to models.ExternalTxStatus) error {
+	collection := r.db.GetCollection("external_transactions")
+	return transitionStatus(ctx, collection, id, []models.ExternalTxStatus{from}, to)
+}
+
+// Records the outcome of a submission attempt
+func (r *ExternalTxRepository) CompleteExternalTx(ctx context.Context, id string, status models.ExternalTxStatus, submittedTxHash, errMsg string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid external transaction ID: %w", err)
+	}
+
+	set := bson.M{"status": status}
+	if submittedTxHash != "" {
+		now := time.Now().UTC()
+		set["submitted_tx_hash"] = submittedTxHash
+		set["submitted_at"] = now
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+
+	collection := r.db.GetCollection("external_transactions")
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to update external transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GovernanceRepository struct {
+	db *DB
+}
+
+func NewGovernanceRepository(db *DB) *GovernanceRepository {
+	return &GovernanceRepository{db: db}
+}
+
+// Stores a new unsigned governance transaction
+func (r *GovernanceRepository) CreateGovernanceTx(ctx context.Context, tx *models.GovernanceTx) error {
+	tx.CreatedAt = time.Now().UTC()
+	tx.Status = models.GovernanceTxAwaitingSignatures
+	if tx.Signatures == nil {
+		tx.Signatures = []models.TxSignature{}
+	}
+
+	collection := r.db.GetCollection("governance_transactions")
+	result, err := collection.InsertOne(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to create governance transaction: %w", err)
+	}
+
+	tx.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Get governance transaction by ID
+func (r *GovernanceRepository) GetGovernanceTxByID(ctx context.Context, id string) (*models.GovernanceTx, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid governance transaction ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("governance_transactions")
+	var tx models.GovernanceTx
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&tx); err != nil {
+		return nil, fmt.Errorf("governance transaction not found: %w", err)
+	}
+	return &tx, nil
+}
+
+// Lists governance transactions, newest first, optionally filtered by status
+func (r *GovernanceRepository) GetGovernanceTxs(ctx context.Context, status *models.GovernanceTxStatus, limit, offset int) ([]*models.GovernanceTx, int64, error) {
+	collection := r.db.GetCollection("governance_transactions")
+
+	filter := bson.M{}
+	if status != nil {
+		filter["status"] = *status
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count governance transactions: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query governance transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.GovernanceTx
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode governance transactions: %w", err)
+	}
+	return txs, total, nil
+}
+
+// Returns unsigned transactions past their validity window
+func (r *GovernanceRepository) GetExpiredGovernanceTxs(ctx context.Context) ([]*models.GovernanceTx, error) {
+	collection := r.db.GetCollection("governance_transactions")
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"status":     models.GovernanceTxAwaitingSignatures,
+		"expires_at": bson.M{"$lte": time.Now().UTC()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired governance transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.GovernanceTx
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode governance transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// Returns the governance UTXOs ("txhash#index") spent by transactions that may still be submitted
+func (r *GovernanceRepository) GetLockedInputs(ctx context.Context) ([]string, error) {
+	collection := r.db.GetCollection("governance_transactions")
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"status": bson.M{"$in": []models.GovernanceTxStatus{
+			models.GovernanceTxAwaitingSignatures,
+			models.GovernanceTxSubmitting,
+		}},
+	}, options.Find().SetProjection(bson.M{"inputs": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locked inputs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	inputs := []string{}
+	for cursor.Next(ctx) {
+		var tx models.GovernanceTx
+		if err := cursor.Decode(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode governance transaction: %w", err)
+		}
+		inputs = append(inputs, tx.Inputs...)
+	}
+	return inputs, cursor.Err()
+}
+
+// HasOpenGovernanceTx reports whether a transaction for the purpose,
+// reference and side may still be submitted
+func (r *GovernanceRepository) HasOpenGovernanceTx(ctx context.Context, purpose models.TxType, referenceID, side string) (bool, error) {
+	collection := r.db.GetCollection("governance_transactions")
+
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"purpose":      purpose,
+		"reference_id": referenceID,
+		"side":         side,
+		"status": bson.M{"$in": []models.GovernanceTxStatus{
+			models.GovernanceTxAwaitingSignatures,
+			models.GovernanceTxSubmitting,
+		}},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to query governance transactions: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Adds a signature from a key (and admin) that has not signed yet.
+// Returns the transaction after the update.
+func (r *GovernanceRepository) AddSignature(ctx context.Context, id string, signature models.TxSignature) (*models.GovernanceTx, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid governance transaction ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("governance_transactions")
+	var updated models.GovernanceTx
+	err = collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":                 objID,
+		"status":              models.GovernanceTxAwaitingSignatures,
+		"expires_at":          bson.M{"$gt": time.Now().UTC()},
+		"signatures.key_hash": bson.M{"$ne": signature.KeyHash},
+		"signatures.admin_id": bson.M{"$ne": signature.AdminID},
+	}, bson.M{
+		"$push": bson.M{"signatures": signature},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("signature not accepted: transaction is not awaiting a signature from this key")
+		}
+		return nil, fmt.Errorf("failed to record signature: %w", err)
+	}
+	return &updated, nil
+}
+
+// Atomically moves a governance transaction between statuses (fails if it is no longer in `from`)
+func (r *GovernanceRepository) TransitionStatus(ctx context.Context, id string, from, to models.GovernanceTxStatus) error {
+	collection := r.db.GetCollection("governance_transactions")
+	return transitionStatus(ctx, collection, id, []models.GovernanceTxStatus{from}, to)
+}
+
+// Records the outcome of a submission attempt
+func (r *GovernanceRepository) CompleteGovernanceTx(ctx context.Context, id string, status models.GovernanceTxStatus, submittedTxHash, errMsg string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid governance transaction ID: %w", err)
+	}
+
+	set := bson.M{"status": status}
+	if submittedTxHash != "" {
+		now := time.Now().UTC()
+		set["submitted_tx_hash"] = submittedTxHash
+		set["submitted_at"] = now
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+
+	collection := r.db.GetCollection("governance_transactions")
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to update governance transaction: %w", err)
+	}
+	return nil
+}
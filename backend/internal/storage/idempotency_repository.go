@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyRepository stores Idempotency-Key records (implements middleware.IdempotencyStore)
+type IdempotencyRepository struct {
+	db *DB
+}
+
+func NewIdempotencyRepository(db *DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Begin claims key for a request with the given fingerprint. Returns the
+// existing record if the key is already taken, nil once it has been claimed.
+func (r *IdempotencyRepository) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	record := &models.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	// The TTL monitor runs about once a minute, so an expired record may still
+	// exist: it is replaced, while a live one makes the upsert collide on _id
+	collection := r.db.GetCollection("idempotency_keys")
+	_, err := collection.ReplaceOne(ctx, bson.M{
+		"_id":        key,
+		"expires_at": bson.M{"$lte": now},
+	}, record, options.Replace().SetUpsert(true))
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	var existing models.IdempotencyRecord
+	if err := collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing); err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+// Complete stores the response of the request that claimed key
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	collection := r.db.GetCollection("idempotency_keys")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{
+			"completed":    true,
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the claim on key of a request that did not complete
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	collection := r.db.GetCollection("idempotency_keys")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key, "completed": false})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
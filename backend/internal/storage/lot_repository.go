@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LotRepository struct {
+	db *DB
+}
+
+func NewLotRepository(db *DB) *LotRepository {
+	return &LotRepository{db: db}
+}
+
+// CreateLot records an issued lot. An issuance is recorded once; recording
+// the same transaction again is a no-op.
+func (r *LotRepository) CreateLot(ctx context.Context, lot *models.LCNLot) error {
+	lot.Status = models.LotOpen
+
+	collection := r.db.GetCollection("lcn_lots")
+	result, err := collection.InsertOne(ctx, lot)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create lot: %w", err)
+	}
+
+	lot.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Lots still held in a wallet: open, or claimed by an expiry sweep that has
+// not completed
+func heldLotsFilter(customerAddress string) bson.M {
+	return bson.M{
+		"customer_address": customerAddress,
+		"status":           bson.M{"$in": []models.LotStatus{models.LotOpen, models.LotExpiring}},
+	}
+}
+
+// Lots an expiry sweep may claim: open, or claimed by a sweep that did not finish
+func claimableLotsFilter(now time.Time) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"status": models.LotOpen},
+		{"status": models.LotExpiring, "claimed_until": bson.M{"$lt": now}},
+	}}
+}
+
+// GetHeldLots returns the lots held in a customer's wallet, oldest first
+func (r *LotRepository) GetHeldLots(ctx context.Context, customerAddress string) ([]*models.LCNLot, error) {
+	collection := r.db.GetCollection("lcn_lots")
+	findOptions := options.Find().SetSort(bson.D{{Key: "issued_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := collection.Find(ctx, heldLotsFilter(customerAddress), findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lots: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	lots := []*models.LCNLot{}
+	if err := cursor.All(ctx, &lots); err != nil {
+		return nil, fmt.Errorf("failed to decode lots: %w", err)
+	}
+	return lots, nil
+}
+
+// CustomersWithExpiredLots returns the addresses of customers holding
+// claimable lots that expired by now, longest overdue first
+func (r *LotRepository) CustomersWithExpiredLots(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	filter := claimableLotsFilter(now)
+	filter["expires_at"] = bson.M{"$lte": now}
+	return r.customerAddresses(ctx, filter, limit)
+}
+
+// CustomersToWarn returns the addresses of customers holding open lots that
+// expire between now and until and were not warned about yet
+func (r *LotRepository) CustomersToWarn(ctx context.Context, now, until time.Time, limit int) ([]string, error) {
+	return r.customerAddresses(ctx, bson.M{
+		"status":     models.LotOpen,
+		"warned_at":  bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now, "$lte": until},
+	}, limit)
+}
+
+func (r *LotRepository) customerAddresses(ctx context.Context, filter bson.M, limit int) ([]string, error) {
+	collection := r.db.GetCollection("lcn_lots")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$customer_address", "first": bson.M{"$min": "$expires_at"}}}},
+		{{Key: "$sort", Value: bson.M{"first": 1}}},
+		{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot holders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Address string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode lot holders: %w", err)
+	}
+	addresses := make([]string, len(results))
+	for i, result := range results {
+		addresses[i] = result.Address
+	}
+	return addresses, nil
+}
+
+func lotObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	objIDs := make([]primitive.ObjectID, len(ids))
+	for i, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lot ID: %w", err)
+		}
+		objIDs[i] = objID
+	}
+	return objIDs, nil
+}
+
+// ClaimLots marks lots as being expired until the given time, so that
+// concurrent sweeps do not expire them twice. Either all lots are claimed or
+// none: returns false, claiming nothing, if another sweep holds any of them.
+func (r *LotRepository) ClaimLots(ctx context.Context, ids []string, until time.Time) (bool, error) {
+	objIDs, err := lotObjectIDs(ids)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+
+	filter := claimableLotsFilter(now)
+	filter["_id"] = bson.M{"$in": objIDs}
+
+	collection := r.db.GetCollection("lcn_lots")
+	result, err := collection.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"status": models.LotExpiring, "claimed_until": until},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim lots: %w", err)
+	}
+	if result.ModifiedCount == int64(len(ids)) {
+		return true, nil
+	}
+	if err := r.UnclaimLots(ctx, ids, until); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// UnclaimLots reopens lots claimed until the given time after their expiry
+// transfer failed
+func (r *LotRepository) UnclaimLots(ctx context.Context, ids []string, until time.Time) error {
+	objIDs, err := lotObjectIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	collection := r.db.GetCollection("lcn_lots")
+	_, err = collection.UpdateMany(ctx, bson.M{
+		"_id":           bson.M{"$in": objIDs},
+		"status":        models.LotExpiring,
+		"claimed_until": until,
+	}, bson.M{
+		"$set":   bson.M{"status": models.LotOpen},
+		"$unset": bson.M{"claimed_until": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release lots: %w", err)
+	}
+	return nil
+}
+
+// MarkLotExpired records the amount of a claimed lot returned by an expiry transaction
+func (r *LotRepository) MarkLotExpired(ctx context.Context, id string, expiredLCN uint64, txHash string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid lot ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("lcn_lots")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": models.LotExpiring,
+	}, bson.M{
+		"$set": bson.M{
+			"status":         models.LotExpired,
+			"expired_lcn":    expiredLCN,
+			"expiry_tx_hash": txHash,
+			"closed_at":      time.Now().UTC(),
+		},
+		"$unset": bson.M{"claimed_until": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark lot expired: %w", err)
+	}
+	return nil
+}
+
+// CloseLots closes claimable lots with nothing left to expire: CONSUMED when they
+// were spent, RELEASED when the customer took custody of their wallet
+func (r *LotRepository) CloseLots(ctx context.Context, ids []string, status models.LotStatus) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	objIDs, err := lotObjectIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	filter := claimableLotsFilter(now)
+	filter["_id"] = bson.M{"$in": objIDs}
+
+	collection := r.db.GetCollection("lcn_lots")
+	_, err = collection.UpdateMany(ctx, filter, bson.M{
+		"$set":   bson.M{"status": status, "closed_at": now},
+		"$unset": bson.M{"claimed_until": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close lots: %w", err)
+	}
+	return nil
+}
+
+// MarkLotsWarned records that the customer was warned about the lots' expiry
+func (r *LotRepository) MarkLotsWarned(ctx context.Context, ids []string, warnedAt time.Time) error {
+	objIDs, err := lotObjectIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	collection := r.db.GetCollection("lcn_lots")
+	_, err = collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": objIDs}}, bson.M{
+		"$set": bson.M{"warned_at": warnedAt},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark lots warned: %w", err)
+	}
+	return nil
+}
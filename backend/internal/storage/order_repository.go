@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrOrderNotFound = errors.New("redemption order not found")
+
+type OrderRepository struct {
+	db *DB
+}
+
+func NewOrderRepository(db *DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// CreateOrder records an order whose stock is reserved. Codes are unique per
+// merchant; a duplicate returns a duplicate key error.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *models.RedemptionOrder) error {
+	order.Status = models.OrderPlaced
+	order.CreatedAt = time.Now().UTC()
+	order.UpdatedAt = order.CreatedAt
+
+	collection := r.db.GetCollection("redemption_orders")
+	result, err := collection.InsertOne(ctx, order)
+	if err != nil {
+		return fmt.Errorf("failed to create redemption order: %w", err)
+	}
+	order.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *OrderRepository) GetOrderByID(ctx context.Context, id string) (*models.RedemptionOrder, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+// GetOrderByCode returns a merchant's order by the code the customer shows
+func (r *OrderRepository) GetOrderByCode(ctx context.Context, merchantID, code string) (*models.RedemptionOrder, error) {
+	return r.findOne(ctx, bson.M{"merchant_id": merchantID, "code": code})
+}
+
+func (r *OrderRepository) findOne(ctx context.Context, filter bson.M) (*models.RedemptionOrder, error) {
+	collection := r.db.GetCollection("redemption_orders")
+
+	var order models.RedemptionOrder
+	if err := collection.FindOne(ctx, filter).Decode(&order); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get redemption order: %w", err)
+	}
+	return &order, nil
+}
+
+// GetOrdersByCustomer lists a customer's orders, newest first
+func (r *OrderRepository) GetOrdersByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*models.RedemptionOrder, int64, error) {
+	return r.list(ctx, bson.M{"customer_id": customerID}, limit, offset)
+}
+
+// GetOrdersByMerchant lists a merchant's orders, optionally by status,
+// newest first. READY and EXPIRED tell ready orders apart by their expiry.
+func (r *OrderRepository) GetOrdersByMerchant(ctx context.Context, merchantID string, status *models.OrderStatus, limit, offset int) ([]*models.RedemptionOrder, int64, error) {
+	filter := bson.M{"merchant_id": merchantID}
+	if status != nil {
+		now := time.Now().UTC()
+		switch *status {
+		case models.OrderReady:
+			filter["status"] = models.OrderReady
+			filter["$or"] = []bson.M{
+				{"expires_at": bson.M{"$exists": false}},
+				{"expires_at": bson.M{"$gt": now}},
+			}
+		case models.OrderExpired:
+			filter["status"] = models.OrderReady
+			filter["expires_at"] = bson.M{"$lte": now}
+		default:
+			filter["status"] = *status
+		}
+	}
+	return r.list(ctx, filter, limit, offset)
+}
+
+func (r *OrderRepository) list(ctx context.Context, filter bson.M, limit, offset int) ([]*models.RedemptionOrder, int64, error) {
+	collection := r.db.GetCollection("redemption_orders")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count redemption orders: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query redemption orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orders := []*models.RedemptionOrder{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode redemption orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+// transition moves an order from one status to another, applying the given
+// updates. Returns false if the order was not in the expected status.
+func (r *OrderRepository) transition(ctx context.Context, filter bson.M, from, to models.OrderStatus, set, unset bson.M) (bool, error) {
+	if set == nil {
+		set = bson.M{}
+	}
+	set["status"] = to
+	set["updated_at"] = time.Now().UTC()
+	filter["status"] = from
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	collection := r.db.GetCollection("redemption_orders")
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update redemption order: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *OrderRepository) idFilter(id string) (bson.M, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redemption order ID: %w", err)
+	}
+	return bson.M{"_id": objID}, nil
+}
+
+// MarkPaid records the transaction that paid a placed order and when its
+// code expires (nil: never)
+func (r *OrderRepository) MarkPaid(ctx context.Context, id, txHash string, expiresAt *time.Time) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	set := bson.M{"tx_hash": txHash}
+	if expiresAt != nil {
+		set["expires_at"] = *expiresAt
+	}
+	_, err = r.transition(ctx, filter, models.OrderPlaced, models.OrderReady, set, nil)
+	return err
+}
+
+// MarkFailed records that a placed order could not be paid
+func (r *OrderRepository) MarkFailed(ctx context.Context, id, errMsg string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.OrderPlaced, models.OrderFailed, bson.M{"error": errMsg}, nil)
+	return err
+}
+
+// FulfillOrder hands over a ready order whose code has not expired. Returns
+// false if it was not ready (or expired) anymore.
+func (r *OrderRepository) FulfillOrder(ctx context.Context, id, fulfilledBy string, now time.Time) (bool, error) {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return false, err
+	}
+	filter["$or"] = []bson.M{
+		{"expires_at": bson.M{"$exists": false}},
+		{"expires_at": bson.M{"$gt": now}},
+	}
+	return r.transition(ctx, filter, models.OrderReady, models.OrderFulfilled, bson.M{
+		"fulfilled_by": fulfilledBy,
+		"fulfilled_at": now,
+	}, nil)
+}
+
+// ClaimCancellation claims a ready order, expired or not, for cancellation
+// while its refund is sent. Returns false if it was not ready.
+func (r *OrderRepository) ClaimCancellation(ctx context.Context, id, cancelledBy string) (bool, error) {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return false, err
+	}
+	return r.transition(ctx, filter, models.OrderReady, models.OrderCancelling, bson.M{
+		"cancelled_by": cancelledBy,
+	}, bson.M{"error": ""})
+}
+
+// CompleteCancellation records the refund of an order being cancelled
+func (r *OrderRepository) CompleteCancellation(ctx context.Context, id, refundTxHash string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.OrderCancelling, models.OrderCancelled, bson.M{
+		"refund_tx_hash": refundTxHash,
+		"cancelled_at":   time.Now().UTC(),
+	}, nil)
+	return err
+}
+
+// AbortCancellation returns an order whose refund could not be sent to ready
+func (r *OrderRepository) AbortCancellation(ctx context.Context, id, errMsg string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.OrderCancelling, models.OrderReady, bson.M{
+		"error": errMsg,
+	}, bson.M{"cancelled_by": ""})
+	return err
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentRequestRepository struct {
+	db *DB
+}
+
+func NewPaymentRequestRepository(db *DB) *PaymentRequestRepository {
+	return &PaymentRequestRepository{db: db}
+}
+
+// Creates a new open payment request
+func (r *PaymentRequestRepository) CreatePaymentRequest(ctx context.Context, request *models.PaymentRequest) error {
+	request.CreatedAt = time.Now().UTC()
+	request.Status = models.PaymentRequestOpen
+
+	collection := r.db.GetCollection("payment_requests")
+	result, err := collection.InsertOne(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to create payment request: %w", err)
+	}
+
+	request.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Get payment request by ID
+func (r *PaymentRequestRepository) GetPaymentRequestByID(ctx context.Context, id string) (*models.PaymentRequest, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("payment_requests")
+	var request models.PaymentRequest
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&request); err != nil {
+		return nil, fmt.Errorf("payment request not found: %w", err)
+	}
+	return &request, nil
+}
+
+// Retrieves a merchant's payment requests, newest first
+func (r *PaymentRequestRepository) GetPaymentRequestsByMerchant(ctx context.Context, merchantID string, limit, offset int, status *models.PaymentRequestStatus) ([]*models.PaymentRequest, int64, error) {
+	collection := r.db.GetCollection("payment_requests")
+
+	filter := bson.M{"merchant_id": merchantID}
+	if status != nil {
+		filter["status"] = *status
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count payment requests: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query payment requests: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	requests := []*models.PaymentRequest{}
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode payment requests: %w", err)
+	}
+
+	return requests, total, nil
+}
+
+// Matches requests that can be paid now: open, or reserved by a payer whose
+// reservation lapsed (e.g. an external wallet transaction that was never signed)
+func payableFilter(objID primitive.ObjectID, now time.Time) bson.M {
+	return bson.M{
+		"_id":        objID,
+		"expires_at": bson.M{"$gt": now},
+		"$or": []bson.M{
+			{"status": models.PaymentRequestOpen},
+			{"status": models.PaymentRequestPaying, "reserved_until": bson.M{"$lt": now}},
+		},
+	}
+}
+
+// ReservePaymentRequest reserves a payable request for one customer until the
+// given time. Returns nil if the request is no longer payable, so concurrent
+// or repeated payments cannot both go through.
+func (r *PaymentRequestRepository) ReservePaymentRequest(ctx context.Context, id, customerID string, until time.Time) (*models.PaymentRequest, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("payment_requests")
+	var request models.PaymentRequest
+	err = collection.FindOneAndUpdate(ctx, payableFilter(objID, time.Now().UTC()), bson.M{
+		"$set": bson.M{
+			"status":         models.PaymentRequestPaying,
+			"customer_id":    customerID,
+			"reserved_until": until,
+		},
+		"$unset": bson.M{"external_tx_id": ""},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&request)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to reserve payment request: %w", err)
+	}
+	return &request, nil
+}
+
+// ReleasePaymentRequest reopens a request the customer reserved but could not pay
+func (r *PaymentRequestRepository) ReleasePaymentRequest(ctx context.Context, id, customerID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("payment_requests")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"status":      models.PaymentRequestPaying,
+		"customer_id": customerID,
+	}, bson.M{
+		"$set":   bson.M{"status": models.PaymentRequestOpen},
+		"$unset": bson.M{"customer_id": "", "reserved_until": "", "external_tx_id": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release payment request: %w", err)
+	}
+	return nil
+}
+
+// Links the unsigned external wallet transaction paying a reserved request
+func (r *PaymentRequestRepository) SetExternalTx(ctx context.Context, id, customerID, externalTxID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("payment_requests")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"status":      models.PaymentRequestPaying,
+		"customer_id": customerID,
+	}, bson.M{
+		"$set": bson.M{"external_tx_id": externalTxID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update payment request: %w", err)
+	}
+	return nil
+}
+
+// MarkSubmitted records the customer's submitted payment transaction
+func (r *PaymentRequestRepository) MarkSubmitted(ctx context.Context, id, customerID, txHash string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("payment_requests")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"status":      models.PaymentRequestPaying,
+		"customer_id": customerID,
+	}, bson.M{
+		"$set":   bson.M{"status": models.PaymentRequestSubmitted, "tx_hash": txHash},
+		"$unset": bson.M{"reserved_until": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update payment request: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payment request is no longer reserved by this customer")
+	}
+	return nil
+}
+
+// MarkPaidByTxHash marks the request paid by a transaction once the indexer
+// confirms it. Returns false if no request was waiting on the transaction.
+func (r *PaymentRequestRepository) MarkPaidByTxHash(ctx context.Context, txHash string) (bool, error) {
+	collection := r.db.GetCollection("payment_requests")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"tx_hash": txHash,
+		"status":  models.PaymentRequestSubmitted,
+	}, bson.M{
+		"$set": bson.M{"status": models.PaymentRequestPaid, "paid_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to mark payment request paid: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ReopenByTxHash reopens a request whose payment transaction failed on-chain
+func (r *PaymentRequestRepository) ReopenByTxHash(ctx context.Context, txHash string) (bool, error) {
+	collection := r.db.GetCollection("payment_requests")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"tx_hash": txHash,
+		"status":  models.PaymentRequestSubmitted,
+	}, bson.M{
+		"$set":   bson.M{"status": models.PaymentRequestOpen},
+		"$unset": bson.M{"customer_id": "", "tx_hash": "", "external_tx_id": ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to reopen payment request: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Cancels a merchant's request that nobody is paying
+func (r *PaymentRequestRepository) CancelPaymentRequest(ctx context.Context, id, merchantID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid payment request ID: %w", err)
+	}
+
+	filter := payableFilter(objID, time.Now().UTC())
+	delete(filter, "expires_at")
+	filter["merchant_id"] = merchantID
+
+	collection := r.db.GetCollection("payment_requests")
+	result, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"status": models.PaymentRequestCancelled},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel payment request: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payment request not found or already being paid")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDB connects to MONGODB_URI and returns a throwaway database, dropped
+// when the test ends
+func testDB(t *testing.T) *DB {
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("Skipping integration test: MONGODB_URI not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx, nil))
+
+	db := &DB{
+		Client:   client,
+		Database: client.Database(fmt.Sprintf("loyalcoin_test_%d", time.Now().UnixNano())),
+	}
+	t.Cleanup(func() {
+		db.Database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+func createTestPaymentRequest(t *testing.T, repo *PaymentRequestRepository, expiresAt time.Time) *models.PaymentRequest {
+	request := &models.PaymentRequest{
+		MerchantID:      "m1",
+		MerchantAddress: "addr_merchant",
+		AmountLCN:       50,
+		ExpiresAt:       expiresAt,
+	}
+	require.NoError(t, repo.CreatePaymentRequest(context.Background(), request))
+	return request
+}
+
+func TestPaymentRequestRepository_ReserveAndRelease(t *testing.T) {
+	repo := NewPaymentRequestRepository(testDB(t))
+	ctx := context.Background()
+	request := createTestPaymentRequest(t, repo, time.Now().UTC().Add(time.Hour))
+	until := time.Now().UTC().Add(15 * time.Minute)
+
+	reserved, err := repo.ReservePaymentRequest(ctx, request.ID, "c1", until)
+	require.NoError(t, err)
+	require.NotNil(t, reserved)
+	assert.Equal(t, models.PaymentRequestPaying, reserved.Status)
+	assert.Equal(t, "c1", reserved.CustomerID)
+
+	// Neither the same nor another customer can reserve it twice
+	for _, customerID := range []string{"c1", "c2"} {
+		again, err := repo.ReservePaymentRequest(ctx, request.ID, customerID, until)
+		require.NoError(t, err)
+		assert.Nil(t, again, customerID)
+	}
+
+	// Nor can the merchant cancel it while it is being paid
+	assert.Error(t, repo.CancelPaymentRequest(ctx, request.ID, "m1"))
+
+	require.NoError(t, repo.SetExternalTx(ctx, request.ID, "c1", "ext1"))
+
+	// Only the customer holding the reservation releases it
+	require.NoError(t, repo.ReleasePaymentRequest(ctx, request.ID, "c2"))
+	stored, err := repo.GetPaymentRequestByID(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentRequestPaying, stored.Status)
+	assert.Equal(t, "ext1", stored.ExternalTxID)
+
+	require.NoError(t, repo.ReleasePaymentRequest(ctx, request.ID, "c1"))
+	stored, err = repo.GetPaymentRequestByID(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentRequestOpen, stored.Status)
+	assert.Empty(t, stored.CustomerID)
+	assert.Nil(t, stored.ReservedUntil)
+	assert.Empty(t, stored.ExternalTxID)
+
+	// Reopened, so another customer can pay it
+	reserved, err = repo.ReservePaymentRequest(ctx, request.ID, "c2", until)
+	require.NoError(t, err)
+	require.NotNil(t, reserved)
+	assert.Equal(t, "c2", reserved.CustomerID)
+}
+
+func TestPaymentRequestRepository_LapsedReservation(t *testing.T) {
+	repo := NewPaymentRequestRepository(testDB(t))
+	ctx := context.Background()
+	request := createTestPaymentRequest(t, repo, time.Now().UTC().Add(time.Hour))
+
+	reserved, err := repo.ReservePaymentRequest(ctx, request.ID, "c1", time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, reserved)
+	require.NoError(t, repo.SetExternalTx(ctx, request.ID, "c1", "ext1"))
+
+	// The unsigned reservation lapsed, so another customer takes it over
+	reserved, err = repo.ReservePaymentRequest(ctx, request.ID, "c2", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, reserved)
+	assert.Equal(t, "c2", reserved.CustomerID)
+	assert.Empty(t, reserved.ExternalTxID)
+
+	// The first customer can no longer submit or release it
+	assert.Error(t, repo.MarkSubmitted(ctx, request.ID, "c1", "tx1"))
+	require.NoError(t, repo.ReleasePaymentRequest(ctx, request.ID, "c1"))
+	stored, err := repo.GetPaymentRequestByID(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "c2", stored.CustomerID)
+}
+
+func TestPaymentRequestRepository_Expiry(t *testing.T) {
+	repo := NewPaymentRequestRepository(testDB(t))
+	ctx := context.Background()
+
+	expired := createTestPaymentRequest(t, repo, time.Now().UTC().Add(-time.Minute))
+	reserved, err := repo.ReservePaymentRequest(ctx, expired.ID, "c1", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, reserved)
+
+	// An expired request nobody is paying can still be cancelled
+	require.NoError(t, repo.CancelPaymentRequest(ctx, expired.ID, "m1"))
+	stored, err := repo.GetPaymentRequestByID(ctx, expired.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentRequestCancelled, stored.Status)
+
+	// A reservation that lapsed after the request expired cannot be renewed
+	request := createTestPaymentRequest(t, repo, time.Now().UTC().Add(time.Hour))
+	reserved, err = repo.ReservePaymentRequest(ctx, request.ID, "c1", time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, reserved)
+	objID, err := primitive.ObjectIDFromHex(request.ID)
+	require.NoError(t, err)
+	_, err = repo.db.GetCollection("payment_requests").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"expires_at": time.Now().UTC().Add(-time.Second)},
+	})
+	require.NoError(t, err)
+	reserved, err = repo.ReservePaymentRequest(ctx, request.ID, "c2", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, reserved)
+}
+
+func TestPaymentRequestRepository_SubmitAndConfirm(t *testing.T) {
+	repo := NewPaymentRequestRepository(testDB(t))
+	ctx := context.Background()
+	request := createTestPaymentRequest(t, repo, time.Now().UTC().Add(time.Hour))
+
+	_, err := repo.ReservePaymentRequest(ctx, request.ID, "c1", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, repo.MarkSubmitted(ctx, request.ID, "c1", "tx1"))
+
+	// Submitted requests are neither released nor reserved again
+	require.NoError(t, repo.ReleasePaymentRequest(ctx, request.ID, "c1"))
+	reserved, err := repo.ReservePaymentRequest(ctx, request.ID, "c2", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, reserved)
+
+	// A failed transaction reopens the request, once
+	reopened, err := repo.ReopenByTxHash(ctx, "tx1")
+	require.NoError(t, err)
+	assert.True(t, reopened)
+	reopened, err = repo.ReopenByTxHash(ctx, "tx1")
+	require.NoError(t, err)
+	assert.False(t, reopened)
+
+	_, err = repo.ReservePaymentRequest(ctx, request.ID, "c2", time.Now().UTC().Add(15*time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, repo.MarkSubmitted(ctx, request.ID, "c2", "tx2"))
+
+	paid, err := repo.MarkPaidByTxHash(ctx, "tx2")
+	require.NoError(t, err)
+	assert.True(t, paid)
+	paid, err = repo.MarkPaidByTxHash(ctx, "tx2")
+	require.NoError(t, err)
+	assert.False(t, paid)
+
+	stored, err := repo.GetPaymentRequestByID(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentRequestPaid, stored.Status)
+	assert.Equal(t, "tx2", stored.TxHash)
+	assert.NotNil(t, stored.PaidAt)
+}
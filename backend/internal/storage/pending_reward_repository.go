@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PendingRewardRepository struct {
+	db *DB
+}
+
+func NewPendingRewardRepository(db *DB) *PendingRewardRepository {
+	return &PendingRewardRepository{db: db}
+}
+
+// Stores a reward for a customer who has not signed up yet
+func (r *PendingRewardRepository) CreatePendingReward(ctx context.Context, reward *models.PendingReward) error {
+	reward.CreatedAt = time.Now().UTC()
+	reward.Status = models.PendingRewardPending
+
+	collection := r.db.GetCollection("pending_rewards")
+	result, err := collection.InsertOne(ctx, reward)
+	if err != nil {
+		return fmt.Errorf("failed to create pending reward: %w", err)
+	}
+
+	reward.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Retrieves a merchant's pending rewards, newest first
+func (r *PendingRewardRepository) GetPendingRewardsByMerchant(ctx context.Context, merchantID string, limit, offset int, status *models.PendingRewardStatus) ([]*models.PendingReward, int64, error) {
+	collection := r.db.GetCollection("pending_rewards")
+
+	filter := bson.M{"merchant_id": merchantID}
+	if status != nil {
+		filter["status"] = *status
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count pending rewards: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query pending rewards: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rewards := []*models.PendingReward{}
+	if err := cursor.All(ctx, &rewards); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode pending rewards: %w", err)
+	}
+
+	return rewards, total, nil
+}
+
+// SumOutstandingByMerchant totals the merchant's rewards still to be paid out
+// of its wallet: unexpired pending ones and those being claimed
+func (r *PendingRewardRepository) SumOutstandingByMerchant(ctx context.Context, merchantID string) (uint64, error) {
+	collection := r.db.GetCollection("pending_rewards")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"merchant_id": merchantID,
+			"$or": bson.A{
+				bson.M{"status": models.PendingRewardPending, "expires_at": bson.M{"$gt": time.Now().UTC()}},
+				bson.M{"status": models.PendingRewardClaiming},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$amount_lcn"},
+		}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum pending rewards: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode pending rewards total: %w", err)
+	}
+	if len(results) == 0 || results[0].Total < 0 {
+		return 0, nil
+	}
+	return uint64(results[0].Total), nil
+}
+
+// ClaimNextPendingReward atomically assigns one unexpired pending reward for
+// any of the identifiers to the customer. Returns nil when none are left.
+func (r *PendingRewardRepository) ClaimNextPendingReward(ctx context.Context, identifiers []string, customerID string) (*models.PendingReward, error) {
+	collection := r.db.GetCollection("pending_rewards")
+
+	var reward models.PendingReward
+	err := collection.FindOneAndUpdate(ctx, bson.M{
+		"identifier": bson.M{"$in": identifiers},
+		"status":     models.PendingRewardPending,
+		"expires_at": bson.M{"$gt": time.Now().UTC()},
+	}, bson.M{
+		"$set": bson.M{
+			"status":      models.PendingRewardClaiming,
+			"customer_id": customerID,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&reward)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim pending reward: %w", err)
+	}
+	return &reward, nil
+}
+
+// Records the outcome of a claim
+func (r *PendingRewardRepository) CompletePendingReward(ctx context.Context, id string, status models.PendingRewardStatus, txHash, errMsg string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid pending reward ID: %w", err)
+	}
+
+	set := bson.M{"status": status}
+	if txHash != "" {
+		set["tx_hash"] = txHash
+		set["claimed_at"] = time.Now().UTC()
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+
+	collection := r.db.GetCollection("pending_rewards")
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to update pending reward: %w", err)
+	}
+	return nil
+}
+
+// Cancels a merchant's reward that has not been claimed yet
+func (r *PendingRewardRepository) CancelPendingReward(ctx context.Context, id, merchantID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid pending reward ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("pending_rewards")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":         objID,
+		"merchant_id": merchantID,
+		"status":      models.PendingRewardPending,
+	}, bson.M{
+		"$set": bson.M{"status": models.PendingRewardCancelled},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending reward: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("pending reward not found or already claimed")
+	}
+	return nil
+}
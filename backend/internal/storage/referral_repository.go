@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrReferralNotFound     = errors.New("referral not found")
+	ErrReferralCodeNotFound = errors.New("referral code not found")
+)
+
+type ReferralRepository struct {
+	db *DB
+}
+
+func NewReferralRepository(db *DB) *ReferralRepository {
+	return &ReferralRepository{db: db}
+}
+
+// CreateReferral records a signup attributed to a referral code. An account
+// is referred at most once.
+func (r *ReferralRepository) CreateReferral(ctx context.Context, referral *models.Referral) error {
+	referral.Status = models.ReferralPending
+	referral.CreatedAt = time.Now().UTC()
+	referral.UpdatedAt = referral.CreatedAt
+
+	collection := r.db.GetCollection("referrals")
+	result, err := collection.InsertOne(ctx, referral)
+	if err != nil {
+		return fmt.Errorf("failed to create referral: %w", err)
+	}
+	referral.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *ReferralRepository) GetReferralByID(ctx context.Context, id string) (*models.Referral, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrReferralNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+// GetReferralByReferee returns the referral that brought an account in
+func (r *ReferralRepository) GetReferralByReferee(ctx context.Context, refereeID string) (*models.Referral, error) {
+	return r.findOne(ctx, bson.M{"referee.id": refereeID})
+}
+
+func (r *ReferralRepository) findOne(ctx context.Context, filter bson.M) (*models.Referral, error) {
+	collection := r.db.GetCollection("referrals")
+
+	var referral models.Referral
+	if err := collection.FindOne(ctx, filter).Decode(&referral); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReferralNotFound
+		}
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+	return &referral, nil
+}
+
+// GetReferralsByReferrer lists the signups an account referred, newest first
+func (r *ReferralRepository) GetReferralsByReferrer(ctx context.Context, referrerID string, limit, offset int) ([]*models.Referral, int64, error) {
+	return r.list(ctx, bson.M{"referrer.id": referrerID}, limit, offset)
+}
+
+// GetReferrals lists referrals, optionally by status, newest first
+func (r *ReferralRepository) GetReferrals(ctx context.Context, status *models.ReferralStatus, limit, offset int) ([]*models.Referral, int64, error) {
+	filter := bson.M{}
+	if status != nil {
+		filter["status"] = *status
+	}
+	return r.list(ctx, filter, limit, offset)
+}
+
+func (r *ReferralRepository) list(ctx context.Context, filter bson.M, limit, offset int) ([]*models.Referral, int64, error) {
+	collection := r.db.GetCollection("referrals")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count referrals: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query referrals: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	referrals := []*models.Referral{}
+	if err := cursor.All(ctx, &referrals); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode referrals: %w", err)
+	}
+	return referrals, total, nil
+}
+
+// CountReferrerSignups counts a referrer's referrals that signed up from the
+// given IP address and from the given device (empty values are not matched)
+func (r *ReferralRepository) CountReferrerSignups(ctx context.Context, referrerID, signupIP, deviceID string) (int64, int64, error) {
+	collection := r.db.GetCollection("referrals")
+	count := func(field, value string) (int64, error) {
+		if value == "" {
+			return 0, nil
+		}
+		return collection.CountDocuments(ctx, bson.M{"referrer.id": referrerID, field: value})
+	}
+	sameIP, err := count("signup_ip", signupIP)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count referrals by IP: %w", err)
+	}
+	sameDevice, err := count("device_id", deviceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count referrals by device: %w", err)
+	}
+	return sameIP, sameDevice, nil
+}
+
+// CountSignupsFromIP counts referred signups from an IP address between two times
+func (r *ReferralRepository) CountSignupsFromIP(ctx context.Context, signupIP string, from, to time.Time) (int64, error) {
+	collection := r.db.GetCollection("referrals")
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"signup_ip":  signupIP,
+		"created_at": bson.M{"$gte": from, "$lte": to},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count referrals by IP: %w", err)
+	}
+	return count, nil
+}
+
+// CountRewardedReferrals counts a referrer's referrals that qualified and
+// were not rejected, towards the per-referrer cap
+func (r *ReferralRepository) CountRewardedReferrals(ctx context.Context, referrerID string) (int64, error) {
+	collection := r.db.GetCollection("referrals")
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"referrer.id": referrerID,
+		"status": bson.M{"$in": []models.ReferralStatus{
+			models.ReferralReview, models.ReferralPaying, models.ReferralPaid, models.ReferralFailed,
+		}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rewarded referrals: %w", err)
+	}
+	return count, nil
+}
+
+// QualifyReferral records the referee's qualifying transaction and moves a
+// pending referral to its next status. Returns false if it was not pending.
+func (r *ReferralRepository) QualifyReferral(ctx context.Context, referral *models.Referral) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(referral.ID)
+	if err != nil {
+		return false, fmt.Errorf("invalid referral ID: %w", err)
+	}
+	now := time.Now().UTC()
+
+	set := bson.M{
+		"status":              referral.Status,
+		"flags":               referral.Flags,
+		"referrer_reward_lcn": referral.ReferrerRewardLCN,
+		"referee_reward_lcn":  referral.RefereeRewardLCN,
+		"qualifying_tx_hash":  referral.QualifyingTxHash,
+		"qualified_at":        now,
+		"updated_at":          now,
+	}
+
+	collection := r.db.GetCollection("referrals")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": models.ReferralPending,
+	}, bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("failed to qualify referral: %w", err)
+	}
+	referral.QualifiedAt = &now
+	return result.ModifiedCount == 1, nil
+}
+
+// ReviewReferral records an admin's decision on a referral in one of the
+// given statuses. Returns false if its status changed meanwhile.
+func (r *ReferralRepository) ReviewReferral(ctx context.Context, id string, from []models.ReferralStatus, to models.ReferralStatus, adminID, notes string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid referral ID: %w", err)
+	}
+	now := time.Now().UTC()
+
+	collection := r.db.GetCollection("referrals")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": bson.M{"$in": from},
+	}, bson.M{
+		"$set": bson.M{
+			"status":       to,
+			"reviewed_by":  adminID,
+			"review_notes": notes,
+			"reviewed_at":  now,
+			"updated_at":   now,
+		},
+		"$unset": bson.M{"error": ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to review referral: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetPayoutTx records the transaction paying one side of a referral
+// (referrer_tx_hash or referee_tx_hash)
+func (r *ReferralRepository) SetPayoutTx(ctx context.Context, id, field, txHash string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid referral ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("referrals")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{field: txHash, "updated_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record referral payout: %w", err)
+	}
+	return nil
+}
+
+// CompletePayout records the outcome of paying a referral: PAID, or FAILED
+// with the error
+func (r *ReferralRepository) CompletePayout(ctx context.Context, id string, status models.ReferralStatus, errMsg string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid referral ID: %w", err)
+	}
+	now := time.Now().UTC()
+
+	set := bson.M{"status": status, "updated_at": now}
+	if status == models.ReferralPaid {
+		set["paid_at"] = now
+	}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+
+	collection := r.db.GetCollection("referrals")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":    objID,
+		"status": models.ReferralPaying,
+	}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to complete referral payout: %w", err)
+	}
+	return nil
+}
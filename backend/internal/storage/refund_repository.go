@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrRefundNotFound = errors.New("refund not found")
+
+type RefundRepository struct {
+	db *DB
+}
+
+func NewRefundRepository(db *DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+func (r *RefundRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	refund.CreatedAt = time.Now().UTC()
+	refund.UpdatedAt = refund.CreatedAt
+
+	collection := r.db.GetCollection("refunds")
+	result, err := collection.InsertOne(ctx, refund)
+	if err != nil {
+		return fmt.Errorf("failed to create refund: %w", err)
+	}
+	refund.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *RefundRepository) GetRefundByID(ctx context.Context, id string) (*models.Refund, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRefundNotFound
+	}
+
+	collection := r.db.GetCollection("refunds")
+	var refund models.Refund
+	if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&refund); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+	return &refund, nil
+}
+
+// GetRefunds lists refunds, optionally of one merchant or customer and by
+// status, newest first
+func (r *RefundRepository) GetRefunds(ctx context.Context, merchantID, customerID string, status *models.RefundStatus, limit, offset int) ([]*models.Refund, int64, error) {
+	filter := bson.M{}
+	if merchantID != "" {
+		filter["merchant_id"] = merchantID
+	}
+	if customerID != "" {
+		filter["customer_id"] = customerID
+	}
+	if status != nil {
+		filter["status"] = *status
+	}
+
+	collection := r.db.GetCollection("refunds")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count refunds: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query refunds: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	refunds := []*models.Refund{}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode refunds: %w", err)
+	}
+	return refunds, total, nil
+}
+
+// TransitionRefund moves a refund from one status to another, setting the
+// given fields. Returns false if it was not in the expected status.
+func (r *RefundRepository) TransitionRefund(ctx context.Context, id string, from, to models.RefundStatus, set bson.M) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid refund ID: %w", err)
+	}
+	if set == nil {
+		set = bson.M{}
+	}
+	set["status"] = to
+	set["updated_at"] = time.Now().UTC()
+
+	collection := r.db.GetCollection("refunds")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("failed to update refund: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
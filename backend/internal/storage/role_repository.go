@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepository struct {
+	db *DB
+}
+
+func NewRoleRepository(db *DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// Inserts a role if it does not exist yet. An existing built-in role gets
+// the permissions added to it since it was last seeded; permissions admins
+// removed from it stay removed.
+func (r *RoleRepository) EnsureRole(ctx context.Context, role *models.RoleDefinition) error {
+	now := time.Now().UTC()
+
+	collection := r.db.GetCollection("roles")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": role.Name}, bson.M{
+		"$setOnInsert": bson.M{
+			"description":        role.Description,
+			"scope":              role.Scope,
+			"permissions":        role.Permissions,
+			"seeded_permissions": role.Permissions,
+			"built_in":           role.BuiltIn,
+			"created_at":         now,
+			"updated_at":         now,
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to ensure role %s: %w", role.Name, err)
+	}
+	if result.UpsertedCount > 0 || !role.BuiltIn {
+		return nil
+	}
+
+	// Roles seeded before seeded_permissions was recorded get every
+	// built-in permission they lack
+	seeded := bson.M{"$literal": role.Permissions}
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":                role.Name,
+		"built_in":           true,
+		"seeded_permissions": bson.M{"$not": bson.M{"$all": role.Permissions}},
+	}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"permissions": bson.M{"$setUnion": bson.A{
+				bson.M{"$ifNull": bson.A{"$permissions", bson.A{}}},
+				bson.M{"$setDifference": bson.A{seeded, bson.M{"$ifNull": bson.A{"$seeded_permissions", bson.A{}}}}},
+			}},
+			"seeded_permissions": seeded,
+			"updated_at":         now,
+		}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+// Creates or replaces a role's description and permissions.
+// A role's scope cannot change once it has been created.
+func (r *RoleRepository) UpsertRole(ctx context.Context, role *models.RoleDefinition) error {
+	now := time.Now().UTC()
+	role.UpdatedAt = now
+
+	collection := r.db.GetCollection("roles")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id": role.Name,
+		"$or": []bson.M{
+			{"scope": role.Scope},
+			{"scope": bson.M{"$exists": false}},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"description": role.Description,
+			"scope":       role.Scope,
+			"permissions": role.Permissions,
+			"updated_at":  now,
+		},
+		"$setOnInsert": bson.M{
+			"built_in":   false,
+			"created_at": now,
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("role %s already exists with a different scope", role.Name)
+		}
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+	if result.UpsertedCount > 0 {
+		role.CreatedAt = now
+	}
+	return nil
+}
+
+// Retrieves a role by name
+func (r *RoleRepository) GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	collection := r.db.GetCollection("roles")
+
+	var role models.RoleDefinition
+	err := collection.FindOne(ctx, bson.M{"_id": name}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+// Lists all roles
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]*models.RoleDefinition, error) {
+	collection := r.db.GetCollection("roles")
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "scope", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	roles := []*models.RoleDefinition{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles: %w", err)
+	}
+	return roles, nil
+}
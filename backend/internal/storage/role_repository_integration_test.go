@@ -0,0 +1,53 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRoleRepository_EnsureRoleGrantsNewPermissions(t *testing.T) {
+	db := testDB(t)
+	repo := NewRoleRepository(db)
+	ctx := context.Background()
+	permissions := func() []models.Permission {
+		role, err := repo.GetRole(ctx, "merchant")
+		require.NoError(t, err)
+		return role.Permissions
+	}
+
+	// Seeded by an older release, before seeded_permissions was recorded
+	_, err := db.GetCollection("roles").InsertOne(ctx, bson.M{
+		"_id":         "merchant",
+		"scope":       models.RoleScopeMerchant,
+		"permissions": []models.Permission{models.PermWalletRead},
+		"built_in":    true,
+	})
+	require.NoError(t, err)
+
+	role := &models.RoleDefinition{
+		Name:        "merchant",
+		Scope:       models.RoleScopeMerchant,
+		Permissions: []models.Permission{models.PermWalletRead, models.PermLCNIssue},
+		BuiltIn:     true,
+	}
+	require.NoError(t, repo.EnsureRole(ctx, role))
+	assert.ElementsMatch(t, role.Permissions, permissions())
+
+	// An admin removes a built-in permission; the next startup keeps it removed
+	// but grants the one the new release added
+	require.NoError(t, repo.UpsertRole(ctx, &models.RoleDefinition{
+		Name:        "merchant",
+		Scope:       models.RoleScopeMerchant,
+		Permissions: []models.Permission{models.PermLCNIssue},
+	}))
+	role.Permissions = append(role.Permissions, models.PermPaymentsRequest)
+	require.NoError(t, repo.EnsureRole(ctx, role))
+	assert.ElementsMatch(t, []models.Permission{models.PermLCNIssue, models.PermPaymentsRequest}, permissions())
+}
@@ -142,3 +142,24 @@ func (r *SettlementRepository) GetAllPendingSettlements(ctx context.Context, lim
 
 	return settlements, total, nil
 }
+
+// Starts dual control on a pending settlement. Returns false if another admin initiated it first.
+func (r *SettlementRepository) InitiateApproval(ctx context.Context, id, adminID, notes string, required int) (bool, error) {
+	collection := r.db.GetCollection("settlement_requests")
+	return initiateApproval(ctx, collection, id, settlementOpenStatuses, newApproval(adminID, notes), required)
+}
+
+// Records an admin's approval of a dual-controlled settlement
+func (r *SettlementRepository) AddApproval(ctx context.Context, id, adminID, notes string) ([]models.Approval, error) {
+	collection := r.db.GetCollection("settlement_requests")
+	return addApproval(ctx, collection, id, settlementOpenStatuses, newApproval(adminID, notes))
+}
+
+// Atomically moves a settlement between statuses (fails if it is no longer in `from`)
+func (r *SettlementRepository) TransitionStatus(ctx context.Context, id string, from []models.SettlementStatus, to models.SettlementStatus) error {
+	collection := r.db.GetCollection("settlement_requests")
+	return transitionStatus(ctx, collection, id, from, to)
+}
+
+// Statuses in which a settlement can still be approved
+var settlementOpenStatuses = []models.SettlementStatus{models.SettlementPending, models.SettlementApproved}
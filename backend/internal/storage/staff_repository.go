@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StaffRepository struct {
+	db *DB
+}
+
+func NewStaffRepository(db *DB) *StaffRepository {
+	return &StaffRepository{db: db}
+}
+
+// Creates a merchant sub-user
+func (r *StaffRepository) CreateStaff(ctx context.Context, staff *models.MerchantStaff) error {
+	staff.CreatedAt = time.Now().UTC()
+	staff.UpdatedAt = staff.CreatedAt
+
+	collection := r.db.GetCollection("merchant_staff")
+	result, err := collection.InsertOne(ctx, staff)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("staff member with this email already exists")
+		}
+		return fmt.Errorf("failed to create staff member: %w", err)
+	}
+
+	staff.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Retrieves a staff member by email
+func (r *StaffRepository) GetStaffByEmail(ctx context.Context, email string) (*models.MerchantStaff, error) {
+	collection := r.db.GetCollection("merchant_staff")
+
+	var staff models.MerchantStaff
+	err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&staff)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("staff member not found")
+		}
+		return nil, fmt.Errorf("failed to get staff member: %w", err)
+	}
+	return &staff, nil
+}
+
+// Retrieves a staff member by ID, scoped to the merchant they belong to
+func (r *StaffRepository) GetStaffByID(ctx context.Context, id, merchantID string) (*models.MerchantStaff, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid staff ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("merchant_staff")
+
+	var staff models.MerchantStaff
+	err = collection.FindOne(ctx, bson.M{"_id": objectID, "merchant_id": merchantID}).Decode(&staff)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("staff member not found")
+		}
+		return nil, fmt.Errorf("failed to get staff member: %w", err)
+	}
+	return &staff, nil
+}
+
+// Lists a merchant's staff members
+func (r *StaffRepository) GetStaffByMerchant(ctx context.Context, merchantID string) ([]*models.MerchantStaff, error) {
+	collection := r.db.GetCollection("merchant_staff")
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := collection.Find(ctx, bson.M{"merchant_id": merchantID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query staff: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	staff := []*models.MerchantStaff{}
+	if err := cursor.All(ctx, &staff); err != nil {
+		return nil, fmt.Errorf("failed to decode staff: %w", err)
+	}
+	return staff, nil
+}
+
+// Updates a staff member's role and status
+func (r *StaffRepository) UpdateStaff(ctx context.Context, staff *models.MerchantStaff) error {
+	objectID, err := primitive.ObjectIDFromHex(staff.ID)
+	if err != nil {
+		return fmt.Errorf("invalid staff ID: %w", err)
+	}
+	staff.UpdatedAt = time.Now().UTC()
+
+	collection := r.db.GetCollection("merchant_staff")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":         objectID,
+		"merchant_id": staff.MerchantID,
+	}, bson.M{
+		"$set": bson.M{
+			"name":          staff.Name,
+			"password_hash": staff.PasswordHash,
+			"role":          staff.Role,
+			"status":        staff.Status,
+			"updated_at":    staff.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update staff member: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a day's transfer counts are kept
+const dailyTransfersRetention = 48 * time.Hour
+
+type TransferLimitRepository struct {
+	db *DB
+}
+
+func NewTransferLimitRepository(db *DB) *TransferLimitRepository {
+	return &TransferLimitRepository{db: db}
+}
+
+func dailyTransfersID(address string, day time.Time) string {
+	return address + ":" + day.UTC().Format("2006-01-02")
+}
+
+// GetDailyTransfers returns what a wallet sent on the given UTC day
+func (r *TransferLimitRepository) GetDailyTransfers(ctx context.Context, address string, day time.Time) (*models.DailyTransfers, error) {
+	collection := r.db.GetCollection("daily_transfers")
+	var daily models.DailyTransfers
+	err := collection.FindOne(ctx, bson.M{"_id": dailyTransfersID(address, day)}).Decode(&daily)
+	if err == mongo.ErrNoDocuments {
+		return &models.DailyTransfers{Address: address, Day: day}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily transfers: %w", err)
+	}
+	return &daily, nil
+}
+
+// ReserveDailyTransfer counts a transfer of amountLCN against the wallet's
+// limits for the day before it is sent (0: no limit). Returns the day's
+// totals including it, or nil, counting nothing, if it would exceed a limit.
+func (r *TransferLimitRepository) ReserveDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64, maxTransfers int, limitLCN uint64) (*models.DailyTransfers, error) {
+	if limitLCN > 0 && amountLCN > limitLCN {
+		return nil, nil
+	}
+	id := dailyTransfersID(address, day)
+	collection := r.db.GetCollection("daily_transfers")
+
+	// Create the day's counter first, so that the conditional update below
+	// never races another request's insert
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$setOnInsert": bson.M{
+			"address":    address,
+			"day":        day,
+			"transfers":  0,
+			"amount_lcn": uint64(0),
+			"expires_at": day.Add(dailyTransfersRetention),
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to create daily transfers: %w", err)
+	}
+
+	filter := bson.M{"_id": id}
+	if maxTransfers > 0 {
+		filter["transfers"] = bson.M{"$lt": maxTransfers}
+	}
+	if limitLCN > 0 {
+		filter["amount_lcn"] = bson.M{"$lte": limitLCN - amountLCN}
+	}
+	var daily models.DailyTransfers
+	err = collection.FindOneAndUpdate(ctx, filter, bson.M{
+		"$inc": bson.M{"transfers": 1, "amount_lcn": amountLCN},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&daily)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve daily transfer: %w", err)
+	}
+	return &daily, nil
+}
+
+// ReleaseDailyTransfer uncounts a reserved transfer that was not sent, or
+// that failed on-chain
+func (r *TransferLimitRepository) ReleaseDailyTransfer(ctx context.Context, address string, day time.Time, amountLCN uint64) error {
+	collection := r.db.GetCollection("daily_transfers")
+	_, err := collection.UpdateOne(ctx, bson.M{
+		"_id":        dailyTransfersID(address, day),
+		"transfers":  bson.M{"$gt": 0},
+		"amount_lcn": bson.M{"$gte": amountLCN},
+	}, bson.M{
+		"$inc": bson.M{"transfers": -1, "amount_lcn": -int64(amountLCN)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release daily transfer: %w", err)
+	}
+	return nil
+}
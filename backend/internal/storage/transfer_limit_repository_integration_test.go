@@ -0,0 +1,87 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferLimitRepository_ReserveAndRelease(t *testing.T) {
+	repo := NewTransferLimitRepository(testDB(t))
+	ctx := context.Background()
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	daily, err := repo.GetDailyTransfers(ctx, "addr_c1", day)
+	require.NoError(t, err)
+	assert.Zero(t, daily.Transfers)
+
+	daily, err = repo.ReserveDailyTransfer(ctx, "addr_c1", day, 60, 3, 100)
+	require.NoError(t, err)
+	require.NotNil(t, daily)
+	assert.Equal(t, 1, daily.Transfers)
+	assert.Equal(t, uint64(60), daily.AmountLCN)
+
+	// 60 + 50 is over the LCN limit
+	daily, err = repo.ReserveDailyTransfer(ctx, "addr_c1", day, 50, 3, 100)
+	require.NoError(t, err)
+	assert.Nil(t, daily)
+
+	daily, err = repo.ReserveDailyTransfer(ctx, "addr_c1", day, 40, 3, 100)
+	require.NoError(t, err)
+	require.NotNil(t, daily)
+	assert.Equal(t, uint64(100), daily.AmountLCN)
+
+	// Releasing a transfer frees its share; other wallets and days are separate
+	require.NoError(t, repo.ReleaseDailyTransfer(ctx, "addr_c1", day, 40))
+	daily, err = repo.GetDailyTransfers(ctx, "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, 1, daily.Transfers)
+	assert.Equal(t, uint64(60), daily.AmountLCN)
+
+	daily, err = repo.ReserveDailyTransfer(ctx, "addr_c2", day, 100, 3, 100)
+	require.NoError(t, err)
+	assert.NotNil(t, daily)
+	daily, err = repo.ReserveDailyTransfer(ctx, "addr_c1", day.AddDate(0, 0, 1), 100, 3, 100)
+	require.NoError(t, err)
+	assert.NotNil(t, daily)
+
+	// Releasing more than was reserved changes nothing
+	require.NoError(t, repo.ReleaseDailyTransfer(ctx, "addr_c1", day, 500))
+	daily, err = repo.GetDailyTransfers(ctx, "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(60), daily.AmountLCN)
+}
+
+func TestTransferLimitRepository_ConcurrentReservations(t *testing.T) {
+	repo := NewTransferLimitRepository(testDB(t))
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			daily, err := repo.ReserveDailyTransfer(context.Background(), "addr_c1", day, 30, 5, 100)
+			assert.NoError(t, err)
+			if daily != nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 100 LCN holds three transfers of 30, even when they race
+	assert.Equal(t, int32(3), reserved.Load())
+	daily, err := repo.GetDailyTransfers(context.Background(), "addr_c1", day)
+	require.NoError(t, err)
+	assert.Equal(t, 3, daily.Transfers)
+	assert.Equal(t, uint64(90), daily.AmountLCN)
+}
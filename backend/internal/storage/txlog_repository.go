@@ -8,6 +8,7 @@ import (
 	"github.com/loyalcoin/backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -150,3 +151,237 @@ func (r *TxLogRepository) UpdateTransaction(ctx context.Context, txLog *models.T
 	}
 	return nil
 }
+
+// SetTxMeta adds fields to the meta of the transaction log recorded for txHash
+func (r *TxLogRepository) SetTxMeta(ctx context.Context, txHash string, meta map[string]interface{}) error {
+	set := bson.M{}
+	for key, value := range meta {
+		set["meta."+key] = value
+	}
+
+	collection := r.db.GetCollection("transaction_logs")
+	_, err := collection.UpdateOne(ctx, bson.M{"tx_hash": txHash}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to update transaction meta: %w", err)
+	}
+	return nil
+}
+
+// HasIssuance reports whether fromAddress has sent anything to toAddress
+// before, failed transactions excluded (first-visit campaigns)
+func (r *TxLogRepository) HasIssuance(ctx context.Context, fromAddress, toAddress string) (bool, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"from_address": fromAddress,
+		"to_address":   toAddress,
+		"status":       bson.M{"$ne": models.TxStatusFailed},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetCampaignStats aggregates the issuances tagged with a campaign
+// (meta.campaigns), failed transactions excluded
+func (r *TxLogRepository) GetCampaignStats(ctx context.Context, campaignID string) (*models.CampaignStats, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"meta.campaigns.campaign_id": campaignID,
+			"status":                     bson.M{"$ne": models.TxStatusFailed},
+		}}},
+		{{Key: "$unwind", Value: "$meta.campaigns"}},
+		{{Key: "$match", Value: bson.M{"meta.campaigns.campaign_id": campaignID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       nil,
+			"issuances": bson.M{"$sum": 1},
+			"confirmed": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.TxStatusConfirmed}}, 1, 0}}},
+			"customers": bson.M{"$addToSet": "$to_address"},
+			"bonus_lcn": bson.M{"$sum": "$meta.campaigns.bonus_lcn"},
+			"total_lcn": bson.M{"$sum": "$amount_lcn"},
+			"purchase":  bson.M{"$sum": "$meta.purchase_amount_etb"},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate campaign stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Issuances int64    `bson:"issuances"`
+		Confirmed int64    `bson:"confirmed"`
+		Customers []string `bson:"customers"`
+		BonusLCN  int64    `bson:"bonus_lcn"`
+		TotalLCN  int64    `bson:"total_lcn"`
+		Purchase  float64  `bson:"purchase"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode campaign stats: %w", err)
+	}
+
+	stats := &models.CampaignStats{}
+	if len(results) == 1 {
+		stats.Issuances = results[0].Issuances
+		stats.Confirmed = results[0].Confirmed
+		stats.Customers = int64(len(results[0].Customers))
+		stats.BonusLCN = uint64(results[0].BonusLCN)
+		stats.TotalLCN = uint64(results[0].TotalLCN)
+		stats.PurchaseETB = results[0].Purchase
+	}
+	return stats, nil
+}
+
+// ReserveRefund sets aside amountLCN of a transaction for a refund, as long
+// as what is refunded and reserved stays within its amount. Returns false if
+// it would not, or the transaction failed.
+func (r *TxLogRepository) ReserveRefund(ctx context.Context, txHash string, amountLCN uint64) (bool, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"tx_hash": txHash,
+		"status":  bson.M{"$ne": models.TxStatusFailed},
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$meta.refunded_lcn", 0}},
+				bson.M{"$ifNull": bson.A{"$meta.refund_pending_lcn", 0}},
+				int64(amountLCN),
+			}},
+			"$amount_lcn",
+		}},
+	}, bson.M{"$inc": bson.M{"meta.refund_pending_lcn": int64(amountLCN)}})
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve refund: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ReleaseRefund gives back a reservation of a refund that did not happen
+func (r *TxLogRepository) ReleaseRefund(ctx context.Context, txHash string, amountLCN uint64) error {
+	collection := r.db.GetCollection("transaction_logs")
+	_, err := collection.UpdateOne(ctx, bson.M{"tx_hash": txHash}, bson.M{
+		"$inc": bson.M{"meta.refund_pending_lcn": -int64(amountLCN)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release refund: %w", err)
+	}
+	return nil
+}
+
+// RecordRefund links a reserved refund, sent as refundTxHash, to the
+// transaction it refunds
+func (r *TxLogRepository) RecordRefund(ctx context.Context, txHash, refundTxHash string, amountLCN uint64) error {
+	collection := r.db.GetCollection("transaction_logs")
+	_, err := collection.UpdateOne(ctx, bson.M{"tx_hash": txHash}, bson.M{
+		"$inc": bson.M{
+			"meta.refund_pending_lcn": -int64(amountLCN),
+			"meta.refunded_lcn":       int64(amountLCN),
+		},
+		"$push": bson.M{"meta.refund_tx_hashes": refundTxHash},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+	return nil
+}
+
+// SetTxType changes the type of the transaction log recorded for txHash
+func (r *TxLogRepository) SetTxType(ctx context.Context, txHash string, txType models.TxType) error {
+	collection := r.db.GetCollection("transaction_logs")
+	_, err := collection.UpdateOne(ctx, bson.M{"tx_hash": txHash}, bson.M{"$set": bson.M{"type": txType}})
+	if err != nil {
+		return fmt.Errorf("failed to update transaction type: %w", err)
+	}
+	return nil
+}
+
+// HasPendingTransactions reports whether an address sends or receives a
+// transaction that is not confirmed yet
+func (r *TxLogRepository) HasPendingTransactions(ctx context.Context, address string) (bool, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"status": models.TxStatusPending,
+		"$or": []bson.M{
+			{"from_address": address},
+			{"to_address": address},
+		},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to count pending transactions: %w", err)
+	}
+	return count > 0, nil
+}
+
+// SumExpired totals the LCN returned by confirmed expiry transactions, by
+// where it was returned to (meta.returned_to)
+func (r *TxLogRepository) SumExpired(ctx context.Context) (map[string]uint64, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"type":   models.TxTypeExpiry,
+			"status": models.TxStatusConfirmed,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$meta.returned_to",
+			"total": bson.M{"$sum": "$amount_lcn"},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum expired LCN: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ReturnedTo string `bson:"_id"`
+		Total      int64  `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode expired LCN: %w", err)
+	}
+	totals := make(map[string]uint64, len(results))
+	for _, result := range results {
+		totals[result.ReturnedTo] = uint64(result.Total)
+	}
+	return totals, nil
+}
+
+// SumTierActivity totals the LCN an address received (earned) and sent
+// (redeemed) in confirmed issuances and redemptions since the given time
+func (r *TxLogRepository) SumTierActivity(ctx context.Context, address string, since time.Time) (uint64, uint64, error) {
+	collection := r.db.GetCollection("transaction_logs")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"$or": []bson.M{
+				{"from_address": address},
+				{"to_address": address},
+			},
+			"type":         bson.M{"$in": []models.TxType{models.TxTypeIssuance, models.TxTypeRedemption}},
+			"status":       models.TxStatusConfirmed,
+			"submitted_at": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"earned": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$to_address", address}}, "$amount_lcn", 0},
+			}},
+			"redeemed": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$from_address", address}}, "$amount_lcn", 0},
+			}},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum tier activity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Earned   int64 `bson:"earned"`
+		Redeemed int64 `bson:"redeemed"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode tier activity: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, 0, nil
+	}
+	return uint64(results[0].Earned), uint64(results[0].Redeemed), nil
+}
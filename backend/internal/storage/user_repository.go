@@ -2,13 +2,16 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/loyalcoin/backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -71,6 +74,38 @@ func (r *UserRepository) GetMerchantByEmail(ctx context.Context, email string) (
 	return &merchant, nil
 }
 
+// Retrieves the merchant (or admin) account owning a wallet address
+func (r *UserRepository) GetMerchantByWalletAddress(ctx context.Context, address string) (*models.Merchant, error) {
+	collection := r.db.GetCollection("merchants")
+
+	var merchant models.Merchant
+	err := collection.FindOne(ctx, bson.M{"wallet.address": address}).Decode(&merchant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("merchant not found")
+		}
+		return nil, fmt.Errorf("failed to get merchant: %w", err)
+	}
+
+	return &merchant, nil
+}
+
+// Retrieves the customer owning a wallet address
+func (r *UserRepository) GetCustomerByWalletAddress(ctx context.Context, address string) (*models.Customer, error) {
+	collection := r.db.GetCollection("customers")
+
+	var customer models.Customer
+	err := collection.FindOne(ctx, bson.M{"wallet.address": address}).Decode(&customer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	return &customer, nil
+}
+
 // Retrieves a customer by email
 func (r *UserRepository) GetCustomerByEmail(ctx context.Context, email string) (*models.Customer, error) {
 	collection := r.db.GetCollection("customers")
@@ -87,6 +122,120 @@ func (r *UserRepository) GetCustomerByEmail(ctx context.Context, email string) (
 	return &customer, nil
 }
 
+var (
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrAmbiguousCustomer = errors.New("identifier matches more than one customer")
+)
+
+// FindCustomerByIdentifier retrieves a customer by a normalized email, phone,
+// handle or username; field is the customers field to match
+func (r *UserRepository) FindCustomerByIdentifier(ctx context.Context, field, value string) (*models.Customer, error) {
+	collection := r.db.GetCollection("customers")
+
+	filter := bson.M{field: value}
+	if field == "email" {
+		// Emails were stored as typed before lookups normalized them
+		filter = bson.M{"email": bson.M{"$regex": "^" + regexp.QuoteMeta(value) + "$", "$options": "i"}}
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(2))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customer: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var customers []models.Customer
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, fmt.Errorf("failed to decode customers: %w", err)
+	}
+	switch len(customers) {
+	case 0:
+		return nil, ErrCustomerNotFound
+	case 1:
+		return &customers[0], nil
+	default:
+		return nil, ErrAmbiguousCustomer
+	}
+}
+
+// SetCustomerHandle assigns a QR handle to a customer that has none yet
+func (r *UserRepository) SetCustomerHandle(ctx context.Context, customerID, handle string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "handle": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"handle": handle}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set customer handle: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetCustomerReferralCode assigns a referral code to a customer that has none yet
+func (r *UserRepository) SetCustomerReferralCode(ctx context.Context, customerID, code string) (bool, error) {
+	return r.setReferralCode(ctx, "customers", customerID, code)
+}
+
+// SetMerchantReferralCode assigns a referral code to a merchant that has none yet
+func (r *UserRepository) SetMerchantReferralCode(ctx context.Context, merchantID, code string) (bool, error) {
+	return r.setReferralCode(ctx, "merchants", merchantID, code)
+}
+
+func (r *UserRepository) setReferralCode(ctx context.Context, collectionName, id, code string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid account ID: %w", err)
+	}
+
+	collection := r.db.GetCollection(collectionName)
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "referral_code": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"referral_code": code}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to set referral code: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// FindReferrerByCode returns the customer or merchant owning a referral code
+func (r *UserRepository) FindReferrerByCode(ctx context.Context, code string) (*models.ReferralParty, error) {
+	var customer models.Customer
+	err := r.db.GetCollection("customers").FindOne(ctx, bson.M{"referral_code": code}).Decode(&customer)
+	if err == nil {
+		return &models.ReferralParty{
+			ID:      customer.ID,
+			Role:    models.RoleCustomer,
+			Name:    customer.Username,
+			Address: customer.Wallet.Address,
+		}, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	var merchant models.Merchant
+	err = r.db.GetCollection("merchants").FindOne(ctx, bson.M{"referral_code": code}).Decode(&merchant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReferralCodeNotFound
+		}
+		return nil, fmt.Errorf("failed to get merchant: %w", err)
+	}
+	return &models.ReferralParty{
+		ID:      merchant.ID,
+		Role:    merchant.Role,
+		Name:    merchant.BusinessName,
+		Address: merchant.Wallet.Address,
+	}, nil
+}
+
 // Retrieves a merchant by ID
 func (r *UserRepository) GetMerchantByID(ctx context.Context, id string) (*models.Merchant, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -127,6 +276,48 @@ func (r *UserRepository) GetCustomerByID(ctx context.Context, id string) (*model
 	return &customer, nil
 }
 
+// GetCustomersToEvaluateTier returns customers whose tier was not evaluated
+// since the given time, never evaluated first
+func (r *UserRepository) GetCustomersToEvaluateTier(ctx context.Context, before time.Time, limit int) ([]*models.Customer, error) {
+	collection := r.db.GetCollection("customers")
+	filter := bson.M{"$or": []bson.M{
+		{"loyalty.evaluated_at": bson.M{"$exists": false}},
+		{"loyalty.evaluated_at": bson.M{"$lt": before}},
+	}}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "loyalty.evaluated_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	customers := []*models.Customer{}
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, fmt.Errorf("failed to decode customers: %w", err)
+	}
+	return customers, nil
+}
+
+// SetCustomerLoyalty records a customer's evaluated tier
+func (r *UserRepository) SetCustomerLoyalty(ctx context.Context, customerID string, loyalty *models.Loyalty) error {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"loyalty": loyalty},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update customer loyalty: %w", err)
+	}
+	return nil
+}
+
 // UpdateMerchant updates a merchant
 func (r *UserRepository) UpdateMerchant(ctx context.Context, merchant *models.Merchant) error {
 	objectID, err := primitive.ObjectIDFromHex(merchant.ID)
@@ -191,3 +382,135 @@ func (r *UserRepository) UpdateCustomer(ctx context.Context, customer *models.Cu
 	}
 	return nil
 }
+
+// SetWalletChallenge stores the message the customer's wallet must sign to be
+// registered, replacing any earlier challenge
+func (r *UserRepository) SetWalletChallenge(ctx context.Context, customerID string, challenge *models.WalletChallenge) error {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"wallet_challenge": challenge},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store wallet challenge: %w", err)
+	}
+	return nil
+}
+
+// ClaimWalletChallenge consumes the customer's wallet challenge if it is still
+// the given message and has not expired. Returns false otherwise, so a
+// challenge is only ever accepted once.
+func (r *UserRepository) ClaimWalletChallenge(ctx context.Context, customerID, message string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                         objectID,
+		"wallet_challenge.message":    message,
+		"wallet_challenge.expires_at": bson.M{"$gt": time.Now().UTC()},
+	}, bson.M{
+		"$unset": bson.M{"wallet_challenge": ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim wallet challenge: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetContactVerification stores a one-time code sent to the customer's email
+// or phone, replacing any earlier code
+func (r *UserRepository) SetContactVerification(ctx context.Context, customerID string, verification *models.ContactVerification) error {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"contact_verification": verification},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store contact verification: %w", err)
+	}
+	return nil
+}
+
+// CountVerificationAttempt records an attempt at the customer's pending code.
+// Returns false once the code is used up, expired or gone, so it can never be
+// guessed more than maxAttempts times.
+func (r *UserRepository) CountVerificationAttempt(ctx context.Context, customerID string, maxAttempts int) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                             objectID,
+		"contact_verification.attempts":   bson.M{"$lt": maxAttempts},
+		"contact_verification.expires_at": bson.M{"$gt": time.Now().UTC()},
+	}, bson.M{
+		"$inc": bson.M{"contact_verification.attempts": 1},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record verification attempt: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ConfirmContact marks the email or phone verified if the customer's pending
+// code for it has the given hash and the contact is still the customer's.
+// The code is consumed; returns false if it did not match.
+func (r *UserRepository) ConfirmContact(ctx context.Context, customerID, channel, contact, codeHash string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                             objectID,
+		channel:                           contact,
+		"contact_verification.channel":    channel,
+		"contact_verification.contact":    contact,
+		"contact_verification.code_hash":  codeHash,
+		"contact_verification.expires_at": bson.M{"$gt": time.Now().UTC()},
+	}, bson.M{
+		"$set":   bson.M{channel + "_verified": true},
+		"$unset": bson.M{"contact_verification": ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm contact: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// MarkRecoveryPhraseExported records that the customer exported the wallet's
+// recovery phrase. Returns false if it had already been exported.
+func (r *UserRepository) MarkRecoveryPhraseExported(ctx context.Context, customerID string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID: %w", err)
+	}
+
+	collection := r.db.GetCollection("customers")
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":                                objectID,
+			"wallet.recovery_phrase_exported_at": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"wallet.recovery_phrase_exported_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark recovery phrase exported: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrVoucherNotFound = errors.New("voucher not found")
+
+type VoucherRepository struct {
+	db *DB
+}
+
+func NewVoucherRepository(db *DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+// CreateVoucher records a voucher about to be minted. Codes are unique per
+// merchant; a duplicate returns a duplicate key error.
+func (r *VoucherRepository) CreateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	voucher.Status = models.VoucherMinting
+	voucher.CreatedAt = time.Now().UTC()
+	voucher.UpdatedAt = voucher.CreatedAt
+
+	collection := r.db.GetCollection("vouchers")
+	result, err := collection.InsertOne(ctx, voucher)
+	if err != nil {
+		return fmt.Errorf("failed to create voucher: %w", err)
+	}
+	voucher.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *VoucherRepository) GetVoucherByID(ctx context.Context, id string) (*models.Voucher, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrVoucherNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+// GetVoucherByCode returns a merchant's voucher by the code the holder shows
+func (r *VoucherRepository) GetVoucherByCode(ctx context.Context, merchantID, code string) (*models.Voucher, error) {
+	return r.findOne(ctx, bson.M{"merchant_id": merchantID, "code": code})
+}
+
+func (r *VoucherRepository) findOne(ctx context.Context, filter bson.M) (*models.Voucher, error) {
+	collection := r.db.GetCollection("vouchers")
+
+	var voucher models.Voucher
+	if err := collection.FindOne(ctx, filter).Decode(&voucher); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrVoucherNotFound
+		}
+		return nil, fmt.Errorf("failed to get voucher: %w", err)
+	}
+	return &voucher, nil
+}
+
+// GetVouchersByHolder lists the vouchers a customer holds or redeemed, newest first
+func (r *VoucherRepository) GetVouchersByHolder(ctx context.Context, customerID string, limit, offset int) ([]*models.Voucher, int64, error) {
+	return r.list(ctx, bson.M{"holder_customer_id": customerID}, bson.D{{Key: "created_at", Value: -1}}, limit, offset)
+}
+
+// GetVouchersByMerchant lists a merchant's vouchers, optionally by status,
+// newest first. ACTIVE and EXPIRED tell active vouchers apart by their expiry.
+func (r *VoucherRepository) GetVouchersByMerchant(ctx context.Context, merchantID string, status *models.VoucherStatus, limit, offset int) ([]*models.Voucher, int64, error) {
+	filter := bson.M{"merchant_id": merchantID}
+	if status != nil {
+		now := time.Now().UTC()
+		switch *status {
+		case models.VoucherActive:
+			filter["status"] = models.VoucherActive
+			filter["$or"] = []bson.M{
+				{"expires_at": bson.M{"$exists": false}},
+				{"expires_at": bson.M{"$gt": now}},
+			}
+		case models.VoucherExpired:
+			filter["status"] = models.VoucherActive
+			filter["expires_at"] = bson.M{"$lte": now}
+		default:
+			filter["status"] = *status
+		}
+	}
+	return r.list(ctx, filter, bson.D{{Key: "created_at", Value: -1}}, limit, offset)
+}
+
+// GetVouchersToSync returns active vouchers whose holder was checked on-chain
+// least recently, never-checked ones first
+func (r *VoucherRepository) GetVouchersToSync(ctx context.Context, limit int) ([]*models.Voucher, error) {
+	vouchers, _, err := r.list(ctx, bson.M{"status": models.VoucherActive}, bson.D{{Key: "synced_at", Value: 1}}, limit, 0)
+	return vouchers, err
+}
+
+func (r *VoucherRepository) list(ctx context.Context, filter bson.M, sort bson.D, limit, offset int) ([]*models.Voucher, int64, error) {
+	collection := r.db.GetCollection("vouchers")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count vouchers: %w", err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(sort)
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64(offset))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query vouchers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	vouchers := []*models.Voucher{}
+	if err := cursor.All(ctx, &vouchers); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode vouchers: %w", err)
+	}
+	return vouchers, total, nil
+}
+
+// transition moves a voucher from one status to another, applying the given
+// updates. Returns false if the voucher was not in the expected status.
+func (r *VoucherRepository) transition(ctx context.Context, filter bson.M, from, to models.VoucherStatus, set, unset bson.M) (bool, error) {
+	if set == nil {
+		set = bson.M{}
+	}
+	set["status"] = to
+	set["updated_at"] = time.Now().UTC()
+	filter["status"] = from
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	collection := r.db.GetCollection("vouchers")
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update voucher: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *VoucherRepository) idFilter(id string) (bson.M, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid voucher ID: %w", err)
+	}
+	return bson.M{"_id": objID}, nil
+}
+
+// SetMintTx records the submitted mint transaction of a voucher being minted
+func (r *VoucherRepository) SetMintTx(ctx context.Context, id, txHash string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.VoucherMinting, models.VoucherMinting, bson.M{"mint_tx_hash": txHash}, nil)
+	return err
+}
+
+// MarkFailed records that a voucher could not be minted
+func (r *VoucherRepository) MarkFailed(ctx context.Context, id, errMsg string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.VoucherMinting, models.VoucherFailed, bson.M{"error": errMsg}, nil)
+	return err
+}
+
+// ConfirmMint activates the voucher minted by a confirmed transaction.
+// Returns false if no voucher was waiting for it.
+func (r *VoucherRepository) ConfirmMint(ctx context.Context, txHash string) (bool, error) {
+	return r.transition(ctx, bson.M{"mint_tx_hash": txHash}, models.VoucherMinting, models.VoucherActive, nil, nil)
+}
+
+// FailMint records that a voucher's mint transaction never made it on-chain
+func (r *VoucherRepository) FailMint(ctx context.Context, txHash, errMsg string) (bool, error) {
+	return r.transition(ctx, bson.M{"mint_tx_hash": txHash}, models.VoucherMinting, models.VoucherFailed, bson.M{"error": errMsg}, nil)
+}
+
+// ClaimRedemption claims an active, unexpired voucher while its burn is
+// sent. Returns false if it was not active (or expired) anymore.
+func (r *VoucherRepository) ClaimRedemption(ctx context.Context, id, redeemedBy string, now time.Time) (bool, error) {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return false, err
+	}
+	filter["$or"] = []bson.M{
+		{"expires_at": bson.M{"$exists": false}},
+		{"expires_at": bson.M{"$gt": now}},
+	}
+	return r.transition(ctx, filter, models.VoucherActive, models.VoucherRedeeming, bson.M{
+		"redeemed_by": redeemedBy,
+	}, bson.M{"error": ""})
+}
+
+// SetBurnTx records the submitted burn transaction of a voucher being redeemed
+func (r *VoucherRepository) SetBurnTx(ctx context.Context, id, txHash string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.VoucherRedeeming, models.VoucherRedeeming, bson.M{"burn_tx_hash": txHash}, nil)
+	return err
+}
+
+// AbortRedemption returns a voucher whose burn could not be sent to active
+func (r *VoucherRepository) AbortRedemption(ctx context.Context, id, errMsg string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.transition(ctx, filter, models.VoucherRedeeming, models.VoucherActive, bson.M{
+		"error": errMsg,
+	}, bson.M{"redeemed_by": "", "burn_tx_hash": ""})
+	return err
+}
+
+// ConfirmBurn marks the voucher burned by a confirmed transaction redeemed.
+// Returns false if no voucher was waiting for it.
+func (r *VoucherRepository) ConfirmBurn(ctx context.Context, txHash string, redeemedAt time.Time) (bool, error) {
+	return r.transition(ctx, bson.M{"burn_tx_hash": txHash}, models.VoucherRedeeming, models.VoucherRedeemed, bson.M{
+		"redeemed_at": redeemedAt,
+	}, nil)
+}
+
+// FailBurn returns a voucher whose burn transaction never made it on-chain to active
+func (r *VoucherRepository) FailBurn(ctx context.Context, txHash, errMsg string) (bool, error) {
+	return r.transition(ctx, bson.M{"burn_tx_hash": txHash}, models.VoucherRedeeming, models.VoucherActive, bson.M{
+		"error": errMsg,
+	}, bson.M{"redeemed_by": "", "burn_tx_hash": ""})
+}
+
+// UpdateHolder records who holds an active voucher on-chain
+func (r *VoucherRepository) UpdateHolder(ctx context.Context, id, holderAddress, holderCustomerID string) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	set := bson.M{"holder_address": holderAddress, "synced_at": now}
+	unset := bson.M{}
+	if holderCustomerID != "" {
+		set["holder_customer_id"] = holderCustomerID
+	} else {
+		unset["holder_customer_id"] = ""
+	}
+	_, err = r.transition(ctx, filter, models.VoucherActive, models.VoucherActive, set, unset)
+	return err
+}
+
+// MarkBurned records an active voucher found burned on-chain
+func (r *VoucherRepository) MarkBurned(ctx context.Context, id string) (bool, error) {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	return r.transition(ctx, filter, models.VoucherActive, models.VoucherRedeemed, bson.M{
+		"redeemed_at": now,
+		"synced_at":   now,
+	}, nil)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collections holding user wallets
+var WalletCollections = []string{"merchants", "customers"}
+
+// Wallet key record of one account, as streamed for key maintenance
+type WalletKey struct {
+	ID     interface{} `bson:"_id"` // ObjectID or string (accounts created by create-admin)
+	Wallet struct {
+		Address             string `bson:"address"`
+		EncryptedPrivateKey string `bson:"encrypted_private_key"`
+	} `bson:"wallet"`
+}
+
+// OwnerID returns the account ID as stored in models (hex for ObjectIDs)
+func (k *WalletKey) OwnerID() string {
+	switch id := k.ID.(type) {
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+// Reads and updates wallet key records across account collections
+type WalletKeyRepository struct {
+	db *DB
+}
+
+func NewWalletKeyRepository(db *DB) *WalletKeyRepository {
+	return &WalletKeyRepository{db: db}
+}
+
+// CountWallets counts accounts with a stored wallet key
+func (r *WalletKeyRepository) CountWallets(ctx context.Context, collectionName string) (int64, error) {
+	collection := r.db.GetCollection(collectionName)
+	count, err := collection.CountDocuments(ctx, walletKeyFilter())
+	if err != nil {
+		return 0, fmt.Errorf("failed to count wallets: %w", err)
+	}
+	return count, nil
+}
+
+// ForEachWallet streams wallet key records in _id order without loading the collection
+func (r *WalletKeyRepository) ForEachWallet(ctx context.Context, collectionName string, batchSize int32, fn func(*WalletKey) error) error {
+	collection := r.db.GetCollection(collectionName)
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"wallet.address": 1, "wallet.encrypted_private_key": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(batchSize)
+
+	cursor, err := collection.Find(ctx, walletKeyFilter(), findOptions)
+	if err != nil {
+		return fmt.Errorf("failed to query wallets: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var key WalletKey
+		if err := cursor.Decode(&key); err != nil {
+			return fmt.Errorf("failed to decode wallet: %w", err)
+		}
+		if err := fn(&key); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ReplaceWalletKey swaps a wallet's key record if it still holds oldKey.
+// Returns false if the record changed concurrently.
+func (r *WalletKeyRepository) ReplaceWalletKey(ctx context.Context, collectionName string, id interface{}, oldKey, newKey string) (bool, error) {
+	collection := r.db.GetCollection(collectionName)
+
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                          id,
+		"wallet.encrypted_private_key": oldKey,
+	}, bson.M{
+		"$set": bson.M{"wallet.encrypted_private_key": newKey},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update wallet key: %w", err)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func walletKeyFilter() bson.M {
+	return bson.M{"wallet.encrypted_private_key": bson.M{"$exists": true, "$ne": ""}}
+}
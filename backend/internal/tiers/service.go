@@ -0,0 +1,205 @@
+// Package tiers places customers on the loyalty ladder from the LCN they
+// earned and redeemed over a rolling window.
+package tiers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// Rolling window of activity that counts towards a tier
+const WindowMonths = 12
+
+type Config struct {
+	Ladder         Ladder
+	EvaluationHour int // hour of the day (UTC) of the nightly re-evaluation
+	BatchSize      int
+}
+
+type Service struct {
+	config    *Config
+	userRepo  *storage.UserRepository
+	txLogRepo *storage.TxLogRepository
+	stopCh    chan struct{}
+	stoppedCh chan struct{}
+}
+
+func NewService(config *Config, userRepo *storage.UserRepository, txLogRepo *storage.TxLogRepository) (*Service, error) {
+	if err := config.Ladder.Validate(); err != nil {
+		return nil, err
+	}
+	if config.EvaluationHour < 0 || config.EvaluationHour > 23 {
+		return nil, fmt.Errorf("tier evaluation hour must be between 0 and 23")
+	}
+	return &Service{
+		config:    config,
+		userRepo:  userRepo,
+		txLogRepo: txLogRepo,
+		stopCh:    make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+	}, nil
+}
+
+// Begins the nightly tier re-evaluation in the background
+func (s *Service) Start() {
+	logger.Info("Starting customer tier service", map[string]interface{}{
+		"tiers":           len(s.config.Ladder),
+		"evaluation_hour": s.config.EvaluationHour,
+	})
+	go s.run()
+}
+
+// Gracefully stops the tier service
+func (s *Service) Stop() {
+	close(s.stopCh)
+	<-s.stoppedCh
+	logger.Info("Customer tier service stopped", nil)
+}
+
+func (s *Service) run() {
+	defer close(s.stoppedCh)
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun(time.Now().UTC(), s.config.EvaluationHour)))
+		select {
+		case <-timer.C:
+			s.evaluateAll()
+		case <-s.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextRun returns the next time the clock shows the given hour (UTC)
+func nextRun(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Ladder returns the configured tiers, lowest first
+func (s *Service) Ladder() Ladder {
+	return s.config.Ladder
+}
+
+// Tier returns the tier a customer's evaluated loyalty reaches. Customers
+// never evaluated are on the lowest tier.
+func (s *Service) Tier(loyalty *models.Loyalty) Tier {
+	if loyalty == nil {
+		return s.config.Ladder[0]
+	}
+	return s.config.Ladder.TierFor(loyalty.QualifyingLCN())
+}
+
+// Loyalty returns a customer's loyalty, evaluating it if it never was
+func (s *Service) Loyalty(ctx context.Context, customer *models.Customer) (*models.Loyalty, error) {
+	if customer.Loyalty != nil {
+		return customer.Loyalty, nil
+	}
+	return s.Evaluate(ctx, customer)
+}
+
+// Evaluate recomputes a customer's tier from their activity over the window
+// and records it
+func (s *Service) Evaluate(ctx context.Context, customer *models.Customer) (*models.Loyalty, error) {
+	now := time.Now().UTC()
+	earned, redeemed, err := s.txLogRepo.SumTierActivity(ctx, customer.Wallet.Address, now.AddDate(0, -WindowMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	loyalty := &models.Loyalty{
+		EarnedLCN:   earned,
+		RedeemedLCN: redeemed,
+		TierSince:   now,
+		EvaluatedAt: now,
+	}
+	loyalty.Tier = s.config.Ladder.TierFor(loyalty.QualifyingLCN()).Name
+
+	previous := customer.Loyalty
+	if previous != nil && previous.Tier == loyalty.Tier {
+		loyalty.TierSince = previous.TierSince
+	}
+	if err := s.userRepo.SetCustomerLoyalty(ctx, customer.ID, loyalty); err != nil {
+		return nil, err
+	}
+	customer.Loyalty = loyalty
+
+	if previous != nil && previous.Tier != loyalty.Tier {
+		logger.Audit("CUSTOMER_TIER_CHANGED", customer.ID, map[string]interface{}{
+			"from_tier":      previous.Tier,
+			"to_tier":        loyalty.Tier,
+			"qualifying_lcn": loyalty.QualifyingLCN(),
+		})
+	}
+	return loyalty, nil
+}
+
+// RecordTransaction re-evaluates the tiers of the customers sending and
+// receiving a confirmed transaction
+func (s *Service) RecordTransaction(ctx context.Context, tx *models.TxLog) {
+	if tx.Type != models.TxTypeIssuance && tx.Type != models.TxTypeRedemption {
+		return
+	}
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, address)
+		if err != nil {
+			continue
+		}
+		if _, err := s.Evaluate(ctx, customer); err != nil {
+			logger.Error("Failed to evaluate customer tier", err, map[string]interface{}{
+				"customer_id": customer.ID,
+				"tx_hash":     tx.TxHash,
+			})
+		}
+	}
+}
+
+// Re-evaluates every customer not evaluated since the run began, so that
+// activity leaving the window lowers tiers
+func (s *Service) evaluateAll() {
+	ctx := context.Background()
+	started := time.Now().UTC()
+	evaluated := 0
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+
+		customers, err := s.userRepo.GetCustomersToEvaluateTier(ctx, started, s.config.BatchSize)
+		if err != nil {
+			logger.Error("Failed to find customers to evaluate", err, nil)
+			return
+		}
+		if len(customers) == 0 {
+			break
+		}
+		for _, customer := range customers {
+			if _, err := s.Evaluate(ctx, customer); err != nil {
+				// Stop rather than fetch the same customers again; the next
+				// transaction or night picks them up
+				logger.Error("Failed to evaluate customer tier", err, map[string]interface{}{
+					"customer_id": customer.ID,
+				})
+				return
+			}
+			evaluated++
+		}
+	}
+
+	logger.Info("Customer tiers re-evaluated", map[string]interface{}{
+		"customers": evaluated,
+		"duration":  time.Since(started).String(),
+	})
+}
@@ -0,0 +1,117 @@
+package tiers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxEarnMultiplier = 10
+
+// Tier is a rung of the loyalty ladder. Customers reach it once the LCN they
+// earned and redeemed over the rolling window adds up to MinLCN.
+type Tier struct {
+	Name           string  `json:"name"`
+	MinLCN         uint64  `json:"min_lcn"`
+	EarnMultiplier float64 `json:"earn_multiplier"`
+}
+
+// Ladder lists the tiers from the lowest up. The lowest tier starts at 0 LCN,
+// so every customer has a tier.
+type Ladder []Tier
+
+// Progress is where a customer stands on the ladder
+type Progress struct {
+	Tier          Tier
+	Next          *Tier   // nil at the top tier
+	LCNToNext     uint64  // qualifying LCN still needed for the next tier
+	PercentToNext float64 // how far the customer is from their tier to the next
+}
+
+// ParseLadder reads tiers written as name:min_lcn:earn_multiplier, separated
+// by commas, e.g. "Bronze:0:1,Silver:1000:1.25,Gold:5000:1.5"
+func ParseLadder(spec string) (Ladder, error) {
+	var ladder Ladder
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("tier %q: expected name:min_lcn:earn_multiplier", entry)
+		}
+		minLCN, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("tier %q: invalid min_lcn", entry)
+		}
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("tier %q: invalid earn_multiplier", entry)
+		}
+		ladder = append(ladder, Tier{
+			Name:           strings.TrimSpace(parts[0]),
+			MinLCN:         minLCN,
+			EarnMultiplier: multiplier,
+		})
+	}
+	if err := ladder.Validate(); err != nil {
+		return nil, err
+	}
+	return ladder, nil
+}
+
+// Validate checks that the ladder starts at 0 LCN, climbs strictly and has
+// sensible multipliers
+func (l Ladder) Validate() error {
+	if len(l) == 0 {
+		return fmt.Errorf("at least one tier is required")
+	}
+	if l[0].MinLCN != 0 {
+		return fmt.Errorf("the lowest tier must start at 0 LCN")
+	}
+	names := make(map[string]bool, len(l))
+	for i, tier := range l {
+		if tier.Name == "" {
+			return fmt.Errorf("tier names cannot be empty")
+		}
+		key := strings.ToLower(tier.Name)
+		if names[key] {
+			return fmt.Errorf("tier %q is listed twice", tier.Name)
+		}
+		names[key] = true
+		if tier.EarnMultiplier < 1 || tier.EarnMultiplier > maxEarnMultiplier {
+			return fmt.Errorf("tier %q: earn multiplier must be between 1 and %d", tier.Name, maxEarnMultiplier)
+		}
+		if i > 0 && tier.MinLCN <= l[i-1].MinLCN {
+			return fmt.Errorf("tier %q must start above tier %q", tier.Name, l[i-1].Name)
+		}
+	}
+	return nil
+}
+
+// TierFor returns the highest tier the qualifying LCN reaches
+func (l Ladder) TierFor(qualifyingLCN uint64) Tier {
+	return l[l.index(qualifyingLCN)]
+}
+
+// ProgressFor returns the tier the qualifying LCN reaches and how far it is from the next
+func (l Ladder) ProgressFor(qualifyingLCN uint64) Progress {
+	i := l.index(qualifyingLCN)
+	progress := Progress{Tier: l[i]}
+	if i+1 < len(l) {
+		next := l[i+1]
+		progress.Next = &next
+		progress.LCNToNext = next.MinLCN - qualifyingLCN
+		progress.PercentToNext = float64(qualifyingLCN-l[i].MinLCN) / float64(next.MinLCN-l[i].MinLCN) * 100
+	}
+	return progress
+}
+
+func (l Ladder) index(qualifyingLCN uint64) int {
+	i := 0
+	for i+1 < len(l) && qualifyingLCN >= l[i+1].MinLCN {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,79 @@
+package tiers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLadder(t *testing.T) {
+	ladder, err := ParseLadder(" Bronze:0:1, Silver:1000:1.25 ,Gold:5000:1.5")
+	if err != nil {
+		t.Fatalf("valid ladder rejected: %v", err)
+	}
+	if len(ladder) != 3 || ladder[1].Name != "Silver" || ladder[1].MinLCN != 1000 || ladder[2].EarnMultiplier != 1.5 {
+		t.Errorf("got %+v", ladder)
+	}
+
+	invalid := []string{
+		"",
+		"Bronze:0",
+		"Bronze:100:1",
+		"Bronze:0:1,Silver:abc:1.2",
+		"Bronze:0:1,Silver:0:1.2",
+		"Bronze:0:1,Silver:1000:0.5",
+		"Bronze:0:1,Silver:1000:11",
+		"Bronze:0:1,bronze:1000:1.2",
+		":0:1",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseLadder(spec); err == nil {
+			t.Errorf("invalid ladder %q accepted", spec)
+		}
+	}
+}
+
+func TestProgressFor(t *testing.T) {
+	ladder, err := ParseLadder("Bronze:0:1,Silver:1000:1.25,Gold:5000:1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		qualifying uint64
+		tier       string
+		next       string
+		toNext     uint64
+		percent    float64
+	}{
+		{0, "Bronze", "Silver", 1000, 0},
+		{250, "Bronze", "Silver", 750, 25},
+		{1000, "Silver", "Gold", 4000, 0},
+		{3000, "Silver", "Gold", 2000, 50},
+		{5000, "Gold", "", 0, 0},
+		{90000, "Gold", "", 0, 0},
+	}
+	for _, tt := range tests {
+		progress := ladder.ProgressFor(tt.qualifying)
+		next := ""
+		if progress.Next != nil {
+			next = progress.Next.Name
+		}
+		if progress.Tier.Name != tt.tier || next != tt.next || progress.LCNToNext != tt.toNext || progress.PercentToNext != tt.percent {
+			t.Errorf("%d LCN: got %s -> %q (%d to go, %.1f%%), want %s -> %q (%d to go, %.1f%%)",
+				tt.qualifying, progress.Tier.Name, next, progress.LCNToNext, progress.PercentToNext,
+				tt.tier, tt.next, tt.toNext, tt.percent)
+		}
+		if ladder.TierFor(tt.qualifying).Name != tt.tier {
+			t.Errorf("%d LCN: TierFor disagrees with ProgressFor", tt.qualifying)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2025, 6, 10, 1, 30, 0, 0, time.UTC)
+	if got, want := nextRun(now, 2), time.Date(2025, 6, 10, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("later today: got %v, want %v", got, want)
+	}
+	if got, want := nextRun(now, 1), time.Date(2025, 6, 11, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("tomorrow: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,297 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/cardano"
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/storage"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Attempts at drawing a code and asset name not taken yet
+const codeAttempts = 5
+
+type Service struct {
+	network        string
+	cardanoService *cardano.CardanoService
+	userRepo       *storage.UserRepository
+	voucherRepo    *storage.VoucherRepository
+}
+
+func NewService(
+	network string,
+	cardanoService *cardano.CardanoService,
+	userRepo *storage.UserRepository,
+	voucherRepo *storage.VoucherRepository,
+) *Service {
+	return &Service{
+		network:        network,
+		cardanoService: cardanoService,
+		userRepo:       userRepo,
+		voucherRepo:    voucherRepo,
+	}
+}
+
+// Issue mints a voucher to a wallet; customerID is empty for wallets outside
+// the platform. The voucher stays MINTING until the indexer confirms the mint.
+// When minting fails the voucher is returned FAILED along with the error.
+func (s *Service) Issue(ctx context.Context, merchantID, issuedBy string, req Request, toAddress, customerID string) (*models.Voucher, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	merchant, err := s.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("merchant not found: %w", err)
+	}
+	policy, err := Policy(merchant.Wallet.Address, s.network)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher := &models.Voucher{
+		MerchantID:       merchantID,
+		BusinessName:     merchant.BusinessName,
+		PolicyID:         PolicyID(policy),
+		Name:             req.Name,
+		Description:      req.Description,
+		ImageURL:         req.ImageURL,
+		Value:            req.Value,
+		ExpiresAt:        req.Expiry(time.Now().UTC()),
+		DepositLovelace:  s.cardanoService.VoucherDeposit(),
+		HolderAddress:    toAddress,
+		HolderCustomerID: customerID,
+		IssuedBy:         issuedBy,
+	}
+	for attempt := 0; attempt < codeAttempts; attempt++ {
+		if voucher.Code, err = NewCode(); err != nil {
+			break
+		}
+		if voucher.AssetName, err = NewAssetName(); err != nil {
+			break
+		}
+		if err = s.voucherRepo.CreateVoucher(ctx, voucher); !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := s.cardanoService.MintVoucher(crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: merchant.Wallet.Address, OwnerID: merchant.ID},
+		EncryptedKey: merchant.Wallet.EncryptedPrivateKey,
+	}, &cardano.VoucherMint{
+		VoucherID: voucher.ID,
+		Policy:    policy,
+		AssetName: voucher.AssetName,
+		ToAddress: toAddress,
+		Datum:     DatumMetadata(voucher),
+		Metadata:  CIP25Metadata(voucher),
+		Deposit:   voucher.DepositLovelace,
+	})
+	if err != nil {
+		voucher.Status = models.VoucherFailed
+		voucher.Error = err.Error()
+		if updateErr := s.voucherRepo.MarkFailed(ctx, voucher.ID, voucher.Error); updateErr != nil {
+			logger.Error("Failed to record voucher failure", updateErr, map[string]interface{}{
+				"voucher_id": voucher.ID,
+			})
+		}
+		return voucher, fmt.Errorf("failed to mint voucher: %w", err)
+	}
+
+	if err := s.voucherRepo.SetMintTx(ctx, voucher.ID, txHash); err != nil {
+		logger.Error("Failed to record voucher mint transaction", err, map[string]interface{}{
+			"voucher_id": voucher.ID,
+			"tx_hash":    txHash,
+		})
+	}
+	voucher.MintTxHash = txHash
+	return voucher, nil
+}
+
+// Redeem burns the merchant's voucher with the given code, as shown by its
+// holder. The platform signs for the holder, so only vouchers held in
+// custodial wallets can be redeemed. The voucher stays REDEEMING until the
+// indexer confirms the burn.
+func (s *Service) Redeem(ctx context.Context, merchantID, code, redeemedBy string) (*models.Voucher, error) {
+	code, ok := NormalizeCode(code)
+	if !ok {
+		return nil, storage.ErrVoucherNotFound
+	}
+	voucher, err := s.voucherRepo.GetVoucherByCode(ctx, merchantID, code)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	switch Status(voucher, now) {
+	case models.VoucherActive:
+	case models.VoucherExpired:
+		return nil, ErrExpired
+	default:
+		return nil, ErrNotActive
+	}
+
+	if voucher.HolderCustomerID == "" {
+		return nil, ErrExternalWallet
+	}
+	holder, err := s.userRepo.GetCustomerByID(ctx, voucher.HolderCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("holder not found: %w", err)
+	}
+	if holder.Wallet.Custody == models.WalletExternal || holder.Wallet.Address != voucher.HolderAddress {
+		return nil, ErrExternalWallet
+	}
+	merchant, err := s.userRepo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("merchant not found: %w", err)
+	}
+	// Vouchers minted before the merchant's wallet was rotated are under a
+	// policy its current key cannot sign for
+	policy, err := Policy(merchant.Wallet.Address, s.network)
+	if err != nil {
+		return nil, err
+	}
+	if PolicyID(policy) != voucher.PolicyID {
+		return nil, ErrPolicyMismatch
+	}
+
+	claimed, err := s.voucherRepo.ClaimRedemption(ctx, voucher.ID, redeemedBy, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrNotActive
+	}
+
+	txHash, err := s.cardanoService.BurnVoucher(crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: merchant.Wallet.Address, OwnerID: merchant.ID},
+		EncryptedKey: merchant.Wallet.EncryptedPrivateKey,
+	}, crypto.WalletKey{
+		KeyBinding:   crypto.KeyBinding{Address: holder.Wallet.Address, OwnerID: holder.ID},
+		EncryptedKey: holder.Wallet.EncryptedPrivateKey,
+	}, voucher, policy)
+	if err != nil {
+		if abortErr := s.voucherRepo.AbortRedemption(ctx, voucher.ID, err.Error()); abortErr != nil {
+			logger.Error("Failed to release voucher redemption", abortErr, map[string]interface{}{
+				"voucher_id": voucher.ID,
+			})
+		}
+		return nil, fmt.Errorf("failed to burn voucher: %w", err)
+	}
+
+	if err := s.voucherRepo.SetBurnTx(ctx, voucher.ID, txHash); err != nil {
+		logger.Error("Failed to record voucher burn transaction", err, map[string]interface{}{
+			"voucher_id": voucher.ID,
+			"tx_hash":    txHash,
+		})
+	}
+	voucher.Status = models.VoucherRedeeming
+	voucher.RedeemedBy = redeemedBy
+	voucher.BurnTxHash = txHash
+	voucher.Error = ""
+	return voucher, nil
+}
+
+// RecordTransaction activates the voucher of a confirmed mint and completes
+// the redemption of a confirmed burn
+func (s *Service) RecordTransaction(ctx context.Context, tx *models.TxLog) {
+	var err error
+	switch tx.Type {
+	case models.TxTypeVoucherMint:
+		_, err = s.voucherRepo.ConfirmMint(ctx, tx.TxHash)
+	case models.TxTypeVoucherBurn:
+		confirmedAt := time.Now().UTC()
+		if tx.ConfirmedAt != nil {
+			confirmedAt = *tx.ConfirmedAt
+		}
+		_, err = s.voucherRepo.ConfirmBurn(ctx, tx.TxHash, confirmedAt)
+	default:
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to confirm voucher transaction", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+			"type":    tx.Type,
+		})
+	}
+}
+
+// RecordFailure fails the voucher of a mint that never made it on-chain and
+// returns the voucher of a failed burn to its holder
+func (s *Service) RecordFailure(ctx context.Context, tx *models.TxLog, reason string) {
+	var err error
+	switch tx.Type {
+	case models.TxTypeVoucherMint:
+		_, err = s.voucherRepo.FailMint(ctx, tx.TxHash, reason)
+	case models.TxTypeVoucherBurn:
+		_, err = s.voucherRepo.FailBurn(ctx, tx.TxHash, reason)
+	default:
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to record voucher transaction failure", err, map[string]interface{}{
+			"tx_hash": tx.TxHash,
+			"type":    tx.Type,
+		})
+	}
+}
+
+// SyncOwnership checks on-chain who holds a batch of active vouchers, the
+// least recently checked first: vouchers move when holders send them on from
+// their own wallets, and are burned outside the platform
+func (s *Service) SyncOwnership(ctx context.Context, batchSize int) {
+	vouchers, err := s.voucherRepo.GetVouchersToSync(ctx, batchSize)
+	if err != nil {
+		logger.Error("Failed to fetch vouchers to sync", err, nil)
+		return
+	}
+	for _, voucher := range vouchers {
+		if err := s.syncVoucher(ctx, voucher); err != nil {
+			logger.Warn("Failed to sync voucher holder", map[string]interface{}{
+				"voucher_id": voucher.ID,
+				"error":      err.Error(),
+			})
+		}
+	}
+}
+
+func (s *Service) syncVoucher(ctx context.Context, voucher *models.Voucher) error {
+	holders, err := s.cardanoService.VoucherHolders(Unit(voucher.PolicyID, UserLabel, voucher.AssetName))
+	if err != nil {
+		return err
+	}
+	if len(holders) == 0 {
+		burned, err := s.voucherRepo.MarkBurned(ctx, voucher.ID)
+		if burned {
+			logger.Info("Voucher burned outside the platform", map[string]interface{}{
+				"voucher_id": voucher.ID,
+			})
+		}
+		return err
+	}
+
+	holderAddress := holders[0]
+	customerID := ""
+	customer, err := s.userRepo.GetCustomerByWalletAddress(ctx, holderAddress)
+	if err == nil {
+		customerID = customer.ID
+	} else if !errors.Is(err, storage.ErrCustomerNotFound) {
+		return err
+	}
+	if holderAddress != voucher.HolderAddress {
+		logger.Info("Voucher changed hands", map[string]interface{}{
+			"voucher_id":  voucher.ID,
+			"from":        voucher.HolderAddress,
+			"to":          holderAddress,
+			"customer_id": customerID,
+		})
+	}
+	return s.voucherRepo.UpdateHolder(ctx, voucher.ID, holderAddress, customerID)
+}
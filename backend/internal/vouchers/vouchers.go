@@ -0,0 +1,202 @@
+// Package vouchers mints single-use merchant vouchers as CIP-68 NFTs. Each
+// merchant mints under its own native script policy, signed by its wallet
+// key. A voucher is a pair of tokens with the same name: the user token
+// (label 222) in the holder's wallet, which any Cardano wallet shows through
+// its CIP-25 metadata, and the reference token (label 100) at the policy
+// script's own address, whose inline datum carries the metadata (merchant,
+// value, expiry). Redeeming burns both.
+package vouchers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/internal/rewards"
+)
+
+const (
+	// CIP-67 asset name labels
+	ReferenceLabel = "000643b0" // (100) reference NFT
+	UserLabel      = "000de140" // (222) user NFT
+
+	MaxValidityDays = 365
+
+	maxNameLength        = 64
+	maxValueLength       = 64
+	maxDescriptionLength = 500
+	maxImageURLLength    = 256
+
+	// Transaction metadata strings are limited to 64 bytes; longer CIP-25
+	// values are split into arrays of chunks
+	metadataChunkSize = 64
+)
+
+var (
+	ErrExternalWallet = errors.New("the voucher is held in a wallet the platform holds no key for")
+	ErrNotActive      = errors.New("voucher is not active")
+	ErrExpired        = errors.New("voucher has expired")
+	ErrPolicyMismatch = errors.New("the merchant's wallet no longer matches the voucher's policy")
+)
+
+// Policy is the merchant's minting policy: a 1-of-1 native script over the
+// payment key of its wallet
+func Policy(merchantAddress, network string) (*crypto.MultiSigScript, error) {
+	address, err := crypto.ParseAddress(merchantAddress, network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merchant wallet address: %w", err)
+	}
+	keyHash, err := address.PaymentKeyHash()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewMultiSigScript(1, []string{hex.EncodeToString(keyHash)})
+}
+
+// PolicyID is the hex policy ID of a minting policy, its script hash
+func PolicyID(policy *crypto.MultiSigScript) string {
+	return hex.EncodeToString(policy.Hash())
+}
+
+// NewAssetName generates a random voucher asset name (hex, without label).
+// It is not the code: asset names are public on-chain, codes are not.
+func NewAssetName() (string, error) {
+	random, err := rewards.NewOrderCode()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString([]byte("LCV" + random)), nil
+}
+
+// NewCode generates the code a holder shows to redeem a voucher, in the same
+// format as catalog order codes
+func NewCode() (string, error) {
+	return rewards.NewOrderCode()
+}
+
+// NormalizeCode reads a code as typed by a cashier; false if it cannot be one
+func NormalizeCode(code string) (string, bool) {
+	return rewards.NormalizeOrderCode(code)
+}
+
+// Unit is the asset ID (policy ID and labelled asset name) of one token of a voucher
+func Unit(policyID, label, assetName string) string {
+	return policyID + label + assetName
+}
+
+// Voucher fields a merchant sets when issuing one
+type Request struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ImageURL     string `json:"image_url"`
+	Value        string `json:"value"`
+	ValidityDays int    `json:"validity_days"` // 0: no expiry
+}
+
+// Validate checks and trims a voucher request
+func (r *Request) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	r.ImageURL = strings.TrimSpace(r.ImageURL)
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d bytes", maxNameLength)
+	}
+	if r.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+	if len(r.Value) > maxValueLength {
+		return fmt.Errorf("value must be at most %d bytes", maxValueLength)
+	}
+	if utf8.RuneCountInString(r.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxDescriptionLength)
+	}
+	if len(r.ImageURL) > maxImageURLLength {
+		return fmt.Errorf("image_url must be at most %d characters", maxImageURLLength)
+	}
+	if r.ImageURL != "" && !strings.HasPrefix(r.ImageURL, "https://") && !strings.HasPrefix(r.ImageURL, "ipfs://") {
+		return fmt.Errorf("image_url must be an https:// or ipfs:// URL")
+	}
+	if r.ValidityDays < 0 || r.ValidityDays > MaxValidityDays {
+		return fmt.Errorf("validity_days must be between 0 and %d", MaxValidityDays)
+	}
+	return nil
+}
+
+// Expiry is when a voucher issued at now stops being redeemable; nil if never
+func (r *Request) Expiry(now time.Time) *time.Time {
+	if r.ValidityDays == 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, r.ValidityDays)
+	return &expiresAt
+}
+
+// Status is the status to report for a voucher at now: active vouchers past
+// their expiry are EXPIRED
+func Status(voucher *models.Voucher, now time.Time) models.VoucherStatus {
+	if voucher.Status == models.VoucherActive && voucher.ExpiresAt != nil && !now.Before(*voucher.ExpiresAt) {
+		return models.VoucherExpired
+	}
+	return voucher.Status
+}
+
+// DatumMetadata is the metadata map of the reference token's CIP-68 datum
+func DatumMetadata(voucher *models.Voucher) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"name":        voucher.Name,
+		"merchant":    voucher.BusinessName,
+		"merchant_id": voucher.MerchantID,
+		"value":       voucher.Value,
+	}
+	if voucher.Description != "" {
+		metadata["description"] = voucher.Description
+	}
+	if voucher.ImageURL != "" {
+		metadata["image"] = voucher.ImageURL
+	}
+	if voucher.ExpiresAt != nil {
+		metadata["expiry"] = voucher.ExpiresAt.UnixMilli()
+	}
+	return metadata
+}
+
+// CIP25Metadata is the label 721 transaction metadata of the user token, for
+// wallets that do not read CIP-68 datums
+func CIP25Metadata(voucher *models.Voucher) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	for key, value := range DatumMetadata(voucher) {
+		if text, ok := value.(string); ok {
+			metadata[key] = chunk(text)
+		} else {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// chunk splits a string longer than a metadata string may be into chunks,
+// without splitting a UTF-8 character
+func chunk(text string) interface{} {
+	if len(text) <= metadataChunkSize {
+		return text
+	}
+	var chunks []string
+	for len(text) > metadataChunkSize {
+		end := metadataChunkSize
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		chunks = append(chunks, text[:end])
+		text = text[end:]
+	}
+	return append(chunks, text)
+}
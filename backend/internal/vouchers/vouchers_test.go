@@ -0,0 +1,146 @@
+package vouchers
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loyalcoin/backend/internal/crypto"
+	"github.com/loyalcoin/backend/internal/models"
+)
+
+// CIP-19 test vector: base address and its payment key hash
+const (
+	testAddress        = "addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwq2ytjqp"
+	testPaymentKeyHash = "9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e"
+)
+
+func TestPolicy(t *testing.T) {
+	policy, err := Policy(testAddress, "preprod")
+	if err != nil {
+		t.Fatalf("Policy: %v", err)
+	}
+	expected, err := crypto.NewMultiSigScript(1, []string{testPaymentKeyHash})
+	if err != nil {
+		t.Fatalf("NewMultiSigScript: %v", err)
+	}
+	if PolicyID(policy) != PolicyID(expected) {
+		t.Errorf("PolicyID = %s, want %s", PolicyID(policy), PolicyID(expected))
+	}
+	// Vector generated with lucid-cardano 0.10 (nativeScriptFromJson / mintingPolicyToId)
+	if got := PolicyID(policy); got != "8f093873401051c93d159c5fcf376f7da0d09a6014749cf37e0c024a" {
+		t.Errorf("Unexpected policy ID: %s", got)
+	}
+
+	if _, err := Policy(testAddress, "mainnet"); err == nil {
+		t.Error("expected an error for an address of another network")
+	}
+}
+
+func TestAssetNameAndUnit(t *testing.T) {
+	assetName, err := NewAssetName()
+	if err != nil {
+		t.Fatalf("NewAssetName: %v", err)
+	}
+	name, err := hex.DecodeString(assetName)
+	if err != nil || !strings.HasPrefix(string(name), "LCV") || len(name) != 11 {
+		t.Errorf("unexpected asset name %q", assetName)
+	}
+
+	policyID := strings.Repeat("ab", 28)
+	if got := Unit(policyID, UserLabel, assetName); got != policyID+"000de140"+assetName {
+		t.Errorf("unexpected user unit %s", got)
+	}
+	if got := Unit(policyID, ReferenceLabel, assetName); got != policyID+"000643b0"+assetName {
+		t.Errorf("unexpected reference unit %s", got)
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   Request
+		valid bool
+	}{
+		{"valid", Request{Name: " Free coffee ", Value: "1 coffee", ValidityDays: 30}, true},
+		{"no expiry", Request{Name: "Free coffee", Value: "1 coffee"}, true},
+		{"ipfs image", Request{Name: "Free coffee", Value: "1 coffee", ImageURL: "ipfs://Qm"}, true},
+		{"no name", Request{Value: "1 coffee"}, false},
+		{"no value", Request{Name: "Free coffee"}, false},
+		{"long name", Request{Name: strings.Repeat("a", 65), Value: "1 coffee"}, false},
+		{"http image", Request{Name: "Free coffee", Value: "1 coffee", ImageURL: "http://example.com/a.png"}, false},
+		{"negative validity", Request{Name: "Free coffee", Value: "1 coffee", ValidityDays: -1}, false},
+		{"long validity", Request{Name: "Free coffee", Value: "1 coffee", ValidityDays: MaxValidityDays + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+
+	req := Request{Name: " Free coffee ", Value: "1 coffee"}
+	if err := req.Validate(); err != nil || req.Name != "Free coffee" {
+		t.Errorf("expected the name to be trimmed, got %q (%v)", req.Name, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		status    models.VoucherStatus
+		expiresAt *time.Time
+		want      models.VoucherStatus
+	}{
+		{models.VoucherActive, nil, models.VoucherActive},
+		{models.VoucherActive, &future, models.VoucherActive},
+		{models.VoucherActive, &past, models.VoucherExpired},
+		{models.VoucherRedeemed, &past, models.VoucherRedeemed},
+		{models.VoucherMinting, &past, models.VoucherMinting},
+	}
+	for _, tt := range tests {
+		voucher := &models.Voucher{Status: tt.status, ExpiresAt: tt.expiresAt}
+		if got := Status(voucher, now); got != tt.want {
+			t.Errorf("Status(%s, %v) = %s, want %s", tt.status, tt.expiresAt, got, tt.want)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	expiresAt := time.UnixMilli(1_800_000_000_000)
+	description := strings.Repeat("é", 40) // 80 bytes
+	voucher := &models.Voucher{
+		MerchantID:   "m1",
+		BusinessName: "Corner Café",
+		Name:         "Free coffee",
+		Description:  description,
+		Value:        "1 coffee",
+		ExpiresAt:    &expiresAt,
+	}
+
+	datum := DatumMetadata(voucher)
+	if datum["merchant"] != "Corner Café" || datum["value"] != "1 coffee" || datum["expiry"] != int64(1_800_000_000_000) {
+		t.Errorf("unexpected datum metadata %v", datum)
+	}
+	if datum["description"] != description {
+		t.Error("expected the datum description in one piece")
+	}
+	if _, ok := datum["image"]; ok {
+		t.Error("expected no image without an image URL")
+	}
+
+	cip25 := CIP25Metadata(voucher)
+	if cip25["name"] != "Free coffee" {
+		t.Errorf("unexpected CIP-25 name %v", cip25["name"])
+	}
+	chunks, ok := cip25["description"].([]string)
+	if !ok || len(chunks) != 2 || strings.Join(chunks, "") != description {
+		t.Fatalf("expected the CIP-25 description in chunks, got %v", cip25["description"])
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > 64 {
+			t.Errorf("chunk of %d bytes", len(chunk))
+		}
+	}
+}
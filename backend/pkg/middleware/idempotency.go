@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// IdempotencyStore persists Idempotency-Key records (implemented by storage.IdempotencyRepository)
+type IdempotencyStore interface {
+	// Begin claims key for a request with the given fingerprint. It returns the
+	// existing record when the key is already taken, nil once it has been claimed.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, error)
+	// Complete stores the response of the request that claimed key
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	// Release gives up the claim on key of a request that did not complete,
+	// so that a retry runs the request again
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware makes retries of value-moving requests safe. A request
+// carrying an Idempotency-Key header runs once; repeating it with the same key
+// and body replays the stored response instead of running the handler again.
+// Keys are scoped to the authenticated user and route, so it must run after
+// AuthMiddleware. Requests without the header are passed through.
+//
+// Only responses below 500 are stored. When the handler fails with a server
+// error, panics, or its response cannot be stored, the key is released so
+// the client's retry runs the request again instead of waiting for the key
+// to expire.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_IDEMPOTENCY_KEY",
+				"message": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength),
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "400_INVALID_REQUEST",
+				"message": "Failed to read request body",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c, body)
+		scopedKey := fmt.Sprintf("%s:%s %s:%s", c.GetString("user_id"), c.Request.Method, c.FullPath(), key)
+		record, err := store.Begin(c.Request.Context(), scopedKey, fingerprint, ttl)
+		if err != nil {
+			// Fail closed: running the request without the key could move value twice
+			logger.Error("Idempotency store unavailable", err, map[string]interface{}{
+				"path": c.Request.URL.Path,
+			})
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"code":    "503_SERVICE_UNAVAILABLE",
+				"message": "Please retry later",
+			})
+			c.Abort()
+			return
+		}
+
+		if record != nil {
+			replayIdempotentResponse(c, record, fingerprint)
+			return
+		}
+
+		// Runs while a handler panic unwinds as well
+		completed := false
+		defer func() {
+			if !completed {
+				releaseIdempotencyKey(c, store, scopedKey)
+			}
+		}()
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		// The request context may be cancelled once the client has gone away,
+		// which is exactly when the response needs to be kept for the retry
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := store.Complete(ctx, scopedKey, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			logger.Error("Failed to store idempotent response", err, map[string]interface{}{
+				"path":    c.Request.URL.Path,
+				"user_id": c.GetString("user_id"),
+			})
+			return
+		}
+		completed = true
+	}
+}
+
+// releaseIdempotencyKey frees the key of a request whose response was not stored
+func releaseIdempotencyKey(c *gin.Context, store IdempotencyStore, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Release(ctx, key); err != nil {
+		logger.Error("Failed to release idempotency key", err, map[string]interface{}{
+			"path":    c.Request.URL.Path,
+			"user_id": c.GetString("user_id"),
+		})
+	}
+}
+
+// replayIdempotentResponse answers a request whose key was already used
+func replayIdempotentResponse(c *gin.Context, record *models.IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"code":    "422_IDEMPOTENCY_KEY_REUSED",
+			"message": "Idempotency-Key was already used for a different request",
+		})
+		c.Abort()
+		return
+	}
+	if !record.Completed {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"code":    "409_REQUEST_IN_PROGRESS",
+			"message": "A request with this Idempotency-Key is still being processed",
+		})
+		c.Abort()
+		return
+	}
+
+	logger.Info("Replaying idempotent response", map[string]interface{}{
+		"path":    c.Request.URL.Path,
+		"user_id": c.GetString("user_id"),
+	})
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+	c.Abort()
+}
+
+// requestFingerprint identifies a request by method, path, query and body
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// capturingWriter keeps a copy of the response body for the idempotency store
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
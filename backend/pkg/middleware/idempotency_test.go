@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryIdempotencyStore struct {
+	mu          sync.Mutex
+	records     map[string]*models.IdempotencyRecord
+	completeErr error
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	s.records[key] = &models.IdempotencyRecord{Key: key, Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completeErr != nil {
+		return s.completeErr
+	}
+	record := s.records[key]
+	record.Completed = true
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Body = body
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok && !record.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	store := &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+
+	calls := 0
+	router := gin.New()
+	router.POST("/issue", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	}, IdempotencyMiddleware(store, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "data": gin.H{"call": calls}})
+	})
+
+	send := func(user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/issue", strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("merchant-1", "key-1", `{"amount":10}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, 1, calls)
+
+	// A retry replays the stored response without running the handler
+	retry := send("merchant-1", "key-1", `{"amount":10}`)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// The same key with another body is rejected
+	reused := send("merchant-1", "key-1", `{"amount":20}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, 1, calls)
+
+	// Keys are scoped per user, and requests without a key always run
+	assert.Equal(t, http.StatusOK, send("merchant-2", "key-1", `{"amount":10}`).Code)
+	assert.Equal(t, 2, calls)
+	send("merchant-1", "", `{"amount":10}`)
+	send("merchant-1", "", `{"amount":10}`)
+	assert.Equal(t, 4, calls)
+
+	// A key whose first request has not finished yet cannot run concurrently
+	store.records["merchant-1:POST /issue:key-2"] = &models.IdempotencyRecord{
+		Fingerprint: store.records["merchant-1:POST /issue:key-1"].Fingerprint,
+	}
+	assert.Equal(t, http.StatusConflict, send("merchant-1", "key-2", `{"amount":10}`).Code)
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotencyMiddleware_ReleasesUnstoredResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	store := &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+
+	var outcome string
+	calls := 0
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.POST("/redeem", func(c *gin.Context) {
+		c.Set("user_id", "customer-1")
+	}, IdempotencyMiddleware(store, time.Hour), func(c *gin.Context) {
+		calls++
+		switch outcome {
+		case "panic":
+			panic("handler bug")
+		case "server error":
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "code": "500_REDEMPTION_FAILED"})
+		case "client error":
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "code": "400_INSUFFICIENT_BALANCE"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		}
+	})
+
+	send := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/redeem", strings.NewReader(`{"amount":10}`))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Panics, server errors and responses that could not be stored leave the
+	// key free, so the retry runs the handler again
+	tests := []struct {
+		outcome     string
+		completeErr error
+		code        int
+	}{
+		{"panic", nil, http.StatusInternalServerError},
+		{"server error", nil, http.StatusInternalServerError},
+		{"ok", errors.New("store down"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		key := "key-" + tt.outcome
+		outcome, store.completeErr = tt.outcome, tt.completeErr
+		require.Equal(t, tt.code, send(key), tt.outcome)
+
+		outcome, store.completeErr = "ok", nil
+		before := calls
+		assert.Equal(t, http.StatusOK, send(key), tt.outcome)
+		assert.Equal(t, before+1, calls, "%s: retry runs the handler", tt.outcome)
+	}
+
+	// Client errors are stored and replayed
+	outcome = "client error"
+	assert.Equal(t, http.StatusBadRequest, send("key-4xx"))
+	outcome = "ok"
+	before := calls
+	assert.Equal(t, http.StatusBadRequest, send("key-4xx"))
+	assert.Equal(t, before, calls)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -10,12 +12,32 @@ import (
 	"github.com/loyalcoin/backend/pkg/logger"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
+// APIKeyAuthenticator resolves merchant API keys (implemented by auth.APIKeyService)
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*auth.APIKeyPrincipal, error)
+}
+
+// PermissionResolver maps roles to permissions (implemented by auth.RBACService)
+type PermissionResolver interface {
+	HasPermission(ctx context.Context, role models.Role, permission models.Permission) (bool, error)
+}
+
+// StaffLookup loads merchant staff accounts (implemented by storage.StaffRepository)
+type StaffLookup interface {
+	GetStaffByID(ctx context.Context, id, merchantID string) (*models.MerchantStaff, error)
+}
+
+// AuthMiddleware validates JWT tokens. When apiKeys is non-nil, merchant API keys
+// are accepted as well, either as "X-API-Key: <key>" or "Authorization: ApiKey <key>".
+// Admin, merchant and staff tokens are checked against their account on every
+// request, so suspending an account or changing its role applies to tokens
+// already issued.
+func AuthMiddleware(jwtService *auth.JWTService, apiKeys APIKeyAuthenticator, accounts auth.MerchantStore, staff StaffLookup) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		apiKeyHeader := c.GetHeader("X-API-Key")
+		if authHeader == "" && apiKeyHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  "error",
 				"code":    "401_UNAUTHORIZED",
@@ -25,9 +47,9 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 			return
 		}
 
-		// Extract Bearer token
+		// Extract Bearer token (or API key)
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if apiKeyHeader == "" && (len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey")) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  "error",
 				"code":    "401_UNAUTHORIZED",
@@ -37,6 +59,15 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		if apiKeyHeader != "" || parts[0] == "ApiKey" {
+			rawKey := apiKeyHeader
+			if rawKey == "" {
+				rawKey = parts[1]
+			}
+			authenticateAPIKey(c, apiKeys, rawKey)
+			return
+		}
+
 		tokenString := parts[1]
 
 		// Validate token
@@ -54,15 +85,189 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		role := claims.Role
+		switch {
+		case claims.MerchantID != "" && claims.MerchantID != claims.UserID:
+			member, ok := activeStaff(c, staff, claims)
+			if !ok {
+				return
+			}
+			role = member.Role
+		case claims.Role != models.RoleCustomer:
+			account, ok := activeAccount(c, accounts, claims)
+			if !ok {
+				return
+			}
+			role = account.Role
+		}
+
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("role", role)
 		c.Set("wallet_address", claims.WalletAddress)
+		c.Set("auth_method", "jwt")
+
+		// Merchant owners and staff act for a business; tokens issued before
+		// staff accounts existed carry no merchant_id claim
+		merchantID := claims.MerchantID
+		if merchantID == "" && claims.Role == models.RoleMerchant {
+			merchantID = claims.UserID
+		}
+		if merchantID != "" {
+			c.Set("merchant_id", merchantID)
+		}
 
 		c.Next()
 	}
 }
 
+// activeStaff loads the staff member behind a staff token and rejects the
+// request if the account is gone or suspended
+func activeStaff(c *gin.Context, staff StaffLookup, claims *auth.JWTClaims) (*models.MerchantStaff, bool) {
+	var member *models.MerchantStaff
+	err := fmt.Errorf("staff accounts cannot be checked")
+	if staff != nil {
+		member, err = staff.GetStaffByID(c.Request.Context(), claims.UserID, claims.MerchantID)
+	}
+	if err != nil || member.Status == models.StatusSuspended {
+		fields := map[string]interface{}{
+			"staff_id":    claims.UserID,
+			"merchant_id": claims.MerchantID,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		logger.Warn("Rejected token of disabled staff account", fields)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_ACCOUNT_DISABLED",
+			"message": "This staff account is disabled",
+		})
+		c.Abort()
+		return nil, false
+	}
+	return member, true
+}
+
+// activeAccount loads the admin or merchant account behind a token and
+// rejects the request if the account is gone or suspended
+func activeAccount(c *gin.Context, accounts auth.MerchantStore, claims *auth.JWTClaims) (*models.Merchant, bool) {
+	var account *models.Merchant
+	err := fmt.Errorf("accounts cannot be checked")
+	if accounts != nil {
+		account, err = accounts.GetMerchantByID(c.Request.Context(), claims.UserID)
+	}
+	if err != nil || account.Status == models.StatusSuspended {
+		fields := map[string]interface{}{
+			"user_id": claims.UserID,
+			"role":    claims.Role,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		logger.Warn("Rejected token of disabled account", fields)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_ACCOUNT_DISABLED",
+			"message": "This account is disabled",
+		})
+		c.Abort()
+		return nil, false
+	}
+	return account, true
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, rawKey string) {
+	if apiKeys == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_API_KEY_NOT_ALLOWED",
+			"message": "API keys are not accepted on this endpoint",
+		})
+		c.Abort()
+		return
+	}
+
+	principal, err := apiKeys.Authenticate(c.Request.Context(), rawKey)
+	if err != nil {
+		logger.Warn("Invalid API key", map[string]interface{}{
+			"error": err.Error(),
+			"ip":    c.ClientIP(),
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"code":    "401_UNAUTHORIZED",
+			"message": "Invalid API key",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", principal.MerchantID)
+	c.Set("merchant_id", principal.MerchantID)
+	c.Set("role", models.RoleMerchant)
+	c.Set("wallet_address", principal.WalletAddress)
+	c.Set("auth_method", "api_key")
+	c.Set("api_key_id", principal.KeyID)
+	c.Set("api_key_scopes", principal.Scopes)
+
+	c.Next()
+}
+
+// RequirePermission allows the request only if the caller holds the permission.
+// JWT sessions are checked against their role's permissions; API-key requests
+// against the scopes granted to the key. The authorizing permission is stored
+// in the context under "permission" for audit logging.
+func RequirePermission(resolver PermissionResolver, permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleValue, _ := c.Get("role")
+		role, _ := roleValue.(models.Role)
+
+		var allowed bool
+		if c.GetString("auth_method") == "api_key" {
+			scopesValue, _ := c.Get("api_key_scopes")
+			scopes, _ := scopesValue.([]models.Permission)
+			for _, s := range scopes {
+				if s == permission {
+					allowed = true
+					break
+				}
+			}
+		} else {
+			var err error
+			allowed, err = resolver.HasPermission(c.Request.Context(), role, permission)
+			if err != nil {
+				logger.Warn("Failed to resolve role permissions", map[string]interface{}{
+					"role":  role,
+					"error": err.Error(),
+				})
+				allowed = false
+			}
+		}
+
+		if allowed {
+			c.Set("permission", permission)
+			c.Next()
+			return
+		}
+
+		logger.Audit("PERMISSION_DENIED", c.GetString("user_id"), map[string]interface{}{
+			"user_role":           role,
+			"auth_method":         c.GetString("auth_method"),
+			"api_key_id":          c.GetString("api_key_id"),
+			"required_permission": permission,
+			"path":                c.Request.URL.Path,
+		})
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"code":    "403_FORBIDDEN",
+			"message": "Missing required permission: " + string(permission),
+		})
+		c.Abort()
+	}
+}
+
 // RequireRole middleware checks if user has required role
 func RequireRole(roles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -113,8 +318,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-API-Key, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/internal/auth"
+	"github.com/loyalcoin/backend/internal/models"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticPermissions map[models.Role][]models.Permission
+
+func (p staticPermissions) HasPermission(ctx context.Context, role models.Role, permission models.Permission) (bool, error) {
+	for _, granted := range p[role] {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+
+	resolver := staticPermissions{
+		models.RoleMerchantCashier: {models.PermLCNIssue},
+	}
+
+	request := func(set func(c *gin.Context), permission models.Permission) (int, interface{}) {
+		var authorizedBy interface{}
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			set(c)
+			c.Next()
+		}, RequirePermission(resolver, permission), func(c *gin.Context) {
+			authorizedBy, _ = c.Get("permission")
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code, authorizedBy
+	}
+
+	cashier := func(c *gin.Context) {
+		c.Set("role", models.RoleMerchantCashier)
+		c.Set("auth_method", "jwt")
+	}
+	code, authorizedBy := request(cashier, models.PermLCNIssue)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.PermLCNIssue, authorizedBy)
+
+	code, _ = request(cashier, models.PermSettlementRequest)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// API keys are limited to their scopes, regardless of the merchant's role
+	apiKey := func(c *gin.Context) {
+		c.Set("role", models.RoleMerchant)
+		c.Set("auth_method", "api_key")
+		c.Set("api_key_scopes", []models.Permission{models.PermWalletRead})
+	}
+	code, _ = request(apiKey, models.PermWalletRead)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = request(apiKey, models.PermLCNIssue)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+type fakeAPIKeys map[string]*models.APIKey // by key hash
+
+func (k fakeAPIKeys) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if apiKey, ok := k[keyHash]; ok && apiKey.RevokedAt == nil {
+		return apiKey, nil
+	}
+	return nil, fmt.Errorf("API key not found")
+}
+
+func (k fakeAPIKeys) TouchAPIKey(ctx context.Context, id string) error {
+	return nil
+}
+
+type fakeMerchants map[string]*models.Merchant
+
+func (m fakeMerchants) GetMerchantByID(ctx context.Context, id string) (*models.Merchant, error) {
+	if merchant, ok := m[id]; ok {
+		return merchant, nil
+	}
+	return nil, fmt.Errorf("merchant not found")
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+
+	posKey, _, posHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	suspendedKey, _, suspendedHash, _ := auth.GenerateAPIKey()
+	revokedKey, _, revokedHash, _ := auth.GenerateAPIKey()
+	revokedAt := time.Now()
+
+	apiKeys := auth.NewAPIKeyService(fakeAPIKeys{
+		posHash:       {ID: "key-1", MerchantID: "m1", Scopes: []models.Permission{models.PermLCNIssue}},
+		suspendedHash: {ID: "key-2", MerchantID: "m2", Scopes: []models.Permission{models.PermLCNIssue}},
+		revokedHash:   {ID: "key-3", MerchantID: "m1", Scopes: []models.Permission{models.PermLCNIssue}, RevokedAt: &revokedAt},
+	}, fakeMerchants{
+		"m1": {ID: "m1", Status: models.StatusActive, Wallet: models.Wallet{Address: "addr_test1m1"}},
+		"m2": {ID: "m2", Status: models.StatusSuspended},
+	})
+	resolver := staticPermissions{}
+
+	request := func(acceptKeys bool, permission models.Permission, headers map[string]string) (int, map[string]interface{}) {
+		var authenticator APIKeyAuthenticator
+		if acceptKeys {
+			authenticator = apiKeys
+		}
+		seen := map[string]interface{}{}
+		router := gin.New()
+		router.GET("/", AuthMiddleware(nil, authenticator, nil, nil), RequirePermission(resolver, permission), func(c *gin.Context) {
+			for _, key := range []string{"user_id", "merchant_id", "role", "wallet_address", "auth_method", "api_key_id"} {
+				seen[key], _ = c.Get(key)
+			}
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, seen
+	}
+
+	// Either header form authenticates the key as its merchant
+	code, seen := request(true, models.PermLCNIssue, map[string]string{"X-API-Key": posKey})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "m1", seen["merchant_id"])
+	assert.Equal(t, "m1", seen["user_id"])
+	assert.Equal(t, models.RoleMerchant, seen["role"])
+	assert.Equal(t, "addr_test1m1", seen["wallet_address"])
+	assert.Equal(t, "api_key", seen["auth_method"])
+	assert.Equal(t, "key-1", seen["api_key_id"])
+
+	code, seen = request(true, models.PermLCNIssue, map[string]string{"Authorization": "ApiKey " + posKey})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "key-1", seen["api_key_id"])
+
+	// Permissions outside the key's scopes are refused
+	code, _ = request(true, models.PermSettlementRequest, map[string]string{"X-API-Key": posKey})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Suspended merchants, revoked, unknown and malformed keys are rejected
+	for name, key := range map[string]string{
+		"suspended merchant": suspendedKey,
+		"revoked key":        revokedKey,
+		"unknown key":        posKey + "x",
+		"malformed key":      "not-a-key",
+	} {
+		code, _ = request(true, models.PermLCNIssue, map[string]string{"X-API-Key": key})
+		assert.Equal(t, http.StatusUnauthorized, code, name)
+	}
+
+	// Session-only routes refuse API keys, in either header
+	code, _ = request(false, models.PermLCNIssue, map[string]string{"X-API-Key": posKey})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request(false, models.PermLCNIssue, map[string]string{"Authorization": "ApiKey " + posKey})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Missing and unknown authorization schemes
+	code, _ = request(true, models.PermLCNIssue, nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request(true, models.PermLCNIssue, map[string]string{"Authorization": "Basic " + posKey})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+type fakeStaff map[string]*models.MerchantStaff
+
+func (s fakeStaff) GetStaffByID(ctx context.Context, id, merchantID string) (*models.MerchantStaff, error) {
+	if staff, ok := s[id]; ok && staff.MerchantID == merchantID {
+		return staff, nil
+	}
+	return nil, fmt.Errorf("staff member not found")
+}
+
+// newTestJWTService signs tokens with a throwaway RSA key
+func newTestJWTService(t *testing.T) *auth.JWTService {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600)
+
+	jwtService, err := auth.NewJWTService(privatePath, publicPath, 1)
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	return jwtService
+}
+
+func TestAuthMiddleware_StaffTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	jwtService := newTestJWTService(t)
+
+	staff := fakeStaff{
+		"s1": {ID: "s1", MerchantID: "m1", Role: models.RoleMerchantCashier, Status: models.StatusActive},
+	}
+	accounts := fakeMerchants{
+		"m1": {ID: "m1", Role: models.RoleMerchant, Status: models.StatusActive},
+	}
+	request := func(token string) (int, interface{}) {
+		var role interface{}
+		router := gin.New()
+		router.GET("/", AuthMiddleware(jwtService, nil, accounts, staff), func(c *gin.Context) {
+			role, _ = c.Get("role")
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, role
+	}
+
+	token, err := jwtService.GenerateMerchantToken("s1", "m1", models.RoleMerchantManager, "addr_test1m1")
+	if err != nil {
+		t.Fatalf("GenerateMerchantToken: %v", err)
+	}
+	// The staff member's current role applies, not the one in the token
+	code, role := request(token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.RoleMerchantCashier, role)
+
+	// Suspending the staff member invalidates tokens already issued
+	staff["s1"].Status = models.StatusSuspended
+	code, _ = request(token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// So does removing the account, or moving it to another merchant
+	delete(staff, "s1")
+	code, _ = request(token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Owner and customer tokens need no staff account
+	owner, _ := jwtService.GenerateMerchantToken("m1", "m1", models.RoleMerchant, "addr_test1m1")
+	code, role = request(owner)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.RoleMerchant, role)
+
+	customer, _ := jwtService.GenerateToken("c1", models.RoleCustomer, "addr_test1c1")
+	code, _ = request(customer)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestAuthMiddleware_AccountTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	jwtService := newTestJWTService(t)
+
+	accounts := fakeMerchants{
+		"a1": {ID: "a1", Role: models.RoleAdmin, Status: models.StatusActive},
+		"m1": {ID: "m1", Role: models.RoleMerchant, Status: models.StatusActive},
+	}
+	request := func(token string) (int, interface{}) {
+		var role interface{}
+		router := gin.New()
+		router.GET("/", AuthMiddleware(jwtService, nil, accounts, fakeStaff{}), func(c *gin.Context) {
+			role, _ = c.Get("role")
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, role
+	}
+
+	admin, err := jwtService.GenerateMerchantToken("a1", "", models.RoleAdmin, "addr_test1a1")
+	if err != nil {
+		t.Fatalf("GenerateMerchantToken: %v", err)
+	}
+	code, role := request(admin)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.RoleAdmin, role)
+
+	// A role assigned after login applies to the token already issued
+	accounts["a1"].Role = models.RoleAuditor
+	code, role = request(admin)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.RoleAuditor, role)
+
+	// Tokens issued before merchant_id was a claim are checked as well
+	owner, _ := jwtService.GenerateToken("m1", models.RoleMerchant, "addr_test1m1")
+	code, _ = request(owner)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Suspending or removing the account invalidates its tokens
+	accounts["m1"].Status = models.StatusSuspended
+	code, _ = request(owner)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	delete(accounts, "a1")
+	code, _ = request(admin)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/pkg/logger"
+)
+
+// RateLimitResult describes a token bucket after a request has been counted
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token is available (denied requests only)
+}
+
+// RateLimitStore is the backend that holds the token buckets.
+// MemoryRateLimitStore serves a single instance, RedisRateLimitStore is shared by replicas.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error)
+}
+
+// RateLimitRule is a bucket definition: Limit requests per Period for every key
+type RateLimitRule struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	// Key returns the bucket key for a request, or "" to skip limiting
+	Key func(c *gin.Context) string
+}
+
+// RateLimitMiddleware enforces a rule against the store
+func RateLimitMiddleware(store RateLimitStore, rule RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rule.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		key := rule.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(c.Request.Context(), fmt.Sprintf("%s:%s", rule.Name, key), rule.Limit, rule.Period)
+		if err != nil {
+			// Fail open: an unavailable limiter backend must not take the API down
+			logger.Warn("Rate limiter unavailable", map[string]interface{}{
+				"rule":  rule.Name,
+				"error": err.Error(),
+			})
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, result)
+
+		if result.Allowed {
+			c.Next()
+			return
+		}
+
+		logger.Warn("Rate limit exceeded", map[string]interface{}{
+			"rule":    rule.Name,
+			"path":    c.Request.URL.Path,
+			"ip":      c.ClientIP(),
+			"user_id": c.GetString("user_id"),
+		})
+
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"status":  "error",
+			"code":    "429_RATE_LIMITED",
+			"message": "Too many requests, please retry later",
+			"data": gin.H{
+				"retry_after_seconds": ceilSeconds(result.RetryAfter),
+			},
+		})
+		c.Abort()
+	}
+}
+
+// RateLimitByIP limits every client IP to limit requests per period
+func RateLimitByIP(store RateLimitStore, limit int, period time.Duration) gin.HandlerFunc {
+	return RateLimitMiddleware(store, RateLimitRule{
+		Name:   "ip",
+		Limit:  limit,
+		Period: period,
+		Key:    IPRateLimitKey,
+	})
+}
+
+// RateLimitByUser limits every authenticated user_id to limit requests per period.
+// Must run after AuthMiddleware.
+func RateLimitByUser(store RateLimitStore, limit int, period time.Duration) gin.HandlerFunc {
+	return RateLimitMiddleware(store, RateLimitRule{
+		Name:   "user",
+		Limit:  limit,
+		Period: period,
+		Key:    UserRateLimitKey,
+	})
+}
+
+// IPRateLimitKey keys a bucket by client IP
+func IPRateLimitKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// UserRateLimitKey keys a bucket by authenticated user ID
+func UserRateLimitKey(c *gin.Context) string {
+	return c.GetString("user_id")
+}
+
+// setRateLimitHeaders writes the X-RateLimit-* headers. When several rules apply
+// to one request the most restrictive bucket (lowest remaining) is reported.
+func setRateLimitHeaders(c *gin.Context, result *RateLimitResult) {
+	header := c.Writer.Header()
+	if current := header.Get("X-RateLimit-Remaining"); current != "" {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining <= result.Remaining && result.Allowed {
+			return
+		}
+	}
+	header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryRateLimitStore keeps token buckets in process memory (single instance deployments)
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Take removes one token from the bucket for key, refilling it at limit tokens per period
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error) {
+	if limit <= 0 || period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d per %s", limit, period)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit)
+	rate := capacity / float64(period) // tokens per nanosecond
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updated: now, period: period}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.updated)
+	if elapsed > 0 {
+		bucket.tokens = math.Min(capacity, bucket.tokens+float64(elapsed)*rate)
+	}
+	bucket.updated = now
+	bucket.period = period
+
+	result := &RateLimitResult{Limit: limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(bucket.tokens))
+	result.ResetAfter = time.Duration((capacity - bucket.tokens) / rate)
+
+	return result, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= bucket.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// tokenBucketScript implements the same token bucket as MemoryRateLimitStore atomically in Redis.
+// The Redis server clock is used so that replicas with skewed clocks share one view of time.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local period_ms = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rate = limit / period_ms
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = limit
+	ts = now
+end
+
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', key, period_ms)
+
+local reset = math.ceil((limit - tokens) / rate)
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// RedisRateLimitStore keeps token buckets in Redis so that all replicas share them
+type RedisRateLimitStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: client,
+		prefix: "ratelimit:",
+	}
+}
+
+// Take removes one token from the bucket for key, refilling it at limit tokens per period
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error) {
+	if limit <= 0 || period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d per %s", limit, period)
+	}
+
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key}, limit, period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rate limit script: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/loyalcoin/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStore_TokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Full bucket allows a burst of exactly `limit` requests
+	for i := 0; i < 3; i++ {
+		result, err := store.Take(ctx, "ip:1.2.3.4", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+
+	// Other keys have their own bucket
+	result, err = store.Take(ctx, "ip:5.6.7.8", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// One token is refilled every period/limit
+	now = now.Add(20 * time.Second)
+	result, err = store.Take(ctx, "ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Minute, result.ResetAfter)
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	store := NewMemoryRateLimitStore()
+
+	router := gin.New()
+	router.Use(RateLimitByIP(store, 10, time.Minute))
+	router.GET("/login", RateLimitMiddleware(store, RateLimitRule{
+		Name:   "login",
+		Limit:  1,
+		Period: time.Minute,
+		Key:    IPRateLimitKey,
+	}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The tighter per-route bucket is reported
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestIPRateLimitKey_UntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "text")
+	store := NewMemoryRateLimitStore()
+
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(nil))
+	router.GET("/login", RateLimitMiddleware(store, RateLimitRule{
+		Name:   "login",
+		Limit:  1,
+		Period: time.Minute,
+		Key:    IPRateLimitKey,
+	}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// A different forged X-Forwarded-For per request still hits one bucket
+	for i, forwarded := range []string{"10.0.0.1", "10.0.0.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if i == 0 {
+			assert.Equal(t, http.StatusOK, w.Code)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		}
+	}
+
+	// Behind a trusted proxy the forwarded address is the client
+	router = gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"203.0.113.0/24"}))
+	router.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, IPRateLimitKey(c))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "198.51.100.9", w.Body.String())
+}
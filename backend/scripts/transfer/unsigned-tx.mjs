@@ -0,0 +1,123 @@
+import { Lucid, Blockfrost } from "lucid-cardano";
+
+const BLOCKFROST_PROJECT_ID = process.env.BLOCKFROST_PROJECT_ID || "preprod6OurCW7t1wZmS1dHM80IOMLluKOYrOdg";
+const BLOCKFROST_API_URL = process.env.BLOCKFROST_API_URL || "https://cardano-preprod.blockfrost.io/api/v0";
+
+// Builds and submits transactions whose keys never reach this process: wallets
+// signed inside Vault or derived from a recovery phrase, the governance
+// multi-sig native script and customers' external (CIP-30) wallets. Signing
+// happens elsewhere: "build" returns the unsigned transaction and its body
+// hash, "submit" attaches the collected vkey witnesses.
+//
+// build:  { action, fromAddress | (keyHashes, required), toAddress, lovelace, excludeInputs, validToMs }
+//      -> { status, txHash, txCbor, inputs }
+// submit: { action, txCbor, witnessSet }
+//      -> { status, txHash }
+
+const sleep = (ms) => new Promise(resolve => setTimeout(resolve, ms));
+
+async function retryWithBackoff(fn, maxRetries = 3, baseDelay = 1000) {
+    for (let attempt = 1; attempt <= maxRetries; attempt++) {
+        try {
+            return await fn();
+        } catch (error) {
+            if (attempt === maxRetries) throw error;
+            await sleep(baseDelay * Math.pow(2, attempt - 1));
+        }
+    }
+}
+
+async function build(lucid, input) {
+    const { fromAddress, keyHashes, required, toAddress, lovelace, excludeInputs, validToMs } = input;
+
+    // Must match crypto.MultiSigScript in the backend (same key order)
+    let script = null;
+    let address = fromAddress;
+    if (keyHashes) {
+        script = lucid.utils.nativeScriptFromJson({
+            type: "atLeast",
+            required,
+            scripts: keyHashes.map((keyHash) => ({ type: "sig", keyHash })),
+        });
+        address = lucid.utils.validatorToAddress(script);
+    }
+
+    // Skip inputs already spent by transactions still waiting for signatures
+    const locked = new Set(excludeInputs || []);
+    const utxos = (await retryWithBackoff(() => lucid.utxosAt(address), 3, 1000))
+        .filter((u) => !locked.has(`${u.txHash}#${u.outputIndex}`));
+
+    if (utxos.length === 0) {
+        throw new Error("No spendable UTXOs in wallet. The wallet may be unfunded or all UTXOs are reserved by pending transactions.");
+    }
+
+    const totalAvailable = utxos.reduce((sum, u) => sum + u.assets.lovelace, 0n);
+    const requiredLovelace = BigInt(lovelace) + 500000n; // Amount + estimated fee
+    if (totalAvailable < requiredLovelace) {
+        throw new Error(`Insufficient funds. Required: ${requiredLovelace}, Available: ${totalAvailable}`);
+    }
+
+    lucid.selectWalletFrom({ address, utxos });
+
+    let builder = lucid.newTx().payToAddress(toAddress, { lovelace: BigInt(lovelace) });
+    if (script) {
+        builder = builder.attachSpendingValidator(script);
+    }
+    if (validToMs) {
+        builder = builder.validTo(validToMs);
+    }
+    const tx = await builder.complete();
+
+    const body = tx.txComplete.body();
+    const inputs = [];
+    for (let i = 0; i < body.inputs().len(); i++) {
+        const txIn = body.inputs().get(i);
+        inputs.push(`${txIn.transaction_id().to_hex()}#${txIn.index().to_str()}`);
+    }
+
+    return { status: "ok", txHash: tx.toHash(), txCbor: tx.toString(), inputs };
+}
+
+async function submit(lucid, input) {
+    const signed = await lucid.fromTx(input.txCbor).assemble([input.witnessSet]).complete();
+    const txHash = await retryWithBackoff(() => signed.submit(), 2, 2000);
+    return { status: "ok", txHash };
+}
+
+async function main() {
+    const chunks = [];
+    for await (const chunk of process.stdin) chunks.push(chunk);
+    const input = JSON.parse(Buffer.concat(chunks).toString());
+
+    try {
+        const lucid = await retryWithBackoff(async () => {
+            return await Lucid.new(
+                new Blockfrost(BLOCKFROST_API_URL, BLOCKFROST_PROJECT_ID),
+                "Preprod",
+            );
+        }, 3, 2000);
+
+        let result;
+        switch (input.action) {
+            case "build":
+                result = await build(lucid, input);
+                break;
+            case "submit":
+                result = await submit(lucid, input);
+                break;
+            default:
+                throw new Error(`Unknown action: ${input.action}`);
+        }
+
+        console.log(JSON.stringify(result));
+    } catch (error) {
+        console.error(JSON.stringify({
+            status: "error",
+            message: error.message || String(error),
+            stack: error.stack || "No stack trace"
+        }));
+        process.exit(1);
+    }
+}
+
+main();
@@ -0,0 +1,156 @@
+import { Lucid, Blockfrost, Data, Constr } from "lucid-cardano";
+
+const BLOCKFROST_PROJECT_ID = process.env.BLOCKFROST_PROJECT_ID || "preprod6OurCW7t1wZmS1dHM80IOMLluKOYrOdg";
+const BLOCKFROST_API_URL = process.env.BLOCKFROST_API_URL || "https://cardano-preprod.blockfrost.io/api/v0";
+
+// Builds unsigned voucher transactions under a merchant's minting policy, a
+// 1-of-1 native script over its payment key (crypto.MultiSigScript in the
+// backend). A voucher is a CIP-68 pair: the user token (222) goes to the
+// holder with the ADA deposit, the reference token (100) carries the metadata
+// datum and sits at the policy script's own address, where transfers from the
+// merchant wallet never spend it. Signing and submitting go through
+// unsigned-tx.mjs "submit".
+//
+// mint: { action, fromAddress, keyHash, toAddress, assetName, datum, metadata, lovelace }
+//    -> { status, txHash, txCbor, policyId }
+// burn: { action, merchantAddress, holderAddress, keyHash, assetName, lovelace }
+//    -> { status, txHash, txCbor, policyId }
+
+const REFERENCE_LABEL = "000643b0"; // (100)
+const USER_LABEL = "000de140"; // (222)
+const MIN_RETURN_LOVELACE = 1500000n;
+
+const sleep = (ms) => new Promise(resolve => setTimeout(resolve, ms));
+
+async function retryWithBackoff(fn, maxRetries = 3, baseDelay = 1000) {
+    for (let attempt = 1; attempt <= maxRetries; attempt++) {
+        try {
+            return await fn();
+        } catch (error) {
+            if (attempt === maxRetries) throw error;
+            await sleep(baseDelay * Math.pow(2, attempt - 1));
+        }
+    }
+}
+
+function voucherPolicy(lucid, keyHash, assetName) {
+    const script = lucid.utils.nativeScriptFromJson({
+        type: "atLeast",
+        required: 1,
+        scripts: [{ type: "sig", keyHash }],
+    });
+    const policyId = lucid.utils.mintingPolicyToId(script);
+    return {
+        script,
+        policyId,
+        scriptAddress: lucid.utils.validatorToAddress(script),
+        referenceUnit: policyId + REFERENCE_LABEL + assetName,
+        userUnit: policyId + USER_LABEL + assetName,
+    };
+}
+
+async function selectWallet(lucid, address) {
+    const utxos = await retryWithBackoff(() => lucid.utxosAt(address), 3, 1000);
+    if (utxos.length === 0) {
+        throw new Error("No UTXOs in merchant wallet. The wallet needs ADA to pay fees and voucher deposits.");
+    }
+    lucid.selectWalletFrom({ address, utxos });
+}
+
+async function mint(lucid, input) {
+    const { fromAddress, keyHash, toAddress, assetName, datum, metadata, lovelace } = input;
+    const policy = voucherPolicy(lucid, keyHash, assetName);
+    await selectWallet(lucid, fromAddress);
+
+    // CIP-68 metadata datum: Constr 0 [metadata, version, extra]
+    const referenceDatum = Data.to(new Constr(0, [Data.fromJson(datum), 1n, new Constr(0, [])]));
+
+    const tx = await lucid.newTx()
+        .mintAssets({ [policy.referenceUnit]: 1n, [policy.userUnit]: 1n })
+        .attachMintingPolicy(policy.script)
+        .payToAddressWithData(policy.scriptAddress, { inline: referenceDatum }, { [policy.referenceUnit]: 1n })
+        .payToAddress(toAddress, { lovelace: BigInt(lovelace), [policy.userUnit]: 1n })
+        // CIP-25 version 2 (byte keys) for wallets that do not read CIP-68 datums
+        .attachMetadataWithConversion(721, {
+            [`0x${policy.policyId}`]: { [`0x${USER_LABEL}${assetName}`]: metadata },
+            version: 2,
+        })
+        .complete();
+
+    return { status: "ok", txHash: tx.toHash(), txCbor: tx.toString(), policyId: policy.policyId };
+}
+
+async function burn(lucid, input) {
+    const { merchantAddress, holderAddress, keyHash, assetName, lovelace } = input;
+    const policy = voucherPolicy(lucid, keyHash, assetName);
+
+    const [userUtxo] = await retryWithBackoff(() => lucid.utxosAtWithUnit(holderAddress, policy.userUnit), 3, 1000);
+    if (!userUtxo) {
+        throw new Error("Voucher token not found in the holder's wallet");
+    }
+    const [referenceUtxo] = await retryWithBackoff(() => lucid.utxosAtWithUnit(policy.scriptAddress, policy.referenceUnit), 3, 1000);
+    if (!referenceUtxo) {
+        throw new Error("Voucher reference token not found at the policy address");
+    }
+    await selectWallet(lucid, merchantAddress);
+
+    // The deposit goes back to the merchant (as change); anything else the
+    // holder's output carried goes back to the holder
+    const deposit = BigInt(lovelace) < userUtxo.assets.lovelace ? BigInt(lovelace) : userUtxo.assets.lovelace;
+    const returned = { ...userUtxo.assets, lovelace: userUtxo.assets.lovelace - deposit };
+    delete returned[policy.userUnit];
+    const hasOtherAssets = Object.keys(returned).length > 1;
+    if (hasOtherAssets && returned.lovelace < MIN_RETURN_LOVELACE) {
+        returned.lovelace = MIN_RETURN_LOVELACE;
+    }
+
+    let builder = lucid.newTx()
+        .collectFrom([userUtxo, referenceUtxo])
+        .attachSpendingValidator(policy.script)
+        .mintAssets({ [policy.referenceUnit]: -1n, [policy.userUnit]: -1n })
+        .attachMintingPolicy(policy.script);
+    if (hasOtherAssets || returned.lovelace >= MIN_RETURN_LOVELACE) {
+        builder = builder.payToAddress(holderAddress, returned);
+    }
+    const tx = await builder.complete();
+
+    return { status: "ok", txHash: tx.toHash(), txCbor: tx.toString(), policyId: policy.policyId };
+}
+
+async function main() {
+    const chunks = [];
+    for await (const chunk of process.stdin) chunks.push(chunk);
+    const input = JSON.parse(Buffer.concat(chunks).toString());
+
+    try {
+        const lucid = await retryWithBackoff(async () => {
+            return await Lucid.new(
+                new Blockfrost(BLOCKFROST_API_URL, BLOCKFROST_PROJECT_ID),
+                "Preprod",
+            );
+        }, 3, 2000);
+
+        let result;
+        switch (input.action) {
+            case "mint":
+                result = await mint(lucid, input);
+                break;
+            case "burn":
+                result = await burn(lucid, input);
+                break;
+            default:
+                throw new Error(`Unknown action: ${input.action}`);
+        }
+
+        console.log(JSON.stringify(result));
+    } catch (error) {
+        console.error(JSON.stringify({
+            status: "error",
+            message: error.message || String(error),
+            stack: error.stack || "No stack trace"
+        }));
+        process.exit(1);
+    }
+}
+
+main();
@@ -38,7 +38,7 @@ export interface AuthResponse {
     data: {
         token: string;
         expires_at: string;
-        user: { id: string; email: string; role: string; wallet_address: string };
+        user: { id: string; email: string; role: string; scope: string; permissions: string[]; wallet_address: string };
     };
 }
 
@@ -104,6 +104,8 @@ export async function approveAllocation(purchaseId: string, action: 'APPROVE' |
     console.log('Calling approveAllocation with:', { purchaseId, action, notes });
     return apiRequest('/api/v1/admin/allocation/approve', {
         method: 'POST',
+        // One decision per purchase: a retried approval replays the first response
+        headers: { 'Idempotency-Key': `allocation-${purchaseId}-${action}` },
         body: JSON.stringify({ purchase_id: purchaseId, action, notes: notes || '' }),
     });
 }
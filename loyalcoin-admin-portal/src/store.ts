@@ -39,8 +39,8 @@ export const useStore = create<AppState>()(
                     const response = await api.login(email, password);
                     const { token, user } = response.data;
 
-                    // Verify this is an admin
-                    if (user.role !== 'ADMIN') {
+                    // Verify this is a platform (admin) account
+                    if (user.scope !== 'PLATFORM') {
                         throw new api.ApiError('403_FORBIDDEN', 'Access denied. Admin account required.', 403);
                     }
 
@@ -9,6 +9,8 @@ import { Signup } from './pages/Signup';
 import { Dashboard } from './pages/Dashboard';
 import { Receive } from './pages/Receive';
 import { Spend } from './pages/Spend';
+import { Gift } from './pages/Gift';
+import { Rewards } from './pages/Rewards';
 import { Transactions } from './pages/Transactions';
 import { Profile } from './pages/Profile';
 
@@ -95,6 +97,12 @@ const App: React.FC = () => {
             <Route path="/spend" element={
                 <ProtectedRoute><Spend /></ProtectedRoute>
             } />
+            <Route path="/gift" element={
+                <ProtectedRoute><Gift /></ProtectedRoute>
+            } />
+            <Route path="/rewards" element={
+                <ProtectedRoute><Rewards /></ProtectedRoute>
+            } />
             <Route path="/transactions" element={
                 <ProtectedRoute><Transactions /></ProtectedRoute>
             } />
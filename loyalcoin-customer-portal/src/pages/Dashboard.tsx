@@ -1,10 +1,65 @@
-import React from 'react';
+import React, { useEffect, useState } from 'react';
 import { Link } from 'react-router-dom';
-import { QrCode, Send, ArrowDownLeft, ArrowUpRight, RefreshCw } from 'lucide-react';
+import { QrCode, Send, ArrowDownLeft, ArrowUpRight, RefreshCw, Clock, Award, Gift, Heart, RotateCcw } from 'lucide-react';
 import { useStore } from '../store';
+import {
+    getExpiringLCN, getTier, getReferrals, getRefunds, consentRefund,
+    ExpiringResponse, TierResponse, ReferralsResponse, Refund,
+} from '../services/api';
 
 export const Dashboard: React.FC = () => {
     const { user, balance, transactions, fetchBalance, fetchTransactions, isLoading } = useStore();
+    const [expiring, setExpiring] = useState<ExpiringResponse['data'] | null>(null);
+    const [tier, setTier] = useState<TierResponse['data'] | null>(null);
+    const [referrals, setReferrals] = useState<ReferralsResponse['data'] | null>(null);
+    const [pendingRefunds, setPendingRefunds] = useState<Refund[]>([]);
+    const [answeringId, setAnsweringId] = useState<string | null>(null);
+    const [refundError, setRefundError] = useState<string | null>(null);
+
+    // Points the customer is about to lose
+    useEffect(() => {
+        getExpiringLCN()
+            .then((res) => setExpiring(res.data))
+            .catch(() => setExpiring(null));
+        getTier()
+            .then((res) => setTier(res.data))
+            .catch(() => setTier(null));
+    }, [balance]);
+
+    useEffect(() => {
+        getReferrals()
+            .then((res) => setReferrals(res.data))
+            .catch(() => setReferrals(null));
+    }, []);
+
+    // Merchants reversing LCN they issued by mistake wait for the customer's answer
+    const loadPendingRefunds = () =>
+        getRefunds('PENDING_CONSENT')
+            .then((res) => setPendingRefunds(res.data.refunds))
+            .catch(() => setPendingRefunds([]));
+
+    useEffect(() => {
+        loadPendingRefunds();
+    }, []);
+
+    const handleConsent = async (refund: Refund, approve: boolean) => {
+        setRefundError(null);
+        if (approve && !window.confirm(`Return ${refund.amount_lcn.toLocaleString()} LCN to the merchant?`)) {
+            return;
+        }
+        setAnsweringId(refund.id);
+        try {
+            await consentRefund(refund.id, approve);
+            loadPendingRefunds();
+            if (approve) {
+                setTimeout(handleRefresh, 2000);
+            }
+        } catch (err: any) {
+            setRefundError(err.message || 'Failed to answer the request. Please try again.');
+        } finally {
+            setAnsweringId(null);
+        }
+    };
 
     // Pull to refresh
     const handleRefresh = () => {
@@ -70,6 +125,94 @@ export const Dashboard: React.FC = () => {
                 </button>
             </div>
 
+            {/* Expiry Warning */}
+            {expiring && expiring.expiring_lcn > 0 && (
+                <div className="alert alert-warning" style={{ display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+                    <Clock size={18} />
+                    <span>
+                        {expiring.expiring_lcn.toLocaleString()} LCN expire{expiring.lots.length > 0 && ` from ${formatDate(expiring.lots[0].expires_at)}`}. Spend them before they are gone!
+                    </span>
+                </div>
+            )}
+
+            {/* Refund Requests */}
+            {refundError && <div className="alert alert-error">{refundError}</div>}
+            {pendingRefunds.map((refund) => (
+                <div key={refund.id} className="card mb-3">
+                    <span style={{ display: 'inline-flex', alignItems: 'center', gap: '0.5rem', fontWeight: 600 }}>
+                        <RotateCcw size={18} color="var(--primary)" />
+                        Return {refund.amount_lcn.toLocaleString()} LCN?
+                    </span>
+                    <p style={{ fontSize: '0.875rem', color: 'var(--text-secondary)', marginTop: '0.5rem' }}>
+                        A merchant asks for LCN it issued to you back: {refund.reason}
+                    </p>
+                    <div style={{ display: 'flex', gap: '0.5rem', marginTop: '0.75rem' }}>
+                        <button
+                            className="btn btn-primary"
+                            style={{ flex: 1 }}
+                            disabled={answeringId !== null}
+                            onClick={() => handleConsent(refund, true)}
+                        >
+                            {answeringId === refund.id ? <span className="spinner" /> : 'Approve'}
+                        </button>
+                        <button
+                            className="btn btn-secondary"
+                            style={{ flex: 1 }}
+                            disabled={answeringId !== null}
+                            onClick={() => handleConsent(refund, false)}
+                        >
+                            Decline
+                        </button>
+                    </div>
+                </div>
+            ))}
+
+            {/* Tier Progress */}
+            {tier && (
+                <div className="card tier-card">
+                    <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'space-between' }}>
+                        <span style={{ display: 'inline-flex', alignItems: 'center', gap: '0.5rem', fontWeight: 600 }}>
+                            <Award size={18} color="var(--primary)" />
+                            {tier.tier.name}
+                        </span>
+                        <span style={{ fontSize: '0.875rem', color: 'var(--text-muted)' }}>
+                            ×{tier.tier.earn_multiplier} points
+                        </span>
+                    </div>
+                    {tier.next_tier ? (
+                        <>
+                            <div className="tier-progress">
+                                <div className="tier-progress-bar" style={{ width: `${Math.min(tier.progress_percent, 100)}%` }} />
+                            </div>
+                            <p style={{ fontSize: '0.875rem', color: 'var(--text-muted)' }}>
+                                {tier.lcn_to_next_tier.toLocaleString()} LCN more to reach {tier.next_tier.name}
+                            </p>
+                        </>
+                    ) : (
+                        <p style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginTop: '0.5rem' }}>
+                            You are at the top tier
+                        </p>
+                    )}
+                </div>
+            )}
+
+            {/* Referral Code */}
+            {referrals && referrals.referral_code && (
+                <div className="card referral-card">
+                    <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'space-between' }}>
+                        <span style={{ display: 'inline-flex', alignItems: 'center', gap: '0.5rem', fontWeight: 600 }}>
+                            <Gift size={18} color="var(--primary)" />
+                            Invite friends
+                        </span>
+                        <span className="referral-code">{referrals.referral_code}</span>
+                    </div>
+                    <p style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginTop: '0.5rem' }}>
+                        You get {referrals.referrer_reward_lcn.toLocaleString()} LCN and they get {referrals.referee_reward_lcn.toLocaleString()} LCN after their first purchase.
+                        {referrals.total > 0 && ` ${referrals.total} joined so far.`}
+                    </p>
+                </div>
+            )}
+
             {/* Quick Actions */}
             <div className="quick-actions">
                 <Link to="/receive" className="action-btn">
@@ -84,6 +227,12 @@ export const Dashboard: React.FC = () => {
                     </div>
                     <span>Spend Points</span>
                 </Link>
+                <Link to="/gift" className="action-btn">
+                    <div className="icon spend">
+                        <Heart size={24} />
+                    </div>
+                    <span>Send to a Friend</span>
+                </Link>
             </div>
 
             {/* Recent Transactions */}
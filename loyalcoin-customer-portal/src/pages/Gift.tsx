@@ -0,0 +1,184 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { ArrowLeft, CheckCircle, AlertCircle } from 'lucide-react';
+import { useStore } from '../store';
+import { transferLCN, getTransferLimits, TransferLimits, ApiError, newIdempotencyKey } from '../services/api';
+
+const MAX_MESSAGE_LENGTH = 140;
+
+export const Gift: React.FC = () => {
+    const navigate = useNavigate();
+    const { balance, fetchBalance, fetchTransactions } = useStore();
+
+    const [recipient, setRecipient] = useState('');
+    const [amount, setAmount] = useState('');
+    const [message, setMessage] = useState('');
+    const [limits, setLimits] = useState<TransferLimits | null>(null);
+    const [loading, setLoading] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [success, setSuccess] = useState<{ recipient: string; amount: number } | null>(null);
+    // Reused when the transfer is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
+
+    const lcnAmount = parseInt(amount, 10) || 0;
+
+    useEffect(() => {
+        getTransferLimits()
+            .then((res) => setLimits(res.data))
+            .catch(() => setLimits(null));
+    }, []);
+
+    const handleSubmit = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setError(null);
+
+        if (lcnAmount <= 0) {
+            setError('Please enter a valid amount');
+            return;
+        }
+        if (balance && lcnAmount > balance.lcn) {
+            setError(`Insufficient balance. You have ${balance.lcn.toLocaleString()} LCN`);
+            return;
+        }
+
+        setLoading(true);
+        try {
+            const response = await transferLCN(recipient.trim(), lcnAmount, message.trim(), idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            setSuccess({ recipient: response.data.recipient, amount: lcnAmount });
+
+            setTimeout(() => {
+                fetchBalance();
+                fetchTransactions();
+            }, 2000);
+        } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
+            setError(err.message || 'Transfer failed. Please try again.');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    if (success) {
+        return (
+            <div>
+                <div className="page-header">
+                    <button className="back-btn" onClick={() => navigate('/')}>
+                        <ArrowLeft size={20} />
+                    </button>
+                    <h1 className="page-title">Gift Sent!</h1>
+                </div>
+
+                <div className="card-glass text-center">
+                    <CheckCircle size={40} color="#10B981" style={{ margin: '0 auto 1.5rem' }} />
+                    <h2 style={{ fontSize: '1.5rem', marginBottom: '0.5rem' }}>
+                        -{success.amount.toLocaleString()} LCN
+                    </h2>
+                    <p style={{ color: 'var(--text-secondary)', marginBottom: '1.5rem' }}>
+                        Sent to {success.recipient}
+                    </p>
+                    <button className="btn btn-primary btn-block" onClick={() => navigate('/')}>
+                        Back to Home
+                    </button>
+                </div>
+            </div>
+        );
+    }
+
+    if (limits && !limits.enabled) {
+        return (
+            <div>
+                <div className="page-header">
+                    <button className="back-btn" onClick={() => navigate(-1)}>
+                        <ArrowLeft size={20} />
+                    </button>
+                    <h1 className="page-title">Send to a Friend</h1>
+                </div>
+                <div className="empty-state">
+                    <p>Sending points to friends is not available.</p>
+                </div>
+            </div>
+        );
+    }
+
+    return (
+        <div>
+            <div className="page-header">
+                <button className="back-btn" onClick={() => navigate(-1)}>
+                    <ArrowLeft size={20} />
+                </button>
+                <h1 className="page-title">Send to a Friend</h1>
+            </div>
+
+            {/* Current Balance */}
+            <div className="card mb-3" style={{ textAlign: 'center' }}>
+                <p style={{ fontSize: '0.875rem', color: 'var(--text-secondary)' }}>Available Balance</p>
+                <p style={{ fontSize: '1.5rem', fontWeight: '700', color: 'var(--primary)' }}>
+                    {balance ? balance.lcn.toLocaleString() : '0'} LCN
+                </p>
+                {limits && limits.remaining_lcn !== undefined && (
+                    <p style={{ fontSize: '0.75rem', color: 'var(--text-muted)', marginTop: '0.25rem' }}>
+                        You can send {limits.remaining_lcn.toLocaleString()} LCN more today
+                    </p>
+                )}
+            </div>
+
+            <form onSubmit={handleSubmit}>
+                {error && (
+                    <div className="alert alert-error" style={{ display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+                        <AlertCircle size={18} />
+                        {error}
+                    </div>
+                )}
+
+                <div className="form-group">
+                    <label className="form-label">Friend</label>
+                    <input
+                        type="text"
+                        className="form-input"
+                        placeholder="Username or phone number"
+                        value={recipient}
+                        onChange={(e) => setRecipient(e.target.value)}
+                        required
+                    />
+                </div>
+
+                <div className="form-group">
+                    <label className="form-label">Amount (LCN)</label>
+                    <input
+                        type="number"
+                        className="form-input"
+                        placeholder="Enter amount"
+                        value={amount}
+                        onChange={(e) => setAmount(e.target.value)}
+                        min="1"
+                        step="1"
+                        required
+                    />
+                </div>
+
+                <div className="form-group">
+                    <label className="form-label">Message (optional)</label>
+                    <input
+                        type="text"
+                        className="form-input"
+                        placeholder="Happy birthday!"
+                        value={message}
+                        onChange={(e) => setMessage(e.target.value)}
+                        maxLength={MAX_MESSAGE_LENGTH}
+                    />
+                </div>
+
+                <button
+                    type="submit"
+                    className="btn btn-primary btn-block"
+                    disabled={loading || !recipient || !amount}
+                >
+                    {loading ? <span className="spinner" /> : 'Send Gift'}
+                </button>
+            </form>
+        </div>
+    );
+};
@@ -1,12 +1,102 @@
 import React, { useState } from 'react';
 import { useNavigate } from 'react-router-dom';
-import { ArrowLeft, Copy, Check, LogOut, User } from 'lucide-react';
-import { useStore } from '../store';
+import { ArrowLeft, Copy, Check, LogOut, User, Key, Wallet, Mail } from 'lucide-react';
+import { useStore, saveUser } from '../store';
+import { exportRecoveryPhrase, createWalletChallenge, registerExternalWallet, sendVerificationCode, verifyContact } from '../services/api';
+import { availableWallets, connectWallet, signWithWallet } from '../services/cip30';
 
 export const Profile: React.FC = () => {
     const navigate = useNavigate();
-    const { user, balance, logout } = useStore();
+    const { user, balance, logout, setUser } = useStore();
     const [copied, setCopied] = useState(false);
+    const [password, setPassword] = useState('');
+    const [phrase, setPhrase] = useState<string | null>(null);
+    const [backupError, setBackupError] = useState('');
+    const [backupLoading, setBackupLoading] = useState(false);
+
+    const handleBackup = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setBackupError('');
+        setBackupLoading(true);
+        try {
+            const response = await exportRecoveryPhrase(password);
+            setPhrase(response.data.recovery_phrase);
+        } catch (err: any) {
+            setBackupError(err.message || 'Failed to export recovery phrase');
+        } finally {
+            setPassword('');
+            setBackupLoading(false);
+        }
+    };
+
+    const [walletPassword, setWalletPassword] = useState('');
+    const [walletError, setWalletError] = useState('');
+    const [walletLoading, setWalletLoading] = useState(false);
+    const wallets = availableWallets();
+
+    // Receive and spend with the customer's own CIP-30 wallet from now on
+    const handleUseWallet = async (walletId: string) => {
+        if (!user) return;
+        setWalletError('');
+        if (!walletPassword) {
+            setWalletError('Enter your password to switch wallets');
+            return;
+        }
+        setWalletLoading(true);
+        try {
+            const mainnet = import.meta.env.VITE_CARDANO_NETWORK === 'mainnet';
+            const address = await connectWallet(walletId, mainnet);
+            // The wallet signs a one-time challenge to prove it controls the address
+            const challenge = await createWalletChallenge(address);
+            const signed = await signWithWallet(walletId, challenge.data.payload_hex);
+            const response = await registerExternalWallet(address, walletPassword, signed.signature, signed.key);
+            const updated = { ...user, wallet_address: response.data.wallet_address };
+            setUser(updated, response.data.token);
+            saveUser(updated);
+        } catch (err: any) {
+            setWalletError(err.message || 'Failed to connect wallet');
+        } finally {
+            setWalletPassword('');
+            setWalletLoading(false);
+        }
+    };
+
+    const [codeSent, setCodeSent] = useState(false);
+    const [code, setCode] = useState('');
+    const [verifyError, setVerifyError] = useState('');
+    const [verifyLoading, setVerifyLoading] = useState(false);
+
+    const handleSendCode = async () => {
+        setVerifyError('');
+        setVerifyLoading(true);
+        try {
+            await sendVerificationCode('email');
+            setCodeSent(true);
+        } catch (err: any) {
+            setVerifyError(err.message || 'Failed to send code');
+        } finally {
+            setVerifyLoading(false);
+        }
+    };
+
+    // Verifying the email also pays out LCN merchants sent to it before signup
+    const handleVerify = async (e: React.FormEvent) => {
+        e.preventDefault();
+        if (!user) return;
+        setVerifyError('');
+        setVerifyLoading(true);
+        try {
+            await verifyContact('email', code);
+            const updated = { ...user, email_verified: true };
+            setUser(updated);
+            saveUser(updated);
+        } catch (err: any) {
+            setVerifyError(err.message || 'Failed to verify code');
+        } finally {
+            setCode('');
+            setVerifyLoading(false);
+        }
+    };
 
     const copyAddress = async () => {
         if (!user?.wallet_address) return;
@@ -43,6 +133,43 @@ export const Profile: React.FC = () => {
                 <p className="profile-email">{user?.email}</p>
             </div>
 
+            {/* Email Verification */}
+            {user && !user.email_verified && (
+                <div className="card mt-3">
+                    <h3 style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginBottom: '0.75rem' }}>
+                        VERIFY EMAIL
+                    </h3>
+                    <p style={{ color: 'var(--text-secondary)', fontSize: '0.75rem', marginBottom: '0.75rem' }}>
+                        Confirm your email to receive LCN merchants sent to it before you signed up.
+                    </p>
+                    {verifyError && <div className="alert alert-error">{verifyError}</div>}
+                    {codeSent ? (
+                        <form onSubmit={handleVerify}>
+                            <div className="form-group">
+                                <label className="form-label">Code sent to {user.email}</label>
+                                <input
+                                    type="text"
+                                    inputMode="numeric"
+                                    className="form-input"
+                                    value={code}
+                                    onChange={(e) => setCode(e.target.value)}
+                                    required
+                                />
+                            </div>
+                            <button type="submit" className="btn btn-outline btn-block" disabled={verifyLoading}>
+                                <Check size={18} />
+                                {verifyLoading ? 'Verifying...' : 'Verify'}
+                            </button>
+                        </form>
+                    ) : (
+                        <button className="btn btn-outline btn-block" onClick={handleSendCode} disabled={verifyLoading}>
+                            <Mail size={18} />
+                            {verifyLoading ? 'Sending...' : 'Send Code'}
+                        </button>
+                    )}
+                </div>
+            )}
+
             {/* Stats */}
             <div className="card mt-3">
                 <h3 style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginBottom: '1rem' }}>
@@ -98,6 +225,84 @@ export const Profile: React.FC = () => {
                 )}
             </div>
 
+            {/* Wallet Backup */}
+            <div className="card mt-3">
+                <h3 style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginBottom: '0.75rem' }}>
+                    BACK UP WALLET
+                </h3>
+                {phrase ? (
+                    <>
+                        <div className="address-display" style={{ fontSize: '0.875rem', lineHeight: '1.6' }}>
+                            {phrase}
+                        </div>
+                        <p style={{ color: 'var(--text-secondary)', fontSize: '0.75rem', marginTop: '0.5rem' }}>
+                            Write these words down and keep them offline. They restore your wallet in
+                            Eternl or Lace and will not be shown again.
+                        </p>
+                    </>
+                ) : (
+                    <form onSubmit={handleBackup}>
+                        <p style={{ color: 'var(--text-secondary)', fontSize: '0.75rem', marginBottom: '0.75rem' }}>
+                            Get your 24-word recovery phrase to use your rewards in any Cardano wallet.
+                            It can be shown only once.
+                        </p>
+                        {backupError && <div className="alert alert-error">{backupError}</div>}
+                        <div className="form-group">
+                            <label className="form-label">Confirm Password</label>
+                            <input
+                                type="password"
+                                className="form-input"
+                                value={password}
+                                onChange={(e) => setPassword(e.target.value)}
+                                required
+                            />
+                        </div>
+                        <button type="submit" className="btn btn-outline btn-block" disabled={backupLoading}>
+                            <Key size={18} />
+                            {backupLoading ? 'Verifying...' : 'Show Recovery Phrase'}
+                        </button>
+                    </form>
+                )}
+            </div>
+
+            {/* External Wallet */}
+            <div className="card mt-3">
+                <h3 style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginBottom: '0.75rem' }}>
+                    USE MY OWN WALLET
+                </h3>
+                <p style={{ color: 'var(--text-secondary)', fontSize: '0.75rem', marginBottom: '0.75rem' }}>
+                    Receive rewards in your own Cardano wallet and approve every spend there.
+                    LoyalCoin will no longer hold a key for you.
+                </p>
+                {walletError && <div className="alert alert-error">{walletError}</div>}
+                {wallets.length === 0 ? (
+                    <p style={{ fontSize: '0.75rem' }}>No browser wallet found. Install Eternl or Lace to continue.</p>
+                ) : (
+                    <>
+                        <div className="form-group">
+                            <label className="form-label">Confirm Password</label>
+                            <input
+                                type="password"
+                                className="form-input"
+                                value={walletPassword}
+                                onChange={(e) => setWalletPassword(e.target.value)}
+                            />
+                        </div>
+                        {wallets.map((wallet) => (
+                            <button
+                                key={wallet.id}
+                                className="btn btn-outline btn-block mt-2"
+                                onClick={() => handleUseWallet(wallet.id)}
+                                disabled={walletLoading}
+                            >
+                                <Wallet size={18} />
+                                {walletLoading ? 'Connecting...' : `Use ${wallet.name}`}
+                            </button>
+                        ))}
+                    </>
+                )}
+            </div>
+
             {/* About */}
             <div className="card mt-3">
                 <h3 style={{ fontSize: '0.875rem', color: 'var(--text-muted)', marginBottom: '0.75rem' }}>
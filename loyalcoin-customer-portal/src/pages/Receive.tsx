@@ -10,6 +10,8 @@ export const Receive: React.FC = () => {
     const [copied, setCopied] = useState(false);
 
     const walletAddress = user?.wallet_address || '';
+    // Merchants can issue to the short handle; older sessions fall back to the address
+    const handle = user?.handle || '';
 
     const copyAddress = async () => {
         try {
@@ -38,7 +40,7 @@ export const Receive: React.FC = () => {
                 {/* QR Code */}
                 <div className="qr-container">
                     <QRCodeSVG
-                        value={walletAddress}
+                        value={handle || walletAddress}
                         size={200}
                         level="H"
                         includeMargin={true}
@@ -47,6 +49,12 @@ export const Receive: React.FC = () => {
                     />
                 </div>
 
+                {handle && (
+                    <p style={{ marginTop: '1rem', fontFamily: 'monospace', fontSize: '1.125rem', letterSpacing: '0.05em' }}>
+                        {handle}
+                    </p>
+                )}
+
                 {/* Address */}
                 <div className="address-display" style={{ marginTop: '1rem' }}>
                     <span style={{
@@ -0,0 +1,279 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { ArrowLeft, AlertCircle, ShoppingBag, Ticket } from 'lucide-react';
+import { useStore } from '../store';
+import {
+    getCatalog, getOrders, placeOrder, cancelOrder, getVouchers,
+    CatalogItem, RedemptionOrder, Voucher, ApiError, newIdempotencyKey,
+} from '../services/api';
+
+// Codes are shown as two groups of four, as cashiers type them
+const formatCode = (code: string) => `${code.slice(0, 4)}-${code.slice(4)}`;
+
+const STATUS_LABELS: Record<RedemptionOrder['status'], string> = {
+    PLACED: 'Processing',
+    READY: 'Ready for pickup',
+    FULFILLED: 'Picked up',
+    CANCELLING: 'Cancelling',
+    CANCELLED: 'Cancelled',
+    FAILED: 'Failed',
+    EXPIRED: 'Expired',
+};
+
+const VOUCHER_STATUS_LABELS: Record<Voucher['status'], string> = {
+    MINTING: 'On its way',
+    ACTIVE: 'Ready to use',
+    REDEEMING: 'Redeeming',
+    REDEEMED: 'Used',
+    FAILED: 'Failed',
+    EXPIRED: 'Expired',
+};
+
+export const Rewards: React.FC = () => {
+    const navigate = useNavigate();
+    const { balance, fetchBalance, fetchTransactions } = useStore();
+
+    const [tab, setTab] = useState<'catalog' | 'orders' | 'vouchers'>('catalog');
+    const [items, setItems] = useState<CatalogItem[]>([]);
+    const [orders, setOrders] = useState<RedemptionOrder[]>([]);
+    const [vouchers, setVouchers] = useState<Voucher[]>([]);
+    const [busyId, setBusyId] = useState<string | null>(null);
+    const [error, setError] = useState<string | null>(null);
+    // Reused when an order is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
+
+    const load = () => {
+        getCatalog().then((res) => setItems(res.data.items)).catch(() => setItems([]));
+        getOrders().then((res) => setOrders(res.data.orders)).catch(() => setOrders([]));
+        getVouchers().then((res) => setVouchers(res.data.vouchers)).catch(() => setVouchers([]));
+    };
+
+    useEffect(load, []);
+
+    const refresh = () => {
+        load();
+        setTimeout(() => {
+            fetchBalance();
+            fetchTransactions();
+        }, 2000);
+    };
+
+    const handleOrder = async (item: CatalogItem) => {
+        setError(null);
+        if (balance && item.price_lcn > balance.lcn) {
+            setError(`Insufficient balance. You have ${balance.lcn.toLocaleString()} LCN`);
+            return;
+        }
+        if (!window.confirm(`Order ${item.name} for ${item.price_lcn.toLocaleString()} LCN?`)) {
+            return;
+        }
+
+        setBusyId(item.id);
+        try {
+            await placeOrder(item.id, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            setTab('orders');
+            refresh();
+        } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
+            setError(err.message || 'Order failed. Please try again.');
+        } finally {
+            setBusyId(null);
+        }
+    };
+
+    const handleCancel = async (order: RedemptionOrder) => {
+        setError(null);
+        if (!window.confirm(`Cancel your ${order.item_name} order? ${order.price_lcn.toLocaleString()} LCN will be refunded.`)) {
+            return;
+        }
+
+        setBusyId(order.id);
+        try {
+            await cancelOrder(order.id);
+            refresh();
+        } catch (err: any) {
+            setError(err.message || 'Cancellation failed. Please try again.');
+        } finally {
+            setBusyId(null);
+        }
+    };
+
+    return (
+        <div>
+            <div className="page-header">
+                <button className="back-btn" onClick={() => navigate(-1)}>
+                    <ArrowLeft size={20} />
+                </button>
+                <h1 className="page-title">Rewards</h1>
+            </div>
+
+            <div className="card mb-3" style={{ textAlign: 'center' }}>
+                <p style={{ fontSize: '0.875rem', color: 'var(--text-secondary)' }}>Available Balance</p>
+                <p style={{ fontSize: '1.5rem', fontWeight: '700', color: 'var(--primary)' }}>
+                    {balance ? balance.lcn.toLocaleString() : '0'} LCN
+                </p>
+            </div>
+
+            {/* Tabs */}
+            <div style={{
+                display: 'flex',
+                gap: '0.5rem',
+                marginBottom: '1.5rem',
+                background: 'var(--bg-card)',
+                padding: '0.25rem',
+                borderRadius: '0.75rem',
+            }}>
+                {([['catalog', 'Catalog'], ['orders', 'My Orders'], ['vouchers', 'Vouchers']] as const).map(([t, label]) => (
+                    <button
+                        key={t}
+                        onClick={() => setTab(t)}
+                        style={{
+                            flex: 1,
+                            padding: '0.75rem',
+                            border: 'none',
+                            borderRadius: '0.5rem',
+                            background: tab === t ? 'var(--primary)' : 'transparent',
+                            color: tab === t ? 'white' : 'var(--text-secondary)',
+                            fontWeight: '600',
+                            fontSize: '0.875rem',
+                            cursor: 'pointer',
+                            transition: 'all 0.2s',
+                        }}
+                    >
+                        {label}
+                    </button>
+                ))}
+            </div>
+
+            {error && (
+                <div className="alert alert-error" style={{ display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+                    <AlertCircle size={18} />
+                    {error}
+                </div>
+            )}
+
+            {tab === 'catalog' && (items.length === 0 ? (
+                <div className="empty-state">
+                    <ShoppingBag size={40} />
+                    <p>No rewards available right now.</p>
+                </div>
+            ) : (
+                <div className="catalog-list">
+                    {items.map((item) => (
+                        <div key={item.id} className="card catalog-item">
+                            {item.image_url && <img src={item.image_url} alt="" className="catalog-image" />}
+                            <div style={{ flex: 1 }}>
+                                <p style={{ fontWeight: 600 }}>{item.name}</p>
+                                <p style={{ fontSize: '0.75rem', color: 'var(--text-secondary)' }}>{item.business_name}</p>
+                                {item.description && (
+                                    <p style={{ fontSize: '0.875rem', marginTop: '0.25rem' }}>{item.description}</p>
+                                )}
+                                <p style={{ fontSize: '0.75rem', color: 'var(--text-muted)', marginTop: '0.25rem' }}>
+                                    {item.stock} left
+                                    {item.validity_days > 0 && ` · pick up within ${item.validity_days} days`}
+                                </p>
+                            </div>
+                            <button
+                                className="btn btn-primary"
+                                disabled={busyId !== null}
+                                onClick={() => handleOrder(item)}
+                            >
+                                {busyId === item.id ? <span className="spinner" /> : `${item.price_lcn.toLocaleString()} LCN`}
+                            </button>
+                        </div>
+                    ))}
+                </div>
+            ))}
+
+            {tab === 'orders' && (orders.length === 0 ? (
+                <div className="empty-state">
+                    <p>You have not ordered any rewards yet.</p>
+                </div>
+            ) : (
+                <div className="catalog-list">
+                    {orders.map((order) => (
+                        <div key={order.id} className="card">
+                            <div style={{ display: 'flex', justifyContent: 'space-between', gap: '1rem' }}>
+                                <p style={{ fontWeight: 600 }}>{order.item_name}</p>
+                                <span className={`order-status ${order.status.toLowerCase()}`}>
+                                    {STATUS_LABELS[order.status]}
+                                </span>
+                            </div>
+                            {order.status === 'READY' && (
+                                <>
+                                    <p className="order-code">{formatCode(order.code)}</p>
+                                    <p style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', textAlign: 'center' }}>
+                                        Show this code at the counter
+                                        {order.expires_at && ` before ${new Date(order.expires_at).toLocaleDateString()}`}
+                                    </p>
+                                </>
+                            )}
+                            {order.error && (
+                                <p style={{ fontSize: '0.75rem', color: 'var(--danger)', marginTop: '0.5rem' }}>{order.error}</p>
+                            )}
+                            <div style={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', marginTop: '0.75rem' }}>
+                                <span style={{ fontSize: '0.75rem', color: 'var(--text-muted)' }}>
+                                    {order.price_lcn.toLocaleString()} LCN · {new Date(order.created_at).toLocaleDateString()}
+                                </span>
+                                {(order.status === 'READY' || order.status === 'EXPIRED') && (
+                                    <button
+                                        className="btn btn-secondary"
+                                        disabled={busyId !== null}
+                                        onClick={() => handleCancel(order)}
+                                    >
+                                        {busyId === order.id ? <span className="spinner" /> : 'Cancel & refund'}
+                                    </button>
+                                )}
+                            </div>
+                        </div>
+                    ))}
+                </div>
+            ))}
+
+            {tab === 'vouchers' && (vouchers.length === 0 ? (
+                <div className="empty-state">
+                    <Ticket size={40} />
+                    <p>No vouchers yet. Merchants can send you vouchers as NFTs.</p>
+                </div>
+            ) : (
+                <div className="catalog-list">
+                    {vouchers.map((voucher) => (
+                        <div key={voucher.id} className="card">
+                            <div style={{ display: 'flex', justifyContent: 'space-between', gap: '1rem' }}>
+                                <div>
+                                    <p style={{ fontWeight: 600 }}>{voucher.name}</p>
+                                    <p style={{ fontSize: '0.75rem', color: 'var(--text-secondary)' }}>
+                                        {voucher.business_name} · {voucher.value}
+                                    </p>
+                                </div>
+                                <span className={`order-status ${voucher.status.toLowerCase()}`}>
+                                    {VOUCHER_STATUS_LABELS[voucher.status]}
+                                </span>
+                            </div>
+                            {voucher.description && (
+                                <p style={{ fontSize: '0.875rem', marginTop: '0.25rem' }}>{voucher.description}</p>
+                            )}
+                            {voucher.status === 'ACTIVE' && (
+                                <>
+                                    <p className="order-code">{formatCode(voucher.code)}</p>
+                                    <p style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', textAlign: 'center' }}>
+                                        Show this code at the counter
+                                        {voucher.expires_at && ` before ${new Date(voucher.expires_at).toLocaleDateString()}`}
+                                    </p>
+                                </>
+                            )}
+                            <p style={{ fontSize: '0.75rem', color: 'var(--text-muted)', marginTop: '0.75rem' }}>
+                                {voucher.redeemed_at
+                                    ? `Used ${new Date(voucher.redeemed_at).toLocaleDateString()}`
+                                    : `Received ${new Date(voucher.created_at).toLocaleDateString()}`}
+                            </p>
+                        </div>
+                    ))}
+                </div>
+            ))}
+        </div>
+    );
+};
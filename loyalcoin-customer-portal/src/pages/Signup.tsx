@@ -1,16 +1,19 @@
 import React, { useState } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
+import { Link, useNavigate, useSearchParams } from 'react-router-dom';
 import { Eye, EyeOff, CheckCircle } from 'lucide-react';
 import { signup, login } from '../services/api';
 import { useStore, saveUser } from '../store';
 
 export const Signup: React.FC = () => {
     const navigate = useNavigate();
+    const [searchParams] = useSearchParams();
     const { setUser } = useStore();
 
     const [username, setUsername] = useState('');
     const [email, setEmail] = useState('');
     const [password, setPassword] = useState('');
+    // Shared invite links carry the code as ?ref=
+    const [referralCode, setReferralCode] = useState(searchParams.get('ref') || '');
     const [showPassword, setShowPassword] = useState(false);
     const [loading, setLoading] = useState(false);
     const [error, setError] = useState<string | null>(null);
@@ -23,7 +26,7 @@ export const Signup: React.FC = () => {
 
         try {
             // Create account
-            await signup(email, password, username);
+            await signup(email, password, username, referralCode.trim() || undefined);
             setSuccess(true);
 
             // Auto-login after signup
@@ -141,6 +144,18 @@ export const Signup: React.FC = () => {
                     </p>
                 </div>
 
+                <div className="form-group">
+                    <label className="form-label">Referral Code (optional)</label>
+                    <input
+                        type="text"
+                        className="form-input"
+                        placeholder="Code from the friend who invited you"
+                        value={referralCode}
+                        onChange={(e) => setReferralCode(e.target.value.toUpperCase())}
+                        maxLength={10}
+                    />
+                </div>
+
                 <button
                     type="submit"
                     className="btn btn-primary btn-block mt-2"
@@ -1,8 +1,9 @@
 import React, { useState, useRef, useEffect } from 'react';
-import { useNavigate } from 'react-router-dom';
-import { ArrowLeft, CheckCircle, AlertCircle, Camera, X } from 'lucide-react';
+import { Link, useNavigate } from 'react-router-dom';
+import { ArrowLeft, CheckCircle, AlertCircle, Camera, X, ShoppingBag } from 'lucide-react';
 import { useStore } from '../store';
-import { redeemLCN } from '../services/api';
+import { redeemLCN, submitRedemption, getPaymentRequest, payRequest, PaymentRequestDetails, PAYMENT_REQUEST_QR_PREFIX, ApiError, newIdempotencyKey } from '../services/api';
+import { signTransaction } from '../services/cip30';
 import { Html5Qrcode } from 'html5-qrcode';
 
 export const Spend: React.FC = () => {
@@ -15,7 +16,10 @@ export const Spend: React.FC = () => {
     const [error, setError] = useState<string | null>(null);
     const [success, setSuccess] = useState<{ txHash: string; amount: number } | null>(null);
     const [showScanner, setShowScanner] = useState(false);
+    const [paymentRequest, setPaymentRequest] = useState<PaymentRequestDetails | null>(null);
     const scannerRef = useRef<Html5Qrcode | null>(null);
+    // Reused when the payment is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
 
     const lcnAmount = parseFloat(amount) || 0;
     const etbEquivalent = lcnAmount / 10; // 10 LCN = 1 ETB
@@ -50,8 +54,12 @@ export const Spend: React.FC = () => {
                     { facingMode: 'environment' },
                     { fps: 10, qrbox: { width: 250, height: 250 } },
                     (decodedText) => {
-                        // Check if it's a valid Cardano address
-                        if (decodedText.startsWith('addr_test1') || decodedText.startsWith('addr1')) {
+                        if (decodedText.startsWith(PAYMENT_REQUEST_QR_PREFIX)) {
+                            stopScanner();
+                            loadPaymentRequest(decodedText.slice(PAYMENT_REQUEST_QR_PREFIX.length));
+                        } else if (decodedText.startsWith('addr_test1') || decodedText.startsWith('addr1')) {
+                            // Plain merchant wallet address
+                            setPaymentRequest(null);
                             setMerchantAddress(decodedText);
                             stopScanner();
                         } else {
@@ -79,6 +87,27 @@ export const Spend: React.FC = () => {
         setShowScanner(false);
     };
 
+    // A merchant payment request fixes the merchant and amount
+    const loadPaymentRequest = async (id: string) => {
+        try {
+            const response = await getPaymentRequest(id);
+            const request = response.data;
+            if (request.status === 'CANCELLED' || request.status === 'EXPIRED') {
+                setError(`This payment request is ${request.status.toLowerCase()}. Ask the merchant for a new one.`);
+                return;
+            }
+            if ((request.status === 'SUBMITTED' || request.status === 'PAID') && !request.paid_by_you) {
+                setError('This payment request has already been paid.');
+                return;
+            }
+            setPaymentRequest(request);
+            setMerchantAddress(request.merchant_address);
+            setAmount(String(request.amount_lcn));
+        } catch (err: any) {
+            setError(err.message || 'Payment request not found');
+        }
+    };
+
     const handleSubmit = async (e: React.FormEvent) => {
         e.preventDefault();
         setError(null);
@@ -102,7 +131,15 @@ export const Spend: React.FC = () => {
         setLoading(true);
 
         try {
-            const response = await redeemLCN(merchantAddress, lcnAmount);
+            let response = paymentRequest
+                ? await payRequest(paymentRequest.id, paymentRequest.amount_lcn, idempotencyKey.current)
+                : await redeemLCN(merchantAddress, lcnAmount, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            if (response.data.tx_cbor && response.data.id) {
+                // External wallet: sign in the browser wallet, then submit the witness
+                const witnessSet = await signTransaction(response.data.tx_cbor);
+                response = await submitRedemption(response.data.id, witnessSet);
+            }
             setSuccess({ txHash: response.data.tx_hash, amount: lcnAmount });
 
             // Refresh balance
@@ -111,6 +148,9 @@ export const Spend: React.FC = () => {
                 fetchTransactions();
             }, 2000);
         } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
             setError(err.message || 'Transaction failed. Please try again.');
         } finally {
             setLoading(false);
@@ -182,6 +222,11 @@ export const Spend: React.FC = () => {
                 </p>
             </div>
 
+            <Link to="/rewards" className="card mb-3" style={{ display: 'flex', alignItems: 'center', gap: '0.75rem', textDecoration: 'none', color: 'inherit' }}>
+                <ShoppingBag size={20} color="var(--primary)" />
+                <span style={{ flex: 1, fontWeight: 600 }}>Order from the rewards catalog</span>
+            </Link>
+
             {/* QR Scanner Modal */}
             {showScanner && (
                 <div style={{
@@ -251,6 +296,24 @@ export const Spend: React.FC = () => {
                     </div>
                 )}
 
+                {paymentRequest && (
+                    <div className="card mb-3">
+                        <p style={{ fontSize: '0.875rem', color: 'var(--text-secondary)' }}>Payment request from</p>
+                        <p style={{ fontWeight: '600' }}>{paymentRequest.business_name}</p>
+                        {paymentRequest.description && (
+                            <p style={{ fontSize: '0.875rem', color: 'var(--text-secondary)' }}>{paymentRequest.description}</p>
+                        )}
+                        <button
+                            type="button"
+                            className="btn btn-secondary"
+                            style={{ marginTop: '0.75rem', padding: '0.5rem 1rem' }}
+                            onClick={() => { setPaymentRequest(null); setMerchantAddress(''); setAmount(''); }}
+                        >
+                            Clear
+                        </button>
+                    </div>
+                )}
+
                 <div className="form-group">
                     <label className="form-label">Merchant Wallet Address</label>
                     <div style={{ display: 'flex', gap: '0.5rem' }}>
@@ -261,6 +324,7 @@ export const Spend: React.FC = () => {
                             value={merchantAddress}
                             onChange={(e) => setMerchantAddress(e.target.value)}
                             style={{ flex: 1 }}
+                            readOnly={!!paymentRequest}
                             required
                         />
                         <button
@@ -293,6 +357,7 @@ export const Spend: React.FC = () => {
                         onChange={(e) => setAmount(e.target.value)}
                         min="1"
                         step="1"
+                        readOnly={!!paymentRequest}
                         required
                     />
                 </div>
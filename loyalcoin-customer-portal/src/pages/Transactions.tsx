@@ -100,8 +100,20 @@ export const Transactions: React.FC = () => {
                                 </div>
                                 <div className="tx-details">
                                     <p className="tx-title">
-                                        {tx.direction === 'received' ? 'Received LCN' : 'Spent LCN'}
+                                        {tx.type === 'TRANSFER'
+                                            ? (tx.direction === 'received' ? `Gift from ${tx.sender_username}` : `Gift to ${tx.recipient_username}`)
+                                            : tx.item_name
+                                                ? (tx.type === 'REFUND' ? `Refund: ${tx.item_name}` : `Ordered ${tx.item_name}`)
+                                                : tx.type === 'REFUND'
+                                                    ? (tx.direction === 'received' ? 'Refund received' : 'Returned to merchant')
+                                                    : (tx.direction === 'received' ? 'Received LCN' : 'Spent LCN')}
                                     </p>
+                                    {tx.type === 'REFUND' && tx.reason && (
+                                        <p className="tx-date" style={{ fontStyle: 'italic' }}>{tx.reason}</p>
+                                    )}
+                                    {tx.message && (
+                                        <p className="tx-date" style={{ fontStyle: 'italic' }}>“{tx.message}”</p>
+                                    )}
                                     <p className="tx-date">{formatDate(tx.submitted_at)}</p>
                                 </div>
                                 <span className={`tx-amount ${tx.direction === 'received' ? 'positive' : 'negative'}`}>
@@ -122,6 +134,18 @@ export const Transactions: React.FC = () => {
                                         {formatAddress(tx.direction === 'received' ? tx.from_address : tx.to_address)}
                                     </span>
                                 </div>
+                                {tx.refund_of && (
+                                    <div style={{ display: 'flex', justifyContent: 'space-between', marginBottom: '0.25rem' }}>
+                                        <span>Refund of:</span>
+                                        <span style={{ fontFamily: 'monospace' }}>{formatAddress(tx.refund_of)}</span>
+                                    </div>
+                                )}
+                                {!!tx.refunded_lcn && (
+                                    <div style={{ display: 'flex', justifyContent: 'space-between', marginBottom: '0.25rem' }}>
+                                        <span>Refunded:</span>
+                                        <span>{tx.refunded_lcn.toLocaleString()} LCN</span>
+                                    </div>
+                                )}
                                 <div style={{ display: 'flex', justifyContent: 'space-between' }}>
                                     <span>Status:</span>
                                     <span style={{
@@ -39,6 +39,8 @@ export interface User {
     username?: string;
     role: string;
     wallet_address: string;
+    handle?: string;
+    email_verified?: boolean;
 }
 
 export interface AuthResponse {
@@ -55,7 +57,9 @@ export interface SignupResponse {
     data: {
         user_id: string;
         wallet_address: string;
+        handle?: string;
         role: string;
+        referral_code?: string;
     };
 }
 
@@ -82,6 +86,19 @@ export interface Transaction {
     status: string;
     submitted_at: string;
     confirmed_at?: string;
+    // Set on TRANSFER transactions between customers
+    sender_username?: string;
+    recipient_username?: string;
+    message?: string;
+    // Set on catalog order payments and refunds
+    order_id?: string;
+    item_name?: string;
+    // Set on refunds, which link to the transaction they refund, and on
+    // refunded transactions
+    refund_of?: string;
+    reason?: string;
+    refunded_lcn?: number;
+    refund_tx_hashes?: string[];
 }
 
 export interface TransactionsResponse {
@@ -99,11 +116,206 @@ export interface RedeemResponse {
     data: {
         tx_hash: string;
         amount_lcn: number;
+        // Set for external wallets: the transaction still needs the wallet's signature
+        id?: string;
+        tx_cbor?: string;
+        expires_at?: string;
+    };
+}
+
+export interface TransferLimits {
+    enabled: boolean;
+    daily_limit_lcn: number;
+    daily_max_transfers: number;
+    sent_today_lcn: number;
+    transfers_today: number;
+    remaining_lcn?: number;
+}
+
+export interface TransferResponse {
+    status: string;
+    data: {
+        tx_hash: string;
+        amount_lcn: number;
+        recipient: string;
+        message: string;
+        limits: TransferLimits;
+    };
+}
+
+export interface CatalogItem {
+    id: string;
+    merchant_id: string;
+    business_name: string;
+    name: string;
+    description?: string;
+    image_url?: string;
+    price_lcn: number;
+    stock: number;
+    validity_days: number;
+}
+
+export interface RedemptionOrder {
+    id: string;
+    item_id: string;
+    item_name: string;
+    merchant_id: string;
+    price_lcn: number;
+    code: string;
+    status: 'PLACED' | 'READY' | 'FULFILLED' | 'CANCELLING' | 'CANCELLED' | 'FAILED' | 'EXPIRED';
+    tx_hash?: string;
+    refund_tx_hash?: string;
+    error?: string;
+    expires_at?: string;
+    fulfilled_at?: string;
+    cancelled_at?: string;
+    created_at: string;
+}
+
+// Single-use voucher held as an NFT; the code is shown at the counter to redeem it
+export interface Voucher {
+    id: string;
+    merchant_id: string;
+    business_name: string;
+    policy_id: string;
+    asset_name: string;
+    code: string;
+    name: string;
+    description?: string;
+    image_url?: string;
+    value: string;
+    expires_at?: string;
+    status: 'MINTING' | 'ACTIVE' | 'REDEEMING' | 'REDEEMED' | 'FAILED' | 'EXPIRED';
+    mint_tx_hash?: string;
+    burn_tx_hash?: string;
+    redeemed_at?: string;
+    created_at: string;
+}
+
+export interface Refund {
+    id: string;
+    merchant_id: string;
+    original_tx_hash: string;
+    original_type: string;
+    // TO_MERCHANT reverses LCN issued to the customer and needs their consent
+    direction: 'TO_MERCHANT' | 'TO_CUSTOMER';
+    amount_lcn: number;
+    reason: string;
+    status: 'PENDING_CONSENT' | 'PROCESSING' | 'COMPLETED' | 'DECLINED' | 'CANCELLED' | 'FAILED';
+    tx_hash?: string;
+    error?: string;
+    created_at: string;
+    completed_at?: string;
+}
+
+export interface PaymentRequestDetails {
+    id: string;
+    business_name: string;
+    merchant_address: string;
+    amount_lcn: number;
+    description?: string;
+    status: 'OPEN' | 'PAYING' | 'SUBMITTED' | 'PAID' | 'CANCELLED' | 'EXPIRED';
+    paid_by_you: boolean;
+    expires_at: string;
+}
+
+export interface ExternalWalletResponse {
+    status: string;
+    data: {
+        token: string;
+        wallet_address: string;
+        custody: string;
+        payment_key_hash: string;
+    };
+}
+
+export interface WalletChallengeResponse {
+    status: string;
+    data: {
+        address: string;
+        message: string;
+        payload_hex: string;
+        expires_at: string;
+    };
+}
+
+export interface RecoveryPhraseResponse {
+    status: string;
+    data: {
+        recovery_phrase: string;
+        derivation_path: string;
+        wallet_address: string;
+        message: string;
+    };
+}
+
+export interface ExpiringLot {
+    id: string;
+    merchant_id: string;
+    amount_lcn: number;
+    remaining_lcn: number;
+    issued_at: string;
+    expires_at: string;
+}
+
+export interface ExpiringResponse {
+    status: string;
+    data: {
+        lots: ExpiringLot[];
+        expiring_lcn: number;
+        days: number;
+    };
+}
+
+export interface Tier {
+    name: string;
+    min_lcn: number;
+    earn_multiplier: number;
+}
+
+export interface TierResponse {
+    status: string;
+    data: {
+        tier: Tier;
+        next_tier: Tier | null;
+        lcn_to_next_tier: number;
+        progress_percent: number;
+        qualifying_lcn: number;
+        earned_lcn: number;
+        redeemed_lcn: number;
+        window_months: number;
+        tier_since: string;
+        evaluated_at: string;
+        tiers: Tier[];
+    };
+}
+
+export interface Referral {
+    id: string;
+    referee_name: string;
+    referee_role: string;
+    status: 'PENDING' | 'REVIEW' | 'PAYING' | 'PAID' | 'REJECTED' | 'FAILED';
+    referrer_reward_lcn: number;
+    created_at: string;
+    qualified_at?: string;
+    paid_at?: string;
+}
+
+export interface ReferralsResponse {
+    status: string;
+    data: {
+        referral_code: string;
+        referrer_reward_lcn: number;
+        referee_reward_lcn: number;
+        referrals: Referral[];
+        total: number;
+        limit: number;
+        offset: number;
     };
 }
 
 // Auth APIs
-export async function signup(email: string, password: string, username: string): Promise<SignupResponse> {
+export async function signup(email: string, password: string, username: string, referralCode?: string): Promise<SignupResponse> {
     return apiRequest<SignupResponse>('/api/v1/auth/signup', {
         method: 'POST',
         body: JSON.stringify({
@@ -111,6 +323,7 @@ export async function signup(email: string, password: string, username: string):
             password,
             role: 'CUSTOMER',
             username,
+            ...(referralCode && { referral_code: referralCode }),
         }),
     });
 }
@@ -131,13 +344,159 @@ export async function getTransactions(limit = 20, offset = 0): Promise<Transacti
     return apiRequest<TransactionsResponse>(`/api/v1/wallet/transactions?limit=${limit}&offset=${offset}`);
 }
 
+// Value-moving requests send an Idempotency-Key so that a retry after a network
+// error replays the first response instead of spending LCN twice
+export const newIdempotencyKey = (): string => crypto.randomUUID();
+
+const idempotencyHeaders = (key?: string): Record<string, string> =>
+    key ? { 'Idempotency-Key': key } : {};
+
 // LCN APIs
-export async function redeemLCN(merchantAddress: string, amountLCN: number): Promise<RedeemResponse> {
+export async function redeemLCN(merchantAddress: string, amountLCN: number, idempotencyKey?: string): Promise<RedeemResponse> {
     return apiRequest<RedeemResponse>('/api/v1/lcn/redeem', {
         method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
         body: JSON.stringify({
             merchant_address: merchantAddress,
             amount_lcn: amountLCN,
         }),
     });
 }
+
+// Gift LCN to another customer by username, phone number or QR handle
+export async function transferLCN(recipient: string, amountLCN: number, message: string, idempotencyKey?: string): Promise<TransferResponse> {
+    return apiRequest<TransferResponse>('/api/v1/lcn/transfer', {
+        method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
+        body: JSON.stringify({
+            recipient,
+            amount_lcn: amountLCN,
+            ...(message && { message }),
+        }),
+    });
+}
+
+export async function getTransferLimits(): Promise<{ status: string; data: TransferLimits }> {
+    return apiRequest('/api/v1/lcn/transfer/limits');
+}
+
+export async function submitRedemption(id: string, witnessSet: string): Promise<RedeemResponse> {
+    return apiRequest<RedeemResponse>(`/api/v1/lcn/redeem/${id}/submit`, {
+        method: 'POST',
+        body: JSON.stringify({ witness_set: witnessSet }),
+    });
+}
+
+// Payment requests: merchant QR codes carry "loyalcoin:pay:<id>"
+export const PAYMENT_REQUEST_QR_PREFIX = 'loyalcoin:pay:';
+
+export async function getPaymentRequest(id: string): Promise<{ status: string; data: PaymentRequestDetails }> {
+    return apiRequest(`/api/v1/lcn/pay/${id}`);
+}
+
+// Paying twice returns the first payment, so a repeated scan never double-charges
+export async function payRequest(id: string, amountLCN: number, idempotencyKey?: string): Promise<RedeemResponse> {
+    return apiRequest<RedeemResponse>(`/api/v1/lcn/pay/${id}`, {
+        method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
+        body: JSON.stringify({ amount_lcn: amountLCN }),
+    });
+}
+
+// Customer APIs
+export async function exportRecoveryPhrase(password: string): Promise<RecoveryPhraseResponse> {
+    return apiRequest<RecoveryPhraseResponse>('/api/v1/customer/wallet/recovery-phrase', {
+        method: 'POST',
+        body: JSON.stringify({ password }),
+    });
+}
+
+// Send a one-time code to the account's email or phone
+export async function sendVerificationCode(channel: 'email' | 'phone'): Promise<{ status: string; data: { channel: string; expires_at: string } }> {
+    return apiRequest('/api/v1/customer/verify/send', {
+        method: 'POST',
+        body: JSON.stringify({ channel }),
+    });
+}
+
+// Check the code; rewards merchants issued to it before signup are then paid out
+export async function verifyContact(channel: 'email' | 'phone', code: string): Promise<{ status: string; data: { channel: string; verified: boolean } }> {
+    return apiRequest('/api/v1/customer/verify', {
+        method: 'POST',
+        body: JSON.stringify({ channel, code }),
+    });
+}
+
+// Message the wallet must sign (signData) before its address can be registered
+export async function createWalletChallenge(address: string): Promise<WalletChallengeResponse> {
+    return apiRequest<WalletChallengeResponse>('/api/v1/customer/wallet/external/challenge', {
+        method: 'POST',
+        body: JSON.stringify({ address }),
+    });
+}
+
+export async function registerExternalWallet(
+    address: string,
+    password: string,
+    signature: string,
+    key: string,
+): Promise<ExternalWalletResponse> {
+    return apiRequest<ExternalWalletResponse>('/api/v1/customer/wallet/external', {
+        method: 'PUT',
+        body: JSON.stringify({ address, password, signature, key }),
+    });
+}
+
+// LCN expiring soon (the warning period by default), soonest first
+export async function getExpiringLCN(days?: number): Promise<ExpiringResponse> {
+    return apiRequest<ExpiringResponse>(`/api/v1/customer/expiring${days !== undefined ? `?days=${days}` : ''}`);
+}
+
+// Loyalty tier and progress to the next one
+export async function getTier(): Promise<TierResponse> {
+    return apiRequest<TierResponse>('/api/v1/customer/tier');
+}
+
+// Referral code to share and the friends who signed up with it
+export async function getReferrals(): Promise<ReferralsResponse> {
+    return apiRequest<ReferralsResponse>('/api/v1/customer/referrals');
+}
+
+// Rewards catalog: items merchants publish, ordered with LCN and picked up with a code
+export async function getCatalog(merchantId?: string): Promise<{ status: string; data: { items: CatalogItem[]; total: number } }> {
+    return apiRequest(`/api/v1/customer/catalog?limit=100${merchantId ? `&merchant_id=${encodeURIComponent(merchantId)}` : ''}`);
+}
+
+export async function placeOrder(itemId: string, idempotencyKey?: string): Promise<{ status: string; data: RedemptionOrder }> {
+    return apiRequest('/api/v1/customer/orders', {
+        method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
+        body: JSON.stringify({ item_id: itemId }),
+    });
+}
+
+export async function getOrders(): Promise<{ status: string; data: { orders: RedemptionOrder[]; total: number } }> {
+    return apiRequest('/api/v1/customer/orders?limit=50');
+}
+
+// Refunds the order's LCN
+export async function cancelOrder(id: string): Promise<{ status: string; data: RedemptionOrder }> {
+    return apiRequest(`/api/v1/customer/orders/${id}/cancel`, { method: 'POST' });
+}
+
+export async function getVouchers(): Promise<{ status: string; data: { vouchers: Voucher[]; total: number } }> {
+    return apiRequest('/api/v1/customer/vouchers?limit=50');
+}
+
+// Refunds of the customer's transactions; reversals of issued LCN wait for their consent
+export async function getRefunds(status?: Refund['status']): Promise<{ status: string; data: { refunds: Refund[]; total: number } }> {
+    return apiRequest(`/api/v1/customer/refunds?limit=50${status ? `&status=${status}` : ''}`);
+}
+
+// Approving sends the LCN back to the merchant
+export async function consentRefund(id: string, approve: boolean): Promise<{ status: string; data: Refund }> {
+    return apiRequest(`/api/v1/customer/refunds/${id}/consent`, {
+        method: 'POST',
+        body: JSON.stringify({ approve }),
+    });
+}
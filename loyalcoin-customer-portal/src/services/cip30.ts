@@ -0,0 +1,123 @@
+// CIP-30 browser wallet (Eternl, Lace, Nami, ...) helpers for customers who
+// registered their own wallet. The wallet signs; LoyalCoin never sees the key.
+
+interface Cip30Api {
+    getChangeAddress(): Promise<string>;
+    signTx(tx: string, partialSign?: boolean): Promise<string>;
+    signData(addr: string, payload: string): Promise<DataSignature>;
+}
+
+// COSE_Sign1 and COSE_Key (hex) from signData
+export interface DataSignature {
+    signature: string;
+    key: string;
+}
+
+interface Cip30Wallet {
+    name: string;
+    icon: string;
+    enable(): Promise<Cip30Api>;
+}
+
+declare global {
+    interface Window {
+        cardano?: Record<string, Cip30Wallet>;
+    }
+}
+
+const WALLET_KEY = 'customer_cip30_wallet';
+
+export interface WalletOption {
+    id: string;
+    name: string;
+    icon: string;
+}
+
+export function availableWallets(): WalletOption[] {
+    const cardano = window.cardano || {};
+    return Object.keys(cardano)
+        .filter((id) => typeof cardano[id]?.enable === 'function')
+        .map((id) => ({ id, name: cardano[id].name || id, icon: cardano[id].icon }));
+}
+
+// Connects to a wallet and returns its address (bech32)
+export async function connectWallet(id: string, mainnet: boolean): Promise<string> {
+    const api = await enable(id);
+    const addressHex = await api.getChangeAddress();
+    localStorage.setItem(WALLET_KEY, id);
+    return bech32Address(hexToBytes(addressHex), mainnet);
+}
+
+// Asks the registered wallet to sign an unsigned transaction; returns the witness set (hex)
+export async function signTransaction(txCbor: string): Promise<string> {
+    const id = localStorage.getItem(WALLET_KEY);
+    if (!id) {
+        throw new Error('Connect your wallet on the Profile page first');
+    }
+    const api = await enable(id);
+    return api.signTx(txCbor, true);
+}
+
+// Signs a message (hex) with the payment key of the wallet's address, proving
+// to LoyalCoin that the customer controls it
+export async function signWithWallet(id: string, payloadHex: string): Promise<DataSignature> {
+    const api = await enable(id);
+    const addressHex = await api.getChangeAddress();
+    return api.signData(addressHex, payloadHex);
+}
+
+async function enable(id: string): Promise<Cip30Api> {
+    const wallet = window.cardano?.[id];
+    if (!wallet) {
+        throw new Error(`Wallet "${id}" is not installed`);
+    }
+    return wallet.enable();
+}
+
+function hexToBytes(hex: string): number[] {
+    const bytes: number[] = [];
+    for (let i = 0; i < hex.length; i += 2) {
+        bytes.push(parseInt(hex.slice(i, i + 2), 16));
+    }
+    return bytes;
+}
+
+// Bech32 (BIP-173) encoding of raw address bytes
+const CHARSET = 'qpzry9x8gf2tvdw0s3jn54khce6mua7l';
+
+function polymod(values: number[]): number {
+    const generator = [0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3];
+    let chk = 1;
+    for (const value of values) {
+        const top = chk >> 25;
+        chk = ((chk & 0x1ffffff) << 5) ^ value;
+        for (let i = 0; i < 5; i++) {
+            if ((top >> i) & 1) chk ^= generator[i];
+        }
+    }
+    return chk;
+}
+
+function bech32Address(bytes: number[], mainnet: boolean): string {
+    const prefix = mainnet ? 'addr' : 'addr_test';
+
+    const data: number[] = [];
+    let acc = 0;
+    let bits = 0;
+    for (const b of bytes) {
+        acc = (acc << 8) | b;
+        bits += 8;
+        while (bits >= 5) {
+            bits -= 5;
+            data.push((acc >> bits) & 31);
+        }
+    }
+    if (bits > 0) data.push((acc << (5 - bits)) & 31);
+
+    const expanded = [...prefix].map((c) => c.charCodeAt(0) >> 5)
+        .concat([0], [...prefix].map((c) => c.charCodeAt(0) & 31));
+    const mod = polymod(expanded.concat(data, [0, 0, 0, 0, 0, 0])) ^ 1;
+    const checksum = [0, 1, 2, 3, 4, 5].map((i) => (mod >> (5 * (5 - i))) & 31);
+
+    return prefix + '1' + data.concat(checksum).map((d) => CHARSET[d]).join('');
+}
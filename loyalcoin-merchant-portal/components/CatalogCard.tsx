@@ -0,0 +1,146 @@
+import React, { useEffect, useState } from 'react';
+import { Card, Button, Input, Badge } from './UIComponents';
+import { ShoppingBag, AlertCircle, Plus } from 'lucide-react';
+import { getCatalog, saveCatalogItem, CatalogItem, ApiError } from '../services/api';
+
+const emptyItem: CatalogItem = { name: '', description: '', price_lcn: 0, stock: 0, validity_days: 30, active: true };
+
+export const CatalogCard: React.FC = () => {
+    const [items, setItems] = useState<CatalogItem[]>([]);
+    const [editing, setEditing] = useState<CatalogItem | null>(null);
+    const [saving, setSaving] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [forbidden, setForbidden] = useState(false);
+
+    const load = () =>
+        getCatalog()
+            .then((response) => setItems(response.data.items))
+            .catch((err) => {
+                // Cashiers cannot manage the catalog
+                if (err instanceof ApiError && err.status === 403) {
+                    setForbidden(true);
+                } else {
+                    setError(err.message || 'Failed to load catalog');
+                }
+            });
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    const handleSave = async (e: React.FormEvent) => {
+        e.preventDefault();
+        if (!editing) return;
+        setSaving(true);
+        setError(null);
+        try {
+            await saveCatalogItem(editing);
+            setEditing(null);
+            await load();
+        } catch (err: any) {
+            setError(err.message || 'Failed to save item');
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const update = (fields: Partial<CatalogItem>) => setEditing((item) => (item ? { ...item, ...fields } : item));
+
+    if (forbidden) {
+        return null;
+    }
+
+    return (
+        <Card className="p-6">
+            <div className="flex items-center justify-between mb-4">
+                <div className="flex items-center gap-3">
+                    <ShoppingBag className="h-5 w-5 text-amber-600" />
+                    <h2 className="text-lg font-bold text-gray-900">Rewards Catalog</h2>
+                </div>
+                {!editing && (
+                    <Button size="sm" variant="secondary" onClick={() => setEditing({ ...emptyItem })}>
+                        <Plus className="h-4 w-4 mr-1" />
+                        Add Item
+                    </Button>
+                )}
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+
+            {editing ? (
+                <form onSubmit={handleSave} className="space-y-4">
+                    <Input label="Name" value={editing.name} onChange={(e) => update({ name: e.target.value })} required />
+                    <Input
+                        label="Description"
+                        value={editing.description || ''}
+                        onChange={(e) => update({ description: e.target.value })}
+                    />
+                    <div className="grid grid-cols-3 gap-3">
+                        <Input
+                            label="Price (LCN)"
+                            type="number"
+                            min="1"
+                            value={editing.price_lcn || ''}
+                            onChange={(e) => update({ price_lcn: parseInt(e.target.value, 10) || 0 })}
+                            required
+                        />
+                        <Input
+                            label="Stock"
+                            type="number"
+                            min="0"
+                            value={editing.stock}
+                            onChange={(e) => update({ stock: parseInt(e.target.value, 10) || 0 })}
+                        />
+                        <Input
+                            label="Pickup days"
+                            type="number"
+                            min="0"
+                            max="365"
+                            value={editing.validity_days}
+                            onChange={(e) => update({ validity_days: parseInt(e.target.value, 10) || 0 })}
+                        />
+                    </div>
+                    <label className="flex items-center gap-2 text-sm text-gray-700">
+                        <input type="checkbox" checked={editing.active} onChange={(e) => update({ active: e.target.checked })} />
+                        Available to customers
+                    </label>
+                    <p className="text-xs text-gray-500">
+                        Customers pay when they order and get a code to show at pickup (0 pickup days = no limit).
+                    </p>
+                    <div className="flex gap-2">
+                        <Button type="submit" size="sm" isLoading={saving}>Save Item</Button>
+                        <Button type="button" size="sm" variant="secondary" onClick={() => setEditing(null)} disabled={saving}>
+                            Cancel
+                        </Button>
+                    </div>
+                </form>
+            ) : items.length === 0 ? (
+                <p className="text-sm text-gray-500">No items yet. Add rewards customers can order with their LCN.</p>
+            ) : (
+                <div className="space-y-3">
+                    {items.map((item) => (
+                        <button
+                            key={item.id}
+                            type="button"
+                            onClick={() => setEditing({ ...item })}
+                            className="w-full flex items-center justify-between p-3 rounded-lg border border-gray-100 hover:bg-gray-50 text-left"
+                        >
+                            <div>
+                                <p className="font-medium text-gray-900">{item.name}</p>
+                                <p className="text-sm text-gray-500">
+                                    {item.price_lcn.toLocaleString()} LCN • {item.stock} in stock
+                                </p>
+                            </div>
+                            {!item.active && <Badge variant="warning">Hidden</Badge>}
+                        </button>
+                    ))}
+                </div>
+            )}
+        </Card>
+    );
+};
@@ -0,0 +1,122 @@
+import React, { useEffect, useState } from 'react';
+import { Card, Badge } from './UIComponents';
+import { Users, AlertCircle } from 'lucide-react';
+import { getCoalition, getCoalitionNettings, CoalitionSummary, Netting, ApiError } from '../services/api';
+
+const formatNet = (net: number) => `${net > 0 ? '+' : ''}${net.toLocaleString()} LCN`;
+
+const netClass = (net: number) => (net > 0 ? 'text-green-600' : net < 0 ? 'text-red-600' : 'text-gray-500');
+
+export const CoalitionCard: React.FC = () => {
+    const [summary, setSummary] = useState<CoalitionSummary | null>(null);
+    const [nettings, setNettings] = useState<Netting[]>([]);
+    const [error, setError] = useState<string | null>(null);
+    const [hidden, setHidden] = useState(false);
+
+    useEffect(() => {
+        getCoalition()
+            .then((response) => {
+                setSummary(response.data);
+                return getCoalitionNettings().then((nettingResponse) => setNettings(nettingResponse.data.nettings || []));
+            })
+            .catch((err) => {
+                // Merchants outside a coalition, and cashiers, have nothing to see
+                if (err instanceof ApiError && (err.status === 403 || err.status === 404)) {
+                    setHidden(true);
+                } else {
+                    setError(err.message || 'Failed to load coalition');
+                }
+            });
+    }, []);
+
+    if (hidden) {
+        return null;
+    }
+
+    const names = new Map((summary?.counterparties || []).map((c) => [c.merchant_id, c.business_name || 'A member']));
+    const nameOf = (merchantID: string) => names.get(merchantID) || 'A member';
+
+    return (
+        <Card className="p-6">
+            <div className="flex items-center justify-between mb-4">
+                <div className="flex items-center gap-3">
+                    <Users className="h-5 w-5 text-amber-600" />
+                    <h2 className="text-lg font-bold text-gray-900">{summary ? summary.coalition.name : 'Coalition'}</h2>
+                </div>
+                {summary && (
+                    <span className="text-sm text-gray-500">
+                        {summary.coalition.members} members • {(summary.coalition.rules.rate_bps / 100).toLocaleString()}% clearing rate
+                    </span>
+                )}
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+
+            {summary && (
+                <div className="space-y-4">
+                    <div className="p-4 rounded-lg bg-amber-50 border border-amber-200">
+                        <p className="text-sm text-gray-600">Net position since the last netting</p>
+                        <p className={`text-2xl font-bold ${netClass(summary.position.net_lcn)}`}>{formatNet(summary.position.net_lcn)}</p>
+                        <p className="text-xs text-gray-500">
+                            Owed to you {summary.position.receivable_lcn.toLocaleString()} LCN • You owe{' '}
+                            {summary.position.payable_lcn.toLocaleString()} LCN • Next netting{' '}
+                            {new Date(summary.coalition.next_netting_at).toLocaleDateString()}
+                        </p>
+                    </div>
+
+                    {summary.counterparties.length > 0 && (
+                        <div className="space-y-2">
+                            {summary.counterparties.map((counterparty) => (
+                                <div key={counterparty.merchant_id} className="flex items-center justify-between text-sm">
+                                    <span className="text-gray-700">{nameOf(counterparty.merchant_id)}</span>
+                                    <span className={netClass(counterparty.net_lcn)}>{formatNet(counterparty.net_lcn)}</span>
+                                </div>
+                            ))}
+                        </div>
+                    )}
+
+                    <p className="text-xs text-gray-500">
+                        When your customers spend LCN another member issued, that member owes you; LCN you issued spent
+                        elsewhere is owed by you. Balances are netted every {summary.coalition.rules.netting_period_days} days
+                        and settled through clearing settlements.
+                    </p>
+
+                    {nettings.length > 0 && (
+                        <div>
+                            <h3 className="text-sm font-medium text-gray-900 mb-2">Recent Nettings</h3>
+                            <div className="space-y-2">
+                                {nettings.map((netting) => (
+                                    <div key={netting.id} className="p-3 border border-gray-100 rounded-lg text-sm">
+                                        <div className="flex items-center justify-between">
+                                            <span className="text-gray-700">{new Date(netting.created_at).toLocaleDateString()}</span>
+                                            <span className={netClass(netting.positions[0]?.net_lcn || 0)}>
+                                                {formatNet(netting.positions[0]?.net_lcn || 0)}
+                                            </span>
+                                        </div>
+                                        {netting.payments.map((payment, i) => (
+                                            <div key={i} className="flex items-center justify-between text-xs text-gray-500 mt-1">
+                                                <span>
+                                                    {payment.payee_id === summary.position.merchant_id
+                                                        ? `From ${nameOf(payment.payer_id)}`
+                                                        : `To ${nameOf(payment.payee_id)}`}
+                                                </span>
+                                                <Badge variant="info">
+                                                    {payment.amount_lcn.toLocaleString()} LCN
+                                                </Badge>
+                                            </div>
+                                        ))}
+                                    </div>
+                                ))}
+                            </div>
+                        </div>
+                    )}
+                </div>
+            )}
+        </Card>
+    );
+};
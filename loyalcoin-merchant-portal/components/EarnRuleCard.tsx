@@ -0,0 +1,132 @@
+import React, { useEffect, useState } from 'react';
+import { Card, Button, Input } from './UIComponents';
+import { Percent, AlertCircle, CheckCircle } from 'lucide-react';
+import { getEarnRule, saveEarnRule, ApiError } from '../services/api';
+
+// Category multipliers are edited as "coffee=2, bakery=1.5"
+const formatMultipliers = (multipliers?: Record<string, number>): string =>
+    Object.entries(multipliers || {}).map(([category, multiplier]) => `${category}=${multiplier}`).join(', ');
+
+const parseMultipliers = (text: string): Record<string, number> => {
+    const multipliers: Record<string, number> = {};
+    text.split(',').map((part) => part.trim()).filter(Boolean).forEach((part) => {
+        const [category, multiplier] = part.split('=').map((s) => s.trim());
+        const value = parseFloat(multiplier);
+        if (!category || isNaN(value)) {
+            throw new Error(`Invalid category multiplier "${part}"`);
+        }
+        multipliers[category] = value;
+    });
+    return multipliers;
+};
+
+export const EarnRuleCard: React.FC = () => {
+    const [version, setVersion] = useState<number | null>(null);
+    const [enabled, setEnabled] = useState(true);
+    const [percent, setPercent] = useState('');
+    const [fixed, setFixed] = useState('');
+    const [multipliers, setMultipliers] = useState('');
+    const [minSpend, setMinSpend] = useState('');
+    const [dailyCap, setDailyCap] = useState('');
+    const [saving, setSaving] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [saved, setSaved] = useState(false);
+    const [forbidden, setForbidden] = useState(false);
+
+    useEffect(() => {
+        getEarnRule()
+            .then((response) => {
+                const rule = response.data;
+                setVersion(rule.version ?? null);
+                setEnabled(rule.enabled);
+                setPercent(String(rule.percent_of_spend));
+                setFixed(String(rule.fixed_per_visit));
+                setMultipliers(formatMultipliers(rule.category_multipliers));
+                setMinSpend(String(rule.min_spend_etb));
+                setDailyCap(String(rule.daily_cap_lcn));
+            })
+            .catch((err) => {
+                // No rule yet: start from an empty form. Cashiers cannot manage rules.
+                if (err instanceof ApiError && err.status === 403) {
+                    setForbidden(true);
+                } else if (!(err instanceof ApiError && err.status === 404)) {
+                    setError(err.message || 'Failed to load earn rule');
+                }
+            });
+    }, []);
+
+    const handleSave = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setSaving(true);
+        setError(null);
+        setSaved(false);
+        try {
+            const response = await saveEarnRule({
+                enabled,
+                percent_of_spend: parseFloat(percent) || 0,
+                fixed_per_visit: parseInt(fixed, 10) || 0,
+                category_multipliers: parseMultipliers(multipliers),
+                min_spend_etb: parseFloat(minSpend) || 0,
+                daily_cap_lcn: parseInt(dailyCap, 10) || 0,
+            });
+            setVersion(response.data.version ?? null);
+            setSaved(true);
+        } catch (err: any) {
+            setError(err.message || 'Failed to save earn rule');
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (forbidden) {
+        return null;
+    }
+
+    return (
+        <Card className="p-6">
+            <div className="flex items-center justify-between mb-4">
+                <div className="flex items-center gap-3">
+                    <Percent className="h-5 w-5 text-amber-600" />
+                    <h2 className="text-lg font-bold text-gray-900">Earn Rule</h2>
+                </div>
+                {version !== null && <span className="text-sm text-gray-500">Version {version}</span>}
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+            {saved && (
+                <div className="mb-4 p-3 rounded-lg bg-green-50 flex items-center text-sm text-green-700">
+                    <CheckCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    Saved as version {version}
+                </div>
+            )}
+
+            <form onSubmit={handleSave} className="space-y-4">
+                <label className="flex items-center gap-2 text-sm text-gray-700">
+                    <input type="checkbox" checked={enabled} onChange={(e) => setEnabled(e.target.checked)} />
+                    Reward purchases automatically
+                </label>
+                <div className="grid grid-cols-2 gap-4">
+                    <Input label="Percent of spend (%)" type="number" min="0" max="100" step="0.1" value={percent} onChange={(e) => setPercent(e.target.value)} />
+                    <Input label="Fixed per visit (LCN)" type="number" min="0" step="1" value={fixed} onChange={(e) => setFixed(e.target.value)} />
+                    <Input label="Minimum spend (ETB)" type="number" min="0" step="0.01" value={minSpend} onChange={(e) => setMinSpend(e.target.value)} />
+                    <Input label="Daily cap per customer (LCN)" type="number" min="0" step="1" placeholder="0 = no cap" value={dailyCap} onChange={(e) => setDailyCap(e.target.value)} />
+                </div>
+                <Input
+                    label="Category multipliers"
+                    placeholder="e.g., coffee=2, bakery=1.5"
+                    value={multipliers}
+                    onChange={(e) => setMultipliers(e.target.value)}
+                />
+                <p className="text-xs text-gray-500">
+                    Saving creates a new version; every reward records the version that computed it.
+                </p>
+                <Button type="submit" size="sm" isLoading={saving}>Save Earn Rule</Button>
+            </form>
+        </Card>
+    );
+};
@@ -0,0 +1,124 @@
+import React, { useEffect, useState } from 'react';
+import { Card, Button, Input } from './UIComponents';
+import { Clock, AlertCircle, CheckCircle } from 'lucide-react';
+import { getExpiryPolicy, saveExpiryPolicy, resetExpiryPolicy, ExpiryPolicy, ApiError } from '../services/api';
+
+const describeMonths = (months: number): string => (months > 0 ? `${months} months` : 'never');
+
+export const ExpiryPolicyCard: React.FC = () => {
+    const [policy, setPolicy] = useState<ExpiryPolicy | null>(null);
+    const [months, setMonths] = useState('');
+    const [saving, setSaving] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [saved, setSaved] = useState(false);
+    const [forbidden, setForbidden] = useState(false);
+
+    const load = () =>
+        getExpiryPolicy()
+            .then((response) => {
+                setPolicy(response.data);
+                setMonths(String(response.data.expiry_months));
+            })
+            .catch((err) => {
+                // Cashiers cannot manage the policy
+                if (err instanceof ApiError && err.status === 403) {
+                    setForbidden(true);
+                } else {
+                    setError(err.message || 'Failed to load expiry policy');
+                }
+            });
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    const handleSave = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setSaving(true);
+        setError(null);
+        setSaved(false);
+        try {
+            await saveExpiryPolicy(parseInt(months, 10) || 0);
+            await load();
+            setSaved(true);
+        } catch (err: any) {
+            setError(err.message || 'Failed to save expiry policy');
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleReset = async () => {
+        setSaving(true);
+        setError(null);
+        setSaved(false);
+        try {
+            await resetExpiryPolicy();
+            await load();
+            setSaved(true);
+        } catch (err: any) {
+            setError(err.message || 'Failed to reset expiry policy');
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (forbidden) {
+        return null;
+    }
+
+    return (
+        <Card className="p-6">
+            <div className="flex items-center justify-between mb-4">
+                <div className="flex items-center gap-3">
+                    <Clock className="h-5 w-5 text-amber-600" />
+                    <h2 className="text-lg font-bold text-gray-900">Points Expiry</h2>
+                </div>
+                {policy && (
+                    <span className="text-sm text-gray-500">
+                        {policy.custom ? 'Custom' : 'Platform default'}: {describeMonths(policy.expiry_months)}
+                    </span>
+                )}
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+            {saved && (
+                <div className="mb-4 p-3 rounded-lg bg-green-50 flex items-center text-sm text-green-700">
+                    <CheckCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    Expiry policy saved
+                </div>
+            )}
+
+            <form onSubmit={handleSave} className="space-y-4">
+                <Input
+                    label="Months until issued LCN expires"
+                    type="number"
+                    min="0"
+                    max="120"
+                    step="1"
+                    placeholder="0 = never expires"
+                    value={months}
+                    onChange={(e) => setMonths(e.target.value)}
+                />
+                <p className="text-xs text-gray-500">
+                    Applies to LCN issued from now on. Customers are warned before their points expire; what they have
+                    not spent by then is returned.
+                    {policy?.platform_expiry_months !== undefined && ` Platform default: ${describeMonths(policy.platform_expiry_months)}.`}
+                </p>
+                <div className="flex gap-2">
+                    <Button type="submit" size="sm" isLoading={saving}>Save Expiry Policy</Button>
+                    {policy?.custom && (
+                        <Button type="button" size="sm" variant="secondary" onClick={handleReset} disabled={saving}>
+                            Use Platform Default
+                        </Button>
+                    )}
+                </div>
+            </form>
+        </Card>
+    );
+};
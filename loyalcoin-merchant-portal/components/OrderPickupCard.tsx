@@ -0,0 +1,62 @@
+import React, { useState } from 'react';
+import { Card, Button, Input } from './UIComponents';
+import { ShoppingBag, AlertCircle, CheckCircle } from 'lucide-react';
+import { validateOrderCode, RedemptionOrder } from '../services/api';
+
+export const OrderPickupCard: React.FC = () => {
+    const [code, setCode] = useState('');
+    const [validating, setValidating] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [fulfilled, setFulfilled] = useState<RedemptionOrder | null>(null);
+
+    const handleValidate = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setValidating(true);
+        setError(null);
+        setFulfilled(null);
+        try {
+            const response = await validateOrderCode(code.trim());
+            setFulfilled(response.data);
+            setCode('');
+        } catch (err: any) {
+            setError(err.message || 'Failed to validate code');
+        } finally {
+            setValidating(false);
+        }
+    };
+
+    return (
+        <Card className="p-6 mb-6">
+            <div className="flex items-center gap-3 mb-4">
+                <ShoppingBag className="h-5 w-5 text-amber-600" />
+                <h2 className="text-lg font-bold text-gray-900">Reward Pickup</h2>
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+            {fulfilled && (
+                <div className="mb-4 p-3 rounded-lg bg-green-50 flex items-center text-sm text-green-700">
+                    <CheckCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    Hand over: {fulfilled.item_name} ({fulfilled.price_lcn.toLocaleString()} LCN, already paid)
+                </div>
+            )}
+
+            <form onSubmit={handleValidate} className="space-y-4">
+                <Input
+                    label="Code shown by the customer"
+                    placeholder="XXXX-XXXX"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value.toUpperCase())}
+                    required
+                />
+                <Button type="submit" size="sm" isLoading={validating} disabled={!code.trim()}>
+                    Validate Code
+                </Button>
+            </form>
+        </Card>
+    );
+};
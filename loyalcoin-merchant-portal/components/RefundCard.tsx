@@ -0,0 +1,205 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Card, Button, Input, Badge } from './UIComponents';
+import { RotateCcw, AlertCircle, CheckCircle } from 'lucide-react';
+import {
+    getRefundable, requestRefund, getRefunds, cancelRefund,
+    Refund, RefundQuote, ApiError, newIdempotencyKey,
+} from '../services/api';
+
+const STATUS_LABELS: Record<Refund['status'], string> = {
+    PENDING_CONSENT: 'Awaiting customer',
+    PROCESSING: 'Processing',
+    COMPLETED: 'Refunded',
+    DECLINED: 'Declined',
+    CANCELLED: 'Withdrawn',
+    FAILED: 'Failed',
+};
+
+const statusVariant = (status: Refund['status']) =>
+    status === 'COMPLETED' ? 'success' : status === 'PENDING_CONSENT' || status === 'PROCESSING' ? 'warning' : 'danger';
+
+export const RefundCard: React.FC = () => {
+    const [refunds, setRefunds] = useState<Refund[]>([]);
+    const [txHash, setTxHash] = useState('');
+    const [quote, setQuote] = useState<RefundQuote | null>(null);
+    const [amount, setAmount] = useState('');
+    const [reason, setReason] = useState('');
+    const [busy, setBusy] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [notice, setNotice] = useState<string | null>(null);
+    const [forbidden, setForbidden] = useState(false);
+    // Reused when a refund is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
+
+    const load = () =>
+        getRefunds()
+            .then((response) => setRefunds(response.data.refunds))
+            .catch((err) => {
+                // Cashiers cannot refund
+                if (err instanceof ApiError && err.status === 403) {
+                    setForbidden(true);
+                }
+            });
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    const reset = () => {
+        setQuote(null);
+        setTxHash('');
+        setAmount('');
+        setReason('');
+    };
+
+    const handleLookup = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setBusy(true);
+        setError(null);
+        setNotice(null);
+        try {
+            const response = await getRefundable(txHash.trim());
+            setQuote(response.data);
+            setAmount(String(response.data.refundable_lcn));
+        } catch (err: any) {
+            setError(err.message || 'Transaction not found');
+        } finally {
+            setBusy(false);
+        }
+    };
+
+    const handleRefund = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setBusy(true);
+        setError(null);
+        try {
+            const response = await requestRefund(txHash.trim(), parseInt(amount, 10) || 0, reason, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            setNotice(
+                response.data.status === 'PENDING_CONSENT'
+                    ? 'Refund requested. The LCN comes back once the customer approves.'
+                    : `Refunded ${response.data.amount_lcn.toLocaleString()} LCN to the customer.`
+            );
+            reset();
+            await load();
+        } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
+            setError(err.message || 'Refund failed');
+        } finally {
+            setBusy(false);
+        }
+    };
+
+    const handleCancel = async (refund: Refund) => {
+        setError(null);
+        try {
+            await cancelRefund(refund.id);
+            await load();
+        } catch (err: any) {
+            setError(err.message || 'Failed to withdraw refund');
+        }
+    };
+
+    if (forbidden) {
+        return null;
+    }
+
+    return (
+        <Card className="p-6 mb-6">
+            <div className="flex items-center gap-3 mb-4">
+                <RotateCcw className="h-5 w-5 text-amber-600" />
+                <h2 className="text-lg font-bold text-gray-900">Refunds</h2>
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+            {notice && (
+                <div className="mb-4 p-3 rounded-lg bg-green-50 flex items-center text-sm text-green-700">
+                    <CheckCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {notice}
+                </div>
+            )}
+
+            {!quote ? (
+                <form onSubmit={handleLookup} className="space-y-4">
+                    <Input
+                        label="Transaction hash of the issuance or redemption"
+                        value={txHash}
+                        onChange={(e) => setTxHash(e.target.value)}
+                        required
+                    />
+                    <Button type="submit" size="sm" isLoading={busy} disabled={!txHash.trim()}>
+                        Look Up
+                    </Button>
+                </form>
+            ) : (
+                <form onSubmit={handleRefund} className="space-y-4">
+                    <p className="text-sm text-gray-600">
+                        {quote.direction === 'TO_CUSTOMER'
+                            ? 'Refund LCN the customer spent with you. It is sent back at once.'
+                            : 'Reverse LCN you issued to the customer. It comes back once they approve.'}
+                        {' '}Up to {quote.refundable_lcn.toLocaleString()} LCN can be refunded.
+                    </p>
+                    <div className="grid grid-cols-2 gap-3">
+                        <Input
+                            label="Amount (LCN)"
+                            type="number"
+                            min="1"
+                            max={String(quote.refundable_lcn)}
+                            value={amount}
+                            onChange={(e) => setAmount(e.target.value)}
+                            required
+                        />
+                        <Input
+                            label="Reason"
+                            placeholder="Returned purchase"
+                            value={reason}
+                            onChange={(e) => setReason(e.target.value)}
+                            required
+                        />
+                    </div>
+                    <div className="flex gap-2">
+                        <Button type="submit" size="sm" isLoading={busy} disabled={quote.refundable_lcn === 0}>
+                            {quote.direction === 'TO_CUSTOMER' ? 'Refund' : 'Request Reversal'}
+                        </Button>
+                        <Button type="button" size="sm" variant="secondary" onClick={reset} disabled={busy}>
+                            Cancel
+                        </Button>
+                    </div>
+                </form>
+            )}
+
+            {refunds.length > 0 && (
+                <div className="mt-6 space-y-3">
+                    {refunds.map((refund) => (
+                        <div key={refund.id} className="flex items-center justify-between p-3 rounded-lg border border-gray-100">
+                            <div>
+                                <p className="font-medium text-gray-900">
+                                    {refund.direction === 'TO_CUSTOMER' ? 'To customer' : 'From customer'}: {refund.amount_lcn.toLocaleString()} LCN
+                                </p>
+                                <p className="text-sm text-gray-500">
+                                    {refund.reason} • {new Date(refund.created_at).toLocaleDateString()}
+                                </p>
+                                {refund.error && <p className="text-xs text-red-600">{refund.error}</p>}
+                            </div>
+                            <div className="flex items-center gap-2">
+                                <Badge variant={statusVariant(refund.status)}>{STATUS_LABELS[refund.status]}</Badge>
+                                {refund.status === 'PENDING_CONSENT' && (
+                                    <Button size="sm" variant="ghost" onClick={() => handleCancel(refund)}>
+                                        Withdraw
+                                    </Button>
+                                )}
+                            </div>
+                        </div>
+                    ))}
+                </div>
+            )}
+        </Card>
+    );
+};
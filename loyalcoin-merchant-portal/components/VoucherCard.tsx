@@ -0,0 +1,156 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Card, Button, Input, Badge } from './UIComponents';
+import { Ticket, AlertCircle, Plus } from 'lucide-react';
+import { getVouchers, issueVoucher, newIdempotencyKey, Voucher, VoucherRequest, ApiError } from '../services/api';
+
+const emptyVoucher: VoucherRequest = { customer: '', name: '', value: '', description: '', validity_days: 30 };
+
+const STATUS_BADGES: Record<Voucher['status'], { label: string; variant: 'success' | 'warning' | 'danger' | 'info' }> = {
+    MINTING: { label: 'Minting', variant: 'info' },
+    ACTIVE: { label: 'Active', variant: 'success' },
+    REDEEMING: { label: 'Redeeming', variant: 'info' },
+    REDEEMED: { label: 'Redeemed', variant: 'info' },
+    FAILED: { label: 'Failed', variant: 'danger' },
+    EXPIRED: { label: 'Expired', variant: 'warning' },
+};
+
+export const VoucherCard: React.FC = () => {
+    const [vouchers, setVouchers] = useState<Voucher[]>([]);
+    const [editing, setEditing] = useState<VoucherRequest | null>(null);
+    const [issuing, setIssuing] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [forbidden, setForbidden] = useState(false);
+    // Reused when issuing is retried after a network error, so the voucher is minted once
+    const idempotencyKey = useRef(newIdempotencyKey());
+
+    const load = () =>
+        getVouchers()
+            .then((response) => setVouchers(response.data.vouchers))
+            .catch((err) => {
+                // Cashiers redeem vouchers but cannot issue them
+                if (err instanceof ApiError && err.status === 403) {
+                    setForbidden(true);
+                } else {
+                    setError(err.message || 'Failed to load vouchers');
+                }
+            });
+
+    useEffect(() => {
+        load();
+    }, []);
+
+    const handleIssue = async (e: React.FormEvent) => {
+        e.preventDefault();
+        if (!editing) return;
+        setIssuing(true);
+        setError(null);
+        try {
+            await issueVoucher(editing, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            setEditing(null);
+            await load();
+        } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
+            setError(err.message || 'Failed to issue voucher');
+        } finally {
+            setIssuing(false);
+        }
+    };
+
+    const update = (fields: Partial<VoucherRequest>) => setEditing((voucher) => (voucher ? { ...voucher, ...fields } : voucher));
+
+    if (forbidden) {
+        return null;
+    }
+
+    return (
+        <Card className="p-6">
+            <div className="flex items-center justify-between mb-4">
+                <div className="flex items-center gap-3">
+                    <Ticket className="h-5 w-5 text-amber-600" />
+                    <h2 className="text-lg font-bold text-gray-900">Vouchers</h2>
+                </div>
+                {!editing && (
+                    <Button size="sm" variant="secondary" onClick={() => setEditing({ ...emptyVoucher })}>
+                        <Plus className="h-4 w-4 mr-1" />
+                        Issue Voucher
+                    </Button>
+                )}
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+
+            {editing ? (
+                <form onSubmit={handleIssue} className="space-y-4">
+                    <Input
+                        label="Customer"
+                        placeholder="Email, phone, username or QR handle"
+                        value={editing.customer}
+                        onChange={(e) => update({ customer: e.target.value })}
+                        required
+                    />
+                    <div className="grid grid-cols-2 gap-3">
+                        <Input label="Name" placeholder="Free coffee" value={editing.name} onChange={(e) => update({ name: e.target.value })} required />
+                        <Input label="Value" placeholder="1 coffee" value={editing.value} onChange={(e) => update({ value: e.target.value })} required />
+                    </div>
+                    <Input
+                        label="Description"
+                        value={editing.description || ''}
+                        onChange={(e) => update({ description: e.target.value })}
+                    />
+                    <div className="grid grid-cols-2 gap-3">
+                        <Input
+                            label="Image URL"
+                            placeholder="https:// or ipfs://"
+                            value={editing.image_url || ''}
+                            onChange={(e) => update({ image_url: e.target.value })}
+                        />
+                        <Input
+                            label="Valid for (days)"
+                            type="number"
+                            min="0"
+                            max="365"
+                            value={editing.validity_days}
+                            onChange={(e) => update({ validity_days: parseInt(e.target.value, 10) || 0 })}
+                        />
+                    </div>
+                    <p className="text-xs text-gray-500">
+                        The voucher is minted as an NFT to the customer's wallet, with about 1.4 ADA from your wallet that
+                        comes back when it is redeemed (0 days = no expiry).
+                    </p>
+                    <div className="flex gap-2">
+                        <Button type="submit" size="sm" isLoading={issuing}>Mint Voucher</Button>
+                        <Button type="button" size="sm" variant="secondary" onClick={() => setEditing(null)} disabled={issuing}>
+                            Cancel
+                        </Button>
+                    </div>
+                </form>
+            ) : vouchers.length === 0 ? (
+                <p className="text-sm text-gray-500">No vouchers yet. Issue single-use vouchers customers see in any Cardano wallet.</p>
+            ) : (
+                <div className="space-y-3">
+                    {vouchers.map((voucher) => (
+                        <div key={voucher.id} className="flex items-center justify-between p-3 rounded-lg border border-gray-100">
+                            <div>
+                                <p className="font-medium text-gray-900">{voucher.name}</p>
+                                <p className="text-sm text-gray-500">
+                                    {voucher.value} • {new Date(voucher.created_at).toLocaleDateString()}
+                                    {voucher.expires_at && ` • expires ${new Date(voucher.expires_at).toLocaleDateString()}`}
+                                </p>
+                                {voucher.error && <p className="text-xs text-red-600">{voucher.error}</p>}
+                            </div>
+                            <Badge variant={STATUS_BADGES[voucher.status].variant}>{STATUS_BADGES[voucher.status].label}</Badge>
+                        </div>
+                    ))}
+                </div>
+            )}
+        </Card>
+    );
+};
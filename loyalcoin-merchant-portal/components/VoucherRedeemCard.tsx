@@ -0,0 +1,62 @@
+import React, { useState } from 'react';
+import { Card, Button, Input } from './UIComponents';
+import { Ticket, AlertCircle, CheckCircle } from 'lucide-react';
+import { redeemVoucher, Voucher } from '../services/api';
+
+export const VoucherRedeemCard: React.FC = () => {
+    const [code, setCode] = useState('');
+    const [redeeming, setRedeeming] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [redeemed, setRedeemed] = useState<Voucher | null>(null);
+
+    const handleRedeem = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setRedeeming(true);
+        setError(null);
+        setRedeemed(null);
+        try {
+            const response = await redeemVoucher(code.trim());
+            setRedeemed(response.data);
+            setCode('');
+        } catch (err: any) {
+            setError(err.message || 'Failed to redeem voucher');
+        } finally {
+            setRedeeming(false);
+        }
+    };
+
+    return (
+        <Card className="p-6 mb-6">
+            <div className="flex items-center gap-3 mb-4">
+                <Ticket className="h-5 w-5 text-amber-600" />
+                <h2 className="text-lg font-bold text-gray-900">Redeem Voucher</h2>
+            </div>
+
+            {error && (
+                <div className="mb-4 p-3 rounded-lg bg-red-50 flex items-center text-sm text-red-700">
+                    <AlertCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    {error}
+                </div>
+            )}
+            {redeemed && (
+                <div className="mb-4 p-3 rounded-lg bg-green-50 flex items-center text-sm text-green-700">
+                    <CheckCircle className="h-4 w-4 mr-2 flex-shrink-0" />
+                    Hand over: {redeemed.name} ({redeemed.value})
+                </div>
+            )}
+
+            <form onSubmit={handleRedeem} className="space-y-4">
+                <Input
+                    label="Voucher code shown by the customer"
+                    placeholder="XXXX-XXXX"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value.toUpperCase())}
+                    required
+                />
+                <Button type="submit" size="sm" isLoading={redeeming} disabled={!code.trim()}>
+                    Redeem Voucher
+                </Button>
+            </form>
+        </Card>
+    );
+};
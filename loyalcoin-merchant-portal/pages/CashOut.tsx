@@ -1,9 +1,10 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useStore } from '../store';
 import { Card, Button, Input, Badge } from '../components/UIComponents';
 import { ArrowLeft, CreditCard, CheckCircle, AlertCircle, Building } from 'lucide-react';
-import { requestSettlement, getSettlementHistory, Settlement } from '../services/api';
+import { requestSettlement, getSettlementHistory, Settlement, ApiError, newIdempotencyKey } from '../services/api';
+import { CoalitionCard } from '../components/CoalitionCard';
 
 // Exchange rate for display: 10 LCN = 1 ETB
 const LCN_TO_ETB_RATE = 10;
@@ -20,6 +21,8 @@ export const CashOut: React.FC = () => {
     const [error, setError] = useState<string | null>(null);
     const [settlements, setSettlements] = useState<Settlement[]>([]);
     const [activeTab, setActiveTab] = useState<'request' | 'history'>('request');
+    // Reused when the request is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
 
     // Convert LCN to ETB for display (10 LCN = 1 ETB)
     const lcnAmount = parseFloat(amountLCN) || 0;
@@ -60,11 +63,15 @@ export const CashOut: React.FC = () => {
                 bank_name: bankName,
                 account_number: accountNumber,
                 account_holder: accountHolder
-            });
+            }, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
             setSuccess(true);
             await Promise.all([fetchBalance(), fetchTransactions()]);
             loadHistory();
         } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
             setError(err.message || 'Failed to request settlement');
         } finally {
             setLoading(false);
@@ -261,7 +268,7 @@ export const CashOut: React.FC = () => {
                                         <div>
                                             <p className="font-medium text-gray-900">{settlement.amount_lcn.toLocaleString()} LCN → {settlement.amount_etb.toLocaleString()} ETB</p>
                                             <p className="text-sm text-gray-500">
-                                                {new Date(settlement.requested_at).toLocaleDateString()} • {settlement.bank_account.bank_name}
+                                                {new Date(settlement.requested_at).toLocaleDateString()} • {settlement.kind === 'CLEARING' ? 'Coalition clearing' : settlement.bank_account.bank_name}
                                             </p>
                                         </div>
                                     </div>
@@ -277,6 +284,9 @@ export const CashOut: React.FC = () => {
                     )}
                 </Card>
             )}
+
+            {/* Coalition Clearing Section */}
+            <CoalitionCard />
         </div>
     );
 };
@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import { useStore } from '../store';
 import { Card, Button, Input } from '../components/UIComponents';
 import { Coins, ArrowLeft, CheckCircle, AlertCircle, Camera, X } from 'lucide-react';
-import { issueLCN } from '../services/api';
+import { issueLCN, ApiError, newIdempotencyKey } from '../services/api';
 import { Html5Qrcode } from 'html5-qrcode';
 
 export const IssueLCN: React.FC = () => {
@@ -16,8 +16,12 @@ export const IssueLCN: React.FC = () => {
     const [success, setSuccess] = useState(false);
     const [error, setError] = useState<string | null>(null);
     const [txHash, setTxHash] = useState<string | null>(null);
+    const [createPending, setCreatePending] = useState(false);
+    const [pendingRewardId, setPendingRewardId] = useState<string | null>(null);
     const [showScanner, setShowScanner] = useState(false);
     const scannerRef = useRef<Html5Qrcode | null>(null);
+    // Reused when the request is retried after a network error
+    const idempotencyKey = useRef(newIdempotencyKey());
 
     // Cleanup scanner on unmount
     useEffect(() => {
@@ -40,12 +44,12 @@ export const IssueLCN: React.FC = () => {
                 { facingMode: 'environment' },
                 { fps: 10, qrbox: { width: 250, height: 250 } },
                 (decodedText) => {
-                    // Check if it's a valid Cardano address
-                    if (decodedText.startsWith('addr_test1') || decodedText.startsWith('addr1')) {
+                    // Customer QR codes carry a LoyalCoin handle or a Cardano address
+                    if (decodedText.startsWith('lc-') || decodedText.startsWith('addr_test1') || decodedText.startsWith('addr1')) {
                         setAddress(decodedText);
                         stopScanner();
                     } else {
-                        setError('Invalid address format. Please scan a valid Cardano wallet QR code.');
+                        setError('Invalid QR code. Please scan the customer\'s LoyalCoin QR code.');
                     }
                 },
                 () => { } // Ignore errors during scanning
@@ -87,11 +91,16 @@ export const IssueLCN: React.FC = () => {
         }
 
         try {
-            const response = await issueLCN(address, amountNum, note);
-            setTxHash(response.data.tx_hash);
+            const response = await issueLCN(address.trim(), amountNum, note, createPending, idempotencyKey.current);
+            idempotencyKey.current = newIdempotencyKey();
+            setTxHash(response.data.tx_hash || null);
+            setPendingRewardId(response.data.pending_reward_id || null);
             setSuccess(true);
             await Promise.all([fetchBalance(), fetchTransactions()]);
         } catch (err: any) {
+            if (err instanceof ApiError) {
+                idempotencyKey.current = newIdempotencyKey();
+            }
             setError(err.message || 'Failed to issue LCN');
         } finally {
             setLoading(false);
@@ -104,9 +113,13 @@ export const IssueLCN: React.FC = () => {
                 <div className="mx-auto h-16 w-16 rounded-full bg-green-100 flex items-center justify-center mb-6">
                     <CheckCircle className="h-8 w-8 text-green-600" />
                 </div>
-                <h2 className="text-2xl font-bold mb-2 text-gray-900">LCN Issued Successfully!</h2>
+                <h2 className="text-2xl font-bold mb-2 text-gray-900">
+                    {pendingRewardId ? 'Reward Held for Customer' : 'LCN Issued Successfully!'}
+                </h2>
                 <p className="text-gray-600 mb-6">
-                    You have sent {amount} LCN to the customer.
+                    {pendingRewardId
+                        ? `${amount} LCN will be sent from your wallet when ${address} signs up and verifies it.`
+                        : `You have sent ${amount} LCN to the customer.`}
                 </p>
                 {txHash && (
                     <div className="p-4 rounded-lg mb-6 bg-gray-50 break-all">
@@ -121,6 +134,8 @@ export const IssueLCN: React.FC = () => {
                         setAddress('');
                         setNote('');
                         setTxHash(null);
+                        setPendingRewardId(null);
+                        setCreatePending(false);
                     }} className="w-full">
                         Issue More
                     </Button>
@@ -184,13 +199,13 @@ export const IssueLCN: React.FC = () => {
                 <form onSubmit={handleIssue} className="space-y-6">
                     <div>
                         <label className="block text-sm font-medium text-gray-700 mb-1.5">
-                            Customer Wallet Address
+                            Customer
                         </label>
                         <div className="flex gap-2">
                             <input
                                 type="text"
                                 className="flex-1 px-4 py-2.5 border border-gray-300 rounded-lg text-sm focus:ring-2 focus:ring-amber-500 focus:border-amber-500"
-                                placeholder="addr_test1..."
+                                placeholder="Email, phone, username or addr_test1..."
                                 value={address}
                                 onChange={(e) => setAddress(e.target.value)}
                                 required
@@ -206,8 +221,16 @@ export const IssueLCN: React.FC = () => {
                             </button>
                         </div>
                         <p className="text-xs text-gray-500 mt-1">
-                            Enter address or scan customer's QR code
+                            Enter the customer's email, phone, username or wallet address, or scan their QR code
                         </p>
+                        <label className="flex items-center gap-2 mt-3 text-sm text-gray-700">
+                            <input
+                                type="checkbox"
+                                checked={createPending}
+                                onChange={(e) => setCreatePending(e.target.checked)}
+                            />
+                            Hold the reward if this email or phone has not signed up yet
+                        </label>
                     </div>
 
                     <Input
@@ -1,14 +1,73 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useStore } from '../store';
-import { Card, Button } from '../components/UIComponents';
-import { ArrowLeft, Copy, Check, QrCode } from 'lucide-react';
+import { Card, Button, Input } from '../components/UIComponents';
+import { ArrowLeft, Copy, Check, QrCode, CheckCircle } from 'lucide-react';
 import { QRCodeSVG } from 'qrcode.react';
+import { createPaymentRequest, getPaymentRequest, cancelPaymentRequest, PaymentRequest } from '../services/api';
+import { OrderPickupCard } from '../components/OrderPickupCard';
+import { VoucherRedeemCard } from '../components/VoucherRedeemCard';
 
 export const Receive: React.FC = () => {
     const navigate = useNavigate();
     const { user, wallet, fetchBalance } = useStore();
     const [copied, setCopied] = useState(false);
+    const [requestAmount, setRequestAmount] = useState('');
+    const [requestDescription, setRequestDescription] = useState('');
+    const [paymentRequest, setPaymentRequest] = useState<PaymentRequest | null>(null);
+    const [qrPayload, setQrPayload] = useState('');
+    const [requestError, setRequestError] = useState<string | null>(null);
+    const [creating, setCreating] = useState(false);
+
+    // Poll the open payment request until the indexer confirms it
+    useEffect(() => {
+        if (!paymentRequest || ['PAID', 'CANCELLED', 'EXPIRED'].includes(paymentRequest.status)) {
+            return;
+        }
+        const timer = setInterval(async () => {
+            try {
+                const response = await getPaymentRequest(paymentRequest.id);
+                setPaymentRequest(response.data);
+                if (response.data.status === 'PAID') {
+                    fetchBalance();
+                }
+            } catch (err) {
+                console.error('Failed to refresh payment request:', err);
+            }
+        }, 5000);
+        return () => clearInterval(timer);
+    }, [paymentRequest?.id, paymentRequest?.status]);
+
+    const handleCreateRequest = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setRequestError(null);
+        const amountNum = parseInt(requestAmount, 10);
+        if (isNaN(amountNum) || amountNum <= 0) {
+            setRequestError('Please enter a whole LCN amount');
+            return;
+        }
+        setCreating(true);
+        try {
+            const response = await createPaymentRequest(amountNum, requestDescription || undefined);
+            setPaymentRequest(response.data.payment_request);
+            setQrPayload(response.data.qr_payload);
+        } catch (err: any) {
+            setRequestError(err.message || 'Failed to create payment request');
+        } finally {
+            setCreating(false);
+        }
+    };
+
+    const handleCancelRequest = async () => {
+        if (!paymentRequest) return;
+        try {
+            await cancelPaymentRequest(paymentRequest.id);
+            setPaymentRequest(null);
+            setQrPayload('');
+        } catch (err: any) {
+            setRequestError(err.message || 'Failed to cancel payment request');
+        }
+    };
 
     const walletAddress = user?.walletAddress || '';
 
@@ -35,6 +94,79 @@ export const Receive: React.FC = () => {
                 <h1 className="text-2xl font-bold text-gray-900">Receive Coins</h1>
             </div>
 
+            {/* Catalog order pickup */}
+            <OrderPickupCard />
+
+            {/* Voucher redemption */}
+            <VoucherRedeemCard />
+
+            {/* Payment request */}
+            <Card className="p-6 mb-6">
+                <h2 className="text-lg font-semibold text-gray-900 mb-4">Request a Payment</h2>
+                {requestError && (
+                    <p className="mb-4 text-sm text-red-600">{requestError}</p>
+                )}
+                {paymentRequest ? (
+                    <div className="flex flex-col items-center">
+                        {paymentRequest.status === 'PAID' ? (
+                            <div className="text-center">
+                                <CheckCircle className="h-12 w-12 text-green-600 mx-auto mb-2" />
+                                <p className="font-semibold text-gray-900">Paid {paymentRequest.amount_lcn.toLocaleString()} LCN</p>
+                            </div>
+                        ) : (
+                            <>
+                                <div className="p-4 bg-white rounded-xl shadow-lg border">
+                                    <QRCodeSVG value={qrPayload} size={220} level="H" includeMargin={true} bgColor="#ffffff" fgColor="#1f2937" />
+                                </div>
+                                <p className="mt-4 text-2xl font-bold text-gray-900">{paymentRequest.amount_lcn.toLocaleString()} LCN</p>
+                                {paymentRequest.description && (
+                                    <p className="text-sm text-gray-600">{paymentRequest.description}</p>
+                                )}
+                                <p className="mt-2 text-sm text-gray-500">
+                                    {paymentRequest.status === 'SUBMITTED'
+                                        ? 'Payment submitted, waiting for confirmation...'
+                                        : paymentRequest.status === 'EXPIRED'
+                                            ? 'This request expired'
+                                            : `Waiting for the customer to pay (expires ${new Date(paymentRequest.expires_at).toLocaleTimeString()})`}
+                                </p>
+                            </>
+                        )}
+                        <div className="mt-4 flex gap-2">
+                            {['OPEN', 'EXPIRED'].includes(paymentRequest.status) && (
+                                <Button variant="outline" size="sm" onClick={handleCancelRequest}>Cancel</Button>
+                            )}
+                            {['PAID', 'CANCELLED', 'EXPIRED'].includes(paymentRequest.status) && (
+                                <Button size="sm" onClick={() => { setPaymentRequest(null); setQrPayload(''); setRequestAmount(''); setRequestDescription(''); }}>
+                                    New Request
+                                </Button>
+                            )}
+                        </div>
+                    </div>
+                ) : (
+                    <form onSubmit={handleCreateRequest} className="space-y-4">
+                        <Input
+                            label="Amount (LCN)"
+                            type="number"
+                            placeholder="0"
+                            value={requestAmount}
+                            onChange={(e) => setRequestAmount(e.target.value)}
+                            required
+                            min="1"
+                            step="1"
+                        />
+                        <Input
+                            label="Description (Optional)"
+                            placeholder="e.g., 2 coffees"
+                            value={requestDescription}
+                            onChange={(e) => setRequestDescription(e.target.value)}
+                        />
+                        <Button type="submit" className="w-full" isLoading={creating} disabled={!requestAmount}>
+                            Show Payment QR Code
+                        </Button>
+                    </form>
+                )}
+            </Card>
+
             <Card className="p-6">
                 {/* Current Balance */}
                 <div className="mb-6 p-4 rounded-lg bg-green-50 text-center">
@@ -3,6 +3,10 @@ import { useNavigate } from 'react-router-dom';
 import { useStore } from '../store';
 import { Card, Button, Input, Badge } from '../components/UIComponents';
 import { ArrowLeft, User, Building, Wallet, Plus, Trash2, CheckCircle } from 'lucide-react';
+import { EarnRuleCard } from '../components/EarnRuleCard';
+import { ExpiryPolicyCard } from '../components/ExpiryPolicyCard';
+import { CatalogCard } from '../components/CatalogCard';
+import { VoucherCard } from '../components/VoucherCard';
 
 export const Settings: React.FC = () => {
     const navigate = useNavigate();
@@ -146,6 +150,18 @@ export const Settings: React.FC = () => {
                     )}
                 </Card>
 
+                {/* Earn Rule Section */}
+                <EarnRuleCard />
+
+                {/* Points Expiry Section */}
+                <ExpiryPolicyCard />
+
+                {/* Rewards Catalog Section */}
+                <CatalogCard />
+
+                {/* Vouchers Section */}
+                <VoucherCard />
+
                 {/* Sign Out */}
                 <Card className="p-6">
                     <Button
@@ -4,6 +4,7 @@ import { useStore } from '../store';
 import { Card, Button, Badge } from '../components/UIComponents';
 import { ArrowLeft, TrendingUp, TrendingDown, CreditCard, Coins, RefreshCw } from 'lucide-react';
 import { TransactionType } from '../types';
+import { RefundCard } from '../components/RefundCard';
 
 export const Transactions: React.FC = () => {
     const navigate = useNavigate();
@@ -79,6 +80,8 @@ export const Transactions: React.FC = () => {
                 </Button>
             </div>
 
+            <RefundCard />
+
             <Card className="p-6">
                 {wallet.transactions.length === 0 ? (
                     <div className="text-center py-12">
@@ -110,6 +110,8 @@ export interface Settlement {
         bank_name: string;
         account_holder: string;
     };
+    kind?: 'CLEARING'; // coalition clearing payment to another member
+    payee_merchant_id?: string;
     requested_at: string;
     processed_at?: string;
 }
@@ -183,16 +185,28 @@ export async function getTransactions(limit = 20, offset = 0): Promise<Transacti
     );
 }
 
+// Value-moving requests send an Idempotency-Key so that a retry after a network
+// error replays the first response instead of moving LCN twice
+export const newIdempotencyKey = (): string => crypto.randomUUID();
+
+const idempotencyHeaders = (key?: string): Record<string, string> =>
+    key ? { 'Idempotency-Key': key } : {};
+
 // LCN Operations
+// `customer` is a wallet address or the customer's email, phone, username or QR handle
 export async function issueLCN(
-    toAddress: string,
+    customer: string,
     amount: number,
-    note?: string
-): Promise<{ status: string; data: { tx_hash: string; message: string } }> {
+    note?: string,
+    createPending?: boolean,
+    idempotencyKey?: string
+): Promise<{ status: string; data: { tx_hash?: string; pending_reward_id?: string; message?: string } }> {
+    const isAddress = customer.startsWith('addr_test1') || customer.startsWith('addr1');
     return apiRequest('/api/v1/lcn/issue', {
         method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
         body: JSON.stringify({
-            customer_address: toAddress,
+            ...(isAddress ? { customer_address: customer } : { customer, create_pending: createPending }),
             amount_lcn: amount,
             reference: note,
         }),
@@ -202,10 +216,12 @@ export async function issueLCN(
 // Settlement
 export async function requestSettlement(
     amountLCN: number,
-    bankAccount: { account_number: string; bank_name: string; account_holder: string }
+    bankAccount: { account_number: string; bank_name: string; account_holder: string },
+    idempotencyKey?: string
 ): Promise<{ status: string; data: { settlement_id: string; message: string } }> {
     return apiRequest('/api/v1/merchant/settlement/request', {
         method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
         body: JSON.stringify({
             amount_lcn: amountLCN,
             bank_account: bankAccount,
@@ -249,3 +265,271 @@ export async function getAllocationHistory(
         `/api/v1/merchant/allocation/history?limit=${limit}&offset=${offset}`
     );
 }
+
+// Payment requests (customer pays an exact amount by scanning a QR code)
+export interface PaymentRequest {
+    id: string;
+    amount_lcn: number;
+    description?: string;
+    status: 'OPEN' | 'PAYING' | 'SUBMITTED' | 'PAID' | 'CANCELLED' | 'EXPIRED';
+    tx_hash?: string;
+    created_at: string;
+    expires_at: string;
+    paid_at?: string;
+}
+
+export async function createPaymentRequest(
+    amountLCN: number,
+    description?: string,
+    expiresInMinutes?: number
+): Promise<{ status: string; data: { payment_request: PaymentRequest; qr_payload: string } }> {
+    return apiRequest('/api/v1/lcn/payment-requests', {
+        method: 'POST',
+        body: JSON.stringify({
+            amount_lcn: amountLCN,
+            description,
+            expires_in_minutes: expiresInMinutes,
+        }),
+    });
+}
+
+export async function getPaymentRequest(id: string): Promise<{ status: string; data: PaymentRequest }> {
+    return apiRequest(`/api/v1/lcn/payment-requests/${id}`);
+}
+
+export async function cancelPaymentRequest(id: string): Promise<{ status: string }> {
+    return apiRequest(`/api/v1/lcn/payment-requests/${id}`, { method: 'DELETE' });
+}
+
+// Earn rules (rewards computed from purchases by POST /lcn/earn)
+export interface EarnRule {
+    id?: string;
+    version?: number;
+    enabled: boolean;
+    percent_of_spend: number;
+    fixed_per_visit: number;
+    category_multipliers?: Record<string, number>;
+    min_spend_etb: number;
+    daily_cap_lcn: number;
+    created_at?: string;
+}
+
+export async function getEarnRule(): Promise<{ status: string; data: EarnRule }> {
+    return apiRequest('/api/v1/merchant/earn-rule');
+}
+
+export async function saveEarnRule(rule: EarnRule): Promise<{ status: string; data: EarnRule }> {
+    return apiRequest('/api/v1/merchant/earn-rule', {
+        method: 'PUT',
+        body: JSON.stringify(rule),
+    });
+}
+
+// Points expiry: months until issued LCN expires (0 = never)
+export interface ExpiryPolicy {
+    expiry_months: number;
+    custom: boolean;
+    platform_expiry_months?: number;
+}
+
+export async function getExpiryPolicy(): Promise<{ status: string; data: ExpiryPolicy }> {
+    return apiRequest('/api/v1/merchant/expiry-policy');
+}
+
+export async function saveExpiryPolicy(expiryMonths: number): Promise<{ status: string }> {
+    return apiRequest('/api/v1/merchant/expiry-policy', {
+        method: 'PUT',
+        body: JSON.stringify({ expiry_months: expiryMonths }),
+    });
+}
+
+export async function resetExpiryPolicy(): Promise<{ status: string; data: ExpiryPolicy }> {
+    return apiRequest('/api/v1/merchant/expiry-policy', { method: 'DELETE' });
+}
+
+// Rewards catalog: items customers order with LCN, picked up with a code
+export interface CatalogItem {
+    id?: string;
+    name: string;
+    description?: string;
+    image_url?: string;
+    price_lcn: number;
+    stock: number;
+    validity_days: number;
+    active: boolean;
+}
+
+export interface RedemptionOrder {
+    id: string;
+    item_name: string;
+    customer_id: string;
+    price_lcn: number;
+    code: string;
+    status: string;
+    expires_at?: string;
+    fulfilled_at?: string;
+    created_at: string;
+}
+
+export async function getCatalog(): Promise<{ status: string; data: { items: CatalogItem[]; total: number } }> {
+    return apiRequest('/api/v1/merchant/catalog?limit=100');
+}
+
+export async function saveCatalogItem(item: CatalogItem): Promise<{ status: string; data: CatalogItem }> {
+    return apiRequest(item.id ? `/api/v1/merchant/catalog/${item.id}` : '/api/v1/merchant/catalog', {
+        method: item.id ? 'PUT' : 'POST',
+        body: JSON.stringify(item),
+    });
+}
+
+// Marks the order picked up; a code is accepted once
+export async function validateOrderCode(code: string): Promise<{ status: string; data: RedemptionOrder }> {
+    return apiRequest('/api/v1/merchant/orders/validate', {
+        method: 'POST',
+        body: JSON.stringify({ code }),
+    });
+}
+
+
+// Refunds: a redemption is refunded to the customer at once; an issuance made
+// in error is reversed once the customer consents or an admin overrides
+export interface Refund {
+    id: string;
+    original_tx_hash: string;
+    direction: 'TO_MERCHANT' | 'TO_CUSTOMER';
+    amount_lcn: number;
+    reason: string;
+    status: 'PENDING_CONSENT' | 'PROCESSING' | 'COMPLETED' | 'DECLINED' | 'CANCELLED' | 'FAILED';
+    tx_hash?: string;
+    error?: string;
+    created_at: string;
+}
+
+export interface RefundQuote {
+    direction: Refund['direction'];
+    customer_id: string;
+    refundable_lcn: number;
+    requires_consent: boolean;
+}
+
+export async function getRefundable(txHash: string): Promise<{ status: string; data: RefundQuote }> {
+    return apiRequest(`/api/v1/merchant/refunds/refundable?tx_hash=${encodeURIComponent(txHash)}`);
+}
+
+export async function requestRefund(
+    txHash: string,
+    amountLCN: number,
+    reason: string,
+    idempotencyKey?: string
+): Promise<{ status: string; data: Refund }> {
+    return apiRequest('/api/v1/merchant/refunds', {
+        method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
+        body: JSON.stringify({ tx_hash: txHash, amount_lcn: amountLCN, reason }),
+    });
+}
+
+export async function getRefunds(): Promise<{ status: string; data: { refunds: Refund[]; total: number } }> {
+    return apiRequest('/api/v1/merchant/refunds?limit=20');
+}
+
+// Withdraws a reversal the customer has not answered yet
+export async function cancelRefund(id: string): Promise<{ status: string; data: Refund }> {
+    return apiRequest(`/api/v1/merchant/refunds/${id}/cancel`, { method: 'POST' });
+}
+
+// Vouchers: single-use NFTs minted under the merchant's own policy and redeemed
+// (burned) with the code the holder shows
+export interface Voucher {
+    id: string;
+    code: string;
+    name: string;
+    description?: string;
+    image_url?: string;
+    value: string;
+    expires_at?: string;
+    holder_address: string;
+    holder_customer_id?: string;
+    status: 'MINTING' | 'ACTIVE' | 'REDEEMING' | 'REDEEMED' | 'FAILED' | 'EXPIRED';
+    mint_tx_hash?: string;
+    burn_tx_hash?: string;
+    error?: string;
+    created_at: string;
+}
+
+export interface VoucherRequest {
+    customer: string; // email, phone, username or QR handle
+    name: string;
+    value: string;
+    description?: string;
+    image_url?: string;
+    validity_days: number;
+}
+
+export async function issueVoucher(voucher: VoucherRequest, idempotencyKey?: string): Promise<{ status: string; data: Voucher }> {
+    return apiRequest('/api/v1/merchant/vouchers', {
+        method: 'POST',
+        headers: idempotencyHeaders(idempotencyKey),
+        body: JSON.stringify(voucher),
+    });
+}
+
+export async function getVouchers(): Promise<{ status: string; data: { vouchers: Voucher[]; total: number } }> {
+    return apiRequest('/api/v1/merchant/vouchers?limit=20');
+}
+
+// Burns the voucher; only vouchers held in custodial wallets can be redeemed
+export async function redeemVoucher(code: string): Promise<{ status: string; data: Voucher }> {
+    return apiRequest('/api/v1/merchant/vouchers/redeem', {
+        method: 'POST',
+        body: JSON.stringify({ code }),
+    });
+}
+
+// Coalitions: LCN issued by one member and redeemed at another clears between
+// them and is netted every period into clearing settlements
+export interface NetPosition {
+    merchant_id: string;
+    business_name?: string;
+    receivable_lcn: number;
+    payable_lcn: number;
+    net_lcn: number; // positive: owed to the merchant
+}
+
+export interface CoalitionSummary {
+    coalition: {
+        id: string;
+        name: string;
+        members: number;
+        rules: { rate_bps: number; netting_period_days: number };
+        next_netting_at: string;
+        last_netted_at?: string;
+    };
+    position: NetPosition;
+    counterparties: NetPosition[];
+}
+
+export interface ClearingPayment {
+    payer_id: string;
+    payee_id: string;
+    amount_lcn: number;
+    settlement_id?: string;
+}
+
+export interface Netting {
+    id: string;
+    cutoff: string;
+    entries: number;
+    gross_lcn: number;
+    positions: NetPosition[];
+    payments: ClearingPayment[];
+    created_at: string;
+}
+
+export async function getCoalition(): Promise<{ status: string; data: CoalitionSummary }> {
+    return apiRequest('/api/v1/merchant/coalition');
+}
+
+export async function getCoalitionNettings(): Promise<{ status: string; data: { nettings: Netting[]; total: number } }> {
+    return apiRequest('/api/v1/merchant/coalition/nettings?limit=5');
+}